@@ -73,6 +73,33 @@ func (m *DatabaseManager) Count() int {
 	return count
 }
 
+// Stats sums the pool statistics of every connection the app holds open.
+func (m *DatabaseManager) Stats() sql.DBStats {
+	var total sql.DBStats
+	if m == nil {
+		return total
+	}
+	m.mu.Lock()
+	connections := make([]*sql.DB, 0, len(m.connections))
+	for _, connection := range m.connections {
+		connections = append(connections, connection)
+	}
+	m.mu.Unlock()
+	for _, connection := range connections {
+		stats := connection.Stats()
+		total.MaxOpenConnections += stats.MaxOpenConnections
+		total.OpenConnections += stats.OpenConnections
+		total.InUse += stats.InUse
+		total.Idle += stats.Idle
+		total.WaitCount += stats.WaitCount
+		total.WaitDuration += stats.WaitDuration
+		total.MaxIdleClosed += stats.MaxIdleClosed
+		total.MaxIdleTimeClosed += stats.MaxIdleTimeClosed
+		total.MaxLifetimeClosed += stats.MaxLifetimeClosed
+	}
+	return total
+}
+
 func (m *DatabaseManager) Close() {
 	if m == nil {
 		return
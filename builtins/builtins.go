@@ -22,6 +22,12 @@ var builtins = map[string]func() value.Value{
 	"parseFloat": ParseFloat,
 	"fail":       Error,
 	"Error":      Error,
+	"structuredClone": func() value.Value {
+		return value.NewFunc(StructuredClone)
+	},
+	"deepEqual": func() value.Value {
+		return value.NewFunc(DeepEqual)
+	},
 	"fetch": func() value.Value {
 		return value.NewFunc(http.Fetch)
 	},
@@ -0,0 +1,23 @@
+package builtins
+
+import "github.com/kitwork/engine/value"
+
+// StructuredClone returns a deep, mutable copy of a value — the way to edit
+// something that came frozen out of a cache.
+func StructuredClone(args ...value.Value) value.Value {
+	if len(args) == 0 {
+		return value.Value{K: value.Nil}
+	}
+	cloned, err := value.Clone(args[0])
+	if err != nil {
+		return value.Value{K: value.Invalid, V: "structuredClone: " + err.Error()}
+	}
+	return cloned
+}
+
+// DeepEqual compares two values structurally (see value.DeepEqual).
+func DeepEqual(args ...value.Value) value.Value {
+	operands := [2]value.Value{{K: value.Nil}, {K: value.Nil}} // a missing argument is undefined
+	copy(operands[:], args)
+	return value.NewBool(value.DeepEqual(operands[0], operands[1]))
+}
@@ -52,6 +52,9 @@ func Object() value.Value {
 			if target.K != value.Map {
 				return target
 			}
+			if value.IsFrozen(target) {
+				return value.Value{K: value.Invalid, V: "Object.assign: target object is frozen"}
+			}
 			tm := target.Map()
 			for _, src := range args[1:] {
 				if src.K == value.Map {
@@ -62,6 +65,22 @@ func Object() value.Value {
 			}
 			return target
 		}),
+		// freeze is DEEP (unlike JS): a frozen cache entry must not hide a mutable nested object.
+		"freeze": value.NewFunc(func(args ...value.Value) value.Value {
+			if len(args) == 0 {
+				return value.Value{K: value.Nil}
+			}
+			return value.Freeze(args[0])
+		}),
+		"isFrozen": value.NewFunc(func(args ...value.Value) value.Value {
+			if len(args) == 0 {
+				return value.TRUE
+			}
+			if args[0].K != value.Map && args[0].K != value.Array {
+				return value.TRUE // primitives are immutable, as in JS
+			}
+			return value.NewBool(value.IsFrozen(args[0]))
+		}),
 		"fromEntries": value.NewFunc(func(args ...value.Value) value.Value {
 			out := map[string]value.Value{}
 			if len(args) == 0 || args[0].K != value.Array {
@@ -0,0 +1,439 @@
+// Package bundle owns the ahead-of-time deployment archive (.kwpkg).
+//
+// A package is one tenant folder frozen at build time: every executable entry
+// (routers, _cron, _queue) as verified bytecode, every template as a snapshot,
+// and every public file as an asset. Sources never enter the archive — the
+// bytecode already carries the bundled imports — so a server that installs a
+// package runs with no JavaScript source on disk.
+//
+// The archive is content addressed and signed: its digest covers the format
+// version, the compiler fingerprint, and every entry; an ed25519 signature over
+// that digest authenticates the producer. Open refuses any package whose
+// signature, digest, or compiler contract does not match this engine build.
+package bundle
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/kitwork/engine/compiler"
+)
+
+const (
+	// FormatVersion is the .kwpkg layout contract. Increment it whenever the
+	// header or entry encoding changes.
+	FormatVersion uint16 = 1
+
+	// Extension is the file suffix Build writes and LoadDirectory scans for.
+	Extension = ".kwpkg"
+
+	// MaxPackageSize bounds a single archive read into memory.
+	MaxPackageSize = 512 << 20
+
+	maxEntryPath = 4096
+)
+
+var packageMagic = [4]byte{'K', 'W', 'P', 'K'}
+
+// Kind classifies one archive entry.
+type Kind uint8
+
+const (
+	KindBytecode Kind = iota + 1 // compiled router/_cron/_queue entry, keyed by its source path
+	KindTemplate                 // *.kitwork.html snapshot
+	KindAsset                    // any other public file
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindBytecode:
+		return "bytecode"
+	case KindTemplate:
+		return "template"
+	case KindAsset:
+		return "asset"
+	default:
+		return fmt.Sprintf("kind(%d)", uint8(k))
+	}
+}
+
+// Entry is one file in a package. Path is slash-separated and relative to the
+// packaged tenant folder; for bytecode it names the source entry it replaces.
+type Entry struct {
+	Kind Kind
+	Path string
+	Data []byte
+}
+
+// Package is an opened, verified archive.
+type Package struct {
+	Name                string // tenant folder the package installs into, relative to the apps root
+	CompilerFingerprint string
+	Digest              string
+	Entries             []Entry
+}
+
+// AssetRecord is one line of the package's asset manifest.
+type AssetRecord struct {
+	Path   string `json:"path"`
+	Kind   string `json:"kind"`
+	Size   int    `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Manifest lists every template and asset with its content hash, in path
+// order. Bytecode entries are omitted: their identity is the package digest.
+func (p *Package) Manifest() []AssetRecord {
+	if p == nil {
+		return nil
+	}
+	records := make([]AssetRecord, 0, len(p.Entries))
+	for _, entry := range p.Entries {
+		if entry.Kind == KindBytecode {
+			continue
+		}
+		sum := sha256.Sum256(entry.Data)
+		records = append(records, AssetRecord{
+			Path:   entry.Path,
+			Kind:   entry.Kind.String(),
+			Size:   len(entry.Data),
+			SHA256: hex.EncodeToString(sum[:]),
+		})
+	}
+	return records
+}
+
+// Bytecode restores every packaged program keyed by its source path. The
+// signature already authenticated these bytes, so the source fingerprint each
+// artifact records replaces the source comparison a local cache would make.
+func (p *Package) Bytecode() (map[string]*compiler.Bytecode, error) {
+	programs := make(map[string]*compiler.Bytecode)
+	if p == nil {
+		return programs, nil
+	}
+	for _, entry := range p.Entries {
+		if entry.Kind != KindBytecode {
+			continue
+		}
+		bytecode, err := compiler.UnmarshalPackagedBytecode(entry.Data)
+		if err != nil {
+			return nil, fmt.Errorf("package %s: %s: %w", p.Digest, entry.Path, err)
+		}
+		programs[entry.Path] = bytecode
+	}
+	return programs, nil
+}
+
+// Marshal encodes and signs a package. Entries are sorted by path so the same
+// tenant folder always produces the same digest.
+func Marshal(name string, entries []Entry, key ed25519.PrivateKey) ([]byte, string, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, "", fmt.Errorf("encode package: signing key must be %d bytes", ed25519.PrivateKeySize)
+	}
+	name, err := cleanEntryPath(name)
+	if err != nil {
+		return nil, "", fmt.Errorf("encode package: name: %w", err)
+	}
+	sorted := append([]Entry(nil), entries...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Path < sorted[j].Path })
+
+	body := make([]byte, 0, 1024)
+	body = appendString(body, name)
+	body = binary.BigEndian.AppendUint32(body, uint32(len(sorted)))
+	for i, entry := range sorted {
+		cleaned, err := cleanEntryPath(entry.Path)
+		if err != nil {
+			return nil, "", fmt.Errorf("encode package: entry %q: %w", entry.Path, err)
+		}
+		if i > 0 && sorted[i-1].Path == entry.Path {
+			return nil, "", fmt.Errorf("encode package: duplicate entry %q", entry.Path)
+		}
+		if entry.Kind < KindBytecode || entry.Kind > KindAsset {
+			return nil, "", fmt.Errorf("encode package: entry %q has unknown kind %d", entry.Path, entry.Kind)
+		}
+		body = append(body, byte(entry.Kind))
+		body = appendString(body, cleaned)
+		body = binary.BigEndian.AppendUint32(body, uint32(len(entry.Data)))
+		body = append(body, entry.Data...)
+	}
+
+	fingerprint, err := hex.DecodeString(compiler.Fingerprint())
+	if err != nil || len(fingerprint) != sha256.Size {
+		return nil, "", fmt.Errorf("encode package: invalid compiler fingerprint")
+	}
+	digest := packageDigest(FormatVersion, fingerprint, body)
+	signature := ed25519.Sign(key, digest[:])
+
+	data := make([]byte, 0, headerSize+len(body))
+	data = append(data, packageMagic[:]...)
+	data = binary.BigEndian.AppendUint16(data, FormatVersion)
+	data = append(data, fingerprint...)
+	data = append(data, digest[:]...)
+	data = append(data, signature...)
+	data = binary.BigEndian.AppendUint64(data, uint64(len(body)))
+	data = append(data, body...)
+	if len(data) > MaxPackageSize {
+		return nil, "", fmt.Errorf("encode package: %d bytes exceeds %d", len(data), MaxPackageSize)
+	}
+	return data, hex.EncodeToString(digest[:]), nil
+}
+
+const headerSize = 4 + 2 + sha256.Size + sha256.Size + ed25519.SignatureSize + 8
+
+// Unmarshal verifies and decodes a package. The signature is checked against
+// publicKey before any entry is parsed, and the compiler fingerprint must
+// match this engine build exactly.
+func Unmarshal(data []byte, publicKey ed25519.PublicKey) (*Package, error) {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("decode package: verification key must be %d bytes", ed25519.PublicKeySize)
+	}
+	if len(data) > MaxPackageSize {
+		return nil, fmt.Errorf("decode package: %d bytes exceeds %d", len(data), MaxPackageSize)
+	}
+	if len(data) < headerSize {
+		return nil, fmt.Errorf("decode package: truncated header")
+	}
+	if !bytes.Equal(data[:4], packageMagic[:]) {
+		return nil, fmt.Errorf("decode package: invalid magic")
+	}
+	version := binary.BigEndian.Uint16(data[4:6])
+	if version != FormatVersion {
+		return nil, fmt.Errorf("decode package: version %d is incompatible with %d", version, FormatVersion)
+	}
+	offset := 6
+	fingerprint := data[offset : offset+sha256.Size]
+	offset += sha256.Size
+	var recorded [sha256.Size]byte
+	copy(recorded[:], data[offset:offset+sha256.Size])
+	offset += sha256.Size
+	signature := data[offset : offset+ed25519.SignatureSize]
+	offset += ed25519.SignatureSize
+	bodyLength := binary.BigEndian.Uint64(data[offset : offset+8])
+	offset += 8
+	if bodyLength != uint64(len(data)-offset) {
+		return nil, fmt.Errorf("decode package: body length %d does not match payload %d", bodyLength, len(data)-offset)
+	}
+	body := data[offset:]
+
+	if packageDigest(version, fingerprint, body) != recorded {
+		return nil, fmt.Errorf("decode package: digest mismatch")
+	}
+	if !ed25519.Verify(publicKey, recorded[:], signature) {
+		return nil, fmt.Errorf("decode package: signature verification failed")
+	}
+	if hex.EncodeToString(fingerprint) != compiler.Fingerprint() {
+		return nil, fmt.Errorf(
+			"decode package: compiler fingerprint %s does not match engine %s",
+			hex.EncodeToString(fingerprint), compiler.Fingerprint(),
+		)
+	}
+
+	reader := bodyReader{data: body}
+	name, err := reader.string()
+	if err != nil {
+		return nil, err
+	}
+	count, err := reader.uint32()
+	if err != nil {
+		return nil, err
+	}
+	pkg := &Package{
+		Name:                name,
+		CompilerFingerprint: hex.EncodeToString(fingerprint),
+		Digest:              hex.EncodeToString(recorded[:]),
+		Entries:             make([]Entry, 0, min(int(count), 1<<16)),
+	}
+	for range count {
+		kind, err := reader.byte()
+		if err != nil {
+			return nil, err
+		}
+		entryPath, err := reader.string()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := cleanEntryPath(entryPath); err != nil {
+			return nil, fmt.Errorf("decode package: entry %q: %w", entryPath, err)
+		}
+		length, err := reader.uint32()
+		if err != nil {
+			return nil, err
+		}
+		payload, err := reader.bytes(int(length))
+		if err != nil {
+			return nil, err
+		}
+		if Kind(kind) < KindBytecode || Kind(kind) > KindAsset {
+			return nil, fmt.Errorf("decode package: entry %q has unknown kind %d", entryPath, kind)
+		}
+		pkg.Entries = append(pkg.Entries, Entry{
+			Kind: Kind(kind),
+			Path: entryPath,
+			Data: bytes.Clone(payload),
+		})
+	}
+	if reader.remaining() != 0 {
+		return nil, fmt.Errorf("decode package: %d trailing bytes", reader.remaining())
+	}
+	return pkg, nil
+}
+
+// Open reads, verifies and decodes a package file. A content-addressed file
+// name (<digest>.kwpkg) must agree with the digest inside the archive.
+func Open(filename string, publicKey ed25519.PublicKey) (*Package, error) {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > MaxPackageSize {
+		return nil, fmt.Errorf("open package %s: %d bytes exceeds %d", filename, info.Size(), MaxPackageSize)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	pkg, err := Unmarshal(data, publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("open package %s: %w", filename, err)
+	}
+	base := strings.TrimSuffix(path.Base(strings.ReplaceAll(filename, "\\", "/")), Extension)
+	if isDigest(base) && base != pkg.Digest {
+		return nil, fmt.Errorf("open package %s: file name does not match digest %s", filename, pkg.Digest)
+	}
+	return pkg, nil
+}
+
+// GenerateKey creates a signing key pair for Build and Open.
+func GenerateKey() (ed25519.PublicKey, ed25519.PrivateKey, error) {
+	return ed25519.GenerateKey(rand.Reader)
+}
+
+// ParsePublicKey decodes a hex-encoded ed25519 verification key.
+func ParsePublicKey(text string) (ed25519.PublicKey, error) {
+	raw, err := hex.DecodeString(strings.TrimSpace(text))
+	if err != nil {
+		return nil, fmt.Errorf("parse package public key: %w", err)
+	}
+	if len(raw) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("parse package public key: got %d bytes, want %d", len(raw), ed25519.PublicKeySize)
+	}
+	return ed25519.PublicKey(raw), nil
+}
+
+// ParsePrivateKey decodes a hex-encoded ed25519 signing key: either the
+// 32-byte seed or the full 64-byte private key.
+func ParsePrivateKey(text string) (ed25519.PrivateKey, error) {
+	raw, err := hex.DecodeString(strings.TrimSpace(text))
+	if err != nil {
+		return nil, fmt.Errorf("parse package private key: %w", err)
+	}
+	switch len(raw) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(raw), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(raw), nil
+	default:
+		return nil, fmt.Errorf("parse package private key: got %d bytes, want %d or %d",
+			len(raw), ed25519.SeedSize, ed25519.PrivateKeySize)
+	}
+}
+
+func packageDigest(version uint16, fingerprint, body []byte) [sha256.Size]byte {
+	hash := sha256.New()
+	hash.Write([]byte("kitwork-package"))
+	hash.Write([]byte{0})
+	var encoded [2]byte
+	binary.BigEndian.PutUint16(encoded[:], version)
+	hash.Write(encoded[:])
+	hash.Write(fingerprint)
+	hash.Write(body)
+	var digest [sha256.Size]byte
+	copy(digest[:], hash.Sum(nil))
+	return digest
+}
+
+// cleanEntryPath accepts only canonical, relative, slash-separated paths, so
+// Install can never write outside its target folder.
+func cleanEntryPath(name string) (string, error) {
+	if name == "" || len(name) > maxEntryPath {
+		return "", fmt.Errorf("invalid path length")
+	}
+	if strings.ContainsAny(name, "\\\x00") || strings.HasPrefix(name, "/") {
+		return "", fmt.Errorf("path must be relative and slash-separated")
+	}
+	if path.Clean(name) != name {
+		return "", fmt.Errorf("path is not canonical")
+	}
+	for _, segment := range strings.Split(name, "/") {
+		if segment == ".." || segment == "." {
+			return "", fmt.Errorf("path escapes the package root")
+		}
+	}
+	return name, nil
+}
+
+func isDigest(text string) bool {
+	if len(text) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(text)
+	return err == nil
+}
+
+func appendString(data []byte, text string) []byte {
+	data = binary.BigEndian.AppendUint16(data, uint16(len(text)))
+	return append(data, text...)
+}
+
+type bodyReader struct {
+	data   []byte
+	offset int
+}
+
+func (r *bodyReader) remaining() int { return len(r.data) - r.offset }
+
+func (r *bodyReader) bytes(length int) ([]byte, error) {
+	if length < 0 || length > r.remaining() {
+		return nil, fmt.Errorf("decode package: truncated body")
+	}
+	out := r.data[r.offset : r.offset+length]
+	r.offset += length
+	return out, nil
+}
+
+func (r *bodyReader) byte() (byte, error) {
+	raw, err := r.bytes(1)
+	if err != nil {
+		return 0, err
+	}
+	return raw[0], nil
+}
+
+func (r *bodyReader) uint32() (uint32, error) {
+	raw, err := r.bytes(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(raw), nil
+}
+
+func (r *bodyReader) string() (string, error) {
+	raw, err := r.bytes(2)
+	if err != nil {
+		return "", err
+	}
+	text, err := r.bytes(int(binary.BigEndian.Uint16(raw)))
+	if err != nil {
+		return "", err
+	}
+	return string(text), nil
+}
@@ -0,0 +1,226 @@
+package bundle
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kitwork/engine/compiler"
+	"github.com/kitwork/engine/runtime"
+)
+
+func testEntries(t *testing.T) []Entry {
+	t.Helper()
+	bytecode, err := compiler.CompileSource(`const answer = 40 + 2;`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := bytecode.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []Entry{
+		{Kind: KindTemplate, Path: "page.kitwork.html", Data: []byte("<p>hi</p>")},
+		{Kind: KindBytecode, Path: "router.kitwork.js", Data: data},
+		{Kind: KindAsset, Path: "assets/logo.svg", Data: []byte("<svg/>")},
+	}
+}
+
+func TestPackageRoundTripIsDeterministicAndVerified(t *testing.T) {
+	public, private, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := testEntries(t)
+	data, digest, err := Marshal("example.com", entries, private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reversed := []Entry{entries[2], entries[1], entries[0]}
+	again, againDigest, err := Marshal("example.com", reversed, private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digest != againDigest || !bytes.Equal(data, again) {
+		t.Fatal("entry order changed the package encoding")
+	}
+
+	pkg, err := Unmarshal(data, public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg.Name != "example.com" || pkg.Digest != digest || pkg.CompilerFingerprint != compiler.Fingerprint() {
+		t.Fatalf("package identity = %+v", pkg)
+	}
+	if len(pkg.Entries) != 3 || pkg.Entries[0].Path != "assets/logo.svg" {
+		t.Fatalf("entries = %+v", pkg.Entries)
+	}
+	manifest := pkg.Manifest()
+	if len(manifest) != 2 || manifest[0].Kind != "asset" || manifest[1].Kind != "template" || manifest[0].SHA256 == "" {
+		t.Fatalf("manifest = %+v", manifest)
+	}
+	programs, err := pkg.Bytecode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	vm := runtime.New(programs["router.kitwork.js"].Program)
+	vm.Run()
+	if got := vm.Vars["answer"].Int(); got != 42 {
+		t.Fatalf("packaged program answer = %d", got)
+	}
+}
+
+func TestPackageRejectsTamperingAndForeignKeys(t *testing.T) {
+	public, private, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _, err := Marshal("example.com", testEntries(t), private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := bytes.Clone(data)
+	tampered[len(tampered)-1] ^= 0xff
+	if _, err := Unmarshal(tampered, public); err == nil || !strings.Contains(err.Error(), "digest mismatch") {
+		t.Fatalf("tampered body error = %v", err)
+	}
+
+	foreign, _, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Unmarshal(data, foreign); err == nil || !strings.Contains(err.Error(), "signature") {
+		t.Fatalf("foreign key error = %v", err)
+	}
+
+	// A package produced by another compiler contract is refused even when
+	// the producer re-signs it: the digest covers the fingerprint.
+	stale := bytes.Clone(data)
+	stale[6] ^= 0xff
+	if _, err := Unmarshal(stale, public); err == nil {
+		t.Fatal("package with a foreign compiler fingerprint was accepted")
+	}
+}
+
+func TestPackageRejectsEscapingPaths(t *testing.T) {
+	_, private, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"../outside", "/abs", "a/../../b", "a\\b", "./a"} {
+		entries := []Entry{{Kind: KindAsset, Path: name, Data: []byte("x")}}
+		if _, _, err := Marshal("example.com", entries, private); err == nil {
+			t.Fatalf("entry path %q was accepted", name)
+		}
+	}
+}
+
+func TestLoaderInstallsWithoutSourceAndPreservesServerState(t *testing.T) {
+	public, private, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, digest, err := Marshal("acme/example.com", testEntries(t), private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packages := t.TempDir()
+	if err := os.WriteFile(filepath.Join(packages, digest+Extension), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	root := t.TempDir()
+	target := filepath.Join(root, "acme", "example.com")
+	if err := os.MkdirAll(filepath.Join(target, "stale"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(target, ".env"), []byte("SECRET=1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	loader := NewLoader()
+	installed, err := loader.LoadDirectory(packages, root, public)
+	if err != nil || installed != 1 {
+		t.Fatalf("LoadDirectory = %d, %v", installed, err)
+	}
+	if _, err := os.Stat(filepath.Join(target, "stale")); !os.IsNotExist(err) {
+		t.Fatal("stale package content survived install")
+	}
+	if env, err := os.ReadFile(filepath.Join(target, ".env")); err != nil || string(env) != "SECRET=1" {
+		t.Fatalf("server-local .env = %q, %v", env, err)
+	}
+	marker, err := os.ReadFile(filepath.Join(target, "router.kitwork.js"))
+	if err != nil || strings.Contains(string(marker), "answer") {
+		t.Fatalf("router marker = %q, %v", marker, err)
+	}
+	if loader.Digest(target) != digest {
+		t.Fatalf("installed digest = %q", loader.Digest(target))
+	}
+
+	bytecode, err := loader.CompileFile(target, "router.kitwork.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bytecode.Files) != 1 || !strings.HasSuffix(bytecode.Files[0], "router.kitwork.js") {
+		t.Fatalf("files = %v", bytecode.Files)
+	}
+	if _, err := loader.CompileFile(target, "other", "router.kitwork.js"); err == nil {
+		t.Fatal("loader compiled a file outside the package")
+	}
+}
+
+// A bad package anywhere in the directory aborts the load before any package
+// is extracted: the sites already deployed stay as they were.
+func TestLoadDirectoryVerifiesEveryPackageFirst(t *testing.T) {
+	public, private, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, digest, err := Marshal("acme/example.com", testEntries(t), private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packages := t.TempDir()
+	if err := os.WriteFile(filepath.Join(packages, "a-"+digest+Extension), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(packages, "z-broken"+Extension), []byte("not a package"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	root := t.TempDir()
+	deployed := filepath.Join(root, "acme", "example.com", "router.kitwork.js")
+	if err := os.MkdirAll(filepath.Dir(deployed), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(deployed, []byte("previous"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if installed, err := NewLoader().LoadDirectory(packages, root, public); err == nil || installed != 0 {
+		t.Fatalf("LoadDirectory = %d, %v", installed, err)
+	}
+	if previous, err := os.ReadFile(deployed); err != nil || string(previous) != "previous" {
+		t.Fatalf("deployed site = %q, %v", previous, err)
+	}
+}
+
+func TestOpenRejectsMismatchedContentAddress(t *testing.T) {
+	public, private, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _, err := Marshal("example.com", testEntries(t), private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(t.TempDir(), strings.Repeat("ab", 32)+Extension)
+	if err := os.WriteFile(filename, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(filename, public); err == nil || !strings.Contains(err.Error(), "does not match digest") {
+		t.Fatalf("Open error = %v", err)
+	}
+}
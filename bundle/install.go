@@ -0,0 +1,257 @@
+package bundle
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kitwork/engine/compiler"
+)
+
+// installRecord is written to <target>/.kitwork/package.json so operators can
+// see which package is deployed and the hash of every file it placed.
+type installRecord struct {
+	Name                string        `json:"name"`
+	Digest              string        `json:"digest"`
+	CompilerFingerprint string        `json:"compiler_fingerprint"`
+	Programs            []string      `json:"programs"`
+	Files               []AssetRecord `json:"files"`
+}
+
+const installRecordPath = ".kitwork/package.json"
+
+// Loader serves compiled programs from installed packages in place of source
+// compilation. It implements the same CompileFile contract as
+// compiler.FileCache, keyed by the absolute path of the source entry the
+// program was built from; a path outside every installed package is an error,
+// never a fallback to compiling whatever happens to be on disk.
+type Loader struct {
+	mu       sync.RWMutex
+	programs map[string]*compiler.Bytecode
+	digests  map[string]string // target folder → installed package digest
+}
+
+func NewLoader() *Loader {
+	return &Loader{
+		programs: make(map[string]*compiler.Bytecode),
+		digests:  make(map[string]string),
+	}
+}
+
+// Install extracts pkg under root/<pkg.Name> and registers its programs.
+//
+// The package owns its folder: every top-level entry that is not hidden is
+// replaced, while dot entries (.env, .persist, .data, .kitwork) stay
+// server-local. Executable entries are written as markers that carry no
+// source — route discovery, hot-reload manifests and _cron/_queue scanning
+// keep working on the filesystem, and the program itself comes from the
+// package. Every install re-extracts, so a hand-edited template never
+// survives a restart.
+func (l *Loader) Install(pkg *Package, root string) (string, error) {
+	staged, err := stage(pkg, root)
+	if err != nil {
+		return "", err
+	}
+	return l.install(staged)
+}
+
+// stagedPackage is a package checked for installing: where it goes and its
+// decoded programs. Nothing on disk has been touched yet.
+type stagedPackage struct {
+	pkg      *Package
+	target   string
+	programs map[string]*compiler.Bytecode
+}
+
+// stage runs every check an install can fail before it writes: the name,
+// the target and the bytecode.
+func stage(pkg *Package, root string) (stagedPackage, error) {
+	if pkg == nil {
+		return stagedPackage{}, fmt.Errorf("install package: nil package")
+	}
+	name, err := cleanEntryPath(pkg.Name)
+	if err != nil {
+		return stagedPackage{}, fmt.Errorf("install package %s: name: %w", pkg.Digest, err)
+	}
+	target, err := filepath.Abs(filepath.Join(root, filepath.FromSlash(name)))
+	if err != nil {
+		return stagedPackage{}, err
+	}
+	programs, err := pkg.Bytecode()
+	if err != nil {
+		return stagedPackage{}, err
+	}
+	return stagedPackage{pkg: pkg, target: target, programs: programs}, nil
+}
+
+// install extracts a staged package and swaps its programs in.
+func (l *Loader) install(staged stagedPackage) (string, error) {
+	if l == nil {
+		return "", fmt.Errorf("install package: nil loader")
+	}
+	pkg, target, programs := staged.pkg, staged.target, staged.programs
+	if err := extract(pkg, target); err != nil {
+		return "", fmt.Errorf("install package %s into %s: %w", pkg.Digest, target, err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	prefix := target + string(filepath.Separator)
+	for key := range l.programs {
+		if strings.HasPrefix(key, prefix) {
+			delete(l.programs, key)
+		}
+	}
+	for entryPath, bytecode := range programs {
+		l.programs[filepath.Join(target, filepath.FromSlash(entryPath))] = bytecode
+	}
+	l.digests[target] = pkg.Digest
+	return target, nil
+}
+
+// CompileFile returns the packaged program for a source entry path.
+func (l *Loader) CompileFile(paths ...string) (*compiler.Bytecode, error) {
+	if l == nil {
+		return nil, fmt.Errorf("package loader is nil")
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("path is required")
+	}
+	filename, err := filepath.Abs(filepath.Join(paths...))
+	if err != nil {
+		return nil, err
+	}
+	l.mu.RLock()
+	bytecode := l.programs[filename]
+	l.mu.RUnlock()
+	if bytecode == nil {
+		return nil, fmt.Errorf("%s is not part of an installed package", filename)
+	}
+	// Programs are immutable and shared; only the watched file list is per call.
+	restored := *bytecode
+	restored.Files = []string{filename}
+	return &restored, nil
+}
+
+// Digest reports the package installed at target ("" when none).
+func (l *Loader) Digest(target string) string {
+	if l == nil {
+		return ""
+	}
+	absolute, err := filepath.Abs(target)
+	if err != nil {
+		return ""
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.digests[absolute]
+}
+
+// Installed lists every installed target folder, sorted.
+func (l *Loader) Installed() []string {
+	if l == nil {
+		return nil
+	}
+	l.mu.RLock()
+	targets := make([]string, 0, len(l.digests))
+	for target := range l.digests {
+		targets = append(targets, target)
+	}
+	l.mu.RUnlock()
+	sort.Strings(targets)
+	return targets
+}
+
+// LoadDirectory opens every *.kwpkg under directory and installs it under
+// root. Every package is verified and staged before the first one is
+// extracted, so one that fails aborts the load with nothing written: a host
+// configured for packages must not come up half-deployed.
+func (l *Loader) LoadDirectory(directory, root string, publicKey ed25519.PublicKey) (int, error) {
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		return 0, err
+	}
+	var staged []stagedPackage
+	seen := make(map[string]string)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), Extension) {
+			continue
+		}
+		filename := filepath.Join(directory, entry.Name())
+		pkg, err := Open(filename, publicKey)
+		if err != nil {
+			return 0, err
+		}
+		if previous, ok := seen[pkg.Name]; ok {
+			return 0, fmt.Errorf("packages %s and %s both install %q", previous, entry.Name(), pkg.Name)
+		}
+		seen[pkg.Name] = entry.Name()
+		next, err := stage(pkg, root)
+		if err != nil {
+			return 0, err
+		}
+		staged = append(staged, next)
+	}
+	installed := 0
+	for _, next := range staged {
+		if _, err := l.install(next); err != nil {
+			return installed, err
+		}
+		installed++
+	}
+	return installed, nil
+}
+
+func extract(pkg *Package, target string) error {
+	if err := os.MkdirAll(target, 0o755); err != nil {
+		return err
+	}
+	existing, err := os.ReadDir(target)
+	if err != nil {
+		return err
+	}
+	for _, entry := range existing {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(target, entry.Name())); err != nil {
+			return err
+		}
+	}
+
+	record := installRecord{
+		Name:                pkg.Name,
+		Digest:              pkg.Digest,
+		CompilerFingerprint: pkg.CompilerFingerprint,
+		Files:               pkg.Manifest(),
+	}
+	for _, entry := range pkg.Entries {
+		filename := filepath.Join(target, filepath.FromSlash(entry.Path))
+		if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+			return err
+		}
+		data := entry.Data
+		if entry.Kind == KindBytecode {
+			record.Programs = append(record.Programs, entry.Path)
+			data = []byte("// Compiled into package " + pkg.Digest + "; the source is not deployed.\n")
+		}
+		if err := os.WriteFile(filename, data, 0o644); err != nil {
+			return err
+		}
+	}
+
+	encoded, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	recordFile := filepath.Join(target, filepath.FromSlash(installRecordPath))
+	if err := os.MkdirAll(filepath.Dir(recordFile), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(recordFile, encoded, 0o644)
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/kitwork/engine/capabilities"
 	collectionhelper "github.com/kitwork/engine/utilities/collection"
 	"github.com/kitwork/engine/utilities/persist"
+	txt "github.com/kitwork/engine/utilities/text"
 	"github.com/kitwork/engine/value"
 )
 
@@ -266,9 +267,15 @@ func (h *Handle) syncFTS(db *sql.DB, index []collectionhelper.IndexEntry) error
 		return nil
 	}
 
+	// unicode61 strips tone marks but keeps đ, so a folded copy of each document (text.Fold, đ → d)
+	// is indexed beside it. An index built before that column existed is rebuilt from scratch.
+	if _, err := db.Exec(`SELECT folded FROM docs LIMIT 0`); err != nil && strings.Contains(err.Error(), "no such column") {
+		db.Exec(`DROP TABLE docs`)
+		db.Exec(`DROP TABLE IF EXISTS doc_state`)
+	}
 	stmts := []string{
 		`CREATE VIRTUAL TABLE IF NOT EXISTS docs USING fts5(
-			collection UNINDEXED, slug UNINDEXED, title, description, body,
+			collection UNINDEXED, slug UNINDEXED, title, description, body, folded,
 			tokenize = "unicode61 remove_diacritics 2")`,
 		`CREATE TABLE IF NOT EXISTS doc_state (
 			collection TEXT NOT NULL, slug TEXT NOT NULL, sig TEXT NOT NULL,
@@ -311,8 +318,9 @@ func (h *Handle) syncFTS(db *sql.DB, index []collectionhelper.IndexEntry) error
 		if _, err := db.Exec(`DELETE FROM docs WHERE collection = ? AND slug = ?`, key, slug); err != nil {
 			return err
 		}
-		if _, err := db.Exec(`INSERT INTO docs (collection, slug, title, description, body) VALUES (?,?,?,?,?)`,
-			key, slug, title, description, doc.Body); err != nil {
+		folded := txt.Fold(title + "\n" + description + "\n" + doc.Body)
+		if _, err := db.Exec(`INSERT INTO docs (collection, slug, title, description, body, folded) VALUES (?,?,?,?,?,?)`,
+			key, slug, title, description, doc.Body, folded); err != nil {
 			return err
 		}
 		if _, err := db.Exec(`INSERT INTO doc_state (collection, slug, sig) VALUES (?,?,?)
@@ -345,9 +353,14 @@ func escapeMatch(text string) string {
 	if len(fields) == 0 {
 		return ""
 	}
+	quote := func(f string) string { return `"` + strings.ReplaceAll(f, `"`, `""`) + `"` }
 	quoted := make([]string, len(fields))
 	for i, f := range fields {
-		quoted[i] = `"` + strings.ReplaceAll(f, `"`, `""`) + `"`
+		quoted[i] = quote(f)
+		// "duong" must find "đường": the folded term matches the folded column.
+		if folded := txt.Fold(f); folded != strings.ToLower(f) {
+			quoted[i] = "(" + quoted[i] + " OR " + quote(folded) + ")"
+		}
 	}
 	return strings.Join(quoted, " ")
 }
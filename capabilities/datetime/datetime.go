@@ -0,0 +1,709 @@
+package datetime
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/kitwork/engine/capabilities"
+	dt "github.com/kitwork/engine/utilities/datetime"
+	"github.com/kitwork/engine/value"
+)
+
+// TimeAdapter is `import { time } from "kitwork"`: zoned date-times, durations, business days and
+// the Vietnamese lunar calendar.
+//
+//	const opening = time.at("2025-01-29 08:00", "Asia/Ho_Chi_Minh");
+//	opening.format("EEEE, dd/MM/yyyy HH:mm", "vi");  // "Thứ Tư, 29/01/2025 08:00"
+//	opening.lunar.text;                               // "01/01/2025"
+//	time.fromLunar({ day: 1, month: 1, year: 2026 }); // Tết 2026, 00:00 in the default zone
+//	opening.add(3, "days").startOf("day");
+//	opening.addBusinessDays(2, { holidays: ["lunar:01-01", "lunar:01-02"] });
+//
+// A script that names no zone gets dt.DefaultZone (Asia/Ho_Chi_Minh), never the host's local zone.
+type TimeAdapter struct {
+	scope capabilities.Scope
+}
+
+func NewTimeAdapter(scope capabilities.Scope) *TimeAdapter {
+	return &TimeAdapter{scope: scope}
+}
+
+func failure(format string, args ...any) value.Value {
+	return value.Value{K: value.Invalid, V: "time: " + fmt.Sprintf(format, args...)}
+}
+
+func zoneArg(args []value.Value, index int) (*time.Location, error) {
+	if index < len(args) && args[index].K == value.String {
+		return dt.LoadZone(args[index].Text())
+	}
+	return dt.LoadZone("")
+}
+
+// Now is the current instant: time.now() or time.now("UTC").
+func (a *TimeAdapter) Now(args ...value.Value) value.Value {
+	loc, err := zoneArg(args, 0)
+	if err != nil {
+		return failure("%v", err)
+	}
+	return value.New(&ZonedTime{t: time.Now().In(loc)})
+}
+
+// Today is midnight of the current day: time.today("vn").
+func (a *TimeAdapter) Today(args ...value.Value) value.Value {
+	loc, err := zoneArg(args, 0)
+	if err != nil {
+		return failure("%v", err)
+	}
+	return value.New(&ZonedTime{t: dt.StartOf(time.Now().In(loc), dt.Day)})
+}
+
+// At builds a zoned time from a string ("2025-01-29 08:00", "29/01/2025", RFC 3339), a map
+// ({ year, month, day, hour, minute, second } — month is 1-based), epoch milliseconds, a Time value
+// or another zoned time, read in the zone given as the second argument.
+func (a *TimeAdapter) At(args ...value.Value) value.Value {
+	if len(args) == 0 {
+		return a.Now()
+	}
+	loc, err := zoneArg(args, 1)
+	if err != nil {
+		return failure("%v", err)
+	}
+	t, err := toTime(args[0], loc, len(args) > 1)
+	if err != nil {
+		return failure("%v", err)
+	}
+	return value.New(&ZonedTime{t: t})
+}
+
+// Parse reads text with an explicit pattern: time.parse("29/01/2025 08:00", "dd/MM/yyyy HH:mm", "vn").
+func (a *TimeAdapter) Parse(args ...value.Value) value.Value {
+	if len(args) < 2 {
+		return failure("parse(text, pattern, zone?) needs a pattern")
+	}
+	loc, err := zoneArg(args, 2)
+	if err != nil {
+		return failure("%v", err)
+	}
+	t, err := dt.Parse(args[0].Text(), args[1].Text(), loc)
+	if err != nil {
+		return failure("%v", err)
+	}
+	return value.New(&ZonedTime{t: t})
+}
+
+// Zone canonicalizes a zone name: time.zone("vn") is "Asia/Ho_Chi_Minh"; an unknown zone is an error.
+func (a *TimeAdapter) Zone(args ...value.Value) value.Value {
+	name := ""
+	if len(args) > 0 {
+		name = args[0].Text()
+	}
+	canonical, err := dt.ZoneName(name)
+	if err != nil {
+		return failure("%v", err)
+	}
+	return value.New(canonical)
+}
+
+// Lunar converts a date (default: today) to the lunar calendar.
+func (a *TimeAdapter) Lunar(args ...value.Value) value.Value {
+	if len(args) == 0 {
+		return value.New(lunarOf(time.Now().In(mustDefaultZone())))
+	}
+	loc, err := zoneArg(args, 1)
+	if err != nil {
+		return failure("%v", err)
+	}
+	t, err := toTime(args[0], loc, len(args) > 1)
+	if err != nil {
+		return failure("%v", err)
+	}
+	return value.New(lunarOf(t))
+}
+
+// FromLunar is midnight of the solar day a lunar date falls on: fromLunar({ day, month, year, leap? },
+// zone?) or fromLunar(day, month, year, zone?).
+func (a *TimeAdapter) FromLunar(args ...value.Value) value.Value {
+	var date dt.LunarDate
+	zoneIndex := 1
+	switch {
+	case len(args) > 0 && args[0].K == value.Map:
+		m := args[0].Map()
+		date = dt.LunarDate{Day: intOf(m["day"]), Month: intOf(m["month"]), Year: intOf(m["year"]), Leap: m["leap"].Truthy()}
+	case len(args) >= 3:
+		date = dt.LunarDate{Day: intOf(args[0]), Month: intOf(args[1]), Year: intOf(args[2])}
+		zoneIndex = 3
+	default:
+		return failure("fromLunar needs { day, month, year }")
+	}
+	loc, err := zoneArg(args, zoneIndex)
+	if err != nil {
+		return failure("%v", err)
+	}
+	t, err := dt.FromLunar(date, loc)
+	if err != nil {
+		return failure("%v", err)
+	}
+	return value.New(&ZonedTime{t: t})
+}
+
+// Duration builds a value.Duration: duration("1h30m"), duration(90, "minutes") or
+// duration({ hours: 1, minutes: 30 }). Days are 24 hours; months and years have no fixed length and
+// are refused — use zoned.add(1, "month") for calendar math.
+func (a *TimeAdapter) Duration(args ...value.Value) value.Value {
+	if len(args) == 0 {
+		return value.New(time.Duration(0))
+	}
+	d, err := toDuration(args)
+	if err != nil {
+		return failure("%v", err)
+	}
+	return value.New(d)
+}
+
+// Calendar builds a reusable business-day calendar: calendar({ holidays: [...], weekend: [6, 0] }).
+func (a *TimeAdapter) Calendar(args ...value.Value) value.Value {
+	var options value.Value
+	if len(args) > 0 {
+		options = args[0]
+	}
+	calendar, err := toCalendar(options)
+	if err != nil {
+		return failure("%v", err)
+	}
+	return value.New(&BusinessCalendar{calendar: calendar})
+}
+
+// BusinessDays counts business days in [from, to): businessDays(from, to, calendarOrOptions?).
+func (a *TimeAdapter) BusinessDays(args ...value.Value) value.Value {
+	if len(args) < 2 {
+		return failure("businessDays(from, to) needs two dates")
+	}
+	loc := mustDefaultZone()
+	from, err := toTime(args[0], loc, false)
+	if err != nil {
+		return failure("%v", err)
+	}
+	to, err := toTime(args[1], loc, false)
+	if err != nil {
+		return failure("%v", err)
+	}
+	var options value.Value
+	if len(args) > 2 {
+		options = args[2]
+	}
+	calendar, err := toCalendar(options)
+	if err != nil {
+		return failure("%v", err)
+	}
+	return value.New(calendar.BusinessDaysBetween(from, to))
+}
+
+func mustDefaultZone() *time.Location {
+	loc, err := dt.LoadZone("")
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+/* =============================================================================
+   ZONED TIME
+   ============================================================================= */
+
+// ZonedTime is an instant paired with the zone it is read in. It is immutable: every method returns
+// a new value. Zero-argument methods are properties to a script and to templates —
+// `{{ order.placedAt.date }}` prints "29/01/2025".
+type ZonedTime struct {
+	t time.Time
+}
+
+// NewZonedTime wraps t, keeping its Location.
+func NewZonedTime(t time.Time) *ZonedTime { return &ZonedTime{t: t} }
+
+// Time is the underlying instant; to a script, `zoned.time` is a Time value.
+func (z *ZonedTime) Time() time.Time { return z.t }
+
+// Location is the zone z is read in; CronBuilder.Timezone accepts any value that has one.
+func (z *ZonedTime) Location() *time.Location { return z.t.Location() }
+
+func (z *ZonedTime) Year() int        { return z.t.Year() }
+func (z *ZonedTime) Month() int       { return int(z.t.Month()) }
+func (z *ZonedTime) Day() int         { return z.t.Day() }
+func (z *ZonedTime) Hour() int        { return z.t.Hour() }
+func (z *ZonedTime) Minute() int      { return z.t.Minute() }
+func (z *ZonedTime) Second() int      { return z.t.Second() }
+func (z *ZonedTime) Millisecond() int { return z.t.Nanosecond() / int(time.Millisecond) }
+func (z *ZonedTime) Weekday() int     { return int(z.t.Weekday()) } // 0 = Sunday, as in JS
+func (z *ZonedTime) Zone() string     { return z.t.Location().String() }
+func (z *ZonedTime) Offset() string   { return dt.Format(z.t, "XXX", "") }
+func (z *ZonedTime) Iso() string      { return z.t.Format(time.RFC3339Nano) }
+func (z *ZonedTime) Date() string     { return dt.Format(z.t, "dd/MM/yyyy", "") }
+func (z *ZonedTime) Clock() string    { return dt.Format(z.t, "HH:mm", "") }
+func (z *ZonedTime) Datetime() string { return dt.Format(z.t, "dd/MM/yyyy HH:mm", "") }
+func (z *ZonedTime) Epoch() int64     { return z.t.UnixMilli() }
+func (z *ZonedTime) Lunar() *Lunar    { return lunarOf(z.t) }
+func (z *ZonedTime) String() string   { return z.Iso() }
+
+func (z *ZonedTime) MarshalJSON() ([]byte, error) { return json.Marshal(z.Iso()) }
+
+// Format renders a CLDR pattern ("dd/MM/yyyy HH:mm"); the optional locale "vi" names months and
+// weekdays in Vietnamese.
+func (z *ZonedTime) Format(args ...value.Value) value.Value {
+	pattern := "yyyy-MM-dd'T'HH:mm:ssXXX"
+	if len(args) > 0 && args[0].K == value.String {
+		pattern = args[0].Text()
+	}
+	locale := ""
+	if len(args) > 1 {
+		locale = args[1].Text()
+	}
+	return value.New(dt.Format(z.t, pattern, locale))
+}
+
+// Add moves forward by a Duration value, a duration string ("1h30m", "3d", "2 months"), an amount
+// and a unit (add(3, "days")) or a map ({ months: 1, days: 2 }). Calendar units keep the wall clock.
+func (z *ZonedTime) Add(args ...value.Value) value.Value {
+	return z.shift(args, 1)
+}
+
+// Subtract is Add in the other direction.
+func (z *ZonedTime) Subtract(args ...value.Value) value.Value {
+	return z.shift(args, -1)
+}
+
+func (z *ZonedTime) shift(args []value.Value, sign int) value.Value {
+	if len(args) == 0 {
+		return value.New(z)
+	}
+	t, err := addAmount(z.t, args, sign)
+	if err != nil {
+		return failure("%v", err)
+	}
+	return value.New(&ZonedTime{t: t})
+}
+
+// StartOf truncates to a unit in z's zone: startOf("day"), "week" (Monday), "month", "quarter", "year".
+func (z *ZonedTime) StartOf(args ...value.Value) value.Value {
+	unit, err := unitArg(args, 0)
+	if err != nil {
+		return failure("%v", err)
+	}
+	return value.New(&ZonedTime{t: dt.StartOf(z.t, unit)})
+}
+
+// EndOf is the last millisecond of the unit: endOf("month") is 23:59:59.999 on its last day.
+func (z *ZonedTime) EndOf(args ...value.Value) value.Value {
+	unit, err := unitArg(args, 0)
+	if err != nil {
+		return failure("%v", err)
+	}
+	return value.New(&ZonedTime{t: dt.EndOf(z.t, unit)})
+}
+
+// WithZone is the same instant read in another zone: withZone("UTC").
+func (z *ZonedTime) WithZone(args ...value.Value) value.Value {
+	loc, err := zoneArg(args, 0)
+	if err != nil {
+		return failure("%v", err)
+	}
+	return value.New(&ZonedTime{t: z.t.In(loc)})
+}
+
+// Diff is z minus other: a Duration value, or whole units with diff(other, "days").
+func (z *ZonedTime) Diff(args ...value.Value) value.Value {
+	if len(args) == 0 {
+		return failure("diff(other, unit?) needs a date")
+	}
+	other, err := toTime(args[0], z.t.Location(), false)
+	if err != nil {
+		return failure("%v", err)
+	}
+	if len(args) < 2 {
+		return value.New(z.t.Sub(other))
+	}
+	unit, err := unitArg(args, 1)
+	if err != nil {
+		return failure("%v", err)
+	}
+	return value.New(dt.Diff(other, z.t, unit))
+}
+
+func (z *ZonedTime) IsBefore(args ...value.Value) value.Value {
+	return z.compare(args, func(other time.Time) bool { return z.t.Before(other) })
+}
+
+func (z *ZonedTime) IsAfter(args ...value.Value) value.Value {
+	return z.compare(args, func(other time.Time) bool { return z.t.After(other) })
+}
+
+// IsSame compares instants, or calendar periods in z's zone with isSame(other, "day").
+func (z *ZonedTime) IsSame(args ...value.Value) value.Value {
+	if len(args) > 1 {
+		unit, err := unitArg(args, 1)
+		if err != nil {
+			return failure("%v", err)
+		}
+		return z.compare(args[:1], func(other time.Time) bool {
+			return dt.StartOf(z.t, unit).Equal(dt.StartOf(other.In(z.t.Location()), unit))
+		})
+	}
+	return z.compare(args, func(other time.Time) bool { return z.t.Equal(other) })
+}
+
+func (z *ZonedTime) compare(args []value.Value, test func(time.Time) bool) value.Value {
+	if len(args) == 0 {
+		return failure("a date to compare with is required")
+	}
+	other, err := toTime(args[0], z.t.Location(), false)
+	if err != nil {
+		return failure("%v", err)
+	}
+	return value.New(test(other))
+}
+
+// AddBusinessDays skips weekends and holidays: addBusinessDays(2, calendarOrOptions?).
+func (z *ZonedTime) AddBusinessDays(args ...value.Value) value.Value {
+	if len(args) == 0 {
+		return value.New(z)
+	}
+	calendar, err := calendarArg(args, 1)
+	if err != nil {
+		return failure("%v", err)
+	}
+	return value.New(&ZonedTime{t: calendar.AddBusinessDays(z.t, intOf(args[0]))})
+}
+
+// IsBusinessDay reports whether z's date is a working day: isBusinessDay(calendarOrOptions?).
+func (z *ZonedTime) IsBusinessDay(args ...value.Value) value.Value {
+	calendar, err := calendarArg(args, 0)
+	if err != nil {
+		return failure("%v", err)
+	}
+	return value.New(calendar.IsBusinessDay(z.t))
+}
+
+// NextBusinessDay is z on a business day, else the next business day at the same clock.
+func (z *ZonedTime) NextBusinessDay(args ...value.Value) value.Value {
+	calendar, err := calendarArg(args, 0)
+	if err != nil {
+		return failure("%v", err)
+	}
+	return value.New(&ZonedTime{t: calendar.NextBusinessDay(z.t)})
+}
+
+/* =============================================================================
+   LUNAR DATE / BUSINESS CALENDAR
+   ============================================================================= */
+
+// Lunar is a Vietnamese lunar date as a script sees it: day, month, year, leap, yearName ("Giáp
+// Thìn") and text ("15/08/2024", "01/02/2023 (nhuận)").
+type Lunar struct {
+	Day      int
+	Month    int
+	Year     int
+	Leap     bool
+	YearName string
+	Text     string
+}
+
+func lunarOf(t time.Time) *Lunar {
+	date := dt.ToLunar(t)
+	return &Lunar{Day: date.Day, Month: date.Month, Year: date.Year, Leap: date.Leap, YearName: date.YearName(), Text: date.String()}
+}
+
+func (l *Lunar) String() string { return l.Text }
+
+func (l *Lunar) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{"day": l.Day, "month": l.Month, "year": l.Year, "leap": l.Leap, "yearName": l.YearName, "text": l.Text})
+}
+
+// BusinessCalendar is a compiled holiday list, built once with time.calendar(...) and passed to
+// addBusinessDays / isBusinessDay / businessDays instead of re-reading the options on every call.
+type BusinessCalendar struct {
+	calendar *dt.Calendar
+}
+
+func (c *BusinessCalendar) IsBusinessDay(args ...value.Value) value.Value {
+	if len(args) == 0 {
+		return failure("isBusinessDay(date) needs a date")
+	}
+	t, err := toTime(args[0], mustDefaultZone(), false)
+	if err != nil {
+		return failure("%v", err)
+	}
+	return value.New(c.calendar.IsBusinessDay(t))
+}
+
+func (c *BusinessCalendar) IsHoliday(args ...value.Value) value.Value {
+	if len(args) == 0 {
+		return failure("isHoliday(date) needs a date")
+	}
+	t, err := toTime(args[0], mustDefaultZone(), false)
+	if err != nil {
+		return failure("%v", err)
+	}
+	return value.New(c.calendar.IsHoliday(t))
+}
+
+/* =============================================================================
+   ARGUMENT CONVERSION
+   ============================================================================= */
+
+// toTime reads a date argument. A zoned time keeps its zone unless reZone is set (an explicit zone
+// argument was given); every other input is read in loc.
+func toTime(v value.Value, loc *time.Location, reZone bool) (time.Time, error) {
+	switch v.K {
+	case value.Struct:
+		if z, ok := v.V.(*ZonedTime); ok {
+			if reZone {
+				return z.t.In(loc), nil
+			}
+			return z.t, nil
+		}
+	case value.Time:
+		return time.Unix(0, int64(v.N)).In(loc), nil
+	case value.Number:
+		return time.UnixMilli(int64(v.N)).In(loc), nil
+	case value.String:
+		return dt.ParseAny(v.Text(), loc)
+	case value.Map:
+		m := v.Map()
+		part := func(name string, fallback int) int {
+			if item, ok := m[name]; ok && item.K == value.Number {
+				return int(item.N)
+			}
+			return fallback
+		}
+		if _, ok := m["year"]; !ok {
+			return time.Time{}, fmt.Errorf("a date map needs at least a year")
+		}
+		return time.Date(part("year", 1970), time.Month(part("month", 1)), part("day", 1),
+			part("hour", 0), part("minute", 0), part("second", 0), part("millisecond", 0)*int(time.Millisecond), loc), nil
+	}
+	return time.Time{}, fmt.Errorf("cannot read a %s as a date", v.K)
+}
+
+func unitArg(args []value.Value, index int) (dt.Unit, error) {
+	if index >= len(args) {
+		return "", fmt.Errorf("a unit (day, week, month, …) is required")
+	}
+	return dt.ParseUnit(args[index].Text())
+}
+
+func intOf(v value.Value) int {
+	if v.K == value.String {
+		n, _ := strconv.Atoi(strings.TrimSpace(v.Text()))
+		return n
+	}
+	return int(v.N)
+}
+
+// unitOrder applies a map amount from the largest unit down, like Temporal's Duration.
+var unitOrder = []struct {
+	keys []string
+	unit dt.Unit
+}{
+	{[]string{"years", "year"}, dt.Year},
+	{[]string{"quarters", "quarter"}, dt.Quarter},
+	{[]string{"months", "month"}, dt.Month},
+	{[]string{"weeks", "week"}, dt.Week},
+	{[]string{"days", "day"}, dt.Day},
+	{[]string{"hours", "hour"}, dt.Hour},
+	{[]string{"minutes", "minute"}, dt.Minute},
+	{[]string{"seconds", "second"}, dt.Second},
+	{[]string{"milliseconds", "millisecond"}, dt.Millisecond},
+}
+
+func addAmount(t time.Time, args []value.Value, sign int) (time.Time, error) {
+	amount := args[0]
+	switch {
+	case amount.K == value.Duration:
+		return t.Add(time.Duration(sign) * time.Duration(int64(amount.N))), nil
+	case amount.K == value.Number && len(args) > 1:
+		unit, err := dt.ParseUnit(args[1].Text())
+		if err != nil {
+			return t, err
+		}
+		return addNumber(t, amount.N*float64(sign), unit)
+	case amount.K == value.Number:
+		return t.Add(time.Duration(sign) * time.Duration(amount.N) * time.Millisecond), nil
+	case amount.K == value.Map:
+		m := amount.Map()
+		for _, step := range unitOrder {
+			for _, key := range step.keys {
+				if n, ok := m[key]; ok {
+					var err error
+					if t, err = addNumber(t, n.N*float64(sign), step.unit); err != nil {
+						return t, err
+					}
+				}
+			}
+		}
+		return t, nil
+	case amount.K == value.String:
+		text := strings.TrimSpace(amount.Text())
+		if d, err := time.ParseDuration(text); err == nil {
+			return t.Add(time.Duration(sign) * d), nil
+		}
+		n, unit, err := splitAmount(text)
+		if err != nil {
+			return t, err
+		}
+		return addNumber(t, n*float64(sign), unit)
+	}
+	return t, fmt.Errorf("cannot add a %s", amount.K)
+}
+
+// addNumber adds a whole number of calendar units, or any amount of clock units.
+func addNumber(t time.Time, n float64, unit dt.Unit) (time.Time, error) {
+	switch unit {
+	case dt.Hour, dt.Minute, dt.Second, dt.Millisecond:
+		return t.Add(time.Duration(n * float64(unitLength(unit)))), nil
+	}
+	if n != math.Trunc(n) {
+		return t, fmt.Errorf("%v %ss is not a whole number of calendar units", n, unit)
+	}
+	return dt.Add(t, int(n), unit), nil
+}
+
+// splitAmount reads "3d", "3 days", "2mo", "1.5 hours".
+func splitAmount(text string) (float64, dt.Unit, error) {
+	index := strings.IndexFunc(text, func(r rune) bool { return unicode.IsLetter(r) })
+	if index <= 0 {
+		return 0, "", fmt.Errorf("invalid amount %q", text)
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(text[:index]), 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid amount %q", text)
+	}
+	unit, err := dt.ParseUnit(text[index:])
+	return n, unit, err
+}
+
+func unitLength(unit dt.Unit) time.Duration {
+	switch unit {
+	case dt.Week:
+		return 7 * 24 * time.Hour
+	case dt.Day:
+		return 24 * time.Hour
+	case dt.Hour:
+		return time.Hour
+	case dt.Minute:
+		return time.Minute
+	case dt.Second:
+		return time.Second
+	case dt.Millisecond:
+		return time.Millisecond
+	}
+	return 0
+}
+
+func toDuration(args []value.Value) (time.Duration, error) {
+	amount := args[0]
+	exact := func(n float64, unit dt.Unit) (time.Duration, error) {
+		length := unitLength(unit)
+		if length == 0 {
+			return 0, fmt.Errorf("a %s has no fixed length; use zoned.add(n, %q)", unit, unit)
+		}
+		return time.Duration(n * float64(length)), nil
+	}
+	switch amount.K {
+	case value.Duration:
+		return time.Duration(int64(amount.N)), nil
+	case value.Number:
+		if len(args) > 1 {
+			unit, err := dt.ParseUnit(args[1].Text())
+			if err != nil {
+				return 0, err
+			}
+			return exact(amount.N, unit)
+		}
+		return time.Duration(amount.N) * time.Millisecond, nil
+	case value.String:
+		text := strings.TrimSpace(amount.Text())
+		if d, err := time.ParseDuration(text); err == nil {
+			return d, nil
+		}
+		n, unit, err := splitAmount(text)
+		if err != nil {
+			return 0, err
+		}
+		return exact(n, unit)
+	case value.Map:
+		var total time.Duration
+		m := amount.Map()
+		for _, step := range unitOrder {
+			for _, key := range step.keys {
+				if n, ok := m[key]; ok {
+					d, err := exact(n.N, step.unit)
+					if err != nil {
+						return 0, err
+					}
+					total += d
+				}
+			}
+		}
+		return total, nil
+	}
+	return 0, fmt.Errorf("cannot read a %s as a duration", amount.K)
+}
+
+func calendarArg(args []value.Value, index int) (*dt.Calendar, error) {
+	if index < len(args) {
+		return toCalendar(args[index])
+	}
+	return toCalendar(value.Value{})
+}
+
+// toCalendar accepts a BusinessCalendar, a holiday array, or { holidays, weekend } (weekend as JS
+// weekday numbers, 0 = Sunday). Nothing at all is a Saturday/Sunday weekend with no holidays.
+func toCalendar(options value.Value) (*dt.Calendar, error) {
+	var holidays []string
+	var weekend []time.Weekday
+	switch options.K {
+	case value.Struct:
+		if c, ok := options.V.(*BusinessCalendar); ok {
+			return c.calendar, nil
+		}
+		return nil, fmt.Errorf("expected a calendar")
+	case value.Array:
+		holidays = holidayList(options)
+	case value.Map:
+		m := options.Map()
+		holidays = holidayList(m["holidays"])
+		if days, ok := m["weekend"]; ok && days.K == value.Array {
+			weekend = []time.Weekday{}
+			for _, day := range days.Array() {
+				weekend = append(weekend, time.Weekday(intOf(day)))
+			}
+		}
+	}
+	return dt.NewCalendar(weekend, holidays)
+}
+
+func holidayList(v value.Value) []string {
+	if v.K != value.Array {
+		return nil
+	}
+	items := v.Array()
+	holidays := make([]string, 0, len(items))
+	for _, item := range items {
+		if z, ok := item.V.(*ZonedTime); ok && item.K == value.Struct {
+			holidays = append(holidays, z.t.Format("2006-01-02"))
+			continue
+		}
+		holidays = append(holidays, item.Text())
+	}
+	return holidays
+}
+
+func init() {
+	capabilities.DefaultRegistry.RegisterWithLifetime("time", capabilities.LifetimeApp, func(scope capabilities.Scope) value.Value {
+		return value.New(NewTimeAdapter(scope))
+	})
+}
@@ -0,0 +1,91 @@
+package datetime_test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/kitwork/engine/capabilities"
+	timecap "github.com/kitwork/engine/capabilities/datetime"
+	"github.com/kitwork/engine/value"
+)
+
+type mockScope struct{}
+
+func (m *mockScope) AppID() string                      { return "app_test" }
+func (m *mockScope) Domain() string                     { return "test.com" }
+func (m *mockScope) ResolvePath(paths ...string) string { return "/test" }
+func (m *mockScope) DB(name string) *sql.DB             { return nil }
+
+func zoned(t *testing.T, v value.Value) *timecap.ZonedTime {
+	t.Helper()
+	z, ok := v.V.(*timecap.ZonedTime)
+	if !ok || v.K != value.Struct {
+		t.Fatalf("expected a zoned time, got %v (%s)", v.Text(), v.K)
+	}
+	return z
+}
+
+func TestTimeCapability(t *testing.T) {
+	if got := capabilities.DefaultRegistry.GetLifetime("time"); got != capabilities.LifetimeApp {
+		t.Fatalf("time lifetime = %v, want LifetimeApp", got)
+	}
+	val, ok := capabilities.DefaultRegistry.Get("time", &mockScope{})
+	if !ok {
+		t.Fatal("time capability not registered")
+	}
+	adapter, ok := val.V.(*timecap.TimeAdapter)
+	if !ok {
+		t.Fatalf("expected *TimeAdapter, got %T", val.V)
+	}
+
+	at := zoned(t, adapter.At(value.New(map[string]value.Value{
+		"year": value.New(2025), "month": value.New(1), "day": value.New(29), "hour": value.New(8),
+	}), value.New("+07:00")))
+	if at.Iso() != "2025-01-29T08:00:00+07:00" {
+		t.Fatalf("at(map) = %s", at.Iso())
+	}
+
+	// Every way of spelling an amount lands on the same instant.
+	want := time.Date(2025, 1, 30, 9, 30, 0, 0, at.Location())
+	for _, args := range [][]value.Value{
+		{value.New(25*time.Hour + 30*time.Minute)},
+		{value.New("25h30m")},
+		{value.New(map[string]value.Value{"days": value.New(1), "hours": value.New(1.5)})},
+	} {
+		if got := zoned(t, at.Add(args...)).Time(); !got.Equal(want) {
+			t.Fatalf("add(%v) = %v", args[0].Text(), got)
+		}
+	}
+	if got := zoned(t, at.Subtract(value.New("2 months"))).Date(); got != "29/11/2024" {
+		t.Fatalf("subtract 2 months = %s", got)
+	}
+
+	if d := adapter.Duration(value.New(map[string]value.Value{"hours": value.New(1), "minutes": value.New(30)})); d.K != value.Duration || d.Text() != "1h30m0s" {
+		t.Fatalf("duration = %s (%s)", d.Text(), d.K)
+	}
+	if d := adapter.Duration(value.New(1), value.New("month")); d.K != value.Invalid {
+		t.Fatalf("a month duration must be refused, got %s", d.Text())
+	}
+	if z := adapter.Now(value.New("Mars/Olympus")); z.K != value.Invalid {
+		t.Fatalf("unknown zone accepted: %s", z.Text())
+	}
+	if name := adapter.Zone(value.New("Asia/Saigon")); name.Text() != "Asia/Ho_Chi_Minh" {
+		t.Fatalf("zone = %s", name.Text())
+	}
+
+	// Host values print and serialize as dates, so templates and ctx.json need no formatting step.
+	wrapped := value.New(at)
+	if wrapped.Text() != "2025-01-29T08:00:00+07:00" || string(wrapped.ToJSON()) != `"2025-01-29T08:00:00+07:00"` {
+		t.Fatalf("text = %s, json = %s", wrapped.Text(), wrapped.ToJSON())
+	}
+	lunar := value.New(at.Lunar())
+	var decoded map[string]any
+	if err := json.Unmarshal(lunar.ToJSON(), &decoded); err != nil || decoded["text"] != "01/01/2025" || decoded["yearName"] != "Ất Tỵ" {
+		t.Fatalf("lunar json = %s", lunar.ToJSON())
+	}
+	if got := lunar.Get("leap"); got.Truthy() {
+		t.Fatal("Tết is not in a leap month")
+	}
+}
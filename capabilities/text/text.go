@@ -0,0 +1,123 @@
+package text
+
+import (
+	"sort"
+
+	"github.com/kitwork/engine/capabilities"
+	txt "github.com/kitwork/engine/utilities/text"
+	"github.com/kitwork/engine/value"
+)
+
+// TextAdapter is `import { text } from "kitwork"`: the same folding and collation collection
+// queries and search use, for handlers and templates.
+type TextAdapter struct{}
+
+func NewTextAdapter(capabilities.Scope) *TextAdapter {
+	return &TextAdapter{}
+}
+
+// Slugify("Phở Bò Hà Nội") is "pho-bo-ha-noi"; an optional second argument replaces the "-".
+func (t *TextAdapter) Slugify(args ...value.Value) value.Value {
+	if len(args) == 0 {
+		return value.NewString("")
+	}
+	separator := ""
+	if len(args) > 1 {
+		separator = args[1].Text()
+	}
+	return value.NewString(txt.Slugify(args[0].Text(), separator))
+}
+
+// Fold lowercases and strips accents (đ → d) — a key for accent-insensitive lookups.
+func (t *TextAdapter) Fold(args ...value.Value) value.Value {
+	if len(args) == 0 {
+		return value.NewString("")
+	}
+	return value.NewString(txt.Fold(args[0].Text()))
+}
+
+// Equals compares two strings ignoring case and accents.
+func (t *TextAdapter) Equals(args ...value.Value) value.Value {
+	if len(args) < 2 {
+		return value.FALSE
+	}
+	return value.ToBool(txt.EqualFold(args[0].Text(), args[1].Text()))
+}
+
+// Contains reports whether the second string occurs in the first, ignoring case and accents.
+func (t *TextAdapter) Contains(args ...value.Value) value.Value {
+	if len(args) < 2 {
+		return value.FALSE
+	}
+	return value.ToBool(txt.ContainsFold(args[0].Text(), args[1].Text()))
+}
+
+// Compare is a Vietnamese dictionary comparator: items.sort(text.compare).
+func (t *TextAdapter) Compare(args ...value.Value) value.Value {
+	if len(args) < 2 {
+		return value.New(0)
+	}
+	return value.New(txt.Compare(args[0].Text(), args[1].Text()))
+}
+
+// Sort returns a copy of an array in Vietnamese dictionary order; a field name sorts objects by
+// that field: text.sort(customers, "name").
+func (t *TextAdapter) Sort(args ...value.Value) value.Value {
+	if len(args) == 0 || args[0].K != value.Array {
+		return value.Value{K: value.Invalid, V: "text.sort: expected an array"}
+	}
+	items := append([]value.Value(nil), args[0].Array()...)
+	key := func(item value.Value) string { return item.Text() }
+	if len(args) > 1 && args[1].Text() != "" {
+		field := args[1].Text()
+		key = func(item value.Value) string { return item.Get(field).Text() }
+	}
+	sort.SliceStable(items, func(i, j int) bool { return txt.Less(key(items[i]), key(items[j])) })
+	return value.New(items)
+}
+
+// SentenceCase capitalises the start of each sentence and lowercases the rest.
+func (t *TextAdapter) SentenceCase(args ...value.Value) value.Value {
+	if len(args) == 0 {
+		return value.NewString("")
+	}
+	return value.NewString(txt.SentenceCase(args[0].Text()))
+}
+
+// TitleCase capitalises every word, as Vietnamese names are written.
+func (t *TextAdapter) TitleCase(args ...value.Value) value.Value {
+	if len(args) == 0 {
+		return value.NewString("")
+	}
+	return value.NewString(txt.TitleCase(args[0].Text()))
+}
+
+// ToWords(1234567, "vi") reads an amount aloud for invoices; lang defaults to "vi" and an optional
+// third argument appends the unit: toWords(150000, "vi", "đồng").
+func (t *TextAdapter) ToWords(args ...value.Value) value.Value {
+	if len(args) == 0 {
+		return value.Value{K: value.Invalid, V: "text.toWords: missing number"}
+	}
+	number := args[0].Text()
+	if args[0].K == value.Number {
+		number = txt.FormatNumber(args[0].N)
+	}
+	lang := "vi"
+	if len(args) > 1 && args[1].Text() != "" {
+		lang = args[1].Text()
+	}
+	words, err := txt.ToWords(number, lang)
+	if err != nil {
+		return value.Value{K: value.Invalid, V: "text.toWords: " + err.Error()}
+	}
+	if len(args) > 2 && args[2].Text() != "" {
+		words += " " + args[2].Text()
+	}
+	return value.NewString(words)
+}
+
+func init() {
+	capabilities.DefaultRegistry.RegisterWithLifetime("text", capabilities.LifetimeApp, func(scope capabilities.Scope) value.Value {
+		return value.New(NewTextAdapter(scope))
+	})
+}
@@ -0,0 +1,57 @@
+package text_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/kitwork/engine/capabilities"
+	textcap "github.com/kitwork/engine/capabilities/text"
+	"github.com/kitwork/engine/value"
+)
+
+type mockScope struct{}
+
+func (m *mockScope) AppID() string                      { return "app_test" }
+func (m *mockScope) Domain() string                     { return "test.com" }
+func (m *mockScope) ResolvePath(paths ...string) string { return "/test" }
+func (m *mockScope) DB(name string) *sql.DB             { return nil }
+
+func TestTextCapability(t *testing.T) {
+	if got := capabilities.DefaultRegistry.GetLifetime("text"); got != capabilities.LifetimeApp {
+		t.Fatalf("text lifetime = %v, want LifetimeApp", got)
+	}
+	val, ok := capabilities.DefaultRegistry.Get("text", &mockScope{})
+	if !ok {
+		t.Fatal("text capability not registered")
+	}
+	adapter, ok := val.V.(*textcap.TextAdapter)
+	if !ok {
+		t.Fatalf("expected *TextAdapter, got %T", val.V)
+	}
+
+	if got := adapter.Slugify(value.New("Cà phê sữa đá")).Text(); got != "ca-phe-sua-da" {
+		t.Fatalf("slugify = %s", got)
+	}
+	if !adapter.Equals(value.New("ĐÀ NẴNG"), value.New("da nang")).Truthy() {
+		t.Fatal("equals must ignore accents and case")
+	}
+	if got := adapter.ToWords(value.New(1250000), value.New("vi"), value.New("đồng")).Text(); got != "một triệu hai trăm năm mươi nghìn đồng" {
+		t.Fatalf("toWords = %s", got)
+	}
+	if got := adapter.ToWords(value.New("12a")); got.K != value.Invalid {
+		t.Fatalf("toWords accepted garbage: %s", got.Text())
+	}
+
+	people := value.New([]value.Value{
+		value.New(map[string]value.Value{"name": value.New("Đức")}),
+		value.New(map[string]value.Value{"name": value.New("Dũng")}),
+		value.New(map[string]value.Value{"name": value.New("Ân")}),
+	})
+	sorted := adapter.Sort(people, value.New("name")).Array()
+	if len(sorted) != 3 || sorted[0].Get("name").Text() != "Ân" || sorted[2].Get("name").Text() != "Đức" {
+		t.Fatalf("sort by name = %s", value.New(sorted).ToJSON())
+	}
+	if people.Array()[0].Get("name").Text() != "Đức" {
+		t.Fatal("sort must not reorder its input")
+	}
+}
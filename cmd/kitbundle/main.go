@@ -0,0 +1,58 @@
+// kitbundle is the build step of a packaged deployment: it compiles tenant folders into signed
+// .kwpkg archives for a host whose manifest sets `bundles`/`bundleKey`. The signing key comes from
+// KITWORK_BUNDLE_KEY (hex seed or full key) so it never shows up in a process list; -keygen prints
+// a fresh pair, the public half going into the host's bundleKey.
+//
+//	go run ./cmd/kitbundle -keygen
+//	KITWORK_BUNDLE_KEY=<hex> go run ./cmd/kitbundle [-config app.kitwork.js] [-out dir] <site> [more-sites...]
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+
+	engine "github.com/kitwork/engine"
+	"github.com/kitwork/engine/bundle"
+)
+
+func main() {
+	config := flag.String("config", "", "manifest to read the apps root from (default: app.kitwork.js or server.kitwork.js)")
+	output := flag.String("out", ".", "directory the packages are written to")
+	keygen := flag.Bool("keygen", false, "print a new signing key pair and exit")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: kitbundle [-config file] [-out dir] <site> [more-sites...]")
+		fmt.Fprintln(os.Stderr, "       kitbundle -keygen")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *keygen {
+		public, private, err := bundle.GenerateKey()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "kitbundle:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("public  %s\nprivate %s\n", hex.EncodeToString(public), hex.EncodeToString(private.Seed()))
+		return
+	}
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	key := os.Getenv("KITWORK_BUNDLE_KEY")
+	if key == "" {
+		fmt.Fprintln(os.Stderr, "kitbundle: KITWORK_BUNDLE_KEY is not set")
+		os.Exit(2)
+	}
+	for _, site := range flag.Args() {
+		report, err := engine.Bundle(site, *output, key, *config)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "kitbundle: %s: %v\n", site, err)
+			os.Exit(1)
+		}
+		fmt.Printf("%s  %d programs, %d templates, %d assets, %d bytes\n",
+			report.File, report.Programs, report.Templates, report.Assets, report.Bytes)
+	}
+}
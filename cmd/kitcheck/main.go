@@ -0,0 +1,51 @@
+// kitcheck is the preflight a deployment runs before it ships: it prepares every site the manifest
+// serves without opening a listener, prints each issue, and with -openapi writes every site's
+// router.openapi() document as <domain>.openapi.json, so a pull request can commit the documents and
+// review the API change as a diff. Exit status 1 means at least one site would not start.
+//
+//	go run ./cmd/kitcheck [-config app.kitwork.js] [-openapi docs/api]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	engine "github.com/kitwork/engine"
+	"github.com/kitwork/engine/core"
+)
+
+func main() {
+	config := flag.String("config", "", "manifest to check (default: app.kitwork.js or server.kitwork.js)")
+	openapi := flag.String("openapi", "", "directory to write each site's OpenAPI document to")
+	flag.Parse()
+
+	report, err := engine.Check(*config)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "kitcheck:", err)
+		os.Exit(2)
+	}
+	os.Exit(printReport(os.Stdout, report, *openapi))
+}
+
+// printReport prints the issues and the totals, writes the OpenAPI documents when dir is set, and
+// returns the exit status.
+func printReport(out io.Writer, report core.CheckReport, dir string) int {
+	for _, issue := range report.Issues {
+		fmt.Fprintln(out, issue.Error())
+	}
+	fmt.Fprintf(out, "%d/%d sites valid, %d/%d programs compatible, %d issues\n",
+		report.Valid, report.Sites, report.Compatible, report.Programs, len(report.Issues))
+	if dir != "" {
+		if err := report.WriteOpenAPI(dir); err != nil {
+			fmt.Fprintln(out, "openapi:", err)
+			return 1
+		}
+		fmt.Fprintf(out, "%d OpenAPI documents written to %s\n", len(report.OpenAPI), dir)
+	}
+	if !report.OK() {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kitwork/engine/core"
+)
+
+// -openapi writes each site's document where a review can diff it.
+func TestPrintReportWritesOpenAPI(t *testing.T) {
+	root := t.TempDir()
+	site := filepath.Join(root, "identity-a", "shop.example")
+	if err := os.MkdirAll(site, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	router := `import { router } from "kitwork";
+router.get((ctx) => ctx.text("ok"));
+router.openapi({ title: "Shop", version: "2" });`
+	if err := os.WriteFile(filepath.Join(site, "router.kitwork.js"), []byte(router), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "api")
+	var out bytes.Buffer
+	if status := printReport(&out, core.Check(root, 100_000), dir); status != 0 {
+		t.Fatalf("status = %d\n%s", status, out.String())
+	}
+	if !strings.Contains(out.String(), "1 OpenAPI documents written") {
+		t.Fatalf("output:\n%s", out.String())
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "shop.example.openapi.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Info struct {
+			Title   string `json:"title"`
+			Version string `json:"version"`
+		} `json:"info"`
+		Paths map[string]any `json:"paths"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Info.Title != "Shop" || doc.Info.Version != "2" || doc.Paths["/"] == nil {
+		t.Fatalf("document = %s", data)
+	}
+}
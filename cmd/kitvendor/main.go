@@ -0,0 +1,40 @@
+// kitvendor audits a third-party ESM package copied into a tenant's vendor/ folder BEFORE it is
+// committed: every .js/.mjs module is parsed with the Kitwork dialect, compiled, and its imports
+// resolved exactly as the engine's bundler will — printing every unsupported construct as
+// file:line:col. Exit status 1 means the package would not compile when imported.
+//
+//	go run ./cmd/kitvendor <vendor/package-dir> [more-dirs...]
+//	go run ./cmd/kitvendor ../tenants/example.com/vendor/dayjs
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/kitwork/engine/compiler"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Println("usage: kitvendor <vendor/package-dir> [more-dirs...]")
+		os.Exit(2)
+	}
+	failed := false
+	for _, dir := range os.Args[1:] {
+		report, err := compiler.CheckVendor(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", dir, err)
+			os.Exit(2)
+		}
+		for _, issue := range report.Issues {
+			fmt.Println(issue.Error())
+		}
+		fmt.Printf("%s: %d modules checked, %d issues\n", dir, len(report.Files), len(report.Issues))
+		if len(report.Issues) > 0 {
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
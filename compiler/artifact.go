@@ -53,6 +53,19 @@ func UnmarshalBytecode(data []byte, expectedSourceFingerprint string) (*Bytecode
 	if expectedSourceFingerprint == "" {
 		return nil, fmt.Errorf("decode bytecode artifact: expected source fingerprint is required")
 	}
+	return decodeBytecode(data, expectedSourceFingerprint)
+}
+
+// UnmarshalPackagedBytecode restores an artifact shipped inside a signed
+// deployment package. There is no source to fingerprint on the serving host:
+// the caller has already authenticated these bytes, so only the compiler
+// contract and the Program encoding are checked. The recorded source
+// fingerprint is kept as the artifact's identity.
+func UnmarshalPackagedBytecode(data []byte) (*Bytecode, error) {
+	return decodeBytecode(data, "")
+}
+
+func decodeBytecode(data []byte, expectedSourceFingerprint string) (*Bytecode, error) {
 	if len(data) > MaxBytecodeArtifactSize {
 		return nil, fmt.Errorf(
 			"decode bytecode artifact: %d bytes exceeds %d",
@@ -33,6 +33,8 @@ type Program struct {
 	Statements []Statement
 	Exports    []string // tên export qua `export const/function` hoặc `export { }`
 	HasDefault bool     // true nếu có `export default …` (đã hạ về const DefaultExportName)
+	// ExportAliases: tên export → binding cục bộ khi đổi tên (`export { a as b }` → "b": "a").
+	ExportAliases map[string]string
 }
 
 // DefaultExportName là biến tổng hợp mà `export default <expr>` được hạ xuống.
@@ -45,15 +47,17 @@ type ImportSpec struct {
 	Local    string // tên biến cục bộ
 }
 
-// ImportStatement đại diện cho một import MODULE TƯƠNG ĐỐI chưa giải quyết
-// (vd `import { x } from "./helper.kitwork.js"`). Import từ "kitwork" KHÔNG dùng
+// ImportStatement đại diện cho một import MODULE chưa giải quyết — tương đối
+// (vd `import { x } from "./helper.kitwork.js"`), app-shared (`_core/…`) hoặc gói
+// vendor theo bare specifier (`import dayjs from "dayjs"`, xem resolveVendorModule). Import từ "kitwork" KHÔNG dùng
 // node này — chúng được hạ thẳng về VarStatement trong parser. Bundler ở package
 // script sẽ giải quyết các node này (IIFE-wrap) trước khi compile.
 type ImportStatement struct {
 	Token      Token
 	Names      []ImportSpec // import có tên: import { a, b as c } from "..."
 	Default    *Identifier  // import mặc định: import x from "..." (nil nếu không có)
-	Source     string       // specifier (đường dẫn tương đối)
+	Namespace  *Identifier  // import namespace: import * as ns from "..." (nil nếu không có)
+	Source     string       // specifier (tương đối, app-shared `_core/…` hoặc gói vendor)
 	SideEffect bool         // import "..."  (không binding)
 }
 
@@ -65,6 +69,10 @@ func (is *ImportStatement) String() string {
 		out.WriteString("\"" + is.Source + "\";")
 		return out.String()
 	}
+	if is.Namespace != nil {
+		out.WriteString("* as " + is.Namespace.Value + " from \"" + is.Source + "\";")
+		return out.String()
+	}
 	if is.Default != nil {
 		out.WriteString(is.Default.Value)
 		if len(is.Names) > 0 {
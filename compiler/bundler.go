@@ -1,6 +1,7 @@
 package compiler
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -23,13 +24,17 @@ import (
 // Bất kỳ trường hợp nào không xử lý được (cycle, không tìm thấy file, …) đều trả lỗi
 // để caller (Bytecode) rớt về esbuild — nên đây là đường đi AN TOÀN, additive.
 func nativeBundle(entryPath string, entryProg *Program) (*Program, []string, error) {
-	program, files, _, err := nativeBundleWithSources(entryPath, entryProg)
+	program, files, _, err := nativeBundleWithSources(entryPath, entryProg, nil)
 	return program, files, err
 }
 
+// nativeBundleWithSources is nativeBundle that also returns every module
+// source by debug name. entrySources (the entry's own text) only seeds error
+// locations for imports the entry itself cannot resolve.
 func nativeBundleWithSources(
 	entryPath string,
 	entryProg *Program,
+	entrySources map[string]string,
 ) (*Program, []string, map[string]string, error) {
 	abs, err := filepath.Abs(entryPath)
 	if err != nil {
@@ -41,6 +46,9 @@ func nativeBundleWithSources(
 		sources:   map[string]string{},
 		debugRoot: filepath.Dir(abs),
 	}
+	for name, source := range entrySources {
+		b.sources[name] = source
+	}
 	body, err := b.rewriteStatements(entryProg.Statements, filepath.Dir(abs))
 	if err != nil {
 		return nil, nil, nil, err
@@ -80,22 +88,26 @@ func (b *bundler) rewriteStatements(stmts []Statement, fromDir string) ([]Statem
 		}
 		absPath, err := resolveModulePath(imp.Source, fromDir)
 		if err != nil {
-			return nil, err
+			return nil, b.locate(imp.Token, err)
 		}
 		fi, err := os.Stat(absPath)
 		if err != nil {
-			return nil, err
+			return nil, b.locate(imp.Token, err)
 		}
 		if fi.IsDir() && !imp.SideEffect {
-			return nil, fmt.Errorf("native bundle: directory import %q cannot export bindings (must be a side-effect import)", imp.Source)
+			return nil, b.locate(imp.Token, fmt.Errorf("native bundle: directory import %q cannot export bindings (must be a side-effect import)", imp.Source))
 		}
 		modVar, err := b.ensureModule(imp.Source, fromDir)
 		if err != nil {
-			return nil, err
+			return nil, b.locate(imp.Token, err)
 		}
 		if imp.SideEffect {
 			continue // IIFE của module đã chạy (side-effect), không cần binding
 		}
+		if imp.Namespace != nil {
+			out = append(out, identBinding(imp.Namespace.Value, modVar, imp.Token))
+			continue
+		}
 		if imp.Default != nil {
 			out = append(out, memberBinding(imp.Default.Value, modVar, "default", imp.Token))
 		}
@@ -179,6 +191,20 @@ func (b *bundler) ensureModule(spec, fromDir string) (string, error) {
 	return name, nil
 }
 
+// locate gắn vị trí của lệnh import (file:dòng:cột) vào lỗi resolve. Lỗi đã có
+// vị trí (từ module sâu hơn) được giữ nguyên để trỏ đúng file gây lỗi.
+func (b *bundler) locate(origin Token, err error) error {
+	var located *SourceError
+	if errors.As(err, &located) {
+		return err
+	}
+	failure := &SourceError{File: origin.Source, Err: err}
+	if source, ok := b.sources[origin.Source]; ok {
+		failure.Line, failure.Column = locateOffset(source, origin.Position)
+	}
+	return failure
+}
+
 func (b *bundler) sourceName(path string) string {
 	relative, err := filepath.Rel(b.debugRoot, path)
 	if err != nil {
@@ -207,6 +233,9 @@ func resolveModulePath(spec, fromDir string) (string, error) {
 		}
 		return "", fmt.Errorf("native bundle: app-shared module %q không tìm thấy khi đi ngược từ %s", spec, fromDir)
 	}
+	if !filepath.IsAbs(spec) && isVendorSpecifier(spec) {
+		return resolveVendorModule(spec, fromDir)
+	}
 
 	base := spec
 	if !filepath.IsAbs(spec) {
@@ -271,9 +300,13 @@ func moduleIIFE(name string, body []Statement) Statement {
 func exportReturn(prog *Program, origin Token) Statement {
 	entries := make([]ObjectEntry, 0, len(prog.Exports)+1)
 	for _, name := range prog.Exports {
+		local := name
+		if aliased, ok := prog.ExportAliases[name]; ok {
+			local = aliased
+		}
 		entries = append(entries, ObjectEntry{
 			Key:   ident(name, origin),
-			Value: ident(name, origin),
+			Value: ident(local, origin),
 		})
 	}
 	if prog.HasDefault {
@@ -302,6 +335,16 @@ func memberBinding(local, modVar, prop string, origin Token) Statement {
 	}
 }
 
+// identBinding: const <local> = <modVar>;  (import * as local)
+func identBinding(local, modVar string, origin Token) Statement {
+	return &VarStatement{
+		Token:        constTok(origin),
+		Names:        []*Identifier{ident(local, origin)},
+		DestructMode: DestructNone,
+		Value:        ident(modVar, origin),
+	}
+}
+
 func ident(name string, origin Token) *Identifier {
 	return &Identifier{
 		Token: Token{
@@ -328,8 +371,8 @@ func tokenFrom(origin Token, kind Kind) Token {
 	}
 }
 
-// hasRelativeImports báo Program có ImportStatement (luôn là import tương đối, vì
-// import "kitwork" được hạ thẳng về VarStatement trong parser).
+// hasRelativeImports báo Program có ImportStatement (tương đối, app-shared hoặc
+// vendor — import "kitwork" được hạ thẳng về VarStatement trong parser).
 func hasRelativeImports(prog *Program) bool {
 	for _, s := range prog.Statements {
 		if _, ok := s.(*ImportStatement); ok {
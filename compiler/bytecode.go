@@ -1,6 +1,7 @@
 package compiler
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -13,15 +14,73 @@ func parseProgram(content string) (*Program, error) {
 }
 
 func parseProgramSource(content, source string) (*Program, error) {
-	l := NewLexerSource(content, source)
-	p := NewParser(l)
-	prog := p.ParseProgram()
-	if len(p.Errors()) > 0 {
-		return nil, fmt.Errorf("assemble error: %s", p.Errors()[0])
+	prog, failures := parseProgramErrors(content, source)
+	if len(failures) > 0 {
+		return nil, fmt.Errorf("assemble error: %w", failures[0])
 	}
 	return prog, nil
 }
 
+// parseProgramErrors parses source and returns EVERY parser error located in it
+// (the compile path stops at the first; the vendor checker reports them all).
+func parseProgramErrors(content, source string) (*Program, []*SourceError) {
+	p := NewParser(NewLexerSource(content, source))
+	prog := p.ParseProgram()
+	messages, positions := p.Errors(), p.ErrorPositions()
+	if len(messages) == 0 {
+		return prog, nil
+	}
+	failures := make([]*SourceError, len(messages))
+	for i, message := range messages {
+		failures[i] = &SourceError{File: source, Err: errors.New(message)}
+		if i < len(positions) {
+			failures[i].Line, failures[i].Column = locateOffset(content, positions[i])
+		}
+	}
+	return prog, failures
+}
+
+// SourceError locates a compile-time failure (syntax, dialect, module
+// resolution) in the file that caused it, so an error deep inside an imported
+// or vendored module names that module instead of the entry.
+type SourceError struct {
+	File   string
+	Line   int32
+	Column int32
+	Err    error
+}
+
+func (e *SourceError) Error() string {
+	switch {
+	case e.File == "":
+		return e.Err.Error()
+	case e.Line > 0:
+		return fmt.Sprintf("%s:%d:%d: %v", e.File, e.Line, e.Column, e.Err)
+	default:
+		return fmt.Sprintf("%s: %v", e.File, e.Err)
+	}
+}
+
+func (e *SourceError) Unwrap() error { return e.Err }
+
+// locateOffset converts a byte offset into a 1-based line and column.
+func locateOffset(content string, pos int32) (int32, int32) {
+	if pos < 0 {
+		pos = 0
+	}
+	if int(pos) > len(content) {
+		pos = int32(len(content))
+	}
+	line, start := int32(1), 0
+	for index := 0; index < int(pos); index++ {
+		if content[index] == '\n' {
+			line++
+			start = index + 1
+		}
+	}
+	return line, pos - int32(start) + 1
+}
+
 // Bytecode compiles a tenant entry file to bytecode. import/export are FULLY
 // native, no esbuild:
 //   - `import … from "kitwork"` / `import x from "kitwork/sub"` → kitwork() bindings
@@ -67,7 +126,7 @@ func prepareFile(paths ...string) (*Program, []string, map[string]string, error)
 	if hasRelativeImports(prog) {
 		var moduleFiles []string
 		var moduleSources map[string]string
-		prog, moduleFiles, moduleSources, err = nativeBundleWithSources(entryAbs, prog)
+		prog, moduleFiles, moduleSources, err = nativeBundleWithSources(entryAbs, prog, sources)
 		if err != nil {
 			return nil, nil, nil, err
 		}
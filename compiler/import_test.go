@@ -121,12 +121,48 @@ func TestRelativeAliasCarriesSpec(t *testing.T) {
 	}
 }
 
-// Kitwork has no npm/node_modules: a bare non-kitwork, non-relative specifier is
-// invalid and must be rejected (no esbuild to fall back to anymore).
-func TestBarePackageRejected(t *testing.T) {
-	_, p := parseSrc(`import pkg from "some-package";`)
-	if len(p.Errors()) == 0 {
-		t.Errorf("expected a parser error for bare non-kitwork package")
+// Kitwork has no npm/node_modules: a bare package specifier is a VENDORED module
+// (resolved against the tenant's vendor/ folder by the bundler), while schemes
+// such as node: or URLs stay invalid.
+func TestBareSpecifierTargetsVendor(t *testing.T) {
+	for _, src := range []string{
+		`import pkg from "some-package";`,
+		`import { isEmail } from "@acme/validators/email";`,
+		`import * as dates from "tiny-dates";`,
+	} {
+		prog, p := parseSrc(src)
+		if len(p.Errors()) > 0 {
+			t.Fatalf("%q: unexpected parser errors: %v", src, p.Errors())
+		}
+		if _, ok := prog.Statements[0].(*ImportStatement); !ok {
+			t.Fatalf("%q: expected *ImportStatement, got %T", src, prog.Statements[0])
+		}
+	}
+	for _, src := range []string{
+		`import fs from "node:fs";`,
+		`import x from "https://cdn.example.com/x.js";`,
+		`import x from "@scope";`,
+	} {
+		if _, p := parseSrc(src); len(p.Errors()) == 0 {
+			t.Errorf("%q: expected a parser error", src)
+		}
+	}
+}
+
+// `export { a as b }` exposes the local under the exported name; re-exports
+// from another module are a dialect error.
+func TestExportListAliases(t *testing.T) {
+	prog, p := parseSrc(`const a = 1; export { a as b, a };`)
+	if len(p.Errors()) > 0 {
+		t.Fatalf("unexpected errors: %v", p.Errors())
+	}
+	if len(prog.Exports) != 2 || prog.Exports[0] != "b" || prog.ExportAliases["b"] != "a" {
+		t.Fatalf("exports = %v aliases = %v", prog.Exports, prog.ExportAliases)
+	}
+	for _, src := range []string{`export { a } from "./x.js";`, `export * from "./x.js";`} {
+		if _, p := parseSrc(src); len(p.Errors()) == 0 {
+			t.Errorf("%q: expected a parser error", src)
+		}
 	}
 }
 
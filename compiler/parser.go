@@ -61,6 +61,8 @@ type (
 type Parser struct {
 	l      *Lexer
 	errors []string
+	// errorPositions[i] là offset nguồn của errors[i] — để lỗi cú pháp trỏ đúng file:dòng:cột.
+	errorPositions []int32
 
 	curToken  Token
 	peekToken Token
@@ -69,8 +71,9 @@ type Parser struct {
 	infixParseFns  map[Kind]infixParseFn
 
 	// Module metadata thu thập khi parse (cho bundler native ở package script).
-	exports    []string // tên export qua `export const/function` / `export { }`
-	hasDefault bool     // có `export default …` (đã hạ về const DefaultExportName)
+	exports       []string          // tên export qua `export const/function` / `export { }`
+	exportAliases map[string]string // tên export → binding cục bộ (`export { a as b }`)
+	hasDefault    bool              // có `export default …` (đã hạ về const DefaultExportName)
 }
 
 func NewParser(l *Lexer) *Parser {
@@ -156,6 +159,7 @@ func (p *Parser) ParseProgram() *Program {
 		p.nextToken()
 	}
 	program.Exports = p.exports
+	program.ExportAliases = p.exportAliases
 	program.HasDefault = p.hasDefault
 	return program
 }
@@ -280,6 +284,34 @@ func isAppSpecifier(s string) bool {
 	return strings.HasPrefix(s, "_")
 }
 
+// isVendorSpecifier báo specifier là BARE (tên gói, vd "dayjs", "@scope/pkg/sub"): bundler resolve nó
+// theo thư mục `vendor/` của tenant (import map vendor/importmap.json, rồi vendor/<gói>). Không có npm:
+// gói phải được chép sẵn vào vendor/ và đi qua cùng parser — nên cùng giới hạn ngôn ngữ như code tenant.
+// Scheme (`node:fs`, `https://…`) không phải gói vendor.
+func isVendorSpecifier(s string) bool {
+	if s == "" || isKitworkSpecifier(s) || isRelativeSpecifier(s) || isAppSpecifier(s) {
+		return false
+	}
+	if strings.ContainsAny(s, ":\\ ") || strings.HasPrefix(s, ".") {
+		return false
+	}
+	name := s
+	if strings.HasPrefix(s, "@") {
+		scope, rest, ok := strings.Cut(s, "/")
+		if !ok || len(scope) < 2 || rest == "" {
+			return false
+		}
+		name = rest
+	}
+	first, _, _ := strings.Cut(name, "/")
+	return first != "" && !strings.HasPrefix(first, ".")
+}
+
+// isModuleSpecifier báo specifier được bundler native giải quyết (ImportStatement).
+func isModuleSpecifier(s string) bool {
+	return isRelativeSpecifier(s) || isAppSpecifier(s) || isVendorSpecifier(s)
+}
+
 // hasAlias báo có ít nhất một binding đổi tên (`imported as local`).
 func hasAlias(specs []ImportSpec) bool {
 	for _, s := range specs {
@@ -367,7 +399,7 @@ func tokenWithValue(origin Token, kind Kind, item value.Value) Token {
 // specifier string. `from` is contextual (a normal identifier), not a keyword.
 func (p *Parser) parseFromSpecifier() (string, bool) {
 	if !p.peekTokenIs(Ident) || p.peekToken.Value.Text() != "from" {
-		p.errors = append(p.errors, "import: expected 'from'")
+		p.fail("import: expected 'from'")
 		return "", false
 	}
 	p.nextToken() // cur: from
@@ -385,10 +417,10 @@ func (p *Parser) parseImportStatement() Statement {
 	if p.peekTokenIs(String) {
 		p.nextToken() // cur: string
 		spec := p.curToken.Value.Text()
-		if isRelativeSpecifier(spec) || isAppSpecifier(spec) {
+		if isModuleSpecifier(spec) {
 			return &ImportStatement{Token: importTok, Source: spec, SideEffect: true}
 		}
-		p.errors = append(p.errors, fmt.Sprintf("native import: unsupported side-effect specifier %q", spec))
+		p.fail(fmt.Sprintf("native import: unsupported side-effect specifier %q", spec))
 		return nil
 	}
 
@@ -399,7 +431,7 @@ func (p *Parser) parseImportStatement() Statement {
 		for !p.peekTokenIs(RightBrace) {
 			p.nextToken() // cur: tên import
 			if !p.curTokenIs(Ident) {
-				p.errors = append(p.errors, "import: expected identifier inside { }")
+				p.fail("import: expected identifier inside { }")
 				return nil
 			}
 			imported := p.curToken.Value.Text()
@@ -416,7 +448,7 @@ func (p *Parser) parseImportStatement() Statement {
 			if p.peekTokenIs(Comma) {
 				p.nextToken()
 			} else if !p.peekTokenIs(RightBrace) {
-				p.errors = append(p.errors, "import: unexpected token in named import")
+				p.fail("import: unexpected token in named import")
 				return nil
 			}
 		}
@@ -451,10 +483,10 @@ func (p *Parser) parseImportStatement() Statement {
 			}
 			return &GroupStatement{Statements: stmts}
 		}
-		if isRelativeSpecifier(spec) || isAppSpecifier(spec) {
+		if isModuleSpecifier(spec) {
 			return &ImportStatement{Token: importTok, Names: specs, Source: spec}
 		}
-		p.errors = append(p.errors, fmt.Sprintf("native import: only 'kitwork', relative, or app-shared (_core/…) modules supported: %q", spec))
+		p.fail(fmt.Sprintf("native import: only 'kitwork', relative, app-shared (_core/…) or vendored package modules supported: %q", spec))
 		return nil
 	}
 
@@ -469,7 +501,7 @@ func (p *Parser) parseImportStatement() Statement {
 		if isKitworkSpecifier(spec) {
 			sub := kitworkSubpath(spec)
 			if sub == "" {
-				p.errors = append(p.errors, "native import: bare \"kitwork\" has no default export")
+				p.fail("native import: bare \"kitwork\" has no default export")
 				return nil
 			}
 			// → const name = kitwork().sub
@@ -487,14 +519,46 @@ func (p *Parser) parseImportStatement() Statement {
 				},
 			}
 		}
-		if isRelativeSpecifier(spec) {
+		if isModuleSpecifier(spec) {
 			return &ImportStatement{Token: importTok, Default: name, Source: spec}
 		}
-		p.errors = append(p.errors, fmt.Sprintf("native import: only 'kitwork' or relative modules supported: %q", spec))
+		p.fail(fmt.Sprintf("native import: only 'kitwork', relative, app-shared (_core/…) or vendored package modules supported: %q", spec))
+		return nil
+	}
+
+	// Namespace:  import * as ns from "..."
+	if p.peekTokenIs(Star) {
+		p.nextToken() // cur: *
+		if !p.peekTokenIs(Ident) || p.peekToken.Value.Text() != "as" {
+			p.fail("import: expected 'as' after '*'")
+			return nil
+		}
+		p.nextToken() // cur: as
+		if !p.expectPeek(Ident) {
+			return nil
+		}
+		name := &Identifier{Token: p.curToken, Value: p.curToken.Value.Text()}
+		spec, ok := p.parseFromSpecifier()
+		if !ok {
+			return nil
+		}
+		if isKitworkSpecifier(spec) {
+			// → const ns = kitwork()   (hoặc kitwork().<sub>)
+			return &VarStatement{
+				Token:        constToken(importTok),
+				Names:        []*Identifier{name},
+				DestructMode: DestructNone,
+				Value:        p.kitworkModule(spec, importTok),
+			}
+		}
+		if isModuleSpecifier(spec) {
+			return &ImportStatement{Token: importTok, Namespace: name, Source: spec}
+		}
+		p.fail(fmt.Sprintf("native import: unsupported namespace specifier %q", spec))
 		return nil
 	}
 
-	p.errors = append(p.errors, "import: unsupported form")
+	p.fail("import: unsupported form")
 	return nil
 }
 
@@ -536,19 +600,53 @@ func (p *Parser) parseExportStatement() Statement {
 		return p.parseFunctionStatement()
 	}
 
-	// export { a, b }  — re-export local đã khai báo: ghi nhận tên, không sinh lệnh
+	// export { a, b as c }  — export local đã khai báo: ghi nhận tên (và alias), không sinh lệnh
 	if p.peekTokenIs(LeftBrace) {
 		p.nextToken() // cur: {
-		for !p.curTokenIs(RightBrace) && !p.curTokenIs(EOF) {
-			if p.curTokenIs(Ident) {
-				p.exports = append(p.exports, p.curToken.Value.Text())
+		for !p.peekTokenIs(RightBrace) {
+			p.nextToken() // cur: tên local
+			if !p.curTokenIs(Ident) {
+				p.fail("export: expected identifier inside { }")
+				return nil
+			}
+			local := p.curToken.Value.Text()
+			exported := local
+			if p.peekTokenIs(Ident) && p.peekToken.Value.Text() == "as" {
+				p.nextToken() // cur: as
+				if !p.expectPeek(Ident) {
+					return nil
+				}
+				exported = p.curToken.Value.Text()
+			}
+			p.exports = append(p.exports, exported)
+			if exported != local {
+				if p.exportAliases == nil {
+					p.exportAliases = map[string]string{}
+				}
+				p.exportAliases[exported] = local
+			}
+			if p.peekTokenIs(Comma) {
+				p.nextToken()
+			} else if !p.peekTokenIs(RightBrace) {
+				p.fail("export: unexpected token in export list")
+				return nil
 			}
-			p.nextToken()
 		}
+		p.nextToken() // cur: }
+		if p.peekTokenIs(Ident) && p.peekToken.Value.Text() == "from" {
+			p.fail("export: re-export `export { … } from` is not supported; import the names, then export them")
+			return nil
+		}
+		return nil
+	}
+
+	if p.peekTokenIs(Star) {
+		p.nextToken() // cur: *
+		p.fail("export: `export * from` is not supported; import the names, then export them")
 		return nil
 	}
 
-	p.errors = append(p.errors, "export: unsupported form")
+	p.fail("export: unsupported form")
 	return nil
 }
 
@@ -1207,12 +1305,24 @@ func (p *Parser) curPrecedence() int {
 func (p *Parser) registerPrefix(k Kind, fn prefixParseFn) { p.prefixParseFns[k] = fn }
 func (p *Parser) registerInfix(k Kind, fn infixParseFn)   { p.infixParseFns[k] = fn }
 func (p *Parser) addError(msg string) {
-	p.errors = append(p.errors, fmt.Sprintf("%s (at pos %d: %q)", msg, p.curToken.Position, p.curToken.String()))
+	p.fail(fmt.Sprintf("%s (at pos %d: %q)", msg, p.curToken.Position, p.curToken.String()))
+}
+
+// fail ghi một lỗi tại token hiện tại.
+func (p *Parser) fail(msg string) {
+	p.errors = append(p.errors, msg)
+	p.errorPositions = append(p.errorPositions, p.curToken.Position)
 }
+
 func (p *Parser) Errors() []string {
 	return p.errors
 }
 
+// ErrorPositions trả offset nguồn của từng lỗi trong Errors(), cùng thứ tự.
+func (p *Parser) ErrorPositions() []int32 {
+	return p.errorPositions
+}
+
 func (p *Parser) parseFunctionStatement() Statement {
 	tok := p.curToken
 
@@ -0,0 +1,302 @@
+package compiler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// VendorDir is the tenant folder bare import specifiers resolve against, and
+// ImportMapFile the optional import map inside it:
+//
+//	vendor/importmap.json   {"imports": {"dayjs": "./dayjs/esm/index.js", "valid/": "./validator/lib/"}}
+//
+// There is no npm at runtime: a third-party ESM package is copied into vendor/
+// once, checked with CheckVendor, and committed. Its files go through the same
+// parser as tenant code, so the Kitwork dialect applies to them unchanged.
+const (
+	VendorDir     = "vendor"
+	ImportMapFile = "importmap.json"
+)
+
+type importMap struct {
+	Imports map[string]string `json:"imports"`
+}
+
+// resolveVendorModule resolves a bare specifier by walking UP from the
+// importing file's directory to each vendor/ folder in turn — like `_core`, a
+// domain's own vendor/ wins and the identity-level one is shared. Inside one
+// vendor/ folder the import map is consulted first (exact key, then the
+// longest "prefix/" key), then vendor/<specifier> as a file, with .js/.mjs,
+// or as a package folder (package.json "module"/"main", then index.js).
+func resolveVendorModule(spec, fromDir string) (string, error) {
+	dir := fromDir
+	for {
+		vendor := filepath.Join(dir, VendorDir)
+		if info, err := os.Stat(vendor); err == nil && info.IsDir() {
+			resolved, ok, err := resolveInVendor(spec, vendor)
+			if err != nil {
+				return "", err
+			}
+			if ok {
+				return resolved, nil
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return "", fmt.Errorf("native bundle: package %q not found in any %s/ folder above %s", spec, VendorDir, fromDir)
+}
+
+func resolveInVendor(spec, vendor string) (string, bool, error) {
+	imports, err := loadImportMap(vendor)
+	if err != nil {
+		return "", false, err
+	}
+	if target, ok := mapSpecifier(imports, spec); ok {
+		base, err := vendorPath(vendor, target)
+		if err != nil {
+			return "", false, fmt.Errorf("native bundle: %s maps %q: %w", filepath.Join(vendor, ImportMapFile), spec, err)
+		}
+		resolved, ok := statVendorModule(base)
+		if !ok {
+			return "", false, fmt.Errorf("native bundle: %s maps %q to %s, which does not exist", filepath.Join(vendor, ImportMapFile), spec, target)
+		}
+		return resolved, true, nil
+	}
+
+	base, err := vendorPath(vendor, spec)
+	if err != nil {
+		return "", false, fmt.Errorf("native bundle: package %q: %w", spec, err)
+	}
+	if resolved, ok := statVendorModule(base); ok {
+		return resolved, true, nil
+	}
+	return "", false, nil
+}
+
+func loadImportMap(vendor string) (map[string]string, error) {
+	filename := filepath.Join(vendor, ImportMapFile)
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var parsed importMap
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("native bundle: %s: %w", filename, err)
+	}
+	return parsed.Imports, nil
+}
+
+// mapSpecifier applies import-map matching: an exact key, else the longest key
+// ending in "/" that prefixes the specifier (its target must end in "/" too).
+func mapSpecifier(imports map[string]string, spec string) (string, bool) {
+	if target, ok := imports[spec]; ok {
+		return target, true
+	}
+	best := ""
+	for key := range imports {
+		if strings.HasSuffix(key, "/") && strings.HasPrefix(spec, key) && len(key) > len(best) {
+			best = key
+		}
+	}
+	if best == "" || !strings.HasSuffix(imports[best], "/") {
+		return "", false
+	}
+	return imports[best] + strings.TrimPrefix(spec, best), true
+}
+
+// vendorPath joins a vendor-relative target and refuses anything that escapes
+// the vendor folder — an import map is committed code, not a path oracle.
+func vendorPath(vendor, target string) (string, error) {
+	if filepath.IsAbs(target) || strings.Contains(target, ":") {
+		return "", fmt.Errorf("target %q must be relative to %s/", target, VendorDir)
+	}
+	joined := filepath.Join(vendor, filepath.FromSlash(target))
+	relative, err := filepath.Rel(vendor, joined)
+	if err != nil || relative == ".." || strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("target %q escapes %s/", target, VendorDir)
+	}
+	return joined, nil
+}
+
+// statVendorModule finds the module file for a vendor path: the file itself,
+// then the .js/.mjs extensions, then (for a package folder) its package.json
+// entry and index file.
+func statVendorModule(base string) (string, bool) {
+	for _, candidate := range []string{base, base + ".js", base + ".mjs"} {
+		if isFile(candidate) {
+			return filepath.Clean(candidate), true
+		}
+	}
+	if !isDirectory(base) {
+		return "", false
+	}
+	if entry, ok := packageEntry(base); ok {
+		return entry, true
+	}
+	for _, candidate := range []string{filepath.Join(base, "index.js"), filepath.Join(base, "index.mjs")} {
+		if isFile(candidate) {
+			return filepath.Clean(candidate), true
+		}
+	}
+	return "", false
+}
+
+// packageEntry reads the ESM entry of a vendored package root from its
+// package.json ("module" before "main").
+func packageEntry(root string) (string, bool) {
+	data, err := os.ReadFile(filepath.Join(root, "package.json"))
+	if err != nil {
+		return "", false
+	}
+	var manifest struct {
+		Module string `json:"module"`
+		Main   string `json:"main"`
+	}
+	if json.Unmarshal(data, &manifest) != nil {
+		return "", false
+	}
+	for _, entry := range []string{manifest.Module, manifest.Main} {
+		if entry == "" {
+			continue
+		}
+		base, err := vendorPath(root, entry)
+		if err != nil {
+			continue
+		}
+		for _, candidate := range []string{base, base + ".js", base + ".mjs"} {
+			if isFile(candidate) {
+				return filepath.Clean(candidate), true
+			}
+		}
+	}
+	return "", false
+}
+
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+func isDirectory(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// VendorReport is the result of CheckVendor.
+type VendorReport struct {
+	Files  []string       // module files checked, relative to the package's parent folder
+	Issues []*SourceError // every construct or import the engine would refuse
+}
+
+// CheckVendor audits a vendored package folder before it is committed: every
+// .js/.mjs module is parsed with the tenant dialect (reporting ALL errors, not
+// the first), compiled, and each of its imports resolved the way the bundler
+// will. Issues carry file:line:column relative to the folder above dir, so
+// `vendor/dayjs` reports `dayjs/esm/index.js:12:3: …`. A clean report means
+// the package compiles when imported; the returned error is for I/O only.
+func CheckVendor(dir string) (VendorReport, error) {
+	var report VendorReport
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return report, err
+	}
+	base := filepath.Dir(root)
+	walkErr := filepath.WalkDir(root, func(filename string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		name := entry.Name()
+		if entry.IsDir() {
+			if filename != root && (strings.HasPrefix(name, ".") || name == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ext := filepath.Ext(name); ext != ".js" && ext != ".mjs" {
+			return nil
+		}
+		relative, err := filepath.Rel(base, filename)
+		if err != nil {
+			return err
+		}
+		relative = filepath.ToSlash(relative)
+		issues, err := checkVendorModule(filename, relative)
+		if err != nil {
+			return err
+		}
+		report.Files = append(report.Files, relative)
+		report.Issues = append(report.Issues, issues...)
+		return nil
+	})
+	if walkErr != nil {
+		return report, walkErr
+	}
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		a, b := report.Issues[i], report.Issues[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return report, nil
+}
+
+func checkVendorModule(filename, name string) ([]*SourceError, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	content := string(data)
+	prog, failures := parseProgramErrors(content, name)
+	if len(failures) > 0 {
+		return failures, nil
+	}
+
+	var issues []*SourceError
+	body := make([]Statement, 0, len(prog.Statements))
+	for _, statement := range prog.Statements {
+		imp, ok := statement.(*ImportStatement)
+		if !ok {
+			body = append(body, statement)
+			continue
+		}
+		if _, err := resolveModulePath(imp.Source, filepath.Dir(filename)); err != nil {
+			line, column := locateOffset(content, imp.Token.Position)
+			issues = append(issues, &SourceError{File: name, Line: line, Column: column, Err: err})
+		}
+	}
+	// Imports resolve (or were reported) above; the module body alone must compile.
+	c := newCompilerWithSources(map[string]string{name: content})
+	if err := c.Compile(&Program{Statements: body}); err != nil {
+		issues = append(issues, compileIssue(name, content, c, err))
+	}
+	return issues, nil
+}
+
+func compileIssue(name, content string, c *Compiler, err error) *SourceError {
+	var located *SourceError
+	if errors.As(err, &located) {
+		return located
+	}
+	issue := &SourceError{File: name, Err: err}
+	if c.currentSource == name {
+		issue.Line, issue.Column = locateOffset(content, c.currentPos)
+	}
+	return issue
+}
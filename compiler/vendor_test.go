@@ -0,0 +1,114 @@
+package compiler
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kitwork/engine/runtime"
+)
+
+func TestBareImportsResolveAgainstVendor(t *testing.T) {
+	entry := writeTenant(t, map[string]string{
+		"app.kitwork.js": `import { shout } from "shouty";
+import pad from "tiny-pad";
+import * as math from "@acme/math/sum";
+import { double } from "helpers/double";
+const result = shout(pad("a", 3)) + ":" + math.sum(2, 3) + ":" + double(4);`,
+		"vendor/importmap.json": `{"imports": {"shouty": "./shouty/src/main.mjs", "helpers/": "./acme-helpers/lib/"}}`,
+		"vendor/shouty/src/main.mjs": `import { upper } from "./upper.js";
+const shout = (text) => upper(text) + "!";
+export { shout };`,
+		"vendor/shouty/src/upper.js": `const up = (text) => text.toUpperCase();
+export { up as upper };`,
+		"vendor/tiny-pad/package.json": `{"name": "tiny-pad", "module": "esm/index.js", "main": "cjs/index.js"}`,
+		"vendor/tiny-pad/esm/index.js": `export default function (text, width) { let out = text; for (let i = out.length; i < width; i++) { out = out + "-"; } return out; }`,
+		"vendor/@acme/math/sum.js":     `export const sum = (a, b) => a + b;`,
+		"vendor/acme-helpers/lib/double.js": `import { sum } from "@acme/math/sum";
+export const double = (n) => sum(n, n);`,
+	})
+
+	bytecode, err := CompileFile(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vm := runtime.New(bytecode.Program)
+	vm.Run()
+	if got := vm.Vars["result"].Text(); got != "A--!:5:8" {
+		t.Fatalf("result = %q", got)
+	}
+	watched := strings.Join(bytecode.Files, "\n")
+	for _, want := range []string{"main.mjs", "upper.js", filepath.Join("esm", "index.js"), "sum.js", "double.js"} {
+		if !strings.Contains(watched, want) {
+			t.Fatalf("bytecode files %v miss %s", bytecode.Files, want)
+		}
+	}
+}
+
+func TestVendorErrorsNameTheVendoredFile(t *testing.T) {
+	entry := writeTenant(t, map[string]string{
+		"app.kitwork.js": `import { parse } from "dates";
+const x = parse("2024");`,
+		"vendor/dates/index.js": `export const parse = (text) => text;
+class Calendar {}`,
+	})
+	_, err := CompileFile(entry)
+	var located *SourceError
+	if !errors.As(err, &located) {
+		t.Fatalf("error %v carries no location", err)
+	}
+	if located.File != "vendor/dates/index.js" || located.Line != 2 || located.Column != 1 {
+		t.Fatalf("location = %s:%d:%d", located.File, located.Line, located.Column)
+	}
+
+	entry = writeTenant(t, map[string]string{
+		"app.kitwork.js": "const a = 1;\nimport { parse } from \"missing-pkg\";",
+		"vendor/.keep":   "",
+	})
+	_, err = CompileFile(entry)
+	if !errors.As(err, &located) || located.File != "app.kitwork.js" || located.Line != 2 {
+		t.Fatalf("unresolved package error = %v", err)
+	}
+}
+
+func TestImportMapCannotEscapeVendor(t *testing.T) {
+	entry := writeTenant(t, map[string]string{
+		"app.kitwork.js":        `import { secret } from "outside";`,
+		"vendor/importmap.json": `{"imports": {"outside": "../lib/secret.js"}}`,
+		"lib/secret.js":         `export const secret = 1;`,
+	})
+	if _, err := CompileFile(entry); err == nil || !strings.Contains(err.Error(), "escapes") {
+		t.Fatalf("escaping import map error = %v", err)
+	}
+}
+
+func TestCheckVendorReportsEveryIssueWithLocation(t *testing.T) {
+	entry := writeTenant(t, map[string]string{
+		"vendor/risky/index.js": `export const ok = (a) => a;
+export { helper } from "./helper.js";`,
+		"vendor/risky/helper.js": `export const helper = () => 1;
+const re = /x+/g;`,
+		"vendor/risky/lib/clean.js": `import { ok } from "../index.js";
+import { gone } from "not-vendored";
+export const twice = (a) => ok(a) + ok(a);`,
+		"vendor/risky/README.md": "not a module",
+	})
+
+	report, err := CheckVendor(filepath.Join(filepath.Dir(entry), "vendor", "risky"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Files) != 3 {
+		t.Fatalf("checked files = %v", report.Files)
+	}
+	var got []string
+	for _, issue := range report.Issues {
+		got = append(got, fmt.Sprintf("%s:%d", issue.File, issue.Line))
+	}
+	want := []string{"risky/helper.js:2", "risky/helper.js:2", "risky/index.js:2", "risky/lib/clean.js:2"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("issues = %v\n%v", got, report.Issues)
+	}
+}
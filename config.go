@@ -3,6 +3,7 @@ package engine
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/kitwork/engine/database"
@@ -20,11 +21,17 @@ type Config struct {
 	HotReload        bool              `json:"hot_reload" yaml:"hot_reload"`
 	BytecodeCache    bool              `json:"bytecode_cache" yaml:"bytecode_cache"`
 	BytecodeCacheDir string            `json:"bytecode_cache_dir" yaml:"bytecode_cache_dir"`
+	Bundles          string            `json:"bundles" yaml:"bundles"`       // dir of signed .kwpkg packages; "" = compile source
+	BundleKey        string            `json:"bundle_key" yaml:"bundle_key"` // hex ed25519 public key the packages must verify against
 	Hostname         string            `json:"hostname" yaml:"hostname"`
 	AllowLocal       bool              `json:"allow_local" yaml:"allow_local"`
 	TrustProxy       bool              `json:"trust_proxy" yaml:"trust_proxy"` // trust X-Forwarded-For — ONLY behind your own proxy
 	Logger           logger.Config     `json:"logger" yaml:"logger"`
 	RateLimit        *RateLimitConfig  `json:"rate_limit" yaml:"rate_limit"` // host-level limits; nil = off
+	Tracing          *TracingConfig    `json:"tracing" yaml:"tracing"`       // span export; nil = off
+	Metrics          *MetricsConfig    `json:"metrics" yaml:"metrics"`       // OpenMetrics listener; nil = off
+	AccessLog        *AccessLogConfig  `json:"access_log" yaml:"access_log"` // per-site access logs; nil = off
+	Admin            *AdminConfig      `json:"admin" yaml:"admin"`           // operator API listener; nil = off
 }
 
 // RateLimitConfig is the HOST-level (server-wide) rate-limit block — the first gate every request
@@ -42,6 +49,72 @@ type RateLimitConfig struct {
 	Period  time.Duration `json:"-" yaml:"-"`
 }
 
+// TracingConfig turns on W3C trace propagation and span export (OTLP/JSON). From server.kitwork.js:
+//
+//	.tracing({ endpoint: "http://collector:4318", sample: 0.1, sites: { "shop.example.com": 1 } })
+//
+// or the YAML `tracing:` block with the same keys. endpoint posts to an OTLP/HTTP collector (a bare
+// address gets /v1/traces); file appends one OTLP/JSON document per line instead. sample is the
+// share of new traces recorded (default 1); sites overrides it per domain.
+type TracingConfig struct {
+	Endpoint string             `json:"endpoint" yaml:"endpoint"`
+	File     string             `json:"file" yaml:"file"`
+	Headers  map[string]string  `json:"headers" yaml:"headers"` // sent with every export (an API key)
+	Service  string             `json:"service" yaml:"service"` // service.name; default "kitwork"
+	Sample   float64            `json:"sample" yaml:"sample"`
+	Sites    map[string]float64 `json:"sites" yaml:"sites"`
+}
+
+// MetricsConfig serves the engine's metrics (OpenMetrics, for Prometheus) on a listener of its own,
+// never the public one. From server.kitwork.js:
+//
+//	.metrics({ listen: "127.0.0.1:9464", path: "/metrics", tenants: 200 })
+//
+// or the YAML `metrics:` block with the same keys. path defaults to /metrics; tenants caps the
+// per-tenant label values (default 200), the rest are reported as "_other".
+type MetricsConfig struct {
+	Listen  string `json:"listen" yaml:"listen"`
+	Path    string `json:"path" yaml:"path"`
+	Tenants int    `json:"tenants" yaml:"tenants"`
+}
+
+// AccessLogConfig writes one line per request to each site's <site>/.kitwork/logs/access.log.
+// From server.kitwork.js:
+//
+//	.accessLog({ format: "json", max_size: 100, redact: ["session*"], headers: ["X-Request-Id"] })
+//
+// or the YAML `access_log:` block with the same keys. format is "json" (default) or "combined";
+// max_size (MB), max_backups and max_age (days) rotate like the engine log. redact names extra
+// query parameters and headers to mask (`*` wildcards) on top of the built-in secrets; headers
+// are request headers recorded in JSON lines.
+type AccessLogConfig struct {
+	Format     string   `json:"format" yaml:"format"`
+	MaxSize    int      `json:"max_size" yaml:"max_size"`
+	MaxBackups int      `json:"max_backups" yaml:"max_backups"`
+	MaxAge     int      `json:"max_age" yaml:"max_age"`
+	Compress   bool     `json:"compress" yaml:"compress"`
+	Redact     []string `json:"redact" yaml:"redact"`
+	Headers    []string `json:"headers" yaml:"headers"`
+}
+
+// AdminConfig serves the operator API (reload, drain, evict, crons, queues, caches, check) on a
+// listener of its own. From server.kitwork.js:
+//
+//	.admin({ listen: "127.0.0.1:9465", token: "${KITWORK_ADMIN_TOKEN}" })
+//
+// or the YAML `admin:` block with the same keys. listen is host:port, or unix:/path for a socket
+// only the host's user can open. token is the bearer token callers present. tls_cert and tls_key
+// serve TLS; client_ca then also admits clients holding a certificate it signed (mTLS). audit is
+// the file every call is logged to, default <root>/.kitwork/logs/admin.log.
+type AdminConfig struct {
+	Listen   string `json:"listen" yaml:"listen"`
+	Token    string `json:"token" yaml:"token"`
+	TLSCert  string `json:"tls_cert" yaml:"tls_cert"`
+	TLSKey   string `json:"tls_key" yaml:"tls_key"`
+	ClientCA string `json:"client_ca" yaml:"client_ca"`
+	Audit    string `json:"audit" yaml:"audit"`
+}
+
 func ParseConfig(raw map[string]interface{}) (*Config, error) {
 	cfg := &Config{
 		Port:      8080,
@@ -82,6 +155,16 @@ func ParseConfig(raw map[string]interface{}) (*Config, error) {
 			cfg.BytecodeCacheDir = directory
 		}
 	}
+	if val, ok := raw["bundles"]; ok {
+		if directory, ok := val.(string); ok {
+			cfg.Bundles = directory
+		}
+	}
+	if val, ok := raw["bundle_key"]; ok {
+		if key, ok := val.(string); ok {
+			cfg.BundleKey = key
+		}
+	}
 	if val, ok := raw["hostname"]; ok {
 		if s, ok := val.(string); ok {
 			cfg.Hostname = s
@@ -190,6 +273,99 @@ func ParseConfig(raw map[string]interface{}) (*Config, error) {
 		}
 	}
 
+	// Tracing: { endpoint | file, headers, service, sample, sites }.
+	if val, ok := raw["tracing"]; ok {
+		if m, ok := val.(map[string]interface{}); ok {
+			tc := &TracingConfig{Sample: coerceFloat(m["sample"], 1)}
+			tc.Endpoint, _ = m["endpoint"].(string)
+			tc.File, _ = m["file"].(string)
+			tc.Service, _ = m["service"].(string)
+			if headers, ok := m["headers"].(map[string]interface{}); ok {
+				tc.Headers = make(map[string]string, len(headers))
+				for k, v := range headers {
+					if s, ok := v.(string); ok {
+						tc.Headers[k] = s
+					}
+				}
+			}
+			if sites, ok := m["sites"].(map[string]interface{}); ok {
+				tc.Sites = make(map[string]float64, len(sites))
+				for site, rate := range sites {
+					tc.Sites[site] = coerceFloat(rate, 1)
+				}
+			}
+			if tc.Endpoint == "" && tc.File == "" {
+				return nil, fmt.Errorf("tracing: set endpoint or file")
+			}
+			cfg.Tracing = tc
+		}
+	}
+
+	// Metrics: { listen, path, tenants }.
+	if val, ok := raw["metrics"]; ok {
+		if m, ok := val.(map[string]interface{}); ok {
+			mc := &MetricsConfig{Path: "/metrics", Tenants: coerceInt(m["tenants"], 200)}
+			mc.Listen, _ = m["listen"].(string)
+			if path, ok := m["path"].(string); ok && path != "" {
+				mc.Path = "/" + strings.TrimPrefix(path, "/")
+			}
+			if mc.Listen == "" {
+				return nil, fmt.Errorf("metrics: set listen (host:port of the private listener)")
+			}
+			cfg.Metrics = mc
+		}
+	}
+
+	// Access logs: { format, max_size, max_backups, max_age, compress, redact, headers }.
+	if val, ok := raw["access_log"]; ok {
+		if m, ok := val.(map[string]interface{}); ok {
+			ac := &AccessLogConfig{
+				Format:     "json",
+				MaxSize:    coerceInt(m["max_size"], 0),
+				MaxBackups: coerceInt(m["max_backups"], 0),
+				MaxAge:     coerceInt(m["max_age"], 0),
+			}
+			if format, ok := m["format"].(string); ok && format != "" {
+				ac.Format = strings.ToLower(format)
+			}
+			if ac.Format != "json" && ac.Format != "combined" {
+				return nil, fmt.Errorf("access_log: format must be json or combined, got %q", ac.Format)
+			}
+			ac.Compress, _ = m["compress"].(bool)
+			if redact, ok := m["redact"]; ok {
+				ac.Redact = coerceStringSlice(redact)
+			}
+			if headers, ok := m["headers"]; ok {
+				ac.Headers = coerceStringSlice(headers)
+			}
+			cfg.AccessLog = ac
+		}
+	}
+
+	// Admin API: { listen, token, tls_cert, tls_key, client_ca, audit }.
+	if val, ok := raw["admin"]; ok {
+		if m, ok := val.(map[string]interface{}); ok {
+			ac := &AdminConfig{}
+			ac.Listen, _ = m["listen"].(string)
+			ac.Token, _ = m["token"].(string)
+			ac.TLSCert, _ = m["tls_cert"].(string)
+			ac.TLSKey, _ = m["tls_key"].(string)
+			ac.ClientCA, _ = m["client_ca"].(string)
+			ac.Audit, _ = m["audit"].(string)
+			switch {
+			case ac.Listen == "":
+				return nil, fmt.Errorf("admin: set listen (host:port or unix:/path of the private listener)")
+			case ac.Token == "" && ac.ClientCA == "":
+				return nil, fmt.Errorf("admin: set token, client_ca, or both")
+			case (ac.TLSCert == "") != (ac.TLSKey == ""):
+				return nil, fmt.Errorf("admin: tls_cert and tls_key go together")
+			case ac.ClientCA != "" && ac.TLSCert == "":
+				return nil, fmt.Errorf("admin: client_ca needs tls_cert and tls_key")
+			}
+			cfg.Admin = ac
+		}
+	}
+
 	// Dynamic database/databases mapping
 	var rawDB interface{}
 	if val, ok := raw["database"]; ok {
@@ -232,6 +408,18 @@ func coerceInt(val interface{}, def int) int {
 	return def
 }
 
+func coerceFloat(val interface{}, def float64) float64 {
+	switch v := val.(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	}
+	return def
+}
+
 func coerceUint64(val interface{}, def uint64) uint64 {
 	switch v := val.(type) {
 	case uint64:
@@ -118,6 +118,8 @@ func (b *ServerBuilder) Web(args ...value.Value) *ServerBuilder {
 			b.config["bytecode_cache"] = val
 		case "bytecodeCacheDir":
 			b.config["bytecode_cache_dir"] = val
+		case "bundleKey":
+			b.config["bundle_key"] = val
 		case "domain":
 			b.config["domains"] = val
 		default:
@@ -186,6 +188,32 @@ func (b *ServerBuilder) RateLimit(v value.Value) *ServerBuilder {
 	return b
 }
 
+// Tracing exports request spans: .tracing({ endpoint | file, sample, sites, headers, service }).
+func (b *ServerBuilder) Tracing(v value.Value) *ServerBuilder {
+	b.config["tracing"] = v
+	return b
+}
+
+// Metrics serves OpenMetrics on a private listener: .metrics({ listen, path, tenants }).
+func (b *ServerBuilder) Metrics(v value.Value) *ServerBuilder {
+	b.config["metrics"] = v
+	return b
+}
+
+// AccessLog writes per-site access logs:
+// .accessLog({ format, max_size, max_backups, max_age, compress, redact, headers }).
+func (b *ServerBuilder) AccessLog(v value.Value) *ServerBuilder {
+	b.config["access_log"] = v
+	return b
+}
+
+// Admin serves the operator API on a private listener:
+// .admin({ listen, token, tls_cert, tls_key, client_ca, audit }).
+func (b *ServerBuilder) Admin(v value.Value) *ServerBuilder {
+	b.config["admin"] = v
+	return b
+}
+
 // TrustProxy: believe X-Forwarded-For/X-Real-IP for the client IP. Enable ONLY when Kitwork runs
 // behind a reverse proxy you control — as the edge server those headers are client-spoofable.
 func (b *ServerBuilder) TrustProxy(v value.Value) *ServerBuilder {
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/kitwork/engine/utilities/accesslog"
+	"github.com/kitwork/engine/work"
+)
+
+// SetAccessLog turns on per-site access logs: every request a site answers is written to
+// <site>/.kitwork/logs/access.log, rotated by opts. Call it ONCE at boot, before serving; sites
+// already loaded keep logging as they were. Close closes the files.
+func (e *Engine) SetAccessLog(opts accesslog.Options) {
+	e.mu.Lock()
+	e.accessLogOptions = &opts
+	e.mu.Unlock()
+}
+
+// accessLogLocked returns the site's logger, opening it on first use. The caller holds e.mu for
+// writing.
+func (e *Engine) accessLogLocked(tenant *work.Tenant) *accesslog.Logger {
+	if e.accessLogOptions == nil {
+		return nil
+	}
+	domain := tenant.Domain()
+	if current := e.accessLogs[domain]; current != nil {
+		return current
+	}
+	current := accesslog.New(tenant.ResolvePath(".kitwork", "logs", "access.log"), *e.accessLogOptions)
+	e.accessLogs[domain] = current
+	return current
+}
+
+// AccessLog is the access log of a site, loading the site if needed.
+func (e *Engine) AccessLog(domain string) (*accesslog.Logger, error) {
+	tenant, err := e.run(domain)
+	if err != nil {
+		return nil, err
+	}
+	if tenant.AccessLog() == nil {
+		return nil, fmt.Errorf("access logging is off")
+	}
+	return tenant.AccessLog(), nil
+}
+
+// closeAccessLogs closes every site's access log.
+func closeAccessLogs(logs map[string]*accesslog.Logger) {
+	for domain, log := range logs {
+		if err := log.Close(); err != nil {
+			slog.Warn("Access log close failed", "hostname", domain, "path", log.Path(), "error", err)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kitwork/engine/utilities/accesslog"
+)
+
+// Each request is logged to the site's .kitwork/logs/access.log with its route, the energy it
+// spent and, once cached, the tier that answered; secrets in the query never reach the file.
+func TestEngineWritesSiteAccessLog(t *testing.T) {
+	tmpDir := t.TempDir()
+	site := filepath.Join(tmpDir, "test", "localhost")
+	writeTreeTenant(t, tmpDir, "home")
+	if err := os.MkdirAll(filepath.Join(site, "posts", "{slug}"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	code := "import { router } from \"kitwork\";\nrouter.get((ctx) => ctx.text(\"post\")).cache(\"1h\");\n"
+	if err := os.WriteFile(filepath.Join(site, "posts", "{slug}", "router.kitwork.js"), []byte(code), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	engine := New(tmpDir, 0, false, "")
+	engine.SetAccessLog(accesslog.Options{})
+	defer engine.Close()
+
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://localhost/posts/hello?token=abc&page=2", nil))
+		if rec.Code != http.StatusOK || rec.Body.String() != "post" {
+			t.Fatalf("request %d: %d %q", i, rec.Code, rec.Body.String())
+		}
+	}
+
+	log, err := engine.AccessLog("localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(site, ".kitwork", "logs", "access.log"); log.Path() != want {
+		t.Fatalf("log path %s, want %s", log.Path(), want)
+	}
+	entries, err := log.Query(accesslog.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("entries = %+v", entries)
+	}
+	hit, miss := entries[0], entries[1]
+	if miss.Route != "/posts/{slug}" || miss.Status != 200 || miss.Bytes != 4 || miss.Tenant != "localhost" {
+		t.Fatalf("first request logged as %+v", miss)
+	}
+	if miss.Energy == 0 || miss.Cache != "" {
+		t.Fatalf("first request energy %d cache %q", miss.Energy, miss.Cache)
+	}
+	if hit.Cache != "ram" || hit.Energy != 0 {
+		t.Fatalf("cached request energy %d cache %q", hit.Energy, hit.Cache)
+	}
+	if miss.Query != "token=[REDACTED]&page=2" || miss.ClientIP != "192.0.2.1" {
+		t.Fatalf("query %q ip %q", miss.Query, miss.ClientIP)
+	}
+}
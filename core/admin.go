@@ -0,0 +1,242 @@
+package core
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AdminOptions configure AdminHandler.
+type AdminOptions struct {
+	// Token is the bearer token a caller must present. Empty accepts only callers with a verified
+	// client certificate, which needs a TLS listener that requests one (mTLS).
+	Token string
+	// Audit receives one JSON line per call, denied ones included; nil audits nothing.
+	Audit io.Writer
+}
+
+// AdminEntry is one audit line.
+type AdminEntry struct {
+	Time     time.Time `json:"time"`
+	Actor    string    `json:"actor"` // "token", "cert:<common name>", or "" when refused
+	Remote   string    `json:"remote"`
+	Method   string    `json:"method"`
+	Path     string    `json:"path"`
+	Query    string    `json:"query,omitempty"`
+	Action   string    `json:"action,omitempty"` // the route pattern that answered
+	Site     string    `json:"site,omitempty"`
+	Status   int       `json:"status"`
+	Error    string    `json:"error,omitempty"`
+	Duration float64   `json:"duration_ms"`
+}
+
+// AdminHandler is the engine's operator API, a JSON API over the methods in operate.go and
+// canary.go. Mount it on a private listener of its own — never the public one:
+//
+//	GET  /v1/health                          host and per-tenant health
+//	GET  /v1/sites                           loaded sites and their generations
+//	GET  /v1/sites/{domain}                  one site's generations
+//	POST /v1/sites/{domain}/reload           build a generation from disk (Deploy)
+//	POST /v1/sites/{domain}/promote          make the canary candidate current
+//	POST /v1/sites/{domain}/rollback         drop the candidate, or go back a generation
+//	POST /v1/sites/{domain}/drain            retire the candidate and retained generations
+//	POST /v1/sites/{domain}/evict            unload the site
+//	GET  /v1/sites/{domain}/health           the site's app health
+//	GET  /v1/sites/{domain}/crons            the app's crons
+//	POST /v1/sites/{domain}/crons/{name}/pause | resume | trigger
+//	GET  /v1/sites/{domain}/jobs?queue=&status=&limit=
+//	POST /v1/sites/{domain}/jobs/retry?queue=&id=
+//	POST /v1/sites/{domain}/cache/flush      drop cached route responses
+//	POST /v1/sites/{domain}/check            Check the site's sources on disk
+//
+// Every call is authenticated (AdminOptions.Token or a verified client certificate) and audited.
+func (e *Engine) AdminHandler(opts AdminOptions) http.Handler {
+	mux := http.NewServeMux()
+	site := func(r *http.Request) string { return r.PathValue("domain") }
+
+	mux.HandleFunc("GET /v1/health", func(w http.ResponseWriter, r *http.Request) {
+		adminJSON(w, http.StatusOK, map[string]any{
+			"host":    e.Health(),
+			"tenants": e.runtimeHealth.Tenants(),
+		})
+	})
+	mux.HandleFunc("GET /v1/sites", func(w http.ResponseWriter, r *http.Request) {
+		adminJSON(w, http.StatusOK, e.Sites())
+	})
+	mux.HandleFunc("GET /v1/sites/{domain}", func(w http.ResponseWriter, r *http.Request) {
+		releases, err := e.Releases(site(r))
+		adminResult(w, map[string]any{"releases": releases}, err)
+	})
+	for action, run := range map[string]func(string) (Release, error){
+		"reload":   e.Deploy,
+		"promote":  e.Promote,
+		"rollback": e.Rollback,
+	} {
+		mux.HandleFunc("POST /v1/sites/{domain}/"+action, func(w http.ResponseWriter, r *http.Request) {
+			release, err := run(site(r))
+			adminResult(w, release, err)
+		})
+	}
+	mux.HandleFunc("POST /v1/sites/{domain}/drain", func(w http.ResponseWriter, r *http.Request) {
+		drained, err := e.Drain(site(r))
+		adminResult(w, map[string]any{"drained": drained}, err)
+	})
+	mux.HandleFunc("POST /v1/sites/{domain}/evict", func(w http.ResponseWriter, r *http.Request) {
+		adminResult(w, map[string]any{"evicted": true}, e.Evict(site(r)))
+	})
+	mux.HandleFunc("GET /v1/sites/{domain}/health", func(w http.ResponseWriter, r *http.Request) {
+		health, err := e.TenantHealth(site(r))
+		adminResult(w, health, err)
+	})
+	mux.HandleFunc("GET /v1/sites/{domain}/crons", func(w http.ResponseWriter, r *http.Request) {
+		crons, err := e.Crons(site(r))
+		adminResult(w, crons, err)
+	})
+	mux.HandleFunc("POST /v1/sites/{domain}/crons/{name}/{action}", func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		switch r.PathValue("action") {
+		case "pause", "resume":
+			paused := r.PathValue("action") == "pause"
+			adminResult(w, map[string]any{"name": name, "paused": paused}, e.PauseCron(site(r), name, paused))
+		case "trigger":
+			slot, err := e.TriggerCron(site(r), name)
+			adminResult(w, map[string]any{"name": name, "scheduled_for": slot}, err)
+		default:
+			adminJSON(w, http.StatusNotFound, map[string]any{"error": "unknown cron action"})
+		}
+	})
+	mux.HandleFunc("GET /v1/sites/{domain}/jobs", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		limit, _ := strconv.Atoi(query.Get("limit"))
+		jobs, err := e.QueueJobs(site(r), query.Get("queue"), query.Get("status"), limit)
+		adminResult(w, jobs, err)
+	})
+	mux.HandleFunc("POST /v1/sites/{domain}/jobs/retry", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		var id int64
+		if raw := query.Get("id"); raw != "" {
+			parsed, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil || parsed < 1 {
+				adminJSON(w, http.StatusBadRequest, map[string]any{"error": "id must be a positive job id"})
+				return
+			}
+			id = parsed
+		}
+		if query.Get("queue") == "" && id == 0 {
+			adminJSON(w, http.StatusBadRequest, map[string]any{"error": "set queue, id, or both"})
+			return
+		}
+		retried, err := e.RetryQueueJobs(site(r), query.Get("queue"), id)
+		adminResult(w, map[string]any{"retried": retried}, err)
+	})
+	mux.HandleFunc("POST /v1/sites/{domain}/cache/flush", func(w http.ResponseWriter, r *http.Request) {
+		ram, disk, err := e.FlushResponseCache(site(r))
+		adminResult(w, map[string]any{"ram": ram, "disk": disk}, err)
+	})
+	mux.HandleFunc("POST /v1/sites/{domain}/check", func(w http.ResponseWriter, r *http.Request) {
+		report := e.CheckSite(site(r))
+		issues := make([]string, len(report.Issues))
+		for i, issue := range report.Issues {
+			issues[i] = issue.Error()
+		}
+		adminJSON(w, http.StatusOK, map[string]any{
+			"ok": report.OK(), "sites": report.Sites, "valid": report.Valid,
+			"programs": report.Programs, "compatible": report.Compatible, "issues": issues,
+		})
+	})
+
+	var auditMu sync.Mutex
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started := time.Now()
+		recorder := &adminRecorder{ResponseWriter: w}
+		actor := adminActor(r, opts.Token)
+		if actor == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="kitwork-admin"`)
+			adminJSON(recorder, http.StatusUnauthorized, map[string]any{"error": "unauthorized"})
+		} else {
+			w.Header().Set("Cache-Control", "no-store")
+			mux.ServeHTTP(recorder, r)
+		}
+		if opts.Audit == nil {
+			return
+		}
+		line, _ := json.Marshal(AdminEntry{
+			Time:     started,
+			Actor:    actor,
+			Remote:   r.RemoteAddr,
+			Method:   r.Method,
+			Path:     r.URL.Path,
+			Query:    r.URL.RawQuery,
+			Action:   r.Pattern,
+			Site:     r.PathValue("domain"),
+			Status:   recorder.status,
+			Error:    recorder.err,
+			Duration: float64(time.Since(started).Microseconds()) / 1000,
+		})
+		auditMu.Lock()
+		_, _ = opts.Audit.Write(append(line, '\n'))
+		auditMu.Unlock()
+	})
+}
+
+// adminActor names the authenticated caller, "" when the request is not authenticated. A verified
+// client certificate wins over a token.
+func adminActor(r *http.Request, token string) string {
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+		return "cert:" + r.TLS.VerifiedChains[0][0].Subject.CommonName
+	}
+	if token == "" {
+		return ""
+	}
+	presented, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(presented)), []byte(token)) != 1 {
+		return ""
+	}
+	return "token"
+}
+
+// adminResult answers 200 with v, or 400 with the error.
+func adminResult(w http.ResponseWriter, v any, err error) {
+	if err != nil {
+		adminJSON(w, http.StatusBadRequest, map[string]any{"error": err.Error()})
+		return
+	}
+	adminJSON(w, http.StatusOK, v)
+}
+
+func adminJSON(w http.ResponseWriter, status int, v any) {
+	if recorder, ok := w.(*adminRecorder); ok && status >= 400 {
+		if body, isMap := v.(map[string]any); isMap {
+			recorder.err, _ = body["error"].(string)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// adminRecorder keeps the status and error of a call for the audit line.
+type adminRecorder struct {
+	http.ResponseWriter
+	status int
+	err    string
+}
+
+func (w *adminRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *adminRecorder) Write(body []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(body)
+}
@@ -0,0 +1,103 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The admin API refuses callers without the token, operates on loaded sites for those with it,
+// and writes one audit line per call either way.
+func TestAdminHandler(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTreeTenant(t, tmpDir, "home")
+	siteDir := filepath.Join(tmpDir, "acme", "example.com")
+	if err := os.MkdirAll(siteDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeRouterBody(t, filepath.Join(siteDir, "router.kitwork.js"), "example")
+	engine := New(tmpDir, 0, false, "")
+	defer engine.Close()
+
+	var audit bytes.Buffer
+	admin := engine.AdminHandler(AdminOptions{Token: "s3cret", Audit: &audit})
+	call := func(method, path, token string) (int, map[string]any) {
+		t.Helper()
+		req := httptest.NewRequest(method, "http://admin"+path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		admin.ServeHTTP(rec, req)
+		var body map[string]any
+		_ = json.Unmarshal(rec.Body.Bytes(), &body)
+		return rec.Code, body
+	}
+
+	if code, _ := call(http.MethodGet, "/v1/sites", ""); code != http.StatusUnauthorized {
+		t.Fatalf("no token: %d", code)
+	}
+	if code, _ := call(http.MethodGet, "/v1/sites", "wrong"); code != http.StatusUnauthorized {
+		t.Fatalf("wrong token: %d", code)
+	}
+	if code, body := call(http.MethodPost, "/v1/sites/localhost/evict", "s3cret"); code != http.StatusBadRequest || body["error"] == nil {
+		t.Fatalf("evicting an unloaded site: %d %v", code, body)
+	}
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://localhost/", nil))
+	if rec.Body.String() != "home" {
+		t.Fatalf("site answered %q", rec.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://admin/v1/sites", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	listing := httptest.NewRecorder()
+	admin.ServeHTTP(listing, req)
+	var sites []SiteStatus
+	if err := json.Unmarshal(listing.Body.Bytes(), &sites); err != nil {
+		t.Fatal(err)
+	}
+	if len(sites) != 1 || sites[0].Domain != "localhost" || len(sites[0].Releases) != 1 {
+		t.Fatalf("sites = %+v", sites)
+	}
+
+	if code, body := call(http.MethodPost, "/v1/sites/localhost/cache/flush", "s3cret"); code != http.StatusOK || body["ram"] == nil {
+		t.Fatalf("cache flush: %d %v", code, body)
+	}
+	if code, body := call(http.MethodPost, "/v1/sites/example.com/check", "s3cret"); code != http.StatusOK || body["ok"] != true || body["sites"] != 1.0 {
+		t.Fatalf("check: %d %v", code, body)
+	}
+	if code, body := call(http.MethodPost, "/v1/sites/nowhere/check", "s3cret"); code != http.StatusOK || body["ok"] != false {
+		t.Fatalf("check of a missing site: %d %v", code, body)
+	}
+	if code, _ := call(http.MethodPost, "/v1/sites/localhost/evict", "s3cret"); code != http.StatusOK {
+		t.Fatalf("evict: %d", code)
+	}
+	if sites := engine.Sites(); len(sites) != 0 {
+		t.Fatalf("evicted site still listed: %+v", sites)
+	}
+
+	lines := strings.Split(strings.TrimSpace(audit.String()), "\n")
+	if len(lines) != 8 {
+		t.Fatalf("audit has %d lines:\n%s", len(lines), audit.String())
+	}
+	var denied, evicted AdminEntry
+	_ = json.Unmarshal([]byte(lines[0]), &denied)
+	_ = json.Unmarshal([]byte(lines[len(lines)-1]), &evicted)
+	if denied.Actor != "" || denied.Status != http.StatusUnauthorized || denied.Error != "unauthorized" {
+		t.Fatalf("denied call audited as %+v", denied)
+	}
+	if evicted.Actor != "token" || evicted.Site != "localhost" || evicted.Status != http.StatusOK ||
+		evicted.Action != "POST /v1/sites/{domain}/evict" {
+		t.Fatalf("evict audited as %+v", evicted)
+	}
+	if strings.Contains(audit.String(), "s3cret") {
+		t.Fatal("token leaked into the audit log")
+	}
+}
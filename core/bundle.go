@@ -0,0 +1,159 @@
+package core
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kitwork/engine/bundle"
+	"github.com/kitwork/engine/compiler"
+	"github.com/kitwork/engine/work"
+)
+
+// BundleReport describes one package written by BuildBundle.
+type BundleReport struct {
+	File      string `json:"file"`
+	Name      string `json:"name"`
+	Digest    string `json:"digest"`
+	Programs  int    `json:"programs"`
+	Templates int    `json:"templates"`
+	Assets    int    `json:"assets"`
+	Bytes     int    `json:"bytes"`
+}
+
+// BuildBundle compiles the tenant folder root/<name> — routers, _cron and
+// _queue entries — into verified bytecode, snapshots its templates and public
+// files, and writes one signed, content-addressed <digest>.kwpkg into output.
+// Sources (including every natively bundled import) never enter the archive;
+// hidden entries such as .env and .persist stay with the server. name is the
+// folder relative to the apps root ("example.com", "acme", "sites/example.com")
+// and is where the package installs on the serving host.
+func BuildBundle(root, name, output string, key ed25519.PrivateKey) (BundleReport, error) {
+	name = filepath.ToSlash(filepath.Clean(name))
+	source := filepath.Join(root, filepath.FromSlash(name))
+	report := BundleReport{Name: name}
+	if info, err := os.Stat(source); err != nil {
+		return report, err
+	} else if !info.IsDir() {
+		return report, fmt.Errorf("bundle %s: not a directory", source)
+	}
+
+	entrypoints, err := profileEntrypoints(source)
+	if err != nil {
+		return report, err
+	}
+	var entries []bundle.Entry
+	bundled := make(map[string]struct{})
+	for _, file := range entrypoints {
+		relative := profileRelativePath(source, file)
+		bytecode, err := compiler.CompileFile(file)
+		if err != nil {
+			return report, fmt.Errorf("bundle %s: compile %s: %w", name, relative, err)
+		}
+		if err := compiler.ValidateArtifact(bytecode); err != nil {
+			return report, fmt.Errorf("bundle %s: %s: %w", name, relative, err)
+		}
+		data, err := bytecode.MarshalBinary()
+		if err != nil {
+			return report, fmt.Errorf("bundle %s: %s: %w", name, relative, err)
+		}
+		for _, imported := range bytecode.Files {
+			if absolute, err := filepath.Abs(imported); err == nil {
+				bundled[absolute] = struct{}{}
+			}
+		}
+		entries = append(entries, bundle.Entry{Kind: bundle.KindBytecode, Path: relative, Data: data})
+		report.Programs++
+	}
+
+	walkErr := filepath.WalkDir(source, func(filename string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		entryName := entry.Name()
+		if entry.IsDir() {
+			if filename != source && (strings.HasPrefix(entryName, ".") || entryName == "node_modules") {
+				return filepath.SkipDir
+			}
+			if entryName == compiler.VendorDir {
+				return filepath.SkipDir // vendored packages live inside the programs that import them
+			}
+			return nil
+		}
+		if strings.HasPrefix(entryName, ".") || entry.Type()&os.ModeSymlink != 0 {
+			return nil
+		}
+		lower := strings.ToLower(entryName)
+		if strings.HasSuffix(lower, ".kitwork.js") {
+			return nil // executable source: shipped only as bytecode
+		}
+		if absolute, err := filepath.Abs(filename); err == nil {
+			if _, ok := bundled[absolute]; ok {
+				return nil // a natively bundled import already lives inside a program
+			}
+		}
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		kind := bundle.KindAsset
+		if strings.HasSuffix(lower, ".kitwork.html") {
+			kind = bundle.KindTemplate
+			report.Templates++
+		} else {
+			report.Assets++
+		}
+		entries = append(entries, bundle.Entry{Kind: kind, Path: profileRelativePath(source, filename), Data: data})
+		return nil
+	})
+	if walkErr != nil {
+		return report, walkErr
+	}
+
+	data, digest, err := bundle.Marshal(name, entries, key)
+	if err != nil {
+		return report, err
+	}
+	if err := os.MkdirAll(output, 0o755); err != nil {
+		return report, err
+	}
+	report.Digest = digest
+	report.Bytes = len(data)
+	report.File = filepath.Join(output, digest+bundle.Extension)
+	if err := os.WriteFile(report.File, data, 0o644); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// LoadBundles switches the engine to packaged deployment: every *.kwpkg in
+// directory is verified against publicKey, installed under the apps root, and
+// from then on every tenant the engine creates executes the packaged bytecode
+// instead of compiling source. Call it during host boot, before serving and
+// before StartAppSchedulers. A package that fails verification aborts the load.
+func (e *Engine) LoadBundles(directory string, publicKey ed25519.PublicKey) (int, error) {
+	loader := bundle.NewLoader()
+	installed, err := loader.LoadDirectory(directory, e.root, publicKey)
+	if err != nil {
+		return installed, err
+	}
+	e.mu.Lock()
+	e.bundles = loader
+	e.mu.Unlock()
+	return installed, nil
+}
+
+// bytecodeLoader returns the packaged-program loader as the work interface;
+// nil (not a typed nil) when the engine compiles from source.
+func (e *Engine) bytecodeLoader() work.BytecodeLoader {
+	e.mu.RLock()
+	loader := e.bundles
+	e.mu.RUnlock()
+	if loader == nil {
+		return nil
+	}
+	return loader
+}
@@ -0,0 +1,98 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kitwork/engine/bundle"
+)
+
+func TestEngineServesTenantFromSignedBundleWithoutSource(t *testing.T) {
+	source := t.TempDir()
+	tenant := filepath.Join(source, "test", "localhost")
+	files := map[string]string{
+		"router.kitwork.js": "import { router } from \"kitwork\";\n" +
+			"import { greet } from \"./_core/greet.kitwork.js\";\n" +
+			"router.get().handle((ctx) => ctx.text(greet(\"bundle\")));\n",
+		"_core/greet.kitwork.js":  "export const greet = (name) => \"hello \" + name;\n",
+		"index.kitwork.html":      "<main>{{ @page }}</main>",
+		"about/page.kitwork.html": "<p>about</p>",
+		"assets/site.css":         "body{}",
+		".env":                    "SECRET=builder",
+	}
+	for name, content := range files {
+		filename := filepath.Join(tenant, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	public, private, err := bundle.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	packages := t.TempDir()
+	report, err := BuildBundle(source, "test/localhost", packages, private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Programs != 1 || report.Templates != 2 || report.Assets != 1 {
+		t.Fatalf("bundle report = %+v", report)
+	}
+	if filepath.Base(report.File) != report.Digest+bundle.Extension {
+		t.Fatalf("bundle file %s is not content addressed", report.File)
+	}
+	pkg, err := bundle.Open(report.File, public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range pkg.Entries {
+		if strings.Contains(entry.Path, "_core") || strings.HasPrefix(entry.Path, ".") {
+			t.Fatalf("bundle shipped %s", entry.Path)
+		}
+	}
+
+	foreign, _, err := bundle.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	refused := New(t.TempDir(), 0, false, "")
+	t.Cleanup(refused.Close)
+	if _, err := refused.LoadBundles(packages, foreign); err == nil {
+		t.Fatal("engine accepted a bundle signed by another key")
+	}
+
+	root := t.TempDir()
+	engine := New(root, 0, false, "")
+	t.Cleanup(engine.Close)
+	installed, err := engine.LoadBundles(packages, public)
+	if err != nil || installed != 1 {
+		t.Fatalf("LoadBundles = %d, %v", installed, err)
+	}
+	deployed := filepath.Join(root, "test", "localhost")
+	if _, err := os.Stat(filepath.Join(deployed, "_core", "greet.kitwork.js")); !os.IsNotExist(err) {
+		t.Fatal("imported module source was deployed")
+	}
+	if marker, _ := os.ReadFile(filepath.Join(deployed, "router.kitwork.js")); strings.Contains(string(marker), "greet") {
+		t.Fatalf("router source was deployed: %q", marker)
+	}
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://localhost/", nil))
+	if recorder.Code != http.StatusOK || recorder.Body.String() != "hello bundle" {
+		t.Fatalf("packaged response = %d %q", recorder.Code, recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://localhost/about", nil))
+	if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), "about") {
+		t.Fatalf("packaged template = %d %q", recorder.Code, recorder.Body.String())
+	}
+}
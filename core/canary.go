@@ -0,0 +1,352 @@
+package core
+
+// Canary releases and instant rollback. A site normally has one live generation; the engine can
+// keep more of them warm:
+//
+//	engine.SetRetainedGenerations(2)                                   // predecessors kept for rollback
+//	engine.SetCanary("example.com", CanaryPolicy{Percent: 10, Header: "X-Canary"})
+//	engine.Deploy("example.com")   // or a hot reload: staged as the candidate, not made current
+//	engine.Releases("example.com") // per-generation traffic and error rates
+//	engine.Promote("example.com")  // the candidate becomes current
+//	engine.Rollback("example.com") // drop the candidate, else return to the newest retained one
+//
+// Promotion and rollback switch between already published generations: nothing is recompiled, and
+// the replaced generation keeps answering the requests it accepted.
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/kitwork/engine/site"
+	"github.com/kitwork/engine/utilities/flags"
+	"github.com/kitwork/engine/work"
+)
+
+// CanaryPolicy decides which requests a site's candidate generation answers. A request that
+// names a cohort — the header or the cookie, any value but 0/false/off — is sent accordingly;
+// otherwise Percent of clients, bucketed by IP and User-Agent so each stays on one side.
+type CanaryPolicy struct {
+	Percent float64
+	Header  string
+	Cookie  string
+}
+
+func (p CanaryPolicy) active() bool {
+	return p.Percent > 0 || p.Header != "" || p.Cookie != ""
+}
+
+func (p CanaryPolicy) admits(domain string, r *http.Request) bool {
+	if p.Header != "" {
+		if cohort := r.Header.Get(p.Header); cohort != "" {
+			return optedIn(cohort)
+		}
+	}
+	if p.Cookie != "" {
+		if cookie, err := r.Cookie(p.Cookie); err == nil && cookie.Value != "" {
+			return optedIn(cookie.Value)
+		}
+	}
+	return p.Percent > 0 && flags.Bucket("canary:"+domain, work.GetClientIP(r)+"|"+r.UserAgent()) < p.Percent
+}
+
+func optedIn(cohort string) bool {
+	switch strings.ToLower(strings.TrimSpace(cohort)) {
+	case "0", "false", "off", "no":
+		return false
+	}
+	return true
+}
+
+// Release describes one live generation of a site.
+type Release struct {
+	Version   uint64       `json:"version"`
+	Role      string       `json:"role"` // current, candidate or retained
+	Inflight  int          `json:"inflight"`
+	Traffic   site.Traffic `json:"traffic"`
+	ErrorRate float64      `json:"error_rate"` // server errors per response
+}
+
+func releaseOf(tenant *work.Tenant, role string) Release {
+	generation := tenant.SiteGeneration()
+	traffic := generation.Traffic()
+	return Release{
+		Version:   generation.Version(),
+		Role:      role,
+		Inflight:  generation.Active(),
+		Traffic:   traffic,
+		ErrorRate: traffic.ErrorRate(),
+	}
+}
+
+// SetCanary sets who a site's candidate generation serves; while a policy is set, a reload
+// stages the new generation instead of making it current. The zero policy turns canarying off;
+// a candidate already staged then gets no traffic until it is promoted or rolled back.
+func (e *Engine) SetCanary(domain string, policy CanaryPolicy) error {
+	if policy.Percent < 0 || policy.Percent > 100 {
+		return fmt.Errorf("canary percent must be between 0 and 100")
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if policy.active() {
+		e.canaries[domain] = policy
+	} else {
+		delete(e.canaries, domain)
+	}
+	return nil
+}
+
+// SetRetainedGenerations keeps up to n replaced generations of each site warm for Rollback.
+// 0, the default, retires a generation as soon as it is replaced.
+func (e *Engine) SetRetainedGenerations(n int) {
+	e.mu.Lock()
+	e.retainGens = max(n, 0)
+	e.mu.Unlock()
+}
+
+// Releases lists a loaded site's live generations: current first, then the candidate, then the
+// retained ones, newest first.
+func (e *Engine) Releases(domain string) ([]Release, error) {
+	e.mu.RLock()
+	cached := e.cache[domain]
+	e.mu.RUnlock()
+	if cached == nil {
+		return nil, fmt.Errorf("site %q is not loaded", domain)
+	}
+	cached.mu.Lock()
+	defer cached.mu.Unlock()
+	releases := []Release{releaseOf(cached.tenant, "current")}
+	if cached.candidate != nil {
+		releases = append(releases, releaseOf(cached.candidate, "candidate"))
+	}
+	for _, tenant := range cached.retained {
+		releases = append(releases, releaseOf(tenant, "retained"))
+	}
+	return releases, nil
+}
+
+// Deploy builds a generation from the site's sources now — the hot reload step, for hosts that
+// do not poll. It is staged when the site has a canary policy, made current otherwise. A site that
+// is not loaded yet is just loaded: that already builds from the current sources. A loaded one is
+// not passed through run, whose own hot reload check could build a second generation.
+func (e *Engine) Deploy(domain string) (Release, error) {
+	e.mu.RLock()
+	cached := e.cache[domain]
+	e.mu.RUnlock()
+	if cached == nil {
+		tenant, err := e.run(domain)
+		if err != nil {
+			return Release{}, err
+		}
+		return releaseOf(tenant, "current"), nil
+	}
+	cached.reloadMu.Lock()
+	defer cached.reloadMu.Unlock()
+	next, staged, err := e.deploy(domain, cached)
+	if err != nil {
+		return Release{}, err
+	}
+	if staged {
+		return releaseOf(next, "candidate"), nil
+	}
+	return releaseOf(next, "current"), nil
+}
+
+// Promote makes the site's candidate current. The generation it replaces is retained.
+func (e *Engine) Promote(domain string) (Release, error) {
+	return e.switchRelease(domain, func(cached *cachedTenant) (*work.Tenant, error) {
+		if cached.candidate == nil {
+			return nil, fmt.Errorf("site %q has no candidate generation", domain)
+		}
+		return cached.candidate, nil
+	})
+}
+
+// Rollback drops the site's candidate, or — with none staged — makes the newest retained
+// generation current again and retires the one it replaces. Hot reload does not rebuild the
+// rejected sources; the next change on disk does.
+func (e *Engine) Rollback(domain string) (Release, error) {
+	return e.switchRelease(domain, func(cached *cachedTenant) (*work.Tenant, error) {
+		if cached.candidate != nil {
+			return cached.tenant, nil
+		}
+		if len(cached.retained) == 0 {
+			return nil, fmt.Errorf("site %q has no retained generation to roll back to", domain)
+		}
+		return cached.retained[0], nil
+	})
+}
+
+func (e *Engine) switchRelease(domain string, pick func(*cachedTenant) (*work.Tenant, error)) (Release, error) {
+	e.mu.RLock()
+	cached := e.cache[domain]
+	e.mu.RUnlock()
+	if cached == nil {
+		return Release{}, fmt.Errorf("site %q is not loaded", domain)
+	}
+	cached.reloadMu.Lock()
+	defer cached.reloadMu.Unlock()
+
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return Release{}, fmt.Errorf("engine is closed")
+	}
+	cached.mu.Lock()
+	target, err := pick(cached)
+	cached.mu.Unlock()
+	if err != nil {
+		e.mu.Unlock()
+		return Release{}, err
+	}
+	if err := e.switchGeneration(target); err != nil {
+		e.mu.Unlock()
+		return Release{}, err
+	}
+	drained := cached.switchTo(target, e.retainGens)
+	e.mu.Unlock()
+	for _, tenant := range drained {
+		e.drainTenant(tenant)
+	}
+	return releaseOf(target, "current"), nil
+}
+
+func (e *Engine) switchGeneration(tenant *work.Tenant) error {
+	return e.timeActivation(tenant.SwitchGeneration)
+}
+
+// deploy prepares a generation from the site's sources and publishes it: staged as the candidate
+// while a canary policy is set, otherwise made current. The caller holds cached.reloadMu.
+func (e *Engine) deploy(hostname string, cached *cachedTenant) (next *work.Tenant, staged bool, err error) {
+	current := cached.current()
+	generation, err := e.prepareGeneration(current.SiteRuntime())
+	if err != nil {
+		return nil, false, err
+	}
+	next = work.NewTenantWithRuntime(e.root, hostname, current.AppRuntime(), current.SiteRuntime(), generation)
+	next.SetRuntimeHealth(e.runtimeHealth)
+	next.SetBytecodeLoader(e.bytecodeLoader())
+	next.SetTracer(e.tracer)
+	next.SetAccessLog(current.AccessLog())
+	next.MaxEnergy = e.maxEnergy
+	next.HotReload = e.hotReload
+	if err := next.Run(); err != nil {
+		next.Close()
+		return nil, false, err
+	}
+
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		next.Close()
+		return nil, false, fmt.Errorf("engine is closed")
+	}
+	var drained []*work.Tenant
+	if e.canaries[hostname].active() {
+		if err := next.StageGeneration(); err != nil {
+			e.mu.Unlock()
+			next.Close()
+			return nil, false, fmt.Errorf("stage site generation: %w", err)
+		}
+		drained, staged = cached.stage(next), true
+	} else {
+		if err := e.activateGeneration(next); err != nil {
+			e.mu.Unlock()
+			next.Close()
+			return nil, false, fmt.Errorf("activate site generation: %w", err)
+		}
+		drained = cached.switchTo(next, e.retainGens)
+	}
+	cached.mu.Lock()
+	cached.watched = next
+	cached.mu.Unlock()
+	e.mu.Unlock()
+	for _, tenant := range drained {
+		e.drainTenant(tenant)
+	}
+	return next, staged, nil
+}
+
+// release picks the generation that answers r: the candidate when the site's policy admits the
+// request, the current one otherwise.
+func (e *Engine) release(domain string, current *work.Tenant, r *http.Request) *work.Tenant {
+	e.mu.RLock()
+	cached := e.cache[domain]
+	policy, canary := e.canaries[domain]
+	e.mu.RUnlock()
+	if !canary || cached == nil {
+		return current
+	}
+	cached.mu.Lock()
+	candidate := cached.candidate
+	cached.mu.Unlock()
+	if candidate == nil || !policy.admits(domain, r) {
+		return current
+	}
+	return candidate
+}
+
+// tenants lists every live generation of the site: current, candidate, retained.
+func (c *cachedTenant) tenants() []*work.Tenant {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	tenants := []*work.Tenant{c.tenant}
+	if c.candidate != nil {
+		tenants = append(tenants, c.candidate)
+	}
+	return append(tenants, c.retained...)
+}
+
+// newest is the tenant built from the latest sources, which may be a candidate or a generation
+// that was rolled back.
+func (c *cachedTenant) newest() *work.Tenant {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.watched != nil {
+		return c.watched
+	}
+	return c.tenant
+}
+
+// stage installs a new candidate and returns the one it supersedes.
+func (c *cachedTenant) stage(next *work.Tenant) (drained []*work.Tenant) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.candidate != nil {
+		drained = append(drained, c.candidate)
+	}
+	c.candidate = next
+	return drained
+}
+
+// switchTo records that next is now current and returns the tenants to drain. Moving forward —
+// a new or promoted generation — retains the replaced one, up to keep; moving back to a retained
+// generation retires the one it replaces, and to the current one drops the candidate.
+func (c *cachedTenant) switchTo(next *work.Tenant, keep int) (drained []*work.Tenant) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	previous := c.tenant
+	if c.candidate != nil && c.candidate != previous {
+		if c.candidate != next {
+			drained = append(drained, c.candidate)
+		}
+		c.candidate = nil
+	}
+	if next == previous {
+		return drained
+	}
+	for i, retained := range c.retained {
+		if retained == next {
+			c.retained = append(c.retained[:i:i], c.retained[i+1:]...)
+			c.tenant = next
+			return append(drained, previous)
+		}
+	}
+	c.tenant = next
+	c.retained = append([]*work.Tenant{previous}, c.retained...)
+	if len(c.retained) > keep {
+		drained = append(drained, c.retained[keep:]...)
+		c.retained = c.retained[:keep]
+	}
+	return drained
+}
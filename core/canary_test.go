@@ -0,0 +1,171 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEngineCanaryPromoteAndRollback(t *testing.T) {
+	tmpDir := t.TempDir()
+	routerFile := writeTreeTenant(t, tmpDir, "v1")
+	engine := New(tmpDir, 0, false, "")
+	t.Cleanup(engine.Close)
+	engine.SetRetainedGenerations(1)
+
+	get := func(headers map[string]string) string {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/", nil)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+		}
+		return rec.Body.String()
+	}
+	roles := func() string {
+		t.Helper()
+		releases, err := engine.Releases("localhost")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out []string
+		for _, release := range releases {
+			out = append(out, release.Role)
+		}
+		return strings.Join(out, ",")
+	}
+	if body := get(nil); body != "v1" {
+		t.Fatalf("initial body %q", body)
+	}
+
+	// With a policy, a deploy is staged: only the cohort sees it.
+	if err := engine.SetCanary("localhost", CanaryPolicy{Header: "X-Canary"}); err != nil {
+		t.Fatal(err)
+	}
+	writeRouterBody(t, routerFile, "v2")
+	if release, err := engine.Deploy("localhost"); err != nil || release.Role != "candidate" {
+		t.Fatalf("deploy = %+v, %v", release, err)
+	}
+	if get(nil) != "v1" || get(map[string]string{"X-Canary": "1"}) != "v2" || get(map[string]string{"X-Canary": "0"}) != "v1" {
+		t.Fatal("the header cohort did not pick the generation")
+	}
+	releases, _ := engine.Releases("localhost")
+	if roles() != "current,candidate" || releases[1].Traffic.Requests != 1 || releases[0].Traffic.Requests != 3 {
+		t.Fatalf("releases = %+v", releases)
+	}
+	if err := engine.SetCanary("localhost", CanaryPolicy{Percent: 100}); err != nil {
+		t.Fatal(err)
+	}
+	if get(nil) != "v2" {
+		t.Fatal("a 100% canary should serve the candidate")
+	}
+
+	// Rolling the candidate back leaves the current generation alone.
+	if _, err := engine.Rollback("localhost"); err != nil {
+		t.Fatal(err)
+	}
+	if get(nil) != "v1" || roles() != "current" {
+		t.Fatalf("after dropping the candidate: %s", roles())
+	}
+
+	// Promotion keeps the replaced generation warm; rollback returns to it without a rebuild.
+	writeRouterBody(t, routerFile, "v3")
+	if _, err := engine.Deploy("localhost"); err != nil {
+		t.Fatal(err)
+	}
+	v1 := engine.cache["localhost"].current().SiteGeneration()
+	if release, err := engine.Promote("localhost"); err != nil || release.Role != "current" {
+		t.Fatalf("promote = %+v, %v", release, err)
+	}
+	if get(nil) != "v3" || roles() != "current,retained" || v1.Retired() {
+		t.Fatalf("after promotion: %s", roles())
+	}
+	v3 := engine.cache["localhost"].current().SiteGeneration()
+	if release, err := engine.Rollback("localhost"); err != nil || release.Version != v1.Version() {
+		t.Fatalf("rollback = %+v, %v", release, err)
+	}
+	if get(nil) != "v1" || roles() != "current" || !v3.Retired() {
+		t.Fatalf("after rollback: %s", roles())
+	}
+	if _, err := engine.Rollback("localhost"); err == nil {
+		t.Fatal("rollback with nothing retained should fail")
+	}
+	if _, err := engine.Promote("localhost"); err == nil {
+		t.Fatal("promote without a candidate should fail")
+	}
+}
+
+// Hot reload compares disk with the newest build, so a rolled-back generation stays current until
+// the sources change again.
+func TestEngineHotReloadKeepsRollback(t *testing.T) {
+	tmpDir := t.TempDir()
+	routerFile := writeTreeTenant(t, tmpDir, "v1")
+	engine := New(tmpDir, 0, true, "")
+	t.Cleanup(engine.Close)
+	engine.SetRetainedGenerations(1)
+	get := func() string {
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://localhost/", nil))
+		return rec.Body.String()
+	}
+	if get() != "v1" {
+		t.Fatal("initial generation")
+	}
+	writeRouterBody(t, routerFile, "v2")
+	future := time.Now().Add(5 * time.Second)
+	if err := os.Chtimes(routerFile, future, future); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := engine.Deploy("localhost"); err != nil {
+		t.Fatal(err)
+	}
+	if get() != "v2" {
+		t.Fatal("deploy did not activate")
+	}
+	if _, err := engine.Rollback("localhost"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(1100 * time.Millisecond)
+	if body := get(); body != "v1" {
+		t.Fatalf("hot reload rebuilt the rejected sources: %q", body)
+	}
+}
+
+// A deploy on a polling host builds one generation, even when the poll would also have seen the
+// change.
+func TestEngineDeployBuildsOnce(t *testing.T) {
+	tmpDir := t.TempDir()
+	routerFile := writeTreeTenant(t, tmpDir, "v1")
+	engine := New(tmpDir, 0, true, "")
+	t.Cleanup(engine.Close)
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://localhost/", nil))
+	if rec.Body.String() != "v1" {
+		t.Fatalf("initial body %q", rec.Body.String())
+	}
+	if err := engine.SetCanary("localhost", CanaryPolicy{Header: "X-Canary"}); err != nil {
+		t.Fatal(err)
+	}
+	first := engine.cache["localhost"].current().SiteGeneration().Version()
+
+	writeRouterBody(t, routerFile, "v2")
+	future := time.Now().Add(5 * time.Second)
+	if err := os.Chtimes(routerFile, future, future); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(1100 * time.Millisecond) // past the poll interval
+	release, err := engine.Deploy("localhost")
+	if err != nil || release.Role != "candidate" || release.Version != first+1 {
+		t.Fatalf("deploy = %+v, %v; want the candidate built right after version %d", release, err, first)
+	}
+	if releases, _ := engine.Releases("localhost"); len(releases) != 2 || releases[1].Version != release.Version {
+		t.Fatalf("releases = %+v", releases)
+	}
+}
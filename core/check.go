@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 
@@ -44,12 +45,32 @@ type CheckReport struct {
 	Programs   int
 	Compatible int
 	Issues     []CheckIssue
+
+	// OpenAPI holds the router.openapi() document of every valid site that declares one, keyed by
+	// domain, so a review can diff the API surface a change produces.
+	OpenAPI map[string][]byte
 }
 
 func (r CheckReport) OK() bool {
 	return len(r.Issues) == 0
 }
 
+// WriteOpenAPI writes each collected document to dir as <domain>.openapi.json.
+func (r CheckReport) WriteOpenAPI(dir string) error {
+	if len(r.OpenAPI) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for domain, document := range r.OpenAPI {
+		if err := os.WriteFile(filepath.Join(dir, domain+".openapi.json"), document, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type checkTarget struct {
 	identity string
 	domain   string
@@ -60,6 +81,15 @@ type checkTarget struct {
 // path, then retires it without activation. It opens no listener and starts no
 // cron scheduler.
 func Check(root string, maxEnergy uint64, bytecodeCacheDirectory ...string) CheckReport {
+	directory := ""
+	if len(bytecodeCacheDirectory) > 0 {
+		directory = bytecodeCacheDirectory[0]
+	}
+	return check(root, maxEnergy, directory, "")
+}
+
+// check is Check, narrowed to one site and its app when domain is set.
+func check(root string, maxEnergy uint64, bytecodeCacheDirectory, domain string) CheckReport {
 	if maxEnergy == 0 {
 		maxEnergy = 10_000_000
 	}
@@ -73,6 +103,16 @@ func Check(root string, maxEnergy uint64, bytecodeCacheDirectory ...string) Chec
 		})
 		return report
 	}
+	if domain != "" {
+		targets = slices.DeleteFunc(targets, func(target checkTarget) bool { return target.domain != domain })
+		if len(targets) == 0 {
+			report.Issues = append(report.Issues, CheckIssue{
+				Stage: "discover", Domain: domain, File: root,
+				Err: fmt.Errorf("no site %q under the root", domain),
+			})
+			return report
+		}
+	}
 
 	runtimes := make(map[string]*app.Runtime)
 	appKey := func(identity, domain string) string {
@@ -82,8 +122,10 @@ func Check(root string, maxEnergy uint64, bytecodeCacheDirectory ...string) Chec
 		return "site:" + domain
 	}
 	identities := make(map[string]struct{})
-	for _, identity := range work.DiscoverAppIdentities(root) {
-		identities[identity] = struct{}{}
+	if domain == "" {
+		for _, identity := range work.DiscoverAppIdentities(root) {
+			identities[identity] = struct{}{}
+		}
 	}
 
 	for _, target := range targets {
@@ -114,9 +156,9 @@ func Check(root string, maxEnergy uint64, bytecodeCacheDirectory ...string) Chec
 			})
 			continue
 		}
-		if len(bytecodeCacheDirectory) > 0 && bytecodeCacheDirectory[0] != "" {
+		if bytecodeCacheDirectory != "" {
 			if err := generation.SetBytecodeCache(
-				compiler.NewFileCache(bytecodeCacheDirectory[0]),
+				compiler.NewFileCache(bytecodeCacheDirectory),
 			); err != nil {
 				report.Issues = append(report.Issues, CheckIssue{
 					Stage: "bytecode cache", Identity: target.identity,
@@ -143,6 +185,25 @@ func Check(root string, maxEnergy uint64, bytecodeCacheDirectory ...string) Chec
 			})
 		} else {
 			report.Valid++
+			document, declared, err := tenant.OpenAPI()
+			if err != nil {
+				report.Issues = append(report.Issues, CheckIssue{
+					Stage: "openapi", Identity: target.identity,
+					Domain: target.domain, File: target.file, Err: err,
+				})
+			} else if declared {
+				if report.OpenAPI == nil {
+					report.OpenAPI = make(map[string][]byte)
+				}
+				report.OpenAPI[target.domain] = document
+			}
+			file, issues := tenant.RedirectIssues()
+			for _, issue := range issues {
+				report.Issues = append(report.Issues, CheckIssue{
+					Stage: "redirects", Identity: target.identity,
+					Domain: target.domain, File: file, Err: issue,
+				})
+			}
 		}
 		tenant.Close()
 		appRuntime.RemoveSite(target.domain)
@@ -182,10 +243,13 @@ func Check(root string, maxEnergy uint64, bytecodeCacheDirectory ...string) Chec
 		})
 	} else {
 		var artifactCache *compiler.FileCache
-		if len(bytecodeCacheDirectory) > 0 && bytecodeCacheDirectory[0] != "" {
-			artifactCache = compiler.NewFileCache(bytecodeCacheDirectory[0])
+		if bytecodeCacheDirectory != "" {
+			artifactCache = compiler.NewFileCache(bytecodeCacheDirectory)
 		}
 		for _, file := range files {
+			if domain != "" && !checkCovers(root, targets[0], file) {
+				continue
+			}
 			var bytecode *compiler.Bytecode
 			var compileErr error
 			if artifactCache != nil {
@@ -217,6 +281,30 @@ func Check(root string, maxEnergy uint64, bytecodeCacheDirectory ...string) Chec
 	return report
 }
 
+// checkCovers reports whether a program belongs to the target's site or to its app's shared
+// folders (_cron, _queue, _core, …).
+func checkCovers(root string, target checkTarget, file string) bool {
+	siteDir := filepath.Dir(target.file)
+	if within(siteDir, file) {
+		return true
+	}
+	if target.identity == "" {
+		return false
+	}
+	rel, err := filepath.Rel(filepath.Join(root, target.identity), file)
+	if err != nil {
+		return false
+	}
+	first, _, _ := strings.Cut(filepath.ToSlash(rel), "/")
+	return strings.HasPrefix(first, "_")
+}
+
+// within reports whether file sits under dir.
+func within(dir, file string) bool {
+	rel, err := filepath.Rel(dir, file)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
 func discoverCheckTargets(root string) ([]checkTarget, error) {
 	entries, err := os.ReadDir(root)
 	if err != nil {
@@ -27,7 +27,8 @@ func TestCheckAggregatesSiteAndCronFailuresWithoutStartingRuntime(t *testing.T)
 	}
 	router := `import { router } from "kitwork";
 router.get((ctx) => ctx.text("ok"));`
-	write(filepath.Join(valid, "router.kitwork.js"), router)
+	write(filepath.Join(valid, "router.kitwork.js"), router+`
+router.openapi({ title: "Valid" });`)
 	write(filepath.Join(valid, "index.kitwork.html"), `<html>{{ @page }}</html>`)
 	write(filepath.Join(valid, "page.kitwork.html"), `<main>ok</main>`)
 	write(
@@ -71,7 +72,52 @@ router.get((ctx) => ctx.json(missing));`,
 			t.Errorf("report does not identify %q:\n%s", want, joined)
 		}
 	}
+	if len(report.OpenAPI) != 1 || !strings.Contains(string(report.OpenAPI["valid.example"]), `"title": "Valid"`) {
+		t.Fatalf("openapi = %q", report.OpenAPI)
+	}
 	if _, err := os.Stat(filepath.Join(root, "identity-a", ".data", "scheduler.db")); !os.IsNotExist(err) {
 		t.Fatal("preflight started or persisted the cron scheduler")
 	}
 }
+
+func TestCheckReportsRedirectRules(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "identity-a", "redirects.example")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, body string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("router.kitwork.js", `import { router } from "kitwork";
+router.get((ctx) => ctx.text("ok"));`)
+	write("robots.txt", "User-agent: *\n")
+	write("_redirects", `/blog/*      /posts/:splat  301
+/blog/:slug  /articles/:slug
+/robots.txt  /robots-new.txt 302
+/robots.txt  /robots-new.txt 302!
+`)
+	report := Check(root, 100_000)
+	var got []string
+	for _, issue := range report.Issues {
+		if issue.Stage != "redirects" || issue.File != filepath.Join(dir, "_redirects") {
+			t.Fatalf("unexpected issue %s", issue.Error())
+		}
+		got = append(got, issue.Err.Error())
+	}
+	want := []string{
+		"line 2: unreachable: line 1 already matches every request it does",
+		"line 3: unreachable: the static file /robots.txt answers first (force the rule with 302!)",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("issues =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	write("_redirects", "/a /b 999\n")
+	if report := Check(root, 100_000); report.Valid != 0 || len(report.Issues) != 1 || !strings.Contains(report.Issues[0].Error(), "_redirects: line 1") {
+		t.Fatalf("a malformed _redirects should fail preparation: %+v", report.Issues)
+	}
+}
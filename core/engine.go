@@ -11,10 +11,13 @@ import (
 	"time"
 
 	"github.com/kitwork/engine/app"
+	"github.com/kitwork/engine/bundle"
 	"github.com/kitwork/engine/compiler"
 	dom "github.com/kitwork/engine/domain"
 	requestscope "github.com/kitwork/engine/request"
 	"github.com/kitwork/engine/site"
+	"github.com/kitwork/engine/utilities/accesslog"
+	"github.com/kitwork/engine/utilities/trace"
 	"github.com/kitwork/engine/work"
 )
 
@@ -28,6 +31,9 @@ type Authorizer func(
 
 type cachedTenant struct {
 	tenant      *work.Tenant
+	candidate   *work.Tenant   // staged canary generation (canary.go); nil when none
+	retained    []*work.Tenant // replaced generations kept warm for rollback, newest first
+	watched     *work.Tenant   // built from the newest sources: what hot reload compares disk against
 	lastAccess  time.Time
 	lastChecked time.Time
 	mu          sync.Mutex
@@ -67,7 +73,13 @@ type Engine struct {
 	authorizer       Authorizer
 	bytecodeCacheMu  sync.RWMutex
 	bytecodeCacheDir string
+	bundles          *bundle.Loader // packaged deployment (LoadBundles); nil = compile source
 	runtimeHealth    *work.RuntimeHealth
+	tracer           *trace.Tracer                // distributed tracing (trace.go); nil = off
+	canaries         map[string]CanaryPolicy      // domain → who its staged generation serves (canary.go)
+	retainGens       int                          // replaced generations kept warm per site for rollback
+	accessLogOptions *accesslog.Options           // per-site access logs (access_log.go); nil = off
+	accessLogs       map[string]*accesslog.Logger // domain → its access log, shared by its generations
 	mu               sync.RWMutex
 	stopCleanup      chan struct{}
 	closeOnce        sync.Once
@@ -87,6 +99,8 @@ func New(root string, maxEnergy uint64, hotReload bool, hostname string) *Engine
 		appRuntimes:   make(map[string]*app.Runtime),
 		appTenants:    make(map[string]*work.Tenant),
 		appStarting:   make(map[string]struct{}),
+		canaries:      make(map[string]CanaryPolicy),
+		accessLogs:    make(map[string]*accesslog.Logger),
 		runtimeHealth: work.NewRuntimeHealth(),
 		idleTimeout:   10 * time.Minute, // mặc định; chỉnh bằng SetIdleTimeout (0 = không evict)
 		stopCleanup:   make(chan struct{}),
@@ -139,6 +153,8 @@ func (e *Engine) StartAppSchedulers() (started int) {
 
 		appTenant := work.NewAppTenantWithRuntime(e.root, identity, appRuntime)
 		appTenant.SetRuntimeHealth(e.runtimeHealth)
+		appTenant.SetBytecodeLoader(e.bytecodeLoader())
+		appTenant.SetTracer(e.tracer)
 		appTenant.MaxEnergy = e.maxEnergy
 		appTenant.HotReload = e.hotReload
 		if err := appTenant.Run(); err != nil {
@@ -204,7 +220,7 @@ func (e *Engine) Health() work.RuntimeHealthSnapshot {
 	snapshot.LoadedSites = len(e.cache)
 	tenants := make([]*work.Tenant, 0, len(e.cache))
 	for _, cached := range e.cache {
-		tenants = append(tenants, cached.current())
+		tenants = append(tenants, cached.tenants()...)
 	}
 	e.mu.RUnlock()
 	for _, tenant := range tenants {
@@ -247,8 +263,12 @@ func (e *Engine) prepareGeneration(siteRuntime *site.Runtime) (
 }
 
 func (e *Engine) activateGeneration(tenant *work.Tenant) error {
+	return e.timeActivation(tenant.ActivateGeneration)
+}
+
+func (e *Engine) timeActivation(activate func() error) error {
 	started := time.Now()
-	err := tenant.ActivateGeneration()
+	err := activate()
 	e.runtimeHealth.RecordGenerationActivate(time.Since(started), err == nil)
 	return err
 }
@@ -279,7 +299,7 @@ func (e *Engine) cleanupLoop(interval time.Duration) {
 	for {
 		select {
 		case <-ticker.C:
-			var evicted []*work.Tenant
+			var evicted []*cachedTenant
 			e.mu.Lock()
 			timeout := e.idleTimeout
 			if timeout > 0 { // 0 = không evict (giữ ấm mọi tenant)
@@ -287,17 +307,14 @@ func (e *Engine) cleanupLoop(interval time.Duration) {
 				for domain, cached := range e.cache {
 					if cached.isExpired(now, timeout) {
 						slog.Info("Evicting idle tenant from cache", "domain", domain)
-						evicted = append(evicted, cached.tenant)
+						evicted = append(evicted, cached)
 						delete(e.cache, domain)
 					}
 				}
 			}
 			e.mu.Unlock()
-			for _, tenant := range evicted {
-				e.drainTenant(tenant)
-				if owner := tenant.AppRuntime(); owner != nil {
-					owner.RemoveSite(tenant.Domain())
-				}
+			for _, cached := range evicted {
+				e.unload(cached)
 			}
 		case <-e.stopCleanup:
 			return
@@ -320,9 +337,11 @@ func (e *Engine) Close() {
 		tenants := make([]*work.Tenant, 0, len(e.cache)+len(e.appTenants))
 		seen := make(map[*work.Tenant]struct{}, len(e.cache)+len(e.appTenants))
 		for _, cached := range e.cache {
-			if _, ok := seen[cached.tenant]; !ok {
-				seen[cached.tenant] = struct{}{}
-				tenants = append(tenants, cached.tenant)
+			for _, tenant := range cached.tenants() {
+				if _, ok := seen[tenant]; !ok {
+					seen[tenant] = struct{}{}
+					tenants = append(tenants, tenant)
+				}
 			}
 		}
 		for _, tenant := range e.appTenants {
@@ -339,6 +358,8 @@ func (e *Engine) Close() {
 		e.appTenants = make(map[string]*work.Tenant)
 		e.appRuntimes = make(map[string]*app.Runtime)
 		e.appStarting = make(map[string]struct{})
+		accessLogs := e.accessLogs
+		e.accessLogs = make(map[string]*accesslog.Logger)
 		e.mu.Unlock()
 
 		for _, tenant := range tenants {
@@ -347,6 +368,11 @@ func (e *Engine) Close() {
 		for _, appRuntime := range apps {
 			appRuntime.Close()
 		}
+		closeAccessLogs(accessLogs)
+		// Last: the drained tenants' final spans are in the queue by now.
+		if err := e.tracer.Close(); err != nil {
+			slog.Warn("Trace export failed on close", "error", err)
+		}
 	})
 }
 
@@ -391,63 +417,31 @@ func (e *Engine) run(hostname string) (*work.Tenant, error) {
 						e.mu.Lock()
 						delete(e.cache, hostname)
 						e.mu.Unlock()
-						e.drainTenant(current)
+						for _, tenant := range cached.tenants() {
+							e.drainTenant(tenant)
+						}
 						if owner := current.AppRuntime(); owner != nil {
 							owner.RemoveSite(hostname)
 						}
+						_ = current.AccessLog().Close()
 						return nil, fmt.Errorf("tenant not found: %s", hostname)
 					}
 					// Lỗi đọc đĩa khác -> Tiếp tục dùng bản cũ
 					slog.Error("os.Stat error during hot reload", "error", err)
 				} else {
-					changed, changeErr := current.SourcesChanged()
+					changed, changeErr := cached.newest().SourcesChanged()
 					if changeErr != nil {
 						slog.Error("Source manifest check failed; keeping current generation", "error", changeErr)
 					} else if changed {
 						slog.Info("Detecting source change. Preparing generation...", "site", hostname)
-						generation, prepareErr := e.prepareGeneration(current.SiteRuntime())
-						if prepareErr != nil {
-							slog.Error(
-								"Generation preparation failed during hot reload. Fallback to cached version",
-								"error",
-								prepareErr,
-							)
-							return current, nil
-						}
-						newTenant := work.NewTenantWithRuntime(
-							e.root,
-							hostname,
-							current.AppRuntime(),
-							current.SiteRuntime(),
-							generation,
-						)
-						newTenant.SetRuntimeHealth(e.runtimeHealth)
-						newTenant.MaxEnergy = e.maxEnergy
-						newTenant.HotReload = e.hotReload
-
-						if err := newTenant.Run(); err != nil {
+						next, staged, err := e.deploy(hostname, cached)
+						switch {
+						case err != nil:
 							// Lỗi cú pháp hoặc file dở dang -> Graceful Compile Fallback
-							slog.Error("Compile error during hot reload. Fallback to cached version", "error", err)
-							newTenant.Close()
-						} else {
-							// Thành công -> cập nhật cache
-							e.mu.Lock()
-							if e.closed {
-								e.mu.Unlock()
-								newTenant.Close()
-								return nil, fmt.Errorf("engine is closed")
-							}
-							if err := e.activateGeneration(newTenant); err != nil {
-								e.mu.Unlock()
-								newTenant.Close()
-								return nil, fmt.Errorf("activate site generation: %w", err)
-							}
-							cached.mu.Lock()
-							oldTenant := cached.tenant
-							cached.tenant = newTenant
-							cached.mu.Unlock()
-							e.mu.Unlock()
-							e.drainTenant(oldTenant)
+							slog.Error("Hot reload failed. Fallback to cached version", "error", err)
+						case staged:
+							slog.Info("Staged canary generation", "hostname", hostname, "version", next.SiteGeneration().Version())
+						default:
 							slog.Info("Successfully reloaded tenant", "hostname", hostname)
 						}
 					}
@@ -483,6 +477,11 @@ func (e *Engine) run(hostname string) (*work.Tenant, error) {
 	}
 	tenant := work.NewTenantWithRuntime(e.root, hostname, appRuntime, siteRuntime, generation)
 	tenant.SetRuntimeHealth(e.runtimeHealth)
+	tenant.SetTracer(e.tracer)
+	tenant.SetAccessLog(e.accessLogLocked(tenant))
+	if e.bundles != nil { // e.mu is held: read the field directly, never a typed nil
+		tenant.SetBytecodeLoader(e.bundles)
+	}
 	tenant.MaxEnergy = e.maxEnergy
 	tenant.HotReload = e.hotReload
 
@@ -499,6 +498,7 @@ func (e *Engine) run(hostname string) (*work.Tenant, error) {
 
 	e.cache[hostname] = &cachedTenant{
 		tenant:      tenant,
+		watched:     tenant,
 		lastAccess:  time.Now(),
 		lastChecked: time.Now(),
 	}
@@ -571,11 +571,21 @@ func (e *Engine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w = observed
 	started := time.Now()
 	e.runtimeHealth.RequestStarted()
+	var generation *site.Generation // the generation that answered: its error rate judges a canary
+	var span *trace.Span            // the request's server span (trace.go); ends after a recovered panic's 503
+	var tenantHealth *work.TenantHealth
 	defer func() {
-		e.runtimeHealth.RequestCompleted(observed.Status(), time.Since(started))
+		elapsed := time.Since(started)
+		e.runtimeHealth.RequestCompleted(observed.Status(), elapsed)
+		tenantHealth.RequestCompleted(observed.Status(), elapsed)
+		generation.RecordResponse(observed.Status())
+		endRequestSpan(span, observed.Status())
 	}()
 	defer func() {
 		if rec := recover(); rec != nil {
+			if rec == http.ErrAbortHandler {
+				panic(rec) // a deliberate abort (a stream that failed mid-body): let net/http drop the connection
+			}
 			slog.Error("Critical panic recovered", "panic", rec)
 			http.Error(w, "Service Unavailable", 503)
 		}
@@ -611,14 +621,22 @@ func (e *Engine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	r, span = e.startRequestSpan(w, r, domain)
+
 	resolveStarted := time.Now()
+	_, resolveSpan := trace.Start(r.Context(), "tenant.resolve", trace.KindInternal)
 	tenant, err := e.run(domain)
 	e.runtimeHealth.RecordResolve(time.Since(resolveStarted))
+	if err != nil {
+		resolveSpan.Fail(err.Error())
+	}
+	resolveSpan.End()
 
 	if err != nil {
 		http.Error(w, err.Error(), 404)
 		return
 	}
+	tenantHealth = tenant.Health()
 
 	e.mu.RLock()
 	authorizer := e.authorizer
@@ -632,6 +650,10 @@ func (e *Engine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		r = r.WithContext(requestscope.WithAuthorization(r.Context(), authorization))
 	}
 
+	// A staged canary generation answers the requests its policy admits (canary.go).
+	tenant = e.release(domain, tenant, r)
+	generation = tenant.SiteGeneration()
+
 	// tenant := work.NewTenant(e.root, domain)
 
 	// tenant.MaxEnergy = e.maxEnergy
@@ -0,0 +1,26 @@
+package core
+
+import "github.com/kitwork/engine/work"
+
+// SetMaintenance turns a site's maintenance admin switch on or off, loading the site if needed.
+// The switch outlives generation swaps; turning it off leaves the site's maintenance file and the
+// system DB flag in charge (see work/maintenance.go).
+func (e *Engine) SetMaintenance(domain string, on bool) (work.MaintenanceStatus, error) {
+	tenant, err := e.run(domain)
+	if err != nil {
+		return work.MaintenanceStatus{}, err
+	}
+	if err := tenant.SetMaintenance(on); err != nil {
+		return work.MaintenanceStatus{}, err
+	}
+	return tenant.Maintenance(), nil
+}
+
+// Maintenance reports whether a site is in maintenance and why.
+func (e *Engine) Maintenance(domain string) (work.MaintenanceStatus, error) {
+	tenant, err := e.run(domain)
+	if err != nil {
+		return work.MaintenanceStatus{}, err
+	}
+	return tenant.Maintenance(), nil
+}
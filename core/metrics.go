@@ -0,0 +1,320 @@
+package core
+
+import (
+	"database/sql"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kitwork/engine/app"
+	"github.com/kitwork/engine/utilities/metrics"
+	"github.com/kitwork/engine/work"
+)
+
+// SetMetricTenants bounds how many tenants the metrics break out by label (default
+// work.DefaultTenantLimit); the rest share the "_other" label. Call it during host boot.
+func (e *Engine) SetMetricTenants(limit int) {
+	e.runtimeHealth.SetTenantLimit(limit)
+}
+
+// MetricsHandler serves WriteMetrics. Mount it on a private listener: the tenant labels name every
+// app this host runs.
+func (e *Engine) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", metrics.ContentType)
+		w.Header().Set("Cache-Control", "no-store")
+		e.WriteMetrics(w)
+	})
+}
+
+// tenantResources is what a scrape reads live from one tenant's resources.
+type tenantResources struct {
+	sse      int
+	queue    map[string]int64
+	database sql.DBStats
+	proxy    map[proxyUpstreamKey]*proxyUpstream
+}
+
+// proxyUpstreamKey names one upstream of one router.proxy route. Sites of an app that declare the
+// same pair count together: up only while it is up in every one of them.
+type proxyUpstreamKey struct{ route, url string }
+
+type proxyUpstream struct {
+	up                  bool
+	inflight            int64
+	successes, failures uint64
+}
+
+// WriteMetrics renders Health, the per-tenant aggregates and the live resource gauges (queue depth,
+// SSE streams, database pools, the VM pool) as OpenMetrics. The metric and label names are a stable
+// contract, listed in METRICS.md: rename one only with a deprecation.
+func (e *Engine) WriteMetrics(out io.Writer) error {
+	snapshot := e.Health()
+	w := metrics.NewWriter(out)
+
+	w.Family("kitwork_http_requests", metrics.Counter, "Requests answered, by outcome.")
+	writeOutcomes(w, "kitwork_http_requests", snapshot.Requests.Successful, snapshot.Requests.ClientErrors, snapshot.Requests.ServerErrors)
+	w.Family("kitwork_http_requests_inflight", metrics.Gauge, "Requests being served.")
+	w.Gauge("kitwork_http_requests_inflight", float64(snapshot.Requests.Inflight))
+	w.Family("kitwork_http_request_duration_seconds", metrics.Histogram, "Time to answer a request.")
+	writeLatency(w, "kitwork_http_request_duration_seconds", snapshot.Latencies.Request)
+	w.Family("kitwork_tenant_resolve_duration_seconds", metrics.Histogram, "Time to find or load the tenant of a request.")
+	writeLatency(w, "kitwork_tenant_resolve_duration_seconds", snapshot.Latencies.Resolve)
+
+	w.Family("kitwork_vm_executions", metrics.Counter, "VM executions, by result.")
+	w.Counter("kitwork_vm_executions", float64(snapshot.Successes), metrics.L("result", "success"))
+	w.Counter("kitwork_vm_executions", float64(snapshot.Failures), metrics.L("result", "failure"))
+	w.Family("kitwork_vm_instructions", metrics.Counter, "Instructions executed.")
+	w.Counter("kitwork_vm_instructions", float64(snapshot.Instructions))
+	w.Family("kitwork_vm_energy", metrics.Counter, "Energy consumed.")
+	w.Counter("kitwork_vm_energy", float64(snapshot.Energy))
+	w.Family("kitwork_vm_duration_seconds", metrics.Histogram, "Time spent in a VM execution.")
+	writeLatency(w, "kitwork_vm_duration_seconds", snapshot.Latencies.VM)
+	w.Family("kitwork_vm_pool_active", metrics.Gauge, "Pooled VMs checked out.")
+	w.Gauge("kitwork_vm_pool_active", float64(work.ActiveVMs()))
+	w.Family("kitwork_programs", metrics.Gauge, "Distinct programs executed (capped).")
+	w.Gauge("kitwork_programs", float64(snapshot.Programs))
+
+	w.Family("kitwork_renders", metrics.Counter, "Page renders, by plan.")
+	w.Counter("kitwork_renders", float64(snapshot.Presentation.PreparedRenders), metrics.L("mode", "prepared"))
+	w.Counter("kitwork_renders", float64(snapshot.Presentation.FallbackRenders), metrics.L("mode", "fallback"))
+	w.Family("kitwork_render_duration_seconds", metrics.Histogram, "Time to render a page.")
+	writeLatency(w, "kitwork_render_duration_seconds", snapshot.Latencies.Render)
+	w.Family("kitwork_response_cache_lookups", metrics.Counter, "Response cache lookups, by result.")
+	w.Counter("kitwork_response_cache_lookups", float64(snapshot.ResponseCache.Hits), metrics.L("result", "hit"))
+	w.Counter("kitwork_response_cache_lookups", float64(snapshot.ResponseCache.Misses), metrics.L("result", "miss"))
+
+	w.Family("kitwork_generation_events", metrics.Counter, "Site generation lifecycle events.")
+	generations := snapshot.Generations
+	for _, event := range []struct {
+		name  string
+		count uint64
+	}{
+		{"prepared", generations.Prepared}, {"prepare_failed", generations.PrepareFailures},
+		{"activated", generations.Activated}, {"activate_failed", generations.ActivateFailures},
+		{"drained", generations.Drained},
+	} {
+		w.Counter("kitwork_generation_events", float64(event.count), metrics.L("event", event.name))
+	}
+	w.Family("kitwork_generation_prepare_duration_seconds", metrics.Histogram, "Time to prepare a generation.")
+	writeLatency(w, "kitwork_generation_prepare_duration_seconds", snapshot.Latencies.GenerationPrepare)
+	w.Family("kitwork_generation_activate_duration_seconds", metrics.Histogram, "Time to activate a generation.")
+	writeLatency(w, "kitwork_generation_activate_duration_seconds", snapshot.Latencies.GenerationActivate)
+	w.Family("kitwork_generation_drain_duration_seconds", metrics.Histogram, "Time to drain a replaced generation.")
+	writeLatency(w, "kitwork_generation_drain_duration_seconds", snapshot.Latencies.GenerationDrain)
+	w.Family("kitwork_loaded_apps", metrics.Gauge, "App runtimes loaded.")
+	w.Gauge("kitwork_loaded_apps", float64(snapshot.LoadedApps))
+	w.Family("kitwork_loaded_sites", metrics.Gauge, "Sites loaded.")
+	w.Gauge("kitwork_loaded_sites", float64(snapshot.LoadedSites))
+	w.Family("kitwork_active_generations", metrics.Gauge, "Generations serving.")
+	w.Gauge("kitwork_active_generations", float64(snapshot.ActiveGenerations))
+	w.Family("kitwork_generation_leases", metrics.Gauge, "Requests holding a generation lease.")
+	w.Gauge("kitwork_generation_leases", float64(snapshot.ActiveGenerationLeases))
+
+	resources := e.tenantResources()
+	tenants := e.runtimeHealth.Tenants()
+
+	w.Family("kitwork_tenant_requests", metrics.Counter, "Requests answered per tenant, by outcome.")
+	for _, tenant := range tenants {
+		writeOutcomes(w, "kitwork_tenant_requests", tenant.Successful, tenant.ClientErrors, tenant.ServerErrors, metrics.L("tenant", tenant.Tenant))
+	}
+	w.Family("kitwork_tenant_request_duration_seconds", metrics.Histogram, "Time to answer a request, per tenant.")
+	for _, tenant := range tenants {
+		writeLatency(w, "kitwork_tenant_request_duration_seconds", tenant.RequestLatency, metrics.L("tenant", tenant.Tenant))
+	}
+	w.Family("kitwork_tenant_vm_executions", metrics.Counter, "VM executions per tenant, by result.")
+	for _, tenant := range tenants {
+		label := metrics.L("tenant", tenant.Tenant)
+		w.Counter("kitwork_tenant_vm_executions", float64(tenant.Successes), label, metrics.L("result", "success"))
+		w.Counter("kitwork_tenant_vm_executions", float64(tenant.Failures), label, metrics.L("result", "failure"))
+	}
+	w.Family("kitwork_tenant_energy", metrics.Counter, "Energy consumed per tenant: requests and jobs.")
+	for _, tenant := range tenants {
+		w.Counter("kitwork_tenant_energy", float64(tenant.Energy), metrics.L("tenant", tenant.Tenant))
+	}
+	w.Family("kitwork_cron_runs", metrics.Counter, "Cron runs started per tenant (first attempts).")
+	for _, tenant := range tenants {
+		w.Counter("kitwork_cron_runs", float64(tenant.CronRuns), metrics.L("tenant", tenant.Tenant))
+	}
+	w.Family("kitwork_cron_lag_seconds", metrics.Gauge, "How late the tenant's last cron run started after its slot.")
+	for _, tenant := range tenants {
+		if tenant.CronRuns > 0 {
+			w.Gauge("kitwork_cron_lag_seconds", float64(tenant.CronLagNanoseconds)/1e9, metrics.L("tenant", tenant.Tenant))
+		}
+	}
+
+	labels := make([]string, 0, len(resources))
+	for label := range resources {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	w.Family("kitwork_queue_depth", metrics.Gauge, "Messages waiting per queue (apps whose worker runs on this node).")
+	for _, label := range labels {
+		queues := make([]string, 0, len(resources[label].queue))
+		for queue := range resources[label].queue {
+			queues = append(queues, queue)
+		}
+		sort.Strings(queues)
+		for _, queue := range queues {
+			w.Gauge("kitwork_queue_depth", float64(resources[label].queue[queue]), metrics.L("tenant", label), metrics.L("queue", queue))
+		}
+	}
+	w.Family("kitwork_sse_connections", metrics.Gauge, "Open server-sent event streams per tenant.")
+	for _, label := range labels {
+		w.Gauge("kitwork_sse_connections", float64(resources[label].sse), metrics.L("tenant", label))
+	}
+	w.Family("kitwork_proxy_upstream_up", metrics.Gauge, "1 while a router.proxy upstream takes traffic: healthy and its breaker not open.")
+	for _, label := range labels {
+		for _, key := range proxyKeys(resources[label].proxy) {
+			w.Gauge("kitwork_proxy_upstream_up", boolGauge(resources[label].proxy[key].up), proxyLabels(label, key)...)
+		}
+	}
+	w.Family("kitwork_proxy_upstream_inflight", metrics.Gauge, "Requests in flight to a router.proxy upstream.")
+	for _, label := range labels {
+		for _, key := range proxyKeys(resources[label].proxy) {
+			w.Gauge("kitwork_proxy_upstream_inflight", float64(resources[label].proxy[key].inflight), proxyLabels(label, key)...)
+		}
+	}
+	w.Family("kitwork_proxy_upstream_requests", metrics.Counter, "Attempts on a router.proxy upstream, by result.")
+	for _, label := range labels {
+		for _, key := range proxyKeys(resources[label].proxy) {
+			stats := resources[label].proxy[key]
+			w.Counter("kitwork_proxy_upstream_requests", float64(stats.successes), append(proxyLabels(label, key), metrics.L("result", "success"))...)
+			w.Counter("kitwork_proxy_upstream_requests", float64(stats.failures), append(proxyLabels(label, key), metrics.L("result", "failure"))...)
+		}
+	}
+	w.Family("kitwork_db_connections", metrics.Gauge, "Database pool connections per tenant, by state.")
+	for _, label := range labels {
+		stats := resources[label].database
+		w.Gauge("kitwork_db_connections", float64(stats.InUse), metrics.L("tenant", label), metrics.L("state", "in_use"))
+		w.Gauge("kitwork_db_connections", float64(stats.Idle), metrics.L("tenant", label), metrics.L("state", "idle"))
+	}
+	w.Family("kitwork_db_waits", metrics.Counter, "Times a query waited for a pool connection, per tenant.")
+	for _, label := range labels {
+		w.Counter("kitwork_db_waits", float64(resources[label].database.WaitCount), metrics.L("tenant", label))
+	}
+	w.Family("kitwork_db_wait_seconds", metrics.Counter, "Time queries spent waiting for a pool connection, per tenant.")
+	for _, label := range labels {
+		w.Counter("kitwork_db_wait_seconds", resources[label].database.WaitDuration.Seconds(), metrics.L("tenant", label))
+	}
+	return w.Close()
+}
+
+// tenantResources reads the live gauges of every loaded app and site, folded onto the bounded
+// tenant labels. App runtimes own the queue worker and the database pools; sites own their streams.
+func (e *Engine) tenantResources() map[string]*tenantResources {
+	e.mu.RLock()
+	apps := make(map[string]*app.Runtime, len(e.appRuntimes))
+	for key, appRuntime := range e.appRuntimes {
+		// A host that failed to resolve leaves an empty runtime behind; it is not a tenant.
+		if appRuntime.SiteCount() > 0 || e.appTenants[appRuntime.ID()] != nil {
+			apps[key] = appRuntime
+		}
+	}
+	sites := make([]*work.Tenant, 0, len(e.cache))
+	for _, cached := range e.cache {
+		sites = append(sites, cached.current())
+	}
+	e.mu.RUnlock()
+
+	out := make(map[string]*tenantResources)
+	entry := func(label string) *tenantResources {
+		label = e.runtimeHealth.Tenant(label).Label()
+		if out[label] == nil {
+			out[label] = &tenantResources{}
+		}
+		return out[label]
+	}
+	for key, appRuntime := range apps {
+		_, label, _ := strings.Cut(key, ":") // appRuntimeKey: "app:<identity>" or "site:<domain>", the app id
+		resources := entry(label)
+		for queue, depth := range work.QueueDepth(appRuntime) {
+			if resources.queue == nil {
+				resources.queue = make(map[string]int64)
+			}
+			resources.queue[queue] += depth
+		}
+		addDBStats(&resources.database, appRuntime.Databases().Stats())
+	}
+	for _, tenant := range sites {
+		if tenant == nil {
+			continue
+		}
+		resources := entry(tenant.AppID())
+		resources.sse += tenant.SSEConnections()
+		for _, status := range tenant.ProxyUpstreams() {
+			if resources.proxy == nil {
+				resources.proxy = make(map[proxyUpstreamKey]*proxyUpstream)
+			}
+			key := proxyUpstreamKey{status.Route, status.URL}
+			stats := resources.proxy[key]
+			if stats == nil {
+				stats = &proxyUpstream{up: true}
+				resources.proxy[key] = stats
+			}
+			stats.up = stats.up && status.Up
+			stats.inflight += status.Inflight
+			stats.successes += status.Successes
+			stats.failures += status.Failures
+		}
+	}
+	return out
+}
+
+func proxyKeys(upstreams map[proxyUpstreamKey]*proxyUpstream) []proxyUpstreamKey {
+	keys := make([]proxyUpstreamKey, 0, len(upstreams))
+	for key := range upstreams {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].route != keys[j].route {
+			return keys[i].route < keys[j].route
+		}
+		return keys[i].url < keys[j].url
+	})
+	return keys
+}
+
+func proxyLabels(tenant string, key proxyUpstreamKey) []metrics.Label {
+	return []metrics.Label{metrics.L("tenant", tenant), metrics.L("route", key.route), metrics.L("upstream", key.url)}
+}
+
+func boolGauge(ok bool) float64 {
+	if ok {
+		return 1
+	}
+	return 0
+}
+
+func addDBStats(total *sql.DBStats, stats sql.DBStats) {
+	total.InUse += stats.InUse
+	total.Idle += stats.Idle
+	total.WaitCount += stats.WaitCount
+	total.WaitDuration += stats.WaitDuration
+}
+
+func writeOutcomes(w *metrics.Writer, name string, success, clientErrors, serverErrors uint64, labels ...metrics.Label) {
+	for _, outcome := range []struct {
+		name  string
+		count uint64
+	}{{"success", success}, {"client_error", clientErrors}, {"server_error", serverErrors}} {
+		w.Counter(name, float64(outcome.count), append(labels[:len(labels):len(labels)], metrics.L("outcome", outcome.name))...)
+	}
+}
+
+func writeLatency(w *metrics.Writer, name string, latency work.LatencySnapshot, labels ...metrics.Label) {
+	bounds := make([]float64, len(latency.Buckets))
+	counts := make([]uint64, len(latency.Buckets))
+	for i, bucket := range latency.Buckets {
+		bounds[i] = (time.Duration(bucket.UpperBoundMicroseconds) * time.Microsecond).Seconds()
+		counts[i] = bucket.Count
+	}
+	w.Histogram(name, bounds, counts, latency.Count, float64(latency.TotalNanoseconds)/1e9, labels...)
+}
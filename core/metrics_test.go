@@ -0,0 +1,57 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kitwork/engine/utilities/metrics"
+)
+
+func TestEngineMetricsExposition(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTreeTenant(t, tmpDir, "ok")
+	engine := New(tmpDir, 0, false, "")
+	t.Cleanup(engine.Close)
+
+	for _, path := range []string{"/", "/missing"} {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://localhost"+path, nil))
+	}
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://unknown.test/", nil))
+
+	rec := httptest.NewRecorder()
+	engine.MetricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != metrics.ContentType {
+		t.Fatalf("status %d, content type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		// The unknown host counts for the process, never as a tenant of its own.
+		`kitwork_http_requests_total{outcome="success"} 1`,
+		`kitwork_http_requests_total{outcome="client_error"} 2`,
+		`kitwork_tenant_requests_total{tenant="localhost",outcome="success"} 1`,
+		`kitwork_tenant_requests_total{tenant="localhost",outcome="client_error"} 1`,
+		`kitwork_tenant_request_duration_seconds_count{tenant="localhost"} 2`,
+		`kitwork_tenant_request_duration_seconds_bucket{tenant="localhost",le="+Inf"} 2`,
+		`kitwork_tenant_vm_executions_total{tenant="localhost",result="success"}`,
+		`kitwork_sse_connections{tenant="localhost"} 0`,
+		`kitwork_db_connections{tenant="localhost",state="in_use"} 0`,
+		"# TYPE kitwork_queue_depth gauge",
+		"kitwork_vm_pool_active 0",
+		"kitwork_loaded_sites 1",
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("metrics lack %s:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, `tenant="unknown.test"`) || !strings.HasSuffix(body, "# EOF\n") {
+		t.Fatalf("exposition:\n%s", body)
+	}
+
+	rec = httptest.NewRecorder()
+	engine.MetricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/metrics", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("POST status %d", rec.Code)
+	}
+}
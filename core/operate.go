@@ -0,0 +1,194 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/kitwork/engine/work"
+)
+
+// Operating a running host: what the admin API (admin.go) exposes, as plain engine methods.
+
+// SiteStatus is one loaded site and its live generations.
+type SiteStatus struct {
+	Domain      string                 `json:"domain"`
+	App         string                 `json:"app"`
+	LastAccess  time.Time              `json:"last_access"`
+	Releases    []Release              `json:"releases"`
+	Maintenance work.MaintenanceStatus `json:"maintenance"`
+}
+
+// Sites lists the loaded sites, sorted by domain. Sites load on their first request, so one that
+// has not been hit since the last eviction is absent.
+func (e *Engine) Sites() []SiteStatus {
+	e.mu.RLock()
+	domains := make([]string, 0, len(e.cache))
+	for domain, cached := range e.cache {
+		if cached.current() != nil {
+			domains = append(domains, domain)
+		}
+	}
+	e.mu.RUnlock()
+	sort.Strings(domains)
+
+	sites := make([]SiteStatus, 0, len(domains))
+	for _, domain := range domains {
+		releases, err := e.Releases(domain)
+		if err != nil {
+			continue // evicted since the listing
+		}
+		e.mu.RLock()
+		cached := e.cache[domain]
+		e.mu.RUnlock()
+		if cached == nil {
+			continue
+		}
+		cached.mu.Lock()
+		tenant, lastAccess := cached.tenant, cached.lastAccess
+		cached.mu.Unlock()
+		sites = append(sites, SiteStatus{
+			Domain:      domain,
+			App:         tenant.AppID(),
+			LastAccess:  lastAccess,
+			Releases:    releases,
+			Maintenance: tenant.Maintenance(),
+		})
+	}
+	return sites
+}
+
+// Evict unloads a site now: every generation drains (in-flight requests finish) and the next
+// request loads it afresh from disk.
+func (e *Engine) Evict(domain string) error {
+	e.mu.Lock()
+	cached := e.cache[domain]
+	delete(e.cache, domain)
+	e.mu.Unlock()
+	if cached == nil {
+		return fmt.Errorf("site %q is not loaded", domain)
+	}
+	cached.reloadMu.Lock() // let a deploy in progress finish before its generations go
+	defer cached.reloadMu.Unlock()
+	e.unload(cached)
+	return nil
+}
+
+// unload drains a site already removed from e.cache and releases what it held.
+func (e *Engine) unload(cached *cachedTenant) {
+	for _, tenant := range cached.tenants() {
+		e.drainTenant(tenant)
+	}
+	tenant := cached.current()
+	if owner := tenant.AppRuntime(); owner != nil {
+		owner.RemoveSite(tenant.Domain())
+	}
+	_ = tenant.AccessLog().Close() // reopened by the site's next request
+}
+
+// Drain retires every generation of a site except the current one — the staged candidate and the
+// ones kept warm for rollback — once their in-flight requests finish. Returns how many went.
+func (e *Engine) Drain(domain string) (int, error) {
+	e.mu.RLock()
+	cached := e.cache[domain]
+	e.mu.RUnlock()
+	if cached == nil {
+		return 0, fmt.Errorf("site %q is not loaded", domain)
+	}
+	cached.reloadMu.Lock()
+	defer cached.reloadMu.Unlock()
+	cached.mu.Lock()
+	drained := cached.retained
+	if cached.candidate != nil {
+		drained = append([]*work.Tenant{cached.candidate}, drained...)
+	}
+	cached.candidate, cached.retained = nil, nil
+	cached.mu.Unlock()
+	for _, tenant := range drained {
+		e.drainTenant(tenant)
+	}
+	return len(drained), nil
+}
+
+// TenantHealth is the health aggregate of a site's app, loading the site if needed.
+func (e *Engine) TenantHealth(domain string) (work.TenantHealthSnapshot, error) {
+	tenant, err := e.run(domain)
+	if err != nil {
+		return work.TenantHealthSnapshot{}, err
+	}
+	return tenant.Health().Snapshot(), nil
+}
+
+// Crons lists the crons of a site's app.
+func (e *Engine) Crons(domain string) ([]map[string]any, error) {
+	tenant, err := e.run(domain)
+	if err != nil {
+		return nil, err
+	}
+	return tenant.Crons(), nil
+}
+
+// PauseCron pauses or resumes one cron of a site's app.
+func (e *Engine) PauseCron(domain, name string, paused bool) error {
+	tenant, err := e.run(domain)
+	if err != nil {
+		return err
+	}
+	return tenant.PauseCron(name, paused)
+}
+
+// TriggerCron queues a run of one cron of a site's app now, and returns the slot it was given.
+func (e *Engine) TriggerCron(domain, name string) (time.Time, error) {
+	tenant, err := e.run(domain)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return tenant.TriggerCron(name)
+}
+
+// QueueJobs lists the queued messages of a site's app.
+func (e *Engine) QueueJobs(domain, queue, status string, limit int) ([]map[string]any, error) {
+	tenant, err := e.run(domain)
+	if err != nil {
+		return nil, err
+	}
+	return tenant.QueueJobs(queue, status, limit), nil
+}
+
+// RetryQueueJobs revives dead-lettered messages of a site's app: one by id, or every failure of
+// the queue when jobID is 0.
+func (e *Engine) RetryQueueJobs(domain, queue string, jobID int64) (int64, error) {
+	tenant, err := e.run(domain)
+	if err != nil {
+		return 0, err
+	}
+	return tenant.RetryQueueJobs(queue, jobID), nil
+}
+
+// FlushResponseCache drops a site's cached route responses in every live generation. Returns how
+// many RAM and disk entries went.
+func (e *Engine) FlushResponseCache(domain string) (ram, disk int, err error) {
+	e.mu.RLock()
+	cached := e.cache[domain]
+	e.mu.RUnlock()
+	if cached == nil {
+		return 0, 0, fmt.Errorf("site %q is not loaded", domain)
+	}
+	for _, tenant := range cached.tenants() {
+		r, d, flushErr := tenant.FlushResponseCache()
+		ram, disk = ram+r, disk+d
+		if flushErr != nil {
+			err = flushErr
+		}
+	}
+	return ram, disk, err
+}
+
+// CheckSite runs Check for one site: its generation is prepared from the sources on disk, apart
+// from the one serving, and its app's crons and queues are compiled.
+func (e *Engine) CheckSite(domain string) CheckReport {
+	e.bytecodeCacheMu.RLock()
+	directory := e.bytecodeCacheDir
+	e.bytecodeCacheMu.RUnlock()
+	return check(e.root, e.maxEnergy, directory, domain)
+}
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"net/http"
+
+	"github.com/kitwork/engine/utilities/trace"
+)
+
+// SetTracer turns on distributed tracing: every request continues the caller's W3C trace context
+// (or starts one) and its spans — tenant resolve, guards, VM execution, render, queries, fetches,
+// queue and cron jobs — are exported through tracer. Call it ONCE at boot, before serving and
+// before StartAppSchedulers; nil (the default) traces nothing. Close flushes it.
+func (e *Engine) SetTracer(tracer *trace.Tracer) {
+	e.tracer = tracer
+}
+
+// SetTraceSampling sets the share of new traces a site records (0..1), overriding the tracer's
+// default; a negative rate restores the default. A request that arrives with a sampled parent is
+// always recorded, so a trace is never cut in half at this hop. With tracing off it does nothing.
+func (e *Engine) SetTraceSampling(domain string, rate float64) {
+	e.tracer.SetSampling(domain, rate)
+}
+
+// startRequestSpan opens the server span of r for domain and echoes its context in the response
+// headers, so a caller can find the trace its request produced.
+func (e *Engine) startRequestSpan(w http.ResponseWriter, r *http.Request, domain string) (*http.Request, *trace.Span) {
+	if e.tracer == nil {
+		return r, nil
+	}
+	ctx, span := e.tracer.Begin(r.Context(), r.Method, trace.KindServer, trace.Extract(r.Header), domain)
+	trace.Inject(ctx, w.Header())
+	if span.Recording() {
+		span.SetAttribute("http.request.method", r.Method)
+		span.SetAttribute("url.path", r.URL.Path)
+		span.SetAttribute("server.address", domain)
+		if agent := r.UserAgent(); agent != "" {
+			span.SetAttribute("user_agent.original", agent)
+		}
+	}
+	return r.WithContext(ctx), span
+}
+
+// endRequestSpan records the answer: a 5xx is an error of this service, a 4xx is the caller's.
+func endRequestSpan(span *trace.Span, status int) {
+	if status == 0 {
+		status = http.StatusOK
+	}
+	span.SetAttribute("http.response.status_code", status)
+	if status >= 500 {
+		span.Fail(http.StatusText(status))
+	}
+	span.End()
+}
@@ -0,0 +1,73 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kitwork/engine/utilities/trace"
+)
+
+// The engine continues an incoming trace, echoes it to the caller and, on Close, flushes the server
+// and resolve spans to the exporter.
+func TestEngineContinuesIncomingTrace(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTreeTenant(t, tmpDir, "v1")
+	out := filepath.Join(t.TempDir(), "spans.jsonl")
+	engine := New(tmpDir, 0, false, "")
+	engine.SetTracer(trace.NewTracer(trace.NewFileExporter(out), trace.Options{ServiceName: "edge"}))
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "http://localhost/", nil)
+	req.Header.Set("Traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+	echoed, ok := trace.ParseTraceparent(rec.Header().Get("Traceparent"))
+	if !ok || echoed.TraceID.String() != traceID || echoed.SpanID.String() == "00f067aa0ba902b7" {
+		t.Fatalf("response traceparent %q", rec.Header().Get("Traceparent"))
+	}
+
+	engine.Close()
+	exported, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"name":"GET"`, `"name":"tenant.resolve"`, `"name":"vm.execute"`,
+		`"traceId":"` + traceID + `"`, `"parentSpanId":"00f067aa0ba902b7"`, `"stringValue":"edge"`} {
+		if !strings.Contains(string(exported), want) {
+			t.Fatalf("export lacks %s:\n%s", want, exported)
+		}
+	}
+}
+
+// A site sampled at zero records nothing of its own traces, but still answers with a context.
+func TestEngineTraceSamplingPerSite(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTreeTenant(t, tmpDir, "v1")
+	out := filepath.Join(t.TempDir(), "spans.jsonl")
+	untraced := New(tmpDir, 0, false, "")
+	untraced.SetTraceSampling("localhost", 0.5) // tracing off: a no-op, not a panic
+	untraced.Close()
+	engine := New(tmpDir, 0, false, "")
+	engine.SetTracer(trace.NewTracer(trace.NewFileExporter(out), trace.Options{}))
+	engine.SetTraceSampling("localhost", 0)
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://localhost/", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d", rec.Code)
+	}
+	if rec.Header().Get("Traceparent") == "" {
+		t.Fatal("no traceparent on an unsampled request")
+	}
+	engine.Close()
+	if exported, err := os.ReadFile(out); err == nil && len(exported) > 0 {
+		t.Fatalf("unsampled site exported spans:\n%s", exported)
+	}
+}
@@ -36,6 +36,18 @@ func DomainRedirect(domain string) (target string, err error) {
 	return
 }
 
+// DomainMaintenance reports the `maintenance` flag of a host in the system `domain` table: false
+// when it is unset, there is no row or no system DB.
+func DomainMaintenance(domain string) (on bool, err error) {
+	if System != nil {
+		var flag sql.NullBool
+		query := "SELECT maintenance FROM domain WHERE hostname = $1"
+		err = System.QueryRow(query, domain).Scan(&flag)
+		on = err == nil && flag.Valid && flag.Bool
+	}
+	return
+}
+
 func DomainSystemExists(domain string) (exists bool, err error) {
 	if System != nil {
 		query := "SELECT EXISTS(SELECT 1 FROM domain WHERE hostname = $1)"
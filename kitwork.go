@@ -2,9 +2,12 @@ package engine
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -13,13 +16,18 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/kitwork/engine/bundle"
 	"github.com/kitwork/engine/core"
 	"github.com/kitwork/engine/database"
 	"github.com/kitwork/engine/domain"
 	"github.com/kitwork/engine/host"
 	"github.com/kitwork/engine/logger"
+	"github.com/kitwork/engine/utilities/accesslog"
 	"github.com/kitwork/engine/utilities/compress"
+	"github.com/kitwork/engine/utilities/trace"
 	"github.com/kitwork/engine/work"
+
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 func Run(configFile ...string) (err error) {
@@ -171,6 +179,20 @@ func Run(configFile ...string) (err error) {
 		handler.SetBytecodeCache(directory)
 	}
 
+	// Packaged deployment: serve tenants from signed .kwpkg archives with no source on the server.
+	// A package that fails signature or compiler-fingerprint verification refuses the boot.
+	if cfg.Bundles != "" {
+		publicKey, keyErr := bundle.ParsePublicKey(cfg.BundleKey)
+		if keyErr != nil {
+			return fmt.Errorf("bundles: %w", keyErr)
+		}
+		installed, loadErr := handler.LoadBundles(bundleDirectory(cfg), publicKey)
+		if loadErr != nil {
+			return fmt.Errorf("bundles: %w", loadErr)
+		}
+		slog.Info("Deployment packages installed", "count", installed, "dir", bundleDirectory(cfg))
+	}
+
 	// Client-IP source: as the edge server Kitwork ignores X-Forwarded-For by default (spoofable);
 	// trust_proxy: true opts in when running behind your own reverse proxy.
 	work.TrustProxyHeaders = cfg.TrustProxy
@@ -187,6 +209,35 @@ func Run(configFile ...string) (err error) {
 		})
 	}
 
+	// Tracing (before the schedulers start, so their jobs are traced too). Spans are batched and
+	// exported in the background; handler.Close flushes the last of them.
+	if cfg.Tracing != nil {
+		handler.SetTracer(newTracer(cfg.Tracing))
+		for site, rate := range cfg.Tracing.Sites {
+			handler.SetTraceSampling(site, rate)
+		}
+		slog.Info("Tracing enabled", "endpoint", cfg.Tracing.Endpoint, "file", cfg.Tracing.File, "sample", cfg.Tracing.Sample)
+	}
+
+	// Access logs: each site opens <site>/.kitwork/logs/access.log as it loads.
+	if cfg.AccessLog != nil {
+		handler.SetAccessLog(accesslog.Options{
+			Format:     cfg.AccessLog.Format,
+			MaxSize:    cfg.AccessLog.MaxSize,
+			MaxBackups: cfg.AccessLog.MaxBackups,
+			MaxAge:     cfg.AccessLog.MaxAge,
+			Compress:   cfg.AccessLog.Compress,
+			Redact:     cfg.AccessLog.Redact,
+			Headers:    cfg.AccessLog.Headers,
+		})
+		slog.Info("Access logs enabled", "format", cfg.AccessLog.Format)
+	}
+
+	// The metrics label cap must be in place before the first tenant (an app scheduler) is labelled.
+	if cfg.Metrics != nil {
+		handler.SetMetricTenants(cfg.Metrics.Tenants)
+	}
+
 	// FILESYSTEM-ROUTED is lazy BY DESIGN: nothing is scanned or compiled at startup — the engine is
 	// idle until the first request, and each folder's router.kitwork.js compiles on first hit. So
 	// there is NO route prewarm; the old eager route-registration is gone with the flat model.
@@ -204,7 +255,40 @@ func Run(configFile ...string) (err error) {
 	// formats and tiny bodies alone; see utilities/compress.
 	srvHandler := compress.Middleware(handler)
 	var servers []*http.Server
-	serverErrors := make(chan error, 2)
+	serverErrors := make(chan error, 4)
+
+	// Metrics get a listener of their own (a private address), never a route on the public one: the
+	// tenant labels name every app on the host.
+	if cfg.Metrics != nil {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle(cfg.Metrics.Path, handler.MetricsHandler())
+		metricsServer := &http.Server{
+			Addr:              cfg.Metrics.Listen,
+			Handler:           metricsMux,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		servers = append(servers, metricsServer)
+		go func() {
+			serverErrors <- metricsServer.ListenAndServe()
+		}()
+		slog.Info("Metrics listener", "listen", cfg.Metrics.Listen, "path", cfg.Metrics.Path)
+	}
+	// The admin API likewise: it can reload, drain and evict any site on the host.
+	if cfg.Admin != nil {
+		adminServer, adminListener, adminErr := newAdminServer(cfg, handler)
+		if adminErr != nil {
+			return fmt.Errorf("admin: %w", adminErr)
+		}
+		servers = append(servers, adminServer)
+		go func() {
+			if adminServer.TLSConfig != nil {
+				serverErrors <- adminServer.ServeTLS(adminListener, "", "")
+				return
+			}
+			serverErrors <- adminServer.Serve(adminListener)
+		}()
+		slog.Info("Admin listener", "listen", cfg.Admin.Listen, "tls", adminServer.TLSConfig != nil)
+	}
 	if !host.IsLocalhost() && !cfg.AllowLocal {
 		tlsConfig := domain.AutoSSL(cfg.Domains)
 
@@ -289,6 +373,30 @@ func Profile(configFile ...string) (core.ProfileReport, error) {
 	return core.Profile(cfg.Root), nil
 }
 
+// BundleReport describes one package written by Bundle.
+type BundleReport = core.BundleReport
+
+// Bundle compiles one tenant folder (relative to the configured apps root) into a signed
+// <digest>.kwpkg under output. privateKey is the hex ed25519 signing key (seed or full key).
+func Bundle(name, output, privateKey string, configFile ...string) (core.BundleReport, error) {
+	cfg, err := commandConfig(configFile...)
+	if err != nil {
+		return core.BundleReport{}, err
+	}
+	key, err := bundle.ParsePrivateKey(privateKey)
+	if err != nil {
+		return core.BundleReport{}, err
+	}
+	return core.BuildBundle(cfg.Root, name, output, key)
+}
+
+func bundleDirectory(cfg *Config) string {
+	if filepath.IsAbs(cfg.Bundles) {
+		return cfg.Bundles
+	}
+	return filepath.Join(cfg.Root, cfg.Bundles)
+}
+
 func bytecodeCacheDirectory(cfg *Config) string {
 	if cfg == nil || !cfg.BytecodeCache {
 		return ""
@@ -411,3 +519,78 @@ func printBanner(cfg *Config, isLocalhost bool) {
 	}
 	fmt.Println()
 }
+
+// newTracer builds the span exporter a tracing block names: the collector endpoint, else the file.
+func newTracer(cfg *TracingConfig) *trace.Tracer {
+	var exporter trace.Exporter
+	if cfg.Endpoint != "" {
+		exporter = trace.NewHTTPExporter(cfg.Endpoint, cfg.Headers)
+	} else {
+		exporter = trace.NewFileExporter(cfg.File)
+	}
+	rate := cfg.Sample
+	if rate <= 0 {
+		rate = -1 // Options reads 0 as "default"; negative is "record none"
+	}
+	return trace.NewTracer(exporter, trace.Options{ServiceName: cfg.Service, SampleRate: rate})
+}
+
+// newAdminServer builds the admin API server and opens its listener: TCP, or a unix socket only the
+// host's user can use. Certificates load here, so a bad path fails the boot.
+func newAdminServer(cfg *Config, handler *core.Engine) (*http.Server, net.Listener, error) {
+	auditPath := cfg.Admin.Audit
+	if auditPath == "" {
+		auditPath = filepath.Join(cfg.Root, ".kitwork", "logs", "admin.log")
+	}
+	server := &http.Server{
+		Handler: handler.AdminHandler(core.AdminOptions{
+			Token: cfg.Admin.Token,
+			Audit: &lumberjack.Logger{Filename: auditPath, MaxSize: 10, MaxBackups: 5, MaxAge: 90},
+		}),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	if cfg.Admin.TLSCert != "" {
+		certificate, err := tls.LoadX509KeyPair(cfg.Admin.TLSCert, cfg.Admin.TLSKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		server.TLSConfig = &tls.Config{Certificates: []tls.Certificate{certificate}, MinVersion: tls.VersionTLS12}
+		if cfg.Admin.ClientCA != "" {
+			pem, err := os.ReadFile(cfg.Admin.ClientCA)
+			if err != nil {
+				return nil, nil, err
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, nil, fmt.Errorf("client_ca %s holds no certificate", cfg.Admin.ClientCA)
+			}
+			server.TLSConfig.ClientCAs = pool
+			// A token still admits a caller without a certificate; without one, a certificate is the
+			// only way in.
+			server.TLSConfig.ClientAuth = tls.VerifyClientCertIfGiven
+			if cfg.Admin.Token == "" {
+				server.TLSConfig.ClientAuth = tls.RequireAndVerifyClientCert
+			}
+		}
+	}
+
+	if socket, ok := strings.CutPrefix(cfg.Admin.Listen, "unix:"); ok {
+		if err := os.Remove(socket); err != nil && !os.IsNotExist(err) {
+			return nil, nil, err
+		}
+		listener, err := net.Listen("unix", socket)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := os.Chmod(socket, 0o600); err != nil {
+			listener.Close()
+			return nil, nil, err
+		}
+		return server, listener, nil
+	}
+	listener, err := net.Listen("tcp", cfg.Admin.Listen)
+	if err != nil {
+		return nil, nil, err
+	}
+	return server, listener, nil
+}
@@ -27,6 +27,8 @@ const (
 	expressionMultiply
 	expressionDivide
 	expressionModulo
+	expressionTranslate
+	expressionFlag
 )
 
 type expression struct {
@@ -39,6 +41,18 @@ type expression struct {
 	alt   *expression
 }
 
+// Translator answers {{ t "key" }}. The binding carries one as $.i18n for the request's locale;
+// params is whatever follows the key — a map of placeholders, or a number used as the plural count.
+type Translator interface {
+	Translate(key string, params value.Value) string
+}
+
+// Flagger answers {{ flag "name" }}. The binding carries one as $.flags for the request: true or
+// false for a plain feature flag, the variant name for an experiment.
+type Flagger interface {
+	Flag(name string) value.Value
+}
+
 type renderScope struct {
 	parent *renderScope
 	values map[string]value.Value
@@ -130,6 +144,9 @@ func compileOutputExpression(raw string) (*expression, bool) {
 
 func compileExpression(raw string) *expression {
 	source := strings.TrimSpace(raw)
+	if helper := compileHelper(source); helper != nil {
+		return helper
+	}
 	if len(source) >= 2 &&
 		((source[0] == '"' && source[len(source)-1] == '"') ||
 			(source[0] == '\'' && source[len(source)-1] == '\'')) {
@@ -214,6 +231,78 @@ func compileExpression(raw string) *expression {
 	}
 }
 
+// compileHelper recognises the template helpers: t "key" and t key.path, each optionally followed
+// by a params expression (t "cart.items" cart.count), and flag "name". A flag helper followed by
+// anything else is left to the operator splits, so flag "checkout" == "redesign" compares.
+func compileHelper(source string) *expression {
+	name, rest, found := strings.Cut(source, " ")
+	if !found {
+		return nil
+	}
+	var kind expressionKind
+	switch name {
+	case "t":
+		kind = expressionTranslate
+	case "flag":
+		kind = expressionFlag
+	default:
+		return nil
+	}
+	rest = strings.TrimSpace(rest)
+	if rest == "" {
+		return nil
+	}
+	end := -1
+	switch c := rest[0]; {
+	case c == '"' || c == '\'':
+		if closing := strings.IndexByte(rest[1:], c); closing >= 0 {
+			end = closing + 2
+		}
+	case c == '$' || c == '_' || (c|0x20 >= 'a' && c|0x20 <= 'z'):
+		end = strings.IndexAny(rest, " \t")
+		if end < 0 {
+			end = len(rest)
+		}
+	}
+	if end < 0 {
+		return nil
+	}
+	compiled := &expression{kind: kind, left: compileExpression(rest[:end])}
+	if params := strings.TrimSpace(rest[end:]); params != "" {
+		if kind == expressionFlag {
+			return nil
+		}
+		compiled.right = compileExpression(params)
+	}
+	return compiled
+}
+
+// translate resolves a compiled t helper against the binding's $.i18n. Without a translator the key
+// itself is shown, the same fallback a missing message gets.
+func translate(compiled *expression, data value.Value, scope *renderScope) value.Value {
+	key := resolveExpression(compiled.left, data, scope)
+	if key.IsBlank() {
+		return value.New("")
+	}
+	var params value.Value
+	if compiled.right != nil {
+		params = resolveExpression(compiled.right, data, scope)
+	}
+	if translator, ok := resolvePath([]string{"$", "i18n"}, data, scope).V.(Translator); ok {
+		return value.New(translator.Translate(key.Text(), params))
+	}
+	return value.New(key.Text())
+}
+
+// flag resolves a compiled flag helper against the binding's $.flags; without one every flag is off.
+func flag(compiled *expression, data value.Value, scope *renderScope) value.Value {
+	name := resolveExpression(compiled.left, data, scope)
+	if flagger, ok := resolvePath([]string{"$", "flags"}, data, scope).V.(Flagger); ok && !name.IsBlank() {
+		return flagger.Flag(name.Text())
+	}
+	return value.New(false)
+}
+
 func resolveExpression(
 	compiled *expression,
 	data value.Value,
@@ -251,6 +340,10 @@ func resolveExpression(
 		return left
 	case expressionPath:
 		return resolvePath(compiled.parts, data, scope)
+	case expressionTranslate:
+		return translate(compiled, data, scope)
+	case expressionFlag:
+		return flag(compiled, data, scope)
 	}
 
 	left := resolveExpression(compiled.left, data, scope)
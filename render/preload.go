@@ -0,0 +1,114 @@
+package render
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Preload is one same-origin asset a page needs before first paint: the KitJS runtime, vendored
+// font faces, linked stylesheets. The server announces them ahead of the HTML (103 Early Hints)
+// and again on the final response, so the browser starts fetching while the page is still being
+// produced.
+type Preload struct {
+	Href        string
+	As          string // script, style, font, image
+	Type        string // font/woff2, …; optional
+	CrossOrigin bool   // fonts and integrity-checked scripts are fetched in CORS mode
+}
+
+// Link formats the preload as one Link header value.
+func (p Preload) Link() string {
+	var b strings.Builder
+	b.WriteString("<" + p.Href + ">; rel=preload; as=" + p.As)
+	if p.Type != "" {
+		b.WriteString(`; type="` + p.Type + `"`)
+	}
+	if p.CrossOrigin {
+		b.WriteString("; crossorigin")
+	}
+	return b.String()
+}
+
+// maxPreloads bounds what one page announces; hinting everything defeats the priority it buys.
+const maxPreloads = 12
+
+var (
+	assetTag  = regexp.MustCompile(`(?i)<(link|script)\b[^>]*>`)
+	attribute = regexp.MustCompile(`(?i)([a-z][a-z0-9-]*)(?:\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+)))?`)
+)
+
+// ScanPreloads lists the critical assets the HTML references, in document order: scripts with a
+// src, stylesheets and explicit preload links. External URLs and template expressions are skipped —
+// only what this server will answer, and only what is known before the page is rendered.
+func ScanPreloads(html string) []Preload {
+	var out []Preload
+	seen := map[string]bool{}
+	for _, tag := range assetTag.FindAllStringSubmatch(html, -1) {
+		attrs := map[string]string{}
+		for _, match := range attribute.FindAllStringSubmatch(tag[0][len(tag[1])+1:], -1) {
+			name := strings.ToLower(match[1])
+			if _, set := attrs[name]; !set {
+				attrs[name] = match[2] + match[3] + match[4]
+			}
+		}
+		_, crossOrigin := attrs["crossorigin"]
+		var p Preload
+		if strings.EqualFold(tag[1], "script") {
+			p = Preload{Href: attrs["src"], As: "script", CrossOrigin: crossOrigin}
+		} else {
+			switch strings.ToLower(attrs["rel"]) {
+			case "stylesheet":
+				p = Preload{Href: attrs["href"], As: "style", CrossOrigin: crossOrigin}
+			case "preload":
+				p = Preload{Href: attrs["href"], As: strings.ToLower(attrs["as"]), Type: attrs["type"], CrossOrigin: crossOrigin}
+			default:
+				continue
+			}
+		}
+		if !localAsset(p.Href) || p.As == "" || seen[p.Href] {
+			continue
+		}
+		seen[p.Href] = true
+		out = append(out, p)
+		if len(out) == maxPreloads {
+			break
+		}
+	}
+	return out
+}
+
+// PreloadFor describes a path declared by hand, inferring how it is fetched from its extension.
+func PreloadFor(href string) (Preload, bool) {
+	if !localAsset(href) {
+		return Preload{}, false
+	}
+	clean, _, _ := strings.Cut(href, "?")
+	switch strings.ToLower(path.Ext(clean)) {
+	case ".js", ".mjs":
+		return Preload{Href: href, As: "script"}, true
+	case ".css":
+		return Preload{Href: href, As: "style"}, true
+	case ".woff2":
+		return Preload{Href: href, As: "font", Type: "font/woff2", CrossOrigin: true}, true
+	case ".woff":
+		return Preload{Href: href, As: "font", Type: "font/woff", CrossOrigin: true}, true
+	case ".png", ".jpg", ".jpeg", ".webp", ".avif", ".gif", ".svg":
+		return Preload{Href: href, As: "image"}, true
+	}
+	return Preload{}, false
+}
+
+func localAsset(href string) bool {
+	return strings.HasPrefix(href, "/") && !strings.HasPrefix(href, "//") &&
+		!strings.Contains(href, "{{") && !strings.ContainsAny(href, "<>\"' \r\n")
+}
+
+// Preloads is what the prepared page references before any data is bound. Empty for a render
+// that was not prepared.
+func (r *Render) Preloads() []Preload {
+	if r == nil {
+		return nil
+	}
+	return r.preloads
+}
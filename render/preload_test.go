@@ -0,0 +1,37 @@
+package render
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScanPreloadsKeepsLocalCriticalAssets(t *testing.T) {
+	html := `<head>
+<link rel="stylesheet" href="/app.css">
+<link rel=preload href="/fonts/brand.woff2" as=font type="font/woff2" crossorigin>
+<link rel="icon" href="/favicon.ico">
+<link rel="stylesheet" href="https://cdn.example/x.css">
+<script src="/app.js"></script><script src="/app.js"></script>
+<script src="//cdn.example/y.js"></script><script src="/{{ version }}.js"></script>
+<script>inline()</script></head>`
+	want := []Preload{
+		{Href: "/app.css", As: "style"},
+		{Href: "/fonts/brand.woff2", As: "font", Type: "font/woff2", CrossOrigin: true},
+		{Href: "/app.js", As: "script"},
+	}
+	if got := ScanPreloads(html); !reflect.DeepEqual(got, want) {
+		t.Fatalf("ScanPreloads = %+v", got)
+	}
+	if got := want[1].Link(); got != `</fonts/brand.woff2>; rel=preload; as=font; type="font/woff2"; crossorigin` {
+		t.Fatalf("Link = %s", got)
+	}
+}
+
+func TestPreloadForInfersFromExtension(t *testing.T) {
+	for href, as := range map[string]string{"/a.mjs?v=2": "script", "/hero.avif": "image", "/f.woff": "font", "/doc.pdf": "", "https://x/a.js": ""} {
+		p, ok := PreloadFor(href)
+		if ok != (as != "") || p.As != as {
+			t.Errorf("PreloadFor(%q) = %+v, %v", href, p, ok)
+		}
+	}
+}
@@ -74,6 +74,7 @@ type Render struct {
 	prepareError         string
 	presentationPrepared bool
 	minifyPrepared       bool
+	preloads             []Preload // critical same-origin assets, found while preparing (preload.go)
 }
 
 type Layout struct {
@@ -403,9 +404,27 @@ func (r *Render) compileTemplate(preparePresentation bool) (
 			}
 		}
 	}
+	if preparePresentation {
+		r.preloads = ScanPreloads(r.presentationForScan(fullTemplate, presentationPrepared))
+	}
 	return parse(specializeTokens(fullTemplate)), presentationPrepared, minifyPrepared, ""
 }
 
+// presentationForScan is the template with its JIT presentation applied, for finding the assets it
+// will reference. A template whose presentation stays request-bound gets a throwaway pass; if that
+// pass cannot run on unbound source, the assembled template is scanned as it is.
+func (r *Render) presentationForScan(fullTemplate string, prepared bool) (out string) {
+	if prepared {
+		return fullTemplate
+	}
+	defer func() {
+		if recover() != nil {
+			out = fullTemplate
+		}
+	}()
+	return r.applyStaticPresentation(fullTemplate)
+}
+
 // Prepare assembles and parses this render path once. The returned Render is
 // immutable and safe for concurrent Bind calls.
 func (r *Render) Prepare() *Render {
@@ -1266,6 +1285,10 @@ func parse(tokens []string) *node {
 
 			switch cmd {
 			case "if":
+				if parts[1] == "flag" && len(parts) > 2 {
+					// {{ if flag "name" }} — the helper and its argument are one operand.
+					parts = append([]string{"if", parts[1] + " " + parts[2]}, parts[3:]...)
+				}
 				n := &node{typ: nodeIf, val: parts[1], expr: compileExpression(parts[1])}
 				if len(parts) > 2 {
 					n.args = parts[2:]
@@ -126,3 +126,54 @@ func TestShouldMinify(t *testing.T) {
 		t.Error("explicit minify types → ON despite default off")
 	}
 }
+
+type upperTranslator struct{}
+
+func (upperTranslator) Translate(key string, params value.Value) string {
+	if params.K == value.Number {
+		return strings.ToUpper(key) + "#" + params.Text()
+	}
+	return strings.ToUpper(key)
+}
+
+// {{ t "key" }} asks the binding's $.i18n; without one the key is shown as written.
+func TestTranslateHelper(t *testing.T) {
+	base := t.TempDir()
+	mkfile(t, base, "views/index.kitwork.html", `{{ @page }}`)
+	mkfile(t, base, "views/page.kitwork.html", `<p>{{ t "nav.home" }}|{{ t 'cart.items' cart.count }}|{{ t item.label }}|{{ t ? "yes" : "no" }}</p>`)
+	data := map[string]value.Value{
+		"cart": value.New(map[string]any{"count": 3}),
+		"item": value.New(map[string]any{"label": "menu.about"}),
+		"t":    value.New(true),
+	}
+	if out := newViews(base).Bind(value.New(data)).String(); !strings.Contains(out, "<p>nav.home|cart.items|menu.about|yes</p>") {
+		t.Fatalf("without a translator: %s", out)
+	}
+	data["i18n"] = value.Value{K: value.Struct, V: upperTranslator{}}
+	if out := newViews(base).Bind(value.New(data)).String(); !strings.Contains(out, "<p>NAV.HOME|CART.ITEMS#3|MENU.ABOUT|yes</p>") {
+		t.Fatalf("with a translator: %s", out)
+	}
+}
+
+type fixedFlags map[string]value.Value
+
+func (f fixedFlags) Flag(name string) value.Value {
+	if v, ok := f[name]; ok {
+		return v
+	}
+	return value.New(false)
+}
+
+func TestFlagHelper(t *testing.T) {
+	base := t.TempDir()
+	mkfile(t, base, "views/index.kitwork.html", `{{ @page }}`)
+	mkfile(t, base, "views/page.kitwork.html", `<p>{{ if flag "beta" }}beta{{ else }}stable{{ end }}|{{ if flag "checkout" == "redesign" }}new{{ else }}old{{ end }}|{{ flag "checkout" }}</p>`)
+	data := map[string]value.Value{}
+	if out := newViews(base).Bind(value.New(data)).String(); !strings.Contains(out, "<p>stable|old|false</p>") {
+		t.Fatalf("without flags: %s", out)
+	}
+	data["flags"] = value.Value{K: value.Struct, V: fixedFlags{"beta": value.New(true), "checkout": value.New("redesign")}}
+	if out := newViews(base).Bind(value.New(data)).String(); !strings.Contains(out, "<p>beta|new|redesign</p>") {
+		t.Fatalf("with flags: %s", out)
+	}
+}
@@ -21,6 +21,7 @@ const (
 	DiagnosticNoProgram         DiagnosticCode = "NO_PROGRAM"
 	DiagnosticVMStopped         DiagnosticCode = "VM_STOPPED"
 	DiagnosticNativePanic       DiagnosticCode = "NATIVE_PANIC"
+	DiagnosticFrozenValue       DiagnosticCode = "FROZEN_VALUE"
 )
 
 // StackFrame is a source-aware snapshot of one active VM frame.
@@ -116,6 +117,12 @@ func (vm *VM) diagnosticValue(code DiagnosticCode, message string, ip int) value
 	return diagnosticResult(diagnostic)
 }
 
+// frozenFailure is the diagnostic for a write to a value.Freeze'd object:
+// cached values are frozen so one handler cannot corrupt the next response.
+func (vm *VM) frozenFailure(message string, ip int) value.Value {
+	return vm.diagnosticValue(DiagnosticFrozenValue, message+" (use structuredClone() for a mutable copy)", ip)
+}
+
 func diagnosticResult(diagnostic *Diagnostic) value.Value {
 	return value.Value{
 		K:        value.Invalid,
@@ -300,6 +300,10 @@ func (vm *VM) execute(floor int) value.Value {
 
 		case SET:
 			item, key, target := vm.pop(), vm.pop(), vm.pop()
+			if value.IsFrozen(target) {
+				vm.push(vm.frozenFailure(fmt.Sprintf("cannot assign %q: object is frozen", key.Text()), opIP))
+				break
+			}
 			if target.IsMap() {
 				target.V.(map[string]value.Value)[key.Text()] = item
 			} else if target.IsArray() {
@@ -310,6 +314,11 @@ func (vm *VM) execute(floor int) value.Value {
 
 		case MERGE:
 			source, target := vm.pop(), vm.peek()
+			if value.IsFrozen(target) {
+				vm.pop()
+				vm.push(vm.frozenFailure("cannot spread into a frozen object", opIP))
+				break
+			}
 			if target.IsMap() && source.IsMap() {
 				targetMap := target.V.(map[string]value.Value)
 				for key, item := range source.Map() {
@@ -604,6 +613,13 @@ func (vm *VM) executeInvoke(frame *Frame) {
 	method := vm.pop().Text()
 
 	targetIndex := len(vm.Stack) - count - 1
+	if targetIndex >= frame.StackBase {
+		if target := vm.Stack[targetIndex]; value.Mutates(target.K, method) && value.IsFrozen(target) {
+			vm.Stack = vm.Stack[:targetIndex]
+			vm.push(vm.frozenFailure(fmt.Sprintf("cannot call %s(): %s is frozen", method, target.K), frame.LastIP))
+			return
+		}
+	}
 	if targetIndex >= frame.StackBase &&
 		count > 0 &&
 		isArrayCallbackMethod(method) {
@@ -663,6 +679,9 @@ func (vm *VM) executeInvoke(frame *Frame) {
 				}
 				if failure := vm.nativeAction("cache set", func() {
 					cache.SetCache(key, result, ttl)
+					if stored, ok := cache.GetCache(key); ok {
+						result = stored // the miss gets what every hit will: the stored copy
+					}
 				}); failure.K == value.Invalid {
 					vm.push(failure)
 					return
@@ -682,6 +701,9 @@ func (vm *VM) executeInvoke(frame *Frame) {
 		}
 	}
 
+	if binder, ok := target.V.(value.ContextBinder); ok && vm.Context != nil {
+		target = binder.BindContext(vm.Context)
+	}
 	vm.push(vm.nativeValue("method "+method, func() value.Value {
 		return target.Invoke(method, args...)
 	}))
@@ -3,6 +3,7 @@ package site
 import (
 	"fmt"
 	"sync"
+	"sync/atomic"
 
 	"github.com/kitwork/engine/capabilities"
 	"github.com/kitwork/engine/compiler"
@@ -28,6 +29,12 @@ type Generation struct {
 	bytecodeCache *compiler.FileCache
 	environment   value.Value
 
+	// Responses this revision answered, by class: the per-generation error rate a
+	// canary is judged by.
+	requests     atomic.Uint64
+	clientErrors atomic.Uint64
+	serverErrors atomic.Uint64
+
 	mu         sync.Mutex
 	active     int
 	retired    bool
@@ -293,6 +300,59 @@ func (g *Generation) Active() int {
 	return active
 }
 
+// Published reports whether the revision was activated or staged. A published
+// revision that is not retired may serve requests.
+func (g *Generation) Published() bool {
+	if g == nil {
+		return false
+	}
+	g.mu.Lock()
+	published := g.published
+	g.mu.Unlock()
+	return published
+}
+
+// Traffic counts the responses one generation answered.
+type Traffic struct {
+	Requests     uint64 `json:"requests"`
+	ClientErrors uint64 `json:"client_errors"`
+	ServerErrors uint64 `json:"server_errors"`
+}
+
+// ErrorRate is the share of responses that were server errors, 0 with no traffic.
+func (t Traffic) ErrorRate() float64 {
+	if t.Requests == 0 {
+		return 0
+	}
+	return float64(t.ServerErrors) / float64(t.Requests)
+}
+
+// RecordResponse counts one response this generation answered.
+func (g *Generation) RecordResponse(status int) {
+	if g == nil {
+		return
+	}
+	g.requests.Add(1)
+	switch {
+	case status >= 500:
+		g.serverErrors.Add(1)
+	case status >= 400:
+		g.clientErrors.Add(1)
+	}
+}
+
+// Traffic returns the generation's response counts.
+func (g *Generation) Traffic() Traffic {
+	if g == nil {
+		return Traffic{}
+	}
+	return Traffic{
+		Requests:     g.requests.Load(),
+		ClientErrors: g.clientErrors.Load(),
+		ServerErrors: g.serverErrors.Load(),
+	}
+}
+
 func (g *Generation) Retired() bool {
 	if g == nil {
 		return true
@@ -340,3 +340,46 @@ func TestGenerationFreezesPresentationAtActivation(t *testing.T) {
 		t.Fatal("retired generation retained its RAM response cache")
 	}
 }
+
+func TestStagedGenerationPromotionAndRollback(t *testing.T) {
+	appRuntime := app.NewRuntime("identity-a")
+	siteRuntime, err := appRuntime.Site("apps", "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stable, _ := siteRuntime.PrepareGeneration()
+	if _, err := siteRuntime.ActivateGeneration(stable); err != nil {
+		t.Fatal(err)
+	}
+	candidate, _ := siteRuntime.PrepareGeneration()
+	if _, err := siteRuntime.SwitchGeneration(candidate); err == nil {
+		t.Fatal("an unpublished generation became current")
+	}
+	if err := siteRuntime.StageGeneration(candidate); err != nil {
+		t.Fatal(err)
+	}
+	if siteRuntime.CurrentGeneration() != stable || !candidate.Published() {
+		t.Fatal("staging changed the current generation or left the candidate unpublished")
+	}
+	candidate.RecordResponse(200)
+	candidate.RecordResponse(503)
+	candidate.RecordResponse(404)
+	if traffic := candidate.Traffic(); traffic.Requests != 3 || traffic.ServerErrors != 1 || traffic.ClientErrors != 1 ||
+		traffic.ErrorRate() < 0.33 || traffic.ErrorRate() > 0.34 {
+		t.Fatalf("candidate traffic = %+v", traffic)
+	}
+
+	if previous, err := siteRuntime.SwitchGeneration(candidate); err != nil || previous != stable {
+		t.Fatalf("promote: previous=%v err=%v", previous, err)
+	}
+	if previous, err := siteRuntime.SwitchGeneration(stable); err != nil || previous != candidate {
+		t.Fatalf("rollback: previous=%v err=%v", previous, err)
+	}
+	candidate.Retire()
+	if _, err := siteRuntime.SwitchGeneration(candidate); err == nil {
+		t.Fatal("a retired generation became current")
+	}
+	if siteRuntime.CurrentGeneration() != stable {
+		t.Fatal("rollback did not restore the stable generation")
+	}
+}
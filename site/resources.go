@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"path/filepath"
 
+	"github.com/kitwork/engine/utilities/diskusage"
 	"github.com/kitwork/engine/utilities/persist"
 	"github.com/kitwork/engine/utilities/ratelimit"
 	"github.com/kitwork/engine/utilities/sse"
@@ -36,6 +37,7 @@ func (r *Runtime) ConfigureResources(siteRoot string) error {
 	if r.resourceRoot == "" {
 		r.resourceRoot = absolute
 		r.persistStore = persist.New(filepath.Join(absolute, ".persist"))
+		r.uploads = diskusage.Open(absolute, filepath.Join(absolute, ".uploads"))
 	}
 	return nil
 }
@@ -50,6 +52,17 @@ func (r *Runtime) PersistStore() *persist.Store {
 	return store
 }
 
+// Uploads counts the bytes the site's uploads hold on disk, across every generation.
+func (r *Runtime) Uploads() *diskusage.Usage {
+	if r == nil {
+		return nil
+	}
+	r.resourceMu.Lock()
+	uploads := r.uploads
+	r.resourceMu.Unlock()
+	return uploads
+}
+
 func (r *Runtime) Limiter() *ratelimit.Limiter {
 	if r == nil {
 		return nil
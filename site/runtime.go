@@ -6,6 +6,7 @@ import (
 	"sync"
 	"sync/atomic"
 
+	"github.com/kitwork/engine/utilities/diskusage"
 	"github.com/kitwork/engine/utilities/persist"
 	"github.com/kitwork/engine/utilities/ratelimit"
 	"github.com/kitwork/engine/utilities/sse"
@@ -33,6 +34,7 @@ type Runtime struct {
 	resourceMu    sync.Mutex
 	resourceRoot  string
 	persistStore  *persist.Store
+	uploads       *diskusage.Usage
 	limiter       *ratelimit.Limiter
 	sseBroker     *sse.SSEBroker
 	contentAssets *contentAssetStore
@@ -99,11 +101,8 @@ func (r *Runtime) PrepareGeneration() (*Generation, error) {
 // previously active revision. Retirement is left to its owning Tenant so it can
 // first stop accepting requests and drain its other resources.
 func (r *Runtime) ActivateGeneration(next *Generation) (*Generation, error) {
-	if r == nil || next == nil {
-		return nil, fmt.Errorf("site generation is nil")
-	}
-	if next.owner != r {
-		return nil, fmt.Errorf("site generation belongs to another runtime")
+	if err := r.checkOwner(next); err != nil {
+		return nil, err
 	}
 
 	r.generationMu.Lock()
@@ -121,33 +120,97 @@ func (r *Runtime) ActivateGeneration(next *Generation) (*Generation, error) {
 			r.current.version,
 		)
 	}
+	if err := r.publishLocked(next); err != nil {
+		return nil, err
+	}
+	previous := r.current
+	r.current = next
+	return previous, nil
+}
+
+// StageGeneration publishes a prepared revision without making it current: it
+// serves the requests its owner routes to it (a canary) until SwitchGeneration
+// promotes it or it is retired.
+func (r *Runtime) StageGeneration(next *Generation) error {
+	if err := r.checkOwner(next); err != nil {
+		return err
+	}
+	r.generationMu.Lock()
+	defer r.generationMu.Unlock()
+	if r.closed.Load() {
+		return fmt.Errorf("site runtime %q is closed", r.domain)
+	}
+	return r.publishLocked(next)
+}
+
+// SwitchGeneration makes an already published, still-warm revision current —
+// promoting a staged candidate or rolling back to a retained predecessor — and
+// returns the revision it replaced, which keeps serving until it is retired.
+// Nothing is recompiled.
+func (r *Runtime) SwitchGeneration(next *Generation) (*Generation, error) {
+	if err := r.checkOwner(next); err != nil {
+		return nil, err
+	}
+	r.generationMu.Lock()
+	defer r.generationMu.Unlock()
+	if r.closed.Load() {
+		return nil, fmt.Errorf("site runtime %q is closed", r.domain)
+	}
+	if r.current == next {
+		return nil, nil
+	}
+	next.mu.Lock()
+	published, retired := next.published, next.retired
+	next.mu.Unlock()
+	if retired {
+		return nil, fmt.Errorf("site generation %d is retired", next.version)
+	}
+	if !published {
+		return nil, fmt.Errorf("site generation %d was never published", next.version)
+	}
+	previous := r.current
+	r.current = next
+	return previous, nil
+}
+
+func (r *Runtime) checkOwner(next *Generation) error {
+	if r == nil || next == nil {
+		return fmt.Errorf("site generation is nil")
+	}
+	if next.owner != r {
+		return fmt.Errorf("site generation belongs to another runtime")
+	}
+	return nil
+}
+
+// publishLocked retains the revision's immutable assets and freezes its
+// preparation-time state. The caller holds generationMu.
+func (r *Runtime) publishLocked(next *Generation) error {
 	next.mu.Lock()
 	defer next.mu.Unlock()
 	if next.retired {
-		return nil, fmt.Errorf("site generation %d is retired", next.version)
+		return fmt.Errorf("site generation %d is retired", next.version)
 	}
 	if next.published {
-		return nil, fmt.Errorf("site generation %d is already published", next.version)
+		return fmt.Errorf("site generation %d is already published", next.version)
 	}
 	var assets []ContentAsset
 	if provider, ok := next.renderPlan.(ContentAssetProvider); ok {
 		var err error
 		assets, err = provider.ContentAssets()
 		if err != nil {
-			return nil, fmt.Errorf("prepare immutable site assets for generation %d: %w", next.version, err)
+			return fmt.Errorf("prepare immutable site assets for generation %d: %w", next.version, err)
 		}
 	}
 	assetHashes, err := r.contentAssets.retain(assets)
 	if err != nil {
-		return nil, fmt.Errorf("retain immutable site assets for generation %d: %w", next.version, err)
+		return fmt.Errorf("retain immutable site assets for generation %d: %w", next.version, err)
 	}
 	next.contentAssets = assetHashes
 	next.published = true
 	next.presentation.Freeze()
 	next.sources.Freeze()
-	previous := r.current
-	r.current = next
-	return previous, nil
+	return nil
 }
 
 func (r *Runtime) CurrentGeneration() *Generation {
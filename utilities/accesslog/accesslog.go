@@ -0,0 +1,252 @@
+// Package accesslog writes a site's access log: one line per request, as JSON or in the Combined
+// Log Format, rotated by size. Sensitive query parameters and headers are redacted before a line is
+// written, and Tail/Query read the log back for dashboards and operators.
+package accesslog
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Entry is one request as logged. Only the JSON format carries every field; the Combined format is
+// the classic Apache line.
+type Entry struct {
+	Time      time.Time         `json:"time"`
+	Tenant    string            `json:"tenant"`
+	Host      string            `json:"host"`
+	Method    string            `json:"method"`
+	Path      string            `json:"path"`
+	Query     string            `json:"query,omitempty"`
+	Proto     string            `json:"proto"`
+	Route     string            `json:"route,omitempty"` // the route folder that answered, e.g. "/blog/{slug}"
+	Status    int               `json:"status"`
+	Bytes     int64             `json:"bytes"`
+	Duration  float64           `json:"duration_ms"`
+	Energy    uint64            `json:"energy"`          // VM energy the request consumed
+	Cache     string            `json:"cache,omitempty"` // "ram" or "disk" when a cached response answered
+	ClientIP  string            `json:"client_ip"`
+	TraceID   string            `json:"trace_id,omitempty"`
+	Referer   string            `json:"referer,omitempty"`
+	UserAgent string            `json:"user_agent,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"` // the request headers Options.Headers names
+}
+
+// Options configure a Logger. Zero values take the defaults noted.
+type Options struct {
+	Format     string   // "json" (default) or "combined"
+	MaxSize    int      // megabytes before the file rotates (default 100)
+	MaxBackups int      // rotated files kept (default 7)
+	MaxAge     int      // days a rotated file is kept (default 30)
+	Compress   bool     // gzip rotated files
+	Redact     []string // query parameters and headers whose values are replaced; `*` wildcards
+	Headers    []string // request headers recorded in the JSON format
+}
+
+// DefaultRedact is always redacted, whatever Options.Redact adds.
+var DefaultRedact = []string{"authorization", "cookie", "set-cookie", "proxy-authorization",
+	"*token*", "*password*", "*secret*", "api_key", "apikey", "key", "signature", "sig", "code"}
+
+// Redacted replaces the value of a redacted field.
+const Redacted = "[REDACTED]"
+
+// Logger appends entries to one file. It is safe for concurrent use.
+type Logger struct {
+	path    string
+	format  string
+	redact  []string
+	headers []string
+
+	mu  sync.Mutex
+	out *lumberjack.Logger
+}
+
+// New returns a logger writing to file; the file and its directory are created on the first entry.
+func New(file string, opts Options) *Logger {
+	format := strings.ToLower(opts.Format)
+	if format != "combined" {
+		format = "json"
+	}
+	redact := make([]string, 0, len(DefaultRedact)+len(opts.Redact))
+	for _, name := range append(append([]string(nil), DefaultRedact...), opts.Redact...) {
+		redact = append(redact, strings.ToLower(strings.TrimSpace(name)))
+	}
+	headers := make([]string, 0, len(opts.Headers))
+	for _, name := range opts.Headers {
+		headers = append(headers, http.CanonicalHeaderKey(strings.TrimSpace(name)))
+	}
+	return &Logger{
+		path:    file,
+		format:  format,
+		redact:  redact,
+		headers: headers,
+		out: &lumberjack.Logger{
+			Filename:   file,
+			MaxSize:    orDefault(opts.MaxSize, 100),
+			MaxBackups: orDefault(opts.MaxBackups, 7),
+			MaxAge:     orDefault(opts.MaxAge, 30),
+			Compress:   opts.Compress,
+		},
+	}
+}
+
+func orDefault(v, fallback int) int {
+	if v > 0 {
+		return v
+	}
+	return fallback
+}
+
+// Path is the file being written.
+func (l *Logger) Path() string {
+	if l == nil {
+		return ""
+	}
+	return l.path
+}
+
+// Format is "json" or "combined".
+func (l *Logger) Format() string {
+	if l == nil {
+		return ""
+	}
+	return l.format
+}
+
+// Capture fills the request side of an entry from r, with the redaction rules applied.
+func (l *Logger) Capture(r *http.Request) Entry {
+	entry := Entry{
+		Host:      r.Host,
+		Method:    r.Method,
+		Path:      r.URL.Path,
+		Query:     l.redactQuery(r.URL.RawQuery),
+		Proto:     r.Proto,
+		Referer:   l.redactURL(r.Referer()),
+		UserAgent: r.UserAgent(),
+	}
+	for _, name := range l.headers {
+		value := r.Header.Get(name)
+		if value == "" {
+			continue
+		}
+		if entry.Headers == nil {
+			entry.Headers = make(map[string]string, len(l.headers))
+		}
+		if l.redacted(name) {
+			value = Redacted
+		}
+		entry.Headers[name] = value
+	}
+	return entry
+}
+
+// Log writes one entry.
+func (l *Logger) Log(entry Entry) error {
+	if l == nil {
+		return nil
+	}
+	var line []byte
+	if l.format == "combined" {
+		line = []byte(combinedLine(entry))
+	} else {
+		encoded, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		line = append(encoded, '\n')
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err := l.out.Write(line)
+	return err
+}
+
+// Close closes the file; a later Log reopens it.
+func (l *Logger) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.out.Close()
+}
+
+// redacted reports whether a query parameter or header name matches a redaction rule.
+func (l *Logger) redacted(name string) bool {
+	name = strings.ToLower(name)
+	for _, rule := range l.redact {
+		if rule == name {
+			return true
+		}
+		if strings.Contains(rule, "*") {
+			if ok, _ := path.Match(rule, name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// redactQuery keeps the query's shape and order, replacing only the redacted values.
+func (l *Logger) redactQuery(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	parts := strings.Split(raw, "&")
+	for i, part := range parts {
+		name, _, hasValue := strings.Cut(part, "=")
+		decoded, err := url.QueryUnescape(name)
+		if err != nil {
+			decoded = name
+		}
+		if hasValue && l.redacted(decoded) {
+			parts[i] = name + "=" + Redacted
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func (l *Logger) redactURL(raw string) string {
+	base, query, found := strings.Cut(raw, "?")
+	if !found {
+		return raw
+	}
+	return base + "?" + l.redactQuery(query)
+}
+
+// combinedLine renders the Apache Combined Log Format:
+//
+//	203.0.113.9 - - [02/Jan/2026:15:04:05 +0000] "GET /a?b=c HTTP/1.1" 200 512 "https://ref/" "agent"
+func combinedLine(e Entry) string {
+	target := e.Path
+	if e.Query != "" {
+		target += "?" + e.Query
+	}
+	bytes := "-"
+	if e.Bytes > 0 {
+		bytes = strconv.FormatInt(e.Bytes, 10)
+	}
+	return fmt.Sprintf("%s - - [%s] \"%s %s %s\" %d %s \"%s\" \"%s\"\n",
+		orDash(e.ClientIP), e.Time.Format("02/Jan/2006:15:04:05 -0700"),
+		e.Method, quoteSafe(target), e.Proto, e.Status, bytes,
+		orDash(quoteSafe(e.Referer)), orDash(quoteSafe(e.UserAgent)))
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+var quoteEscaper = strings.NewReplacer(`"`, `\"`, "\n", `\n`, "\r", `\r`)
+
+func quoteSafe(s string) string { return quoteEscaper.Replace(s) }
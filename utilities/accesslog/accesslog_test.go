@@ -0,0 +1,87 @@
+package accesslog_test
+
+import (
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kitwork/engine/utilities/accesslog"
+)
+
+func TestCaptureRedactsQueryAndHeaders(t *testing.T) {
+	log := accesslog.New(filepath.Join(t.TempDir(), "access.log"), accesslog.Options{
+		Redact:  []string{"session*"},
+		Headers: []string{"Authorization", "X-Request-Id"},
+	})
+	r := httptest.NewRequest("GET", "/pay?amount=5&access_token=abc&sessionId=s1&q=x", nil)
+	r.Header.Set("Authorization", "Bearer secret")
+	r.Header.Set("X-Request-Id", "r-1")
+	r.Header.Set("Referer", "https://example.com/from?password=hunter2&page=2")
+
+	entry := log.Capture(r)
+	if entry.Query != "amount=5&access_token=[REDACTED]&sessionId=[REDACTED]&q=x" {
+		t.Fatalf("query = %q", entry.Query)
+	}
+	if entry.Referer != "https://example.com/from?password=[REDACTED]&page=2" {
+		t.Fatalf("referer = %q", entry.Referer)
+	}
+	if entry.Headers["Authorization"] != accesslog.Redacted || entry.Headers["X-Request-Id"] != "r-1" {
+		t.Fatalf("headers = %v", entry.Headers)
+	}
+}
+
+func TestQueryFiltersNewestFirst(t *testing.T) {
+	log := accesslog.New(filepath.Join(t.TempDir(), "logs", "access.log"), accesslog.Options{})
+	defer log.Close()
+	start := time.Now()
+	for i, status := range []int{200, 404, 500, 200, 503} {
+		entry := accesslog.Entry{Time: start.Add(time.Duration(i) * time.Second), Method: "GET", Path: "/p", Route: "/p", Status: status}
+		if i == 3 {
+			entry.Cache = "ram"
+		}
+		if err := log.Log(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	errors, err := log.Query(accesslog.Filter{Status: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(errors) != 2 || errors[0].Status != 503 || errors[1].Status != 500 {
+		t.Fatalf("5xx = %+v", errors)
+	}
+	cached, _ := log.Query(accesslog.Filter{Cache: "ram"})
+	if len(cached) != 1 || cached[0].Status != 200 {
+		t.Fatalf("cached = %+v", cached)
+	}
+	latest, _ := log.Query(accesslog.Filter{Limit: 2})
+	if len(latest) != 2 || latest[0].Status != 503 || latest[1].Status != 200 {
+		t.Fatalf("latest = %+v", latest)
+	}
+	lines, err := log.Tail(2)
+	if err != nil || len(lines) != 2 || !strings.Contains(lines[1], `"status":503`) {
+		t.Fatalf("tail = %v, %v", lines, err)
+	}
+}
+
+func TestCombinedFormat(t *testing.T) {
+	log := accesslog.New(filepath.Join(t.TempDir(), "access.log"), accesslog.Options{Format: "combined"})
+	defer log.Close()
+	when := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	err := log.Log(accesslog.Entry{Time: when, ClientIP: "203.0.113.9", Method: "GET", Path: "/a", Query: "b=c",
+		Proto: "HTTP/1.1", Status: 200, Bytes: 512, UserAgent: `say "hi"`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines, _ := log.Tail(1)
+	want := `203.0.113.9 - - [02/Jan/2026:15:04:05 +0000] "GET /a?b=c HTTP/1.1" 200 512 "-" "say \"hi\""`
+	if len(lines) != 1 || lines[0] != want {
+		t.Fatalf("line = %v\nwant %s", lines, want)
+	}
+	if _, err := log.Query(accesslog.Filter{}); err != accesslog.ErrNotJSON {
+		t.Fatalf("query err = %v", err)
+	}
+}
@@ -0,0 +1,215 @@
+package accesslog
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Filter selects entries for Query. Zero fields match everything.
+type Filter struct {
+	Since     time.Time
+	Until     time.Time
+	Method    string
+	Route     string // exact route pattern
+	Path      string // path prefix
+	Status    int    // exact status; 4 or 5 match the whole class
+	MinStatus int
+	ClientIP  string
+	TraceID   string
+	Cache     string // "ram", "disk", or "none" for uncached
+	Limit     int    // newest entries returned (default 100, at most 10000)
+}
+
+// ErrNotJSON is returned by Query for a log written in the Combined format.
+var ErrNotJSON = errors.New("accesslog: query needs the json format")
+
+const maxQueryLimit = 10000
+
+// Tail returns the last n lines of the current file, oldest first, in whichever format it is
+// written.
+func (l *Logger) Tail(n int) ([]string, error) {
+	if n < 1 {
+		n = 100
+	}
+	if n > maxQueryLimit {
+		n = maxQueryLimit
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ring := make([]string, 0, n)
+	err := scanFile(l.path, func(line []byte) bool {
+		if len(ring) == n {
+			ring = ring[1:]
+		}
+		ring = append(ring, string(line))
+		return true
+	})
+	if errors.Is(err, os.ErrNotExist) {
+		return []string{}, nil
+	}
+	return ring, err
+}
+
+// Query returns the newest entries matching filter, newest first. It reads the current file and
+// then its rotated backups, stopping once a backup is older than filter.Since.
+func (l *Logger) Query(filter Filter) ([]Entry, error) {
+	if l.format != "json" {
+		return nil, ErrNotJSON
+	}
+	limit := filter.Limit
+	if limit < 1 {
+		limit = 100
+	}
+	if limit > maxQueryLimit {
+		limit = maxQueryLimit
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	out := make([]Entry, 0, limit)
+	for _, file := range l.files() {
+		if !filter.Since.IsZero() && file.modified.Before(filter.Since) {
+			break
+		}
+		var matched []Entry
+		err := scanFile(file.path, func(line []byte) bool {
+			var entry Entry
+			if json.Unmarshal(line, &entry) != nil || !filter.matches(entry) {
+				return true
+			}
+			if len(matched) == limit {
+				matched = matched[1:]
+			}
+			matched = append(matched, entry)
+			return true
+		})
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		for i := len(matched) - 1; i >= 0 && len(out) < limit; i-- {
+			out = append(out, matched[i])
+		}
+		if len(out) == limit {
+			break
+		}
+	}
+	return out, nil
+}
+
+type logFile struct {
+	path     string
+	modified time.Time
+}
+
+// files lists the current file and then its backups, newest first. Lumberjack names backups
+// "<name>-<timestamp><ext>", optionally gzipped, so the name order is the age order.
+func (l *Logger) files() []logFile {
+	files := []logFile{{path: l.path, modified: time.Now()}}
+	dir := filepath.Dir(l.path)
+	ext := filepath.Ext(l.path)
+	prefix := strings.TrimSuffix(filepath.Base(l.path), ext) + "-"
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return files
+	}
+	var backups []logFile
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if !strings.HasSuffix(name, ext) && !strings.HasSuffix(name, ext+".gz") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, logFile{path: filepath.Join(dir, name), modified: info.ModTime()})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].path > backups[j].path })
+	return append(files, backups...)
+}
+
+func scanFile(path string, visit func(line []byte) bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	var reader io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		reader = gz
+	}
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		if !visit(scanner.Bytes()) {
+			break
+		}
+	}
+	return scanner.Err()
+}
+
+func (f Filter) matches(e Entry) bool {
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && e.Time.After(f.Until) {
+		return false
+	}
+	if f.Method != "" && !strings.EqualFold(f.Method, e.Method) {
+		return false
+	}
+	if f.Route != "" && f.Route != e.Route {
+		return false
+	}
+	if f.Path != "" && !strings.HasPrefix(e.Path, f.Path) {
+		return false
+	}
+	switch {
+	case f.Status >= 1 && f.Status <= 5:
+		if e.Status/100 != f.Status {
+			return false
+		}
+	case f.Status != 0 && f.Status != e.Status:
+		return false
+	}
+	if f.MinStatus != 0 && e.Status < f.MinStatus {
+		return false
+	}
+	if f.ClientIP != "" && f.ClientIP != e.ClientIP {
+		return false
+	}
+	if f.TraceID != "" && f.TraceID != e.TraceID {
+		return false
+	}
+	switch f.Cache {
+	case "":
+	case "none":
+		if e.Cache != "" {
+			return false
+		}
+	default:
+		if f.Cache != e.Cache {
+			return false
+		}
+	}
+	return true
+}
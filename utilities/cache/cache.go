@@ -34,25 +34,29 @@ func NewStore(max int) *Store {
 	return &Store{m: make(map[string]Entry), max: max}
 }
 
-// Get returns the entry for key if present and unexpired.
+// Get returns the entry for key if present and unexpired. An expired entry stays until Set
+// replaces or evicts it, so GetStale can still hand it out.
 func (s *Store) Get(key string) (Entry, bool) {
+	e, stale, ok := s.GetStale(key)
+	if !ok || stale {
+		return Entry{}, false
+	}
+	return e, true
+}
+
+// GetStale returns the entry even when EXPIRED — the fail-open read for a caller whose live source
+// just failed. ok is false only when the key is absent; stale reports whether it is past its expiry.
+func (s *Store) GetStale(key string) (e Entry, stale bool, ok bool) {
 	s.mu.RLock()
+	defer s.mu.RUnlock()
 	if s.closed {
-		s.mu.RUnlock()
-		return Entry{}, false
+		return Entry{}, false, false
 	}
-	e, ok := s.m[key]
-	s.mu.RUnlock()
+	e, ok = s.m[key]
 	if !ok {
-		return Entry{}, false
-	}
-	if !e.expireAt.IsZero() && time.Now().After(e.expireAt) {
-		s.mu.Lock()
-		delete(s.m, key)
-		s.mu.Unlock()
-		return Entry{}, false
+		return Entry{}, false, false
 	}
-	return e, true
+	return e, !e.expireAt.IsZero() && time.Now().After(e.expireAt), true
 }
 
 // Set stores e under key with the given TTL (0 = no expiry). Evicts one entry when at capacity.
@@ -66,12 +70,38 @@ func (s *Store) Set(key string, e Entry, ttl time.Duration) {
 		return
 	}
 	if _, exists := s.m[key]; !exists && len(s.m) >= s.max {
-		for k := range s.m { // simple FIFO-ish eviction — cheap, bounds memory
-			delete(s.m, k)
+		s.evict(time.Now())
+	}
+	s.m[key] = e
+}
+
+// evict drops one expired entry, or any one when none has expired — cheap, bounds memory.
+func (s *Store) evict(now time.Time) {
+	victim := ""
+	for k, e := range s.m {
+		if !e.expireAt.IsZero() && now.After(e.expireAt) {
+			victim = k
 			break
 		}
+		if victim == "" {
+			victim = k
+		}
 	}
-	s.m[key] = e
+	delete(s.m, victim)
+}
+
+// Clear drops every entry and returns how many there were.
+func (s *Store) Clear() int {
+	if s == nil {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.m)
+	if !s.closed {
+		s.m = make(map[string]Entry)
+	}
+	return n
 }
 
 // Close releases all entries and makes the store terminal.
@@ -3,7 +3,8 @@ package collection
 import (
 	"fmt"
 	"sort"
-	"strings"
+
+	"github.com/kitwork/engine/utilities/text"
 )
 
 // Query is the in-memory filter/sort/slice spec applied to a collection's frontmatter index. It runs
@@ -141,7 +142,8 @@ func compareValues(a, b any) int {
 			}
 		}
 	}
-	return strings.Compare(toString(a), toString(b))
+	// Strings sort in Vietnamese dictionary order (a < ă < â, d < đ, tones after letters).
+	return text.Compare(toString(a), toString(b))
 }
 
 func containsValue(haystack, needle any) bool {
@@ -154,7 +156,7 @@ func containsValue(haystack, needle any) bool {
 		}
 		return false
 	case string:
-		return strings.Contains(strings.ToLower(h), strings.ToLower(toString(needle)))
+		return text.ContainsFold(h, toString(needle)) // "ha noi" finds "Hà Nội"
 	default:
 		return false
 	}
@@ -92,3 +92,20 @@ func TestIndexSurvivesBrokenFrontmatter(t *testing.T) {
 		t.Fatalf("index len = %d, want 2 (bad.md skipped, good ones kept)", len(index))
 	}
 }
+
+func TestQueryVietnameseText(t *testing.T) {
+	index := []IndexEntry{
+		entry("dao", map[string]any{"title": "Đà Lạt mùa hoa"}),
+		entry("an", map[string]any{"title": "Ăn gì ở Hà Nội"}),
+		entry("da", map[string]any{"title": "Dạo phố cổ"}),
+		entry("ao", map[string]any{"title": "Áo dài"}),
+	}
+	got := Query{OrderField: "title"}.Apply(index)
+	if s := slugs(got); len(s) != 4 || s[0] != "ao" || s[1] != "an" || s[2] != "da" || s[3] != "dao" {
+		t.Errorf("title asc = %v, want [ao an da dao] (a < ă, d < đ)", s)
+	}
+	got = Query{Filters: []Filter{{Field: "title", Op: "contains", Value: "ha noi"}}}.Apply(index)
+	if s := slugs(got); len(s) != 1 || s[0] != "an" {
+		t.Errorf("title contains ha noi = %v, want [an]", s)
+	}
+}
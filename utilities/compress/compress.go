@@ -74,6 +74,12 @@ type compressWriter struct {
 }
 
 func (c *compressWriter) WriteHeader(status int) {
+	if status >= 100 && status < 200 {
+		// Informational responses (103 Early Hints) carry no body and go out at once; the final
+		// status is still to come.
+		c.ResponseWriter.WriteHeader(status)
+		return
+	}
 	if c.wroteHeader {
 		return
 	}
@@ -64,4 +64,24 @@ func TestSqliteCronStore(t *testing.T) {
 	if crons[0]["name"] != "daily-backup" {
 		t.Errorf("Expected name 'daily-backup', got '%v'", crons[0]["name"])
 	}
+
+	if !store.SetStatus("app_1", "daily-backup", "paused") || store.Status("app_1", "daily-backup") != "paused" {
+		t.Fatal("Expected the cron to be paused")
+	}
+	if err := store.Sync("app_1", jobs); err != nil || store.Status("app_1", "daily-backup") != "paused" {
+		t.Fatalf("Sync must keep a pause, got %q (%v)", store.Status("app_1", "daily-backup"), err)
+	}
+	if store.SetStatus("app_1", "missing", "paused") {
+		t.Error("Expected SetStatus to report an unknown cron")
+	}
+	at := time.Now().Add(time.Second)
+	if !store.Trigger("app_1", "daily-backup", at) || store.Trigger("app_1", "daily-backup", at) {
+		t.Fatal("Expected one manual slot per instant")
+	}
+	if store.Trigger("app_1", "missing", at) {
+		t.Error("Expected Trigger to refuse an unknown cron")
+	}
+	if manual := store.Claim("app_1", "node_1", 30*time.Second, 10); len(manual) != 1 || manual[0].MaxAttempts != 1 {
+		t.Fatalf("Expected the manual slot to be claimable, got %+v", manual)
+	}
 }
@@ -45,6 +45,9 @@ type Store interface {
 	Heartbeat(nodeID string, leaseTTL time.Duration)
 	Retention(identity, name string, completedBefore, failedBefore time.Time)
 	ListCrons(identity string) []map[string]any
+	SetStatus(identity, name, status string) bool
+	Status(identity, name string) string
+	Trigger(identity, name string, at time.Time) bool
 }
 
 // SqliteStore is a per-tenant SQLite implementation of Store.
@@ -242,6 +245,40 @@ func (s *SqliteStore) Retention(identity, name string, completedBefore, failedBe
 		identity, name, RFC(failedBefore))
 }
 
+// SetStatus marks a cron 'active' or 'paused'. Sync leaves the status of a known cron alone, so a
+// pause outlives reloads of its file. Reports whether the cron exists.
+func (s *SqliteStore) SetStatus(identity, name, status string) bool {
+	res, err := s.DB.Exec(`UPDATE crons SET status=?, updated_at=? WHERE identity=? AND name=?`,
+		status, RFC(time.Now()), identity, name)
+	if err != nil {
+		return false
+	}
+	n, _ := res.RowsAffected()
+	return n == 1
+}
+
+// Status is the cron's stored status, "" when it is unknown.
+func (s *SqliteStore) Status(identity, name string) string {
+	var status string
+	s.DB.QueryRow(`SELECT status FROM crons WHERE identity=? AND name=?`, identity, name).Scan(&status)
+	return status
+}
+
+// Trigger opens a pending slot at `at` for a manual run, with the cron's attempt budget. Reports
+// false for an unknown cron or a slot that already exists.
+func (s *SqliteStore) Trigger(identity, name string, at time.Time) bool {
+	now := RFC(time.Now())
+	res, err := s.DB.Exec(`INSERT OR IGNORE INTO cron_runs
+		(identity, name, scheduled_for, status, attempt, max_attempts, available_at, created_at)
+		SELECT identity, name, ?, 'pending', 0, max_attempts, ?, ? FROM crons WHERE identity=? AND name=?`,
+		RFC(at), now, now, identity, name)
+	if err != nil {
+		return false
+	}
+	n, _ := res.RowsAffected()
+	return n == 1
+}
+
 func (s *SqliteStore) ListCrons(identity string) []map[string]any {
 	rows, err := s.DB.Query(`SELECT name, source, schedule, timezone, overlap, max_attempts, retention,
 		status, last_run, last_status, run_count, fail_count, updated_at FROM crons WHERE identity=? ORDER BY name`, identity)
@@ -451,6 +488,34 @@ func (s *PgStore) Retention(identity, name string, completedBefore, failedBefore
 		identity, name, failedBefore.UTC())
 }
 
+func (s *PgStore) SetStatus(identity, name, status string) bool {
+	res, err := s.DB.Exec(`UPDATE crons SET status=$3, updated_at=NOW() WHERE identity=$1 AND name=$2`,
+		identity, name, status)
+	if err != nil {
+		return false
+	}
+	n, _ := res.RowsAffected()
+	return n == 1
+}
+
+func (s *PgStore) Status(identity, name string) string {
+	var status string
+	s.DB.QueryRow(`SELECT status FROM crons WHERE identity=$1 AND name=$2`, identity, name).Scan(&status)
+	return status
+}
+
+func (s *PgStore) Trigger(identity, name string, at time.Time) bool {
+	res, err := s.DB.Exec(`INSERT INTO cron_runs
+		(identity, name, scheduled_for, status, attempt, max_attempts, available_at, created_at)
+		SELECT identity, name, $3, 'pending', 0, max_attempts, NOW(), NOW() FROM crons WHERE identity=$1 AND name=$2
+		ON CONFLICT (identity, name, scheduled_for) DO NOTHING`, identity, name, at.UTC())
+	if err != nil {
+		return false
+	}
+	n, _ := res.RowsAffected()
+	return n == 1
+}
+
 func (s *PgStore) ListCrons(identity string) []map[string]any {
 	rows, err := s.DB.Query(`SELECT name, source, schedule, timezone, overlap, max_attempts, retention,
 		status, last_run, last_status, run_count, fail_count, updated_at FROM crons WHERE identity=$1 ORDER BY name`, identity)
@@ -0,0 +1,142 @@
+package datetime
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Calendar decides which days are business days: not a weekend day and not a holiday. Holidays are
+// written the way HR publishes them:
+//
+//	"2025-04-30"     one date
+//	"09-02"          every year (Quốc khánh)
+//	"lunar:03-10"    every year on a lunar date (Giỗ Tổ Hùng Vương)
+//
+// Tết is a lunar range: list "lunar:12-29", "lunar:12-30", "lunar:01-01" … "lunar:01-05". In a year
+// whose 12th lunar month has 29 days "lunar:12-30" simply never matches.
+type Calendar struct {
+	weekend [7]bool
+	dates   map[int]bool    // civil day numbers
+	yearly  map[[2]int]bool // {month, day}
+	lunar   map[[2]int]bool // {lunar month, lunar day}; leap months do not repeat a holiday
+}
+
+// DefaultWeekend is Saturday and Sunday.
+var DefaultWeekend = []time.Weekday{time.Saturday, time.Sunday}
+
+// NewCalendar builds a Calendar. A nil weekend is DefaultWeekend; an empty, non-nil one means every
+// weekday is a working day.
+func NewCalendar(weekend []time.Weekday, holidays []string) (*Calendar, error) {
+	if weekend == nil {
+		weekend = DefaultWeekend
+	}
+	calendar := &Calendar{dates: map[int]bool{}, yearly: map[[2]int]bool{}, lunar: map[[2]int]bool{}}
+	for _, day := range weekend {
+		if day < time.Sunday || day > time.Saturday {
+			return nil, fmt.Errorf("invalid weekday %d", day)
+		}
+		calendar.weekend[day] = true
+	}
+	if calendar.weekend == [7]bool{true, true, true, true, true, true, true} {
+		return nil, fmt.Errorf("a calendar needs at least one working weekday")
+	}
+	for _, holiday := range holidays {
+		if err := calendar.addHoliday(strings.TrimSpace(holiday)); err != nil {
+			return nil, err
+		}
+	}
+	return calendar, nil
+}
+
+func (c *Calendar) addHoliday(holiday string) error {
+	if rest, ok := strings.CutPrefix(holiday, "lunar:"); ok {
+		month, day, ok := monthDay(rest)
+		if !ok || day > 30 {
+			return fmt.Errorf("invalid lunar holiday %q (want lunar:MM-dd)", holiday)
+		}
+		c.lunar[[2]int{month, day}] = true
+		return nil
+	}
+	if month, day, ok := monthDay(holiday); ok {
+		c.yearly[[2]int{month, day}] = true
+		return nil
+	}
+	t, err := time.Parse("2006-01-02", holiday)
+	if err != nil {
+		return fmt.Errorf("invalid holiday %q (want yyyy-MM-dd, MM-dd or lunar:MM-dd)", holiday)
+	}
+	c.dates[civilDays(t)] = true
+	return nil
+}
+
+func monthDay(text string) (month, day int, ok bool) {
+	left, right, found := strings.Cut(text, "-")
+	if !found || len(left) > 2 || len(right) > 2 {
+		return 0, 0, false
+	}
+	month, errMonth := strconv.Atoi(left)
+	day, errDay := strconv.Atoi(right)
+	if errMonth != nil || errDay != nil || month < 1 || month > 12 || day < 1 || day > 31 {
+		return 0, 0, false
+	}
+	return month, day, true
+}
+
+// IsHoliday reports whether t's wall-clock date is one of the calendar's holidays.
+func (c *Calendar) IsHoliday(t time.Time) bool {
+	if c.dates[civilDays(t)] || c.yearly[[2]int{int(t.Month()), t.Day()}] {
+		return true
+	}
+	if len(c.lunar) == 0 {
+		return false
+	}
+	lunar := ToLunar(t)
+	return !lunar.Leap && c.lunar[[2]int{lunar.Month, lunar.Day}]
+}
+
+// IsBusinessDay reports whether t's date is neither a weekend day nor a holiday.
+func (c *Calendar) IsBusinessDay(t time.Time) bool {
+	return !c.weekend[t.Weekday()] && !c.IsHoliday(t)
+}
+
+// AddBusinessDays moves t by n business days, keeping its clock: Friday + 1 is Monday. n == 0
+// returns t unchanged even on a holiday; use NextBusinessDay to roll forward.
+func (c *Calendar) AddBusinessDays(t time.Time, n int) time.Time {
+	step := 1
+	if n < 0 {
+		step, n = -1, -n
+	}
+	for n > 0 {
+		t = t.AddDate(0, 0, step)
+		if c.IsBusinessDay(t) {
+			n--
+		}
+	}
+	return t
+}
+
+// NextBusinessDay is t when t is a business day, else the next one (same clock).
+func (c *Calendar) NextBusinessDay(t time.Time) time.Time {
+	for !c.IsBusinessDay(t) {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t
+}
+
+// BusinessDaysBetween counts the business days in [from, to) by date — negative when to is earlier.
+func (c *Calendar) BusinessDaysBetween(from, to time.Time) int {
+	to = to.In(from.Location())
+	sign := 1
+	if civilDays(to) < civilDays(from) {
+		from, to, sign = to, from, -1
+	}
+	count := 0
+	for day := StartOf(from, Day); civilDays(day) < civilDays(to); day = day.AddDate(0, 0, 1) {
+		if c.IsBusinessDay(day) {
+			count++
+		}
+	}
+	return sign * count
+}
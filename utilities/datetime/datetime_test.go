@@ -0,0 +1,165 @@
+package datetime
+
+import (
+	"testing"
+	"time"
+)
+
+func mustZone(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := LoadZone(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func TestZonesAndPatterns(t *testing.T) {
+	for name, want := range map[string]string{"vn": "Asia/Ho_Chi_Minh", "Asia/Saigon": "Asia/Ho_Chi_Minh", "": DefaultZone, "UTC+7": "+07:00", "-0330": "-03:30"} {
+		if got, err := ZoneName(name); err != nil || got != want {
+			t.Fatalf("ZoneName(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	for _, bad := range []string{"Mars/Olympus", "Local", "+25:00"} {
+		if _, err := LoadZone(bad); err == nil {
+			t.Fatalf("LoadZone(%q) accepted", bad)
+		}
+	}
+
+	saigon := mustZone(t, "vn")
+	at := time.Date(2024, time.February, 9, 20, 5, 7, 123456789, saigon)
+	cases := []struct{ pattern, locale, want string }{
+		{"dd/MM/yyyy HH:mm", "", "09/02/2024 20:05"},
+		{"d/M/yy h:mm a", "", "9/2/24 8:05 PM"},
+		{"EEEE, dd MMMM yyyy", "en", "Friday, 09 February 2024"},
+		{"yyyy-MM-dd'T'HH:mm:ss.SSSXXX", "", "2024-02-09T20:05:07.123+07:00"},
+		{"'Quý' Q, 'it''s' z Z", "", "Quý 1, it's +07 +0700"},
+		{"EEEE, 'ngày' d MMMM 'lúc' HH'h'", "vi", "Thứ Sáu, ngày 9 tháng 2 lúc 20h"},
+	}
+	for _, c := range cases {
+		if got := Format(at, c.pattern, c.locale); got != c.want {
+			t.Fatalf("Format(%q) = %q, want %q", c.pattern, got, c.want)
+		}
+	}
+
+	parsed, err := Parse("10/02/2024 08:00", "dd/MM/yyyy HH:mm", saigon)
+	if err != nil || !parsed.Equal(time.Date(2024, 2, 10, 1, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Parse = %v, %v", parsed, err)
+	}
+	parsed, err = ParseAny("2024-02-10T08:00:00Z", saigon)
+	if err != nil || parsed.Hour() != 15 || parsed.Location() != saigon {
+		t.Fatalf("ParseAny offset = %v, %v", parsed, err)
+	}
+	if parsed, err = ParseAny("1/3/2024", saigon); err != nil || parsed.Month() != time.March {
+		t.Fatalf("ParseAny day-first = %v, %v", parsed, err)
+	}
+	if _, err := Parse("2024", "'Y2K' yyyy", saigon); err == nil {
+		t.Fatal("digits in a literal must be refused for parsing")
+	}
+}
+
+func TestPeriodsAndCalendarArithmetic(t *testing.T) {
+	saigon := mustZone(t, "Asia/Ho_Chi_Minh")
+	at := time.Date(2024, time.May, 15, 14, 30, 0, 0, saigon) // Wednesday
+	checks := []struct {
+		got, want time.Time
+	}{
+		{StartOf(at, Day), time.Date(2024, 5, 15, 0, 0, 0, 0, saigon)},
+		{StartOf(at, Week), time.Date(2024, 5, 13, 0, 0, 0, 0, saigon)},
+		{StartOf(at, Quarter), time.Date(2024, 4, 1, 0, 0, 0, 0, saigon)},
+		{EndOf(at, Month), time.Date(2024, 5, 31, 23, 59, 59, 999e6, saigon)},
+		{Add(time.Date(2024, 1, 31, 9, 0, 0, 0, saigon), 1, Month), time.Date(2024, 2, 29, 9, 0, 0, 0, saigon)},
+		{Add(time.Date(2024, 2, 29, 9, 0, 0, 0, saigon), 1, Year), time.Date(2025, 2, 28, 9, 0, 0, 0, saigon)},
+	}
+	for i, check := range checks {
+		if !check.got.Equal(check.want) {
+			t.Fatalf("check %d = %v, want %v", i, check.got, check.want)
+		}
+	}
+
+	// Calendar days keep the wall clock across a DST change; hours do not.
+	newYork := mustZone(t, "America/New_York")
+	before := time.Date(2024, time.March, 9, 8, 0, 0, 0, newYork)
+	if got := Add(before, 1, Day); got.Hour() != 8 {
+		t.Fatalf("+1 day across DST = %v", got)
+	}
+	if got := Add(before, 24, Hour); got.Hour() != 9 {
+		t.Fatalf("+24h across DST = %v", got)
+	}
+	if Diff(before, Add(before, 1, Day), Day) != 1 || Diff(before, Add(before, 1, Day), Hour) != 23 {
+		t.Fatal("Diff across DST")
+	}
+	if Diff(time.Date(2024, 1, 31, 0, 0, 0, 0, saigon), time.Date(2024, 2, 29, 0, 0, 0, 0, saigon), Month) != 1 {
+		t.Fatal("Diff months with clamping")
+	}
+	if unit, err := ParseUnit("Days"); err != nil || unit != Day {
+		t.Fatalf("ParseUnit = %v, %v", unit, err)
+	}
+}
+
+func TestLunarConversion(t *testing.T) {
+	saigon := mustZone(t, "vn")
+	known := []struct {
+		solar time.Time
+		lunar LunarDate
+	}{
+		{time.Date(2024, 2, 10, 0, 0, 0, 0, saigon), LunarDate{Day: 1, Month: 1, Year: 2024}},
+		{time.Date(2025, 1, 29, 0, 0, 0, 0, saigon), LunarDate{Day: 1, Month: 1, Year: 2025}},
+		{time.Date(2024, 9, 17, 0, 0, 0, 0, saigon), LunarDate{Day: 15, Month: 8, Year: 2024}},
+		{time.Date(2024, 2, 9, 0, 0, 0, 0, saigon), LunarDate{Day: 30, Month: 12, Year: 2023}},
+		{time.Date(2023, 3, 22, 0, 0, 0, 0, saigon), LunarDate{Day: 1, Month: 2, Year: 2023, Leap: true}},
+		{time.Date(2025, 7, 25, 0, 0, 0, 0, saigon), LunarDate{Day: 1, Month: 6, Year: 2025, Leap: true}},
+		// 2007: Vietnam (UTC+7) had Tết one day before China (UTC+8).
+		{time.Date(2007, 2, 17, 0, 0, 0, 0, saigon), LunarDate{Day: 1, Month: 1, Year: 2007}},
+	}
+	for _, k := range known {
+		if got := ToLunar(k.solar); got != k.lunar {
+			t.Fatalf("ToLunar(%s) = %+v, want %+v", k.solar.Format("2006-01-02"), got, k.lunar)
+		}
+		back, err := FromLunar(k.lunar, saigon)
+		if err != nil || !back.Equal(k.solar) {
+			t.Fatalf("FromLunar(%v) = %v, %v", k.lunar, back, err)
+		}
+	}
+	if name := (LunarDate{Year: 2024}).YearName(); name != "Giáp Thìn" {
+		t.Fatalf("YearName = %q", name)
+	}
+	if _, err := FromLunar(LunarDate{Day: 1, Month: 5, Year: 2024, Leap: true}, saigon); err == nil {
+		t.Fatal("2024 has no leap 5th month")
+	}
+	if _, err := FromLunar(LunarDate{Day: 30, Month: 12, Year: 2024}, saigon); err == nil {
+		t.Fatal("lunar 12/2024 has 29 days")
+	}
+}
+
+func TestBusinessDays(t *testing.T) {
+	saigon := mustZone(t, "vn")
+	calendar, err := NewCalendar(nil, []string{"2024-04-29", "04-30", "05-01", "lunar:03-10", "lunar:01-01", "lunar:01-02"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	friday := time.Date(2024, 4, 26, 17, 0, 0, 0, saigon)
+	// Sat, Sun, then 29/4 (bridge day), 30/4, 1/5 are off: Friday + 1 business day is Thursday 2/5.
+	if got := calendar.AddBusinessDays(friday, 1); !got.Equal(time.Date(2024, 5, 2, 17, 0, 0, 0, saigon)) {
+		t.Fatalf("AddBusinessDays = %v", got)
+	}
+	if got := calendar.AddBusinessDays(time.Date(2024, 5, 2, 9, 0, 0, 0, saigon), -1); got.Day() != 26 {
+		t.Fatalf("AddBusinessDays(-1) = %v", got)
+	}
+	// Giỗ Tổ 2024 (10/3 lunar) fell on Thursday 18/04; Tết 2024 on Saturday 10/02 and Sunday 11/02.
+	if calendar.IsBusinessDay(time.Date(2024, 4, 18, 9, 0, 0, 0, saigon)) {
+		t.Fatal("lunar holiday counted as a business day")
+	}
+	if got := calendar.BusinessDaysBetween(time.Date(2024, 4, 15, 0, 0, 0, 0, saigon), time.Date(2024, 4, 22, 0, 0, 0, 0, saigon)); got != 4 {
+		t.Fatalf("BusinessDaysBetween = %d", got)
+	}
+	if got := calendar.BusinessDaysBetween(time.Date(2024, 4, 22, 0, 0, 0, 0, saigon), time.Date(2024, 4, 15, 0, 0, 0, 0, saigon)); got != -4 {
+		t.Fatalf("BusinessDaysBetween reversed = %d", got)
+	}
+	if _, err := NewCalendar(nil, []string{"30/04"}); err == nil {
+		t.Fatal("malformed holiday accepted")
+	}
+	if _, err := NewCalendar([]time.Weekday{0, 1, 2, 3, 4, 5, 6}, nil); err == nil {
+		t.Fatal("all-weekend calendar accepted")
+	}
+}
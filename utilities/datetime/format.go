@@ -0,0 +1,287 @@
+package datetime
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Patterns use the CLDR letters every date library shares (dayjs, date-fns, Java, .NET), NOT Go's
+// reference layout — "dd/MM/yyyy HH:mm" rather than "02/01/2006 15:04":
+//
+//	yyyy yy        year                       Q          quarter 1–4
+//	M MM MMM MMMM  month 1, 01, Jan, January  d dd       day of month
+//	E EEE EEEE     Mon, Mon, Monday           H HH       hour 0–23
+//	h hh           hour 1–12                  a          AM/PM
+//	m mm           minute                     s ss       second
+//	S SS SSS       fraction of a second       z          zone abbreviation (ICT)
+//	X XXX / Z      offset +07 +07:00 / +0700  'text'     literal ('' is a quote)
+//
+// Any other character is copied as is. Locale "vi" names months, weekdays and AM/PM in Vietnamese
+// ("Thứ Hai", "tháng 2", "SA"/"CH"); every other locale is English.
+
+type token struct {
+	letter  byte   // pattern letter, 0 for a literal
+	count   int    // repeat count of letter
+	literal string // text of a literal run
+}
+
+const patternLetters = "yQMdEHhamsSzXZ"
+
+func tokenize(pattern string) []token {
+	var tokens []token
+	var literal strings.Builder
+	flush := func() {
+		if literal.Len() > 0 {
+			tokens = append(tokens, token{literal: literal.String()})
+			literal.Reset()
+		}
+	}
+	for i := 0; i < len(pattern); {
+		char := pattern[i]
+		switch {
+		case char == '\'':
+			// Quoted literal; '' inside or outside quotes is one quote.
+			i++
+			if i < len(pattern) && pattern[i] == '\'' {
+				literal.WriteByte('\'')
+				i++
+				continue
+			}
+			for i < len(pattern) {
+				if pattern[i] == '\'' {
+					if i+1 < len(pattern) && pattern[i+1] == '\'' {
+						literal.WriteByte('\'')
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				literal.WriteByte(pattern[i])
+				i++
+			}
+		case strings.IndexByte(patternLetters, char) >= 0:
+			flush()
+			count := 1
+			for i+count < len(pattern) && pattern[i+count] == char {
+				count++
+			}
+			tokens = append(tokens, token{letter: char, count: count})
+			i += count
+		default:
+			literal.WriteByte(char)
+			i++
+		}
+	}
+	flush()
+	return tokens
+}
+
+var (
+	viWeekdays      = [...]string{"Chủ Nhật", "Thứ Hai", "Thứ Ba", "Thứ Tư", "Thứ Năm", "Thứ Sáu", "Thứ Bảy"}
+	viWeekdaysShort = [...]string{"CN", "T2", "T3", "T4", "T5", "T6", "T7"}
+)
+
+// Format renders t (in its own Location) with a CLDR pattern; see the table above.
+func Format(t time.Time, pattern, locale string) string {
+	vi := strings.HasPrefix(strings.ToLower(locale), "vi")
+	var out strings.Builder
+	for _, tok := range tokenize(pattern) {
+		if tok.letter == 0 {
+			out.WriteString(tok.literal)
+			continue
+		}
+		switch tok.letter {
+		case 'y':
+			if tok.count == 2 {
+				out.WriteString(pad(t.Year()%100, 2))
+			} else {
+				out.WriteString(pad(t.Year(), tok.count))
+			}
+		case 'Q':
+			out.WriteString(strconv.Itoa((int(t.Month())-1)/3 + 1))
+		case 'M':
+			switch {
+			case tok.count >= 4 && vi:
+				out.WriteString("tháng " + strconv.Itoa(int(t.Month())))
+			case tok.count >= 4:
+				out.WriteString(t.Month().String())
+			case tok.count == 3 && vi:
+				out.WriteString("thg " + strconv.Itoa(int(t.Month())))
+			case tok.count == 3:
+				out.WriteString(t.Month().String()[:3])
+			default:
+				out.WriteString(pad(int(t.Month()), tok.count))
+			}
+		case 'd':
+			out.WriteString(pad(t.Day(), tok.count))
+		case 'E':
+			switch {
+			case tok.count >= 4 && vi:
+				out.WriteString(viWeekdays[t.Weekday()])
+			case tok.count >= 4:
+				out.WriteString(t.Weekday().String())
+			case vi:
+				out.WriteString(viWeekdaysShort[t.Weekday()])
+			default:
+				out.WriteString(t.Weekday().String()[:3])
+			}
+		case 'H':
+			out.WriteString(pad(t.Hour(), tok.count))
+		case 'h':
+			hour := t.Hour() % 12
+			if hour == 0 {
+				hour = 12
+			}
+			out.WriteString(pad(hour, tok.count))
+		case 'a':
+			switch {
+			case vi && t.Hour() < 12:
+				out.WriteString("SA")
+			case vi:
+				out.WriteString("CH")
+			case t.Hour() < 12:
+				out.WriteString("AM")
+			default:
+				out.WriteString("PM")
+			}
+		case 'm':
+			out.WriteString(pad(t.Minute(), tok.count))
+		case 's':
+			out.WriteString(pad(t.Second(), tok.count))
+		case 'S':
+			fraction := fmt.Sprintf("%09d", t.Nanosecond())
+			if tok.count < len(fraction) {
+				fraction = fraction[:tok.count]
+			}
+			out.WriteString(fraction)
+		case 'z':
+			name, _ := t.Zone()
+			out.WriteString(name)
+		case 'X':
+			_, offset := t.Zone()
+			switch {
+			case offset == 0:
+				out.WriteByte('Z')
+			case tok.count == 1 && offset%3600 == 0:
+				out.WriteString(formatOffset(offset, true)[:3])
+			default:
+				out.WriteString(formatOffset(offset, tok.count >= 3))
+			}
+		case 'Z':
+			_, offset := t.Zone()
+			out.WriteString(formatOffset(offset, tok.count >= 5))
+		}
+	}
+	return out.String()
+}
+
+func pad(n, width int) string {
+	text := strconv.Itoa(n)
+	for len(text) < width {
+		text = "0" + text
+	}
+	return text
+}
+
+// Parse reads text with a CLDR pattern in loc; a parsed offset (X, Z) fixes the instant and the
+// result is then expressed in loc. Month and weekday NAMES parse in English only.
+func Parse(text, pattern string, loc *time.Location) (time.Time, error) {
+	layout, err := goLayout(pattern)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, err := time.ParseInLocation(layout, strings.TrimSpace(text), loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q does not match %q", text, pattern)
+	}
+	return t.In(loc), nil
+}
+
+// goLayout translates a CLDR pattern to Go's reference layout for parsing.
+func goLayout(pattern string) (string, error) {
+	var layout strings.Builder
+	for _, tok := range tokenize(pattern) {
+		if tok.letter == 0 {
+			// Go reads digits in a layout as reference values, so they cannot be literals.
+			if strings.ContainsAny(tok.literal, "0123456789") {
+				return "", fmt.Errorf("pattern %q: digits cannot be parsed as literal text", pattern)
+			}
+			layout.WriteString(tok.literal)
+			continue
+		}
+		part, ok := goLayoutPart(tok)
+		if !ok {
+			return "", fmt.Errorf("pattern %q: %s cannot be parsed", pattern, strings.Repeat(string(tok.letter), tok.count))
+		}
+		layout.WriteString(part)
+	}
+	return layout.String(), nil
+}
+
+func goLayoutPart(tok token) (string, bool) {
+	switch tok.letter {
+	case 'y':
+		if tok.count == 2 {
+			return "06", true
+		}
+		return "2006", true
+	case 'M':
+		return pick(tok.count, "1", "01", "Jan", "January"), true
+	case 'd':
+		return pick(tok.count, "2", "02", "02", "02"), true
+	case 'E':
+		return pick(tok.count, "Mon", "Mon", "Mon", "Monday"), true
+	case 'H':
+		return "15", true
+	case 'h':
+		return pick(tok.count, "3", "03", "03", "03"), true
+	case 'a':
+		return "PM", true
+	case 'm':
+		return pick(tok.count, "4", "04", "04", "04"), true
+	case 's':
+		return pick(tok.count, "5", "05", "05", "05"), true
+	case 'S':
+		return strings.Repeat("0", min(tok.count, 9)), true
+	case 'z':
+		return "MST", true
+	case 'X':
+		return pick(tok.count, "Z07", "Z0700", "Z07:00", "Z07:00"), true
+	case 'Z':
+		return "-0700", true
+	}
+	return "", false
+}
+
+func pick(count int, options ...string) string {
+	return options[min(count, len(options))-1]
+}
+
+// isoLayouts are tried, in order, by ParseAny. Slash dates are DAY-first ("10/02/2024" is 10 Feb) —
+// the way our tenants write them; builtins Date keeps its US month-first reading.
+var isoLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2/1/2006 15:04:05",
+	"2/1/2006 15:04",
+	"2/1/2006",
+}
+
+// ParseAny reads the common ISO and day-first forms without a pattern. Text without an offset is a
+// wall-clock time in loc.
+func ParseAny(text string, loc *time.Location) (time.Time, error) {
+	text = strings.TrimSpace(text)
+	for _, layout := range isoLayouts {
+		if t, err := time.ParseInLocation(layout, text, loc); err == nil {
+			return t.In(loc), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot read %q as a date (use yyyy-MM-dd[THH:mm[:ss]] or dd/MM/yyyy [HH:mm])", text)
+}
@@ -0,0 +1,242 @@
+package datetime
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Vietnamese lunar calendar (Âm lịch), after Hồ Ngọc Đức's astronomical algorithm: new moons and
+// solar terms are computed for the UTC+7 meridian, which is why Vietnam and China — which computes at
+// UTC+8 — occasionally celebrate Tết on different days (1985, 2007). Valid roughly 1800–2199.
+
+// LunarMeridian is the time zone offset, in hours, the Vietnamese lunar calendar is computed for.
+const LunarMeridian = 7.0
+
+// LunarDate is a day of the lunar calendar. Leap is set inside a leap month (tháng nhuận), which
+// repeats the number of the month before it.
+type LunarDate struct {
+	Day   int
+	Month int
+	Year  int
+	Leap  bool
+}
+
+var (
+	heavenlyStems   = [...]string{"Giáp", "Ất", "Bính", "Đinh", "Mậu", "Kỷ", "Canh", "Tân", "Nhâm", "Quý"}
+	earthlyBranches = [...]string{"Tý", "Sửu", "Dần", "Mão", "Thìn", "Tỵ", "Ngọ", "Mùi", "Thân", "Dậu", "Tuất", "Hợi"}
+)
+
+// YearName is the sexagenary (can chi) name of the lunar year: 2024 → "Giáp Thìn".
+func (d LunarDate) YearName() string {
+	return heavenlyStems[(d.Year+6)%10] + " " + earthlyBranches[(d.Year+8)%12]
+}
+
+// String renders "15/08/2024", with " (nhuận)" inside a leap month.
+func (d LunarDate) String() string {
+	text := fmt.Sprintf("%02d/%02d/%d", d.Day, d.Month, d.Year)
+	if d.Leap {
+		text += " (nhuận)"
+	}
+	return text
+}
+
+// ToLunar converts the wall-clock DATE of t (in t's zone) to the lunar calendar.
+func ToLunar(t time.Time) LunarDate {
+	year, month, day := t.Date()
+	return solarToLunar(day, int(month), year, LunarMeridian)
+}
+
+// FromLunar returns midnight, in loc, of the solar day a lunar date falls on. Day 30 of a 29-day
+// month, or Leap on a month that is not that year's leap month, is an error.
+func FromLunar(date LunarDate, loc *time.Location) (time.Time, error) {
+	if date.Month < 1 || date.Month > 12 || date.Day < 1 || date.Day > 30 {
+		return time.Time{}, fmt.Errorf("invalid lunar date %s", date)
+	}
+	day, month, year, ok := lunarToSolar(date.Day, date.Month, date.Year, date.Leap, LunarMeridian)
+	if !ok {
+		return time.Time{}, fmt.Errorf("lunar year %d has no leap month %d", date.Year, date.Month)
+	}
+	t := time.Date(year, time.Month(month), day, 0, 0, 0, 0, loc)
+	// Round-trip to reject a day 30 that spilled into the next month.
+	if back := ToLunar(t); back.Day != date.Day || back.Month != date.Month || back.Leap != date.Leap {
+		return time.Time{}, fmt.Errorf("lunar month %d/%d has no day %d", date.Month, date.Year, date.Day)
+	}
+	return t, nil
+}
+
+func jdFromDate(day, month, year int) int {
+	a := (14 - month) / 12
+	y := year + 4800 - a
+	m := month + 12*a - 3
+	jd := day + (153*m+2)/5 + 365*y + y/4 - y/100 + y/400 - 32045
+	if jd < 2299161 {
+		jd = day + (153*m+2)/5 + 365*y + y/4 - 32083
+	}
+	return jd
+}
+
+func jdToDate(jd int) (day, month, year int) {
+	var b, c int
+	if jd > 2299160 { // Gregorian
+		a := jd + 32044
+		b = (4*a + 3) / 146097
+		c = a - (b*146097)/4
+	} else {
+		c = jd + 32082
+	}
+	d := (4*c + 3) / 1461
+	e := c - (1461*d)/4
+	m := (5*e + 2) / 153
+	day = e - (153*m+2)/5 + 1
+	month = m + 3 - 12*(m/10)
+	year = b*100 + d - 4800 + m/10
+	return day, month, year
+}
+
+// newMoon is the Julian day of the k-th new moon after 1900-01-01 13:52 UCT.
+func newMoon(k int) float64 {
+	const dr = math.Pi / 180
+	T := float64(k) / 1236.85
+	T2 := T * T
+	T3 := T2 * T
+	jd1 := 2415020.75933 + 29.53058868*float64(k) + 0.0001178*T2 - 0.000000155*T3
+	jd1 += 0.00033 * math.Sin((166.56+132.87*T-0.009173*T2)*dr)
+	M := 359.2242 + 29.10535608*float64(k) - 0.0000333*T2 - 0.00000347*T3
+	Mpr := 306.0253 + 385.81691806*float64(k) + 0.0107306*T2 + 0.00001236*T3
+	F := 21.2964 + 390.67050646*float64(k) - 0.0016528*T2 - 0.00000239*T3
+	C1 := (0.1734-0.000393*T)*math.Sin(M*dr) + 0.0021*math.Sin(2*dr*M)
+	C1 = C1 - 0.4068*math.Sin(Mpr*dr) + 0.0161*math.Sin(dr*2*Mpr)
+	C1 = C1 - 0.0004*math.Sin(dr*3*Mpr)
+	C1 = C1 + 0.0104*math.Sin(dr*2*F) - 0.0051*math.Sin(dr*(M+Mpr))
+	C1 = C1 - 0.0074*math.Sin(dr*(M-Mpr)) + 0.0004*math.Sin(dr*(2*F+M))
+	C1 = C1 - 0.0004*math.Sin(dr*(2*F-M)) - 0.0006*math.Sin(dr*(2*F+Mpr))
+	C1 = C1 + 0.0010*math.Sin(dr*(2*F-Mpr)) + 0.0005*math.Sin(dr*(2*Mpr+M))
+	var deltaT float64
+	if T < -11 {
+		deltaT = 0.001 + 0.000839*T + 0.0002261*T2 - 0.00000845*T3 - 0.000000081*T*T3
+	} else {
+		deltaT = -0.000278 + 0.000265*T + 0.000262*T2
+	}
+	return jd1 + C1 - deltaT
+}
+
+// sunLongitude is the sun's ecliptic longitude, in radians, at Julian day jdn.
+func sunLongitude(jdn float64) float64 {
+	const dr = math.Pi / 180
+	T := (jdn - 2451545.0) / 36525
+	T2 := T * T
+	M := 357.52910 + 35999.05030*T - 0.0001559*T2 - 0.00000048*T*T2
+	L0 := 280.46645 + 36000.76983*T + 0.0003032*T2
+	DL := (1.914600 - 0.004817*T - 0.000014*T2) * math.Sin(dr*M)
+	DL += (0.019993-0.000101*T)*math.Sin(dr*2*M) + 0.000290*math.Sin(dr*3*M)
+	L := (L0 + DL) * dr
+	return L - math.Pi*2*math.Floor(L/(math.Pi*2))
+}
+
+// sunSector is the solar term sector (0–11, 30° each) at local midnight starting day jd.
+func sunSector(jd int, tz float64) int {
+	return int(math.Floor(sunLongitude(float64(jd)-0.5-tz/24) / math.Pi * 6))
+}
+
+func newMoonDay(k int, tz float64) int {
+	return int(math.Floor(newMoon(k) + 0.5 + tz/24))
+}
+
+// lunarMonth11 is the first day of the lunar month containing the winter solstice of year.
+func lunarMonth11(year int, tz float64) int {
+	off := float64(jdFromDate(31, 12, year)) - 2415021
+	k := int(math.Floor(off / 29.530588853))
+	nm := newMoonDay(k, tz)
+	if sunSector(nm, tz) >= 9 {
+		nm = newMoonDay(k-1, tz)
+	}
+	return nm
+}
+
+// leapMonthOffset is the index, counted from month 11, of the first month without a major solar term.
+func leapMonthOffset(a11 int, tz float64) int {
+	k := int(math.Floor((float64(a11)-2415021.076998695)/29.530588853 + 0.5))
+	i := 1
+	arc := sunSector(newMoonDay(k+i, tz), tz)
+	for {
+		last := arc
+		i++
+		arc = sunSector(newMoonDay(k+i, tz), tz)
+		if arc == last || i >= 14 {
+			break
+		}
+	}
+	return i - 1
+}
+
+func solarToLunar(day, month, year int, tz float64) LunarDate {
+	dayNumber := jdFromDate(day, month, year)
+	k := int(math.Floor((float64(dayNumber) - 2415021.076998695) / 29.530588853))
+	monthStart := newMoonDay(k+1, tz)
+	if monthStart > dayNumber {
+		monthStart = newMoonDay(k, tz)
+	}
+	a11 := lunarMonth11(year, tz)
+	b11 := a11
+	var lunarYear int
+	if a11 >= monthStart {
+		lunarYear = year
+		a11 = lunarMonth11(year-1, tz)
+	} else {
+		lunarYear = year + 1
+		b11 = lunarMonth11(year+1, tz)
+	}
+	lunarDay := dayNumber - monthStart + 1
+	diff := (monthStart - a11) / 29
+	leap := false
+	lunarMonth := diff + 11
+	if b11-a11 > 365 {
+		leapDiff := leapMonthOffset(a11, tz)
+		if diff >= leapDiff {
+			lunarMonth = diff + 10
+			leap = diff == leapDiff
+		}
+	}
+	if lunarMonth > 12 {
+		lunarMonth -= 12
+	}
+	if lunarMonth >= 11 && diff < 4 {
+		lunarYear--
+	}
+	return LunarDate{Day: lunarDay, Month: lunarMonth, Year: lunarYear, Leap: leap}
+}
+
+func lunarToSolar(lunarDay, lunarMonth, lunarYear int, leap bool, tz float64) (day, month, year int, ok bool) {
+	var a11, b11 int
+	if lunarMonth < 11 {
+		a11 = lunarMonth11(lunarYear-1, tz)
+		b11 = lunarMonth11(lunarYear, tz)
+	} else {
+		a11 = lunarMonth11(lunarYear, tz)
+		b11 = lunarMonth11(lunarYear+1, tz)
+	}
+	k := int(math.Floor(0.5 + (float64(a11)-2415021.076998695)/29.530588853))
+	off := lunarMonth - 11
+	if off < 0 {
+		off += 12
+	}
+	if b11-a11 > 365 {
+		leapOff := leapMonthOffset(a11, tz)
+		leapMonth := leapOff - 2
+		if leapMonth < 0 {
+			leapMonth += 12
+		}
+		if leap && lunarMonth != leapMonth {
+			return 0, 0, 0, false
+		}
+		if leap || off >= leapOff {
+			off++
+		}
+	} else if leap {
+		return 0, 0, 0, false
+	}
+	monthStart := newMoonDay(k+off, tz)
+	day, month, year = jdToDate(monthStart + lunarDay - 1)
+	return day, month, year, true
+}
@@ -0,0 +1,170 @@
+package datetime
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Unit is a calendar or clock unit accepted by Add, StartOf and EndOf.
+type Unit string
+
+const (
+	Year        Unit = "year"
+	Quarter     Unit = "quarter"
+	Month       Unit = "month"
+	Week        Unit = "week" // weeks start on Monday (ISO 8601, and the Vietnamese calendar)
+	Day         Unit = "day"
+	Hour        Unit = "hour"
+	Minute      Unit = "minute"
+	Second      Unit = "second"
+	Millisecond Unit = "millisecond"
+)
+
+var unitAliases = map[string]Unit{
+	"y": Year, "yr": Year, "q": Quarter, "mo": Month, "w": Week, "d": Day,
+	"h": Hour, "hr": Hour, "min": Minute, "s": Second, "sec": Second, "ms": Millisecond,
+}
+
+// ParseUnit accepts a unit name, its plural ("days") or its short form ("d", "min", "ms").
+func ParseUnit(name string) (Unit, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if unit, ok := unitAliases[name]; ok {
+		return unit, nil
+	}
+	name = strings.TrimSuffix(name, "s")
+	switch unit := Unit(name); unit {
+	case Year, Quarter, Month, Week, Day, Hour, Minute, Second, Millisecond:
+		return unit, nil
+	}
+	return "", fmt.Errorf("unknown time unit %q", name)
+}
+
+// Add moves t by n units. Calendar units keep the wall clock ("+1 day" at 08:00 is 08:00 the next day,
+// across a DST change too) and month/year arithmetic clamps to the month's last day — 31/01 + 1 month
+// is 28/02 or 29/02, never 03/03. Clock units add exact elapsed time.
+func Add(t time.Time, n int, unit Unit) time.Time {
+	switch unit {
+	case Year:
+		return addMonths(t, 12*n)
+	case Quarter:
+		return addMonths(t, 3*n)
+	case Month:
+		return addMonths(t, n)
+	case Week:
+		return t.AddDate(0, 0, 7*n)
+	case Day:
+		return t.AddDate(0, 0, n)
+	case Hour:
+		return t.Add(time.Duration(n) * time.Hour)
+	case Minute:
+		return t.Add(time.Duration(n) * time.Minute)
+	case Second:
+		return t.Add(time.Duration(n) * time.Second)
+	case Millisecond:
+		return t.Add(time.Duration(n) * time.Millisecond)
+	}
+	return t
+}
+
+func addMonths(t time.Time, months int) time.Time {
+	year, month, day := t.Date()
+	first := time.Date(year, month+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	return first.AddDate(0, 0, min(day, DaysIn(first.Year(), first.Month()))-1)
+}
+
+// DaysIn returns the number of days in a Gregorian month.
+func DaysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// StartOf truncates t to the beginning of its unit in t's own zone.
+func StartOf(t time.Time, unit Unit) time.Time {
+	year, month, day := t.Date()
+	loc := t.Location()
+	switch unit {
+	case Year:
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+	case Quarter:
+		return time.Date(year, month-(month-1)%3, 1, 0, 0, 0, 0, loc)
+	case Month:
+		return time.Date(year, month, 1, 0, 0, 0, 0, loc)
+	case Week:
+		back := (int(t.Weekday()) + 6) % 7 // days since Monday
+		return time.Date(year, month, day-back, 0, 0, 0, 0, loc)
+	case Day:
+		return time.Date(year, month, day, 0, 0, 0, 0, loc)
+	case Hour:
+		return time.Date(year, month, day, t.Hour(), 0, 0, 0, loc)
+	case Minute:
+		return time.Date(year, month, day, t.Hour(), t.Minute(), 0, 0, loc)
+	case Second:
+		return time.Date(year, month, day, t.Hour(), t.Minute(), t.Second(), 0, loc)
+	case Millisecond:
+		return t.Truncate(time.Millisecond)
+	}
+	return t
+}
+
+// EndOf is the last millisecond of t's unit (23:59:59.999 for a day) — the inclusive upper bound
+// reports and SQL BETWEEN expect.
+func EndOf(t time.Time, unit Unit) time.Time {
+	return Add(StartOf(t, unit), 1, unit).Add(-time.Millisecond)
+}
+
+// Diff counts whole units from a to b (negative when b is earlier). Calendar units compare wall
+// clocks, so a day across a DST change is still one day.
+func Diff(a, b time.Time, unit Unit) int {
+	b = b.In(a.Location())
+	switch unit {
+	case Year, Quarter, Month:
+		months := (b.Year()-a.Year())*12 + int(b.Month()-a.Month())
+		// Drop the last month if it is not complete yet.
+		if months > 0 && addMonths(a, months).After(b) {
+			months--
+		} else if months < 0 && addMonths(a, months).Before(b) {
+			months++
+		}
+		switch unit {
+		case Year:
+			return months / 12
+		case Quarter:
+			return months / 3
+		}
+		return months
+	case Week, Day:
+		days := civilDays(b) - civilDays(a)
+		clockA, clockB := wallClock(a), wallClock(b)
+		if days > 0 && clockB < clockA {
+			days--
+		} else if days < 0 && clockB > clockA {
+			days++
+		}
+		if unit == Week {
+			return days / 7
+		}
+		return days
+	case Hour:
+		return int(b.Sub(a) / time.Hour)
+	case Minute:
+		return int(b.Sub(a) / time.Minute)
+	case Second:
+		return int(b.Sub(a) / time.Second)
+	case Millisecond:
+		return int(b.Sub(a) / time.Millisecond)
+	}
+	return 0
+}
+
+// civilDays numbers t's wall-clock date, ignoring the clock and the zone offset.
+func civilDays(t time.Time) int {
+	year, month, day := t.Date()
+	return int(time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Unix() / 86400)
+}
+
+// wallClock is the time of day shown on t's clock, which is not the time elapsed since midnight on
+// the day a DST change happens.
+func wallClock(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second + time.Duration(t.Nanosecond())
+}
@@ -0,0 +1,124 @@
+// Package datetime is the zone-aware calendar behind the `time` capability: IANA zones with the
+// short aliases our tenants actually type, "dd/MM/yyyy HH:mm" patterns, start/end-of-period math,
+// business days over a holiday list, and Vietnamese lunar (Âm lịch) conversion.
+//
+// Every function takes and returns a time.Time whose Location is the zone the caller works in — the
+// wall clock IS the point: "start of day" in Asia/Ho_Chi_Minh and in UTC are different instants.
+//
+// This is pure Go. The kitwork() JS binding lives in capabilities/datetime and work/time.go.
+package datetime
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata" // zones resolve on hosts without /usr/share/zoneinfo (scratch images)
+)
+
+// DefaultZone is the zone used when a script names none. It is NOT the host's time.Local: a tenant's
+// "today" must not change when the app moves between servers.
+const DefaultZone = "Asia/Ho_Chi_Minh"
+
+// zoneAliases maps the informal names tenants write to their IANA zone. Keys are lower-case.
+var zoneAliases = map[string]string{
+	"vn":          "Asia/Ho_Chi_Minh",
+	"vietnam":     "Asia/Ho_Chi_Minh",
+	"ict":         "Asia/Ho_Chi_Minh",
+	"asia/saigon": "Asia/Ho_Chi_Minh",
+	"hanoi":       "Asia/Ho_Chi_Minh",
+	"saigon":      "Asia/Ho_Chi_Minh",
+	"utc":         "UTC",
+	"gmt":         "UTC",
+	"z":           "UTC",
+}
+
+var zones sync.Map // canonical name → *time.Location
+
+// LoadZone resolves an IANA name ("Asia/Ho_Chi_Minh"), an alias ("vn", "ICT", "Asia/Saigon") or a
+// fixed offset ("+07:00", "UTC+7", "GMT-03:30"). An empty name is DefaultZone. "Local" is refused on
+// purpose — see DefaultZone.
+func LoadZone(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = DefaultZone
+	}
+	if alias, ok := zoneAliases[strings.ToLower(name)]; ok {
+		name = alias
+	}
+	if cached, ok := zones.Load(name); ok {
+		return cached.(*time.Location), nil
+	}
+	if strings.EqualFold(name, "local") {
+		return nil, fmt.Errorf("time zone %q depends on the host; name the zone (e.g. %q)", name, DefaultZone)
+	}
+	loc, err := fixedZone(name)
+	if err != nil {
+		return nil, err
+	}
+	if loc == nil {
+		if loc, err = time.LoadLocation(name); err != nil {
+			return nil, fmt.Errorf("unknown time zone %q", name)
+		}
+	}
+	actual, _ := zones.LoadOrStore(name, loc)
+	return actual.(*time.Location), nil
+}
+
+// ZoneName is the name LoadZone would canonicalize name to ("vn" → "Asia/Ho_Chi_Minh"), or an error
+// for an unknown zone. Callers that persist a zone (cron definitions) store this form.
+func ZoneName(name string) (string, error) {
+	loc, err := LoadZone(name)
+	if err != nil {
+		return "", err
+	}
+	return loc.String(), nil
+}
+
+// fixedZone parses "+07:00", "-0330", "UTC+7", "GMT-3:30". It returns (nil, nil) for anything that
+// is not offset-shaped so LoadZone can try the IANA database next.
+func fixedZone(name string) (*time.Location, error) {
+	offset := name
+	for _, prefix := range []string{"UTC", "GMT", "utc", "gmt"} {
+		offset = strings.TrimPrefix(offset, prefix)
+	}
+	if offset == "" || (offset[0] != '+' && offset[0] != '-') {
+		return nil, nil
+	}
+	sign := 1
+	if offset[0] == '-' {
+		sign = -1
+	}
+	digits := strings.ReplaceAll(offset[1:], ":", "")
+	var hours, minutes int
+	var err error
+	switch len(digits) {
+	case 1, 2:
+		hours, err = strconv.Atoi(digits)
+	case 3, 4:
+		hours, err = strconv.Atoi(digits[:len(digits)-2])
+		if err == nil {
+			minutes, err = strconv.Atoi(digits[len(digits)-2:])
+		}
+	default:
+		err = strconv.ErrSyntax
+	}
+	if err != nil || hours > 14 || minutes > 59 {
+		return nil, fmt.Errorf("invalid UTC offset %q", name)
+	}
+	seconds := sign * (hours*3600 + minutes*60)
+	return time.FixedZone(formatOffset(seconds, true), seconds), nil
+}
+
+func formatOffset(seconds int, colon bool) string {
+	sign := '+'
+	if seconds < 0 {
+		sign = '-'
+		seconds = -seconds
+	}
+	if colon {
+		return fmt.Sprintf("%c%02d:%02d", sign, seconds/3600, seconds%3600/60)
+	}
+	return fmt.Sprintf("%c%02d%02d", sign, seconds/3600, seconds%3600/60)
+}
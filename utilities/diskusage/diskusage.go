@@ -0,0 +1,156 @@
+// Package diskusage accounts the bytes a site's uploads hold on disk: temp files still being
+// received and the files kept from them. The kept files are listed in a ledger next to the temp
+// files, so the count survives restarts and is rebuilt from what is really on disk when the site
+// starts. It knows nothing about HTTP or multipart bodies.
+package diskusage
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ledgerName is the file in dir listing kept files, one path per line relative to root.
+const ledgerName = "kept"
+
+// tempPattern matches the temp files a receiver creates in dir with os.CreateTemp(dir, "upload-*").
+const tempPattern = "upload-*"
+
+// Usage is one site's upload bytes. Every method is safe for concurrent use.
+type Usage struct {
+	root string // kept paths are recorded relative to it
+	dir  string // temp files and the ledger
+
+	mu   sync.Mutex
+	temp int64            // bytes of temp files being written or not yet released
+	kept map[string]int64 // relative path → size of a kept file
+}
+
+// Open counts the uploads under root: dir is where temp files and the ledger live. Temp files
+// left by an earlier process belong to requests that can no longer finish, so they are removed;
+// ledger entries whose file is gone are dropped.
+func Open(root, dir string) *Usage {
+	u := &Usage{root: root, dir: dir, kept: map[string]int64{}}
+	if stale, err := filepath.Glob(filepath.Join(dir, tempPattern)); err == nil {
+		for _, path := range stale {
+			os.Remove(path)
+		}
+	}
+	if f, err := os.Open(filepath.Join(dir, ledgerName)); err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			if rel := strings.TrimSpace(scanner.Text()); rel != "" {
+				u.kept[rel] = 0
+			}
+		}
+		f.Close()
+	}
+	u.Recount()
+	return u
+}
+
+// Bytes is everything held: temp plus kept.
+func (u *Usage) Bytes() int64 {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.totalLocked()
+}
+
+// Reserve charges n temp bytes unless that would take the total past limit. It never touches the
+// disk: a caller about to refuse an upload may Recount once and try again.
+func (u *Usage) Reserve(n, limit int64) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.totalLocked()+n > limit {
+		return false
+	}
+	u.temp += n
+	return true
+}
+
+// Release returns n temp bytes: the request that reserved them has ended.
+func (u *Usage) Release(n int64) {
+	u.mu.Lock()
+	u.temp -= n
+	u.mu.Unlock()
+}
+
+// Keep records a file kept at path (resolved the same way as root) with size bytes, replacing what
+// an earlier file at the same path counted.
+func (u *Usage) Keep(path string, size int64) {
+	rel, err := filepath.Rel(u.root, path)
+	if err != nil {
+		return
+	}
+	rel = filepath.ToSlash(rel)
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if _, known := u.kept[rel]; !known {
+		if os.MkdirAll(u.dir, 0o700) == nil {
+			if f, err := os.OpenFile(filepath.Join(u.dir, ledgerName), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600); err == nil {
+				f.WriteString(rel + "\n")
+				f.Close()
+			}
+		}
+	}
+	u.kept[rel] = size
+}
+
+func (u *Usage) totalLocked() int64 {
+	total := u.temp
+	for _, size := range u.kept {
+		total += size
+	}
+	return total
+}
+
+// Recount re-reads the kept files' sizes, so files deleted since the last count stop counting, and
+// rewrites the ledger when it shrank. The files are statted without holding the lock: uploads to
+// the site go on while it runs.
+func (u *Usage) Recount() {
+	u.mu.Lock()
+	rels := make([]string, 0, len(u.kept))
+	for rel := range u.kept {
+		rels = append(rels, rel)
+	}
+	u.mu.Unlock()
+
+	sizes := make(map[string]int64, len(rels)) // -1: gone
+	for _, rel := range rels {
+		sizes[rel] = -1
+		if info, err := os.Stat(filepath.Join(u.root, filepath.FromSlash(rel))); err == nil && !info.IsDir() {
+			sizes[rel] = info.Size()
+		}
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	dropped := false
+	for rel, size := range sizes {
+		if _, known := u.kept[rel]; !known {
+			continue
+		}
+		if size < 0 {
+			delete(u.kept, rel)
+			dropped = true
+			continue
+		}
+		u.kept[rel] = size
+	}
+	if !dropped {
+		return
+	}
+	var b strings.Builder
+	for rel := range u.kept {
+		b.WriteString(rel + "\n")
+	}
+	ledger := filepath.Join(u.dir, ledgerName)
+	if tmp, err := os.CreateTemp(u.dir, "ledger-*"); err == nil {
+		_, werr := tmp.WriteString(b.String())
+		if cerr := tmp.Close(); werr != nil || cerr != nil || os.Rename(tmp.Name(), ledger) != nil {
+			os.Remove(tmp.Name())
+		}
+	}
+}
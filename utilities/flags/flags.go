@@ -0,0 +1,309 @@
+// Package flags evaluates feature flags and A/B experiments: kill switches, percentage rollouts,
+// weighted variants and attribute rules, with deterministic bucketing so a visitor keeps the answer
+// they got the first time.
+//
+// Definitions are JSON, one entry per flag:
+//
+//	{
+//	  "maintenance-banner": true,
+//	  "new-checkout": { "rollout": 10 },
+//	  "checkout-copy": { "variants": { "control": 50, "short": 25, "long": 25 } },
+//	  "beta-search": { "rules": [ { "country": ["VN", "SG"] }, { "header.x-beta": "1" }, { "ip": "10.0.0.0/8" } ] },
+//	  "old-thing": { "enabled": false }
+//	}
+//
+// A rule matches when every attribute it names matches (a list means any of); the first matching
+// rule serves its "serve" value (true by default, or a variant name) before rollout is consulted.
+package flags
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// Flag is one compiled definition.
+type Flag struct {
+	Key      string
+	Enabled  bool
+	Rollout  float64 // percent of visitors that get the flag on; 100 unless declared
+	Variants []Variant
+	Rules    []Rule
+}
+
+// Variant is one arm of an experiment and its share of traffic.
+type Variant struct {
+	Name   string
+	Weight float64
+}
+
+// Rule serves a fixed answer to the requests whose attributes match.
+type Rule struct {
+	Match map[string][]string // attribute → accepted values (ip values may be CIDR ranges)
+	Serve string              // "on", "off" or a variant name
+}
+
+// Attributes describe the request a flag is evaluated for: user, ip, country, header.<name>, and
+// whatever the caller adds.
+type Attributes map[string]string
+
+// Decision is the answer for one flag.
+type Decision struct {
+	Flag    string
+	On      bool
+	Variant string // the variant served, or "on"/"off" for a plain flag
+	Reason  string // unknown, disabled, rule, rollout, variant, default
+}
+
+// Random reports whether the decision came from bucketing, so it is worth recording as an exposure.
+func (d Decision) Random() bool { return d.Reason == "rollout" || d.Reason == "variant" }
+
+// Parse compiles a definitions document.
+func Parse(data []byte) (map[string]*Flag, error) {
+	var document map[string]any
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	set := make(map[string]*Flag, len(document))
+	for key, definition := range document {
+		flag, err := Compile(key, definition)
+		if err != nil {
+			return nil, err
+		}
+		set[key] = flag
+	}
+	return set, nil
+}
+
+// Compile reads one definition: a boolean, or an object with enabled, rollout, variants and rules.
+func Compile(key string, definition any) (*Flag, error) {
+	flag := &Flag{Key: key, Enabled: true, Rollout: 100}
+	switch d := definition.(type) {
+	case bool:
+		flag.Enabled = d
+		return flag, nil
+	case map[string]any:
+		if enabled, ok := d["enabled"].(bool); ok {
+			flag.Enabled = enabled
+		}
+		if rollout, ok := d["rollout"]; ok {
+			percent, ok := rollout.(float64)
+			if !ok || percent < 0 || percent > 100 {
+				return nil, fmt.Errorf("flag %q: rollout must be a percentage between 0 and 100", key)
+			}
+			flag.Rollout = percent
+		}
+		if variants, ok := d["variants"]; ok {
+			weights, ok := variants.(map[string]any)
+			if !ok || len(weights) == 0 {
+				return nil, fmt.Errorf("flag %q: variants must map names to weights", key)
+			}
+			for name, weight := range weights {
+				w, ok := weight.(float64)
+				if !ok || w < 0 {
+					return nil, fmt.Errorf("flag %q: variant %q needs a non-negative weight", key, name)
+				}
+				flag.Variants = append(flag.Variants, Variant{Name: name, Weight: w})
+			}
+			sort.Slice(flag.Variants, func(i, j int) bool { return flag.Variants[i].Name < flag.Variants[j].Name })
+		}
+		if rules, ok := d["rules"]; ok {
+			list, ok := rules.([]any)
+			if !ok {
+				return nil, fmt.Errorf("flag %q: rules must be a list", key)
+			}
+			for i, item := range list {
+				rule, err := compileRule(flag, item)
+				if err != nil {
+					return nil, fmt.Errorf("flag %q: rule %d: %w", key, i, err)
+				}
+				flag.Rules = append(flag.Rules, rule)
+			}
+		}
+		return flag, nil
+	}
+	return nil, fmt.Errorf("flag %q must be a boolean or an object", key)
+}
+
+func compileRule(flag *Flag, item any) (Rule, error) {
+	fields, ok := item.(map[string]any)
+	if !ok {
+		return Rule{}, fmt.Errorf("expected an object")
+	}
+	rule := Rule{Match: map[string][]string{}, Serve: "on"}
+	for name, want := range fields {
+		if name == "serve" {
+			switch s := want.(type) {
+			case bool:
+				if !s {
+					rule.Serve = "off"
+				}
+			case string:
+				if !flag.hasVariant(s) {
+					return Rule{}, fmt.Errorf("serves unknown variant %q", s)
+				}
+				rule.Serve = s
+			default:
+				return Rule{}, fmt.Errorf("serve must be a boolean or a variant name")
+			}
+			continue
+		}
+		var values []string
+		switch w := want.(type) {
+		case []any:
+			for _, v := range w {
+				values = append(values, fmt.Sprint(v))
+			}
+		default:
+			values = []string{fmt.Sprint(w)}
+		}
+		rule.Match[strings.ToLower(name)] = values
+	}
+	if len(rule.Match) == 0 {
+		return Rule{}, fmt.Errorf("matches nothing")
+	}
+	return rule, nil
+}
+
+func (f *Flag) hasVariant(name string) bool {
+	for _, v := range f.Variants {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Evaluate decides the flag for a request. bucketKey is the stable identity rollouts and variants
+// hash — a visitor id or a user id; the same key always lands in the same bucket.
+func (f *Flag) Evaluate(bucketKey string, attrs Attributes) Decision {
+	d := Decision{Flag: f.Key}
+	switch {
+	case !f.Enabled:
+		d.Reason = "disabled"
+	case f.matchRule(attrs, &d):
+		d.Reason = "rule"
+	case len(f.Variants) > 0:
+		d.Reason = "variant"
+		if Bucket(f.Key, bucketKey) < f.Rollout {
+			d.Variant = f.pick(Bucket(f.Key+"/variant", bucketKey))
+		}
+	case f.Rollout >= 100:
+		d.Reason, d.Variant = "default", "on"
+	default:
+		d.Reason = "rollout"
+		if Bucket(f.Key, bucketKey) < f.Rollout {
+			d.Variant = "on"
+		}
+	}
+	if d.Variant == "" {
+		d.Variant = "off"
+		if len(f.Variants) > 0 && d.Reason != "disabled" {
+			d.Variant = f.control() // outside the rollout
+		}
+	}
+	d.On = d.Variant != "off"
+	return d
+}
+
+func (f *Flag) matchRule(attrs Attributes, d *Decision) bool {
+	for _, rule := range f.Rules {
+		matched := true
+		for name, values := range rule.Match {
+			if !matchAttribute(name, attrs[name], values) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			d.Variant = rule.Serve
+			return true
+		}
+	}
+	return false
+}
+
+func matchAttribute(name, have string, values []string) bool {
+	if have == "" {
+		return false
+	}
+	for _, want := range values {
+		if name == "ip" && strings.Contains(want, "/") {
+			if _, network, err := net.ParseCIDR(want); err == nil {
+				if ip := net.ParseIP(have); ip != nil && network.Contains(ip) {
+					return true
+				}
+			}
+			continue
+		}
+		if strings.EqualFold(have, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// control is the arm visitors outside an experiment's rollout see: "control" when declared, else
+// the first variant by name.
+func (f *Flag) control() string {
+	if f.hasVariant("control") {
+		return "control"
+	}
+	return f.Variants[0].Name
+}
+
+// pick maps a bucket (0–100) onto the variants by weight.
+func (f *Flag) pick(bucket float64) string {
+	total := 0.0
+	for _, v := range f.Variants {
+		total += v.Weight
+	}
+	if total == 0 {
+		return f.Variants[0].Name
+	}
+	at := bucket / 100 * total
+	for _, v := range f.Variants {
+		if at < v.Weight {
+			return v.Name
+		}
+		at -= v.Weight
+	}
+	return f.Variants[len(f.Variants)-1].Name
+}
+
+// Bucket hashes key and id into [0, 100) with four decimal places of resolution.
+func Bucket(key, id string) float64 {
+	sum := sha256.Sum256([]byte(key + "\x00" + id))
+	return float64(binary.BigEndian.Uint32(sum[:4])%1_000_000) / 10_000
+}
+
+// NewVisitor is a fresh random visitor id.
+func NewVisitor() string {
+	var b [12]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// Sign seals a visitor id for a cookie: "<id>.<mac>".
+func Sign(id string, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(id))
+	return id + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// Verify opens a Sign()ed cookie value and returns the visitor id it carries.
+func Verify(cookie string, secret []byte) (string, bool) {
+	id, _, ok := strings.Cut(cookie, ".")
+	if !ok || id == "" || len(id) > 64 {
+		return "", false
+	}
+	return id, hmac.Equal([]byte(Sign(id, secret)), []byte(cookie))
+}
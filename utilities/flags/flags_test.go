@@ -0,0 +1,108 @@
+package flags
+
+import (
+	"database/sql"
+	"math"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestEvaluateRulesRolloutAndVariants(t *testing.T) {
+	set, err := Parse([]byte(`{
+		"banner": true,
+		"killed": { "enabled": false, "rules": [ { "user": "alice" } ] },
+		"new-checkout": { "rollout": 10 },
+		"beta": { "rules": [ { "country": ["VN", "SG"], "header.x-beta": "1" }, { "ip": "10.0.0.0/8", "serve": false } ], "rollout": 0 },
+		"copy": { "variants": { "control": 50, "short": 50 }, "rules": [ { "user": "qa", "serve": "short" } ] }
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := set["banner"].Evaluate("v1", nil); !d.On || d.Random() {
+		t.Fatalf("banner = %+v", d)
+	}
+	if d := set["killed"].Evaluate("v1", Attributes{"user": "alice"}); d.On || d.Reason != "disabled" {
+		t.Fatalf("killed = %+v", d)
+	}
+	if d := set["beta"].Evaluate("v1", Attributes{"country": "vn", "header.x-beta": "1"}); !d.On || d.Reason != "rule" {
+		t.Fatalf("beta by country = %+v", d)
+	}
+	if d := set["beta"].Evaluate("v1", Attributes{"country": "VN"}); d.On {
+		t.Fatalf("beta needs every condition = %+v", d)
+	}
+	if d := set["beta"].Evaluate("v1", Attributes{"ip": "10.1.2.3"}); d.On || d.Reason != "rule" {
+		t.Fatalf("beta by ip = %+v", d)
+	}
+	if d := set["copy"].Evaluate("v1", Attributes{"user": "qa"}); d.Variant != "short" {
+		t.Fatalf("copy for qa = %+v", d)
+	}
+
+	on, arms := 0, map[string]int{}
+	for i := 0; i < 10000; i++ {
+		id := NewVisitor()
+		d := set["new-checkout"].Evaluate(id, nil)
+		if d != set["new-checkout"].Evaluate(id, nil) {
+			t.Fatal("bucketing is not sticky")
+		}
+		if d.On {
+			on++
+		}
+		arms[set["copy"].Evaluate(id, nil).Variant]++
+	}
+	if math.Abs(float64(on)-1000) > 150 {
+		t.Fatalf("10%% rollout turned on %d of 10000", on)
+	}
+	if math.Abs(float64(arms["control"])-5000) > 300 || arms["control"]+arms["short"] != 10000 {
+		t.Fatalf("50/50 split = %v", arms)
+	}
+
+	if _, err := Parse([]byte(`{"x": {"rollout": 150}}`)); err == nil {
+		t.Fatal("rollout above 100 should be rejected")
+	}
+	if _, err := Parse([]byte(`{"x": {"variants": {"a": 1}, "rules": [{"user": "u", "serve": "b"}]}}`)); err == nil {
+		t.Fatal("a rule serving an unknown variant should be rejected")
+	}
+}
+
+func TestSignedVisitor(t *testing.T) {
+	secret := []byte("k")
+	cookie := Sign("abc", secret)
+	if id, ok := Verify(cookie, secret); !ok || id != "abc" {
+		t.Fatalf("Verify(%q) = %q %v", cookie, id, ok)
+	}
+	for _, forged := range []string{"abd" + cookie[3:], "abc", cookie + "x", Sign("abc", []byte("other"))} {
+		if _, ok := Verify(forged, secret); ok {
+			t.Fatalf("Verify accepted %q", forged)
+		}
+	}
+}
+
+func TestStore(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	store, err := NewStore(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put("x", map[string]any{"rollout": float64(25)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put("bad", map[string]any{"rollout": "lots"}); err == nil {
+		t.Fatal("an invalid definition was stored")
+	}
+	set, err := store.Definitions()
+	if err != nil || set["x"] == nil || set["x"].Rollout != 25 {
+		t.Fatalf("Definitions = %v %v", set, err)
+	}
+	if err := store.Record([]Exposure{{"x", "on", "v1", "/"}, {"x", "on", "v1", "/a"}, {"x", "off", "v2", "/"}}); err != nil {
+		t.Fatal(err)
+	}
+	summary, err := store.Summarize("x")
+	if err != nil || len(summary) != 2 || summary[1] != (Summary{"x", "on", 2, 1}) {
+		t.Fatalf("Summarize = %+v %v", summary, err)
+	}
+}
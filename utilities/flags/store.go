@@ -0,0 +1,141 @@
+package flags
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Exposure records that a visitor was served a bucketed answer.
+type Exposure struct {
+	Flag    string
+	Variant string
+	Visitor string
+	Path    string
+}
+
+// Summary is the exposure count for one arm of a flag.
+type Summary struct {
+	Flag     string `json:"flag"`
+	Variant  string `json:"variant"`
+	Exposed  int    `json:"exposed"`
+	Visitors int    `json:"visitors"`
+}
+
+// Store keeps flag definitions managed at runtime and the exposure log, in a tenant database.
+type Store struct {
+	db *sql.DB
+}
+
+// NewStore prepares the flags and flag_exposures tables.
+func NewStore(db *sql.DB) (*Store, error) {
+	if db == nil {
+		return nil, fmt.Errorf("flags store unavailable")
+	}
+	for _, ddl := range []string{
+		`CREATE TABLE IF NOT EXISTS flags (
+			key TEXT PRIMARY KEY,
+			definition TEXT NOT NULL,
+			updated_at TEXT NOT NULL)`,
+		`CREATE TABLE IF NOT EXISTS flag_exposures (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			flag TEXT NOT NULL,
+			variant TEXT NOT NULL,
+			visitor TEXT NOT NULL,
+			path TEXT NOT NULL,
+			exposed_at TEXT NOT NULL)`,
+		`CREATE INDEX IF NOT EXISTS flag_exposures_flag ON flag_exposures (flag, variant)`,
+	} {
+		if _, err := db.Exec(ddl); err != nil {
+			return nil, err
+		}
+	}
+	return &Store{db: db}, nil
+}
+
+// Definitions loads every stored flag.
+func (s *Store) Definitions() (map[string]*Flag, error) {
+	rows, err := s.db.Query(`SELECT key, definition FROM flags`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	set := map[string]*Flag{}
+	for rows.Next() {
+		var key, raw string
+		if err := rows.Scan(&key, &raw); err != nil {
+			return nil, err
+		}
+		var definition any
+		if err := json.Unmarshal([]byte(raw), &definition); err != nil {
+			return nil, fmt.Errorf("flag %q: %w", key, err)
+		}
+		flag, err := Compile(key, definition)
+		if err != nil {
+			return nil, err
+		}
+		set[key] = flag
+	}
+	return set, rows.Err()
+}
+
+// Put stores a definition after checking it compiles.
+func (s *Store) Put(key string, definition any) error {
+	if _, err := Compile(key, definition); err != nil {
+		return err
+	}
+	raw, err := json.Marshal(definition)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT INTO flags (key, definition, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET definition = excluded.definition, updated_at = excluded.updated_at`,
+		key, string(raw), time.Now().UTC().Format(time.RFC3339))
+	return err
+}
+
+// Delete removes a stored definition; a file definition of the same key applies again.
+func (s *Store) Delete(key string) error {
+	_, err := s.db.Exec(`DELETE FROM flags WHERE key = ?`, key)
+	return err
+}
+
+// Record appends exposures in one transaction.
+func (s *Store) Record(exposures []Exposure) error {
+	if len(exposures) == 0 {
+		return nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, e := range exposures {
+		if _, err := tx.Exec(`INSERT INTO flag_exposures (flag, variant, visitor, path, exposed_at) VALUES (?, ?, ?, ?, ?)`,
+			e.Flag, e.Variant, e.Visitor, e.Path, now); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Summarize counts exposures and distinct visitors per flag and variant; an empty flag means all.
+func (s *Store) Summarize(flag string) ([]Summary, error) {
+	rows, err := s.db.Query(`SELECT flag, variant, COUNT(*), COUNT(DISTINCT visitor) FROM flag_exposures
+		WHERE ? = '' OR flag = ? GROUP BY flag, variant ORDER BY flag, variant`, flag, flag)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Summary
+	for rows.Next() {
+		var sum Summary
+		if err := rows.Scan(&sum.Flag, &sum.Variant, &sum.Exposed, &sum.Visitors); err != nil {
+			return nil, err
+		}
+		out = append(out, sum)
+	}
+	return out, rows.Err()
+}
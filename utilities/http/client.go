@@ -2,6 +2,7 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/kitwork/engine/utilities/trace"
 	"github.com/kitwork/engine/value"
 )
 
@@ -24,6 +26,23 @@ type HTTP struct {
 	cacheTTL     time.Duration
 	persistTTL   time.Duration
 	retry        int // .retry(n): re-attempt transient failures on idempotent reads (see request.go)
+
+	// ctx carries the caller's trace: each request is a client span under it and sends its
+	// traceparent upstream. It never cancels the request — timeouts stay the client's own.
+	ctx context.Context
+}
+
+// WithContext returns a client whose requests join the trace ctx carries.
+func (h *HTTP) WithContext(ctx context.Context) *HTTP {
+	next := h.clone()
+	next.ctx = ctx
+	return next
+}
+
+// BindContext implements value.ContextBinder: an imported client traces each call under the
+// execution that made it.
+func (h *HTTP) BindContext(ctx context.Context) value.Value {
+	return value.New(h.WithContext(ctx))
 }
 
 func (h *HTTP) Timeout(ms int) *HTTP {
@@ -133,6 +152,15 @@ func (h *HTTP) do(method, url string, body value.Value) value.Value {
 		req.Header.Set(k, v)
 	}
 
+	spanCtx, span := trace.Start(h.ctx, "fetch "+method, trace.KindClient)
+	defer span.End()
+	if span.Recording() {
+		span.SetAttribute("http.request.method", method)
+		span.SetAttribute("server.address", req.URL.Host)
+		span.SetAttribute("url.full", req.URL.Scheme+"://"+req.URL.Host+req.URL.Path) // no query: it may carry keys
+	}
+	trace.Inject(spanCtx, req.Header)
+
 	if method == "POST" && req.Header.Get("Content-Type") == "" {
 		req.Header.Set("Content-Type", "application/json")
 	}
@@ -163,12 +191,17 @@ func (h *HTTP) do(method, url string, body value.Value) value.Value {
 
 	resp, err := client.Do(req)
 	if err != nil {
+		span.Fail(err.Error())
 		if snap, ok := staleFallback(key, wantPersist, h.persistStore); ok {
 			return storedResponse(snap, true)
 		}
 		return value.New(Response{Status: 0, Error: err.Error()})
 	}
 	defer resp.Body.Close()
+	span.SetAttribute("http.response.status_code", resp.StatusCode)
+	if resp.StatusCode >= 500 {
+		span.Fail(resp.Status)
+	}
 
 	resBody, _ := io.ReadAll(resp.Body)
 	// Keep the upstream's media type: a cached/proxied copy must replay under the ORIGINAL type.
@@ -52,3 +52,12 @@ var localClient = &stdhttp.Client{
 	Transport: localTransport,
 	Timeout:   10 * time.Second,
 }
+
+// Transport is the transport the client dials through: private and loopback space is refused
+// unless IsLocalAllowed says otherwise. router.proxy forwards requests through it too.
+func Transport() *stdhttp.Transport {
+	if IsLocalAllowed != nil && IsLocalAllowed() {
+		return localTransport
+	}
+	return sharedTransport
+}
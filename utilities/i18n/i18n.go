@@ -0,0 +1,305 @@
+// Package i18n holds message catalogs and the locale rules a multilingual site needs: picking a
+// locale from Accept-Language, choosing a plural form and interpolating {placeholders}.
+//
+// A catalog is a JSON document. Nested objects flatten to dotted keys; an object whose keys are all
+// plural categories (zero, one, two, few, many, other) is one pluralised message:
+//
+//	{
+//	  "nav": { "home": "Home" },
+//	  "greeting": "Hello, {name}!",
+//	  "cart": { "items": { "zero": "Your cart is empty", "one": "{count} item", "other": "{count} items" } }
+//	}
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// message is one catalog entry: plain text, or one text per plural category.
+type message struct {
+	text   string
+	plural map[string]string
+}
+
+// Catalog is one locale's messages, keyed by dotted path.
+type Catalog struct {
+	messages map[string]message
+}
+
+// pluralCategories are the CLDR categories a pluralised message may declare.
+var pluralCategories = map[string]bool{"zero": true, "one": true, "two": true, "few": true, "many": true, "other": true}
+
+// Parse reads a catalog document.
+func Parse(data []byte) (*Catalog, error) {
+	var document map[string]any
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	catalog := &Catalog{messages: map[string]message{}}
+	if err := catalog.flatten("", document); err != nil {
+		return nil, err
+	}
+	return catalog, nil
+}
+
+func (c *Catalog) flatten(prefix string, node map[string]any) error {
+	for key, item := range node {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		switch typed := item.(type) {
+		case string:
+			c.messages[path] = message{text: typed}
+		case map[string]any:
+			if forms, ok := pluralForms(typed); ok {
+				c.messages[path] = message{plural: forms}
+				continue
+			}
+			if err := c.flatten(path, typed); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("message %q must be a string or an object", path)
+		}
+	}
+	return nil
+}
+
+// pluralForms recognises {one, other, …}: every key a plural category, every value a string, and
+// an "other" form to fall back on.
+func pluralForms(node map[string]any) (map[string]string, bool) {
+	if _, ok := node["other"]; !ok {
+		return nil, false
+	}
+	forms := make(map[string]string, len(node))
+	for key, item := range node {
+		text, ok := item.(string)
+		if !ok || !pluralCategories[key] {
+			return nil, false
+		}
+		forms[key] = text
+	}
+	return forms, true
+}
+
+// Len is the number of messages in the catalog.
+func (c *Catalog) Len() int {
+	if c == nil {
+		return 0
+	}
+	return len(c.messages)
+}
+
+// Bundle is a site's catalogs. Lookups fall back to the default locale, then to the key itself, so
+// a missing translation shows up as its key rather than as an empty string.
+type Bundle struct {
+	Default  string
+	catalogs map[string]*Catalog
+}
+
+// NewBundle starts an empty bundle whose fallback locale is def.
+func NewBundle(def string) *Bundle {
+	return &Bundle{Default: def, catalogs: map[string]*Catalog{}}
+}
+
+// Add sets the catalog for a locale.
+func (b *Bundle) Add(locale string, catalog *Catalog) {
+	b.catalogs[locale] = catalog
+}
+
+// Translate renders key in locale. A "count" parameter selects the plural form; every {name} in
+// the message is replaced by the matching parameter, unknown placeholders are left as written.
+func (b *Bundle) Translate(locale, key string, params map[string]any) string {
+	msg, ok := b.lookup(locale, key)
+	if !ok {
+		return key
+	}
+	text := msg.text
+	if msg.plural != nil {
+		text = msg.plural["other"]
+		if count, ok := number(params["count"]); ok {
+			if form, ok := msg.plural["zero"]; ok && count == 0 {
+				text = form
+			} else if form, ok := msg.plural[PluralCategory(locale, count)]; ok {
+				text = form
+			}
+		}
+	}
+	return interpolate(text, params)
+}
+
+func (b *Bundle) lookup(locale, key string) (message, bool) {
+	if b == nil {
+		return message{}, false
+	}
+	for _, candidate := range []string{locale, b.Default} {
+		if catalog := b.catalogs[candidate]; catalog != nil {
+			if msg, ok := catalog.messages[key]; ok {
+				return msg, true
+			}
+		}
+	}
+	return message{}, false
+}
+
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func interpolate(text string, params map[string]any) string {
+	if len(params) == 0 || !strings.Contains(text, "{") {
+		return text
+	}
+	var b strings.Builder
+	for {
+		open := strings.IndexByte(text, '{')
+		if open < 0 {
+			break
+		}
+		end := strings.IndexByte(text[open:], '}')
+		if end < 0 {
+			break
+		}
+		name := strings.TrimSpace(text[open+1 : open+end])
+		b.WriteString(text[:open])
+		if item, ok := params[name]; ok && item != nil {
+			b.WriteString(format(item))
+		} else {
+			b.WriteString(text[open : open+end+1])
+		}
+		text = text[open+end+1:]
+	}
+	b.WriteString(text)
+	return b.String()
+}
+
+func format(v any) string {
+	if n, ok := v.(float64); ok {
+		return strconv.FormatFloat(n, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// PluralCategory is the CLDR plural category of n in locale, for the languages whose rules differ
+// from the English one/other split. Fractions are always "other", except where the language treats
+// 0 ≤ n < 2 as one.
+func PluralCategory(locale string, n float64) string {
+	language := strings.ToLower(locale)
+	if i := strings.IndexAny(language, "-_"); i >= 0 {
+		language = language[:i]
+	}
+	integer := n == float64(int64(n))
+	i := int64(n)
+	if i < 0 {
+		i = -i
+	}
+	mod10, mod100 := i%10, i%100
+	switch language {
+	case "vi", "ja", "zh", "ko", "th", "id", "ms", "lo", "my", "km":
+		return "other"
+	case "fr", "pt", "hi", "bn":
+		if n >= 0 && n < 2 {
+			return "one"
+		}
+	case "ru", "uk", "be":
+		switch {
+		case !integer:
+			return "other"
+		case mod10 == 1 && mod100 != 11:
+			return "one"
+		case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+			return "few"
+		default:
+			return "many"
+		}
+	case "pl":
+		switch {
+		case !integer:
+			return "other"
+		case i == 1:
+			return "one"
+		case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+			return "few"
+		default:
+			return "many"
+		}
+	case "cs", "sk":
+		switch {
+		case !integer:
+			return "many"
+		case i == 1:
+			return "one"
+		case i >= 2 && i <= 4:
+			return "few"
+		}
+	default:
+		if integer && i == 1 {
+			return "one"
+		}
+	}
+	return "other"
+}
+
+// Match picks the supported locale an Accept-Language header prefers, or "" when none fits. An
+// exact tag wins over a language-only match, so "en-GB" chooses "en-GB" when offered and "en"
+// otherwise.
+func Match(header string, supported []string) string {
+	type preference struct {
+		tag string
+		q   float64
+	}
+	var preferences []preference
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" {
+			continue
+		}
+		p := preference{tag: tag, q: 1}
+		for _, param := range fields[1:] {
+			name, val, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(name, "q") {
+				if q, err := strconv.ParseFloat(val, 64); err == nil {
+					p.q = q
+				}
+			}
+		}
+		if p.q > 0 {
+			preferences = append(preferences, p)
+		}
+	}
+	sort.SliceStable(preferences, func(i, j int) bool { return preferences[i].q > preferences[j].q })
+
+	for _, p := range preferences {
+		if p.tag == "*" {
+			continue
+		}
+		language, _, _ := strings.Cut(p.tag, "-")
+		for _, locale := range supported {
+			if strings.EqualFold(locale, p.tag) {
+				return locale
+			}
+		}
+		for _, locale := range supported {
+			base, _, _ := strings.Cut(strings.ToLower(locale), "-")
+			if base == language {
+				return locale
+			}
+		}
+	}
+	return ""
+}
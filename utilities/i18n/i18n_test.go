@@ -0,0 +1,58 @@
+package i18n
+
+import "testing"
+
+func TestTranslatePluralsAndFallback(t *testing.T) {
+	en, err := Parse([]byte(`{"greeting": "Hello, {name}!", "cart": {"items": {"zero": "Empty", "one": "{count} item", "other": "{count} items"}}, "only": "English only"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ru, err := Parse([]byte(`{"cart": {"items": {"one": "{count} товар", "few": "{count} товара", "many": "{count} товаров", "other": "{count} товара"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle := NewBundle("en")
+	bundle.Add("en", en)
+	bundle.Add("ru", ru)
+
+	for _, c := range []struct {
+		locale, key string
+		params      map[string]any
+		want        string
+	}{
+		{"en", "greeting", map[string]any{"name": "Lan"}, "Hello, Lan!"},
+		{"en", "greeting", nil, "Hello, {name}!"},
+		{"en", "cart.items", map[string]any{"count": float64(0)}, "Empty"},
+		{"en", "cart.items", map[string]any{"count": float64(1)}, "1 item"},
+		{"en", "cart.items", map[string]any{"count": 2.5}, "2.5 items"},
+		{"ru", "cart.items", map[string]any{"count": float64(21)}, "21 товар"},
+		{"ru", "cart.items", map[string]any{"count": float64(3)}, "3 товара"},
+		{"ru", "cart.items", map[string]any{"count": float64(11)}, "11 товаров"},
+		{"ru", "only", nil, "English only"},
+		{"ru", "missing.key", nil, "missing.key"},
+	} {
+		if got := bundle.Translate(c.locale, c.key, c.params); got != c.want {
+			t.Errorf("Translate(%s, %s, %v) = %q, want %q", c.locale, c.key, c.params, got, c.want)
+		}
+	}
+
+	if _, err := Parse([]byte(`{"bad": 3}`)); err == nil {
+		t.Fatal("a number message should be rejected")
+	}
+}
+
+func TestMatchAcceptLanguage(t *testing.T) {
+	supported := []string{"vi", "en", "pt-BR"}
+	for header, want := range map[string]string{
+		"":                          "",
+		"en-US,en;q=0.9":            "en",
+		"fr-FR, vi;q=0.5, en;q=0.4": "vi",
+		"pt":                        "pt-BR",
+		"de, *;q=0.1":               "",
+		"en;q=0, vi;q=0.2":          "vi",
+	} {
+		if got := Match(header, supported); got != want {
+			t.Errorf("Match(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+// Package metrics writes the OpenMetrics text exposition format — what Prometheus, and every
+// scraper that speaks its protocol, reads natively. It is a writer, not a registry: the caller owns
+// its counters and renders them on each scrape.
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ContentType is the media type of an OpenMetrics exposition.
+const ContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// Type is a metric family's type.
+type Type string
+
+const (
+	Counter   Type = "counter"
+	Gauge     Type = "gauge"
+	Histogram Type = "histogram"
+)
+
+// Label is one name="value" pair of a sample.
+type Label struct {
+	Name, Value string
+}
+
+// L builds a Label.
+func L(name, value string) Label { return Label{name, value} }
+
+// Writer renders one exposition. Declare each family once, write its samples, and Close to end
+// the document; the first write error is kept and returned by Close.
+type Writer struct {
+	out *bufio.Writer
+	err error
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{out: bufio.NewWriter(w)}
+}
+
+// Family declares a metric family. A counter family is named without its _total suffix, and a
+// name ending in _seconds is declared in that unit.
+func (w *Writer) Family(name string, kind Type, help string) {
+	w.write("# TYPE ", name, " ", string(kind), "\n")
+	if strings.HasSuffix(name, "_seconds") {
+		w.write("# UNIT ", name, " seconds\n")
+	}
+	w.write("# HELP ", name, " ", escape(help), "\n")
+}
+
+// Counter writes one counter sample of a family declared with Family.
+func (w *Writer) Counter(name string, value float64, labels ...Label) {
+	w.sample(name+"_total", value, labels)
+}
+
+// Gauge writes one gauge sample.
+func (w *Writer) Gauge(name string, value float64, labels ...Label) {
+	w.sample(name, value, labels)
+}
+
+// Histogram writes one histogram: counts[i] observations fell at or below bounds[i] and above
+// bounds[i-1] (not cumulative); count is every observation, including those past the last bound.
+func (w *Writer) Histogram(name string, bounds []float64, counts []uint64, count uint64, sum float64, labels ...Label) {
+	var cumulative uint64
+	bucket := make([]Label, len(labels)+1)
+	copy(bucket, labels)
+	for i, bound := range bounds {
+		if i < len(counts) {
+			cumulative += counts[i]
+		}
+		bucket[len(labels)] = Label{"le", formatValue(bound)}
+		w.sample(name+"_bucket", float64(cumulative), bucket)
+	}
+	bucket[len(labels)] = Label{"le", "+Inf"}
+	w.sample(name+"_bucket", float64(count), bucket)
+	w.sample(name+"_count", float64(count), labels)
+	w.sample(name+"_sum", sum, labels)
+}
+
+// Close ends the exposition and flushes it.
+func (w *Writer) Close() error {
+	w.write("# EOF\n")
+	if err := w.out.Flush(); w.err == nil {
+		w.err = err
+	}
+	return w.err
+}
+
+func (w *Writer) sample(name string, value float64, labels []Label) {
+	w.write(name)
+	if len(labels) > 0 {
+		w.write("{")
+		for i, label := range labels {
+			if i > 0 {
+				w.write(",")
+			}
+			w.write(label.Name, `="`, escape(label.Value), `"`)
+		}
+		w.write("}")
+	}
+	w.write(" ", formatValue(value), "\n")
+}
+
+func (w *Writer) write(parts ...string) {
+	if w.err != nil {
+		return
+	}
+	for _, part := range parts {
+		if _, err := w.out.WriteString(part); err != nil {
+			w.err = err
+			return
+		}
+	}
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escape(s string) string { return escaper.Replace(s) }
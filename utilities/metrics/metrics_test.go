@@ -0,0 +1,42 @@
+package metrics_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kitwork/engine/utilities/metrics"
+)
+
+func TestWriterExposition(t *testing.T) {
+	var out strings.Builder
+	w := metrics.NewWriter(&out)
+	w.Family("app_requests", metrics.Counter, "Requests answered.")
+	w.Counter("app_requests", 3, metrics.L("tenant", `a"b\c`))
+	w.Family("app_latency_seconds", metrics.Histogram, "Latency.\nSecond line.")
+	w.Histogram("app_latency_seconds", []float64{0.001, 0.01}, []uint64{2, 1}, 4, 0.25, metrics.L("tenant", "x"))
+	w.Family("app_inflight", metrics.Gauge, "In flight.")
+	w.Gauge("app_inflight", 1.5)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# TYPE app_requests counter
+# HELP app_requests Requests answered.
+app_requests_total{tenant="a\"b\\c"} 3
+# TYPE app_latency_seconds histogram
+# UNIT app_latency_seconds seconds
+# HELP app_latency_seconds Latency.\nSecond line.
+app_latency_seconds_bucket{tenant="x",le="0.001"} 2
+app_latency_seconds_bucket{tenant="x",le="0.01"} 3
+app_latency_seconds_bucket{tenant="x",le="+Inf"} 4
+app_latency_seconds_count{tenant="x"} 4
+app_latency_seconds_sum{tenant="x"} 0.25
+# TYPE app_inflight gauge
+# HELP app_inflight In flight.
+app_inflight 1.5
+# EOF
+`
+	if out.String() != want {
+		t.Fatalf("exposition:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
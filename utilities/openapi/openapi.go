@@ -0,0 +1,178 @@
+// Package openapi renders an OpenAPI 3.1 document from a flat list of operations. The router
+// collects the operations by walking the folder tree (work/openapi.go); this package owns the
+// document shape: path templates, parameter objects, operation ids and stable output.
+//
+// Output is deterministic — paths, methods and map keys come out sorted — so a document emitted
+// by the check command can be diffed in review.
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+const (
+	Version   = "3.1.0"
+	MediaType = "application/json; charset=utf-8"
+)
+
+// Info is the document's info object plus its server URLs.
+type Info struct {
+	Title       string
+	Version     string
+	Description string
+	Servers     []string
+}
+
+// Param is one path or query parameter.
+type Param struct {
+	Name        string
+	In          string // "path" (default) or "query"
+	Type        string // JSON Schema type: "string" (default) or "integer"
+	Pattern     string
+	Description string
+	Required    bool           // implied for path parameters
+	Schema      map[string]any // a full schema; replaces Type and Pattern
+}
+
+// Operation is one method on one path.
+type Operation struct {
+	Path        string // template form: /users/{id}
+	Method      string // GET, POST, …
+	Params      []Param
+	Summary     string
+	Description string
+	Tags        []string
+	Deprecated  bool
+	RequestBody map[string]any // OpenAPI requestBody object; nil when the operation takes none
+	Responses   map[string]any // status → response object; empty means a bare 200
+	Extensions  map[string]any // x-… keys, copied onto the operation
+}
+
+// Document builds the document as plain maps, ready for encoding/json.
+func Document(info Info, operations []Operation) map[string]any {
+	if info.Title == "" {
+		info.Title = "API"
+	}
+	if info.Version == "" {
+		info.Version = "1.0.0"
+	}
+	infoObject := map[string]any{"title": info.Title, "version": info.Version}
+	if info.Description != "" {
+		infoObject["description"] = info.Description
+	}
+	doc := map[string]any{"openapi": Version, "info": infoObject}
+	if len(info.Servers) > 0 {
+		servers := make([]any, len(info.Servers))
+		for i, server := range info.Servers {
+			servers[i] = map[string]any{"url": server}
+		}
+		doc["servers"] = servers
+	}
+
+	paths := map[string]any{}
+	for _, op := range operations {
+		item, _ := paths[op.Path].(map[string]any)
+		if item == nil {
+			item = map[string]any{}
+			paths[op.Path] = item
+		}
+		item[strings.ToLower(op.Method)] = operation(op)
+	}
+	doc["paths"] = paths
+	return doc
+}
+
+func operation(op Operation) map[string]any {
+	out := map[string]any{"operationId": OperationID(op.Method, op.Path)}
+	if op.Summary != "" {
+		out["summary"] = op.Summary
+	}
+	if op.Description != "" {
+		out["description"] = op.Description
+	}
+	if len(op.Tags) > 0 {
+		out["tags"] = op.Tags
+	}
+	if op.Deprecated {
+		out["deprecated"] = true
+	}
+	if len(op.Params) > 0 {
+		params := make([]any, len(op.Params))
+		for i, p := range op.Params {
+			schema := p.Schema
+			if schema == nil {
+				schema = map[string]any{"type": "string"}
+				if p.Type != "" {
+					schema["type"] = p.Type
+				}
+				if p.Pattern != "" {
+					schema["pattern"] = p.Pattern
+				}
+			}
+			in := p.In
+			if in == "" {
+				in = "path"
+			}
+			param := map[string]any{"name": p.Name, "in": in, "required": in == "path" || p.Required, "schema": schema}
+			if p.Description != "" {
+				param["description"] = p.Description
+			}
+			params[i] = param
+		}
+		out["parameters"] = params
+	}
+	if op.RequestBody != nil {
+		out["requestBody"] = op.RequestBody
+	}
+	responses := op.Responses
+	if len(responses) == 0 {
+		responses = map[string]any{"200": map[string]any{"description": "OK"}}
+	}
+	out["responses"] = responses
+	for key, v := range op.Extensions {
+		if strings.HasPrefix(key, "x-") {
+			out[key] = v
+		}
+	}
+	return out
+}
+
+// OperationID derives a stable id from method and path: GET /users/{id}/posts → getUsersIdPosts.
+func OperationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	upper := true
+	for _, r := range path {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			if upper && r >= 'a' && r <= 'z' {
+				r -= 'a' - 'A'
+			}
+			b.WriteRune(r)
+			upper = false
+			continue
+		}
+		upper = true
+	}
+	return b.String()
+}
+
+// JSON encodes the document indented, with a trailing newline.
+func JSON(info Info, operations []Operation) ([]byte, error) {
+	sort.SliceStable(operations, func(i, j int) bool {
+		if operations[i].Path != operations[j].Path {
+			return operations[i].Path < operations[j].Path
+		}
+		return operations[i].Method < operations[j].Method
+	})
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(Document(info, operations)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,55 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestDocumentShapeAndStableOutput(t *testing.T) {
+	operations := func() []Operation {
+		return []Operation{
+			{Path: "/users/{id}", Method: "GET", Params: []Param{{Name: "id", Type: "integer"}},
+				Extensions: map[string]any{"x-kitwork-guards": 1, "ignored": true}},
+			{Path: "/users", Method: "POST", Summary: "Create", Responses: map[string]any{"201": map[string]any{"description": "Created"}}},
+			{Path: "/users", Method: "GET"},
+		}
+	}
+	first, err := JSON(Info{Title: "Shop", Servers: []string{"https://shop.test"}}, operations())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops := operations()
+	ops[0], ops[2] = ops[2], ops[0]
+	second, err := JSON(Info{Title: "Shop", Servers: []string{"https://shop.test"}}, ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first, second) {
+		t.Fatalf("output depends on declaration order:\n%s\n%s", first, second)
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(first, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc["openapi"] != Version || doc["info"].(map[string]any)["version"] != "1.0.0" {
+		t.Fatalf("head = %v %v", doc["openapi"], doc["info"])
+	}
+	paths := doc["paths"].(map[string]any)
+	get := paths["/users/{id}"].(map[string]any)["get"].(map[string]any)
+	if get["operationId"] != "getUsersId" || get["x-kitwork-guards"] != float64(1) || get["ignored"] != nil {
+		t.Fatalf("GET /users/{id} = %v", get)
+	}
+	param := get["parameters"].([]any)[0].(map[string]any)
+	if param["in"] != "path" || param["required"] != true || param["schema"].(map[string]any)["type"] != "integer" {
+		t.Fatalf("parameter = %v", param)
+	}
+	if _, ok := get["responses"].(map[string]any)["200"]; !ok {
+		t.Fatalf("default response missing: %v", get["responses"])
+	}
+	post := paths["/users"].(map[string]any)["post"].(map[string]any)
+	if post["summary"] != "Create" || post["responses"].(map[string]any)["201"] == nil {
+		t.Fatalf("POST /users = %v", post)
+	}
+}
@@ -84,3 +84,26 @@ func (s *Store) Set(key string, r Record, ttl time.Duration) error {
 	f.Close()
 	return os.Rename(tmp, s.path(key))
 }
+
+// Clear removes the records stored directly under Dir and returns how many went. Records kept
+// under a subdirectory ("fetch/<hash>") belong to another cache and stay.
+func (s *Store) Clear() (int, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".gob" {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.Dir, entry.Name())); err != nil && !os.IsNotExist(err) {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"net/url"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -177,6 +178,32 @@ func RSS(data value.Value, requestBase, selfPath string) (string, error) {
 
 type sitemapEntry struct {
 	loc, lastmod, changefreq, priority string
+	alternates                         []alternate
+}
+
+// alternate is one language version of a sitemap URL (hreflang → href).
+type alternate struct {
+	hreflang, href string
+}
+
+// sitemapAlternates reads an entry's { hreflang: url } map, ordered by language with x-default last.
+func sitemapAlternates(v value.Value, requestBase string) []alternate {
+	if !v.IsMap() {
+		return nil
+	}
+	result := make([]alternate, 0, len(v.Map()))
+	for hreflang, href := range v.Map() {
+		if ref := absolute(requestBase, text(href)); ref != "" {
+			result = append(result, alternate{hreflang: hreflang, href: ref})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if (result[i].hreflang == "x-default") != (result[j].hreflang == "x-default") {
+			return result[j].hreflang == "x-default"
+		}
+		return result[i].hreflang < result[j].hreflang
+	})
+	return result
 }
 
 func sitemapEntries(data value.Value, requestBase string) ([]sitemapEntry, error) {
@@ -198,6 +225,7 @@ func sitemapEntries(data value.Value, requestBase string) ([]sitemapEntry, error
 			item.lastmod = text(field(m, "lastmod", "updated", "date"))
 			item.changefreq = text(field(m, "changefreq"))
 			item.priority = text(field(m, "priority"))
+			item.alternates = sitemapAlternates(field(m, "alternates"), requestBase)
 		} else {
 			item.loc = text(entry)
 		}
@@ -217,7 +245,14 @@ func sitemapEntries(data value.Value, requestBase string) ([]sitemapEntry, error
 func renderURLSet(entries []sitemapEntry) (string, error) {
 	var body strings.Builder
 	body.WriteString(`<?xml version="1.0" encoding="UTF-8"?>`)
-	body.WriteString(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+	body.WriteString(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"`)
+	for _, item := range entries {
+		if len(item.alternates) > 0 {
+			body.WriteString(` xmlns:xhtml="http://www.w3.org/1999/xhtml"`)
+			break
+		}
+	}
+	body.WriteString(`>`)
 	for _, item := range entries {
 		body.WriteString(`<url><loc>` + escaped(item.loc) + `</loc>`)
 		if item.lastmod != "" {
@@ -229,6 +264,9 @@ func renderURLSet(entries []sitemapEntry) (string, error) {
 		if item.priority != "" {
 			body.WriteString(`<priority>` + escaped(item.priority) + `</priority>`)
 		}
+		for _, alt := range item.alternates {
+			body.WriteString(`<xhtml:link rel="alternate" hreflang="` + escaped(alt.hreflang) + `" href="` + escaped(alt.href) + `"/>`)
+		}
 		body.WriteString(`</url>`)
 	}
 	body.WriteString(`</urlset>`)
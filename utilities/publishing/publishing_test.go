@@ -85,3 +85,22 @@ func TestSitemapAutomaticallyBuildsIndexAndPages(t *testing.T) {
 		t.Fatal("out-of-range sitemap page must fail")
 	}
 }
+
+func TestSitemapAlternates(t *testing.T) {
+	data := value.New([]any{
+		map[string]any{"loc": "/about", "alternates": map[string]any{"x-default": "/about", "vi": "/about", "en": "/en/about"}},
+		"/plain",
+	})
+	document, err := Sitemap(data, "https://example.test", "/sitemap.xml", "/sitemap.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<url><loc>https://example.test/about</loc>` +
+		`<xhtml:link rel="alternate" hreflang="en" href="https://example.test/en/about"/>` +
+		`<xhtml:link rel="alternate" hreflang="vi" href="https://example.test/about"/>` +
+		`<xhtml:link rel="alternate" hreflang="x-default" href="https://example.test/about"/></url>`
+	if !strings.Contains(document, `xmlns:xhtml="http://www.w3.org/1999/xhtml"`) || !strings.Contains(document, want) ||
+		!strings.Contains(document, `<url><loc>https://example.test/plain</loc></url>`) {
+		t.Fatalf("sitemap = %s", document)
+	}
+}
@@ -28,6 +28,7 @@ type ClaimedJob struct {
 	Payload     string // JSON as dispatched; the runtime decodes it
 	Attempt     int
 	MaxAttempts int
+	Traceparent string // W3C trace context of the dispatch; "" when it was not traced
 }
 
 // QueueRecord is a handler definition — a projection of one _queue/*.kitwork.js file.
@@ -47,8 +48,8 @@ type Store interface {
 	Sync(identity string, queues []QueueRecord) error
 	// Enqueue records one message. Reports whether a row was actually created: a repeat of a
 	// dispatch that carried the same dedupe key is DROPPED, and the caller is told so rather than
-	// left to assume.
-	Enqueue(identity, name, payload, dedupeKey string, maxAttempts int, availableAt time.Time) (int64, bool, error)
+	// left to assume. traceparent rides on the row so the run continues the dispatcher's trace.
+	Enqueue(identity, name, payload, dedupeKey, traceparent string, maxAttempts int, availableAt time.Time) (int64, bool, error)
 	Claim(identity, nodeID string, leaseTTL time.Duration, limit int) []ClaimedJob
 	Complete(jobID int64, output string, gas int64)
 	Fail(jobID int64, attempt int, retry bool, availableAt time.Time, errMsg, output string, gas int64)
@@ -59,6 +60,8 @@ type Store interface {
 	ListQueues(identity string) []map[string]any
 	ListJobs(identity, name, status string, limit int) []map[string]any
 	RetryFailed(identity, name string, jobID int64) int64
+	// Depth counts the messages waiting to run (pending, due or delayed), per queue.
+	Depth(identity string) map[string]int64
 }
 
 // ── SQLite: one node, zero external infrastructure ───────────────────────────────────────────────
@@ -96,7 +99,7 @@ func (s *SqliteStore) InitSchema() error {
 			status TEXT NOT NULL DEFAULT 'pending',
 			attempt INTEGER NOT NULL DEFAULT 0, max_attempts INTEGER NOT NULL DEFAULT 1,
 			available_at TEXT, node TEXT, lease_until TEXT, started_at TEXT, finished_at TEXT,
-			error_message TEXT, output TEXT, gas_used INTEGER,
+			error_message TEXT, output TEXT, gas_used INTEGER, traceparent TEXT,
 			created_at TEXT NOT NULL DEFAULT (datetime('now')))`,
 		`CREATE UNIQUE INDEX IF NOT EXISTS uniq_queue_dedupe ON queue_jobs(identity, name, dedupe_key)`,
 		`CREATE INDEX IF NOT EXISTS idx_queue_claim   ON queue_jobs(identity, status, available_at)`,
@@ -107,6 +110,12 @@ func (s *SqliteStore) InitSchema() error {
 			return fmt.Errorf("sqlite queue schema: %w", err)
 		}
 	}
+	// Tables created before jobs carried a trace gain the column; SQLite has no ADD COLUMN IF NOT
+	// EXISTS, so "duplicate column" is the already-migrated answer.
+	if _, err := s.DB.Exec(`ALTER TABLE queue_jobs ADD COLUMN traceparent TEXT`); err != nil &&
+		!strings.Contains(err.Error(), "duplicate column") {
+		return fmt.Errorf("sqlite queue schema: %w", err)
+	}
 	return nil
 }
 
@@ -152,20 +161,23 @@ func (s *SqliteStore) deleteMissing(identity string, keep []string) {
 		strings.Join(marks, ",")+`)`, args...)
 }
 
-func (s *SqliteStore) Enqueue(identity, name, payload, dedupeKey string, maxAttempts int, availableAt time.Time) (int64, bool, error) {
+func (s *SqliteStore) Enqueue(identity, name, payload, dedupeKey, traceparent string, maxAttempts int, availableAt time.Time) (int64, bool, error) {
 	now := RFC(time.Now())
-	var key any
+	var key, parent any
 	if dedupeKey != "" {
 		key = dedupeKey
 	}
+	if traceparent != "" {
+		parent = traceparent
+	}
 	// max_attempts is PINNED onto the row at dispatch, read from the handler definition — so editing
 	// a handler's .retry() changes the next dispatch, never the messages already waiting. The
 	// caller's value is the fallback for a dispatch that arrives before the definition is synced.
 	res, err := s.DB.Exec(`INSERT OR IGNORE INTO queue_jobs
-		(identity, name, payload, dedupe_key, status, attempt, max_attempts, available_at, created_at)
+		(identity, name, payload, dedupe_key, status, attempt, max_attempts, available_at, traceparent, created_at)
 		VALUES (?,?,?,?, 'pending', 0,
-			COALESCE((SELECT max_attempts FROM queues WHERE identity=? AND name=?), ?), ?, ?)`,
-		identity, name, payload, key, identity, name, maxAttempts, RFC(availableAt), now)
+			COALESCE((SELECT max_attempts FROM queues WHERE identity=? AND name=?), ?), ?, ?, ?)`,
+		identity, name, payload, key, identity, name, maxAttempts, RFC(availableAt), parent, now)
 	if err != nil {
 		return 0, false, err
 	}
@@ -183,23 +195,23 @@ func (s *SqliteStore) Enqueue(identity, name, payload, dedupeKey string, maxAtte
 // the file while rows are open, so an in-flight UPDATE against the same table would deadlock. The
 // UPDATE then re-checks status='pending', so two dispatchers in one process still cannot both win.
 func (s *SqliteStore) Claim(identity, nodeID string, leaseTTL time.Duration, limit int) []ClaimedJob {
-	rows, err := s.DB.Query(`SELECT id, name, payload, attempt, max_attempts
+	rows, err := s.DB.Query(`SELECT id, name, payload, attempt, max_attempts, traceparent
 		FROM queue_jobs WHERE identity=? AND status='pending' AND available_at <= ?
 		ORDER BY available_at, id LIMIT ?`, identity, RFC(time.Now()), limit)
 	if err != nil {
 		return nil
 	}
 	type candidate struct {
-		id                int64
-		name, payload     string
-		attempt, maxTries int
+		id                    int64
+		name, payload, parent string
+		attempt, maxTries     int
 	}
 	var cand []candidate
 	for rows.Next() {
 		var c candidate
-		var payload sql.NullString
-		if rows.Scan(&c.id, &c.name, &payload, &c.attempt, &c.maxTries) == nil {
-			c.payload = payload.String
+		var payload, parent sql.NullString
+		if rows.Scan(&c.id, &c.name, &payload, &c.attempt, &c.maxTries, &parent) == nil {
+			c.payload, c.parent = payload.String, parent.String
 			cand = append(cand, c)
 		}
 	}
@@ -219,7 +231,7 @@ func (s *SqliteStore) Claim(identity, nodeID string, leaseTTL time.Duration, lim
 		if n, _ := res.RowsAffected(); n == 1 {
 			out = append(out, ClaimedJob{
 				ID: c.id, Name: c.name, Payload: c.payload,
-				Attempt: c.attempt + 1, MaxAttempts: c.maxTries,
+				Attempt: c.attempt + 1, MaxAttempts: c.maxTries, Traceparent: c.parent,
 			})
 		}
 	}
@@ -297,6 +309,11 @@ func (s *SqliteStore) ListQueues(identity string) []map[string]any {
 	return scanQueues(rows)
 }
 
+func (s *SqliteStore) Depth(identity string) map[string]int64 {
+	return scanDepth(s.DB.Query(`SELECT name, COUNT(*) FROM queue_jobs
+		WHERE identity=? AND status='pending' GROUP BY name`, identity))
+}
+
 func (s *SqliteStore) ListJobs(identity, name, status string, limit int) []map[string]any {
 	q, args := listJobsQuery("?", identity, name, status, limit)
 	rows, err := s.DB.Query(q, args...)
@@ -368,8 +385,9 @@ func (s *PgStore) InitSchema() error {
 			attempt INTEGER NOT NULL DEFAULT 0, max_attempts INTEGER NOT NULL DEFAULT 1,
 			available_at TIMESTAMPTZ, node TEXT, lease_until TIMESTAMPTZ,
 			started_at TIMESTAMPTZ, finished_at TIMESTAMPTZ,
-			error_message TEXT, output TEXT, gas_used BIGINT,
+			error_message TEXT, output TEXT, gas_used BIGINT, traceparent TEXT,
 			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW())`,
+		`ALTER TABLE queue_jobs ADD COLUMN IF NOT EXISTS traceparent TEXT`,
 		`CREATE UNIQUE INDEX IF NOT EXISTS uniq_queue_dedupe ON queue_jobs(identity, name, dedupe_key)`,
 		`CREATE INDEX IF NOT EXISTS idx_queue_claim ON queue_jobs(identity, status, available_at)`,
 		`CREATE INDEX IF NOT EXISTS idx_queue_lease ON queue_jobs(status, lease_until)`,
@@ -419,20 +437,23 @@ func (s *PgStore) deleteMissing(identity string, keep []string) {
 		strings.Join(marks, ",")+`)`, args...)
 }
 
-func (s *PgStore) Enqueue(identity, name, payload, dedupeKey string, maxAttempts int, availableAt time.Time) (int64, bool, error) {
-	var key any
+func (s *PgStore) Enqueue(identity, name, payload, dedupeKey, traceparent string, maxAttempts int, availableAt time.Time) (int64, bool, error) {
+	var key, parent any
 	if dedupeKey != "" {
 		key = dedupeKey
 	}
+	if traceparent != "" {
+		parent = traceparent
+	}
 	var id int64
 	// See the SQLite twin: max_attempts is pinned from the handler definition at dispatch time, with
 	// the caller's value as the fallback before that definition exists.
 	err := s.DB.QueryRow(`INSERT INTO queue_jobs
-		(identity, name, payload, dedupe_key, status, attempt, max_attempts, available_at, created_at)
+		(identity, name, payload, dedupe_key, status, attempt, max_attempts, available_at, traceparent, created_at)
 		VALUES ($1,$2,$3,$4,'pending',0,
-			COALESCE((SELECT max_attempts FROM queues WHERE identity=$1 AND name=$2), $5), $6, NOW())
+			COALESCE((SELECT max_attempts FROM queues WHERE identity=$1 AND name=$2), $5), $6, $7, NOW())
 		ON CONFLICT (identity, name, dedupe_key) DO NOTHING
-		RETURNING id`, identity, name, payload, key, maxAttempts, availableAt.UTC()).Scan(&id)
+		RETURNING id`, identity, name, payload, key, maxAttempts, availableAt.UTC(), parent).Scan(&id)
 	// DO NOTHING returns no row on a dedupe collision, which arrives here as ErrNoRows. That is the
 	// success path for an idempotent dispatch, not a failure.
 	if err == sql.ErrNoRows {
@@ -459,7 +480,7 @@ func (s *PgStore) Claim(identity, nodeID string, leaseTTL time.Duration, limit i
 			SELECT id FROM queue_jobs
 			WHERE identity=$3 AND status='pending' AND available_at <= NOW()
 			ORDER BY available_at, id FOR UPDATE SKIP LOCKED LIMIT $4)
-		RETURNING id, name, payload, attempt, max_attempts`, nodeID, secs, identity, limit)
+		RETURNING id, name, payload, attempt, max_attempts, traceparent`, nodeID, secs, identity, limit)
 	if err != nil {
 		fmt.Printf("[Queue] pg claim: %v\n", err)
 		return nil
@@ -468,9 +489,9 @@ func (s *PgStore) Claim(identity, nodeID string, leaseTTL time.Duration, limit i
 	var won []ClaimedJob
 	for rows.Next() {
 		var j ClaimedJob
-		var payload sql.NullString
-		if rows.Scan(&j.ID, &j.Name, &payload, &j.Attempt, &j.MaxAttempts) == nil {
-			j.Payload = payload.String
+		var payload, parent sql.NullString
+		if rows.Scan(&j.ID, &j.Name, &payload, &j.Attempt, &j.MaxAttempts, &parent) == nil {
+			j.Payload, j.Traceparent = payload.String, parent.String
 			won = append(won, j)
 		}
 	}
@@ -544,6 +565,11 @@ func (s *PgStore) ListQueues(identity string) []map[string]any {
 	return scanQueues(rows)
 }
 
+func (s *PgStore) Depth(identity string) map[string]int64 {
+	return scanDepth(s.DB.Query(`SELECT name, COUNT(*) FROM queue_jobs
+		WHERE identity=$1 AND status='pending' GROUP BY name`, identity))
+}
+
 func (s *PgStore) ListJobs(identity, name, status string, limit int) []map[string]any {
 	q, args := listJobsQuery("$", identity, name, status, limit)
 	rows, err := s.DB.Query(q, args...)
@@ -649,6 +675,22 @@ func scanQueues(rows *sql.Rows) []map[string]any {
 	return out
 }
 
+func scanDepth(rows *sql.Rows, err error) map[string]int64 {
+	out := map[string]int64{}
+	if err != nil {
+		return out
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		var count int64
+		if rows.Scan(&name, &count) == nil {
+			out[name] = count
+		}
+	}
+	return out
+}
+
 func scanJobs(rows *sql.Rows) []map[string]any {
 	var out []map[string]any
 	for rows.Next() {
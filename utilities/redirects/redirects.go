@@ -0,0 +1,339 @@
+// Package redirects compiles a site's _redirects file: one rule per line, in the Netlify format.
+//
+//	# from                  to                     status
+//	/blog/:slug             /posts/:slug           301
+//	/news/*                 /blog/:splat           302
+//	/store  id=:id          /products/:id          308
+//	/app/*                  /index.html            200     # a rewrite: served in place
+//	/old-docs/*             https://docs.example.com/:splat  301!
+//
+// :name matches one path segment, a trailing * the rest of the path (:splat in the target), and
+// key=value pairs after the path match the query string (a :name value captures it). The status
+// defaults to 301; 200 rewrites the request to a local path instead of redirecting. Rules are
+// tried in order and the first match wins. A rule does not apply where a static file exists,
+// unless its status is forced with "!".
+package redirects
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Rule is one compiled line.
+type Rule struct {
+	Line   int
+	From   string
+	To     string
+	Status int
+	Force  bool
+	Query  map[string]string // query parameter → literal value, or ":name" to capture it
+
+	segments []string // From split on "/", without the trailing "*"
+	splat    bool
+}
+
+// Rewrite reports whether the rule serves its target in place instead of redirecting.
+func (r *Rule) Rewrite() bool { return r.Status == 200 }
+
+// Table is a compiled _redirects file.
+type Table struct {
+	Rules []*Rule
+}
+
+// statuses are the codes a rule may answer with.
+var statuses = map[int]bool{200: true, 301: true, 302: true, 303: true, 307: true, 308: true}
+
+// Parse compiles a _redirects document. Errors name the line.
+func Parse(data []byte) (*Table, error) {
+	table := &Table{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if i := strings.Index(text, "#"); i >= 0 {
+			text = text[:i]
+		}
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		rule, err := compile(line, fields)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		table.Rules = append(table.Rules, rule)
+	}
+	return table, scanner.Err()
+}
+
+func compile(line int, fields []string) (*Rule, error) {
+	rule := &Rule{Line: line, Status: 301, From: fields[0], Query: map[string]string{}}
+	if !strings.HasPrefix(rule.From, "/") {
+		return nil, fmt.Errorf("%q: the source must be a path", rule.From)
+	}
+	rest := fields[1:]
+	for len(rest) > 0 && strings.Contains(rest[0], "=") && !strings.HasPrefix(rest[0], "/") && !strings.Contains(rest[0], "://") {
+		key, val, _ := strings.Cut(rest[0], "=")
+		if key == "" {
+			return nil, fmt.Errorf("%q: a query condition needs a name", rest[0])
+		}
+		rule.Query[key] = val
+		rest = rest[1:]
+	}
+	if len(rest) == 0 {
+		return nil, fmt.Errorf("%q has no target", rule.From)
+	}
+	rule.To, rest = rest[0], rest[1:]
+	if len(rest) > 0 {
+		code := rest[0]
+		rule.Force = strings.HasSuffix(code, "!")
+		status, err := strconv.Atoi(strings.TrimSuffix(code, "!"))
+		if err != nil || !statuses[status] {
+			return nil, fmt.Errorf("%q is not a supported status (200, 301, 302, 303, 307, 308)", code)
+		}
+		rule.Status, rest = status, rest[1:]
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("unexpected %q", strings.Join(rest, " "))
+	}
+
+	from := normalize(rule.From)
+	if strings.HasSuffix(from, "/*") || from == "/*" {
+		rule.splat = true
+		from = strings.TrimSuffix(from, "*")
+	} else if strings.Contains(from, "*") {
+		return nil, fmt.Errorf("%q: * may only end the source", rule.From)
+	}
+	rule.segments = strings.Split(strings.Trim(from, "/"), "/")
+	if rule.segments[0] == "" {
+		rule.segments = nil
+	}
+
+	if rule.Rewrite() && !strings.HasPrefix(rule.To, "/") {
+		return nil, fmt.Errorf("%q: a 200 rewrite must target a local path", rule.To)
+	}
+	bound := rule.names()
+	for _, name := range placeholders(rule.To) {
+		if !bound[name] {
+			return nil, fmt.Errorf("%q uses :%s, which the source does not capture", rule.To, name)
+		}
+	}
+	return rule, nil
+}
+
+// names are the placeholders a rule captures.
+func (r *Rule) names() map[string]bool {
+	names := map[string]bool{}
+	for _, seg := range r.segments {
+		if strings.HasPrefix(seg, ":") {
+			names[seg[1:]] = true
+		}
+	}
+	for _, val := range r.Query {
+		if strings.HasPrefix(val, ":") {
+			names[val[1:]] = true
+		}
+	}
+	if r.splat {
+		names["splat"] = true
+	}
+	return names
+}
+
+// placeholders lists the :names a target refers to.
+func placeholders(target string) []string {
+	var names []string
+	for i := 0; i < len(target); i++ {
+		if target[i] != ':' || (i > 0 && target[i-1] != '/' && target[i-1] != '=' && target[i-1] != '-' && target[i-1] != '.') {
+			continue
+		}
+		j := i + 1
+		for j < len(target) && isNameByte(target[j]) {
+			j++
+		}
+		if j > i+1 {
+			names = append(names, target[i+1:j])
+		}
+		i = j - 1
+	}
+	return names
+}
+
+func isNameByte(c byte) bool {
+	return c == '_' || (c >= '0' && c <= '9') || (c|0x20 >= 'a' && c|0x20 <= 'z')
+}
+
+// normalize drops a trailing slash, so /about/ and /about are one path.
+func normalize(p string) string {
+	if len(p) > 1 {
+		p = strings.TrimSuffix(p, "/")
+	}
+	return p
+}
+
+// Match finds the first rule for a request and returns it with its target filled in. The request's
+// query string carries over to a target that has none. The path is cleaned first and captured
+// values are escaped into the target, so a request cannot turn a local target into another host
+// ("//evil.com") — a filled local target that still names one does not match.
+func (t *Table) Match(requestPath string, query url.Values) (*Rule, string, bool) {
+	if t == nil {
+		return nil, "", false
+	}
+	segments := strings.Split(strings.Trim(normalize(path.Clean("/"+requestPath)), "/"), "/")
+	if segments[0] == "" {
+		segments = nil
+	}
+	for _, rule := range t.Rules {
+		captured, ok := rule.match(segments, query)
+		if !ok {
+			continue
+		}
+		target := fill(rule.To, captured)
+		if strings.HasPrefix(rule.To, "/") && !strings.HasPrefix(rule.To, "//") && (strings.HasPrefix(target, "//") || strings.HasPrefix(target, "/\\")) {
+			continue
+		}
+		if !strings.Contains(target, "?") && len(query) > 0 {
+			target += "?" + query.Encode()
+		}
+		return rule, target, true
+	}
+	return nil, "", false
+}
+
+func (r *Rule) match(segments []string, query url.Values) (map[string]string, bool) {
+	if len(segments) < len(r.segments) || (!r.splat && len(segments) != len(r.segments)) {
+		return nil, false
+	}
+	captured := map[string]string{}
+	for i, seg := range r.segments {
+		if strings.HasPrefix(seg, ":") {
+			captured[seg[1:]] = segments[i]
+		} else if seg != segments[i] {
+			return nil, false
+		}
+	}
+	for key, want := range r.Query {
+		have, present := query[key]
+		if !present {
+			return nil, false
+		}
+		value := ""
+		if len(have) > 0 {
+			value = have[0]
+		}
+		if strings.HasPrefix(want, ":") {
+			captured[want[1:]] = value
+		} else if want != value {
+			return nil, false
+		}
+	}
+	if r.splat {
+		captured["splat"] = strings.Join(segments[len(r.segments):], "/")
+	}
+	return captured, true
+}
+
+// fill puts the captured values into target, escaped for where they land: path segments (a splat
+// keeps its slashes) or, after the "?", query values.
+func fill(target string, captured map[string]string) string {
+	if !strings.Contains(target, ":") {
+		return target
+	}
+	query := strings.IndexByte(target, '?')
+	var b strings.Builder
+	for i := 0; i < len(target); i++ {
+		if target[i] == ':' && i+1 < len(target) && isNameByte(target[i+1]) {
+			j := i + 1
+			for j < len(target) && isNameByte(target[j]) {
+				j++
+			}
+			if val, ok := captured[target[i+1:j]]; ok {
+				if query >= 0 && i > query {
+					b.WriteString(url.QueryEscape(val))
+				} else {
+					parts := strings.Split(val, "/")
+					for k, part := range parts {
+						parts[k] = url.PathEscape(part)
+					}
+					b.WriteString(strings.Join(parts, "/"))
+				}
+				i = j - 1
+				continue
+			}
+		}
+		b.WriteByte(target[i])
+	}
+	return b.String()
+}
+
+// Problem is a rule that can never take effect as written.
+type Problem struct {
+	Line    int
+	Message string
+}
+
+func (p Problem) Error() string { return fmt.Sprintf("line %d: %s", p.Line, p.Message) }
+
+// Lint reports rules that conflict with or are shadowed by an earlier rule, and redirects that
+// point back at the path they match.
+func (t *Table) Lint() []Problem {
+	if t == nil {
+		return nil
+	}
+	var problems []Problem
+	for i, rule := range t.Rules {
+		if !rule.Rewrite() && !strings.Contains(rule.From, ":") && !rule.splat && len(rule.Query) == 0 &&
+			normalize(strings.SplitN(rule.To, "?", 2)[0]) == normalize(rule.From) {
+			problems = append(problems, Problem{rule.Line, fmt.Sprintf("%s redirects to itself", rule.From)})
+			continue
+		}
+		for _, earlier := range t.Rules[:i] {
+			if !earlier.covers(rule) {
+				continue
+			}
+			if earlier.same(rule) && (earlier.To != rule.To || earlier.Status != rule.Status) {
+				problems = append(problems, Problem{rule.Line, fmt.Sprintf("conflicts with line %d, which matches the same requests and wins", earlier.Line)})
+			} else {
+				problems = append(problems, Problem{rule.Line, fmt.Sprintf("unreachable: line %d already matches every request it does", earlier.Line)})
+			}
+			break
+		}
+	}
+	return problems
+}
+
+// covers reports whether every request other matches also matches r.
+func (r *Rule) covers(other *Rule) bool {
+	if r.Force != other.Force && !r.Force {
+		return false // a forced later rule still applies where a file shadows the earlier one
+	}
+	if len(other.segments) < len(r.segments) || (!r.splat && (other.splat || len(other.segments) != len(r.segments))) {
+		return false
+	}
+	for i, seg := range r.segments {
+		if strings.HasPrefix(seg, ":") {
+			continue
+		}
+		if seg != other.segments[i] {
+			return false
+		}
+	}
+	for key, want := range r.Query {
+		have, ok := other.Query[key]
+		if !ok || (!strings.HasPrefix(want, ":") && want != have) {
+			return false
+		}
+	}
+	return true
+}
+
+// same reports whether two rules match exactly the same requests.
+func (r *Rule) same(other *Rule) bool {
+	return r.covers(other) && other.covers(r)
+}
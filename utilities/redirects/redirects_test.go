@@ -0,0 +1,127 @@
+package redirects
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestMatchRules(t *testing.T) {
+	table, err := Parse([]byte(`
+# comments and blank lines are ignored
+/blog/:slug        /posts/:slug               301
+/news/*            /blog/:splat               302
+/store  id=:id     /products/:id?ref=store    308
+/app/*             /index.html                200
+/docs/*            https://docs.example.com/:splat  301!
+/                  /home                      307
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		path, query string
+		want        string
+		status      int
+	}{
+		{"/blog/hello", "", "/posts/hello", 301},
+		{"/blog/hello/", "", "/posts/hello", 301},
+		{"/blog/hello", "utm=x", "/posts/hello?utm=x", 301},
+		{"/news/2024/05/launch", "", "/blog/2024/05/launch", 302},
+		{"/news", "", "/blog/", 302},
+		{"/store", "id=42", "/products/42?ref=store", 308},
+		{"/app/settings/profile", "", "/index.html", 200},
+		{"/docs/guide/intro", "", "https://docs.example.com/guide/intro", 301},
+		{"/", "", "/home", 307},
+	} {
+		query, _ := url.ParseQuery(c.query)
+		rule, target, ok := table.Match(c.path, query)
+		if !ok || target != c.want || rule.Status != c.status {
+			t.Errorf("Match(%s?%s) = %q %v, want %q %d", c.path, c.query, target, rule, c.want, c.status)
+		}
+	}
+	for _, path := range []string{"/blog", "/blog/a/b", "/store", "/about"} {
+		if _, target, ok := table.Match(path, nil); ok {
+			t.Errorf("Match(%s) = %q, want no match", path, target)
+		}
+	}
+	if rule, _, _ := table.Match("/docs/x", nil); !rule.Force {
+		t.Error("301! should be forced")
+	}
+}
+
+// Captured values cannot make a local target point at another host: the path is cleaned before
+// matching and every value is escaped where it lands.
+func TestMatchEscapesCapturedValues(t *testing.T) {
+	table, err := Parse([]byte(`
+/go/*          /:splat            301
+/find  q=:q    /search?q=:q       302
+/u/:name       /users/:name       301
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct{ path, query, want string }{
+		{"/go//evil.com", "", "/evil.com"},
+		{"/go/\\evil.com", "", "/%5Cevil.com"},
+		{"/go/a/b", "", "/a/b"},
+		{"/find", "q=a%26admin%3D1", "/search?q=a%26admin%3D1"},
+		{"/u/a?b", "", "/users/a%3Fb"},
+	} {
+		query, _ := url.ParseQuery(c.query)
+		if _, target, ok := table.Match(c.path, query); !ok || target != c.want {
+			t.Errorf("Match(%s?%s) = %q %v, want %q", c.path, c.query, target, ok, c.want)
+		}
+	}
+	for _, path := range []string{"/go//evil.com", "/go///evil.com", "/go/%2F%2Fevil.com"} {
+		if _, target, ok := table.Match(path, nil); ok && (strings.HasPrefix(target, "//") || strings.HasPrefix(target, "/\\")) {
+			t.Errorf("Match(%s) = %q: an open redirect", path, target)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for source, want := range map[string]string{
+		"blog /posts":              "must be a path",
+		"/blog":                    "no target",
+		"/blog /posts 404":         "not a supported status",
+		"/blog/:slug /posts/:id":   "does not capture",
+		"/a/*/b /c":                "may only end",
+		"/app/* https://x.com 200": "local path",
+		"/a /b 301 extra":          "unexpected",
+	} {
+		_, err := Parse([]byte("\n" + source))
+		if err == nil || !strings.Contains(err.Error(), want) || !strings.HasPrefix(err.Error(), "line 2:") {
+			t.Errorf("Parse(%q) = %v, want %q", source, err, want)
+		}
+	}
+}
+
+func TestLint(t *testing.T) {
+	table, err := Parse([]byte(`/blog/* /posts/:splat 301
+/blog/:slug /articles/:slug 301
+/a /b 301
+/a /c 302
+/loop /loop/ 301
+/shop id=:id /p/:id 301
+/shop id=7 /p/seven 301
+/files/* /f/:splat 301
+/files/x /override 301!
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, p := range table.Lint() {
+		got = append(got, p.Error())
+	}
+	want := []string{
+		"line 2: unreachable: line 1 already matches every request it does",
+		"line 4: conflicts with line 3, which matches the same requests and wins",
+		"line 5: /loop redirects to itself",
+		"line 7: unreachable: line 6 already matches every request it does",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("Lint() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,528 @@
+// Package schema validates decoded JSON-like data (map[string]any, []any, string, float64, bool,
+// nil) against a JSON-Schema-compatible object and returns the typed value alongside every
+// problem found, each addressed by a JSON Pointer.
+//
+// The supported vocabulary is the part request validation needs: type (one or a list), required,
+// properties, additionalProperties: false, items, enum, const, default, minimum/maximum and their
+// exclusive forms, minLength/maxLength, minItems/maxItems, uniqueItems, pattern and format
+// (email, phone, uuid, date, date-time, time, uri/url, ipv4, ipv6). min/max are accepted as
+// shorthands that mean length for strings, count for arrays and value for numbers, and a property
+// written as a bare type name ({ age: "integer" }) means { type: "integer" }. Unknown keywords and
+// formats are ignored, as the specification does for annotations.
+//
+// With coerce set, string inputs — query strings and form fields — are converted to the declared
+// type first: "42" to 42, "true" to true, and a single value to a one-element array.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Issue is one failed rule.
+type Issue struct {
+	Path    string `json:"path"` // JSON Pointer into the input: "/address/city", "/items/0"; "" is the root
+	Message string `json:"message"`
+}
+
+// Schema is a compiled schema. It is immutable and safe for concurrent use.
+type Schema struct {
+	root       *node
+	definition map[string]any
+}
+
+type node struct {
+	types      []string
+	properties map[string]*node
+	names      []string // property names, sorted, so issues come out in a stable order
+	required   []string
+	closed     bool // additionalProperties: false
+	items      *node
+
+	enum       []any
+	constant   any
+	hasConst   bool
+	def        any
+	hasDefault bool
+
+	minimum, maximum                   *float64
+	exclusiveMinimum, exclusiveMaximum *float64
+	minLength, maxLength               *int
+	minItems, maxItems                 *int
+	unique                             bool
+
+	pattern    *regexp.Regexp
+	patternErr error // a pattern that does not compile fails every value (fail-closed)
+	format     string
+}
+
+// Compile reads a schema definition. It never fails: a rule that cannot be read either is ignored
+// (an unknown keyword) or rejects every value (a bad pattern), so a typo never lets data through.
+func Compile(definition map[string]any) *Schema {
+	return &Schema{root: compileNode(definition), definition: definition}
+}
+
+// Definition is the object the schema was compiled from, for documentation (OpenAPI).
+func (s *Schema) Definition() map[string]any { return s.definition }
+
+// Properties lists the top-level property names, sorted.
+func (s *Schema) Properties() []string { return s.root.names }
+
+func compileNode(v any) *node {
+	n := &node{}
+	switch def := v.(type) {
+	case string:
+		n.types = []string{def}
+		return n
+	case map[string]any:
+		n.read(def)
+	}
+	return n
+}
+
+func (n *node) read(def map[string]any) {
+	switch t := def["type"].(type) {
+	case string:
+		n.types = []string{t}
+	case []any:
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				n.types = append(n.types, s)
+			}
+		}
+	}
+	if properties, ok := def["properties"].(map[string]any); ok {
+		n.properties = make(map[string]*node, len(properties))
+		for name, property := range properties {
+			n.properties[name] = compileNode(property)
+			n.names = append(n.names, name)
+		}
+		sort.Strings(n.names)
+	}
+	if required, ok := def["required"].([]any); ok {
+		for _, name := range required {
+			if s, ok := name.(string); ok {
+				n.required = append(n.required, s)
+			}
+		}
+	}
+	if additional, ok := def["additionalProperties"].(bool); ok && !additional {
+		n.closed = true
+	}
+	if items, ok := def["items"]; ok {
+		n.items = compileNode(items)
+	}
+	if len(n.types) == 0 {
+		switch {
+		case n.properties != nil:
+			n.types = []string{"object"}
+		case n.items != nil:
+			n.types = []string{"array"}
+		}
+	}
+
+	if enum, ok := def["enum"].([]any); ok {
+		n.enum = enum
+	}
+	n.constant, n.hasConst = def["const"]
+	n.def, n.hasDefault = def["default"]
+
+	n.minimum = number(def["minimum"])
+	n.maximum = number(def["maximum"])
+	n.exclusiveMinimum = number(def["exclusiveMinimum"])
+	n.exclusiveMaximum = number(def["exclusiveMaximum"])
+	n.minLength = count(def["minLength"])
+	n.maxLength = count(def["maxLength"])
+	n.minItems = count(def["minItems"])
+	n.maxItems = count(def["maxItems"])
+	n.unique, _ = def["uniqueItems"].(bool)
+	if _, ok := def["min"]; ok {
+		switch {
+		case n.is("string"):
+			n.minLength = count(def["min"])
+		case n.is("array"):
+			n.minItems = count(def["min"])
+		default:
+			n.minimum = number(def["min"])
+		}
+	}
+	if _, ok := def["max"]; ok {
+		switch {
+		case n.is("string"):
+			n.maxLength = count(def["max"])
+		case n.is("array"):
+			n.maxItems = count(def["max"])
+		default:
+			n.maximum = number(def["max"])
+		}
+	}
+
+	if pattern, ok := def["pattern"].(string); ok {
+		n.pattern, n.patternErr = regexp.Compile(pattern)
+	}
+	n.format, _ = def["format"].(string)
+}
+
+func (n *node) is(kind string) bool {
+	for _, t := range n.types {
+		if t == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks data and returns it typed: coerced when coerce is set, defaults filled
+// in, arrays and objects copied. The value is meaningful only when no issues are returned.
+func (s *Schema) Validate(data any, coerce bool) (any, []Issue) {
+	v := &validator{coerce: coerce}
+	out := v.check(s.root, data, "")
+	return out, v.issues
+}
+
+type validator struct {
+	coerce bool
+	issues []Issue
+}
+
+func (v *validator) fail(path, format string, args ...any) {
+	v.issues = append(v.issues, Issue{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) check(n *node, data any, path string) any {
+	if v.coerce {
+		data = coerce(n, data)
+	}
+	if len(n.types) > 0 && !matchesType(n.types, data) {
+		v.fail(path, "must be %s", typeNames(n.types))
+		return data
+	}
+
+	if n.hasConst && !equal(data, n.constant) {
+		v.fail(path, "must be %s", literal(n.constant))
+		return data
+	}
+	if n.enum != nil {
+		found := false
+		for _, option := range n.enum {
+			if equal(data, option) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			options := make([]string, len(n.enum))
+			for i, option := range n.enum {
+				options[i] = literal(option)
+			}
+			v.fail(path, "must be one of %s", strings.Join(options, ", "))
+			return data
+		}
+	}
+
+	switch value := data.(type) {
+	case map[string]any:
+		return v.object(n, value, path)
+	case []any:
+		return v.array(n, value, path)
+	case string:
+		v.text(n, value, path)
+	case float64:
+		v.number(n, value, path)
+	}
+	return data
+}
+
+func (v *validator) object(n *node, data map[string]any, path string) any {
+	out := make(map[string]any, len(data))
+	var unknown []string
+	for key, item := range data {
+		if _, declared := n.properties[key]; !declared {
+			if n.closed {
+				unknown = append(unknown, key)
+				continue
+			}
+			out[key] = item
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		v.fail(path+"/"+escape(key), "is not allowed")
+	}
+	for _, name := range n.required {
+		if item, ok := data[name]; !ok || item == nil {
+			if property := n.properties[name]; property == nil || !property.hasDefault {
+				v.fail(path+"/"+escape(name), "is required")
+			}
+		}
+	}
+	for _, name := range n.names {
+		property := n.properties[name]
+		item, ok := data[name]
+		if !ok || item == nil {
+			if property.hasDefault {
+				out[name] = property.def
+			} else if ok {
+				out[name] = nil
+			}
+			continue
+		}
+		out[name] = v.check(property, item, path+"/"+escape(name))
+	}
+	return out
+}
+
+func (v *validator) array(n *node, data []any, path string) any {
+	if n.minItems != nil && len(data) < *n.minItems {
+		v.fail(path, "must have at least %d %s", *n.minItems, plural(*n.minItems, "item"))
+	}
+	if n.maxItems != nil && len(data) > *n.maxItems {
+		v.fail(path, "must have at most %d %s", *n.maxItems, plural(*n.maxItems, "item"))
+	}
+	out := make([]any, len(data))
+	for i, item := range data {
+		if n.items != nil {
+			item = v.check(n.items, item, path+"/"+strconv.Itoa(i))
+		}
+		out[i] = item
+	}
+	if n.unique {
+		for i := range out {
+			for j := i + 1; j < len(out); j++ {
+				if equal(out[i], out[j]) {
+					v.fail(path+"/"+strconv.Itoa(j), "duplicates item %d", i)
+				}
+			}
+		}
+	}
+	return out
+}
+
+func (v *validator) text(n *node, s string, path string) {
+	length := len([]rune(s))
+	if n.minLength != nil && length < *n.minLength {
+		v.fail(path, "must be at least %d %s", *n.minLength, plural(*n.minLength, "character"))
+	}
+	if n.maxLength != nil && length > *n.maxLength {
+		v.fail(path, "must be at most %d %s", *n.maxLength, plural(*n.maxLength, "character"))
+	}
+	if n.patternErr != nil {
+		v.fail(path, "cannot be checked: invalid pattern")
+	} else if n.pattern != nil && !n.pattern.MatchString(s) {
+		v.fail(path, "must match %s", n.pattern.String())
+	}
+	if n.format != "" {
+		if check, known := formats[n.format]; known && !check(s) {
+			v.fail(path, "must be a valid %s", n.format)
+		}
+	}
+}
+
+func (v *validator) number(n *node, f float64, path string) {
+	if n.minimum != nil && f < *n.minimum {
+		v.fail(path, "must be at least %s", formatNumber(*n.minimum))
+	}
+	if n.maximum != nil && f > *n.maximum {
+		v.fail(path, "must be at most %s", formatNumber(*n.maximum))
+	}
+	if n.exclusiveMinimum != nil && f <= *n.exclusiveMinimum {
+		v.fail(path, "must be greater than %s", formatNumber(*n.exclusiveMinimum))
+	}
+	if n.exclusiveMaximum != nil && f >= *n.exclusiveMaximum {
+		v.fail(path, "must be less than %s", formatNumber(*n.exclusiveMaximum))
+	}
+}
+
+// coerce converts a string input toward the declared type. Anything it cannot convert is
+// returned unchanged so the type check reports it.
+func coerce(n *node, data any) any {
+	if list, ok := data.([]any); ok && !n.is("array") && len(list) == 1 {
+		data = list[0] // ?page=2 decoded as a one-element list
+	}
+	s, ok := data.(string)
+	if !ok || n.is("string") {
+		return data
+	}
+	for _, kind := range n.types {
+		switch kind {
+		case "integer", "number":
+			if f, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+				return f
+			}
+		case "boolean":
+			switch strings.ToLower(strings.TrimSpace(s)) {
+			case "true", "1", "on", "yes":
+				return true
+			case "false", "0", "off", "no":
+				return false
+			}
+		case "null":
+			if s == "" {
+				return nil
+			}
+		case "array":
+			if n.items != nil {
+				return []any{coerce(n.items, s)}
+			}
+			return []any{s}
+		}
+	}
+	return data
+}
+
+func matchesType(types []string, data any) bool {
+	for _, kind := range types {
+		switch kind {
+		case "string":
+			if _, ok := data.(string); ok {
+				return true
+			}
+		case "number":
+			if _, ok := data.(float64); ok {
+				return true
+			}
+		case "integer":
+			if f, ok := data.(float64); ok && f == math.Trunc(f) {
+				return true
+			}
+		case "boolean":
+			if _, ok := data.(bool); ok {
+				return true
+			}
+		case "object":
+			if _, ok := data.(map[string]any); ok {
+				return true
+			}
+		case "array":
+			if _, ok := data.([]any); ok {
+				return true
+			}
+		case "null":
+			if data == nil {
+				return true
+			}
+		default:
+			return true // unknown type names do not constrain
+		}
+	}
+	return false
+}
+
+var (
+	emailPattern = regexp.MustCompile(`^[^\s@]+@[^\s@]+\.[^\s@]+$`)
+	phonePattern = regexp.MustCompile(`^\+?[0-9]{8,15}$`)
+	uuidPattern  = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	phoneFiller  = strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "")
+)
+
+var formats = map[string]func(string) bool{
+	"email": func(s string) bool { return len(s) <= 254 && emailPattern.MatchString(s) },
+	"phone": func(s string) bool { return phonePattern.MatchString(phoneFiller.Replace(s)) },
+	"uuid":  uuidPattern.MatchString,
+	"date": func(s string) bool {
+		_, err := time.Parse(time.DateOnly, s)
+		return err == nil
+	},
+	"date-time": func(s string) bool {
+		_, err := time.Parse(time.RFC3339, s)
+		return err == nil
+	},
+	"time": func(s string) bool {
+		for _, layout := range []string{"15:04:05", "15:04"} {
+			if _, err := time.Parse(layout, s); err == nil {
+				return true
+			}
+		}
+		return false
+	},
+	"uri": validURL,
+	"url": validURL,
+	"ipv4": func(s string) bool {
+		ip := net.ParseIP(s)
+		return ip != nil && ip.To4() != nil && strings.Contains(s, ".")
+	},
+	"ipv6": func(s string) bool {
+		return net.ParseIP(s) != nil && strings.Contains(s, ":")
+	},
+}
+
+func validURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+func number(v any) *float64 {
+	if f, ok := v.(float64); ok {
+		return &f
+	}
+	if i, ok := v.(int); ok {
+		f := float64(i)
+		return &f
+	}
+	return nil
+}
+
+func count(v any) *int {
+	if f := number(v); f != nil && *f >= 0 {
+		i := int(*f)
+		return &i
+	}
+	return nil
+}
+
+func equal(a, b any) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	x, errA := json.Marshal(a)
+	y, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(x) == string(y)
+}
+
+func literal(v any) string {
+	if s, ok := v.(string); ok {
+		return strconv.Quote(s)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+func typeNames(types []string) string {
+	names := make([]string, len(types))
+	for i, kind := range types {
+		switch kind {
+		case "integer", "array", "object":
+			names[i] = "an " + kind
+		case "null":
+			names[i] = "null"
+		default:
+			names[i] = "a " + kind
+		}
+	}
+	return strings.Join(names, " or ")
+}
+
+func formatNumber(f float64) string { return strconv.FormatFloat(f, 'f', -1, 64) }
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
+
+// escape encodes one JSON Pointer reference token (RFC 6901).
+func escape(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,79 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFormatsAndNestedPaths(t *testing.T) {
+	s := Compile(map[string]any{
+		"type":     "object",
+		"required": []any{"id"},
+		"properties": map[string]any{
+			"id":    map[string]any{"type": "string", "format": "uuid"},
+			"phone": map[string]any{"type": "string", "format": "phone"},
+			"items": map[string]any{
+				"type":     "array",
+				"minItems": float64(1),
+				"items": map[string]any{
+					"properties": map[string]any{"sku": map[string]any{"type": "string", "pattern": "^[A-Z]{3}$"}, "qty": "integer"},
+					"required":   []any{"sku"},
+				},
+			},
+		},
+	})
+
+	_, issues := s.Validate(map[string]any{
+		"id":    "123e4567-e89b-12d3-a456-426614174000",
+		"phone": "+84 (90) 123-4567",
+		"items": []any{map[string]any{"sku": "ABC", "qty": float64(2)}},
+	}, false)
+	if len(issues) != 0 {
+		t.Fatalf("valid input: %v", issues)
+	}
+
+	_, issues = s.Validate(map[string]any{
+		"id":    "not-a-uuid",
+		"phone": "12",
+		"items": []any{map[string]any{"sku": "abc", "qty": 1.5}, map[string]any{}},
+	}, false)
+	want := []Issue{
+		{"/id", "must be a valid uuid"},
+		{"/items/0/qty", "must be an integer"},
+		{"/items/0/sku", "must match ^[A-Z]{3}$"},
+		{"/items/1/sku", "is required"},
+		{"/phone", "must be a valid phone"},
+	}
+	if !reflect.DeepEqual(issues, want) {
+		t.Fatalf("issues = %v\nwant     %v", issues, want)
+	}
+}
+
+func TestCoercionOnlyWhenAsked(t *testing.T) {
+	s := Compile(map[string]any{"properties": map[string]any{
+		"n":    map[string]any{"type": "number", "max": float64(10)},
+		"ok":   "boolean",
+		"ids":  map[string]any{"type": "array", "items": "integer"},
+		"name": "string",
+	}})
+	input := map[string]any{"n": "2.5", "ok": "false", "ids": []any{"1", "2"}, "name": "42"}
+
+	typed, issues := s.Validate(input, true)
+	if len(issues) != 0 {
+		t.Fatalf("coerced: %v", issues)
+	}
+	want := map[string]any{"n": 2.5, "ok": false, "ids": []any{float64(1), float64(2)}, "name": "42"}
+	if !reflect.DeepEqual(typed, want) {
+		t.Fatalf("typed = %#v", typed)
+	}
+	if _, issues := s.Validate(input, false); len(issues) != 4 {
+		t.Fatalf("without coercion: %v", issues)
+	}
+}
+
+func TestBadPatternFailsClosed(t *testing.T) {
+	s := Compile(map[string]any{"type": "string", "pattern": "("})
+	if _, issues := s.Validate("anything", false); len(issues) != 1 {
+		t.Fatalf("issues = %v", issues)
+	}
+}
@@ -0,0 +1,178 @@
+package socket
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// Hub is one tenant's set of live connections and the rooms they joined. Every method is safe for
+// concurrent use; fan-out only queues onto each connection's outbox, so it never waits on a client.
+type Hub struct {
+	mu     sync.RWMutex
+	conns  map[string]*Conn
+	routes map[string]int // live connections per Conn.Route
+	rooms  map[string]map[*Conn]struct{}
+	closed bool
+	code   int
+	reason string
+}
+
+func NewHub() *Hub {
+	return &Hub{conns: map[string]*Conn{}, routes: map[string]int{}, rooms: map[string]map[*Conn]struct{}{}}
+}
+
+var (
+	// ErrFull is RegisterLimit refusing a connection because its route is at its limit.
+	ErrFull = errors.New("socket: too many concurrent connections")
+	// ErrDuplicate is a connection whose ID is already registered: it would take the other's place.
+	ErrDuplicate = errors.New("socket: connection id already registered")
+)
+
+// Register adds a connection. After Close the hub refuses it and closes it with the hub's code,
+// so a handshake that raced the drain still gets a proper close frame.
+func (h *Hub) Register(c *Conn) bool {
+	registered, _ := h.RegisterLimit(c, 0)
+	return registered
+}
+
+// RegisterLimit is Register with a cap on the live connections of c.Route (limit <= 0 means
+// none). The count is checked and c added under one lock, so concurrent handshakes cannot
+// overshoot the cap; a full route leaves c untouched and returns ErrFull, and an ID already in the
+// hub returns ErrDuplicate.
+func (h *Hub) RegisterLimit(c *Conn, limit int) (bool, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		c.Close(h.code, h.reason)
+		return false, nil
+	}
+	if _, taken := h.conns[c.ID]; taken {
+		return false, ErrDuplicate
+	}
+	if limit > 0 && h.routes[c.Route] >= limit {
+		return false, ErrFull
+	}
+	h.conns[c.ID] = c
+	h.routes[c.Route]++
+	return true, nil
+}
+
+// Unregister removes a connection from the hub and from every room it joined.
+func (h *Hub) Unregister(c *Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.conns[c.ID] == c {
+		delete(h.conns, c.ID)
+		if h.routes[c.Route]--; h.routes[c.Route] <= 0 {
+			delete(h.routes, c.Route)
+		}
+	}
+	for room := range c.rooms {
+		h.leaveLocked(c, room)
+	}
+}
+
+func (h *Hub) Join(c *Conn, room string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.conns[c.ID] != c || room == "" {
+		return
+	}
+	members := h.rooms[room]
+	if members == nil {
+		members = map[*Conn]struct{}{}
+		h.rooms[room] = members
+	}
+	members[c] = struct{}{}
+	c.rooms[room] = struct{}{}
+}
+
+func (h *Hub) Leave(c *Conn, room string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.leaveLocked(c, room)
+}
+
+func (h *Hub) leaveLocked(c *Conn, room string) {
+	delete(c.rooms, room)
+	if members := h.rooms[room]; members != nil {
+		delete(members, c)
+		if len(members) == 0 {
+			delete(h.rooms, room)
+		}
+	}
+}
+
+// Rooms lists the rooms c is in, sorted.
+func (h *Hub) Rooms(c *Conn) []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	rooms := make([]string, 0, len(c.rooms))
+	for room := range c.rooms {
+		rooms = append(rooms, room)
+	}
+	sort.Strings(rooms)
+	return rooms
+}
+
+// Broadcast queues m for every member of room except `except` (nil sends to all) and returns how
+// many connections accepted it.
+func (h *Hub) Broadcast(room string, m Message, except *Conn) int {
+	h.mu.RLock()
+	targets := make([]*Conn, 0, len(h.rooms[room]))
+	for c := range h.rooms[room] {
+		if c != except {
+			targets = append(targets, c)
+		}
+	}
+	h.mu.RUnlock()
+	sent := 0
+	for _, c := range targets {
+		if c.Send(m) {
+			sent++
+		}
+	}
+	return sent
+}
+
+// SendTo queues m for the connection with the given id.
+func (h *Hub) SendTo(id string, m Message) bool {
+	h.mu.RLock()
+	c := h.conns[id]
+	h.mu.RUnlock()
+	return c != nil && c.Send(m)
+}
+
+// Count is the number of live connections.
+func (h *Hub) Count() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.conns)
+}
+
+// Size is the number of connections in room.
+func (h *Hub) Size(room string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.rooms[room])
+}
+
+// Close refuses new connections and starts the closing handshake on every live one — the drain a
+// generation swap (CloseRestart) or a site shutdown (CloseGoingAway) performs.
+func (h *Hub) Close(code int, reason string) {
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		return
+	}
+	h.closed, h.code, h.reason = true, code, reason
+	conns := make([]*Conn, 0, len(h.conns))
+	for _, c := range h.conns {
+		conns = append(conns, c)
+	}
+	h.mu.Unlock()
+	for _, c := range conns {
+		c.Close(code, reason)
+	}
+}
@@ -0,0 +1,323 @@
+// Package socket is the bidirectional half of Kitwork's realtime layer (utilities/sse is the
+// one-way half): an RFC 6455 connection pump and a tenant-scoped Hub of connections and rooms.
+//
+// The wire protocol is gobwas/ws. A connection is served by two goroutines — Pump's read loop,
+// which hands every complete message to the caller IN ORDER, and a writer that drains the
+// connection's outbox, pings, and sends the close frame — so a slow handler never blocks a
+// broadcast and a slow client never blocks the room: its bounded outbox overflows and the
+// connection is closed instead.
+//
+// This is pure Go (bytes in, bytes out). The router.socket() JS binding lives in work/socket.go.
+package socket
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+)
+
+// Close codes (RFC 6455 §7.4.1, plus 1012 from the IANA registry).
+const (
+	CloseNormal     = 1000
+	CloseGoingAway  = 1001 // the server is shutting the site down
+	CloseNoStatus   = 1005 // the peer closed without a code
+	CloseAbnormal   = 1006 // the connection dropped without a close frame
+	ClosePolicy     = 1008 // rate limit, slow consumer
+	CloseTooBig     = 1009
+	CloseInternal   = 1011 // a handler failed
+	CloseRestart    = 1012 // a new site generation replaced this one — reconnect
+	DefaultMessage  = 64 << 10
+	DefaultPing     = 30 * time.Second
+	outboxSize      = 64
+	closeHandshake  = 2 * time.Second
+	writeTimeout    = 10 * time.Second
+	maxReasonLength = 123 // a close frame payload is at most 125 bytes, two of them the code
+)
+
+// Message is one complete WebSocket data message.
+type Message struct {
+	Binary bool
+	Data   []byte
+}
+
+// Conn is one client connection as the Hub and handlers see it: an id, a bounded outbox and the
+// rooms it joined. The network side is owned by Pump.
+type Conn struct {
+	ID    string
+	Route string // the endpoint it connected to; RegisterLimit caps connections per route
+
+	outbox    chan Message
+	closing   chan struct{}
+	closeOnce sync.Once
+	code      int
+	reason    string
+
+	rooms map[string]struct{} // guarded by the owning Hub's mutex
+}
+
+// NewID is a random connection id. Ids are the hub's keys and what SendTo addresses, so they come
+// from the server, never from the client.
+func NewID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+func NewConn(id string) *Conn {
+	return &Conn{
+		ID:      id,
+		outbox:  make(chan Message, outboxSize),
+		closing: make(chan struct{}),
+		rooms:   map[string]struct{}{},
+	}
+}
+
+// Send queues a message without blocking. A client that lets its outbox fill up is closed with
+// ClosePolicy — it is not keeping up, and holding the sender would stall every room it is in.
+func (c *Conn) Send(m Message) bool {
+	select {
+	case <-c.closing:
+		return false
+	default:
+	}
+	select {
+	case c.outbox <- m:
+		return true
+	default:
+		c.Close(ClosePolicy, "slow consumer")
+		return false
+	}
+}
+
+// Close starts the closing handshake with code and reason; only the first call counts. Messages
+// already queued are still delivered before the close frame.
+func (c *Conn) Close(code int, reason string) {
+	c.closeOnce.Do(func() {
+		if len(reason) > maxReasonLength {
+			reason = reason[:maxReasonLength]
+		}
+		c.code, c.reason = code, reason
+		close(c.closing)
+	})
+}
+
+// Closing is closed once Close has been called.
+func (c *Conn) Closing() <-chan struct{} { return c.closing }
+
+// Options tunes Pump. Zero values mean DefaultMessage and DefaultPing.
+type Options struct {
+	MaxMessage int64
+	Ping       time.Duration
+}
+
+// IsUpgrade reports whether a request asks to switch to the WebSocket protocol.
+func IsUpgrade(r *http.Request) bool {
+	return headerHas(r.Header, "Upgrade", "websocket") && headerHas(r.Header, "Connection", "upgrade")
+}
+
+func headerHas(h http.Header, name, token string) bool {
+	for _, line := range h.Values(name) {
+		for _, part := range strings.Split(line, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Upgrade completes the opening handshake and hijacks the connection. On failure the client has
+// already been answered with an HTTP error.
+func Upgrade(w http.ResponseWriter, r *http.Request) (net.Conn, error) {
+	conn, _, _, err := ws.UpgradeHTTP(r, w)
+	if err != nil && conn != nil {
+		conn.Close()
+	}
+	return conn, err
+}
+
+// Pump serves netConn until either side closes it, calling onMessage for every data message in
+// arrival order on the calling goroutine. It returns the close code and reason: the peer's when
+// the client closed, the server's when c.Close was called, CloseAbnormal when the line dropped.
+func Pump(netConn net.Conn, c *Conn, opts Options, onMessage func(Message)) (int, string) {
+	if opts.MaxMessage <= 0 {
+		opts.MaxMessage = DefaultMessage
+	}
+	if opts.Ping <= 0 {
+		opts.Ping = DefaultPing
+	}
+	out := &frameWriter{conn: netConn}
+	stopped := make(chan struct{})
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		out.run(c, opts.Ping, stopped)
+	}()
+
+	code, reason := read(netConn, c, out, opts.MaxMessage, onMessage)
+	close(stopped)
+	<-writerDone
+	netConn.Close()
+	return code, reason
+}
+
+func read(netConn net.Conn, c *Conn, out *frameWriter, limit int64, onMessage func(Message)) (int, string) {
+	rd := &wsutil.Reader{Source: netConn, State: ws.StateServerSide, CheckUTF8: true}
+	for {
+		hdr, err := rd.NextFrame()
+		if err != nil {
+			return closedBy(c, CloseAbnormal, "")
+		}
+		if hdr.OpCode.IsControl() {
+			out.mu.Lock()
+			err := wsutil.ControlHandler{
+				Src: rd, Dst: out, State: ws.StateServerSide, DisableSrcCiphering: true,
+			}.Handle(hdr)
+			var closed wsutil.ClosedError
+			if errors.As(err, &closed) {
+				out.closed = true // the handler answered the peer's close frame
+			}
+			out.mu.Unlock()
+			if errors.As(err, &closed) {
+				return closedBy(c, int(closed.Code), closed.Reason)
+			}
+			if err != nil {
+				return closedBy(c, CloseAbnormal, "")
+			}
+			continue
+		}
+		data, err := io.ReadAll(io.LimitReader(rd, limit+1))
+		if err != nil {
+			return closedBy(c, CloseAbnormal, "")
+		}
+		if int64(len(data)) > limit {
+			c.Close(CloseTooBig, "message too big")
+			drainUntilClosed(netConn, rd, out)
+			return c.code, c.reason
+		}
+		onMessage(Message{Binary: hdr.OpCode == ws.OpBinary, Data: data})
+		select {
+		case <-c.closing:
+			// A handler (or a slow-consumer overflow) closed the connection: wait briefly for the
+			// peer's close echo, then report the server's code.
+			drainUntilClosed(netConn, rd, out)
+			return c.code, c.reason
+		default:
+		}
+	}
+}
+
+// closedBy records code as the reason the connection ended unless the server already started
+// closing, and reports whichever won.
+func closedBy(c *Conn, code int, reason string) (int, string) {
+	c.Close(code, reason)
+	return c.code, c.reason
+}
+
+// drainUntilClosed discards the peer's remaining frames until its close echo arrives or the
+// closing handshake times out.
+func drainUntilClosed(netConn net.Conn, rd *wsutil.Reader, out *frameWriter) {
+	netConn.SetReadDeadline(time.Now().Add(closeHandshake))
+	for {
+		hdr, err := rd.NextFrame()
+		if err != nil {
+			return
+		}
+		if hdr.OpCode == ws.OpClose {
+			out.mu.Lock()
+			out.closed = true // never echo a close we started
+			out.mu.Unlock()
+			return
+		}
+		if rd.Discard() != nil {
+			return
+		}
+	}
+}
+
+// frameWriter serialises frames from the writer goroutine and the read loop's control replies.
+// Write is the raw sink for wsutil.ControlHandler and must be called with mu held.
+type frameWriter struct {
+	mu     sync.Mutex
+	conn   net.Conn
+	closed bool // a close frame went out; nothing may follow it
+}
+
+func (w *frameWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return len(p), nil
+	}
+	w.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return w.conn.Write(p)
+}
+
+func (w *frameWriter) frame(f ws.Frame) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return net.ErrClosed
+	}
+	w.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	err := ws.WriteFrame(w.conn, f)
+	if f.Header.OpCode == ws.OpClose {
+		w.closed = true
+	}
+	return err
+}
+
+func (w *frameWriter) run(c *Conn, ping time.Duration, stopped <-chan struct{}) {
+	ticker := time.NewTicker(ping)
+	defer ticker.Stop()
+	send := func(m Message) bool {
+		op := ws.OpText
+		if m.Binary {
+			op = ws.OpBinary
+		}
+		if err := w.frame(ws.NewFrame(op, true, m.Data)); err != nil {
+			c.Close(CloseAbnormal, "")
+			w.conn.Close() // unblock the read loop
+			return false
+		}
+		return true
+	}
+	for {
+		select {
+		case <-stopped:
+			return
+		case m := <-c.outbox:
+			if !send(m) {
+				return
+			}
+		case <-ticker.C:
+			if w.frame(ws.NewPingFrame(nil)) != nil {
+				w.conn.Close()
+				return
+			}
+		case <-c.closing:
+			for queued := true; queued; {
+				select {
+				case m := <-c.outbox:
+					queued = send(m)
+				default:
+					queued = false
+				}
+			}
+			if c.code != CloseNoStatus && c.code != CloseAbnormal { // reserved: never sent on the wire
+				w.frame(ws.NewCloseFrame(ws.NewCloseFrameBody(ws.StatusCode(c.code), c.reason)))
+			}
+			// The read loop is waiting for the peer's echo; make sure it gives up in time.
+			w.conn.SetReadDeadline(time.Now().Add(closeHandshake))
+			<-stopped
+			return
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package socket
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestHubRoomsBroadcastAndClose(t *testing.T) {
+	hub := NewHub()
+	a, b, c := NewConn("a"), NewConn("b"), NewConn("c")
+	for _, conn := range []*Conn{a, b, c} {
+		if !hub.Register(conn) {
+			t.Fatalf("register %s refused", conn.ID)
+		}
+	}
+	hub.Join(a, "lobby")
+	hub.Join(b, "lobby")
+	hub.Join(b, "ops")
+	if got := hub.Rooms(b); len(got) != 2 || got[0] != "lobby" || got[1] != "ops" {
+		t.Fatalf("rooms(b) = %v", got)
+	}
+	if sent := hub.Broadcast("lobby", Message{Data: []byte("hi")}, a); sent != 1 || len(b.outbox) != 1 || len(a.outbox) != 0 {
+		t.Fatalf("broadcast except sender = %d", sent)
+	}
+	if !hub.SendTo("c", Message{Data: []byte("dm")}) || hub.SendTo("nobody", Message{}) {
+		t.Fatal("SendTo must reach exactly the named connection")
+	}
+
+	hub.Unregister(b)
+	if hub.Size("lobby") != 1 || hub.Size("ops") != 0 || hub.Count() != 2 {
+		t.Fatalf("unregister left b behind: lobby=%d ops=%d count=%d", hub.Size("lobby"), hub.Size("ops"), hub.Count())
+	}
+
+	// A client that never reads is closed instead of stalling the sender.
+	for i := 0; i <= outboxSize; i++ {
+		c.Send(Message{Data: []byte("x")})
+	}
+	select {
+	case <-c.Closing():
+		if c.code != ClosePolicy {
+			t.Fatalf("slow consumer code = %d", c.code)
+		}
+	default:
+		t.Fatal("an overflowing outbox must close the connection")
+	}
+
+	hub.Close(CloseRestart, "site updated")
+	select {
+	case <-a.Closing():
+	default:
+		t.Fatal("Close must close every live connection")
+	}
+	late := NewConn("late")
+	if hub.Register(late) || late.code != CloseRestart {
+		t.Fatal("a closed hub must refuse and close new connections")
+	}
+}
+
+// Concurrent registrations never take the hub past its limit.
+func TestHubRegisterLimit(t *testing.T) {
+	hub := NewHub()
+	var admitted, full atomic.Int32
+	var wg sync.WaitGroup
+	for i := range 64 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := hub.RegisterLimit(NewConn(strconv.Itoa(i)), 10)
+			switch {
+			case ok:
+				admitted.Add(1)
+			case errors.Is(err, ErrFull):
+				full.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if admitted.Load() != 10 || full.Load() != 54 || hub.Count() != 10 {
+		t.Fatalf("admitted=%d full=%d count=%d, want 10/54/10", admitted.Load(), full.Load(), hub.Count())
+	}
+}
+
+// A second connection with a registered ID is refused instead of taking the first one's place, and
+// the cap counts each route on its own.
+func TestHubRegisterDuplicateAndPerRoute(t *testing.T) {
+	hub := NewHub()
+	first := NewConn("same")
+	if ok, err := hub.RegisterLimit(first, 0); !ok || err != nil {
+		t.Fatalf("first = %v, %v", ok, err)
+	}
+	if ok, err := hub.RegisterLimit(NewConn("same"), 0); ok || !errors.Is(err, ErrDuplicate) {
+		t.Fatalf("duplicate = %v, %v", ok, err)
+	}
+	if !hub.SendTo("same", Message{Data: []byte("x")}) || len(first.outbox) != 1 {
+		t.Fatal("the first connection lost its id")
+	}
+
+	for _, route := range []string{"/a", "/b"} {
+		c := NewConn(NewID())
+		c.Route = route
+		if ok, err := hub.RegisterLimit(c, 1); !ok || err != nil {
+			t.Fatalf("%s = %v, %v", route, ok, err)
+		}
+	}
+	extra := NewConn(NewID())
+	extra.Route = "/a"
+	if _, err := hub.RegisterLimit(extra, 1); !errors.Is(err, ErrFull) {
+		t.Fatalf("second on /a = %v", err)
+	}
+}
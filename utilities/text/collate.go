@@ -0,0 +1,136 @@
+package text
+
+import (
+	"strings"
+	"unicode"
+)
+
+// alphabet is the Vietnamese letter order (bảng chữ cái), with f j w z slotted in where Latin puts
+// them so loanwords and foreign names sort sensibly.
+const alphabet = "aăâbcdđeêfghijklmnoôơpqrstuưvwxyz"
+
+var letterRank = func() map[rune]int {
+	ranks := make(map[rune]int, 33)
+	for i, r := range []rune(alphabet) {
+		ranks[r] = i
+	}
+	return ranks
+}()
+
+// primary ranks a letter ignoring its tone: spaces and punctuation first, then digits, then the
+// alphabet, then everything else by code point.
+func primary(letter rune) int {
+	if base, ok := latinFold[letter]; ok {
+		letter = base
+	}
+	switch rank, ok := letterRank[letter]; {
+	case ok:
+		return 0x200 + rank
+	case letter >= '0' && letter <= '9':
+		return 0x100 + int(letter-'0')
+	case letter < 0x80:
+		return int(letter)
+	default:
+		return 0x300 + int(letter)
+	}
+}
+
+// Compare orders a and b the way a Vietnamese dictionary does: by letters first (a < ă < â < b …
+// d < đ …), then by tone (ngang, huyền, hỏi, ngã, sắc, nặng), then lower case before upper. It
+// returns 0 only for identical strings, so it is safe as a sort comparator and as an equality test.
+func Compare(a, b string) int {
+	ga, ua := glyphsOf(a)
+	gb, ub := glyphsOf(b)
+	if c := compareBy(ga, gb, func(g glyph) int { return primary(g.letter) }); c != 0 {
+		return c
+	}
+	if c := compareBy(ga, gb, func(g glyph) int { return g.tone }); c != 0 {
+		return c
+	}
+	for i := range ua {
+		if ua[i] != ub[i] {
+			if ua[i] {
+				return 1
+			}
+			return -1
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+// Less reports whether a sorts before b under Compare.
+func Less(a, b string) bool {
+	return Compare(a, b) < 0
+}
+
+func compareBy(a, b []glyph, key func(glyph) int) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if ka, kb := key(a[i]), key(b[i]); ka != kb {
+			if ka < kb {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	}
+	return 0
+}
+
+// SentenceCase lowercases s and capitalises the first letter of each sentence — after the start,
+// and after ".", "!" or "?" followed by whitespace. "XIN CHÀO. BẠN KHỎE KHÔNG?" → "Xin chào. Bạn
+// khỏe không?"
+func SentenceCase(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	capitalise, ended := true, false
+	for _, r := range s {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if capitalise {
+				b.WriteRune(unicode.ToUpper(r))
+				capitalise = false
+			} else {
+				b.WriteRune(unicode.ToLower(r))
+			}
+			ended = false
+			continue
+		case r == '.' || r == '!' || r == '?' || r == '…':
+			ended = true
+		case unicode.IsSpace(r):
+			if ended {
+				capitalise = true
+			}
+		default:
+			ended = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// TitleCase capitalises every word and lowercases the rest, the way Vietnamese names are written:
+// "nguyễn THỊ minh khai" → "Nguyễn Thị Minh Khai".
+func TitleCase(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	start := true
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) {
+			if start {
+				b.WriteRune(unicode.ToUpper(r))
+			} else {
+				b.WriteRune(unicode.ToLower(r))
+			}
+			start = false
+			continue
+		}
+		start = r != '\'' && r != '’'
+		b.WriteRune(r)
+	}
+	return b.String()
+}
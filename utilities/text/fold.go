@@ -0,0 +1,158 @@
+// Package text holds the Vietnamese-aware string helpers shared by collection queries, collection
+// search and the `text` capability: diacritic folding, slugs, sentence/title casing, dictionary
+// collation and reading numbers aloud (đọc số tiền).
+//
+// Everything works on precomposed (NFC) and decomposed (NFD) input alike — combining marks are
+// recognised and attached to the letter before them. Pure Go, no dependencies; the kitwork() JS
+// binding lives in capabilities/text.
+package text
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Tones in the order dictionaries list them: ngang, huyền, hỏi, ngã, sắc, nặng.
+const (
+	toneNone = iota
+	toneGrave
+	toneHook
+	toneTilde
+	toneAcute
+	toneDot
+)
+
+// toneRows lists every toned form of a Vietnamese vowel, in the order the Unicode charts (and
+// keyboards) use: ngang, huyền, sắc, hỏi, ngã, nặng.
+var toneRows = map[rune]string{
+	'a': "aàáảãạ", 'ă': "ăằắẳẵặ", 'â': "âầấẩẫậ",
+	'e': "eèéẻẽẹ", 'ê': "êềếểễệ",
+	'i': "iìíỉĩị",
+	'o': "oòóỏõọ", 'ô': "ôồốổỗộ", 'ơ': "ơờớởỡợ",
+	'u': "uùúủũụ", 'ư': "ưừứửữự",
+	'y': "yỳýỷỹỵ",
+}
+
+var chartTones = [...]int{toneNone, toneGrave, toneAcute, toneHook, toneTilde, toneDot}
+
+// letterBase strips the vowel shape: the ASCII letter each Vietnamese letter is typed from.
+var letterBase = map[rune]rune{'ă': 'a', 'â': 'a', 'đ': 'd', 'ê': 'e', 'ô': 'o', 'ơ': 'o', 'ư': 'u'}
+
+// latinFold covers the other Latin accents that turn up in names and imported content.
+var latinFold = map[rune]rune{
+	'ä': 'a', 'å': 'a', 'æ': 'a', 'ç': 'c', 'ë': 'e', 'î': 'i', 'ï': 'i', 'ñ': 'n',
+	'ö': 'o', 'ø': 'o', 'û': 'u', 'ü': 'u', 'ÿ': 'y', 'ð': 'd', 'ß': 's',
+}
+
+// glyph is one lower-cased letter split into its Vietnamese letter (with vowel shape) and tone.
+type glyph struct {
+	letter rune
+	tone   int
+}
+
+var glyphs = func() map[rune]glyph {
+	table := make(map[rune]glyph, 72)
+	for letter, row := range toneRows {
+		for i, r := range []rune(row) {
+			table[r] = glyph{letter: letter, tone: chartTones[i]}
+		}
+	}
+	return table
+}()
+
+// combining maps the combining marks of decomposed input onto a tone or a vowel shape.
+var combining = map[rune]int{
+	'̀': toneGrave, '̉': toneHook, '̃': toneTilde, '́': toneAcute, '̣': toneDot,
+}
+
+var shapeMarks = map[rune]map[rune]rune{
+	'̆': {'a': 'ă'},                     // breve
+	'̂': {'a': 'â', 'e': 'ê', 'o': 'ô'}, // circumflex
+	'̛': {'o': 'ơ', 'u': 'ư'},           // horn
+}
+
+// split lowercases r and returns its Vietnamese letter and tone.
+func split(r rune) glyph {
+	r = unicode.ToLower(r)
+	if g, ok := glyphs[r]; ok {
+		return g
+	}
+	return glyph{letter: r}
+}
+
+// glyphsOf decomposes s into glyphs, merging combining marks into the letter before them. upper
+// reports, per glyph, whether the source letter was upper case.
+func glyphsOf(s string) (out []glyph, upper []bool) {
+	out = make([]glyph, 0, len(s))
+	upper = make([]bool, 0, len(s))
+	for _, r := range s {
+		if n := len(out); n > 0 && unicode.Is(unicode.Mn, r) {
+			last := &out[n-1]
+			if tone, ok := combining[r]; ok {
+				last.tone = tone
+				continue
+			}
+			if shaped, ok := shapeMarks[r][last.letter]; ok {
+				last.letter = shaped
+			}
+			continue
+		}
+		out = append(out, split(r))
+		upper = append(upper, unicode.IsUpper(r))
+	}
+	return out, upper
+}
+
+// Fold lowercases s and removes every tone and vowel mark, đ included: "Đường Hồ Chí Minh" →
+// "duong ho chi minh". Two strings that fold equal read the same to someone typing without accents.
+func Fold(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		g := split(r)
+		letter := g.letter
+		if base, ok := letterBase[letter]; ok {
+			letter = base
+		} else if base, ok := latinFold[letter]; ok {
+			letter = base
+		}
+		b.WriteRune(letter)
+	}
+	return b.String()
+}
+
+// EqualFold reports whether a and b are the same text ignoring case and accents.
+func EqualFold(a, b string) bool {
+	return Fold(a) == Fold(b)
+}
+
+// ContainsFold reports whether needle occurs in haystack ignoring case and accents — "ha noi"
+// finds "Hà Nội".
+func ContainsFold(haystack, needle string) bool {
+	return strings.Contains(Fold(haystack), Fold(needle))
+}
+
+// Slugify turns a title into a URL segment: folded, with every run of other characters collapsed
+// into separator ("-" when empty) and trimmed from both ends. "Phở Bò Hà Nội!" → "pho-bo-ha-noi".
+func Slugify(s, separator string) string {
+	if separator == "" {
+		separator = "-"
+	}
+	var b strings.Builder
+	pending := false
+	for _, r := range Fold(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if pending && b.Len() > 0 {
+				b.WriteString(separator)
+			}
+			pending = false
+			b.WriteRune(r)
+			continue
+		}
+		pending = true
+	}
+	return b.String()
+}
@@ -0,0 +1,87 @@
+package text
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFoldAndSlugify(t *testing.T) {
+	for in, want := range map[string]string{
+		"Đường Hồ Chí Minh":        "duong ho chi minh",
+		"Nguyễn Thị Minh Khai":     "nguyen thi minh khai",
+		"ĐẶNG ỨNG":                 "dang ung",
+		"Ho\u0302\u0300 Go\u031bm": "ho gom", // decomposed input
+		"Crème brûlée, Señor":      "creme brulee, senor",
+	} {
+		if got := Fold(in); got != want {
+			t.Errorf("Fold(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if !ContainsFold("Bún chả Hà Nội", "HA NOI") || ContainsFold("Hải Phòng", "ha noi") {
+		t.Fatal("ContainsFold must ignore accents and case, nothing else")
+	}
+	if got := Slugify("  Phở Bò — Hà Nội (2025)! ", ""); got != "pho-bo-ha-noi-2025" {
+		t.Fatalf("Slugify = %q", got)
+	}
+	if got := Slugify("Đà Lạt mộng mơ", "_"); got != "da_lat_mong_mo" {
+		t.Fatalf("Slugify with separator = %q", got)
+	}
+}
+
+func TestCompareFollowsTheVietnameseDictionary(t *testing.T) {
+	words := []string{"đào", "ăn", "Anh", "anh", "bé", "be", "bẻ", "bè", "bẹ", "bẽ", "ân", "dê", "an", "ổi", "ơi", "ong", "10", "ư", "uống"}
+	slices.SortFunc(words, Compare)
+	want := []string{"10", "an", "anh", "Anh", "ăn", "ân", "be", "bè", "bẻ", "bẽ", "bé", "bẹ", "dê", "đào", "ong", "ổi", "ơi", "uống", "ư"}
+	if !slices.Equal(words, want) {
+		t.Fatalf("sorted = %v\nwant   %v", words, want)
+	}
+	// Decomposed and precomposed spellings collate together, but only identical strings compare 0.
+	if c := Compare("Ho\u0302\u0300", "Hồ"); c == 0 || Compare("Ho\u0302\u0300", "Hổ") >= 0 {
+		t.Fatalf("Compare(NFD, NFC) = %d", c)
+	}
+	if Compare("hồ", "hổ") >= 0 || Compare("hổ", "hộ") >= 0 {
+		t.Fatal("tones must order huyền < hỏi < nặng")
+	}
+}
+
+func TestCasing(t *testing.T) {
+	if got := SentenceCase("XIN CHÀO. BẠN KHỎE KHÔNG? tôi ỔN!"); got != "Xin chào. Bạn khỏe không? Tôi ổn!" {
+		t.Fatalf("SentenceCase = %q", got)
+	}
+	if got := TitleCase("nguyễn THỊ minh-khai"); got != "Nguyễn Thị Minh-Khai" {
+		t.Fatalf("TitleCase = %q", got)
+	}
+}
+
+func TestToWords(t *testing.T) {
+	for _, tc := range []struct{ number, lang, want string }{
+		{"0", "vi", "không"},
+		{"5", "vi", "năm"},
+		{"15", "vi", "mười lăm"},
+		{"21", "vi", "hai mươi mốt"},
+		{"105", "vi", "một trăm linh năm"},
+		{"1234567", "vi", "một triệu hai trăm ba mươi tư nghìn năm trăm sáu mươi bảy"},
+		{"1000005", "vi", "một triệu không trăm linh năm"},
+		{"2001000", "vi", "hai triệu không trăm linh một nghìn"},
+		{"1500000000", "vi", "một tỷ năm trăm triệu"},
+		{"1000000000000", "vi", "một nghìn tỷ"},
+		{"-12.05", "vi", "âm mười hai phẩy không năm"},
+		{"1234567", "en", "one million two hundred thirty-four thousand five hundred sixty-seven"},
+		{"1000001", "en", "one million one"},
+		{"3.14", "en", "three point one four"},
+	} {
+		got, err := ToWords(tc.number, tc.lang)
+		if err != nil || got != tc.want {
+			t.Errorf("ToWords(%s, %s) = %q, %v; want %q", tc.number, tc.lang, got, err, tc.want)
+		}
+	}
+	if _, err := ToWords("12abc", "vi"); err == nil {
+		t.Fatal("garbage must be refused")
+	}
+	if _, err := ToWords("12", "fr"); err == nil {
+		t.Fatal("unsupported language must be refused")
+	}
+	if got := FormatNumber(1e15); got != "1000000000000000" {
+		t.Fatalf("FormatNumber = %q", got)
+	}
+}
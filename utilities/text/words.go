@@ -0,0 +1,172 @@
+package text
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var viDigits = [...]string{"không", "một", "hai", "ba", "bốn", "năm", "sáu", "bảy", "tám", "chín"}
+
+var enOnes = [...]string{
+	"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine",
+	"ten", "eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen", "seventeen", "eighteen", "nineteen",
+}
+
+var enTens = [...]string{"", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety"}
+
+var enScales = [...]string{"", "thousand", "million", "billion", "trillion", "quadrillion", "quintillion"}
+
+// ToWords reads a number aloud in lang ("vi" or "en"): 1234567 in Vietnamese is "một triệu hai trăm
+// ba mươi tư nghìn năm trăm sáu mươi bảy" — the amount-in-words line on an invoice. Negative numbers
+// read with "âm"/"minus", decimals digit by digit after "phẩy"/"point". The number is given as text
+// so amounts beyond float64's 2^53 keep every digit.
+func ToWords(number, lang string) (string, error) {
+	number = strings.TrimSpace(strings.ReplaceAll(number, "_", ""))
+	negative := strings.HasPrefix(number, "-")
+	number = strings.TrimPrefix(strings.TrimPrefix(number, "-"), "+")
+	whole, fraction, _ := strings.Cut(number, ".")
+	if whole == "" {
+		whole = "0"
+	}
+	n, err := strconv.ParseUint(whole, 10, 64)
+	if err != nil || strings.Trim(fraction, "0123456789") != "" {
+		return "", fmt.Errorf("not a number: %q", number)
+	}
+	fraction = strings.TrimRight(fraction, "0")
+
+	var words, minus, point string
+	var digits []string
+	switch strings.ToLower(lang) {
+	case "", "vi", "vn", "vi-vn":
+		words, minus, point, digits = viNumber(n, false), "âm", "phẩy", viDigits[:]
+	case "en", "en-us", "en-gb":
+		words, minus, point, digits = enNumber(n), "minus", "point", enOnes[:10]
+	default:
+		return "", fmt.Errorf("unsupported language %q", lang)
+	}
+	if fraction != "" {
+		parts := []string{words, point}
+		for _, d := range fraction {
+			parts = append(parts, digits[d-'0'])
+		}
+		words = strings.Join(parts, " ")
+	}
+	if negative && (n != 0 || fraction != "") {
+		words = minus + " " + words
+	}
+	return words, nil
+}
+
+// FormatNumber renders f as the plain decimal ToWords expects — no exponent, no float noise.
+func FormatNumber(f float64) string {
+	if f == math.Trunc(f) && math.Abs(f) < 1e21 {
+		return strconv.FormatFloat(f, 'f', 0, 64)
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// viNumber reads n in Vietnamese. Above a billion it recurses on the number of "tỷ", so 10^12 is
+// "một nghìn tỷ". full makes a leading zero hundreds digit audible ("không trăm") — required for
+// every group after the first.
+func viNumber(n uint64, full bool) string {
+	if n == 0 && !full {
+		return viDigits[0]
+	}
+	const billion = 1_000_000_000
+	if n >= billion {
+		words := viNumber(n/billion, full) + " tỷ"
+		if rest := n % billion; rest > 0 {
+			words += " " + viBelowBillion(rest, true)
+		}
+		return words
+	}
+	return viBelowBillion(n, full)
+}
+
+func viBelowBillion(n uint64, full bool) string {
+	var parts []string
+	for _, group := range []struct {
+		value uint64
+		scale string
+	}{{n / 1_000_000, "triệu"}, {n / 1000 % 1000, "nghìn"}, {n % 1000, ""}} {
+		if group.value == 0 {
+			continue
+		}
+		parts = append(parts, viHundreds(int(group.value), full))
+		if group.scale != "" {
+			parts = append(parts, group.scale)
+		}
+		full = true
+	}
+	return strings.Join(parts, " ")
+}
+
+// viHundreds reads 1–999 with the spoken rules: "linh" for a zero tens digit, "mười" for ten,
+// "mốt" and "tư" from twenty-one up ("hai mươi mốt"), "lăm" from fifteen up ("mười lăm").
+func viHundreds(n int, full bool) string {
+	hundreds, tens, units := n/100, n/10%10, n%10
+	var parts []string
+	if hundreds > 0 || full {
+		parts = append(parts, viDigits[hundreds], "trăm")
+	}
+	switch {
+	case tens == 0:
+		if units > 0 && len(parts) > 0 {
+			parts = append(parts, "linh")
+		}
+	case tens == 1:
+		parts = append(parts, "mười")
+	default:
+		parts = append(parts, viDigits[tens], "mươi")
+	}
+	switch {
+	case units == 0:
+	case units == 1 && tens > 1:
+		parts = append(parts, "mốt")
+	case units == 4 && tens > 1:
+		parts = append(parts, "tư")
+	case units == 5 && tens > 0:
+		parts = append(parts, "lăm")
+	default:
+		parts = append(parts, viDigits[units])
+	}
+	return strings.Join(parts, " ")
+}
+
+func enNumber(n uint64) string {
+	if n == 0 {
+		return enOnes[0]
+	}
+	var groups []string
+	for scale := 0; n > 0; scale++ {
+		if group := int(n % 1000); group > 0 {
+			words := enHundreds(group)
+			if enScales[scale] != "" {
+				words += " " + enScales[scale]
+			}
+			groups = append([]string{words}, groups...)
+		}
+		n /= 1000
+	}
+	return strings.Join(groups, " ")
+}
+
+func enHundreds(n int) string {
+	var parts []string
+	if n >= 100 {
+		parts = append(parts, enOnes[n/100], "hundred")
+		n %= 100
+	}
+	switch {
+	case n == 0:
+	case n < 20:
+		parts = append(parts, enOnes[n])
+	case n%10 == 0:
+		parts = append(parts, enTens[n/10])
+	default:
+		parts = append(parts, enTens[n/10]+"-"+enOnes[n%10])
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,192 @@
+package trace
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Exporter ships one OTLP/JSON ExportTraceServiceRequest document.
+type Exporter interface {
+	Export(payload []byte) error
+}
+
+// The OTLP/JSON shapes (opentelemetry-proto, JSON mapping): ids are hex, 64-bit integers strings.
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string         `json:"traceId"`
+		SpanID            string         `json:"spanId"`
+		ParentSpanID      string         `json:"parentSpanId,omitempty"`
+		TraceState        string         `json:"traceState,omitempty"`
+		Name              string         `json:"name"`
+		Kind              Kind           `json:"kind"`
+		StartTimeUnixNano string         `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+		Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+		Status            otlpStatus     `json:"status"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code,omitempty"` // 0 unset, 2 error
+		Message string `json:"message,omitempty"`
+	}
+	otlpKeyValue struct {
+		Key   string         `json:"key"`
+		Value map[string]any `json:"value"`
+	}
+)
+
+// Encode renders spans as one OTLP/JSON ExportTraceServiceRequest.
+func Encode(service string, spans []*Span) ([]byte, error) {
+	out := make([]otlpSpan, 0, len(spans))
+	for _, span := range spans {
+		span.mu.Lock()
+		encoded := otlpSpan{
+			TraceID:           span.context.TraceID.String(),
+			SpanID:            span.context.SpanID.String(),
+			TraceState:        span.context.State,
+			Name:              span.name,
+			Kind:              span.kind,
+			StartTimeUnixNano: strconv.FormatInt(span.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.end.UnixNano(), 10),
+		}
+		if span.parent.IsValid() {
+			encoded.ParentSpanID = span.parent.String()
+		}
+		for _, attr := range span.attrs {
+			encoded.Attributes = append(encoded.Attributes, otlpKeyValue{attr.Key, anyValue(attr.Value)})
+		}
+		if span.failed {
+			encoded.Status = otlpStatus{Code: 2, Message: span.message}
+		}
+		span.mu.Unlock()
+		out = append(out, encoded)
+	}
+	return json.Marshal(otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: []otlpKeyValue{{"service.name", anyValue(service)}}},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: "kitwork"}, Spans: out}},
+	}}})
+}
+
+func anyValue(v any) map[string]any {
+	switch v := v.(type) {
+	case string:
+		return map[string]any{"stringValue": v}
+	case bool:
+		return map[string]any{"boolValue": v}
+	case int:
+		return map[string]any{"intValue": strconv.Itoa(v)}
+	case int64:
+		return map[string]any{"intValue": strconv.FormatInt(v, 10)}
+	case uint64:
+		return map[string]any{"intValue": strconv.FormatUint(v, 10)}
+	case float64:
+		return map[string]any{"doubleValue": v}
+	default:
+		return map[string]any{"stringValue": fmt.Sprint(v)}
+	}
+}
+
+// FileExporter appends each export as one line of OTLP/JSON — the OpenTelemetry file exporter
+// format, which collectors and most trace tools can import.
+type FileExporter struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// NewFileExporter writes to path, creating it and its directory on the first export.
+func NewFileExporter(path string) *FileExporter {
+	return &FileExporter{path: path}
+}
+
+func (f *FileExporter) Export(payload []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+			return err
+		}
+		file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return err
+		}
+		f.file = file
+	}
+	_, err := f.file.Write(append(payload, '\n'))
+	return err
+}
+
+func (f *FileExporter) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// HTTPExporter posts each export to an OTLP/HTTP collector.
+type HTTPExporter struct {
+	endpoint string
+	headers  map[string]string
+	client   *http.Client
+}
+
+// NewHTTPExporter posts to endpoint; a bare collector address ("http://collector:4318") gets the
+// standard /v1/traces path. Headers are added to every request (an API key, say).
+func NewHTTPExporter(endpoint string, headers map[string]string) *HTTPExporter {
+	if u, err := url.Parse(endpoint); err == nil && strings.Trim(u.Path, "/") == "" {
+		u.Path = "/v1/traces"
+		endpoint = u.String()
+	}
+	return &HTTPExporter{endpoint: endpoint, headers: headers, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (h *HTTPExporter) Export(payload []byte) error {
+	req, err := http.NewRequest(http.MethodPost, h.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range h.headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("otlp export: %s answered %s", h.endpoint, resp.Status)
+	}
+	return nil
+}
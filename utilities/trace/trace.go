@@ -0,0 +1,243 @@
+// Package trace carries W3C trace context (traceparent / tracestate) through a request and records
+// spans, exported as OTLP/JSON (export.go).
+//
+//	tracer := trace.NewTracer(trace.NewHTTPExporter("http://collector:4318", nil), trace.Options{})
+//	ctx, span := tracer.Begin(ctx, "GET", trace.KindServer, trace.Extract(r.Header), "example.com")
+//	defer span.End()
+//	_, child := trace.Start(ctx, "db.query", trace.KindClient) // nested under whatever ctx carries
+//	trace.Inject(ctx, outbound.Header)                           // propagate to the next hop
+//
+// A trace that is not sampled still has ids, so it propagates unchanged; its spans just record
+// nothing. Every *Span method is nil-safe: code that may run outside a trace needs no checks.
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TraceID and SpanID are the W3C identifiers; the zero value is invalid.
+type (
+	TraceID [16]byte
+	SpanID  [8]byte
+)
+
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+func (id SpanID) String() string  { return hex.EncodeToString(id[:]) }
+func (id TraceID) IsValid() bool  { return id != TraceID{} }
+func (id SpanID) IsValid() bool   { return id != SpanID{} }
+
+// SpanContext is what crosses process boundaries.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+	State   string // tracestate, passed along untouched
+}
+
+// IsValid reports whether both ids are set.
+func (sc SpanContext) IsValid() bool { return sc.TraceID.IsValid() && sc.SpanID.IsValid() }
+
+// Traceparent renders the version-00 traceparent header value.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// ParseTraceparent reads a traceparent header value. Later versions are accepted as long as they
+// start with the version-00 fields, as the spec asks.
+func ParseTraceparent(value string) (SpanContext, bool) {
+	value = strings.TrimSpace(value)
+	if len(value) < 55 || value[2] != '-' || value[35] != '-' || value[52] != '-' {
+		return SpanContext{}, false
+	}
+	version, err := hex.DecodeString(value[:2])
+	if err != nil || version[0] == 0xff || (version[0] == 0 && len(value) != 55) || (len(value) > 55 && value[55] != '-') {
+		return SpanContext{}, false
+	}
+	var sc SpanContext
+	flags, err := hex.DecodeString(value[53:55])
+	if err != nil || !lowerHex(value[3:35]) || !lowerHex(value[36:52]) {
+		return SpanContext{}, false
+	}
+	hex.Decode(sc.TraceID[:], []byte(value[3:35]))
+	hex.Decode(sc.SpanID[:], []byte(value[36:52]))
+	sc.Sampled = flags[0]&1 == 1
+	return sc, sc.IsValid()
+}
+
+func lowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// Extract reads the trace context of an incoming request; the zero SpanContext when there is none.
+func Extract(header http.Header) SpanContext {
+	sc, ok := ParseTraceparent(header.Get("Traceparent"))
+	if !ok {
+		return SpanContext{}
+	}
+	sc.State = strings.Join(header.Values("Tracestate"), ",")
+	return sc
+}
+
+// Inject writes the trace context ctx carries into outgoing headers. Without one it does nothing.
+func Inject(ctx context.Context, header http.Header) {
+	span := FromContext(ctx)
+	if span == nil {
+		return
+	}
+	header.Set("Traceparent", span.context.Traceparent())
+	if span.context.State != "" {
+		header.Set("Tracestate", span.context.State)
+	} else {
+		header.Del("Tracestate")
+	}
+}
+
+// Kind is the OTLP span kind.
+type Kind int
+
+const (
+	KindInternal Kind = 1
+	KindServer   Kind = 2
+	KindClient   Kind = 3
+	KindProducer Kind = 4
+	KindConsumer Kind = 5
+)
+
+// Attribute is one span attribute. Values are strings, bools, integers or floats.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// Span is one timed operation.
+type Span struct {
+	tracer  *Tracer
+	context SpanContext
+	parent  SpanID
+	name    string
+	kind    Kind
+	start   time.Time
+
+	mu      sync.Mutex
+	end     time.Time
+	attrs   []Attribute
+	failed  bool
+	message string
+	ended   bool
+}
+
+// Context is the span's identity, for propagation.
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.context
+}
+
+// Recording reports whether the span will be exported.
+func (s *Span) Recording() bool { return s != nil && s.tracer != nil && s.context.Sampled }
+
+// SetAttribute records a key/value on the span.
+func (s *Span) SetAttribute(key string, value any) {
+	if !s.Recording() {
+		return
+	}
+	s.mu.Lock()
+	s.attrs = append(s.attrs, Attribute{key, value})
+	s.mu.Unlock()
+}
+
+// Fail marks the span as an error.
+func (s *Span) Fail(message string) {
+	if !s.Recording() {
+		return
+	}
+	s.mu.Lock()
+	s.failed, s.message = true, message
+	s.mu.Unlock()
+}
+
+// End finishes the span and hands it to the tracer's exporter. Only the first call counts.
+func (s *Span) End() {
+	if !s.Recording() {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended, s.end = true, time.Now()
+	s.mu.Unlock()
+	s.tracer.enqueue(s)
+}
+
+type spanKey struct{}
+
+// WithSpan returns ctx carrying span as the current span.
+func WithSpan(ctx context.Context, span *Span) context.Context {
+	if span == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// FromContext is the current span of ctx, or nil.
+func FromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// Start begins a child of the span ctx carries. Outside a trace it returns ctx and a nil span.
+func Start(ctx context.Context, name string, kind Kind) (context.Context, *Span) {
+	parent := FromContext(ctx)
+	if parent == nil {
+		return ctx, nil
+	}
+	span := &Span{
+		tracer: parent.tracer,
+		context: SpanContext{
+			TraceID: parent.context.TraceID,
+			SpanID:  newSpanID(),
+			Sampled: parent.context.Sampled,
+			State:   parent.context.State,
+		},
+		parent: parent.context.SpanID,
+		name:   name,
+		kind:   kind,
+		start:  time.Now(),
+	}
+	return WithSpan(ctx, span), span
+}
+
+func newSpanID() (id SpanID) {
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return id
+}
+
+func newTraceID() (id TraceID) {
+	for !id.IsValid() {
+		rand.Read(id[:])
+	}
+	return id
+}
@@ -0,0 +1,173 @@
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTraceparent(t *testing.T) {
+	const valid = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, ok := ParseTraceparent(valid)
+	if !ok || !sc.Sampled || sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.Traceparent() != valid {
+		t.Fatalf("ParseTraceparent(%q) = %+v, %v", valid, sc, ok)
+	}
+	if sc, ok := ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-later"); !ok || sc.Sampled {
+		t.Errorf("a later version with extra fields should parse: %+v %v", sc, ok)
+	}
+	for _, bad := range []string{
+		"", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-x",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+	} {
+		if _, ok := ParseTraceparent(bad); ok {
+			t.Errorf("ParseTraceparent(%q) should fail", bad)
+		}
+	}
+
+	header := http.Header{}
+	header.Set("Traceparent", valid)
+	header.Add("Tracestate", "vendor=a")
+	header.Add("Tracestate", "other=b")
+	tracer := NewTracer(ExporterFunc(func([]byte) error { return nil }), Options{})
+	defer tracer.Close()
+	ctx, span := tracer.Begin(context.Background(), "GET", KindServer, Extract(header), "example.com")
+	_, child := Start(ctx, "fetch", KindClient)
+	out := http.Header{}
+	Inject(WithSpan(ctx, child), out)
+	got, _ := ParseTraceparent(out.Get("Traceparent"))
+	if got.TraceID != sc.TraceID || got.SpanID != child.Context().SpanID || child.parent != span.Context().SpanID ||
+		span.parent != sc.SpanID || out.Get("Tracestate") != "vendor=a,other=b" {
+		t.Fatalf("propagation: %v %q", out, out.Get("Tracestate"))
+	}
+}
+
+// ExporterFunc adapts a function for tests.
+type ExporterFunc func([]byte) error
+
+func (f ExporterFunc) Export(payload []byte) error { return f(payload) }
+
+func TestSamplingPerSite(t *testing.T) {
+	tracer := NewTracer(ExporterFunc(func([]byte) error { return nil }), Options{SampleRate: 1})
+	defer tracer.Close()
+	tracer.SetSampling("quiet.example", 0)
+	tracer.SetSampling("half.example", 0.5)
+	count := func(site string) (n int) {
+		for range 1000 {
+			if _, span := tracer.Begin(context.Background(), "GET", KindServer, SpanContext{}, site); span.Recording() {
+				n++
+			}
+		}
+		return n
+	}
+	if n := count("busy.example"); n != 1000 {
+		t.Errorf("default rate recorded %d/1000", n)
+	}
+	if n := count("quiet.example"); n != 0 {
+		t.Errorf("rate 0 recorded %d", n)
+	}
+	if n := count("half.example"); n < 400 || n > 600 {
+		t.Errorf("rate 0.5 recorded %d/1000", n)
+	}
+	// A sampled parent is followed whatever the site's rate; the context still propagates unsampled.
+	parent := SpanContext{TraceID: newTraceID(), SpanID: newSpanID(), Sampled: true}
+	if _, span := tracer.Begin(context.Background(), "GET", KindServer, parent, "quiet.example"); !span.Recording() {
+		t.Error("a sampled parent should be followed")
+	}
+	_, span := tracer.Begin(context.Background(), "GET", KindServer, SpanContext{}, "quiet.example")
+	if !span.Context().IsValid() || span.Recording() {
+		t.Error("an unsampled trace should still have ids")
+	}
+}
+
+// A local collector stand-in receives batched OTLP/JSON.
+func TestHTTPExportAndFile(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" || r.Header.Get("X-Api-Key") != "k" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		bodies = append(bodies, string(body))
+	}))
+	defer collector.Close()
+	tracer := NewTracer(NewHTTPExporter(collector.URL, map[string]string{"X-Api-Key": "k"}), Options{ServiceName: "edge", FlushInterval: time.Hour})
+	ctx, root := tracer.Begin(context.Background(), "GET", KindServer, SpanContext{}, "example.com")
+	root.SetAttribute("http.response.status_code", 500)
+	_, child := Start(ctx, "db.query", KindClient)
+	child.SetAttribute("db.statement", "SELECT 1")
+	child.Fail("boom")
+	child.End()
+	root.End()
+	root.End()
+	if err := tracer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(bodies) != 1 {
+		t.Fatalf("exports = %d", len(bodies))
+	}
+	var doc struct {
+		ResourceSpans []struct {
+			Resource struct {
+				Attributes []struct {
+					Key   string
+					Value map[string]any
+				}
+			}
+			ScopeSpans []struct {
+				Spans []struct {
+					TraceID, SpanID, ParentSpanID, Name string
+					Kind                                int
+					Attributes                          []struct {
+						Key   string
+						Value map[string]any
+					}
+					Status struct {
+						Code    int
+						Message string
+					}
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal([]byte(bodies[0]), &doc); err != nil {
+		t.Fatal(err)
+	}
+	resource := doc.ResourceSpans[0]
+	spans := resource.ScopeSpans[0].Spans
+	if resource.Resource.Attributes[0].Value["stringValue"] != "edge" || len(spans) != 2 {
+		t.Fatalf("document: %s", bodies[0])
+	}
+	db, get := spans[0], spans[1]
+	if db.Name != "db.query" || db.Kind != 3 || db.ParentSpanID != get.SpanID || db.TraceID != get.TraceID ||
+		db.Status.Code != 2 || db.Status.Message != "boom" || get.ParentSpanID != "" ||
+		get.Attributes[0].Value["intValue"] != "500" {
+		t.Fatalf("spans: %s", bodies[0])
+	}
+
+	path := filepath.Join(t.TempDir(), "traces", "spans.jsonl")
+	fileTracer := NewTracer(NewFileExporter(path), Options{})
+	_, span := fileTracer.Begin(context.Background(), "cron report", KindInternal, SpanContext{}, "")
+	span.End()
+	if err := fileTracer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 1 || !strings.Contains(lines[0], `"name":"cron report"`) {
+		t.Fatalf("file export: %q", data)
+	}
+}
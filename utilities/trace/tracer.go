@@ -0,0 +1,210 @@
+package trace
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+	"time"
+)
+
+// Options tune a Tracer. Zero values pick the defaults.
+type Options struct {
+	ServiceName   string        // resource service.name; default "kitwork"
+	SampleRate    float64       // share of new traces recorded, 0..1; default 1 (negative: 0)
+	BatchSize     int           // spans per export; default 256
+	FlushInterval time.Duration // longest a span waits for export; default 2s
+	MaxQueue      int           // spans buffered before new ones are dropped; default 8192
+}
+
+// Tracer starts traces, samples them per site and exports finished spans in batches.
+type Tracer struct {
+	exporter Exporter
+	options  Options
+
+	mu      sync.Mutex
+	rates   map[string]float64
+	pending []*Span
+	dropped uint64
+	closed  bool
+
+	exportMu sync.Mutex
+	wake     chan struct{}
+	done     chan struct{}
+	stopped  chan struct{}
+}
+
+// NewTracer starts a tracer exporting through exporter.
+func NewTracer(exporter Exporter, options Options) *Tracer {
+	if options.ServiceName == "" {
+		options.ServiceName = "kitwork"
+	}
+	if options.SampleRate == 0 {
+		options.SampleRate = 1
+	}
+	options.SampleRate = clampRate(options.SampleRate)
+	if options.BatchSize <= 0 {
+		options.BatchSize = 256
+	}
+	if options.FlushInterval <= 0 {
+		options.FlushInterval = 2 * time.Second
+	}
+	if options.MaxQueue <= 0 {
+		options.MaxQueue = 8192
+	}
+	t := &Tracer{
+		exporter: exporter,
+		options:  options,
+		rates:    map[string]float64{},
+		wake:     make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go t.loop()
+	return t
+}
+
+func clampRate(rate float64) float64 {
+	return min(max(rate, 0), 1)
+}
+
+// SetSampling sets the share of new traces recorded for one site, overriding the default rate.
+// A negative rate restores the default. On a nil tracer it does nothing.
+func (t *Tracer) SetSampling(site string, rate float64) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if rate < 0 {
+		delete(t.rates, site)
+		return
+	}
+	t.rates[site] = clampRate(rate)
+}
+
+// sampled decides a new trace: its id's low 8 bytes, read as a fraction, against the site's rate —
+// the same answer every node would give for that id.
+func (t *Tracer) sampled(site string, id TraceID) bool {
+	t.mu.Lock()
+	rate, ok := t.rates[site]
+	t.mu.Unlock()
+	if !ok {
+		rate = t.options.SampleRate
+	}
+	if rate >= 1 {
+		return true
+	}
+	return float64(binary.BigEndian.Uint64(id[8:])>>11)/(1<<53) < rate
+}
+
+// Begin starts the top span of a unit of work for site: a child of remote when that is valid
+// (keeping its sampling decision), else the root of a new trace sampled at the site's rate.
+func (t *Tracer) Begin(ctx context.Context, name string, kind Kind, remote SpanContext, site string) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+	span := &Span{tracer: t, name: name, kind: kind, start: time.Now()}
+	if remote.IsValid() {
+		span.context = SpanContext{TraceID: remote.TraceID, SpanID: newSpanID(), Sampled: remote.Sampled, State: remote.State}
+		span.parent = remote.SpanID
+	} else {
+		id := newTraceID()
+		span.context = SpanContext{TraceID: id, SpanID: newSpanID(), Sampled: t.sampled(site, id)}
+	}
+	return WithSpan(ctx, span), span
+}
+
+// Dropped counts spans discarded because the export queue was full.
+func (t *Tracer) Dropped() uint64 {
+	if t == nil {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.dropped
+}
+
+func (t *Tracer) enqueue(span *Span) {
+	t.mu.Lock()
+	if t.closed || len(t.pending) >= t.options.MaxQueue {
+		t.dropped++
+		t.mu.Unlock()
+		return
+	}
+	t.pending = append(t.pending, span)
+	full := len(t.pending) >= t.options.BatchSize
+	t.mu.Unlock()
+	if full {
+		select {
+		case t.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (t *Tracer) loop() {
+	defer close(t.stopped)
+	ticker := time.NewTicker(t.options.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.done:
+			return
+		case <-ticker.C:
+		case <-t.wake:
+		}
+		t.Flush()
+	}
+}
+
+// Flush exports every finished span now. Export errors are returned; those spans are not retried.
+func (t *Tracer) Flush() error {
+	if t == nil {
+		return nil
+	}
+	t.exportMu.Lock()
+	defer t.exportMu.Unlock()
+	var firstErr error
+	for {
+		t.mu.Lock()
+		batch := t.pending[:min(len(t.pending), t.options.BatchSize)]
+		t.pending = t.pending[len(batch):]
+		if len(t.pending) == 0 {
+			t.pending = nil
+		}
+		t.mu.Unlock()
+		if len(batch) == 0 {
+			return firstErr
+		}
+		payload, err := Encode(t.options.ServiceName, batch)
+		if err == nil {
+			err = t.exporter.Export(payload)
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+}
+
+// Close stops the background exporter after a last flush. Spans ended afterwards are dropped.
+func (t *Tracer) Close() error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil
+	}
+	t.closed = true
+	t.mu.Unlock()
+	close(t.done)
+	<-t.stopped
+	err := t.Flush()
+	if closer, ok := t.exporter.(interface{ Close() error }); ok {
+		if closeErr := closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
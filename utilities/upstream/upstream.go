@@ -0,0 +1,283 @@
+// Package upstream is a pool of interchangeable origins for router.proxy. It picks one per attempt
+// (round robin or least connections) and keeps each one's health two ways: passively, from how the
+// requests sent to it went, and actively, from a periodic health check. An upstream that fails
+// Threshold requests in a row trips its circuit breaker: it gets no traffic for Cooldown, then one
+// trial request (half-open) decides whether it closes again.
+//
+// It is pure: the caller fetches and reports the outcome with Done; the health check is a function
+// the caller supplies, so the SSRF-guarded client stays the only way out.
+package upstream
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	RoundRobin = "round_robin"
+	LeastConn  = "least_conn"
+)
+
+// Breaker states, as reported by Status.
+const (
+	Closed   = "closed"
+	Open     = "open"
+	HalfOpen = "half_open"
+)
+
+// Defaults for the zero Options fields.
+const (
+	DefaultInterval  = 10 * time.Second
+	DefaultThreshold = 3
+	DefaultCooldown  = 30 * time.Second
+)
+
+// ErrUnavailable is Pick's answer when every upstream is down, tripped, or already tried.
+var ErrUnavailable = errors.New("no upstream available")
+
+// Options configure a Pool.
+type Options struct {
+	Strategy    string        // RoundRobin (default) or LeastConn
+	HealthCheck string        // path probed on every upstream each Interval; "" = passive health only
+	Interval    time.Duration // between active checks
+	Threshold   int           // consecutive failures that open the breaker
+	Cooldown    time.Duration // how long an open breaker sheds traffic before a trial request
+}
+
+// Upstream is one origin of a pool.
+type Upstream struct {
+	URL string
+
+	mu        sync.Mutex
+	down      bool // the last active check failed
+	state     string
+	failures  int // consecutive
+	openedAt  time.Time
+	probing   bool // the half-open trial request is in flight
+	lastError string
+
+	inflight  atomic.Int64
+	successes atomic.Uint64
+	failed    atomic.Uint64
+}
+
+// Status is one upstream's point-in-time report.
+type Status struct {
+	URL       string `json:"url"`
+	Up        bool   `json:"up"`      // would be picked now
+	Healthy   bool   `json:"healthy"` // the last active check passed (true without checks)
+	State     string `json:"state"`   // breaker: closed, open or half_open
+	Inflight  int64  `json:"inflight"`
+	Successes uint64 `json:"successes"`
+	Failures  uint64 `json:"failures"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// Pool is a set of upstreams serving one route.
+type Pool struct {
+	opts      Options
+	upstreams []*Upstream
+	next      atomic.Uint64
+	now       func() time.Time
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// New builds a pool over absolute http(s) URLs.
+func New(urls []string, opts Options) (*Pool, error) {
+	if len(urls) == 0 {
+		return nil, fmt.Errorf("proxy: upstreams is empty")
+	}
+	switch opts.Strategy {
+	case "":
+		opts.Strategy = RoundRobin
+	case RoundRobin, LeastConn:
+	default:
+		return nil, fmt.Errorf("proxy: strategy must be %s or %s, got %q", RoundRobin, LeastConn, opts.Strategy)
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultInterval
+	}
+	if opts.Threshold < 1 {
+		opts.Threshold = DefaultThreshold
+	}
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = DefaultCooldown
+	}
+	if opts.HealthCheck != "" && !strings.HasPrefix(opts.HealthCheck, "/") {
+		opts.HealthCheck = "/" + opts.HealthCheck
+	}
+	p := &Pool{opts: opts, now: time.Now, stop: make(chan struct{}), done: make(chan struct{})}
+	for _, raw := range urls {
+		parsed, err := url.Parse(strings.TrimSpace(raw))
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, fmt.Errorf("proxy: upstream %q is not an absolute http(s) url", raw)
+		}
+		p.upstreams = append(p.upstreams, &Upstream{URL: strings.TrimRight(parsed.String(), "/"), state: Closed})
+	}
+	return p, nil
+}
+
+// Pick chooses the upstream for the next attempt, skipping the ones in tried, and counts the
+// attempt as in flight: report it with Done. A half-open upstream is handed out to one caller only.
+func (p *Pool) Pick(tried []*Upstream) (*Upstream, error) {
+	now := p.now()
+	n := len(p.upstreams)
+	start := int(p.next.Add(1)-1) % n
+	for range n { // a lost half-open race makes the candidate ineligible; at most n rounds
+		var chosen *Upstream
+		for i := range n {
+			u := p.upstreams[(start+i)%n]
+			if !u.eligible(now, p.opts.Cooldown) || slices.Contains(tried, u) {
+				continue
+			}
+			if p.opts.Strategy == RoundRobin {
+				chosen = u
+				break
+			}
+			if chosen == nil || u.inflight.Load() < chosen.inflight.Load() {
+				chosen = u
+			}
+		}
+		if chosen == nil {
+			return nil, ErrUnavailable
+		}
+		if chosen.admit(now, p.opts.Cooldown) {
+			chosen.inflight.Add(1)
+			return chosen, nil
+		}
+	}
+	return nil, ErrUnavailable
+}
+
+// Done reports how an attempt on u went: err is nil on success, else why it failed — a transport
+// error or a 5xx. Failures count toward the breaker; a half-open trial decides it.
+func (p *Pool) Done(u *Upstream, err error) {
+	u.inflight.Add(-1)
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if err == nil {
+		u.successes.Add(1)
+		u.failures, u.state, u.probing = 0, Closed, false
+		return
+	}
+	u.failed.Add(1)
+	u.failures++
+	u.lastError = err.Error()
+	if u.state == HalfOpen || u.failures >= p.opts.Threshold {
+		u.state, u.openedAt, u.probing = Open, p.now(), false
+	}
+}
+
+// Start runs the active health check every Interval until Close: check gets each upstream's
+// health URL and returns nil when it answered healthy. Without Options.HealthCheck it does nothing.
+func (p *Pool) Start(check func(url string) error) {
+	if p.opts.HealthCheck == "" || check == nil {
+		close(p.done)
+		return
+	}
+	go func() {
+		defer close(p.done)
+		ticker := time.NewTicker(p.opts.Interval)
+		defer ticker.Stop()
+		for {
+			var wg sync.WaitGroup
+			for _, u := range p.upstreams {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					err := check(u.URL + p.opts.HealthCheck)
+					u.mu.Lock()
+					u.down = err != nil
+					if err != nil {
+						u.lastError = "health check: " + err.Error()
+					}
+					u.mu.Unlock()
+				}()
+			}
+			wg.Wait()
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Close stops the health checks and waits for a check in progress. Safe to call more than once.
+func (p *Pool) Close() {
+	if p == nil {
+		return
+	}
+	p.stopOnce.Do(func() { close(p.stop) })
+	<-p.done
+}
+
+// Status reports every upstream, in declaration order.
+func (p *Pool) Status() []Status {
+	now := p.now()
+	out := make([]Status, len(p.upstreams))
+	for i, u := range p.upstreams {
+		u.mu.Lock()
+		out[i] = Status{
+			URL:       u.URL,
+			Healthy:   !u.down,
+			State:     u.state,
+			LastError: u.lastError,
+		}
+		u.mu.Unlock()
+		out[i].Up = u.eligible(now, p.opts.Cooldown)
+		out[i].Inflight = u.inflight.Load()
+		out[i].Successes = u.successes.Load()
+		out[i].Failures = u.failed.Load()
+	}
+	return out
+}
+
+// eligible reports whether u could take a request now, without claiming anything.
+func (u *Upstream) eligible(now time.Time, cooldown time.Duration) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.down {
+		return false
+	}
+	switch u.state {
+	case Open:
+		return now.Sub(u.openedAt) >= cooldown
+	case HalfOpen:
+		return !u.probing
+	}
+	return true
+}
+
+// admit claims u for one request: an open breaker past its cooldown turns half-open and lets this
+// request through as its trial; false when another caller got there first.
+func (u *Upstream) admit(now time.Time, cooldown time.Duration) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.down {
+		return false
+	}
+	switch u.state {
+	case Open:
+		if now.Sub(u.openedAt) < cooldown {
+			return false
+		}
+		u.state, u.probing = HalfOpen, true
+	case HalfOpen:
+		if u.probing {
+			return false
+		}
+		u.probing = true
+	}
+	return true
+}
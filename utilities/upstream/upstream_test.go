@@ -0,0 +1,152 @@
+package upstream
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+var errBoom = errors.New("502 from upstream")
+
+func pickURL(t *testing.T, p *Pool, tried ...*Upstream) *Upstream {
+	t.Helper()
+	u, err := p.Pick(tried)
+	if err != nil {
+		t.Fatalf("Pick: %v", err)
+	}
+	return u
+}
+
+// Round robin takes turns and skips the upstreams already tried; least_conn prefers the one with
+// the fewest requests in flight.
+func TestPickStrategies(t *testing.T) {
+	p, err := New([]string{"http://a.example/", "http://b.example", "http://c.example"}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var order []string
+	for range 4 {
+		u := pickURL(t, p)
+		order = append(order, u.URL)
+		p.Done(u, nil)
+	}
+	if order[0] != "http://a.example" || order[1] != "http://b.example" || order[3] != order[0] {
+		t.Fatalf("round robin order %v", order)
+	}
+	a, b, c := p.upstreams[0], p.upstreams[1], p.upstreams[2]
+	if u := pickURL(t, p, a, b); u != c {
+		t.Fatalf("tried a and b, got %s", u.URL)
+	}
+	p.Done(c, nil)
+	if _, err := p.Pick([]*Upstream{a, b, c}); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("all tried: %v", err)
+	}
+
+	lc, _ := New([]string{"http://a.example", "http://b.example"}, Options{Strategy: LeastConn})
+	busy := pickURL(t, lc)
+	for range 3 {
+		if u := pickURL(t, lc); u == busy {
+			t.Fatalf("least_conn picked the busy upstream %s", u.URL)
+		} else {
+			lc.Done(u, nil)
+		}
+	}
+
+	for _, bad := range [][]string{nil, {"ftp://a.example"}, {"/relative"}} {
+		if _, err := New(bad, Options{}); err == nil {
+			t.Fatalf("New(%v) accepted", bad)
+		}
+	}
+	if _, err := New([]string{"http://a.example"}, Options{Strategy: "random"}); err == nil {
+		t.Fatal("unknown strategy accepted")
+	}
+}
+
+// Threshold failures in a row open the breaker; after the cooldown one trial request is let through,
+// and its outcome closes the breaker or opens it for another cooldown.
+func TestBreaker(t *testing.T) {
+	now := time.Unix(1_760_000_000, 0)
+	p, _ := New([]string{"http://a.example", "http://b.example"}, Options{Threshold: 2, Cooldown: time.Minute})
+	p.now = func() time.Time { return now }
+	a, b := p.upstreams[0], p.upstreams[1]
+
+	for range 2 {
+		p.Done(pickURL(t, p, b), errBoom)
+	}
+	if status := p.Status()[0]; status.State != Open || status.Up || status.Failures != 2 {
+		t.Fatalf("after two failures: %+v", status)
+	}
+	for range 3 {
+		if u := pickURL(t, p); u != b {
+			t.Fatalf("open breaker still picked: %s", u.URL)
+		} else {
+			p.Done(u, nil)
+		}
+	}
+
+	now = now.Add(time.Minute)
+	trial := pickURL(t, p, b)
+	if trial != a || p.Status()[0].State != HalfOpen {
+		t.Fatalf("after cooldown: %s %+v", trial.URL, p.Status()[0])
+	}
+	if _, err := p.Pick([]*Upstream{b}); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("second half-open trial: %v", err)
+	}
+	p.Done(trial, errBoom)
+	if p.Status()[0].State != Open {
+		t.Fatalf("failed trial: %+v", p.Status()[0])
+	}
+
+	now = now.Add(time.Minute)
+	p.Done(pickURL(t, p, b), nil)
+	if status := p.Status()[0]; status.State != Closed || !status.Up {
+		t.Fatalf("passed trial: %+v", status)
+	}
+}
+
+// The active check marks an upstream down until a later check passes.
+func TestHealthCheck(t *testing.T) {
+	p, _ := New([]string{"http://a.example", "http://b.example"}, Options{HealthCheck: "healthz", Interval: 5 * time.Millisecond})
+	var mu sync.Mutex
+	healthy := map[string]bool{"http://a.example/healthz": false, "http://b.example/healthz": true}
+	checked := make(chan struct{}, 64)
+	p.Start(func(url string) error {
+		defer func() { checked <- struct{}{} }()
+		mu.Lock()
+		defer mu.Unlock()
+		if !healthy[url] {
+			return errBoom
+		}
+		return nil
+	})
+	defer p.Close()
+	waitChecks := func() {
+		for range 4 { // two rounds: the state after a full round is settled
+			<-checked
+		}
+	}
+
+	waitChecks()
+	for range 3 {
+		if u := pickURL(t, p); u.URL != "http://b.example" {
+			t.Fatalf("picked the unhealthy upstream %s", u.URL)
+		} else {
+			p.Done(u, nil)
+		}
+	}
+	if status := p.Status()[0]; status.Healthy || status.Up || status.LastError == "" {
+		t.Fatalf("unhealthy status: %+v", status)
+	}
+
+	mu.Lock()
+	healthy["http://a.example/healthz"] = true
+	mu.Unlock()
+	waitChecks()
+	waitChecks()
+	if status := p.Status()[0]; !status.Healthy || !status.Up {
+		t.Fatalf("recovered status: %+v", status)
+	}
+	p.Close()
+	p.Close()
+}
@@ -0,0 +1,83 @@
+package webhook
+
+import (
+	"database/sql"
+	"time"
+)
+
+// SeenStore remembers the delivery ids an app has accepted, so a captured delivery replayed inside
+// its tolerance window is refused. The PRIMARY KEY is the arbiter: of two nodes racing on the same
+// delivery, exactly one INSERT creates the row. Two implementations, like the cron and queue
+// stores: per-app SQLite for a single node, shared Postgres for many.
+type SeenStore interface {
+	InitSchema() error
+	// Remember records id for identity until the given time, and reports whether it is new —
+	// false means the delivery was already received.
+	Remember(identity, id string, until time.Time) (bool, error)
+	// Forget drops id again: the delivery was not processed, so the sender's retry must be let in.
+	Forget(identity, id string) error
+}
+
+// SqliteSeen is a SQLite SeenStore.
+type SqliteSeen struct{ DB *sql.DB }
+
+func (s *SqliteSeen) InitSchema() error {
+	_, err := s.DB.Exec(`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		identity   TEXT NOT NULL,
+		id         TEXT NOT NULL,
+		expires_at INTEGER NOT NULL,
+		PRIMARY KEY (identity, id)
+	)`)
+	return err
+}
+
+func (s *SqliteSeen) Remember(identity, id string, until time.Time) (bool, error) {
+	if _, err := s.DB.Exec(`DELETE FROM webhook_deliveries WHERE identity=? AND expires_at<?`,
+		identity, time.Now().Unix()); err != nil {
+		return false, err
+	}
+	res, err := s.DB.Exec(`INSERT OR IGNORE INTO webhook_deliveries (identity, id, expires_at) VALUES (?, ?, ?)`,
+		identity, id, until.Unix())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (s *SqliteSeen) Forget(identity, id string) error {
+	_, err := s.DB.Exec(`DELETE FROM webhook_deliveries WHERE identity=? AND id=?`, identity, id)
+	return err
+}
+
+// PgSeen is a shared Postgres SeenStore.
+type PgSeen struct{ DB *sql.DB }
+
+func (s *PgSeen) InitSchema() error {
+	_, err := s.DB.Exec(`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+		identity   TEXT NOT NULL,
+		id         TEXT NOT NULL,
+		expires_at BIGINT NOT NULL,
+		PRIMARY KEY (identity, id)
+	)`)
+	return err
+}
+
+func (s *PgSeen) Remember(identity, id string, until time.Time) (bool, error) {
+	if _, err := s.DB.Exec(`DELETE FROM webhook_deliveries WHERE identity=$1 AND expires_at<$2`,
+		identity, time.Now().Unix()); err != nil {
+		return false, err
+	}
+	res, err := s.DB.Exec(`INSERT INTO webhook_deliveries (identity, id, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (identity, id) DO NOTHING`, identity, id, until.Unix())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (s *PgSeen) Forget(identity, id string) error {
+	_, err := s.DB.Exec(`DELETE FROM webhook_deliveries WHERE identity=$1 AND id=$2`, identity, id)
+	return err
+}
@@ -0,0 +1,289 @@
+// Package webhook verifies signed webhook deliveries: an HMAC of the raw request body (and, for
+// most senders, a timestamp and a delivery id) carried in a header. It is pure — it knows nothing
+// about tenants or the VM; the runtime hands it the headers and the exact bytes that arrived, and
+// keeps the ids it has seen in a SeenStore.
+//
+// Schemes are plain HMACs ("hmac-sha256", "hmac-sha1", "hmac-sha512") over a template of the
+// delivery, or a preset for a sender's format:
+//
+//	stripe    Stripe-Signature: t=<unix>,v1=<hex>[,v1=<hex>]   signs "<t>.<body>"
+//	github    X-Hub-Signature-256: sha256=<hex>                 signs the body; id X-GitHub-Delivery
+//	standard  webhook-id, webhook-timestamp, webhook-signature: v1,<base64> ...
+//	          (Standard Webhooks)                               signs "<id>.<timestamp>.<body>"
+//	slack     X-Slack-Signature: v0=<hex>                       signs "v0:<timestamp>:<body>"
+//
+// Every comparison is constant-time over the decoded MAC.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTolerance is how far a delivery's timestamp may be from the clock.
+const DefaultTolerance = 5 * time.Minute
+
+// DefaultRemember is how long the id of a delivery WITHOUT a timestamp is remembered for replay
+// protection. A timestamped delivery is remembered until its tolerance window closes.
+const DefaultRemember = 24 * time.Hour
+
+var (
+	ErrNoSecret  = errors.New("no webhook secret configured")
+	ErrMissing   = errors.New("webhook signature header missing")
+	ErrMismatch  = errors.New("webhook signature mismatch")
+	ErrTimestamp = errors.New("webhook timestamp missing or malformed")
+	ErrStale     = errors.New("webhook timestamp outside the tolerance window")
+	ErrReplay    = errors.New("webhook delivery already received")
+)
+
+// Options describe how a sender signs. A preset Scheme fills the rest; any field set here wins.
+type Options struct {
+	Scheme          string   // "hmac-sha256" (default), "hmac-sha1", "hmac-sha512", "stripe", "github", "standard", "slack"
+	Header          string   // the signature header
+	Secrets         []string // accepted secrets; more than one lets a rotation overlap
+	Prefix          string   // stripped from the signature: "sha256=", "v0="
+	Encoding        string   // "hex" or "base64"; "" accepts either
+	TimestampHeader string   // unix seconds of the delivery; checked only when Template signs {timestamp}
+	IDHeader        string   // the delivery id; the replay key only when Template signs {id}
+	Template        string   // what is signed: {id}, {timestamp} and {body} are filled in; default "{body}"
+	Tolerance       time.Duration
+	Remember        time.Duration
+	format          string // "" plain, "stripe" or "standard": how the signature header is laid out
+	hash            func() hash.Hash
+}
+
+// Result is a verified delivery.
+type Result struct {
+	ID        string    // the delivery id, or Key when the sender sends none
+	Key       string    // the replay key: the id when it is signed, else a digest of the matched MAC
+	Timestamp time.Time // zero unless the timestamp is signed
+	Until     time.Time // until when the id must be remembered to refuse a replay
+}
+
+func preset(scheme string) (Options, error) {
+	switch strings.ToLower(scheme) {
+	case "", "hmac-sha256", "sha256":
+		return Options{Scheme: "hmac-sha256", hash: sha256.New}, nil
+	case "hmac-sha1", "sha1":
+		return Options{Scheme: "hmac-sha1", hash: sha1.New}, nil
+	case "hmac-sha512", "sha512":
+		return Options{Scheme: "hmac-sha512", hash: sha512.New}, nil
+	case "stripe":
+		return Options{Scheme: "stripe", Header: "Stripe-Signature", Encoding: "hex",
+			Template: "{timestamp}.{body}", format: "stripe", hash: sha256.New}, nil
+	case "github":
+		return Options{Scheme: "github", Header: "X-Hub-Signature-256", Prefix: "sha256=", Encoding: "hex",
+			IDHeader: "X-GitHub-Delivery", hash: sha256.New}, nil
+	case "standard", "standard-webhooks", "svix":
+		return Options{Scheme: "standard", Header: "webhook-signature", Encoding: "base64",
+			TimestampHeader: "webhook-timestamp", IDHeader: "webhook-id",
+			Template: "{id}.{timestamp}.{body}", format: "standard", hash: sha256.New}, nil
+	case "slack":
+		return Options{Scheme: "slack", Header: "X-Slack-Signature", Prefix: "v0=", Encoding: "hex",
+			TimestampHeader: "X-Slack-Request-Timestamp", Template: "v0:{timestamp}:{body}", hash: sha256.New}, nil
+	}
+	return Options{}, fmt.Errorf("unknown webhook scheme %q", scheme)
+}
+
+// Resolve fills opts from its scheme's preset and checks it can verify anything.
+func Resolve(opts Options) (Options, error) {
+	base, err := preset(opts.Scheme)
+	if err != nil {
+		return Options{}, err
+	}
+	for _, field := range []struct{ set, from *string }{
+		{&base.Header, &opts.Header}, {&base.Prefix, &opts.Prefix}, {&base.Encoding, &opts.Encoding},
+		{&base.TimestampHeader, &opts.TimestampHeader}, {&base.IDHeader, &opts.IDHeader},
+		{&base.Template, &opts.Template},
+	} {
+		if *field.from != "" {
+			*field.set = *field.from
+		}
+	}
+	base.Secrets = opts.Secrets
+	base.Tolerance, base.Remember = opts.Tolerance, opts.Remember
+	if base.Tolerance <= 0 {
+		base.Tolerance = DefaultTolerance
+	}
+	if base.Remember <= 0 {
+		base.Remember = DefaultRemember
+	}
+	if base.Template == "" {
+		base.Template = "{body}"
+	}
+	if base.Header == "" {
+		return Options{}, fmt.Errorf("webhook scheme %s needs a header", base.Scheme)
+	}
+	if base.Encoding != "" && base.Encoding != "hex" && base.Encoding != "base64" {
+		return Options{}, fmt.Errorf("webhook encoding must be hex or base64, got %q", base.Encoding)
+	}
+	if len(base.Secrets) == 0 {
+		return Options{}, ErrNoSecret
+	}
+	for _, secret := range base.Secrets {
+		if secret == "" {
+			return Options{}, ErrNoSecret
+		}
+	}
+	return base, nil
+}
+
+// Verify checks one delivery against resolved opts (see Resolve) at now. body must be the exact
+// bytes that arrived — a re-encoded JSON body does not verify.
+func Verify(opts Options, header http.Header, body []byte, now time.Time) (Result, error) {
+	raw := strings.TrimSpace(header.Get(opts.Header))
+	if raw == "" {
+		return Result{}, ErrMissing
+	}
+
+	var timestamp string
+	var signatures []string
+	switch opts.format {
+	case "stripe":
+		for _, part := range strings.Split(raw, ",") {
+			key, val, _ := strings.Cut(strings.TrimSpace(part), "=")
+			switch key {
+			case "t":
+				timestamp = val
+			case "v1":
+				signatures = append(signatures, val)
+			}
+		}
+	case "standard":
+		for _, part := range strings.Fields(raw) {
+			if version, sig, ok := strings.Cut(part, ","); ok && version == "v1" {
+				signatures = append(signatures, sig)
+			}
+		}
+	default:
+		signatures = []string{strings.TrimPrefix(raw, opts.Prefix)}
+	}
+	if len(signatures) == 0 {
+		return Result{}, ErrMissing
+	}
+	if opts.TimestampHeader != "" {
+		timestamp = strings.TrimSpace(header.Get(opts.TimestampHeader))
+	}
+	id := ""
+	if opts.IDHeader != "" {
+		id = strings.TrimSpace(header.Get(opts.IDHeader))
+	}
+
+	// Only a signed timestamp bounds the delivery: one merely sent alongside could be reset to now
+	// by whoever replays it, so without {timestamp} in the template the key is kept for Remember.
+	result := Result{ID: id}
+	if strings.Contains(opts.Template, "{timestamp}") {
+		seconds, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return Result{}, ErrTimestamp
+		}
+		result.Timestamp = time.Unix(seconds, 0)
+		if skew := now.Sub(result.Timestamp); skew > opts.Tolerance || skew < -opts.Tolerance {
+			return Result{}, ErrStale
+		}
+		result.Until = result.Timestamp.Add(opts.Tolerance)
+	} else {
+		result.Until = now.Add(opts.Remember)
+	}
+	if strings.Contains(opts.Template, "{id}") && id == "" {
+		return Result{}, fmt.Errorf("webhook id header %s missing", opts.IDHeader)
+	}
+
+	signed := signedContent(opts.Template, id, timestamp, body)
+	for _, secret := range opts.Secrets {
+		key := []byte(secret)
+		if opts.format == "standard" {
+			if encoded, ok := strings.CutPrefix(secret, "whsec_"); ok {
+				if decoded, err := base64.StdEncoding.DecodeString(encoded); err == nil {
+					key = decoded
+				}
+			}
+		}
+		mac := hmac.New(opts.hash, key)
+		mac.Write(signed)
+		expected := mac.Sum(nil)
+		for _, signature := range signatures {
+			if given := decode(signature, opts.Encoding); given != nil && hmac.Equal(given, expected) {
+				// An id outside the signed content can be swapped freely, so it cannot key the
+				// replay check; the MAC itself can, whatever encoding the signature came in.
+				if strings.Contains(opts.Template, "{id}") {
+					result.Key = id
+				} else {
+					digest := sha256.Sum256(expected)
+					result.Key = "sig:" + hex.EncodeToString(digest[:16])
+				}
+				if result.ID == "" {
+					result.ID = result.Key
+				}
+				return result, nil
+			}
+		}
+	}
+	return Result{}, ErrMismatch
+}
+
+// Sign is the signature header value a sender using opts would send for body. Tests and callers
+// that relay deliveries use it; it takes the first secret.
+func Sign(opts Options, id string, timestamp time.Time, body []byte) string {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	key := []byte(opts.Secrets[0])
+	if encoded, ok := strings.CutPrefix(opts.Secrets[0], "whsec_"); ok && opts.format == "standard" {
+		if decoded, err := base64.StdEncoding.DecodeString(encoded); err == nil {
+			key = decoded
+		}
+	}
+	mac := hmac.New(opts.hash, key)
+	mac.Write(signedContent(opts.Template, id, ts, body))
+	sum := mac.Sum(nil)
+	switch opts.format {
+	case "stripe":
+		return "t=" + ts + ",v1=" + hex.EncodeToString(sum)
+	case "standard":
+		return "v1," + base64.StdEncoding.EncodeToString(sum)
+	}
+	if opts.Encoding == "base64" {
+		return opts.Prefix + base64.StdEncoding.EncodeToString(sum)
+	}
+	return opts.Prefix + hex.EncodeToString(sum)
+}
+
+func signedContent(template, id, timestamp string, body []byte) []byte {
+	before, after, found := strings.Cut(template, "{body}")
+	fill := strings.NewReplacer("{id}", id, "{timestamp}", timestamp)
+	out := []byte(fill.Replace(before))
+	if !found {
+		return out
+	}
+	out = append(out, body...)
+	return append(out, fill.Replace(after)...)
+}
+
+// decode reads a signature as hex or base64 (standard or URL alphabet); nil when it is neither.
+func decode(signature, encoding string) []byte {
+	signature = strings.TrimSpace(signature)
+	if encoding != "base64" {
+		if b, err := hex.DecodeString(signature); err == nil {
+			return b
+		}
+		if encoding == "hex" {
+			return nil
+		}
+	}
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+		if b, err := enc.DecodeString(signature); err == nil {
+			return b
+		}
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package webhook_test
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kitwork/engine/utilities/webhook"
+	_ "modernc.org/sqlite"
+)
+
+// Each preset verifies what its sender signs, a tampered body or another secret does not, and a
+// delivery outside the tolerance window is stale even when correctly signed.
+func TestVerifyPresets(t *testing.T) {
+	now := time.Unix(1_760_000_000, 0)
+	body := []byte(`{"type":"payment.succeeded","amount":1200}`)
+	for _, tc := range []struct {
+		scheme, secret string
+		headers        func(signature string) http.Header
+	}{
+		{"stripe", "whsec_stripe", func(sig string) http.Header {
+			return http.Header{"Stripe-Signature": {sig}}
+		}},
+		{"github", "gh-secret", func(sig string) http.Header {
+			return http.Header{"X-Hub-Signature-256": {sig}, "X-Github-Delivery": {"72d3162e"}}
+		}},
+		{"standard", "whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw", func(sig string) http.Header {
+			return http.Header{"Webhook-Signature": {"v1,bm90LXRoaXM= " + sig}, "Webhook-Id": {"msg_2KWPB"},
+				"Webhook-Timestamp": {"1760000000"}}
+		}},
+		{"slack", "slack-secret", func(sig string) http.Header {
+			return http.Header{"X-Slack-Signature": {sig}, "X-Slack-Request-Timestamp": {"1760000000"}}
+		}},
+	} {
+		opts, err := webhook.Resolve(webhook.Options{Scheme: tc.scheme, Secrets: []string{tc.secret}})
+		if err != nil {
+			t.Fatalf("%s: %v", tc.scheme, err)
+		}
+		header := tc.headers(webhook.Sign(opts, "msg_2KWPB", now, body))
+		result, err := webhook.Verify(opts, header, body, now.Add(time.Minute))
+		if err != nil {
+			t.Fatalf("%s: %v", tc.scheme, err)
+		}
+		if result.ID == "" || result.Until.IsZero() {
+			t.Fatalf("%s: result %+v", tc.scheme, result)
+		}
+		if _, err := webhook.Verify(opts, header, append(body, ' '), now); !errors.Is(err, webhook.ErrMismatch) {
+			t.Fatalf("%s: tampered body: %v", tc.scheme, err)
+		}
+		other, _ := webhook.Resolve(webhook.Options{Scheme: tc.scheme, Secrets: []string{"whsec_b3RoZXI="}})
+		if _, err := webhook.Verify(other, header, body, now); !errors.Is(err, webhook.ErrMismatch) {
+			t.Fatalf("%s: wrong secret: %v", tc.scheme, err)
+		}
+		if tc.scheme != "github" {
+			if _, err := webhook.Verify(opts, header, body, now.Add(10*time.Minute)); !errors.Is(err, webhook.ErrStale) {
+				t.Fatalf("%s: stale delivery: %v", tc.scheme, err)
+			}
+		}
+	}
+}
+
+// A plain HMAC reads hex or base64, strips the prefix, accepts any of several secrets during a
+// rotation, and fills the template it was told was signed.
+func TestVerifyPlainHMAC(t *testing.T) {
+	now := time.Now()
+	body := []byte("order=42&status=paid")
+	signer, err := webhook.Resolve(webhook.Options{Header: "X-Signature", Prefix: "sha256=", Encoding: "base64",
+		TimestampHeader: "X-Timestamp", Template: "{timestamp}:{body}", Secrets: []string{"new"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := http.Header{"X-Signature": {webhook.Sign(signer, "", now, body)}, "X-Timestamp": {strconv.FormatInt(now.Unix(), 10)}}
+
+	verifier, _ := webhook.Resolve(webhook.Options{Header: "X-Signature", Prefix: "sha256=",
+		TimestampHeader: "X-Timestamp", Template: "{timestamp}:{body}", Secrets: []string{"old", "new"}})
+	if _, err := webhook.Verify(verifier, header, body, now); err != nil {
+		t.Fatalf("rotated secret: %v", err)
+	}
+	header.Del("X-Signature")
+	if _, err := webhook.Verify(verifier, header, body, now); !errors.Is(err, webhook.ErrMissing) {
+		t.Fatalf("missing header: %v", err)
+	}
+	if _, err := webhook.Resolve(webhook.Options{Header: "X-Signature"}); !errors.Is(err, webhook.ErrNoSecret) {
+		t.Fatalf("no secret: %v", err)
+	}
+	if _, err := webhook.Resolve(webhook.Options{Scheme: "md5", Secrets: []string{"s"}}); err == nil {
+		t.Fatal("unknown scheme resolved")
+	}
+}
+
+// The replay key follows what was signed: the id when the template covers it, else the MAC — so
+// an unsigned delivery id, or the same signature re-encoded, does not make a new key.
+func TestVerifyReplayKey(t *testing.T) {
+	now := time.Now()
+	body := []byte(`{"action":"opened"}`)
+	github, _ := webhook.Resolve(webhook.Options{Scheme: "github", Secrets: []string{"gh-secret"}})
+	signature := webhook.Sign(github, "", now, body)
+	first, err := webhook.Verify(github, http.Header{"X-Hub-Signature-256": {signature}, "X-Github-Delivery": {"d-1"}}, body, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	upper := "sha256=" + strings.ToUpper(strings.TrimPrefix(signature, "sha256="))
+	second, err := webhook.Verify(github, http.Header{"X-Hub-Signature-256": {upper}, "X-Github-Delivery": {"d-2"}}, body, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.ID != "d-1" || second.ID != "d-2" || first.Key != second.Key || !strings.HasPrefix(first.Key, "sig:") {
+		t.Fatalf("unsigned id: %+v / %+v", first, second)
+	}
+
+	standard, _ := webhook.Resolve(webhook.Options{Scheme: "standard", Secrets: []string{"whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw"}})
+	header := http.Header{"Webhook-Signature": {webhook.Sign(standard, "msg_1", now, body)}, "Webhook-Id": {"msg_1"},
+		"Webhook-Timestamp": {strconv.FormatInt(now.Unix(), 10)}}
+	result, err := webhook.Verify(standard, header, body, now)
+	if err != nil || result.Key != "msg_1" {
+		t.Fatalf("signed id: %+v %v", result, err)
+	}
+}
+
+// A timestamp the template does not sign is not trusted: it neither shortens how long the replay
+// key is kept nor makes an old delivery stale.
+func TestVerifyUnsignedTimestamp(t *testing.T) {
+	now := time.Now()
+	body := []byte("order=42")
+	opts, _ := webhook.Resolve(webhook.Options{Header: "X-Signature", TimestampHeader: "X-Timestamp",
+		Secrets: []string{"s"}, Remember: time.Hour})
+	header := http.Header{"X-Signature": {webhook.Sign(opts, "", now, body)},
+		"X-Timestamp": {strconv.FormatInt(now.Add(-time.Hour).Unix(), 10)}}
+	result, err := webhook.Verify(opts, header, body, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Timestamp.IsZero() || !result.Until.Equal(now.Add(time.Hour)) {
+		t.Fatalf("unsigned timestamp trusted: %+v", result)
+	}
+}
+
+// The seen store admits an id once until it expires; identities do not share ids.
+func TestSqliteSeen(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	store := &webhook.SqliteSeen{DB: db}
+	if err := store.InitSchema(); err != nil {
+		t.Fatal(err)
+	}
+	until := time.Now().Add(time.Minute)
+	for _, tc := range []struct {
+		identity, id string
+		until        time.Time
+		fresh        bool
+	}{
+		{"acme", "evt_1", until, true},
+		{"acme", "evt_1", until, false},
+		{"other", "evt_1", until, true},
+		{"acme", "evt_2", time.Now().Add(-time.Minute), true},
+		{"acme", "evt_2", until, true}, // the expired row was pruned first
+	} {
+		fresh, err := store.Remember(tc.identity, tc.id, tc.until)
+		if err != nil || fresh != tc.fresh {
+			t.Fatalf("Remember(%s, %s) = %v, %v; want %v", tc.identity, tc.id, fresh, err, tc.fresh)
+		}
+	}
+}
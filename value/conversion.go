@@ -2,6 +2,7 @@ package value
 
 import (
 	"encoding/json"
+	"fmt"
 	"strconv"
 	"time"
 	"unsafe"
@@ -76,6 +77,13 @@ func (v Value) Append(b []byte) []byte {
 			first = false
 		}
 		return append(b, '}')
+	case Struct:
+		// Host values that know their text form (a zoned time, a lunar date) print it, so
+		// `{{ order.placedAt }}` renders in a template instead of vanishing.
+		if s, ok := v.V.(fmt.Stringer); ok {
+			return append(b, s.String()...)
+		}
+		return b
 	default:
 		return b
 	}
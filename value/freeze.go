@@ -0,0 +1,272 @@
+package value
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+	"weak"
+)
+
+/* =============================================================================
+   FREEZE / CLONE / DEEP EQUAL
+
+   A script object is a Go map and a script array a *[]Value; every Value that
+   refers to one shares it. "Frozen" is therefore a property of that shared
+   identity — not a flag on one Value copy — so `Object.freeze(obj)` also
+   protects every other binding and every cache reader of obj. The registry
+   holds weak pointers: it never keeps a frozen object alive, and an address
+   reused after collection is never mistaken for the frozen object. Every SET
+   checks it, so a lookup takes no lock: only a sweep serializes, and only
+   with other sweeps.
+   ============================================================================= */
+
+var frozen struct {
+	refs    sync.Map     // uintptr → weak.Pointer[byte]
+	size    atomic.Int64 // entries in refs; zero until the first freeze
+	sweepMu sync.Mutex
+	sweep   atomic.Int64 // registry size that triggers the next sweep of collected entries
+}
+
+// identity returns the shared storage behind a Map or Array value.
+func identity(v Value) (unsafe.Pointer, bool) {
+	switch v.K {
+	case Map:
+		if m, ok := v.V.(map[string]Value); ok && m != nil {
+			return reflect.ValueOf(m).UnsafePointer(), true
+		}
+	case Array:
+		if ptr, ok := v.V.(*[]Value); ok && ptr != nil {
+			return unsafe.Pointer(ptr), true
+		}
+	}
+	return nil, false
+}
+
+// IsFrozen reports whether v is an object or array frozen by Freeze.
+func IsFrozen(v Value) bool {
+	if frozen.size.Load() == 0 {
+		return false
+	}
+	ptr, ok := identity(v)
+	if !ok {
+		return false
+	}
+	ref, ok := frozen.refs.Load(uintptr(ptr))
+	return ok && unsafe.Pointer(ref.(weak.Pointer[byte]).Value()) == ptr
+}
+
+// Freeze deep-freezes v in place and returns it: v and every object or array
+// reachable from it reject SET/MERGE and mutating methods from then on.
+// Scalars and host values (structs, functions, proxies) are returned as is.
+func Freeze(v Value) Value {
+	freezeInto(v, make(map[uintptr]bool))
+	return v
+}
+
+func freezeInto(v Value, seen map[uintptr]bool) {
+	ptr, ok := identity(v)
+	if !ok || seen[uintptr(ptr)] {
+		return
+	}
+	seen[uintptr(ptr)] = true
+	markFrozen(ptr)
+	switch v.K {
+	case Map:
+		for _, item := range v.Map() {
+			freezeInto(item, seen)
+		}
+	case Array:
+		for _, item := range v.Array() {
+			freezeInto(item, seen)
+		}
+	}
+}
+
+func markFrozen(ptr unsafe.Pointer) {
+	key := uintptr(ptr)
+	if ref, ok := frozen.refs.Load(key); ok && unsafe.Pointer(ref.(weak.Pointer[byte]).Value()) == ptr {
+		return
+	}
+	if _, replaced := frozen.refs.Swap(key, weak.Make((*byte)(ptr))); !replaced {
+		frozen.size.Add(1)
+	}
+	if frozen.size.Load() >= max(1024, frozen.sweep.Load()) {
+		sweepFrozen()
+	}
+}
+
+// sweepFrozen drops entries whose object was collected; the next sweep waits
+// until the registry doubles, keeping marking amortized O(1).
+func sweepFrozen() {
+	frozen.sweepMu.Lock()
+	defer frozen.sweepMu.Unlock()
+	frozen.refs.Range(func(key, ref any) bool {
+		if ref.(weak.Pointer[byte]).Value() == nil && frozen.refs.CompareAndDelete(key, ref) {
+			frozen.size.Add(-1)
+		}
+		return true
+	})
+	frozen.sweep.Store(2 * frozen.size.Load())
+}
+
+// Mutates reports whether the built-in method name changes its receiver in
+// place — the calls a frozen receiver must refuse.
+func Mutates(k Kind, method string) bool {
+	switch k {
+	case Array:
+		switch method {
+		case "push", "pop", "shift", "unshift", "reverse", "shuffle", "compact", "unique", "sort":
+			return true
+		}
+	case Map:
+		switch method {
+		case "delete", "merge":
+			return true
+		}
+	}
+	return false
+}
+
+// Clone is structuredClone: a deep copy of maps, arrays and bytes that keeps
+// shared and cyclic references shared within the copy. The copy is never
+// frozen. Functions, proxies and host structs cannot be cloned.
+func Clone(v Value) (Value, error) {
+	return cloneInto(v, make(map[uintptr]Value))
+}
+
+func cloneInto(v Value, seen map[uintptr]Value) (Value, error) {
+	switch v.K {
+	case Map:
+		source := v.Map()
+		if source == nil {
+			return v, nil
+		}
+		key := uintptr(reflect.ValueOf(source).UnsafePointer())
+		if copied, ok := seen[key]; ok {
+			return copied, nil
+		}
+		target := make(map[string]Value, len(source))
+		copied := Value{K: Map, V: target}
+		seen[key] = copied
+		for name, item := range source {
+			cloned, err := cloneInto(item, seen)
+			if err != nil {
+				return Value{K: Nil}, err
+			}
+			target[name] = cloned
+		}
+		return copied, nil
+	case Array:
+		ptr, ok := v.V.(*[]Value)
+		if !ok {
+			ptr = &[]Value{}
+			*ptr = v.Array()
+		}
+		key := uintptr(unsafe.Pointer(ptr))
+		if copied, ok := seen[key]; ok {
+			return copied, nil
+		}
+		items := make([]Value, len(*ptr))
+		copied := Value{K: Array, V: &items}
+		seen[key] = copied
+		for index, item := range *ptr {
+			cloned, err := cloneInto(item, seen)
+			if err != nil {
+				return Value{K: Nil}, err
+			}
+			items[index] = cloned
+		}
+		return copied, nil
+	case Bytes:
+		data, _ := v.V.([]byte)
+		return Value{K: Bytes, V: bytes.Clone(data)}, nil
+	case Func, Proxy, Struct, Any:
+		return Value{K: Nil}, fmt.Errorf("DataCloneError: %s value could not be cloned", v.K)
+	default:
+		return v, nil
+	}
+}
+
+// DeepEqual compares two values structurally: same kind, equal scalars, and
+// maps/arrays with deep-equal members (cycles compare by shape). NaN equals
+// NaN, as in assertion libraries; functions and host values equal only
+// themselves.
+func DeepEqual(a, b Value) bool {
+	return deepEqual(a, b, make(map[[2]uintptr]bool))
+}
+
+func deepEqual(a, b Value, seen map[[2]uintptr]bool) bool {
+	if a.K != b.K {
+		return false
+	}
+	switch a.K {
+	case Nil:
+		return true
+	case Invalid:
+		return false
+	case Number, Time, Duration:
+		return a.N == b.N || (math.IsNaN(a.N) && math.IsNaN(b.N))
+	case Bool:
+		return a.Truthy() == b.Truthy()
+	case String:
+		return a.Text() == b.Text()
+	case Bytes:
+		left, _ := a.V.([]byte)
+		right, _ := b.V.([]byte)
+		return bytes.Equal(left, right)
+	case Map, Array:
+		left, leftOK := identity(a)
+		right, rightOK := identity(b)
+		if leftOK && rightOK {
+			if left == right {
+				return true
+			}
+			pair := [2]uintptr{uintptr(left), uintptr(right)}
+			if seen[pair] {
+				return true
+			}
+			seen[pair] = true
+		}
+		if a.K == Map {
+			leftMap, rightMap := a.Map(), b.Map()
+			if len(leftMap) != len(rightMap) {
+				return false
+			}
+			for name, item := range leftMap {
+				other, ok := rightMap[name]
+				if !ok || !deepEqual(item, other, seen) {
+					return false
+				}
+			}
+			return true
+		}
+		leftItems, rightItems := a.Array(), b.Array()
+		if len(leftItems) != len(rightItems) {
+			return false
+		}
+		for index := range leftItems {
+			if !deepEqual(leftItems[index], rightItems[index], seen) {
+				return false
+			}
+		}
+		return true
+	default:
+		return sameReference(a.V, b.V)
+	}
+}
+
+func sameReference(a, b any) bool {
+	left, right := reflect.ValueOf(a), reflect.ValueOf(b)
+	if !left.IsValid() || !right.IsValid() || left.Type() != right.Type() {
+		return !left.IsValid() && !right.IsValid()
+	}
+	switch left.Kind() {
+	case reflect.Pointer, reflect.Func, reflect.Map, reflect.Slice, reflect.Chan, reflect.UnsafePointer:
+		return left.Pointer() == right.Pointer()
+	}
+	return left.Comparable() && right.Comparable() && left.Equal(right)
+}
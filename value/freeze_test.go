@@ -0,0 +1,93 @@
+package value
+
+import (
+	"math"
+	"runtime"
+	"testing"
+)
+
+func TestFreezeIsDeepAndSharedByEveryCopy(t *testing.T) {
+	nested := New([]Value{New(1)})
+	root := New(map[string]Value{"items": nested, "name": New("x")})
+	alias := root // another binding of the same object
+	Freeze(root)
+
+	if !IsFrozen(alias) || !IsFrozen(root.Get("items")) || !IsFrozen(nested) {
+		t.Fatal("freeze must cover every copy of the object and everything reachable from it")
+	}
+	if IsFrozen(New(map[string]Value{})) || IsFrozen(New("x")) {
+		t.Fatal("an unrelated object or a scalar reported frozen")
+	}
+	cyclic := New(map[string]Value{})
+	cyclic.Set("self", cyclic)
+	Freeze(cyclic) // must terminate
+	if !IsFrozen(cyclic.Get("self")) {
+		t.Fatal("cyclic object not frozen")
+	}
+}
+
+func TestFreezeRegistryDoesNotRetainObjects(t *testing.T) {
+	for i := 0; i < 4096; i++ {
+		Freeze(New(map[string]Value{"i": New(i)}))
+	}
+	fresh := New(map[string]Value{})
+	Freeze(fresh)
+	runtime.GC()
+	sweepFrozen()
+	if size := frozen.size.Load(); size >= 4096 {
+		t.Fatalf("registry kept %d entries for collected objects", size)
+	}
+	if !IsFrozen(fresh) {
+		t.Fatal("sweep dropped a live frozen object")
+	}
+}
+
+func TestCloneIsDeepMutableAndKeepsSharing(t *testing.T) {
+	shared := New(map[string]Value{"n": New(1)})
+	source := New(map[string]Value{
+		"a":     shared,
+		"b":     shared,
+		"list":  New([]Value{New("x")}),
+		"bytes": New([]byte("hi")),
+	})
+	Freeze(source)
+	copied, err := Clone(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if IsFrozen(copied) || IsFrozen(copied.Get("list")) {
+		t.Fatal("clone of a frozen value must be mutable")
+	}
+	copied.Get("a").Set("n", New(2))
+	if copied.Get("b").Get("n").N != 2 || shared.Get("n").N != 1 {
+		t.Fatal("clone must keep internal sharing and never touch the source")
+	}
+	if !DeepEqual(source.Get("list"), copied.Get("list")) {
+		t.Fatal("cloned array differs")
+	}
+	if _, err := Clone(New(map[string]Value{"fn": NewFunc(func(...Value) Value { return NULL })})); err == nil {
+		t.Fatal("functions must not be cloneable")
+	}
+}
+
+func TestDeepEqual(t *testing.T) {
+	left := New(map[string]Value{"a": New([]Value{New(1), New("x")}), "nan": New(math.NaN())})
+	right := New(map[string]Value{"a": New([]Value{New(1), New("x")}), "nan": New(math.NaN())})
+	if !DeepEqual(left, right) {
+		t.Fatal("structurally equal values differ")
+	}
+	right.Get("a").Push(New(2))
+	if DeepEqual(left, right) {
+		t.Fatal("different array lengths compared equal")
+	}
+	if DeepEqual(New(1), New("1")) || DeepEqual(NULL, New(0)) {
+		t.Fatal("values of different kinds compared equal")
+	}
+	loop := New(map[string]Value{})
+	loop.Set("self", loop)
+	other := New(map[string]Value{})
+	other.Set("self", other)
+	if !DeepEqual(loop, other) {
+		t.Fatal("same-shaped cycles differ")
+	}
+}
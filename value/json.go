@@ -47,6 +47,11 @@ func (v Value) MarshalJSON() ([]byte, error) {
 		return []byte(`"<function>"`), nil
 	case Proxy:
 		return []byte(`"<proxy>"`), nil
+	case Struct:
+		if marshaler, ok := v.V.(json.Marshaler); ok {
+			return marshaler.MarshalJSON()
+		}
+		return []byte("null"), nil
 	default:
 		return []byte("null"), nil
 	}
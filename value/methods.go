@@ -665,14 +665,20 @@ func (v Value) ArrayFlat(args ...Value) Value {
 	return New(out)
 }
 
-// ArraySort — sort() KHÔNG comparator (có comparator thì VM xử lý vì cần Lambda).
+// ArraySort — sort() KHÔNG comparator, hoặc comparator là hàm host
+// (arr.sort(text.compare)); comparator Lambda thì VM xử lý.
 // LỆCH CHUẨN CÓ CHỦ ĐÍCH: JS mặc định ép phần tử thành chuỗi rồi so sánh
 // ([10, 2].sort() → [10, 2] — footgun nổi tiếng). Kitwork chọn hành vi hợp
 // trực giác: toàn số → xếp theo số tăng dần; còn lại → xếp theo chuỗi.
 // Sắp xếp TẠI CHỖ và trả về chính mảng (giống JS).
-func (v Value) ArraySort(_ ...Value) Value {
+func (v Value) ArraySort(args ...Value) Value {
 	if ptr, ok := v.V.(*[]Value); ok {
 		a := *ptr
+		if len(args) > 0 && args[0].K == Func {
+			compare := args[0]
+			sort.SliceStable(a, func(i, j int) bool { return compare.Call("sort", a[i], a[j]).N < 0 })
+			return v
+		}
 		allNumbers := true
 		for _, item := range a {
 			if item.K != Number {
@@ -1,6 +1,7 @@
 package value
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 )
@@ -184,3 +185,10 @@ type TenantCache interface {
 	DeleteCache(key string)
 	ClearCache()
 }
+
+// ContextBinder is a host handle that is resolved once (a module-level import) but acts for whoever
+// calls it: a database traces each query under the request that ran it. Before a method call the VM
+// swaps the handle for BindContext(vm.Context) — a copy bound to the running execution.
+type ContextBinder interface {
+	BindContext(ctx context.Context) Value
+}
@@ -0,0 +1,163 @@
+package work
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/kitwork/engine/runtime"
+	"github.com/kitwork/engine/utilities/accesslog"
+	"github.com/kitwork/engine/utilities/socket"
+	"github.com/kitwork/engine/utilities/trace"
+)
+
+// Access logs: core.Engine opens one accesslog.Logger per site (<site>/.kitwork/logs/access.log)
+// and hands it to every generation of that site. Serve wraps the response to count status and
+// bytes, and the request context carries an accessRecord that the router fills as it goes — the
+// route pattern that matched, the cache tier that answered, the energy the VMs spent — so the line
+// written when the request ends holds all of it.
+
+// SetAccessLog gives this tenant its site's access log. Call it before Run; nil logs nothing. The
+// logger is shared by the site's generations, so Close leaves it open.
+func (t *Tenant) SetAccessLog(log *accesslog.Logger) {
+	if t != nil {
+		t.accessLog = log
+	}
+}
+
+// AccessLog is the site's access log, nil when access logging is off.
+func (t *Tenant) AccessLog() *accesslog.Logger {
+	if t == nil {
+		return nil
+	}
+	return t.accessLog
+}
+
+type accessRecord struct {
+	route  atomic.Pointer[string]
+	cache  atomic.Pointer[string]
+	energy atomic.Uint64
+}
+
+type accessRecordKey struct{}
+
+func accessRecordFrom(ctx context.Context) *accessRecord {
+	if ctx == nil {
+		return nil
+	}
+	record, _ := ctx.Value(accessRecordKey{}).(*accessRecord)
+	return record
+}
+
+func noteAccessRoute(r *http.Request, route string) {
+	if record := accessRecordFrom(r.Context()); record != nil {
+		record.route.Store(&route)
+	}
+}
+
+func noteAccessCache(r *http.Request, tier string) {
+	if record := accessRecordFrom(r.Context()); record != nil {
+		record.cache.Store(&tier)
+	}
+}
+
+// noteAccessEnergy charges a VM execution to the request it ran for, if any.
+func noteAccessEnergy(vm *runtime.VM, energy uint64) {
+	if record := accessRecordFrom(vm.Context); record != nil {
+		record.energy.Add(energy)
+	}
+}
+
+// beginAccessLog wraps the response and request for logging and returns the function that writes
+// the line. Without an access log it returns them unchanged.
+func (t *Tenant) beginAccessLog(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request, func()) {
+	if t.accessLog == nil {
+		return w, r, func() {}
+	}
+	started := time.Now()
+	record := &accessRecord{}
+	r = r.WithContext(context.WithValue(r.Context(), accessRecordKey{}, record))
+	counted := &accessResponseWriter{ResponseWriter: w}
+	return counted, r, func() {
+		entry := t.accessLog.Capture(r)
+		entry.Time = started
+		entry.Tenant = t.appID()
+		entry.Status = counted.status
+		if entry.Status == 0 {
+			entry.Status = http.StatusOK
+			if socket.IsUpgrade(r) {
+				entry.Status = http.StatusSwitchingProtocols
+			}
+		}
+		entry.Bytes = counted.bytes
+		entry.Duration = float64(time.Since(started).Microseconds()) / 1000
+		entry.Energy = record.energy.Load()
+		if route := record.route.Load(); route != nil {
+			entry.Route = *route
+		}
+		if tier := record.cache.Load(); tier != nil {
+			entry.Cache = *tier
+		}
+		entry.ClientIP = GetClientIP(r)
+		if id := trace.FromContext(r.Context()).Context().TraceID; id.IsValid() {
+			entry.TraceID = id.String()
+		}
+		_ = t.accessLog.Log(entry)
+	}
+}
+
+// accessResponseWriter counts the status and body bytes, keeping the ResponseController seam
+// (Unwrap) that flushes SSE and hijacks WebSocket connections.
+type accessResponseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *accessResponseWriter) WriteHeader(status int) {
+	if status >= 100 && status < 200 {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *accessResponseWriter) Write(body []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(body)
+	w.bytes += int64(n)
+	return n, err
+}
+
+func (w *accessResponseWriter) ReadFrom(reader io.Reader) (int64, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	var n int64
+	var err error
+	if readerFrom, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = readerFrom.ReadFrom(reader)
+	} else {
+		n, err = io.Copy(w.ResponseWriter, reader)
+	}
+	w.bytes += n
+	return n, err
+}
+
+func (w *accessResponseWriter) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+func (w *accessResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,97 @@
+package work
+
+import (
+	"fmt"
+	"time"
+)
+
+// Operator controls for the host's admin API. Cron and queue controls act on the app's scheduler
+// and queue tables, so they work from any of the app's domains and reach every node sharing the
+// store; the response cache belongs to the site.
+
+const (
+	cronActive = "active"
+	cronPaused = "paused"
+)
+
+// Crons lists this app's crons as the scheduler stores them; see Cron.List.
+func (t *Tenant) Crons() []map[string]any {
+	store := t.openCronStore()
+	if store == nil {
+		return []map[string]any{}
+	}
+	crons := store.listCrons(t.appID())
+	if crons == nil {
+		crons = []map[string]any{}
+	}
+	return crons
+}
+
+// PauseCron pauses or resumes one cron. A paused cron opens no slots — the ones it misses are
+// skipped, not made up — and the pause survives reloads and restarts. A run already claimed
+// finishes.
+func (t *Tenant) PauseCron(name string, paused bool) error {
+	store := t.openCronStore()
+	if store == nil {
+		return fmt.Errorf("scheduler store unavailable")
+	}
+	status := cronActive
+	if paused {
+		status = cronPaused
+	}
+	if !store.setStatus(t.appID(), name, status) {
+		return fmt.Errorf("cron %q not found", name)
+	}
+	return nil
+}
+
+// TriggerCron queues a run of one cron now, paused or not. The scheduler claims it on its next
+// tick like any slot, with the cron's retry budget and history.
+func (t *Tenant) TriggerCron(name string) (time.Time, error) {
+	store := t.openCronStore()
+	if store == nil {
+		return time.Time{}, fmt.Errorf("scheduler store unavailable")
+	}
+	at := time.Now().UTC().Truncate(time.Second)
+	if !store.trigger(t.appID(), name, at) {
+		return time.Time{}, fmt.Errorf("cron %q not found or already queued for %s", name, at.Format(time.RFC3339))
+	}
+	return at, nil
+}
+
+// QueueJobs lists the app's queued messages, newest first; name and status narrow it ("" = all).
+// See Queue.Jobs.
+func (t *Tenant) QueueJobs(name, status string, limit int) []map[string]any {
+	store := t.openQueueStore()
+	if store == nil {
+		return []map[string]any{}
+	}
+	if limit < 1 {
+		limit = 50
+	}
+	jobs := store.ListJobs(t.appID(), name, status, limit)
+	if jobs == nil {
+		jobs = []map[string]any{}
+	}
+	return jobs
+}
+
+// RetryQueueJobs revives dead-lettered messages of a queue — jobID, or all of them when it is 0 —
+// and returns how many were revived. See Queue.Replay.
+func (t *Tenant) RetryQueueJobs(name string, jobID int64) int64 {
+	store := t.openQueueStore()
+	if store == nil {
+		return 0
+	}
+	return store.RetryFailed(t.appID(), name, jobID)
+}
+
+// FlushResponseCache drops this generation's cached route responses: the RAM tier (.cache) and
+// the disk tier (.persist). Fetch responses cached on disk are kept. Returns how many entries went.
+func (t *Tenant) FlushResponseCache() (ram, disk int, err error) {
+	ram = t.respCache.Clear()
+	if t.persistStore != nil {
+		disk, err = t.persistStore.Clear()
+	}
+	return ram, disk, err
+}
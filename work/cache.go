@@ -11,8 +11,20 @@ import (
 type CacheItem struct {
 	Value    value.Value
 	ExpireAt time.Time
+	Clone    bool // stored with store: "clone" — every read gets its own copy
 }
 
+// Cache storage modes, chosen per set: cache.set(key, value, { ttl: "5m", store: "clone" }).
+// The cache hands the SAME value to every later request, so by default a deep-frozen copy is stored
+// — the caller's own object stays mutable — and a handler that mutates what it read gets a FROZEN_VALUE diagnostic instead of silently
+// corrupting the next response. "clone" stores a private copy and clones again on every read
+// (mutable results, paid per read); "shared" is the old unprotected behavior.
+const (
+	CacheStoreFreeze = "freeze"
+	CacheStoreClone  = "clone"
+	CacheStoreShared = "shared"
+)
+
 // GeneralCache wraps the tenant general-purpose cache namespace
 type GeneralCache struct {
 	tenant *Tenant
@@ -23,12 +35,26 @@ func (c *GeneralCache) GetCache(key string) (value.Value, bool) {
 	defer c.tenant.lruCacheLock.RUnlock()
 	item, ok := c.tenant.lruCache[key]
 	if ok && (item.ExpireAt.IsZero() || time.Now().Before(item.ExpireAt)) {
+		if item.Clone {
+			if cloned, err := value.Clone(item.Value); err == nil {
+				return cloned, true
+			}
+		}
 		return item.Value, true
 	}
 	return value.Value{K: value.Nil}, false
 }
 
+// SetCache stores val under key. ttlVal is a duration ("5m", milliseconds) or an options object
+// { ttl, store } where store is one of the CacheStore* modes (default freeze).
 func (c *GeneralCache) SetCache(key string, val value.Value, ttlVal value.Value) {
+	store := CacheStoreFreeze
+	if ttlVal.K == value.Map {
+		if mode := ttlVal.Get("store"); !mode.IsBlank() {
+			store = mode.Text()
+		}
+		ttlVal = ttlVal.Get("ttl")
+	}
 	var ttl time.Duration
 	if !ttlVal.IsBlank() {
 		if ttlVal.IsNumeric() {
@@ -38,6 +64,22 @@ func (c *GeneralCache) SetCache(key string, val value.Value, ttlVal value.Value)
 		}
 	}
 
+	cloned := false
+	switch store {
+	case CacheStoreShared:
+	case CacheStoreClone:
+		if copied, err := value.Clone(val); err == nil {
+			val, cloned = copied, true
+		} else {
+			val = value.Freeze(val) // holds functions or host values: protect it instead
+		}
+	default:
+		if copied, err := value.Clone(val); err == nil {
+			val = copied
+		}
+		val = value.Freeze(val) // an uncloneable value is frozen in place: still never shared mutable
+	}
+
 	c.tenant.lruCacheLock.Lock()
 	defer c.tenant.lruCacheLock.Unlock()
 
@@ -65,6 +107,7 @@ func (c *GeneralCache) SetCache(key string, val value.Value, ttlVal value.Value)
 	c.tenant.lruCache[key] = &CacheItem{
 		Value:    val,
 		ExpireAt: expireAt,
+		Clone:    cloned,
 	}
 }
 
@@ -0,0 +1,85 @@
+package work
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// A value handed out by the general cache is shared by every later request, so a deep-frozen copy
+// is stored: a handler that mutates it fails loudly instead of corrupting the next response, the
+// object a handler stored stays its own, structuredClone() gives a mutable copy, and
+// store: "clone" hands out a private copy per read.
+func TestGeneralCacheFreezesSharedValues(t *testing.T) {
+	tmp := t.TempDir()
+	dir := filepath.Join(tmp, "test", "localhost")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	const settings = `import { router, cache } from "kitwork";
+const settings = () => cache.get("settings", () => ({ tags: ["a"], limits: { max: 1 } }), "1m");
+`
+	routes := map[string]string{
+		"":       `router.get((ctx) => ctx.text("ok"));`,
+		"mutate": `router.get((ctx) => { settings().tags.push("b"); return ctx.text("changed"); });`,
+		"assign": `router.get((ctx) => { settings().limits.max = 9; return ctx.text("changed"); });`,
+		"copy": `router.get((ctx) => {
+	const own = structuredClone(settings());
+	own.tags.push("b");
+	return ctx.json({ own: own.tags.length, shared: settings().tags.length, same: deepEqual(own, settings()), frozen: Object.isFrozen(settings().limits) });
+});`,
+		"own": `router.get((ctx) => {
+	const mine = { n: 1 };
+	cache.set("mine", mine, "1m");
+	mine.n = 2;
+	return ctx.json({ mine: mine.n, cached: cache.get("mine").n, frozen: Object.isFrozen(cache.get("mine")) });
+});`,
+		"private": `router.get((ctx) => {
+	cache.set("draft", { n: 1 }, { ttl: "1m", store: "clone" });
+	const draft = cache.get("draft");
+	draft.n = 2;
+	return ctx.json({ mine: draft.n, cached: cache.get("draft").n });
+});`,
+	}
+	for name, body := range routes {
+		folder := filepath.Join(dir, name)
+		if err := os.MkdirAll(folder, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(folder, "router.kitwork.js"), []byte(settings+body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tenant := NewTenant(tmp, "localhost")
+	if err := tenant.Run(); err != nil {
+		t.Fatal(err)
+	}
+	get := func(path string) (int, string) {
+		rec := httptest.NewRecorder()
+		tenant.Serve(rec, httptest.NewRequest(http.MethodGet, "http://localhost"+path, nil))
+		return rec.Code, rec.Body.String()
+	}
+
+	for _, path := range []string{"/mutate", "/assign"} {
+		if code, body := get(path); code < 500 || strings.Contains(body, "changed") {
+			t.Fatalf("%s on a cached value = %d %q", path, code, body)
+		}
+	}
+	code, body := get("/copy")
+	if code != http.StatusOK || !strings.Contains(body, `"own":2`) || !strings.Contains(body, `"shared":1`) ||
+		!strings.Contains(body, `"same":false`) || !strings.Contains(body, `"frozen":true`) {
+		t.Fatalf("/copy = %d %s", code, body)
+	}
+	code, body = get("/own")
+	if code != http.StatusOK || !strings.Contains(body, `"mine":2`) || !strings.Contains(body, `"cached":1`) ||
+		!strings.Contains(body, `"frozen":true`) {
+		t.Fatalf("/own = %d %s", code, body)
+	}
+	code, body = get("/private")
+	if code != http.StatusOK || !strings.Contains(body, `"mine":2`) || !strings.Contains(body, `"cached":1`) {
+		t.Fatalf("/private = %d %s", code, body)
+	}
+}
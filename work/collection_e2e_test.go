@@ -45,7 +45,8 @@ func TestCollectionQueryAndSearchE2E(t *testing.T) {
 		`  const total = posts.where("status", "published").count();` + "\n" +
 		`  const hits = posts.search("nguyen");` + "\n" + // no diacritics → must find "Nguyễn"
 		`  const phrase = posts.search("may bay");` + "\n" +
-		`  return ctx.json({ list: list, total: total, hits: hits, phrase: phrase });` + "\n" +
+		`  const folded = posts.search("dieu khien");` + "\n" + // đ is not a tone mark: only the folded column finds "điều"
+		`  return ctx.json({ list: list, total: total, hits: hits, phrase: phrase, folded: folded });` + "\n" +
 		`});`
 	if err := os.WriteFile(filepath.Join(dir, "router.kitwork.js"), []byte(router), 0644); err != nil {
 		t.Fatal(err)
@@ -94,6 +95,10 @@ func TestCollectionQueryAndSearchE2E(t *testing.T) {
 		t.Errorf("search 'may bay' must find 'Máy bay không người lái'; body: %s", body)
 	}
 
+	if !strings.Contains(body, `"folded":[{`) || !strings.Contains(body[strings.Index(body, `"folded":`):], `"slug":"may-bay"`) {
+		t.Errorf("search 'dieu khien' must find 'điều khiển'; body: %s", body)
+	}
+
 	// The projection database landed in the tenant's .data (disposable, gitignored).
 	if _, err := os.Stat(filepath.Join(dir, ".data", "collection.db")); err != nil {
 		t.Errorf("FTS projection .data/collection.db missing: %v", err)
@@ -1,6 +1,7 @@
 package work
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -67,6 +68,12 @@ func (e tenantLambdaExecutor) ExecuteLambda(fn *value.Lambda, args []value.Value
 // handlers off the request path through its Scope. (Tenant.Run() is the tenant boot method; this is
 // named Execute to avoid that.)
 func (t *Tenant) Execute(program *runtime.Program, fn *value.Lambda, args []value.Value) (gas uint64, runErr error) {
+	return t.executeIn(nil, program, fn, args)
+}
+
+// executeIn is Execute under ctx: the VM's context, so the job's spans (queries, fetches) join the
+// trace ctx carries. nil runs without one, as Execute always has.
+func (t *Tenant) executeIn(ctx context.Context, program *runtime.Program, fn *value.Lambda, args []value.Value) (gas uint64, runErr error) {
 	if fn == nil {
 		return 0, fmt.Errorf("run: nil lambda")
 	}
@@ -85,6 +92,7 @@ func (t *Tenant) Execute(program *runtime.Program, fn *value.Lambda, args []valu
 	}()
 
 	t.prepareExecutionVM(vm, t.vm.Globals, t.vm.Builtins)
+	vm.Context = ctx
 	vm.FastResetPrepared(program)
 	vm.MaxEnergy = t.MaxEnergy
 	for k, v := range t.vm.Vars {
@@ -12,4 +12,7 @@ const (
 	DefaultAPITimeout  = 30 * time.Second
 	DefaultWorkerRetry = 0
 	DefaultStaticCache = 24 * time.Hour
+
+	// Upload Defaults
+	DefaultUploadQuota = 1 << 30 // bytes a site's uploads may hold on disk: kept files plus those being received
 )
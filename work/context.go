@@ -113,6 +113,10 @@ func (c *Context) arguments(lambda *value.Lambda) []value.Value {
 			args = append(args, value.New(c.Response()))
 		case "sse":
 			args = append(args, value.New(&SseHelper{tenant: c.tenant(), context: c}))
+		case "body":
+			args = append(args, validatedInput(c.router().body))
+		case "query":
+			args = append(args, validatedInput(c.router().query))
 		case "err", "error", "e":
 			if c.router().err != nil {
 				args = append(args, value.New(c.router().err.Error()))
@@ -382,15 +382,36 @@ func (r *Request) Body() value.Value {
 		return value.Value{K: value.Nil}
 	}
 
-	body, err := io.ReadAll(req.Body)
+	body, err := keepBody(req)
 	if err != nil {
 		return value.Value{K: value.Nil}
 	}
+	return value.New(string(body))
+}
 
-	// Restore body
-	req.Body = io.NopCloser(bytes.NewBuffer(body))
+// keepBody reads the whole request body and puts the same bytes back, so the next reader — a
+// webhook signature check, the JSON decoder, a form parse — sees exactly what arrived.
+func keepBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, err
+}
 
-	return value.New(string(body))
+// parseFormKeepingBody is req.ParseForm without consuming the body: a handler that read a form
+// field can still verify a signature over the raw bytes.
+func parseFormKeepingBody(req *http.Request) error {
+	body, err := keepBody(req)
+	if err != nil {
+		return err
+	}
+	err = req.ParseForm()
+	if body != nil {
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return err
 }
 
 func (r *Request) JSON() value.Value {
@@ -412,6 +433,9 @@ func (r *Request) FormValue(key string) value.Value {
 	if req == nil {
 		return value.Value{K: value.Nil}
 	}
+	if req.Form == nil && !strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/") {
+		_ = parseFormKeepingBody(req) // multipart bodies are left to FormValue's own parse
+	}
 	return value.New(req.FormValue(key))
 }
 
@@ -420,7 +444,7 @@ func (r *Request) FormParams() value.Value {
 	if req == nil {
 		return value.Value{K: value.Nil}
 	}
-	if err := req.ParseForm(); err != nil {
+	if err := parseFormKeepingBody(req); err != nil {
 		return value.Value{K: value.Nil}
 	}
 	res := make(map[string]value.Value)
@@ -448,6 +472,10 @@ func (r *Request) SaveFile(fieldName string, destPath string) value.Value {
 	if req == nil {
 		return value.New(false)
 	}
+	if r.router.uploads != nil { // a .body() route already streamed the parts to disk
+		list := r.router.uploads[fieldName]
+		return value.New(len(list) > 0 && list[0].Save(destPath))
+	}
 
 	file, header, err := req.FormFile(fieldName)
 	if err != nil {
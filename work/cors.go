@@ -0,0 +1,246 @@
+package work
+
+// Declarative CORS: router.cors({...}) on a folder.
+//
+// The policy is inherited like the other folder settings — the deepest folder on the resolve chain
+// that declares one wins, and router.cors(false) switches it off for a subtree. Preflight OPTIONS
+// requests are answered straight from the policy before any VM is leased; actual requests get
+// their Access-Control-* headers from the responder. Those headers are computed per request and
+// never stored with a .cache()/.persist() entry, so a cached response replays with the CURRENT
+// request's origin, not the first caller's.
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kitwork/engine/value"
+)
+
+// defaultCorsMethods is what a preflight allows when the policy lists no methods.
+var defaultCorsMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"}
+
+type CorsOptions struct {
+	// Origins specifies the allowed origins: "*" for any, an exact origin, a wildcard such as
+	// "https://*.example.com", or a regular expression between slashes ("/^https://.+\.dev$/").
+	Origins []string
+
+	// Methods specifies the allowed HTTP methods.
+	Methods []string
+
+	// Headers specifies the allowed request headers. Empty echoes what the preflight asks for.
+	Headers []string
+
+	// Expose specifies the response headers that browsers
+	// are allowed to expose to JavaScript.
+	Expose []string
+
+	// Credentials indicates whether the browser may send
+	// credentials such as cookies or HTTP authentication.
+	Credentials bool
+
+	// MaxAge specifies how long the browser may cache
+	// the result of a preflight request.
+	MaxAge time.Duration
+
+	patterns []*regexp.Regexp // wildcard and /regex/ origins, compiled once at declaration
+}
+
+// Cors declares the CORS policy for this folder AND every descendant:
+//
+//	router.cors("*")
+//	router.cors(["https://app.example.com", "https://*.example.com"])
+//	router.cors({ origins: "/^https://.+\\.example\\.com$/", credentials: true, maxAge: "10m",
+//		headers: ["Content-Type", "Authorization"], expose: ["X-Total-Count"] })
+//	router.cors(false) // a subfolder opts out of an inherited policy
+//
+// An object without origins allows any origin. A numeric maxAge is seconds, as the header is.
+// With credentials, "*" reflects the caller's origin — browsers reject a literal "*" there.
+func (f *FolderRouter) Cors(v value.Value) *FolderRouter {
+	opts := &CorsOptions{}
+	switch {
+	case v.K == value.Bool || v.K == value.Nil:
+		if v.Truthy() {
+			opts.Origins = []string{"*"}
+		}
+	case v.IsMap():
+		mp := v.Map()
+		opts.Origins = []string{"*"}
+		if o, ok := mp["origins"]; ok {
+			opts.Origins = corsList(o)
+		} else if o, ok := mp["origin"]; ok {
+			opts.Origins = corsList(o)
+		}
+		opts.Methods = corsList(mp["methods"])
+		for i, m := range opts.Methods {
+			opts.Methods[i] = strings.ToUpper(m)
+		}
+		opts.Headers = corsList(mp["headers"])
+		opts.Expose = corsList(mp["expose"])
+		opts.Credentials = mp["credentials"].Truthy()
+		if age, ok := mp["maxAge"]; ok {
+			if age.IsNumeric() {
+				opts.MaxAge = time.Duration(age.N) * time.Second
+			} else {
+				opts.MaxAge, _ = ParseDuration(age.Text())
+			}
+		}
+	default:
+		opts.Origins = corsList(v)
+	}
+	opts.compile()
+	f.cors = opts
+	return f
+}
+
+// corsList accepts one string, a comma-separated string or an array of strings.
+func corsList(v value.Value) []string {
+	var items []string
+	if v.K == value.Array {
+		for _, item := range v.Array() {
+			items = append(items, item.Text())
+		}
+	} else if v.K == value.String {
+		items = strings.Split(v.Text(), ",")
+		if s := strings.TrimSpace(v.Text()); len(s) > 2 && s[0] == '/' && s[len(s)-1] == '/' {
+			items = []string{s} // a regex may contain commas
+		}
+	}
+	out := items[:0]
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// compile turns wildcard and /regex/ origins into patterns. A wildcard stands for one or more
+// host characters, so "https://*.example.com" never matches "https://example.com" or
+// "https://evil.com/.example.com". An invalid regex matches nothing.
+func (o *CorsOptions) compile() {
+	o.patterns = nil
+	for _, origin := range o.Origins {
+		switch {
+		case len(origin) > 2 && origin[0] == '/' && origin[len(origin)-1] == '/':
+			if re, err := regexp.Compile(origin[1 : len(origin)-1]); err == nil {
+				o.patterns = append(o.patterns, re)
+			}
+		case origin != "*" && strings.Contains(origin, "*"):
+			expr := strings.ReplaceAll(regexp.QuoteMeta(strings.ToLower(origin)), `\*`, `[a-z0-9.-]+`)
+			o.patterns = append(o.patterns, regexp.MustCompile("^"+expr+"$"))
+		}
+	}
+}
+
+func (o *CorsOptions) enabled() bool { return o != nil && len(o.Origins) > 0 }
+
+// anyOrigin reports whether the answer is the same "*" for every caller (no Vary needed).
+func (o *CorsOptions) anyOrigin() bool {
+	return !o.Credentials && len(o.Origins) == 1 && o.Origins[0] == "*"
+}
+
+// allowOrigin returns the Access-Control-Allow-Origin value for origin, or "" when it is refused.
+func (o *CorsOptions) allowOrigin(origin string) string {
+	if origin == "" {
+		return ""
+	}
+	for _, allowed := range o.Origins {
+		if allowed == "*" {
+			if o.Credentials {
+				return origin
+			}
+			return "*"
+		}
+		if strings.EqualFold(allowed, origin) {
+			return origin
+		}
+	}
+	for _, re := range o.patterns {
+		if re.MatchString(origin) || re.MatchString(strings.ToLower(origin)) {
+			return origin
+		}
+	}
+	return ""
+}
+
+// corsPolicy is the policy of the deepest folder on the chain that declares one.
+func corsPolicy(chain []*RouteNode) *CorsOptions {
+	var policy *CorsOptions
+	for _, node := range chain {
+		if node.folder != nil && node.folder.cors != nil {
+			policy = node.folder.cors
+		}
+	}
+	if !policy.enabled() {
+		return nil
+	}
+	return policy
+}
+
+func isPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions && r.Header.Get("Origin") != "" &&
+		r.Header.Get("Access-Control-Request-Method") != ""
+}
+
+// addVary appends names to the Vary header, skipping ones already listed.
+func addVary(w http.ResponseWriter, names ...string) {
+	present := map[string]bool{}
+	for _, line := range w.Header().Values("Vary") {
+		for _, name := range strings.Split(line, ",") {
+			present[strings.ToLower(strings.TrimSpace(name))] = true
+		}
+	}
+	for _, name := range names {
+		if !present[strings.ToLower(name)] {
+			w.Header().Add("Vary", name)
+			present[strings.ToLower(name)] = true
+		}
+	}
+}
+
+// writeCorsHeaders writes the headers of an actual (non-preflight) response.
+func writeCorsHeaders(opts *CorsOptions, w http.ResponseWriter, r *http.Request) {
+	if !opts.anyOrigin() {
+		addVary(w, "Origin")
+	}
+	allowed := opts.allowOrigin(r.Header.Get("Origin"))
+	if allowed == "" {
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Origin", allowed)
+	if opts.Credentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+	if len(opts.Expose) > 0 {
+		w.Header().Set("Access-Control-Expose-Headers", strings.Join(opts.Expose, ", "))
+	}
+}
+
+// servePreflight answers an OPTIONS preflight from the policy alone. A refused origin still gets
+// a 204, just without Access-Control-Allow-Origin — the browser then blocks the real request.
+func servePreflight(opts *CorsOptions, w http.ResponseWriter, r *http.Request) {
+	addVary(w, "Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers")
+	if allowed := opts.allowOrigin(r.Header.Get("Origin")); allowed != "" {
+		w.Header().Set("Access-Control-Allow-Origin", allowed)
+		if opts.Credentials {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+		methods := opts.Methods
+		if len(methods) == 0 {
+			methods = defaultCorsMethods
+		}
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+		if len(opts.Headers) > 0 {
+			w.Header().Set("Access-Control-Allow-Headers", strings.Join(opts.Headers, ", "))
+		} else if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+			w.Header().Set("Access-Control-Allow-Headers", requested)
+		}
+		if opts.MaxAge > 0 {
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(opts.MaxAge/time.Second)))
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
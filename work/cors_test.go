@@ -0,0 +1,95 @@
+package work
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// router.cors() is inherited down the tree, answers preflights without a handler, matches
+// wildcard origins, and a cached response replays with the current caller's origin only.
+func TestFolderCorsPolicy(t *testing.T) {
+	tmp := t.TempDir()
+	dir := filepath.Join(tmp, "test", "localhost")
+	write := func(rel, content string) {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("router.kitwork.js", `import { router } from "kitwork";
+router.cors({ origins: ["https://app.example.com", "https://*.example.org"], credentials: true, maxAge: 600, expose: ["X-Total"] });`)
+	write("api/router.kitwork.js", `import { router } from "kitwork";
+router.get((ctx) => ctx.json({ ok: true })).cache("1h");`)
+	write("public/router.kitwork.js", `import { router } from "kitwork";
+router.cors("*");
+router.get((ctx) => ctx.text("open"));`)
+	write("private/router.kitwork.js", `import { router } from "kitwork";
+router.cors(false);
+router.get((ctx) => ctx.text("closed"));`)
+	tenant := NewTenant(tmp, "localhost")
+	if err := tenant.Run(); err != nil {
+		t.Fatal(err)
+	}
+	serve := func(method, path, origin string, headers ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "http://localhost"+path, nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		rec := httptest.NewRecorder()
+		tenant.Serve(rec, req)
+		return rec
+	}
+	varies := func(rec *httptest.ResponseRecorder) bool {
+		return strings.Contains(strings.Join(rec.Header().Values("Vary"), ","), "Origin")
+	}
+
+	pre := serve(http.MethodOptions, "/api/", "https://app.example.com",
+		"Access-Control-Request-Method", "PUT", "Access-Control-Request-Headers", "content-type, x-token")
+	if pre.Code != http.StatusNoContent ||
+		pre.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" ||
+		pre.Header().Get("Access-Control-Allow-Credentials") != "true" ||
+		pre.Header().Get("Access-Control-Max-Age") != "600" ||
+		!strings.Contains(pre.Header().Get("Access-Control-Allow-Methods"), "PUT") ||
+		pre.Header().Get("Access-Control-Allow-Headers") != "content-type, x-token" || !varies(pre) {
+		t.Fatalf("preflight = %d %v", pre.Code, pre.Header())
+	}
+	if refused := serve(http.MethodOptions, "/api/", "https://evil.com", "Access-Control-Request-Method", "GET"); refused.Code != http.StatusNoContent || refused.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("refused preflight = %d %v", refused.Code, refused.Header())
+	}
+
+	for _, tc := range []struct{ origin, allow, cache string }{
+		{"https://shop.example.org", "https://shop.example.org", ""},
+		{"https://app.example.com", "https://app.example.com", "hit"},
+		{"https://evil.com", "", "hit"},
+		{"https://example.org", "", "hit"}, // the wildcard needs a subdomain
+	} {
+		rec := serve(http.MethodGet, "/api/", tc.origin)
+		if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != tc.allow ||
+			rec.Header().Get("X-Kitwork-Cache") != tc.cache || !varies(rec) {
+			t.Fatalf("GET /api/ from %s = %d %v", tc.origin, rec.Code, rec.Header())
+		}
+		if tc.allow != "" && rec.Header().Get("Access-Control-Expose-Headers") != "X-Total" {
+			t.Fatalf("expose headers missing: %v", rec.Header())
+		}
+	}
+
+	if rec := serve(http.MethodGet, "/public/", "https://anyone.net"); rec.Header().Get("Access-Control-Allow-Origin") != "*" || varies(rec) {
+		t.Fatalf("GET /public/ = %v", rec.Header())
+	}
+	if rec := serve(http.MethodGet, "/private/", "https://app.example.com"); rec.Body.String() != "closed" || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Fatalf("GET /private/ = %s %v", rec.Body.String(), rec.Header())
+	}
+	if rec := serve(http.MethodOptions, "/private/", "https://app.example.com", "Access-Control-Request-Method", "GET"); rec.Code == http.StatusNoContent {
+		t.Fatalf("router.cors(false) must not answer preflights: %v", rec.Header())
+	}
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/kitwork/engine/compiler"
 	"github.com/kitwork/engine/runtime"
+	"github.com/kitwork/engine/utilities/datetime"
 	"github.com/kitwork/engine/value"
 )
 
@@ -192,10 +193,23 @@ func (cb *CronBuilder) Retry(args ...value.Value) *CronBuilder {
 	return cb
 }
 
+// Timezone sets the zone the schedule is read in — .daily("08:00").timezone("Asia/Ho_Chi_Minh") fires
+// at 08:00 Vietnam time. It takes the names the time capability takes ("vn", "+07:00") or a zoned
+// value (time.now("vn")), and stores the canonical IANA name. An unknown zone is reported and ignored.
 func (cb *CronBuilder) Timezone(args ...value.Value) *CronBuilder {
-	if len(args) > 0 && !args[0].IsCallable() {
-		cb.job.Timezone = args[0].Text()
+	if len(args) == 0 || args[0].IsCallable() {
+		return cb
+	}
+	if zoned, ok := args[0].V.(interface{ Location() *time.Location }); ok && args[0].K == value.Struct {
+		cb.job.Timezone = zoned.Location().String()
+		return cb
+	}
+	name, err := datetime.ZoneName(args[0].Text())
+	if err != nil {
+		fmt.Printf("[Cron] timezone: %v — ignored\n", err)
+		return cb
 	}
+	cb.job.Timezone = name
 	return cb
 }
 
@@ -272,7 +286,7 @@ func (t *Tenant) LoadCronFiles() {
 			continue
 		}
 		file := filepath.Join(dir, e.Name())
-		bc, err := compiler.CompileFile(file)
+		bc, err := t.compileFile(file)
 		if err != nil {
 			fmt.Printf("[Cron] compile %s: %v\n", e.Name(), err)
 			continue
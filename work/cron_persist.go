@@ -1,12 +1,15 @@
 package work
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/kitwork/engine/database"
 	"github.com/kitwork/engine/id"
+	"github.com/kitwork/engine/utilities/datetime"
+	"github.com/kitwork/engine/utilities/trace"
 	"github.com/kitwork/engine/value"
 )
 
@@ -180,7 +183,9 @@ func (t *Tenant) persistDispatcher(scheduler *cronRuntime, cancel chan struct{},
 		case <-ticker.C:
 			now := time.Now().UTC()
 			t.dispatchDue(scheduler, now, appID, persisted, lastSlot)
-			t.claimAndRun(scheduler, appID)
+			if !t.jobsPaused("cron") { // maintenance: due slots wait, pending, until it ends
+				t.claimAndRun(scheduler, appID)
+			}
 			scheduler.store.reclaim(appID, false)
 			tick++
 			if tick%60 == 0 { // ~once a minute
@@ -222,6 +227,11 @@ func (t *Tenant) dispatchDue(scheduler *cronRuntime, now time.Time, appID string
 		if prev, ok := lastSlot[job.Name]; ok && prev.Equal(slot) {
 			continue // already handled this window on an earlier tick
 		}
+		// A paused cron (the admin API) skips its slots; they are not made up when it resumes.
+		if scheduler.store.status(appID, job.Name) == cronPaused {
+			lastSlot[job.Name] = slot
+			continue
+		}
 		// Overlap 'skip': don't open a new slot while a previous run for this job is still active.
 		if job.OverlapPolicy == "skip" && scheduler.store.hasActive(appID, job.Name) {
 			continue
@@ -268,15 +278,28 @@ func (t *Tenant) runClaimed(scheduler *cronRuntime, r claimedRow, job *CronJob)
 	tryNum := r.attempt + 1
 	final := tryNum >= r.maxAttempts
 	ctx := t.cronContext(r.id, tryNum, r.maxAttempts, final, r.scheduledFor, &out)
+	if slot, err := time.Parse(time.RFC3339Nano, r.scheduledFor); err == nil && r.attempt == 0 {
+		t.tenantHealth.RecordCronLag(time.Since(slot)) // a retry's delay is its backoff, not lag
+	}
 
-	gas, runErr := t.runInJobVM(job, job.Callback, []value.Value{ctx})
+	// Each run is the root of its own trace: a schedule has no caller to continue.
+	traceCtx, span := t.tracer.Begin(context.Background(), "cron "+r.name, trace.KindInternal, trace.SpanContext{}, t.Domain())
+	span.SetAttribute("kitwork.app", t.appID())
+	span.SetAttribute("kitwork.job.id", r.id)
+	span.SetAttribute("kitwork.job.attempt", tryNum)
+	defer span.End()
+
+	gas, runErr := t.runInJobVM(traceCtx, job, job.Callback, []value.Value{ctx})
+	if runErr != nil {
+		span.Fail(runErr.Error())
+	}
 
 	appID := t.appID()
 	if runErr == nil {
 		scheduler.store.complete(r.id, out.String(), int64(gas))
 		scheduler.store.recordSummary(appID, r.name, "completed")
 		if job.OnSuccess != nil {
-			_, _ = t.runInJobVM(job, job.OnSuccess, []value.Value{ctx})
+			_, _ = t.runInJobVM(traceCtx, job, job.OnSuccess, []value.Value{ctx})
 		}
 		return
 	}
@@ -292,7 +315,7 @@ func (t *Tenant) runClaimed(scheduler *cronRuntime, r claimedRow, job *CronJob)
 	}
 	if job.OnError != nil {
 		errObj := value.New(map[string]value.Value{"message": value.New(runErr.Error())})
-		_, _ = t.runInJobVM(job, job.OnError, []value.Value{ctx, errObj})
+		_, _ = t.runInJobVM(traceCtx, job, job.OnError, []value.Value{ctx, errObj})
 	}
 }
 
@@ -321,13 +344,13 @@ func (t *Tenant) cronContext(execID int64, attempt, maxAttempts int, final bool,
 // runInJobVM runs a lambda that belongs to a cron file's bytecode: a pooled VM is FastReset onto THAT
 // bytecode (the lambda's Address offsets index into it) and given the tenant's Builtins/Globals/Vars.
 // Returns gas consumed and any run error (an Invalid result — thrown value or energy-limit halt).
-func (t *Tenant) runInJobVM(job *CronJob, lambda *value.Lambda, args []value.Value) (gas uint64, runErr error) {
+func (t *Tenant) runInJobVM(ctx context.Context, job *CronJob, lambda *value.Lambda, args []value.Value) (gas uint64, runErr error) {
 	if job.Bytecode == nil {
 		return 0, fmt.Errorf("cron %q has no bytecode", job.Name)
 	}
 	// The generic compute seam (capabilities.Runtime, see compute.go) IS this runner — cron dogfoods
 	// it, so every cron test also exercises the seam a migrated cron capability would use.
-	return t.Execute(job.Bytecode.Program, lambda, args)
+	return t.executeIn(ctx, job.Bytecode.Program, lambda, args)
 }
 
 // cronSuccessRetention is how long a SUCCESSFUL run's row is kept in cron_runs. Successes are transient
@@ -354,17 +377,25 @@ func (t *Tenant) retentionSweep(scheduler *cronRuntime, persisted []*CronJob) {
 // jobSlot returns the stable slot timestamp for `now` and whether the job is due. The slot is truncated
 // so many dispatcher ticks inside one window collapse to one INSERT OR IGNORE. Cron expressions match at
 // minute granularity (the spec's persisted granularity); intervals bucket by their period.
+//
+// Calendar fields (hour, day, month, weekday) are read on the job's Timezone clock, so "0 8 * * *" with
+// .timezone("Asia/Ho_Chi_Minh") is due at 08:00 there and @daily rolls over at that zone's midnight.
 func jobSlot(job *CronJob, now time.Time) (time.Time, bool) {
+	if loc, err := datetime.LoadZone(job.Timezone); err == nil && job.Timezone != "" {
+		now = now.In(loc)
+	} else {
+		now = now.UTC()
+	}
 	expr := job.Expression
 	switch {
 	case expr == "@hourly":
-		return now.Truncate(time.Hour), true
+		return datetime.StartOf(now, datetime.Hour), true
 	case expr == "@daily":
-		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC), true
+		return datetime.StartOf(now, datetime.Day), true
 	case expr == "@weekly":
-		return now.Truncate(time.Hour), true // coarse fallback; .weekly("mon 09:00") uses a real cron expr
+		return datetime.StartOf(now, datetime.Week), true // one slot per week; .weekly("mon 09:00") uses a real cron expr
 	case expr == "@monthly":
-		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC), true
+		return datetime.StartOf(now, datetime.Month), true
 	case strings.HasPrefix(expr, "@every "):
 		d, err := ParseDuration(strings.TrimPrefix(expr, "@every "))
 		if err != nil || d <= 0 {
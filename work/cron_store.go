@@ -21,6 +21,9 @@ type cronStore interface {
 	heartbeat(nodeID string, leaseTTL time.Duration)
 	retention(identity, name string, completedBefore, failedBefore time.Time)
 	listCrons(identity string) []map[string]any
+	setStatus(identity, name, status string) bool
+	status(identity, name string) string
+	trigger(identity, name string, at time.Time) bool
 }
 
 func cronJobsToRecords(jobs []*CronJob) []cronhelper.JobRecord {
@@ -93,6 +96,15 @@ func (s *sqliteStore) retention(identity, name string, completedBefore, failedBe
 func (s *sqliteStore) listCrons(identity string) []map[string]any {
 	return s.inner.ListCrons(identity)
 }
+func (s *sqliteStore) setStatus(identity, name, status string) bool {
+	return s.inner.SetStatus(identity, name, status)
+}
+func (s *sqliteStore) status(identity, name string) string {
+	return s.inner.Status(identity, name)
+}
+func (s *sqliteStore) trigger(identity, name string, at time.Time) bool {
+	return s.inner.Trigger(identity, name, at)
+}
 
 type pgStore struct {
 	inner *cronhelper.PgStore
@@ -148,3 +160,12 @@ func (s *pgStore) retention(identity, name string, completedBefore, failedBefore
 func (s *pgStore) listCrons(identity string) []map[string]any {
 	return s.inner.ListCrons(identity)
 }
+func (s *pgStore) setStatus(identity, name, status string) bool {
+	return s.inner.SetStatus(identity, name, status)
+}
+func (s *pgStore) status(identity, name string) string {
+	return s.inner.Status(identity, name)
+}
+func (s *pgStore) trigger(identity, name string, at time.Time) bool {
+	return s.inner.Trigger(identity, name, at)
+}
@@ -1,6 +1,7 @@
 package work
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
@@ -43,6 +44,7 @@ func (w *KitWork) Database() *Database {
 type Database struct {
 	tenant       *Tenant
 	requestScope *requestscope.Scope
+	ctx          context.Context // the calling execution, bound per call: parents the query spans (db.trace.go)
 	config       *database.Config
 	sqlDB        *sql.DB
 	tx           *sql.Tx
@@ -147,6 +149,9 @@ func (d *Database) NewQuery() *query.Query {
 	if d.tx != nil {
 		exec = d.tx
 	}
+	if exec != nil {
+		exec = tracedExecutor{exec: exec, database: d}
+	}
 	return query.New(exec, tenantLambdaExecutor{tenant: d.tenant, requestScope: d.requestScope})
 }
 
@@ -239,6 +244,7 @@ func (d *Database) Atomic(args ...value.Value) value.Value {
 	txDb := &Database{
 		tenant:       d.tenant,
 		requestScope: d.requestScope,
+		ctx:          d.ctx,
 		config:       d.config,
 		sqlDB:        d.sqlDB,
 		tx:           tx,
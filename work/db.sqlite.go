@@ -66,7 +66,9 @@ func (s *SQLite) Exec(sqlText string, args ...value.Value) value.Value {
 	for i, a := range args {
 		goArgs[i] = a.Interface()
 	}
+	span := s.startSpan(sqlText)
 	res, err := conn.Exec(sqlText, goArgs...)
+	endDBSpan(span, err)
 	if err != nil {
 		return value.Value{K: value.Invalid, V: err.Error()}
 	}
@@ -0,0 +1,101 @@
+package work
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/kitwork/engine/utilities/query"
+	"github.com/kitwork/engine/utilities/trace"
+	"github.com/kitwork/engine/value"
+)
+
+// tracedExecutor wraps a query executor with one client span per statement, parented by the span
+// the calling script runs under (see Database.traceContext). The statement is recorded as written
+// — placeholders, never the bound values.
+type tracedExecutor struct {
+	exec     query.Executor
+	database *Database
+}
+
+func (e tracedExecutor) QueryContext(ctx context.Context, statement string, args ...any) (*sql.Rows, error) {
+	span := e.database.startSpan(statement)
+	rows, err := e.exec.QueryContext(ctx, statement, args...)
+	endDBSpan(span, err)
+	return rows, err
+}
+
+func (e tracedExecutor) ExecContext(ctx context.Context, statement string, args ...any) (sql.Result, error) {
+	span := e.database.startSpan(statement)
+	res, err := e.exec.ExecContext(ctx, statement, args...)
+	endDBSpan(span, err)
+	return res, err
+}
+
+// BindContext implements value.ContextBinder: an imported handle is created once, at module load,
+// so every call gets a copy bound to the execution that made it. The copy shares the pool and any
+// open transaction.
+func (d *Database) BindContext(ctx context.Context) value.Value {
+	bound := *d
+	bound.ctx = ctx
+	return value.New(&bound)
+}
+
+// BindContext keeps the SQLite type, so the bound copy still answers exec/open/memory.
+func (s *SQLite) BindContext(ctx context.Context) value.Value {
+	bound := *s.Database
+	bound.ctx = ctx
+	return value.New(&SQLite{Database: &bound})
+}
+
+// traceContext is where this handle's query spans hang: the calling execution's current span, else
+// the request's.
+func (d *Database) traceContext() context.Context {
+	if d.ctx != nil {
+		return d.ctx
+	}
+	if d.requestScope != nil {
+		return d.requestScope.Context()
+	}
+	return context.Background()
+}
+
+func (d *Database) startSpan(statement string) *trace.Span {
+	_, span := trace.Start(d.traceContext(), "db.query", trace.KindClient)
+	if span.Recording() {
+		span.SetAttribute("db.system", d.system())
+		span.SetAttribute("db.statement", statement)
+		if operation, _, _ := strings.Cut(strings.TrimSpace(statement), " "); operation != "" {
+			span.SetAttribute("db.operation", strings.ToUpper(operation))
+		}
+	}
+	return span
+}
+
+func endDBSpan(span *trace.Span, err error) {
+	if err != nil {
+		span.Fail(err.Error())
+	}
+	span.End()
+}
+
+// system names the database engine for db.system: the blueprint's type, else the driver's.
+func (d *Database) system() string {
+	if d.preset != nil && d.preset.Type != "" {
+		return strings.ToLower(d.preset.Type)
+	}
+	if d.sqlDB == nil {
+		return "other_sql"
+	}
+	switch driver := strings.ToLower(fmt.Sprintf("%T", d.sqlDB.Driver())); {
+	case strings.Contains(driver, "pq.") || strings.Contains(driver, "pgx") || strings.Contains(driver, "postgres"):
+		return "postgresql"
+	case strings.Contains(driver, "mysql"):
+		return "mysql"
+	case strings.Contains(driver, "sqlite"):
+		return "sqlite"
+	default:
+		return "other_sql"
+	}
+}
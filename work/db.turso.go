@@ -3,10 +3,12 @@
 package work
 
 import (
+	"context"
 	"path/filepath"
 
 	"github.com/kitwork/engine/database"
 	requestscope "github.com/kitwork/engine/request"
+	"github.com/kitwork/engine/value"
 )
 
 // TursoDB is what `import { turso } from "kitwork"` resolves to — but ONLY in a `-tags turso` build.
@@ -30,6 +32,13 @@ type TursoDB struct {
 	*SQLite
 }
 
+// BindContext keeps the TursoDB type, whose open/memory stay on turso.
+func (d *TursoDB) BindContext(ctx context.Context) value.Value {
+	bound := *d.Database
+	bound.ctx = ctx
+	return value.New(&TursoDB{&SQLite{Database: &bound}})
+}
+
 // Turso resolves `import { turso }` to the tenant's default database at .data/app.db, on tursogo.
 func (w *KitWork) Turso() *TursoDB {
 	return &TursoDB{tursoForRequest(w.tenant, "app.db", w.requestScope)}
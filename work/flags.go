@@ -0,0 +1,448 @@
+package work
+
+// Feature flags and A/B experiments (see utilities/flags for the definition format).
+//
+// Definitions come from flags.kitwork.json at the site root — versioned with the code, reloaded
+// with it — and from the flags table of the tenant's .data/flags.db, which the flags capability
+// edits at runtime and which wins on the same key:
+//
+//	import { flags } from "kitwork";
+//	flags.set("new-checkout", { rollout: 25 });       // takes effect within flagRefresh
+//	flags.exposures("new-checkout");                  // [{ flag, variant, exposed, visitors }]
+//
+// Handlers ask ctx.flag("new-checkout") and templates {{ if flag "new-checkout" }}: a plain flag
+// answers true/false, an experiment its variant name. Visitors are bucketed by a signed kw_visitor
+// cookie (or by a user attribute passed as ctx.flag(name, { user })), so the answer is sticky.
+// Every bucketed answer is recorded in flag_exposures once per request, and a .cache()/.persist()
+// route keeps one entry per combination of the flags it read. A route that reads a flag with its
+// own attributes is not cached at all: the answer belongs to that user, not to the request's key.
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kitwork/engine/utilities/flags"
+	"github.com/kitwork/engine/value"
+)
+
+// flagsFile is the site's versioned flag definitions.
+const flagsFile = "flags" + extension + ".json"
+
+// visitorCookie carries the signed visitor id that sticky bucketing hashes.
+const visitorCookie = "kw_visitor"
+
+// flagRefresh bounds how stale runtime (database) definitions may be on a node that did not make
+// the change. A var so tests can shorten it.
+var flagRefresh = 5 * time.Second
+
+// flagRuntime is the tenant's flag state: file definitions of this generation plus the database
+// ones, reloaded at most every flagRefresh.
+type flagRuntime struct {
+	file map[string]*flags.Flag
+
+	mu     sync.Mutex
+	store  *flags.Store
+	stored map[string]*flags.Flag
+	loaded time.Time
+	secret []byte
+}
+
+// loadFlags reads flags.kitwork.json for a new generation. A missing file means no file flags.
+func (t *Tenant) loadFlags() error {
+	file := t.resolve(flagsFile)
+	if t.generation != nil {
+		if err := t.generation.Sources().WatchFile(file); err != nil {
+			return err
+		}
+	}
+	t.flags = &flagRuntime{file: map[string]*flags.Flag{}}
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err == nil {
+		t.flags.file, err = flags.Parse(data)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", flagsFile, err)
+	}
+	return nil
+}
+
+func (t *Tenant) flagRuntime() *flagRuntime {
+	t.flagsOnce.Do(func() {
+		if t.flags == nil {
+			t.flags = &flagRuntime{file: map[string]*flags.Flag{}}
+		}
+	})
+	return t.flags
+}
+
+// flagStore opens the tenant's flags database on first use.
+func (t *Tenant) flagStore() (*flags.Store, error) {
+	rt := t.flagRuntime()
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	if rt.store != nil {
+		return rt.store, nil
+	}
+	store, err := flags.NewStore(sqliteFor(t, "flags.db").db())
+	if err != nil {
+		return nil, err
+	}
+	rt.store = store
+	return store, nil
+}
+
+// flag looks a definition up, database first.
+func (t *Tenant) flag(name string) *flags.Flag {
+	rt := t.flagRuntime()
+	rt.mu.Lock()
+	stale := time.Since(rt.loaded) > flagRefresh
+	rt.mu.Unlock()
+	if stale {
+		if store, err := t.flagStore(); err == nil {
+			stored, err := store.Definitions()
+			rt.mu.Lock()
+			if err == nil {
+				rt.stored = stored
+			}
+			rt.loaded = time.Now() // a broken table is retried after flagRefresh, not on every read
+			rt.mu.Unlock()
+		}
+	}
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	if f := rt.stored[name]; f != nil {
+		return f
+	}
+	return rt.file[name]
+}
+
+// visitorSecret signs the visitor cookie: FLAGS_SECRET from the environment, else a random key kept
+// in .data/flags.key so every node of the site and every restart agree.
+func (t *Tenant) visitorSecret() []byte {
+	rt := t.flagRuntime()
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	if rt.secret != nil {
+		return rt.secret
+	}
+	if env, ok := t.env.V.(*envVars); ok {
+		if secret, ok := env.lookup("FLAGS_SECRET"); ok {
+			rt.secret = []byte(secret)
+			return rt.secret
+		}
+	}
+	keyFile := t.resolve(".data", "flags.key")
+	if data, err := os.ReadFile(keyFile); err == nil && len(data) >= 32 {
+		rt.secret = data
+		return rt.secret
+	}
+	key := []byte(hex.EncodeToString([]byte(flags.NewVisitor() + flags.NewVisitor())))
+	if err := os.MkdirAll(t.resolve(".data"), 0o755); err == nil {
+		_ = os.WriteFile(keyFile, key, 0o600)
+	}
+	rt.secret = key
+	return rt.secret
+}
+
+// flagEvaluator answers the flags one request asks about, once each.
+type flagEvaluator struct {
+	router    *Router
+	visitor   string
+	attrs     flags.Attributes
+	decisions map[string]flags.Decision // answers for the request's own attributes
+	refined   map[string]bool           // flags also asked about with extra attributes
+	exposures []flags.Exposure
+}
+
+func (r *Router) flagEvaluator() *flagEvaluator {
+	if r.flags == nil {
+		r.flags = &flagEvaluator{router: r, decisions: map[string]flags.Decision{}}
+	}
+	return r.flags
+}
+
+// ensureVisitor reads the signed visitor cookie, or issues one. The cookie goes straight onto the
+// writer so a cached response carries it too.
+func (e *flagEvaluator) ensureVisitor() string {
+	if e.visitor != "" {
+		return e.visitor
+	}
+	secret := e.router.tenant.visitorSecret()
+	if cookie, err := e.router.request.Cookie(visitorCookie); err == nil {
+		if id, ok := flags.Verify(cookie.Value, secret); ok {
+			e.visitor = id
+			return id
+		}
+	}
+	e.visitor = flags.NewVisitor()
+	http.SetCookie(e.router.responseWriter, &http.Cookie{
+		Name: visitorCookie, Value: flags.Sign(e.visitor, secret), Path: "/",
+		MaxAge: 365 * 24 * 3600, HttpOnly: true, SameSite: http.SameSiteLaxMode,
+		Secure: e.router.request.TLS != nil,
+	})
+	return e.visitor
+}
+
+// attributes are what rules match on: user, ip, country and every header as header.<name>.
+func (e *flagEvaluator) attributes() flags.Attributes {
+	if e.attrs != nil {
+		return e.attrs
+	}
+	r := e.router.request
+	attrs := flags.Attributes{"ip": GetClientIP(r)}
+	for _, name := range []string{"CF-IPCountry", "CloudFront-Viewer-Country", "X-Vercel-IP-Country", "X-Country-Code"} {
+		if country := r.Header.Get(name); country != "" {
+			attrs["country"] = strings.ToUpper(country)
+			break
+		}
+	}
+	for name, values := range r.Header {
+		attrs["header."+strings.ToLower(name)] = strings.Join(values, ",")
+	}
+	if e.router.user != "" {
+		attrs["user"] = e.router.user
+	}
+	e.attrs = attrs
+	return attrs
+}
+
+// decide evaluates a flag for this request; extra attributes (ctx.flag's second argument) refine
+// the request's own. An answer for extra attributes is not remembered: templates and cache keys
+// only ever see the request's own. An unknown flag is off.
+func (e *flagEvaluator) decide(name string, extra map[string]string) flags.Decision {
+	if d, ok := e.decisions[name]; ok && len(extra) == 0 {
+		return d
+	}
+	definition := e.router.tenant.flag(name)
+	if definition == nil {
+		d := flags.Decision{Flag: name, Variant: "off", Reason: "unknown"}
+		e.decisions[name] = d
+		return d
+	}
+	attrs := e.attributes()
+	if len(extra) > 0 {
+		merged := make(flags.Attributes, len(attrs)+len(extra))
+		for k, v := range attrs {
+			merged[k] = v
+		}
+		for k, v := range extra {
+			merged[strings.ToLower(k)] = v
+		}
+		attrs = merged
+	}
+	bucketKey := attrs["user"]
+	if bucketKey == "" && (len(definition.Variants) > 0 || definition.Rollout < 100) {
+		bucketKey = e.ensureVisitor()
+	}
+	d := definition.Evaluate(bucketKey, attrs)
+	_, seen := e.decisions[name]
+	if len(extra) > 0 {
+		seen = e.refined[name]
+		if e.refined == nil {
+			e.refined = map[string]bool{}
+		}
+		e.refined[name] = true
+	}
+	if !seen && d.Random() {
+		e.exposures = append(e.exposures, flags.Exposure{Flag: name, Variant: d.Variant, Visitor: bucketKey, Path: e.router.request.URL.Path})
+	}
+	if len(extra) == 0 {
+		e.decisions[name] = d
+	}
+	return d
+}
+
+// Flag implements render.Flagger: true/false for a plain flag, the variant name for an experiment.
+func (e *flagEvaluator) Flag(name string) value.Value {
+	return flagValue(e.router.tenant.flag(name), e.decide(name, nil))
+}
+
+func flagValue(definition *flags.Flag, d flags.Decision) value.Value {
+	if definition == nil || len(definition.Variants) == 0 || d.Variant == "off" {
+		return value.New(d.On)
+	}
+	return value.New(d.Variant)
+}
+
+// record writes the request's exposures. serveTree defers it, so it runs after the response.
+func (e *flagEvaluator) record() {
+	if e == nil || len(e.exposures) == 0 {
+		return
+	}
+	store, err := e.router.tenant.flagStore()
+	if err == nil {
+		err = store.Record(e.exposures)
+	}
+	if err != nil {
+		fmt.Printf("[Flags] %s: exposures not recorded: %v\n", e.router.tenant.appID(), err)
+	}
+}
+
+// flagKey extends a cache key with the variants this request gets for the flags the route is known
+// to read. Routes that never read a flag keep their plain key.
+func (r *Router) flagKey(method *FolderMethod) string {
+	names := method.flagNames.Load()
+	if names == nil {
+		return ""
+	}
+	var b strings.Builder
+	for _, name := range *names {
+		b.WriteString(" flag:" + name + "=" + r.flagEvaluator().decide(name, nil).Variant)
+	}
+	return b.String()
+}
+
+// learnFlags remembers which flags a cacheable route read, so later requests key by them, and
+// returns this response's key suffix. ok is false when the handler read a flag with its own
+// attributes: the response is not stored, and the route stops being looked up in the cache.
+func (r *Router) learnFlags(method *FolderMethod) (suffix string, ok bool) {
+	if r.flags != nil && len(r.flags.refined) > 0 {
+		method.flagsByAttribute.Store(true)
+		return "", false
+	}
+	if r.flags == nil || len(r.flags.decisions) == 0 {
+		return r.flagKey(method), true
+	}
+	for {
+		current := method.flagNames.Load()
+		known := map[string]bool{}
+		var names []string
+		if current != nil {
+			names = append(names, *current...)
+			for _, name := range names {
+				known[name] = true
+			}
+		}
+		added := false
+		for name := range r.flags.decisions {
+			if !known[name] {
+				names = append(names, name)
+				added = true
+			}
+		}
+		if !added {
+			break
+		}
+		sort.Strings(names)
+		if method.flagNames.CompareAndSwap(current, &names) {
+			break
+		}
+	}
+	return r.flagKey(method), true
+}
+
+// Flag answers a feature flag for this request: ctx.flag("new-checkout"), or with attributes for
+// the rules and bucketing, ctx.flag("new-checkout", { user: session.id, plan: "pro" }).
+func (c *Context) Flag(name string, args ...value.Value) value.Value {
+	r := c.router()
+	var extra map[string]string
+	if len(args) > 0 && args[0].IsMap() {
+		extra = map[string]string{}
+		for k, v := range args[0].Map() {
+			extra[k] = scalarText(v)
+		}
+	}
+	return flagValue(r.tenant.flag(name), r.flagEvaluator().decide(name, extra))
+}
+
+// Flags is the flags capability: runtime definitions and the exposure log.
+type Flags struct {
+	tenant *Tenant
+}
+
+func (w *KitWork) Flags() *Flags { return &Flags{tenant: w.tenant} }
+
+func (f *Flags) store() (*flags.Store, value.Value) {
+	store, err := f.tenant.flagStore()
+	if err != nil {
+		return nil, value.Value{K: value.Invalid, V: "flags: " + err.Error()}
+	}
+	return store, value.Value{}
+}
+
+// Set stores a definition in the tenant database; it overrides the file's definition of the key.
+func (f *Flags) Set(key string, definition value.Value) value.Value {
+	store, failure := f.store()
+	if store == nil {
+		return failure
+	}
+	if err := store.Put(key, definition.Interface()); err != nil {
+		return value.Value{K: value.Invalid, V: "flags: " + err.Error()}
+	}
+	f.tenant.flagRuntime().expire()
+	return value.New(true)
+}
+
+// Remove deletes a stored definition.
+func (f *Flags) Remove(key string) value.Value {
+	store, failure := f.store()
+	if store == nil {
+		return failure
+	}
+	if err := store.Delete(key); err != nil {
+		return value.Value{K: value.Invalid, V: "flags: " + err.Error()}
+	}
+	f.tenant.flagRuntime().expire()
+	return value.New(true)
+}
+
+// List names every defined flag and where its definition comes from.
+func (f *Flags) List() value.Value {
+	f.tenant.flag("") // refresh the database definitions
+	rt := f.tenant.flagRuntime()
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	source := map[string]string{}
+	for key := range rt.file {
+		source[key] = "file"
+	}
+	for key := range rt.stored {
+		source[key] = "database"
+	}
+	keys := make([]string, 0, len(source))
+	for key := range source {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	out := make([]any, 0, len(keys))
+	for _, key := range keys {
+		out = append(out, map[string]any{"key": key, "source": source[key]})
+	}
+	return value.New(out)
+}
+
+// Exposures summarises the exposure log per flag and variant; with a key, for that flag only.
+func (f *Flags) Exposures(args ...value.Value) value.Value {
+	store, failure := f.store()
+	if store == nil {
+		return failure
+	}
+	flag := ""
+	if len(args) > 0 {
+		flag = args[0].Text()
+	}
+	summary, err := store.Summarize(flag)
+	if err != nil {
+		return value.Value{K: value.Invalid, V: "flags: " + err.Error()}
+	}
+	out := make([]any, 0, len(summary))
+	for _, s := range summary {
+		out = append(out, map[string]any{"flag": s.Flag, "variant": s.Variant, "exposed": s.Exposed, "visitors": s.Visitors})
+	}
+	return value.New(out)
+}
+
+func (rt *flagRuntime) expire() {
+	rt.mu.Lock()
+	rt.loaded = time.Time{}
+	rt.mu.Unlock()
+}
@@ -0,0 +1,142 @@
+package work
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kitwork/engine/utilities/flags"
+)
+
+// Flags answer per visitor — stickily, through the signed cookie — in handlers and templates; a
+// cached route keeps one entry per variant, and every bucketed answer lands in flag_exposures.
+func TestFlagsBucketingCacheAndExposures(t *testing.T) {
+	tmp := t.TempDir()
+	dir := filepath.Join(tmp, "test", "localhost")
+	write := func(rel, content string) {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("router.kitwork.js", `import { router } from "kitwork";`)
+	write("flags.kitwork.json", `{
+  "everyone": true,
+  "nobody": { "rollout": 0 },
+  "beta": { "rollout": 0, "rules": [ { "header.x-beta": "1" }, { "user": "admin" } ] },
+  "checkout": { "variants": { "control": 50, "redesign": 50 } }
+}`)
+	write("api/router.kitwork.js", `import { router } from "kitwork";
+router.get((ctx) => ({
+	everyone: ctx.flag("everyone"), nobody: ctx.flag("nobody"), beta: ctx.flag("beta"),
+	admin: ctx.flag("beta", { user: "admin" }), checkout: ctx.flag("checkout"), unknown: ctx.flag("unknown"),
+}));`)
+	write("admin/router.kitwork.js", `import { router, flags } from "kitwork";
+router.post((ctx) => flags.set("nobody", { rollout: 100 }));
+router.get((ctx) => ({ list: flags.list(), exposures: flags.exposures("checkout") }));`)
+	write("perks/router.kitwork.js", `import { router } from "kitwork";
+router.get((ctx) => ({ beta: ctx.flag("beta", { user: ctx.header("X-User") }) })).cache("1h");`)
+	write("index.kitwork.html", `<html><body>{{ @page }}</body></html>`)
+	write("shop/page.kitwork.html", `<p>{{ if flag "checkout" == "redesign" }}new{{ else }}old{{ end }}</p>`)
+	write("shop/router.kitwork.js", `import { router } from "kitwork";
+router.get((ctx) => ctx.view()).cache("1h");`)
+	tenant := NewTenant(tmp, "localhost")
+	if err := tenant.Run(); err != nil {
+		t.Fatal(err)
+	}
+	serve := func(method, path string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "http://localhost"+path, nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		tenant.Serve(rec, req)
+		return rec
+	}
+
+	rec := serve(http.MethodGet, "/api", nil)
+	body := rec.Body.String()
+	for _, want := range []string{`"everyone":true`, `"nobody":false`, `"beta":false`, `"admin":true`, `"unknown":false`} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("/api missing %s: %s", want, body)
+		}
+	}
+	cookie := rec.Header().Get("Set-Cookie")
+	if !strings.HasPrefix(cookie, visitorCookie+"=") || !strings.Contains(cookie, "HttpOnly") {
+		t.Fatalf("visitor cookie = %q", cookie)
+	}
+	visitor := strings.SplitN(strings.TrimPrefix(cookie, visitorCookie+"="), ";", 2)[0]
+	id, ok := flags.Verify(visitor, tenant.visitorSecret())
+	if !ok {
+		t.Fatalf("cookie %q does not verify", visitor)
+	}
+	variant := tenant.flag("checkout").Evaluate(id, nil).Variant
+	if !strings.Contains(body, `"checkout":"`+variant+`"`) {
+		t.Fatalf("checkout should be %q for visitor %s: %s", variant, id, body)
+	}
+
+	// The cookie keeps the visitor in the same bucket; a header rule turns beta on.
+	rec = serve(http.MethodGet, "/api", map[string]string{"Cookie": visitorCookie + "=" + visitor, "X-Beta": "1"})
+	if rec.Header().Get("Set-Cookie") != "" || !strings.Contains(rec.Body.String(), `"checkout":"`+variant+`"`) ||
+		!strings.Contains(rec.Body.String(), `"beta":true`) {
+		t.Fatalf("returning visitor: %q %s", rec.Header().Get("Set-Cookie"), rec.Body.String())
+	}
+
+	// A forged cookie is replaced.
+	if rec := serve(http.MethodGet, "/api", map[string]string{"Cookie": visitorCookie + "=" + id + ".forged"}); rec.Header().Get("Set-Cookie") == "" {
+		t.Fatal("a forged visitor cookie should be reissued")
+	}
+
+	// The cached page renders per variant: find one visitor in each arm and check neither sees the
+	// other's entry.
+	visitors := map[string]string{}
+	for i := 0; len(visitors) < 2 && i < 100; i++ {
+		id := flags.NewVisitor()
+		visitors[tenant.flag("checkout").Evaluate(id, nil).Variant] = flags.Sign(id, tenant.visitorSecret())
+	}
+	for round := 0; round < 2; round++ {
+		for arm, want := range map[string]string{"control": "<p>old", "redesign": "<p>new"} {
+			rec := serve(http.MethodGet, "/shop", map[string]string{"Cookie": visitorCookie + "=" + visitors[arm]})
+			if !strings.Contains(rec.Body.String(), want) {
+				t.Fatalf("round %d, %s visitor: %s", round, arm, rec.Body.String())
+			}
+			if hit := rec.Header().Get("X-Kitwork-Cache") == "hit"; hit != (round == 1) {
+				t.Fatalf("round %d, %s visitor: cache hit = %v", round, arm, hit)
+			}
+		}
+	}
+
+	// A flag read with the caller's own attributes is that user's answer: the route is not cached,
+	// so the admin's response never reaches another user, even one sharing the visitor cookie.
+	shared := map[string]string{"Cookie": visitorCookie + "=" + visitor}
+	for _, step := range []struct{ user, want string }{{"admin", `"beta":true`}, {"bob", `"beta":false`}, {"admin", `"beta":true`}} {
+		headers := map[string]string{"X-User": step.user}
+		for k, v := range shared {
+			headers[k] = v
+		}
+		rec := serve(http.MethodGet, "/perks", headers)
+		if !strings.Contains(rec.Body.String(), step.want) || rec.Header().Get("X-Kitwork-Cache") != "" {
+			t.Fatalf("/perks as %s: %s (cache %q)", step.user, rec.Body.String(), rec.Header().Get("X-Kitwork-Cache"))
+		}
+	}
+
+	// Runtime definitions override the file; exposures are summarised per variant.
+	if rec := serve(http.MethodPost, "/admin", nil); !strings.Contains(rec.Body.String(), "true") {
+		t.Fatalf("flags.set: %s", rec.Body.String())
+	}
+	if rec := serve(http.MethodGet, "/api", nil); !strings.Contains(rec.Body.String(), `"nobody":true`) {
+		t.Fatalf("database definition should win: %s", rec.Body.String())
+	}
+	body = serve(http.MethodGet, "/admin", nil).Body.String()
+	for _, want := range []string{`{"key":"nobody","source":"database"}`, `{"key":"checkout","source":"file"}`, `"variant":"redesign"`, `"variant":"control"`} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("/admin missing %s: %s", want, body)
+		}
+	}
+}
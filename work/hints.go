@@ -0,0 +1,113 @@
+package work
+
+// 103 Early Hints for pages. While the VM is still producing a page, the browser is already told
+// which assets that page will ask for — the KitJS runtime, vendored font faces, linked
+// stylesheets — so it can fetch them in parallel instead of after the HTML arrives. The asset set
+// comes from the render plan (found once per generation while the page template is prepared). The
+// 103 goes out only once the folder and method guards have let the request in, and the same Link
+// header is repeated on the final response, for clients and proxies that ignore 1xx, only when
+// that response is a 2xx HTML page — never on a redirect or an error.
+//
+//	router.hints(false)                        // this folder and below: no hints
+//	router.hints(["/hero.avif", "/app.css"])   // announce these too
+//	router.hints({ enabled: true, assets: ["/fonts/brand.woff2"] })
+//
+// Hints are on by default. They go only to GET requests from HTTP/1.1+ clients that accept HTML,
+// for folders that have a page.
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/kitwork/engine/render"
+	"github.com/kitwork/engine/value"
+)
+
+type hintPolicy struct {
+	disabled bool
+	assets   []render.Preload
+}
+
+// Hints configures early hints for this folder and its subfolders. The deepest declaration wins.
+func (f *FolderRouter) Hints(v value.Value) *FolderRouter {
+	policy := &hintPolicy{}
+	var assets value.Value
+	switch {
+	case v.IsBool() || v.K == value.Nil:
+		policy.disabled = !v.Truthy()
+	case v.IsMap():
+		mp := v.Map()
+		if enabled, ok := mp["enabled"]; ok {
+			policy.disabled = !enabled.Truthy()
+		}
+		assets = mp["assets"]
+	default:
+		assets = v
+	}
+	for _, href := range corsList(assets) {
+		if preload, ok := render.PreloadFor(href); ok {
+			policy.assets = append(policy.assets, preload)
+		}
+	}
+	f.hints = policy
+	return f
+}
+
+// earlyHints lists the Link values for a page request, or nil when none should be sent.
+func (t *Tenant) earlyHints(r *http.Request, chain []*RouteNode, node *RouteNode) []string {
+	if r.Method != http.MethodGet || !r.ProtoAtLeast(1, 1) || !strings.Contains(r.Header.Get("Accept"), "text/html") ||
+		!t.folderHasPage(node) {
+		return nil
+	}
+	var policy *hintPolicy
+	for _, n := range chain {
+		if n.folder != nil && n.folder.hints != nil {
+			policy = n.folder.hints
+		}
+	}
+	if policy != nil && policy.disabled {
+		return nil
+	}
+	var preloads []render.Preload
+	if renderer := t.treeRender(node); renderer != nil {
+		preloads = renderer.preloads
+	}
+	if policy != nil {
+		preloads = append(append([]render.Preload(nil), preloads...), policy.assets...)
+	}
+	links := make([]string, 0, len(preloads))
+	seen := map[string]bool{}
+	for _, preload := range preloads {
+		if !seen[preload.Href] {
+			seen[preload.Href] = true
+			links = append(links, preload.Link())
+		}
+	}
+	return links
+}
+
+// sendEarlyHints flushes a 103 carrying the Link headers ahead of the real response. They come off
+// the header map again and stay on the router until the final response is known (writeHintLinks).
+func (r *Router) sendEarlyHints(w http.ResponseWriter, links []string) {
+	if len(links) == 0 {
+		return
+	}
+	for _, link := range links {
+		w.Header().Add("Link", link)
+	}
+	w.WriteHeader(http.StatusEarlyHints)
+	w.Header().Del("Link")
+	r.hints = links
+}
+
+// writeHintLinks repeats the announced Link headers on a 2xx HTML response. Call it once the
+// Content-Type is set, before WriteHeader.
+func (r *Router) writeHintLinks(w http.ResponseWriter) {
+	if len(r.hints) == 0 || r.response.Code() < 200 || r.response.Code() >= 300 ||
+		!strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") {
+		return
+	}
+	for _, link := range r.hints {
+		w.Header().Add("Link", link)
+	}
+}
@@ -0,0 +1,92 @@
+package work
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// A page's critical assets go out in a 103 before the handler runs, and again as Link on a 2xx HTML
+// final response; router.hints(false) turns that off for a folder and below. A request the guards
+// refuse gets no hints, and a redirect or an error keeps no Link.
+func TestEarlyHintsForPages(t *testing.T) {
+	tmp := t.TempDir()
+	dir := filepath.Join(tmp, "test", "localhost")
+	write := func(rel, content string) {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("router.kitwork.js", `import { router } from "kitwork";
+router.hints(["/hero.avif"]);`)
+	write("index.kitwork.html", `<!doctype html><head><link rel="stylesheet" href="/site.css"></head><body>{{ @page }}</body>`)
+	write("page.kitwork.html", `<main>home</main>`)
+	write("plain/router.kitwork.js", `import { router } from "kitwork";
+router.hints(false);`)
+	write("plain/page.kitwork.html", `<main>plain</main>`)
+	write("api/router.kitwork.js", `import { router } from "kitwork";
+router.get(() => ({ ok: true }));`)
+	write("members/router.kitwork.js", `import { router } from "kitwork";
+router.guard((ctx) => ctx.status(403).text("members only"));`)
+	write("members/page.kitwork.html", `<main>secret</main>`)
+	write("moved/router.kitwork.js", `import { router } from "kitwork";
+router.get((ctx) => ctx.redirect("/"));`)
+	write("moved/page.kitwork.html", `<main>moved</main>`)
+	write("broken/router.kitwork.js", `import { router } from "kitwork";
+router.get((ctx) => ctx.status(500).html("<p>down</p>"));`)
+	write("broken/page.kitwork.html", `<main>broken</main>`)
+	tenant := NewTenant(tmp, "localhost")
+	if err := tenant.Run(); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(tenant.Serve))
+	defer server.Close()
+
+	get := func(path string) (hints []string, final *http.Response) {
+		trace := &httptrace.ClientTrace{Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+			if code == http.StatusEarlyHints {
+				hints = append(hints, header["Link"]...)
+			}
+			return nil
+		}}
+		req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		req.Host = "localhost"
+		req.Header.Set("Accept", "text/html,*/*;q=0.8")
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+		client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return hints, resp
+	}
+
+	want := []string{"</site.css>; rel=preload; as=style", "</hero.avif>; rel=preload; as=image"}
+	hints, resp := get("/")
+	if resp.StatusCode != http.StatusOK || strings.Join(hints, ",") != strings.Join(want, ",") {
+		t.Fatalf("/ hints = %q (status %d)", hints, resp.StatusCode)
+	}
+	if got := resp.Header.Values("Link"); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("/ final Link = %q", got)
+	}
+	for _, path := range []string{"/plain", "/api", "/members"} {
+		if hints, resp := get(path); len(hints) != 0 || resp.Header.Get("Link") != "" {
+			t.Fatalf("%s hints = %q, Link = %q", path, hints, resp.Header.Get("Link"))
+		}
+	}
+	for path, status := range map[string]int{"/moved": http.StatusSeeOther, "/broken": http.StatusInternalServerError} {
+		if _, resp := get(path); resp.StatusCode != status || resp.Header.Get("Link") != "" {
+			t.Fatalf("%s = %d, Link = %q", path, resp.StatusCode, resp.Header.Get("Link"))
+		}
+	}
+}
@@ -0,0 +1,344 @@
+package work
+
+// Internationalised routing: one folder tree, several languages.
+//
+//	router.locales(["vi", "en"], { default: "vi" })                      // /about, /en/about
+//	router.locales(["vi", "en"], { default: "vi", strategy: "domain",
+//		domains: { vi: "example.vn", en: "example.com" } })
+//
+// Declared once, in the root router. With the prefix strategy the first path segment names the
+// locale and is stripped before the folder tree resolves, so /en/about serves the about/ folder;
+// the default locale needs no prefix. With the domain strategy the host names it. When the URL does
+// not, the locale cookie (cookie: "locale") and then Accept-Language decide, and a page navigation
+// to an unprefixed URL in another language is redirected to its prefixed form. detect: false keeps
+// the URL authoritative.
+//
+// Messages live in _i18n/<locale>.json (see utilities/i18n). Handlers translate with ctx.t("key",
+// { name }) and read ctx.locale; templates write {{ t "key" }}. $.meta.language follows the active
+// locale, every page gets hreflang alternates in <head> ($.meta.alternates), and router.sitemap()
+// lists each language version with its alternates.
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kitwork/engine/utilities/i18n"
+	"github.com/kitwork/engine/value"
+)
+
+type localeConfig struct {
+	locales  []string
+	fallback string            // the default locale: unprefixed URLs, missing messages
+	strategy string            // "prefix" | "domain"
+	domains  map[string]string // locale → host, for the domain strategy
+	cookie   string
+	detect   bool // consult the cookie and Accept-Language when the URL names no locale
+	bundle   *i18n.Bundle
+}
+
+// Locales declares the site's languages and loads their message catalogs.
+func (f *FolderRouter) Locales(list value.Value, options ...value.Value) *FolderRouter {
+	if f.node.parent != nil {
+		f.setDeclarationError(fmt.Errorf("router.locales() belongs in the root router"))
+		return f
+	}
+	lc := &localeConfig{strategy: "prefix", cookie: "locale", detect: true, domains: map[string]string{}}
+	for _, locale := range corsList(list) {
+		if locale = strings.Trim(strings.TrimSpace(locale), "/"); locale != "" && !strings.ContainsAny(locale, "/. ") {
+			lc.locales = append(lc.locales, locale)
+		}
+	}
+	if len(lc.locales) == 0 {
+		f.setDeclarationError(fmt.Errorf("router.locales() needs at least one locale"))
+		return f
+	}
+	lc.fallback = lc.locales[0]
+	if len(options) > 0 && options[0].IsMap() {
+		opts := options[0].Map()
+		if def := scalarText(opts["default"]); def != "" {
+			if lc.locale(def) == "" {
+				f.setDeclarationError(fmt.Errorf("router.locales(): default %q is not one of the locales", def))
+				return f
+			}
+			lc.fallback = lc.locale(def)
+		}
+		switch strategy := scalarText(opts["strategy"]); strategy {
+		case "", "prefix":
+		case "domain":
+			lc.strategy = strategy
+		default:
+			f.setDeclarationError(fmt.Errorf("router.locales(): unknown strategy %q", strategy))
+			return f
+		}
+		if domains := opts["domains"]; domains.IsMap() {
+			for locale, host := range domains.Map() {
+				if known := lc.locale(locale); known != "" {
+					lc.domains[known] = strings.ToLower(host.Text())
+				}
+			}
+		}
+		if cookie := scalarText(opts["cookie"]); cookie != "" {
+			lc.cookie = cookie
+		}
+		if detect, ok := opts["detect"]; ok {
+			lc.detect = detect.Truthy()
+		}
+	}
+	if lc.strategy == "domain" && len(lc.domains) == 0 {
+		f.setDeclarationError(fmt.Errorf("router.locales(): the domain strategy needs domains"))
+		return f
+	}
+
+	lc.bundle = i18n.NewBundle(lc.fallback)
+	for _, locale := range lc.locales {
+		file := filepath.Join(f.node.diskPath(), "_i18n", locale+".json")
+		f.node.srcFiles = append(f.node.srcFiles, file) // watched even when absent, like router files
+		data, err := os.ReadFile(file)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err == nil {
+			var catalog *i18n.Catalog
+			if catalog, err = i18n.Parse(data); err == nil {
+				lc.bundle.Add(locale, catalog)
+				continue
+			}
+		}
+		f.setDeclarationError(fmt.Errorf("_i18n/%s.json: %w", locale, err))
+		return f
+	}
+	f.locales = lc
+	return f
+}
+
+// locale returns the declared spelling of a locale name, or "".
+func (lc *localeConfig) locale(name string) string {
+	for _, locale := range lc.locales {
+		if strings.EqualFold(locale, name) {
+			return locale
+		}
+	}
+	return ""
+}
+
+// localeRequest is what locale detection decided for one request.
+type localeRequest struct {
+	locale    string
+	path      string // the request path without its locale prefix: what the folder tree resolves
+	explicit  bool   // the URL (prefix or host) named the locale
+	redirect  string // a page navigation that belongs under another locale's prefix
+	negotiate bool   // the answer depends on the cookie or Accept-Language
+}
+
+func (lc *localeConfig) resolve(r *http.Request) localeRequest {
+	lr := localeRequest{path: r.URL.Path}
+	switch lc.strategy {
+	case "prefix":
+		first, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+		if locale := lc.locale(first); locale != "" {
+			lr.locale, lr.path, lr.explicit = locale, "/"+rest, true
+		}
+	case "domain":
+		host := strings.ToLower(r.Host)
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		for locale, domain := range lc.domains {
+			if domain == host {
+				lr.locale, lr.explicit = locale, true
+			}
+		}
+	}
+	if lr.explicit {
+		return lr
+	}
+	lr.locale = lc.fallback
+	if !lc.detect {
+		return lr
+	}
+	lr.negotiate = true
+	if cookie, err := r.Cookie(lc.cookie); err == nil && lc.locale(cookie.Value) != "" {
+		lr.locale = lc.locale(cookie.Value)
+	} else if matched := i18n.Match(r.Header.Get("Accept-Language"), lc.locales); matched != "" {
+		lr.locale = matched
+	}
+	if lc.strategy == "prefix" && lr.locale != lc.fallback &&
+		(r.Method == http.MethodGet || r.Method == http.MethodHead) && strings.Contains(r.Header.Get("Accept"), "text/html") {
+		lr.redirect = lc.href(lr.locale, lr.path)
+		if r.URL.RawQuery != "" {
+			lr.redirect += "?" + r.URL.RawQuery
+		}
+	}
+	return lr
+}
+
+// href is where locale's version of a (prefix-free) path lives: a path for the prefix strategy,
+// a scheme-relative URL for the domain strategy, "" for a locale without a domain.
+func (lc *localeConfig) href(locale, routePath string) string {
+	if lc.strategy == "domain" {
+		if domain := lc.domains[locale]; domain != "" {
+			return "//" + domain + routePath
+		}
+		return ""
+	}
+	if locale == lc.fallback {
+		return routePath
+	}
+	if routePath == "/" {
+		return "/" + locale
+	}
+	return "/" + locale + routePath
+}
+
+// alternates lists every language version of routePath as { hreflang, href }, plus x-default.
+func (lc *localeConfig) alternates(scheme, host, routePath string) []map[string]string {
+	absolute := func(href string) string {
+		if strings.HasPrefix(href, "//") {
+			return scheme + ":" + href
+		}
+		return scheme + "://" + host + href
+	}
+	var out []map[string]string
+	for _, locale := range lc.locales {
+		if href := lc.href(locale, routePath); href != "" {
+			out = append(out, map[string]string{"hreflang": locale, "href": absolute(href)})
+		}
+	}
+	if href := lc.href(lc.fallback, routePath); href != "" {
+		out = append(out, map[string]string{"hreflang": "x-default", "href": absolute(href)})
+	}
+	return out
+}
+
+// alternateLinks renders alternates as <link rel="alternate"> tags for <head>.
+func alternateLinks(alternates []map[string]string) string {
+	var b strings.Builder
+	for _, alt := range alternates {
+		b.WriteString(`<link rel="alternate" hreflang="` + htmlAttr(alt["hreflang"]) + `" href="` + htmlAttr(alt["href"]) + `">`)
+	}
+	return b.String()
+}
+
+func htmlAttr(s string) string {
+	return strings.NewReplacer("&", "&amp;", `"`, "&quot;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// localizeSitemap lists every language version of each sitemap entry, each carrying the full set
+// of alternates. Entries that declare their own alternates are left as written.
+func (lc *localeConfig) localizeSitemap(data value.Value, scheme, host string) value.Value {
+	localize := func(entries []value.Value) value.Value {
+		out := make([]value.Value, 0, len(entries)*len(lc.locales))
+		for _, entry := range entries {
+			fields := map[string]value.Value{}
+			if entry.IsMap() {
+				for k, v := range entry.Map() {
+					fields[k] = v
+				}
+			} else {
+				fields["loc"] = entry
+			}
+			if _, declared := fields["alternates"]; declared {
+				out = append(out, entry)
+				continue
+			}
+			loc := ""
+			for _, name := range []string{"loc", "path", "url"} {
+				if v, ok := fields[name]; ok {
+					loc = scalarText(v)
+					delete(fields, name)
+					break
+				}
+			}
+			routePath := loc
+			if i := strings.Index(loc, "://"); i >= 0 {
+				routePath = "/"
+				if slash := strings.IndexByte(loc[i+3:], '/'); slash >= 0 {
+					routePath = loc[i+3+slash:]
+				}
+			}
+			if !strings.HasPrefix(routePath, "/") {
+				out = append(out, entry)
+				continue
+			}
+			alternates := map[string]value.Value{}
+			for _, alt := range lc.alternates(scheme, host, routePath) {
+				alternates[alt["hreflang"]] = value.New(alt["href"])
+			}
+			for _, locale := range lc.locales {
+				if href, ok := alternates[locale]; ok {
+					localized := make(map[string]value.Value, len(fields)+2)
+					for k, v := range fields {
+						localized[k] = v
+					}
+					localized["loc"] = href
+					localized["alternates"] = value.New(alternates)
+					out = append(out, value.New(localized))
+				}
+			}
+		}
+		return value.New(out)
+	}
+	if data.K == value.Array {
+		return localize(data.Array())
+	}
+	if data.IsMap() {
+		resolved := make(map[string]value.Value, len(data.Map()))
+		for k, v := range data.Map() {
+			resolved[k] = v
+		}
+		for _, key := range []string{"pages", "entries", "items"} {
+			if entries, ok := resolved[key]; ok {
+				resolved[key] = localize(entries.Array())
+				break
+			}
+		}
+		return value.New(resolved)
+	}
+	return data
+}
+
+// localizer is the request's translator: ctx.t() and the template's {{ t "key" }}.
+type localizer struct {
+	bundle *i18n.Bundle
+	locale string
+}
+
+// Translate implements render.Translator. A number stands for the plural count.
+func (l *localizer) Translate(key string, params value.Value) string {
+	var named map[string]any
+	switch {
+	case params.K == value.Number:
+		named = map[string]any{"count": params.N}
+	case params.IsMap():
+		named, _ = params.Interface().(map[string]any)
+	}
+	return l.bundle.Translate(l.locale, key, named)
+}
+
+func (r *Router) localizer() *localizer {
+	if r.locales == nil {
+		return nil
+	}
+	return &localizer{bundle: r.locales.bundle, locale: r.locale}
+}
+
+// Locale is the request's active locale; empty when the site declared none.
+func (c *Context) Locale() value.Value { return value.New(c.router().locale) }
+
+// T translates a message key in the request's locale: ctx.t("greeting", { name }),
+// ctx.t("cart.items", count). Without router.locales() the key comes back unchanged.
+func (c *Context) T(key string, params ...value.Value) value.Value {
+	l := c.router().localizer()
+	if l == nil {
+		return value.New(key)
+	}
+	var p value.Value
+	if len(params) > 0 {
+		p = params[0]
+	}
+	return value.New(l.Translate(key, p))
+}
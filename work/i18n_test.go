@@ -0,0 +1,111 @@
+package work
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// One folder tree serves both languages: the prefix picks the locale, unprefixed navigations follow
+// the cookie or Accept-Language, and pages, handlers and the sitemap all speak the active locale.
+func TestLocalesRoutingAndCatalogs(t *testing.T) {
+	tmp := t.TempDir()
+	dir := filepath.Join(tmp, "test", "localhost")
+	write := func(rel, content string) {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("router.kitwork.js", `import { router } from "kitwork";
+router.locales(["vi", "en"], { default: "vi" });
+router.sitemap(["/", "/about"]);`)
+	write("_i18n/vi.json", `{"site": {"name": "Cửa hàng"}, "greeting": "Xin chào, {name}!", "cart": {"items": {"other": "{count} món"}}}`)
+	write("_i18n/en.json", `{"site": {"name": "Shop"}, "greeting": "Hello, {name}!", "cart": {"items": {"one": "{count} item", "other": "{count} items"}}}`)
+	write("index.kitwork.html", `<html lang="{{ $.meta.language }}"><head><title>{{ t "site.name" }}</title></head><body>{{ @page }}</body></html>`)
+	write("page.kitwork.html", `<main>home</main>`)
+	write("about/page.kitwork.html", `<p>{{ t "cart.items" count }}|{{ hello }}|{{ lang }}</p>`)
+	write("about/router.kitwork.js", `import { router } from "kitwork";
+router.get((ctx) => ctx.view({ count: 1, hello: ctx.t("greeting", { name: "Lan" }), lang: ctx.locale }));`)
+	write("api/router.kitwork.js", `import { router } from "kitwork";
+router.get((ctx) => ({ message: ctx.t("greeting", { name: "Lan" }), missing: ctx.t("nope") }));`)
+	tenant := NewTenant(tmp, "localhost")
+	if err := tenant.Run(); err != nil {
+		t.Fatal(err)
+	}
+	get := func(path string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost"+path, nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		tenant.Serve(rec, req)
+		return rec
+	}
+
+	rec := get("/about", nil)
+	for _, want := range []string{
+		`<html lang="vi">`, `<title>Cửa hàng</title>`, `<p>1 món|Xin chào, Lan!|vi`,
+		`<link rel="alternate" hreflang="vi" href="http://localhost/about">`,
+		`<link rel="alternate" hreflang="en" href="http://localhost/en/about">`,
+		`<link rel="alternate" hreflang="x-default" href="http://localhost/about">`,
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Fatalf("/about missing %q:\n%s", want, rec.Body.String())
+		}
+	}
+	if body := get("/en/about", nil).Body.String(); !strings.Contains(body, `<html lang="en">`) ||
+		!strings.Contains(body, `<title>Shop</title>`) || !strings.Contains(body, `<p>1 item|Hello, Lan!|en`) {
+		t.Fatalf("/en/about:\n%s", body)
+	}
+	if body := get("/en", nil).Body.String(); !strings.Contains(body, "<main>home</main>") || !strings.Contains(body, `lang="en"`) {
+		t.Fatalf("/en:\n%s", body)
+	}
+
+	navigation := map[string]string{"Accept": "text/html", "Accept-Language": "en-US,en;q=0.9"}
+	if rec := get("/about?tab=1", navigation); rec.Code != http.StatusFound || rec.Header().Get("Location") != "/en/about?tab=1" {
+		t.Fatalf("negotiated navigation = %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+	navigation["Cookie"] = "locale=vi"
+	if rec := get("/about", navigation); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `lang="vi"`) {
+		t.Fatalf("cookie choice = %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = get("/api", map[string]string{"Accept-Language": "en"})
+	if rec.Body.String() != `{"message":"Hello, Lan!","missing":"nope"}` || !strings.Contains(rec.Header().Get("Vary"), "Accept-Language") {
+		t.Fatalf("/api = %v %s", rec.Header(), rec.Body.String())
+	}
+
+	sitemap := get("/sitemap.xml", nil).Body.String()
+	for _, want := range []string{
+		`<url><loc>http://localhost/en/about</loc>`,
+		`<url><loc>http://localhost/about</loc>`,
+		`<url><loc>http://localhost/en</loc>`,
+		`<xhtml:link rel="alternate" hreflang="en" href="http://localhost/en/about"/>`,
+	} {
+		if !strings.Contains(sitemap, want) {
+			t.Fatalf("sitemap missing %q:\n%s", want, sitemap)
+		}
+	}
+}
+
+func TestLocalesRejectsUnknownDefault(t *testing.T) {
+	tmp := t.TempDir()
+	dir := filepath.Join(tmp, "test", "localhost")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "router.kitwork.js"), []byte(`import { router } from "kitwork";
+router.locales(["vi", "en"], { default: "fr" });`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewTenant(tmp, "localhost").Run(); err == nil || !strings.Contains(err.Error(), `default "fr"`) {
+		t.Fatalf("Run = %v", err)
+	}
+}
@@ -2,7 +2,10 @@ package work
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/kitwork/engine/utilities/accesslog"
 	"github.com/kitwork/engine/value"
 )
 
@@ -13,3 +16,80 @@ type Log struct {
 }
 
 func (l *Log) Print(v value.Value) { fmt.Println(v) }
+
+// Access reads this site's access log, newest first:
+//
+//	log.access()                                            // the last 100 requests
+//	log.access({ status: 5, since: "1h", limit: 20 })       // 5xx of the last hour
+//	log.access({ route: "/blog/{slug}", cache: "none" })    // uncached hits of one route
+//
+// Filters: since/until (a duration back from now, or an RFC 3339 time), method, route, path
+// (prefix), status (exact, or 4/5 for the class), min_status, ip, trace, cache ("ram", "disk",
+// "none") and limit. Empty when access logging is off; an error for a Combined-format log.
+func (l *Log) Access(args ...value.Value) value.Value {
+	log := l.tenant.AccessLog()
+	if log == nil {
+		return collectionValue([]accesslog.Entry{})
+	}
+	var filter accesslog.Filter
+	if len(args) > 0 && args[0].IsMap() {
+		opts := args[0].Map()
+		filter = accesslog.Filter{
+			Since:     logTime(opts["since"]),
+			Until:     logTime(opts["until"]),
+			Method:    optText(opts["method"]),
+			Route:     optText(opts["route"]),
+			Path:      optText(opts["path"]),
+			Status:    opts["status"].Int(),
+			MinStatus: opts["min_status"].Int(),
+			ClientIP:  optText(opts["ip"]),
+			TraceID:   optText(opts["trace"]),
+			Cache:     optText(opts["cache"]),
+			Limit:     opts["limit"].Int(),
+		}
+	}
+	entries, err := log.Query(filter)
+	if err != nil {
+		return value.Value{K: value.Invalid, V: err.Error()}
+	}
+	return collectionValue(entries)
+}
+
+// Tail returns the last lines of this site's access log as written: log.tail() · log.tail(20).
+func (l *Log) Tail(args ...value.Value) value.Value {
+	log := l.tenant.AccessLog()
+	if log == nil {
+		return collectionValue([]string{})
+	}
+	n := 100
+	if len(args) > 0 && args[0].Int() > 0 {
+		n = args[0].Int()
+	}
+	lines, err := log.Tail(n)
+	if err != nil {
+		return value.Value{K: value.Invalid, V: err.Error()}
+	}
+	return collectionValue(lines)
+}
+
+func optText(v value.Value) string {
+	if v.K != value.String {
+		return ""
+	}
+	return strings.TrimSpace(v.Text())
+}
+
+// logTime reads a filter bound: "15m" means fifteen minutes ago, otherwise an RFC 3339 time.
+func logTime(v value.Value) time.Time {
+	text := optText(v)
+	if text == "" {
+		return time.Time{}
+	}
+	if d, err := time.ParseDuration(text); err == nil {
+		return time.Now().Add(-d)
+	}
+	if at, err := time.Parse(time.RFC3339, text); err == nil {
+		return at
+	}
+	return time.Time{}
+}
@@ -0,0 +1,412 @@
+package work
+
+// Maintenance mode: a site answers 503 with its maintenance page while the engine keeps running.
+// Any of three switches turns it on —
+//
+//	maintenance.kitwork.json at the site root   // a file flag; re-read every maintenanceRefresh
+//	the system DB's domain.maintenance column   // for fleets managed from the control plane
+//	Engine.SetMaintenance / Tenant.SetMaintenance // an admin call, kept across generations
+//
+// — and the file also holds the details:
+//
+//	{
+//	  "retry_after": "30m",                      // Retry-After when no window gives an end
+//	  "allow": ["203.0.113.7", "10.0.0.0/8"],    // these clients pass
+//	  "bypass": "let-me-in",                     // ?maintenance_bypass=let-me-in sets a pass cookie
+//	  "pause": ["cron", "queue"],                // the app's background work waits too
+//	  "windows": [{ "start": "2026-11-01T02:00:00Z", "end": "2026-11-01T04:00:00Z" }],
+//	  "message": "Back at 04:00 UTC"
+//	}
+//
+// An empty file, or one without windows, means "on now"; with windows it is on only inside them
+// unless "enabled": true. maintenance.kitwork.html at the site root replaces the built-in page.
+// State lives in an app resource so a new generation, the app's scheduler and an admin call all
+// see the same switch.
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kitwork/engine/database"
+)
+
+const (
+	maintenanceFile         = "maintenance" + extension + ".json"
+	maintenancePage         = "maintenance" + extension + ".html"
+	maintenanceCookie       = "kw_maintenance"
+	maintenanceBypassQuery  = "maintenance_bypass"
+	maintenanceResourceName = "maintenance"
+	maintenanceRetryAfter   = 5 * time.Minute
+)
+
+// maintenanceRefresh bounds how long a changed file or DB flag takes to apply. A var so tests can
+// shorten it.
+var maintenanceRefresh = 2 * time.Second
+
+// MaintenanceWindow is a scheduled downtime.
+type MaintenanceWindow struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// maintenanceConfig is a parsed maintenance.kitwork.json.
+type maintenanceConfig struct {
+	Enabled    *bool               `json:"enabled"`
+	RetryAfter string              `json:"retry_after"`
+	Allow      []string            `json:"allow"`
+	Bypass     string              `json:"bypass"`
+	Pause      []string            `json:"pause"`
+	Windows    []MaintenanceWindow `json:"windows"`
+	Message    string              `json:"message"`
+
+	retry time.Duration
+	nets  []*net.IPNet
+}
+
+func parseMaintenance(data []byte) (*maintenanceConfig, error) {
+	config := &maintenanceConfig{retry: maintenanceRetryAfter}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return config, nil
+	}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, err
+	}
+	if config.RetryAfter != "" {
+		retry, err := ParseDuration(config.RetryAfter)
+		if seconds, numErr := strconv.Atoi(config.RetryAfter); numErr == nil && seconds > 0 {
+			retry, err = time.Duration(seconds)*time.Second, nil // plain seconds, as in the header
+		}
+		if err != nil {
+			return nil, fmt.Errorf("retry_after: %w", err)
+		}
+		config.retry = retry
+	}
+	for _, entry := range config.Allow {
+		if !strings.Contains(entry, "/") {
+			if strings.Contains(entry, ":") {
+				entry += "/128"
+			} else {
+				entry += "/32"
+			}
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("allow: %q is not an IP address or CIDR range", entry)
+		}
+		config.nets = append(config.nets, network)
+	}
+	for _, kind := range config.Pause {
+		if kind != "cron" && kind != "queue" {
+			return nil, fmt.Errorf("pause: %q is not cron or queue", kind)
+		}
+	}
+	for i, window := range config.Windows {
+		if !window.End.After(window.Start) {
+			return nil, fmt.Errorf("windows[%d]: end must be after start", i)
+		}
+	}
+	return config, nil
+}
+
+// window returns the scheduled window that contains now, if any.
+func (c *maintenanceConfig) window(now time.Time) (MaintenanceWindow, bool) {
+	for _, window := range c.Windows {
+		if !now.Before(window.Start) && now.Before(window.End) {
+			return window, true
+		}
+	}
+	return MaintenanceWindow{}, false
+}
+
+func (c *maintenanceConfig) pauses(kind string) bool {
+	for _, paused := range c.Pause {
+		if paused == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *maintenanceConfig) allows(ip string) bool {
+	addr := net.ParseIP(strings.Trim(ip, "[]"))
+	if addr == nil {
+		return false
+	}
+	for _, network := range c.nets {
+		if network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// bypassToken is the cookie value a correct ?maintenance_bypass= earns: a digest, so the secret
+// itself is never stored in the browser.
+func (c *maintenanceConfig) bypassToken(domain string) string {
+	sum := sha256.Sum256([]byte("kitwork-maintenance\x00" + domain + "\x00" + c.Bypass))
+	return hex.EncodeToString(sum[:16])
+}
+
+// MaintenanceStatus is whether a site is in maintenance and why.
+type MaintenanceStatus struct {
+	Active bool      `json:"active"`
+	Source string    `json:"source,omitempty"` // admin, system, file or window
+	Until  time.Time `json:"until,omitzero"`   // the end of the current window
+	Paused []string  `json:"paused,omitempty"` // background work held while active
+}
+
+// maintenanceSite is one domain's switches. The file and the DB flag are re-read at most every
+// maintenanceRefresh.
+type maintenanceSite struct {
+	domain string
+	dir    string
+
+	mu      sync.Mutex
+	config  *maintenanceConfig // nil without a file
+	page    []byte
+	system  bool
+	forced  bool
+	checked time.Time
+}
+
+func (s *maintenanceSite) refresh(now time.Time) {
+	if !s.checked.IsZero() && now.Sub(s.checked) < maintenanceRefresh {
+		return
+	}
+	s.checked = now
+	s.config, s.page = nil, nil
+	if data, err := os.ReadFile(filepath.Join(s.dir, maintenanceFile)); err == nil {
+		config, parseErr := parseMaintenance(data)
+		if parseErr != nil {
+			// The file is there, so someone meant to switch maintenance on: do, with the defaults.
+			fmt.Printf("[Maintenance] %s: %s: %v — using defaults\n", s.domain, maintenanceFile, parseErr)
+			config = &maintenanceConfig{retry: maintenanceRetryAfter}
+		}
+		s.config = config
+	}
+	if page, err := os.ReadFile(filepath.Join(s.dir, maintenancePage)); err == nil {
+		s.page = page
+	}
+	on, err := database.DomainMaintenance(s.domain)
+	s.system = err == nil && on // no column / no row / DB error → off
+}
+
+// status reports the site's switches at now, with its config (nil without a file).
+func (s *maintenanceSite) status(now time.Time) (MaintenanceStatus, *maintenanceConfig, []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refresh(now)
+	status := MaintenanceStatus{Active: true}
+	config := s.config
+	switch {
+	case s.forced:
+		status.Source = "admin"
+	case s.system:
+		status.Source = "system"
+	case config != nil && config.Enabled != nil && *config.Enabled,
+		config != nil && config.Enabled == nil && len(config.Windows) == 0:
+		status.Source = "file"
+	default:
+		status.Active = false
+	}
+	if config != nil {
+		if window, ok := config.window(now); ok {
+			status.Until = window.End
+			if !status.Active {
+				status.Active, status.Source = true, "window"
+			}
+		}
+		if status.Active {
+			status.Paused = config.Pause
+		}
+	}
+	return status, config, s.page
+}
+
+// maintenanceRuntime is the app resource holding every site's switches.
+type maintenanceRuntime struct {
+	mu    sync.Mutex
+	sites map[string]*maintenanceSite
+}
+
+func (m *maintenanceRuntime) Close() {}
+
+func (m *maintenanceRuntime) site(domain, dir string) *maintenanceSite {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	site := m.sites[domain]
+	if site == nil {
+		site = &maintenanceSite{domain: domain, dir: dir}
+		m.sites[domain] = site
+	}
+	return site
+}
+
+func (t *Tenant) maintenanceRuntime() *maintenanceRuntime {
+	if t == nil || t.appRuntime == nil {
+		return nil
+	}
+	if current, ok := t.appRuntime.Resource(maintenanceResourceName).(*maintenanceRuntime); ok {
+		return current
+	}
+	resource, _, err := t.appRuntime.InstallResource(maintenanceResourceName, &maintenanceRuntime{sites: map[string]*maintenanceSite{}})
+	if err != nil {
+		return nil
+	}
+	current, _ := resource.(*maintenanceRuntime)
+	return current
+}
+
+// maintenanceSite is this tenant's entry; nil for the app tenant.
+func (t *Tenant) maintenanceSite() *maintenanceSite {
+	if t.entity == nil || t.entity.Domain == "" {
+		return nil
+	}
+	runtime := t.maintenanceRuntime()
+	if runtime == nil {
+		return nil
+	}
+	return runtime.site(t.entity.Domain, t.resolve())
+}
+
+// registerMaintenanceSites makes the app tenant aware of every domain of its app, so a site can
+// pause the app's background work before it has served a request.
+func (t *Tenant) registerMaintenanceSites() {
+	runtime := t.maintenanceRuntime()
+	if runtime == nil || t.entity == nil || t.entity.Identity == "" {
+		return
+	}
+	entries, err := os.ReadDir(t.resolveApp())
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		dir := t.resolveApp(entry.Name())
+		if _, err := os.Stat(filepath.Join(dir, RouterFileName)); entry.IsDir() && err == nil {
+			runtime.site(entry.Name(), dir)
+		}
+	}
+}
+
+// SetMaintenance flips the site's admin switch. Turning it off leaves the file, window and system
+// DB switches in charge.
+func (t *Tenant) SetMaintenance(on bool) error {
+	site := t.maintenanceSite()
+	if site == nil {
+		return fmt.Errorf("maintenance needs a site")
+	}
+	site.mu.Lock()
+	site.forced = on
+	site.mu.Unlock()
+	return nil
+}
+
+// Maintenance reports whether the site is in maintenance now.
+func (t *Tenant) Maintenance() MaintenanceStatus {
+	site := t.maintenanceSite()
+	if site == nil {
+		return MaintenanceStatus{}
+	}
+	status, _, _ := site.status(time.Now())
+	return status
+}
+
+// jobsPaused reports whether a site of the app is in maintenance with its kind ("cron" or "queue")
+// of background work paused.
+func (t *Tenant) jobsPaused(kind string) bool {
+	runtime := t.maintenanceRuntime()
+	if runtime == nil {
+		return false
+	}
+	runtime.mu.Lock()
+	sites := make([]*maintenanceSite, 0, len(runtime.sites))
+	for _, site := range runtime.sites {
+		sites = append(sites, site)
+	}
+	runtime.mu.Unlock()
+	now := time.Now()
+	for _, site := range sites {
+		if status, config, _ := site.status(now); status.Active && config != nil && config.pauses(kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// serveMaintenance answers r with the maintenance page while the site is in maintenance and the
+// client is neither allowlisted nor holding the bypass cookie. It reports whether it answered.
+func (t *Tenant) serveMaintenance(w http.ResponseWriter, r *http.Request) bool {
+	site := t.maintenanceSite()
+	if site == nil {
+		return false
+	}
+	now := time.Now()
+	status, config, page := site.status(now)
+	if !status.Active {
+		return false
+	}
+	if config != nil {
+		if config.allows(GetClientIP(r)) {
+			return false
+		}
+		if config.Bypass != "" {
+			token := config.bypassToken(site.domain)
+			if offered := r.URL.Query().Get(maintenanceBypassQuery); offered != "" &&
+				subtle.ConstantTimeCompare([]byte(offered), []byte(config.Bypass)) == 1 {
+				http.SetCookie(w, &http.Cookie{Name: maintenanceCookie, Value: token, Path: "/",
+					HttpOnly: true, Secure: r.TLS != nil, SameSite: http.SameSiteLaxMode})
+				return false
+			}
+			if cookie, err := r.Cookie(maintenanceCookie); err == nil &&
+				subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(token)) == 1 {
+				return false
+			}
+		}
+	}
+
+	retry := maintenanceRetryAfter
+	if config != nil {
+		retry = config.retry
+	}
+	if !status.Until.IsZero() {
+		retry = status.Until.Sub(now)
+	}
+	seconds := int((retry + time.Second - 1) / time.Second)
+	message := "This site is down for maintenance. Please check back soon."
+	if config != nil && config.Message != "" {
+		message = config.Message
+	}
+
+	header := w.Header()
+	header.Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+	header.Set("Cache-Control", "no-store")
+	accept := r.Header.Get("Accept")
+	switch {
+	case page != nil:
+		header.Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write(page)
+	case strings.Contains(accept, "application/json") && !strings.Contains(accept, "text/html"):
+		header.Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]any{"error": "maintenance", "message": message, "retry_after": seconds})
+	default:
+		header.Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintf(w, "<!doctype html><html><head><meta charset=\"utf-8\"><title>Maintenance</title></head>"+
+			"<body><h1>Down for maintenance</h1><p>%s</p></body></html>", html.EscapeString(message))
+	}
+	return true
+}
@@ -0,0 +1,126 @@
+package work
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Maintenance answers 503 with Retry-After and the site's page, lets allowlisted clients and the
+// bypass cookie through, follows scheduled windows and the admin switch, and pauses background work.
+func TestMaintenanceMode(t *testing.T) {
+	defer func(refresh time.Duration) { maintenanceRefresh = refresh }(maintenanceRefresh)
+	maintenanceRefresh = 0
+
+	tmp := t.TempDir()
+	dir := filepath.Join(tmp, "test", "localhost")
+	write := func(rel, content string) {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("router.kitwork.js", `import { router } from "kitwork";
+router.get((ctx) => ctx.text("live"));`)
+	tenant := NewTenant(tmp, "localhost")
+	if err := tenant.Run(); err != nil {
+		t.Fatal(err)
+	}
+	serve := func(path, remote string, headers map[string]string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost"+path, nil)
+		if remote != "" {
+			req.RemoteAddr = remote
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		tenant.Serve(rec, req)
+		return rec
+	}
+	if rec := serve("/", "", nil); rec.Code != http.StatusOK || rec.Body.String() != "live" {
+		t.Fatalf("before maintenance: %d %q", rec.Code, rec.Body.String())
+	}
+
+	write("maintenance.kitwork.json", `{
+  "retry_after": "30m",
+  "allow": ["10.1.0.0/16"],
+  "bypass": "let-me-in",
+  "pause": ["queue"],
+  "message": "Migrating <db>"
+}`)
+	rec := serve("/", "", nil)
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") != "1800" ||
+		!strings.Contains(rec.Body.String(), "Migrating &lt;db&gt;") {
+		t.Fatalf("maintenance page: %d %q %q", rec.Code, rec.Header().Get("Retry-After"), rec.Body.String())
+	}
+	if rec := serve("/", "", map[string]string{"Accept": "application/json"}); !strings.Contains(rec.Body.String(), `"retry_after":1800`) {
+		t.Errorf("json answer: %q", rec.Body.String())
+	}
+	if rec := serve("/", "10.1.2.3:4000", nil); rec.Code != http.StatusOK {
+		t.Errorf("an allowlisted client got %d", rec.Code)
+	}
+	if rec := serve("/?maintenance_bypass=wrong", "", nil); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("a wrong bypass got %d", rec.Code)
+	}
+	rec = serve("/?maintenance_bypass=let-me-in", "", nil)
+	cookies := rec.Result().Cookies()
+	if rec.Code != http.StatusOK || len(cookies) != 1 || strings.Contains(cookies[0].Value, "let-me-in") {
+		t.Fatalf("bypass: %d %v", rec.Code, cookies)
+	}
+	if rec := serve("/", "", nil, cookies[0]); rec.Code != http.StatusOK {
+		t.Errorf("the bypass cookie got %d", rec.Code)
+	}
+	if !tenant.jobsPaused("queue") || tenant.jobsPaused("cron") {
+		t.Error("only the queue should be paused")
+	}
+	write("maintenance.kitwork.html", "<h1>custom</h1>")
+	if rec := serve("/", "", nil); rec.Code != http.StatusServiceUnavailable || rec.Body.String() != "<h1>custom</h1>" {
+		t.Errorf("custom page: %d %q", rec.Code, rec.Body.String())
+	}
+
+	// A scheduled window is on only while it lasts, and its end sets Retry-After.
+	now := time.Now().UTC()
+	window := func(start, end time.Time) string {
+		return `{ "windows": [{ "start": "` + start.Format(time.RFC3339) + `", "end": "` + end.Format(time.RFC3339) + `" }] }`
+	}
+	write("maintenance.kitwork.json", window(now.Add(time.Hour), now.Add(2*time.Hour)))
+	if rec := serve("/", "", nil); rec.Code != http.StatusOK {
+		t.Errorf("before the window: %d", rec.Code)
+	}
+	write("maintenance.kitwork.json", window(now.Add(-time.Minute), now.Add(10*time.Minute)))
+	rec = serve("/", "", nil)
+	retry, _ := strconv.Atoi(rec.Header().Get("Retry-After"))
+	if rec.Code != http.StatusServiceUnavailable || retry < 590 || retry > 600 || tenant.Maintenance().Source != "window" {
+		t.Errorf("inside the window: %d retry %d %+v", rec.Code, retry, tenant.Maintenance())
+	}
+
+	// The admin switch works without a file and survives a new generation of the site.
+	if err := os.Remove(filepath.Join(dir, "maintenance.kitwork.json")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tenant.SetMaintenance(true); err != nil {
+		t.Fatal(err)
+	}
+	next := NewTenantWithRuntime(tmp, "localhost", tenant.AppRuntime(), tenant.SiteRuntime(), nil)
+	if status := next.Maintenance(); !status.Active || status.Source != "admin" {
+		t.Errorf("admin switch on the next generation: %+v", status)
+	}
+	if err := tenant.SetMaintenance(false); err != nil {
+		t.Fatal(err)
+	}
+	if rec := serve("/", "", nil); rec.Code != http.StatusOK {
+		t.Errorf("after maintenance: %d", rec.Code)
+	}
+}
@@ -0,0 +1,226 @@
+package work
+
+// Content negotiation: one route, several representations, picked by the Accept header.
+//
+//	router.get((ctx, res) => res.format({
+//		html: () => ctx.view({ orders }),
+//		json: () => orders,
+//		csv:  () => toCSV(orders),
+//		default: () => orders,
+//	}));
+//
+// Keys are short names (html, json, csv, text, xml, …) or full media types. The branch with the
+// highest Accept quality wins; when the client accepts several equally, html beats json beats the
+// rest in alphabetical order. Every negotiated response carries Vary: Accept. With nothing
+// acceptable the default branch runs, or the answer is 406. A .cache()/.persist() route keys its
+// entries by the negotiated type, so an HTML page is never replayed to a JSON client.
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kitwork/engine/compiler"
+	"github.com/kitwork/engine/runtime"
+	"github.com/kitwork/engine/value"
+)
+
+// formatTypes maps the short names res.format() accepts to their media types.
+var formatTypes = map[string]string{
+	"html": "text/html",
+	"json": "application/json",
+	"text": "text/plain",
+	"csv":  "text/csv",
+	"xml":  "application/xml",
+	"rss":  "application/rss+xml",
+	"atom": "application/atom+xml",
+	"ics":  "text/calendar",
+	"yaml": "application/yaml",
+	"md":   "text/markdown",
+}
+
+// Format answers with the branch that best matches the request's Accept header. The branch runs
+// after the handler returns; see negotiateFormat.
+func (r *Response) Format(branches value.Value) {
+	if !branches.IsMap() || len(branches.Map()) == 0 {
+		r.ErrorString("res.format: expected a map of media types to handlers", http.StatusInternalServerError)
+		return
+	}
+	r.Return(branches, "format")
+}
+
+func (c *Context) Format(branches value.Value) { c.Response().Format(branches) }
+
+// formatOffer is one branch of a res.format() map.
+type formatOffer struct {
+	key       string // as written: "json", "text/csv"
+	mediaType string
+}
+
+// formatOffers lists a format map's branches in tie-break order.
+func formatOffers(branches map[string]value.Value) []formatOffer {
+	offers := make([]formatOffer, 0, len(branches))
+	for key := range branches {
+		if key == "default" {
+			continue
+		}
+		mediaType := strings.ToLower(strings.TrimSpace(key))
+		if known, ok := formatTypes[mediaType]; ok {
+			mediaType = known
+		} else if !strings.Contains(mediaType, "/") {
+			guessed, _, _ := mime.ParseMediaType(mime.TypeByExtension("." + mediaType))
+			if guessed == "" {
+				continue
+			}
+			mediaType = guessed
+		}
+		offers = append(offers, formatOffer{key: key, mediaType: mediaType})
+	}
+	rank := func(mediaType string) int {
+		switch mediaType {
+		case "text/html":
+			return 0
+		case "application/json":
+			return 1
+		}
+		return 2
+	}
+	sort.Slice(offers, func(i, j int) bool {
+		if ri, rj := rank(offers[i].mediaType), rank(offers[j].mediaType); ri != rj {
+			return ri < rj
+		}
+		return offers[i].mediaType < offers[j].mediaType
+	})
+	return offers
+}
+
+// negotiate picks the offer the Accept header prefers, or -1 when none is acceptable. A missing
+// header accepts anything.
+func negotiate(accept string, offers []formatOffer) int {
+	if strings.TrimSpace(accept) == "" {
+		accept = "*/*"
+	}
+	type mediaRange struct {
+		typ, sub    string
+		q           float64
+		specificity int
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		fields := strings.Split(part, ";")
+		typ, sub, found := strings.Cut(strings.ToLower(strings.TrimSpace(fields[0])), "/")
+		if !found {
+			continue
+		}
+		rng := mediaRange{typ: typ, sub: sub, q: 1}
+		for _, param := range fields[1:] {
+			name, val, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(name, "q") {
+				if q, err := strconv.ParseFloat(val, 64); err == nil {
+					rng.q = q
+				}
+			}
+		}
+		switch {
+		case typ == "*":
+			rng.specificity = 0
+		case sub == "*":
+			rng.specificity = 1
+		default:
+			rng.specificity = 2
+		}
+		ranges = append(ranges, rng)
+	}
+
+	best, bestQ := -1, 0.0
+	for i, offer := range offers {
+		typ, sub, _ := strings.Cut(offer.mediaType, "/")
+		q, specificity := 0.0, -1
+		for _, rng := range ranges {
+			if (rng.typ == "*" || rng.typ == typ) && (rng.sub == "*" || rng.sub == sub) && rng.specificity > specificity {
+				q, specificity = rng.q, rng.specificity
+			}
+		}
+		if q > bestQ {
+			best, bestQ = i, q
+		}
+	}
+	return best
+}
+
+// negotiatedKey extends a cache key with the type the request would negotiate, once the route is
+// known to answer with res.format(). Routes that never negotiated keep their plain key.
+func negotiatedKey(method *FolderMethod, key string, r *http.Request) string {
+	offers := method.formats.Load()
+	if offers == nil {
+		return key
+	}
+	if chosen := negotiate(r.Header.Get("Accept"), *offers); chosen >= 0 {
+		return key + " accept=" + (*offers)[chosen].mediaType
+	}
+	return key + " accept=default"
+}
+
+// negotiateFormat runs the res.format() branch the request accepts and leaves its answer on the
+// response. It returns what the cache key records: the chosen media type, "default" for the
+// fallback branch, or "" when the answer is a 406.
+func (t *Tenant) negotiateFormat(
+	vm *runtime.VM,
+	bc *compiler.Bytecode,
+	method *FolderMethod,
+	ctxObj *Context,
+	w http.ResponseWriter,
+) string {
+	reqRouter := ctxObj.router()
+	response := reqRouter.response
+	branches := response.Data().Map()
+	offers := formatOffers(branches)
+	method.formats.Store(&offers)
+	addVary(w, "Accept")
+
+	chosen := negotiate(reqRouter.request.Header.Get("Accept"), offers)
+	label, mediaType := "default", ""
+	branch, ok := branches["default"]
+	if chosen >= 0 {
+		branch, ok = branches[offers[chosen].key], true
+		label, mediaType = offers[chosen].mediaType, offers[chosen].mediaType
+	}
+	if !ok {
+		response.Return(value.New("Not Acceptable"), "text", http.StatusNotAcceptable)
+		return ""
+	}
+
+	response.Return(value.Value{K: value.Nil}, "")
+	result := branch
+	if branch.K == value.Func {
+		result = t.execTree(vm, bc, lambdaOf(branch), ctxObj)
+		if result.K == value.Invalid {
+			t.recordRuntimeFailure(reqRouter, bc, "format", result)
+			reqRouter.err = fmt.Errorf("%s", result.Text())
+			return label
+		}
+	}
+	if response.IsSend() || reqRouter.viewBuilder != nil || result.IsBlank() {
+		return label // the branch answered itself (ctx.view, res.send, …)
+	}
+	switch {
+	case mediaType == "text/html":
+		response.HTML(result)
+	case mediaType == "application/json", mediaType == "" && (result.K == value.Map || result.K == value.Array):
+		response.JSON(result)
+	case mediaType == "":
+		response.HTML(result)
+	default:
+		if result.K == value.Map || result.K == value.Array {
+			result = value.New(string(result.ToJSON()))
+		}
+		if strings.HasPrefix(mediaType, "text/") {
+			mediaType += "; charset=utf-8"
+		}
+		response.Type(mediaType).Send(result)
+	}
+	return label
+}
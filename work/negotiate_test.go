@@ -0,0 +1,96 @@
+package work
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNegotiatePicksByQuality(t *testing.T) {
+	offers := []formatOffer{{"html", "text/html"}, {"json", "application/json"}, {"csv", "text/csv"}}
+	for accept, want := range map[string]int{
+		"":                                    0,
+		"*/*":                                 0,
+		"application/json":                    1,
+		"text/html;q=0.5, application/json":   1,
+		"text/*;q=0.9, text/csv":              2,
+		"text/*, application/json;q=0.2":      0,
+		"image/png":                           -1,
+		"application/json;q=0, */*;q=0.1":     0,
+		"text/csv;q=0.8, application/*;q=0.8": 1,
+		"application/xml, text/html;q=0":      -1,
+	} {
+		if got := negotiate(accept, offers); got != want {
+			t.Errorf("negotiate(%q) = %d, want %d", accept, got, want)
+		}
+	}
+}
+
+// res.format() answers each client in its own representation, says so with Vary: Accept, refuses
+// what it cannot serve, and caches one entry per negotiated type.
+func TestResponseFormat(t *testing.T) {
+	tmp := t.TempDir()
+	dir := filepath.Join(tmp, "test", "localhost")
+	write := func(rel, content string) {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("router.kitwork.js", `import { router } from "kitwork";`)
+	write("orders/router.kitwork.js", `import { router } from "kitwork";
+router.get((res) => res.format({
+	html: () => "<ul><li>A-1</li></ul>",
+	json: () => [{ id: "A-1" }],
+	csv: () => "id\nA-1\n",
+})).cache("1m");`)
+	write("report/router.kitwork.js", `import { router } from "kitwork";
+router.get((ctx) => ctx.format({ json: { ok: true }, default: () => "plain fallback" }));`)
+	tenant := NewTenant(tmp, "localhost")
+	if err := tenant.Run(); err != nil {
+		t.Fatal(err)
+	}
+	get := func(path, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost"+path, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		rec := httptest.NewRecorder()
+		tenant.Serve(rec, req)
+		return rec
+	}
+
+	for _, round := range []string{"miss", "hit"} {
+		for accept, want := range map[string]struct{ body, contentType string }{
+			"text/html,application/xhtml+xml,*/*;q=0.8": {"<ul><li>A-1</li></ul>", "text/html"},
+			"application/json":                          {`[{"id":"A-1"}]`, "application/json"},
+			"text/csv":                                  {"id\nA-1\n", "text/csv"},
+		} {
+			rec := get("/orders/", accept)
+			if rec.Code != http.StatusOK || rec.Body.String() != want.body ||
+				!strings.HasPrefix(rec.Header().Get("Content-Type"), want.contentType) ||
+				!strings.Contains(rec.Header().Get("Vary"), "Accept") {
+				t.Fatalf("%s %q = %d %v %q", round, accept, rec.Code, rec.Header(), rec.Body.String())
+			}
+			if cached := rec.Header().Get("X-Kitwork-Cache") == "hit"; cached != (round == "hit") {
+				t.Fatalf("%s %q: X-Kitwork-Cache = %q", round, accept, rec.Header().Get("X-Kitwork-Cache"))
+			}
+		}
+	}
+	if rec := get("/orders/", "image/webp"); rec.Code != http.StatusNotAcceptable {
+		t.Fatalf("unacceptable = %d %q", rec.Code, rec.Body.String())
+	}
+
+	if rec := get("/report/", "application/json"); rec.Body.String() != `{"ok":true}` {
+		t.Fatalf("literal branch = %q", rec.Body.String())
+	}
+	if rec := get("/report/", "text/html"); rec.Code != http.StatusOK || rec.Body.String() != "plain fallback" {
+		t.Fatalf("default branch = %d %q", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,337 @@
+package work
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kitwork/engine/utilities/openapi"
+	"github.com/kitwork/engine/utilities/schema"
+	"github.com/kitwork/engine/value"
+)
+
+// OpenAPI declares a generated OpenAPI 3.1 document at /openapi.json, built from the route tree:
+// one path per folder, one operation per declared method, path parameters from {segments}, and the
+// folder's limits, guards and cache tiers as x-kitwork extensions. A map sets the info block and
+// may move the document: router.openapi({ title: "Shop", version: "2.1", path: "/api/spec.json" }).
+// Like rss/sitemap it is answered from the root folder.
+func (f *FolderRouter) OpenAPI(args ...value.Value) *FolderMethod {
+	return f.output("openapi", "/openapi.json", args...)
+}
+
+// Describe attaches documentation to the method for the OpenAPI document:
+// .describe({ summary, description, tags, deprecated, request, responses }). request is a JSON
+// Schema for the body (or a full requestBody with content); responses maps a status to a
+// description string or a response object.
+func (m *FolderMethod) Describe(v value.Value) *FolderMethod {
+	if v.IsString() {
+		v = value.New(map[string]value.Value{"summary": v})
+	}
+	if v.IsMap() {
+		m.describe = v
+	}
+	return m
+}
+
+// OpenAPI renders the tenant's document as the check command sees it: the root folder's
+// router.openapi() declaration with its static info map. ok is false when the site declares none.
+func (t *Tenant) OpenAPI() (document []byte, ok bool, err error) {
+	root := t.routeTree().root
+	if root == nil || !root.folderReady.Load() || root.folder == nil {
+		return nil, false, nil
+	}
+	for _, method := range root.folder.outputs {
+		if method.outputKind == "openapi" {
+			document, err = t.openAPIDocument(method.outputData, "")
+			return document, true, err
+		}
+	}
+	return nil, false, nil
+}
+
+// openAPIDocument walks every prepared folder of the active route tree. base, when set, becomes
+// the single server URL unless the declaration lists its own servers.
+func (t *Tenant) openAPIDocument(data value.Value, base string) ([]byte, error) {
+	info := openapi.Info{Title: t.Domain(), Version: "1.0.0"}
+	if base != "" {
+		info.Servers = []string{base}
+	}
+	if data.IsMap() {
+		fields := data.Map()
+		if title := scalarText(fields["title"]); title != "" {
+			info.Title = title
+		}
+		if version := scalarText(fields["version"]); version != "" {
+			info.Version = version
+		}
+		info.Description = scalarText(fields["description"])
+		if servers := fields["servers"]; servers.K == value.Array || servers.IsString() {
+			info.Servers = textList(servers)
+		}
+	}
+
+	var operations []openapi.Operation
+	for _, node := range t.routeTree().routeNodes() {
+		folder := node.folder
+		if folder == nil || len(folder.methods) == 0 {
+			continue
+		}
+		template, params := openAPIPath(node)
+		chain := folderChain(node)
+		for name, method := range folder.methods {
+			if method.socket != nil || method.outputKind != "" {
+				continue
+			}
+			operations = append(operations, openAPIOperation(name, template, params, chain, method))
+		}
+	}
+	return openapi.JSON(info, operations)
+}
+
+// folderChain lists the compiled folders from the root down to node.
+func folderChain(node *RouteNode) []*FolderRouter {
+	var chain []*FolderRouter
+	for n := node; n != nil; n = n.parent {
+		if n.folder != nil {
+			chain = append(chain, n.folder)
+		}
+	}
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain
+}
+
+// openAPIPath turns the folder's path into an OpenAPI template: {id[number]} → {id} typed as an
+// integer, {slug(re)} → {slug} with a pattern, {...rest} → {rest}.
+func openAPIPath(node *RouteNode) (string, []openapi.Param) {
+	var segments []string
+	var params []openapi.Param
+	for _, seg := range strings.Split(node.relPath(), "/") {
+		if seg == "" {
+			continue
+		}
+		matcher := parseSegment(seg)
+		if matcher == nil {
+			segments = append(segments, seg)
+			continue
+		}
+		param := openapi.Param{Name: matcher.name}
+		switch matcher.kind {
+		case segTyped:
+			switch matcher.typ {
+			case "number", "int", "integer", "digit", "digits":
+				param.Type = "integer"
+			}
+		case segRegex:
+			param.Pattern = matcher.re.String()
+		case segSplat:
+			param.Description = "The rest of the path, slashes included."
+		}
+		segments = append(segments, "{"+matcher.name+"}")
+		params = append(params, param)
+	}
+	return "/" + strings.Join(segments, "/"), params
+}
+
+func openAPIOperation(name, template string, params []openapi.Param, chain []*FolderRouter, method *FolderMethod) openapi.Operation {
+	op := openapi.Operation{
+		Path:       template,
+		Method:     name,
+		Params:     params,
+		Responses:  map[string]any{},
+		Extensions: map[string]any{},
+	}
+
+	guards := len(method.guards)
+	var limits []methodLimit
+	for _, folder := range chain {
+		guards += len(folder.guards)
+		limits = append(limits, folder.limits...)
+	}
+	limits = append(limits, method.limits...)
+	if guards > 0 {
+		op.Extensions["x-kitwork-guards"] = guards
+	}
+	if len(limits) > 0 {
+		rules := make([]any, len(limits))
+		for i, limit := range limits {
+			rules[i] = map[string]any{"rate": limit.Rate, "per": limit.Per.Seconds(), "by": limit.Dim}
+		}
+		op.Extensions["x-ratelimit"] = rules
+		op.Responses["429"] = map[string]any{
+			"description": "Rate limit exceeded",
+			"headers": map[string]any{
+				"Retry-After": map[string]any{"schema": map[string]any{"type": "integer"}},
+			},
+		}
+	}
+	var tiers []string
+	if method.cacheExpiry != nil {
+		tiers = append(tiers, "ram")
+	}
+	if method.persistExpiry != nil {
+		tiers = append(tiers, "disk")
+	}
+	if len(tiers) > 0 {
+		op.Extensions["x-kitwork-cache"] = tiers
+	}
+	if method.isProxy {
+		op.Extensions["x-kitwork-proxy"] = true
+	}
+	if method.body != nil {
+		op.RequestBody = openAPIUploadBody(method.body)
+		op.Responses["413"] = map[string]any{"description": "Request body too large"}
+	}
+	if method.bodySchema != nil {
+		op.RequestBody = map[string]any{
+			"required": true,
+			"content": map[string]any{
+				"application/json": map[string]any{"schema": method.bodySchema.Definition()},
+			},
+		}
+	}
+	if method.querySchema != nil {
+		op.Params = append(append([]openapi.Param(nil), op.Params...), openAPIQueryParams(method.querySchema)...)
+	}
+	if method.bodySchema != nil || method.querySchema != nil {
+		op.Responses["422"] = map[string]any{
+			"description": "Validation failed",
+			"content":     map[string]any{problemMediaType: map[string]any{}},
+		}
+	}
+
+	if !method.describe.IsMap() {
+		return op
+	}
+	described := method.describe.Map()
+	op.Summary = scalarText(described["summary"])
+	op.Description = scalarText(described["description"])
+	op.Deprecated = described["deprecated"].Truthy()
+	if tags := described["tags"]; tags.K == value.Array || tags.IsString() {
+		op.Tags = textList(tags)
+	}
+	if request := described["request"]; request.IsMap() {
+		fields := request.Map()
+		if _, ok := fields["content"]; ok {
+			op.RequestBody, _ = request.Interface().(map[string]any)
+		} else {
+			schema := request.Interface()
+			if nested, ok := fields["schema"]; ok {
+				schema = nested.Interface()
+			}
+			op.RequestBody = map[string]any{
+				"required": true,
+				"content":  map[string]any{"application/json": map[string]any{"schema": schema}},
+			}
+		}
+	}
+	if responses := described["responses"]; responses.IsMap() {
+		for status, response := range responses.Map() {
+			if response.IsMap() {
+				object, _ := response.Interface().(map[string]any)
+				if _, ok := object["description"]; !ok {
+					object["description"] = httpStatusText(status)
+				}
+				op.Responses[status] = object
+				continue
+			}
+			description := scalarText(response)
+			if description == "" {
+				description = httpStatusText(status)
+			}
+			op.Responses[status] = map[string]any{"description": description}
+		}
+	}
+	return op
+}
+
+// openAPIQueryParams lists a .query(schema)'s properties as query parameters.
+func openAPIQueryParams(query *schema.Schema) []openapi.Param {
+	definition := query.Definition()
+	properties, _ := definition["properties"].(map[string]any)
+	required := map[string]bool{}
+	if list, ok := definition["required"].([]any); ok {
+		for _, name := range list {
+			if s, ok := name.(string); ok {
+				required[s] = true
+			}
+		}
+	}
+	var params []openapi.Param
+	for _, name := range query.Properties() {
+		param := openapi.Param{Name: name, In: "query", Required: required[name]}
+		switch property := properties[name].(type) {
+		case string:
+			param.Schema = map[string]any{"type": property}
+		case map[string]any:
+			param.Schema = property
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+// openAPIUploadBody documents a .body() spec as multipart/form-data with one binary property per
+// declared file field.
+func openAPIUploadBody(spec *bodySpec) map[string]any {
+	properties := map[string]any{}
+	names := make([]string, 0, len(spec.files))
+	for name := range spec.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		file := spec.files[name]
+		property := map[string]any{"type": "string", "format": "binary"}
+		if len(file.types) > 0 {
+			property["contentMediaType"] = strings.Join(file.types, ", ")
+		}
+		if file.maxCount > 1 {
+			property = map[string]any{"type": "array", "items": property, "maxItems": file.maxCount}
+		}
+		properties[name] = property
+	}
+	schema := map[string]any{"type": "object"}
+	if len(properties) > 0 {
+		schema["properties"] = properties
+	}
+	body := map[string]any{
+		"content": map[string]any{"multipart/form-data": map[string]any{"schema": schema}},
+	}
+	if spec.maxSize > 0 {
+		body["x-kitwork-max-size"] = spec.maxSize
+	}
+	return body
+}
+
+// scalarText reads a string or number field; anything else — a missing key included — is "".
+func scalarText(v value.Value) string {
+	if v.IsString() || v.IsNumeric() {
+		return v.Text()
+	}
+	return ""
+}
+
+func textList(v value.Value) []string {
+	if v.IsString() {
+		return []string{v.Text()}
+	}
+	var out []string
+	for _, item := range v.Array() {
+		if text := scalarText(item); text != "" {
+			out = append(out, text)
+		}
+	}
+	return out
+}
+
+func httpStatusText(status string) string {
+	if code, err := strconv.Atoi(status); err == nil {
+		if text := http.StatusText(code); text != "" {
+			return text
+		}
+	}
+	return "Response"
+}
@@ -0,0 +1,112 @@
+package work
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// router.openapi() documents every declared method from the tree: typed and pattern params,
+// inherited limits and guards, cache tiers, upload bodies and .describe() metadata.
+func TestOpenAPIDocument(t *testing.T) {
+	tmp := t.TempDir()
+	dir := filepath.Join(tmp, "test", "localhost")
+	write := func(rel, content string) {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("router.kitwork.js", `import { router } from "kitwork";
+router.openapi({ title: "Shop", version: 2, path: "/api/spec.json" });
+router.get((ctx) => ctx.json({ ok: true }));`)
+	write("users/router.kitwork.js", `import { router } from "kitwork";
+router.guard((ctx) => true);
+router.ratelimit({ ip: 100, minute: 1 });
+router.get((ctx) => ctx.json([])).cache("5m").describe("List users");
+router.post((ctx) => ctx.json({})).describe({
+	summary: "Create a user",
+	tags: ["users"],
+	request: { type: "object", required: ["name"], properties: { name: { type: "string" } } },
+	responses: { 201: "Created", 409: { description: "Name taken" } }
+});`)
+	write("users/{id[number]}/router.kitwork.js", `import { router } from "kitwork";
+router.get((ctx) => ctx.json({})).limit(5, "1s");`)
+	write("users/{id[number]}/avatar/router.kitwork.js", `import { router } from "kitwork";
+router.put((ctx) => ctx.json({})).body({ maxSize: "2MB", files: { avatar: { types: ["image/*"] } } });`)
+	write("posts/{slug([a-z-]+)}/router.kitwork.js", `import { router } from "kitwork";
+router.get((ctx) => ctx.json({}));`)
+	tenant := NewTenant(tmp, "localhost")
+	if err := tenant.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	tenant.Serve(rec, httptest.NewRequest(http.MethodGet, "http://localhost/api/spec.json", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") == "" {
+		t.Fatalf("spec = %d %v %s", rec.Code, rec.Header(), rec.Body.String())
+	}
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Info    struct{ Title, Version string }
+		Servers []struct{ URL string }
+		Paths   map[string]map[string]struct {
+			OperationID string `json:"operationId"`
+			Summary     string
+			Tags        []string
+			Parameters  []struct {
+				Name   string
+				Schema map[string]any
+			}
+			RequestBody map[string]any
+			Responses   map[string]map[string]any
+			Guards      int      `json:"x-kitwork-guards"`
+			Limits      []any    `json:"x-ratelimit"`
+			Cache       []string `json:"x-kitwork-cache"`
+		}
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.OpenAPI != "3.1.0" || doc.Info.Title != "Shop" || doc.Info.Version != "2" ||
+		len(doc.Servers) != 1 || doc.Servers[0].URL != "http://localhost" {
+		t.Fatalf("document head = %+v", doc)
+	}
+	if len(doc.Paths) != 5 {
+		t.Fatalf("paths = %v", doc.Paths)
+	}
+
+	list := doc.Paths["/users"]["get"]
+	if list.Summary != "List users" || list.Guards != 1 || len(list.Limits) != 1 || len(list.Cache) != 1 || list.Cache[0] != "ram" ||
+		list.Responses["429"] == nil || list.OperationID != "getUsers" {
+		t.Fatalf("GET /users = %+v", list)
+	}
+	create := doc.Paths["/users"]["post"]
+	if create.Summary != "Create a user" || len(create.Tags) != 1 || create.RequestBody["content"] == nil ||
+		create.Responses["201"]["description"] != "Created" || create.Responses["409"]["description"] != "Name taken" {
+		t.Fatalf("POST /users = %+v", create)
+	}
+	user := doc.Paths["/users/{id}"]["get"]
+	if len(user.Parameters) != 1 || user.Parameters[0].Name != "id" || user.Parameters[0].Schema["type"] != "integer" ||
+		len(user.Limits) != 2 || user.Guards != 1 {
+		t.Fatalf("GET /users/{id} = %+v", user)
+	}
+	avatar := doc.Paths["/users/{id}/avatar"]["put"]
+	if content, _ := avatar.RequestBody["content"].(map[string]any); content["multipart/form-data"] == nil {
+		t.Fatalf("PUT avatar body = %+v", avatar.RequestBody)
+	}
+	if post := doc.Paths["/posts/{slug}"]["get"]; len(post.Parameters) != 1 || post.Parameters[0].Schema["pattern"] != "[a-z-]+" {
+		t.Fatalf("GET /posts/{slug} = %+v", post)
+	}
+
+	document, ok, err := tenant.OpenAPI()
+	if err != nil || !ok || len(document) == 0 {
+		t.Fatalf("OpenAPI() = %d bytes, %v, %v", len(document), ok, err)
+	}
+}
@@ -1,6 +1,7 @@
 package work
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
@@ -15,6 +16,7 @@ import (
 	"github.com/kitwork/engine/database"
 	"github.com/kitwork/engine/runtime"
 	queuestore "github.com/kitwork/engine/utilities/queue"
+	"github.com/kitwork/engine/utilities/trace"
 	"github.com/kitwork/engine/value"
 )
 
@@ -65,12 +67,19 @@ type QueueHandler struct {
 // cron — a noun you call methods on, reached via kitwork().queue.
 type Queue struct {
 	tenant *Tenant
+	ctx    context.Context // the dispatching execution, bound per call: its span becomes the message's parent
 }
 
 func (w *KitWork) Queue() *Queue {
 	return &Queue{tenant: w.tenant}
 }
 
+// BindContext implements value.ContextBinder, so a dispatch from a module-level import still
+// carries the trace of the execution that dispatched.
+func (q *Queue) BindContext(ctx context.Context) value.Value {
+	return value.New(&Queue{tenant: q.tenant, ctx: ctx})
+}
+
 // ── authoring side (inside _queue/<name>.kitwork.js) ─────────────────────────────────────────────
 
 func (q *Queue) Handle(args ...value.Value) *QueueBuilder {
@@ -238,11 +247,22 @@ func (q *Queue) Dispatch(args ...value.Value) value.Value {
 		worker.mu.Unlock()
 	}
 
-	id, queued, err := store.Enqueue(q.tenant.appID(), name, payload, dedupeKey,
+	_, span := trace.Start(q.ctx, "queue "+name+" publish", trace.KindProducer)
+	span.SetAttribute("messaging.system", "kitwork")
+	span.SetAttribute("messaging.destination.name", name)
+	traceparent := ""
+	if parent := span.Context(); parent.IsValid() {
+		traceparent = parent.Traceparent()
+	}
+	id, queued, err := store.Enqueue(q.tenant.appID(), name, payload, dedupeKey, traceparent,
 		maxAttempts, time.Now().Add(delay))
 	if err != nil {
+		span.Fail(err.Error())
+		span.End()
 		return dispatchResult(0, false, err.Error())
 	}
+	span.SetAttribute("messaging.message.id", id)
+	span.End()
 	return dispatchResult(id, queued, "")
 }
 
@@ -414,7 +434,7 @@ func (t *Tenant) LoadQueueFiles() {
 			continue
 		}
 		file := filepath.Join(dir, e.Name())
-		bc, compileErr := compiler.CompileFile(file)
+		bc, compileErr := t.compileFile(file)
 		if compileErr != nil {
 			fmt.Printf("[Queue] compile %s: %v\n", e.Name(), compileErr)
 			continue
@@ -1,6 +1,7 @@
 package work
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -8,6 +9,7 @@ import (
 
 	"github.com/kitwork/engine/database"
 	queuestore "github.com/kitwork/engine/utilities/queue"
+	"github.com/kitwork/engine/utilities/trace"
 	"github.com/kitwork/engine/value"
 )
 
@@ -206,7 +208,9 @@ func (t *Tenant) queuePoller(
 		case <-cancel:
 			return
 		case <-ticker.C:
-			t.claimAndRunQueue(worker, appID, nodeID)
+			if !t.jobsPaused("queue") { // maintenance: messages stay queued until it ends
+				t.claimAndRunQueue(worker, appID, nodeID)
+			}
 			worker.store.Reclaim(appID, false)
 			tick++
 			if tick%240 == 0 { // ~once a minute at the default interval
@@ -291,14 +295,26 @@ func (t *Tenant) runQueueJob(worker *queueRuntime, claimed queuestore.ClaimedJob
 	final := claimed.Attempt >= claimed.MaxAttempts
 	job := t.queueContext(claimed, final, &out)
 
-	gas, runErr := t.runInQueueVM(handler, handler.Callback, []value.Value{job})
+	// The run continues the trace of the dispatch that queued it, carried on the row.
+	parent, _ := trace.ParseTraceparent(claimed.Traceparent)
+	ctx, span := t.tracer.Begin(context.Background(), "queue "+claimed.Name, trace.KindConsumer, parent, t.Domain())
+	span.SetAttribute("messaging.system", "kitwork")
+	span.SetAttribute("messaging.destination.name", claimed.Name)
+	span.SetAttribute("messaging.message.id", claimed.ID)
+	span.SetAttribute("kitwork.job.attempt", claimed.Attempt)
+	defer span.End()
+
+	gas, runErr := t.runInQueueVM(ctx, handler, handler.Callback, []value.Value{job})
+	if runErr != nil {
+		span.Fail(runErr.Error())
+	}
 
 	appID := t.appID()
 	if runErr == nil {
 		worker.store.Complete(claimed.ID, out.String(), int64(gas))
 		worker.store.RecordSummary(appID, claimed.Name, "completed")
 		if handler.OnSuccess != nil {
-			_, _ = t.runInQueueVM(handler, handler.OnSuccess, []value.Value{job})
+			_, _ = t.runInQueueVM(ctx, handler, handler.OnSuccess, []value.Value{job})
 		}
 		return
 	}
@@ -311,7 +327,7 @@ func (t *Tenant) runQueueJob(worker *queueRuntime, claimed queuestore.ClaimedJob
 	}
 	if handler.OnError != nil {
 		errObj := value.New(map[string]value.Value{"message": value.New(runErr.Error())})
-		_, _ = t.runInQueueVM(handler, handler.OnError, []value.Value{job, errObj})
+		_, _ = t.runInQueueVM(ctx, handler, handler.OnError, []value.Value{job, errObj})
 	}
 }
 
@@ -351,14 +367,14 @@ func (t *Tenant) queueContext(claimed queuestore.ClaimedJob, final bool, out *st
 
 // runInQueueVM runs a lambda belonging to a queue file's bytecode: a pooled VM is FastReset onto THAT
 // bytecode (the lambda's Address offsets index into it) with the tenant's builtins and globals.
-func (t *Tenant) runInQueueVM(handler *QueueHandler, lambda *value.Lambda, args []value.Value) (gas uint64, runErr error) {
+func (t *Tenant) runInQueueVM(ctx context.Context, handler *QueueHandler, lambda *value.Lambda, args []value.Value) (gas uint64, runErr error) {
 	if handler.Bytecode == nil {
 		return 0, fmt.Errorf("queue %q has no bytecode", handler.Name)
 	}
 	if lambda == nil {
 		return 0, fmt.Errorf("queue %q has no handler", handler.Name)
 	}
-	return t.Execute(handler.Bytecode.Program, lambda, args)
+	return t.executeIn(ctx, handler.Bytecode.Program, lambda, args)
 }
 
 // queueRetentionSweep prunes finished messages — successes fast, failures for the handler's window.
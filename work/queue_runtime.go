@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/kitwork/engine/app"
 	queuestore "github.com/kitwork/engine/utilities/queue"
 )
 
@@ -30,6 +31,26 @@ type queueRuntime struct {
 	closed    bool
 }
 
+// QueueDepth counts the app's waiting messages per queue, through the store its running worker
+// already holds: an app whose worker is not running on this node reports nil, so a metrics scrape
+// never opens a database of its own.
+func QueueDepth(appRuntime *app.Runtime) map[string]int64 {
+	if appRuntime == nil {
+		return nil
+	}
+	worker, _ := appRuntime.Resource(queueResourceName).(*queueRuntime)
+	if worker == nil {
+		return nil
+	}
+	worker.mu.Lock()
+	store, owner, running := worker.store, worker.owner, worker.running
+	worker.mu.Unlock()
+	if store == nil || owner == nil || !running {
+		return nil
+	}
+	return store.Depth(owner.appID())
+}
+
 // handlerFor resolves a claimed message's queue to the code that runs it.
 func (q *queueRuntime) handlerFor(name string) *QueueHandler {
 	q.mu.Lock()
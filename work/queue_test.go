@@ -326,3 +326,26 @@ queue.handle((job) => { job.log("ran"); });`)
 		t.Errorf("claimed %d undelayed messages, want 1", len(claimed))
 	}
 }
+
+// QueueDepth reads through the running worker's store: a delayed message is waiting, and a stopped
+// worker reports nothing rather than opening the database for the scrape.
+func TestQueueDepthCountsWaitingMessages(t *testing.T) {
+	tenant := newQueueTenant(t, "later", `import { queue } from "kitwork";
+queue.handle((job) => { job.log("ran"); });`)
+	defer tenant.Close()
+
+	for i := 0; i < 2; i++ {
+		result := dispatch(t, tenant, value.New("later"), value.New(map[string]any{}),
+			value.New(map[string]any{"delay": "1h"}))
+		if !result.Get("queued").IsTrue() {
+			t.Fatalf("dispatch refused: %v", result.Get("error").Text())
+		}
+	}
+	if depth := QueueDepth(tenant.appRuntime); depth["later"] != 2 {
+		t.Fatalf("depth = %v, want later:2", depth)
+	}
+	tenant.StopQueueWorker()
+	if depth := QueueDepth(tenant.appRuntime); depth != nil {
+		t.Fatalf("stopped worker depth = %v", depth)
+	}
+}
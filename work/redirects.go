@@ -0,0 +1,93 @@
+package work
+
+// The site's _redirects file (see utilities/redirects): compiled once per generation, consulted
+// before the folder tree. A redirect answers at once; a 200 rewrite changes the request's path and
+// query in place and serving continues with the rewritten request — static files, then the tree.
+// Rules give way to a static file at the same path unless forced ("301!").
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/kitwork/engine/utilities/redirects"
+)
+
+// redirectsFile is the rules file at the site root. It is never served as a static file.
+const redirectsFile = "_redirects"
+
+// loadRedirects compiles _redirects for a new generation. A missing file means no rules.
+func (t *Tenant) loadRedirects() error {
+	file := t.resolve(redirectsFile)
+	if t.generation != nil {
+		if err := t.generation.Sources().WatchFile(file); err != nil {
+			return err
+		}
+	}
+	t.redirects = nil
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err == nil {
+		t.redirects, err = redirects.Parse(data)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", redirectsFile, err)
+	}
+	return nil
+}
+
+// matchRedirect finds the _redirects rule for a request, if any.
+func (t *Tenant) matchRedirect(r *http.Request) (*redirects.Rule, string, bool) {
+	if t.redirects == nil || len(t.redirects.Rules) == 0 {
+		return nil, "", false
+	}
+	return t.redirects.Match(r.URL.Path, r.URL.Query())
+}
+
+// applyRedirect answers a matched rule. It reports whether the response was written; a rewrite
+// only changes the request.
+func applyRedirect(w http.ResponseWriter, r *http.Request, rule *redirects.Rule, target string) bool {
+	if !rule.Rewrite() {
+		http.Redirect(w, r, target, rule.Status)
+		return true
+	}
+	rewritten, err := url.Parse(target)
+	if err != nil {
+		http.Error(w, "Bad Gateway", http.StatusBadGateway)
+		return true
+	}
+	u := *r.URL
+	u.Path, u.RawPath, u.RawQuery = rewritten.Path, "", rewritten.RawQuery
+	r.URL = &u
+	return false
+}
+
+// RedirectIssues lists the _redirects rules that can never take effect: shadowed by or in conflict
+// with an earlier rule, redirecting to themselves, or — unforced — hidden behind a static file.
+// core.Check reports them.
+func (t *Tenant) RedirectIssues() (file string, issues []error) {
+	file = t.resolve(redirectsFile)
+	if t.redirects == nil {
+		return file, nil
+	}
+	for _, problem := range t.redirects.Lint() {
+		issues = append(issues, problem)
+	}
+	for _, rule := range t.redirects.Rules {
+		if rule.Force || strings.ContainsAny(rule.From, ":*") || len(rule.Query) > 0 {
+			continue
+		}
+		static := filepath.Join(t.resolve(), filepath.FromSlash(path.Clean(rule.From)))
+		if info, err := os.Stat(static); err == nil && !info.IsDir() {
+			issues = append(issues, redirects.Problem{Line: rule.Line,
+				Message: fmt.Sprintf("unreachable: the static file %s answers first (force the rule with %d!)", rule.From, rule.Status)})
+		}
+	}
+	return file, issues
+}
@@ -0,0 +1,79 @@
+package work
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// _redirects answers before the folder tree: redirects with captures, splats and query matching,
+// 200 rewrites into another folder, and static files winning over unforced rules.
+func TestRedirectsFile(t *testing.T) {
+	tmp := t.TempDir()
+	dir := filepath.Join(tmp, "test", "localhost")
+	write := func(rel, content string) {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("router.kitwork.js", `import { router } from "kitwork";`)
+	write("app/router.kitwork.js", `import { router } from "kitwork";
+router.get((ctx) => ctx.text("app " + ctx.query("tab")));`)
+	write("robots.txt", "static robots")
+	write("legal.txt", "static legal")
+	write("_redirects", `/blog/:slug      /posts/:slug          301
+/news/*          /blog/:splat          302
+/store  id=:id   /products/:id         308
+/dashboard/*     /app?tab=:splat       200
+/robots.txt      /robots-new.txt       302
+/legal.txt       /terms                301!
+`)
+	tenant := NewTenant(tmp, "localhost")
+	if err := tenant.Run(); err != nil {
+		t.Fatal(err)
+	}
+	serve := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		tenant.Serve(rec, httptest.NewRequest(http.MethodGet, "http://localhost"+path, nil))
+		return rec
+	}
+	for _, c := range []struct {
+		path, location string
+		status         int
+	}{
+		{"/blog/hello?utm=x", "/posts/hello?utm=x", http.StatusMovedPermanently},
+		{"/news/2024/launch", "/blog/2024/launch", http.StatusFound},
+		{"/store?id=42", "/products/42?id=42", http.StatusPermanentRedirect},
+		{"/legal.txt", "/terms", http.StatusMovedPermanently},
+	} {
+		rec := serve(c.path)
+		if rec.Code != c.status || rec.Header().Get("Location") != c.location {
+			t.Errorf("GET %s = %d %q, want %d %q", c.path, rec.Code, rec.Header().Get("Location"), c.status, c.location)
+		}
+	}
+	if rec := serve("/dashboard/billing"); rec.Code != http.StatusOK || rec.Body.String() != "app billing" {
+		t.Errorf("rewrite = %d %q", rec.Code, rec.Body.String())
+	}
+	if rec := serve("/robots.txt"); rec.Code != http.StatusOK || rec.Body.String() != "static robots" {
+		t.Errorf("an unforced rule should give way to the static file: %d %q", rec.Code, rec.Body.String())
+	}
+	if rec := serve("/_redirects"); rec.Code == http.StatusOK && strings.Contains(rec.Body.String(), "/posts/") {
+		t.Error("_redirects should not be served")
+	}
+	if _, issues := tenant.RedirectIssues(); len(issues) != 1 || !strings.Contains(issues[0].Error(), "line 5: unreachable: the static file /robots.txt") {
+		t.Errorf("issues = %v", issues)
+	}
+
+	write("_redirects", "/a /b 404\n")
+	broken := NewTenant(tmp, "localhost")
+	if err := broken.Run(); err == nil || !strings.Contains(err.Error(), "_redirects: line 1") {
+		t.Fatalf("Run with a malformed _redirects = %v", err)
+	}
+}
@@ -29,6 +29,7 @@ type plannedRenderer struct {
 	page     *render.Render
 	notfound *render.Render
 	health   *RuntimeHealth
+	preloads []render.Preload // the page's critical assets, announced by early hints (hints.go)
 }
 
 func newRenderPlan(t *Tenant, tree *RouteTree) (*RenderPlan, error) {
@@ -119,10 +120,12 @@ func newRenderPlan(t *Tenant, tree *RouteTree) (*RenderPlan, error) {
 		notfoundConfig := config
 		notfoundConfig.NotfoundMode = true
 		pageRender := baseRender.Prepare()
+		var preloads []render.Preload
 		if presentation.KitJS || snapshot.Exists(filepath.Join(base, filepath.FromSlash(relative), "page.kitwork.html")) {
 			if err := pageRender.PreparationError(); err != nil {
 				return nil, fmt.Errorf("prepare template for route %q: %w", relative, err)
 			}
+			preloads = pageRender.Preloads()
 		}
 		notfoundRender := render.New(notfoundConfig).Prepare()
 		if presentation.KitJS {
@@ -135,6 +138,7 @@ func newRenderPlan(t *Tenant, tree *RouteTree) (*RenderPlan, error) {
 			page:     pageRender,
 			notfound: notfoundRender,
 			health:   t.runtimeHealth,
+			preloads: preloads,
 		}
 	}
 	if plan.kitJSAssets != nil {
@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -65,6 +64,11 @@ type Router struct {
 	params map[string]string
 	err    error // Biến lưu lỗi để truyền giữa các công đoạn
 
+	hints []string // Link values a 103 announced; repeated only on a 2xx HTML response (hints.go)
+
+	// webhookKeys are the replay keys ctx.verifyWebhook claimed; kept only on a 2xx (webhook.go).
+	webhookKeys []string
+
 	requestID string
 
 	// Cache configuration
@@ -78,6 +82,25 @@ type Router struct {
 
 	cors *CorsOptions
 
+	// uploads are the files a .body() route received, on disk until the request ends (upload.go).
+	uploads map[string][]*Upload
+
+	// body and query are the typed values a .body(schema)/.query(schema) route validated; handlers
+	// receive them as their body and query parameters (validate.go).
+	body  value.Value
+	query value.Value
+
+	// locale is the language detection chose for this request; locales is the site's declaration
+	// and localePath the request path without its locale prefix (i18n.go).
+	locale     string
+	locales    *localeConfig
+	localePath string
+
+	// user is the authenticated principal's subject; flags is the request's feature-flag answers
+	// (flags.go), created by the first flag read.
+	user  string
+	flags *flagEvaluator
+
 	// Metadata and application level bindings
 	meta value.Value
 
@@ -205,6 +228,7 @@ func (r *Router) responder(w http.ResponseWriter) {
 		w.Write([]byte(data.String()))
 	case "typed":
 		w.Header().Set("Content-Type", r.response.ContentType())
+		r.writeHintLinks(w)
 		w.WriteHeader(r.response.Code())
 		if request.Method != http.MethodHead {
 			w.Write(r.response.toBytes())
@@ -224,6 +248,7 @@ func (r *Router) responder(w http.ResponseWriter) {
 		w.Write(b)
 	case "html":
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		r.writeHintLinks(w)
 		w.WriteHeader(r.response.Code())
 		w.Write(r.response.toBytes())
 	case "error":
@@ -309,63 +334,3 @@ func (r *Router) streamSSE(w http.ResponseWriter) {
 		}
 	}
 }
-
-type CorsOptions struct {
-	// Origins specifies the allowed origins.
-	// Use "*" to allow all origins.
-	Origins []string
-
-	// Methods specifies the allowed HTTP methods.
-	Methods []string
-
-	// Headers specifies the allowed request headers.
-	Headers []string
-
-	// Expose specifies the response headers that browsers
-	// are allowed to expose to JavaScript.
-	Expose []string
-
-	// Credentials indicates whether the browser may send
-	// credentials such as cookies or HTTP authentication.
-	Credentials bool
-
-	// MaxAge specifies how long the browser may cache
-	// the result of a preflight request.
-	MaxAge time.Duration
-}
-
-func writeCorsHeaders(opts *CorsOptions, w http.ResponseWriter, r *http.Request) {
-	origin := r.Header.Get("Origin")
-	if origin == "" {
-		return
-	}
-
-	allowedOrigin := ""
-	for _, o := range opts.Origins {
-		if o == "*" {
-			allowedOrigin = "*"
-			break
-		}
-		if o == origin {
-			allowedOrigin = origin
-			break
-		}
-	}
-
-	if allowedOrigin != "" {
-		w.Header().Set("Access-Control-Allow-Origin", allowedOrigin)
-		if opts.Credentials && allowedOrigin != "*" {
-			w.Header().Set("Access-Control-Allow-Credentials", "true")
-		}
-	}
-
-	if len(opts.Headers) > 0 {
-		w.Header().Set("Access-Control-Allow-Headers", strings.Join(opts.Headers, ", "))
-	}
-	if len(opts.Methods) > 0 {
-		w.Header().Set("Access-Control-Allow-Methods", strings.Join(opts.Methods, ", "))
-	}
-	if opts.MaxAge > 0 {
-		w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(opts.MaxAge)))
-	}
-}
@@ -66,6 +66,7 @@ func responseBytes(resp *Response) (body []byte, contentType string, status int,
 	}
 }
 
+// serveCached replays a stored response; state is the X-Kitwork-Cache answer, "hit" or "stale".
 func serveCached(
 	w http.ResponseWriter,
 	request *http.Request,
@@ -73,6 +74,7 @@ func serveCached(
 	contentType string,
 	status int,
 	headers map[string]string,
+	state string,
 ) {
 	if status == 0 {
 		status = http.StatusOK
@@ -81,7 +83,7 @@ func serveCached(
 	for name, data := range headers {
 		w.Header().Set(name, data)
 	}
-	w.Header().Set("X-Kitwork-Cache", "hit")
+	w.Header().Set("X-Kitwork-Cache", state)
 	if requestNotModified(request, w.Header()) {
 		w.WriteHeader(http.StatusNotModified)
 		return
@@ -92,17 +94,43 @@ func serveCached(
 	}
 }
 
+// cachedResponse looks the key up in the RAM tier, then the disk tier; tier names the one that hit
+// ("ram" or "disk") and is empty on a miss.
 func (t *Tenant) cachedResponse(
 	method *FolderMethod,
 	key string,
-) (body []byte, contentType string, status int, headers map[string]string, ok bool) {
+) (body []byte, contentType string, status int, headers map[string]string, tier string) {
 	if method.cacheExpiry != nil {
 		if entry, hit := t.respCache.Get(key); hit {
+			return entry.Body, entry.ContentType, entry.Status, entry.Headers, "ram"
+		}
+	}
+	if method.persistExpiry != nil && method.isProxy {
+		// A proxy route keeps its expired copy on disk: it is what staleResponse falls back to.
+		if record, stale, ok := t.persistStore.GetStale(hashKey(key)); ok && !stale {
+			return record.Body, record.ContentType, record.Status, record.Headers, "disk"
+		}
+	} else if method.persistExpiry != nil {
+		if record, hit := t.persistStore.Get(hashKey(key)); hit {
+			return record.Body, record.ContentType, record.Status, record.Headers, "disk"
+		}
+	}
+	return nil, "", 0, nil, ""
+}
+
+// staleResponse is cachedResponse's fail-open twin for a proxy route whose upstreams all failed: the
+// last copy either tier holds, expired or not. ok is false when neither holds one.
+func (t *Tenant) staleResponse(
+	method *FolderMethod,
+	key string,
+) (body []byte, contentType string, status int, headers map[string]string, ok bool) {
+	if method.cacheExpiry != nil {
+		if entry, _, hit := t.respCache.GetStale(key); hit {
 			return entry.Body, entry.ContentType, entry.Status, entry.Headers, true
 		}
 	}
 	if method.persistExpiry != nil {
-		if record, hit := t.persistStore.Get(hashKey(key)); hit {
+		if record, _, hit := t.persistStore.GetStale(hashKey(key)); hit {
 			return record.Body, record.ContentType, record.Status, record.Headers, true
 		}
 	}
@@ -111,9 +139,23 @@ func (t *Tenant) cachedResponse(
 
 func (t *Tenant) saveResponse(method *FolderMethod, key string, response *Response) {
 	body, contentType, status, headers, ok := responseBytes(response)
-	if !ok || status < 200 || status >= 300 {
+	if !ok {
 		return
 	}
+	t.storeResponse(method, key, body, contentType, status, headers)
+}
+
+// storeResponse writes a 2xx answer to the tiers the method declared.
+func (t *Tenant) storeResponse(method *FolderMethod, key string, body []byte, contentType string, status int, headers map[string]string) {
+	if status < 200 || status >= 300 {
+		return
+	}
+	// A reflected Access-Control-Allow-Origin belongs to the caller that produced the entry;
+	// replaying it would hand one origin's grant to the next. router.cors re-applies on every hit.
+	if origin, set := headers["Access-Control-Allow-Origin"]; set && origin != "*" {
+		delete(headers, "Access-Control-Allow-Origin")
+		delete(headers, "Access-Control-Allow-Credentials")
+	}
 	now := time.Now()
 	if method.cacheExpiry != nil {
 		t.respCache.Set(key, cache.Entry{
@@ -11,16 +11,19 @@ package work
 
 import (
 	"fmt"
+	"net/http"
 	"os"
 	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/kitwork/engine/compiler"
 	"github.com/kitwork/engine/runtime"
 	"github.com/kitwork/engine/site"
+	"github.com/kitwork/engine/utilities/schema"
 	"github.com/kitwork/engine/value"
 )
 
@@ -61,10 +64,36 @@ type FolderMethod struct {
 	outputData value.Value
 	outputPath string
 
-	// .proxy(): answer from an upstream instead of a page. proxyTarget is a fixed URL or a handler
-	// computing one per request. See serveProxy in tree_proxy.go.
-	isProxy     bool
-	proxyTarget value.Value
+	// .proxy(): answer from an upstream instead of a page — a fixed URL, a handler computing one
+	// per request, or a pool of upstreams. See serveProxy in router_proxy.go.
+	isProxy bool
+	proxy   *proxyRoute
+
+	// .socket(): a WebSocket endpoint instead of an HTTP answer. See work/socket.go.
+	socket *socketRoute
+
+	// .body(): request body limits and upload rules. See work/upload.go.
+	body *bodySpec
+
+	// .body(schema) / .query(schema): checked before the handler, typed values passed to it. See
+	// validate.go.
+	bodySchema  *schema.Schema
+	querySchema *schema.Schema
+
+	// formats is the offer list the handler last answered with through res.format(); once set, the
+	// response cache keys entries by the negotiated type. See negotiate.go.
+	formats atomic.Pointer[[]formatOffer]
+
+	// flagNames are the feature flags the handler has read (sorted); once set, the response cache
+	// keys entries by this request's answer for each of them. See flags.go.
+	flagNames atomic.Pointer[[]string]
+	// flagsByAttribute is set once the handler read a flag with its own attributes
+	// (ctx.flag(name, { user })): that answer is per user, not per request, so the route is no
+	// longer cached.
+	flagsByAttribute atomic.Bool
+
+	// .describe(): summary, request schema and responses for the OpenAPI document. See openapi.go.
+	describe value.Value
 
 	// Response caching + rate limiting (see cache/persist/ratelimit helper packages). The expiry
 	// resolvers accept a rolling duration OR a wall-clock boundary ("nextday 03:00", "weekly", …),
@@ -90,6 +119,30 @@ func parseTTL(v value.Value) time.Duration {
 	return d
 }
 
+// parseSize reads a byte count from a number (bytes) or a string ("512KB", "20MB", "1GB"); units
+// are binary (1KB = 1024 bytes). 0 means unset or unreadable.
+func parseSize(v value.Value) int64 {
+	if v.IsNumeric() {
+		return int64(v.N)
+	}
+	s := strings.ToUpper(strings.TrimSpace(v.Text()))
+	unit := int64(1)
+	for _, u := range []struct {
+		suffix string
+		size   int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(s, u.suffix) {
+			s, unit = strings.TrimSpace(strings.TrimSuffix(s, u.suffix)), u.size
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return int64(n * float64(unit))
+}
+
 func (m *FolderMethod) Handle(l value.Value) *FolderMethod { m.handle = lambdaOf(l); return m }
 
 // Guard registers before-hooks that run IN ORDER ahead of the handler — each may block (return
@@ -262,12 +315,23 @@ type FolderRouter struct {
 	guards   []*value.Lambda // folder before-chain — applied to this folder AND every descendant
 	methods  map[string]*FolderMethod
 	outputs  map[string]*FolderMethod // semantic generated endpoints such as /rss.xml and /sitemap.xml
+	socket   *FolderMethod            // router.socket(): answers GETs that ask for a WebSocket upgrade
 	notFound *value.Lambda
 	errorH   *value.Lambda
 	meta     map[string]value.Value // declared via router.meta()/.favicon(); inherited down the chain
 	jsonld   []value.Value          // router.jsonld() nodes — inherited down the chain, accumulated
 	limits   []methodLimit          // router.ratelimit() rules — this folder AND every descendant
-	assetErr error                  // first fatal .assets() conflict, surfaced as a generation error
+	cors     *CorsOptions           // router.cors(): the deepest declaration on the chain wins
+	hints    *hintPolicy            // router.hints(): early hints for pages; deepest declaration wins
+	locales  *localeConfig          // router.locales(): root router only (i18n.go)
+	declErr  error                  // first fatal declaration error (.assets() conflict, .locales()), surfaced as a generation error
+}
+
+// setDeclarationError keeps the first fatal declaration error; compileFolder reports it.
+func (f *FolderRouter) setDeclarationError(err error) {
+	if f.declErr == nil {
+		f.declErr = err
+	}
 }
 
 func (f *FolderRouter) declare(name string, args ...value.Value) *FolderMethod {
@@ -287,18 +351,32 @@ func (f *FolderRouter) Post(args ...value.Value) *FolderMethod { return f.declar
 //
 //	router.proxy("https://cdn.example.com/logo.png").persist("30d")
 //	router.proxy((ctx) => "https://cdn.example.com/" + ctx.params("id") + ".png").persist("30d")
+//	router.proxy("https://app.internal", { headers: ["Authorization", "Cookie"] })
+//
+// Every verb is forwarded — method, body, headers, the rest of a {...rest} path and the query — and
+// the answer streams back with its status, headers and cookies. A verb the folder declares itself
+// (router.post after router.proxy) is answered by the folder instead. Compose with
+// .persist()/.cache(): a GET hit replays the stored bytes with NO VM and NO refetch — the "cached
+// mount" (declare once, first hit fetches, the rest serve from disk).
+//
+// An options object alone spreads the route over several interchangeable upstreams, with health
+// checks and a circuit breaker per upstream (see newProxyRoute for every option):
 //
-// Compose with .persist()/.cache(): a hit replays the stored bytes with NO VM and NO refetch — the
-// "cached mount" (declare once, first hit fetches, the rest serve from disk). The upstream's
-// Content-Type is preserved, so images come back as images.
+//	router.proxy({ upstreams: ["https://a.internal", "https://b.internal"], healthCheck: "/healthz" })
 //
 // SSRF: the TENANT owns the target (it computes it) — the engine still blocks private/loopback space
 // at the transport as a backstop. Never build the target straight from untrusted input.
 func (f *FolderRouter) Proxy(args ...value.Value) *FolderMethod {
-	m := f.declare("GET") // declare WITHOUT args so a handler-target never becomes the page handler
-	m.isProxy = true
-	if len(args) > 0 {
-		m.proxyTarget = args[0]
+	// Declared WITHOUT a handle so a handler-target never becomes the page handler.
+	m := &FolderMethod{method: http.MethodGet, isProxy: true}
+	route, err := newProxyRoute(f, args...)
+	if err != nil {
+		f.setDeclarationError(err)
+		return m
+	}
+	m.proxy = route
+	for _, name := range route.methods {
+		f.methods[name] = m
 	}
 	return m
 }
@@ -316,8 +394,8 @@ func (f *FolderRouter) output(kind, defaultPath string, args ...value.Value) *Fo
 				if err := f.tenant.presentation().AddAssetMount(site.AssetMount{
 					URL:  path.Join(f.node.relPath(), cleanAssetPrefix(defaultPath)),
 					Disk: path.Join(f.node.relPath(), disk),
-				}); err != nil && f.assetErr == nil {
-					f.assetErr = err
+				}); err != nil {
+					f.setDeclarationError(err)
 				}
 			}
 			return m // served statically; not registered as a generated output
@@ -462,8 +540,8 @@ func (f *FolderRouter) Assets(args ...value.Value) *FolderRouter {
 		if err := f.tenant.presentation().AddAssetMount(site.AssetMount{
 			URL:  path.Join(f.node.relPath(), url),
 			Disk: path.Join(f.node.relPath(), disk),
-		}); err != nil && f.assetErr == nil {
-			f.assetErr = err
+		}); err != nil {
+			f.setDeclarationError(err)
 		}
 	}
 	if len(args) == 2 { // alias form: (publicURLGlob, diskDir) — explicit, taken as-is
@@ -619,9 +697,9 @@ func (n *RouteNode) compileFolder(t *Tenant) error {
 			relative := strings.TrimPrefix(routerFile, t.resolve()+string(filepath.Separator))
 			return fmt.Errorf("initialize router %s: %w", relative, err)
 		}
-		if fr.assetErr != nil {
+		if fr.declErr != nil {
 			relative := strings.TrimPrefix(routerFile, t.resolve()+string(filepath.Separator))
-			return fmt.Errorf("router %s: %w", relative, fr.assetErr)
+			return fmt.Errorf("router %s: %w", relative, fr.declErr)
 		}
 	}
 	return nil
@@ -6,6 +6,7 @@ import (
 
 	"github.com/kitwork/engine/compiler"
 	"github.com/kitwork/engine/runtime"
+	"github.com/kitwork/engine/utilities/openapi"
 	"github.com/kitwork/engine/utilities/publishing"
 	"github.com/kitwork/engine/value"
 )
@@ -72,6 +73,9 @@ func (t *Tenant) executeGeneratedOutput(
 		}
 		response.Send(value.New(document))
 	case "sitemap":
+		if locales := ctx.router().locales; locales != nil {
+			data = locales.localizeSitemap(data, request.Scheme().String(), request.Host().String())
+		}
 		document, err := publishing.Sitemap(data, base, method.outputPath, ctx.Path().String())
 		if err != nil {
 			return err
@@ -87,6 +91,14 @@ func (t *Tenant) executeGeneratedOutput(
 		response := ctx.Type(publishing.RobotsMediaType)
 		response.Header("ETag", publishing.ETag(document))
 		response.Send(value.New(document))
+	case "openapi":
+		document, err := t.openAPIDocument(data, base)
+		if err != nil {
+			return err
+		}
+		response := ctx.Type(openapi.MediaType)
+		response.Header("ETag", publishing.ETag(string(document)))
+		response.Send(value.New(string(document)))
 	default:
 		return fmt.Errorf("unknown generated output %q", method.outputKind)
 	}
@@ -1,70 +1,688 @@
 package work
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/kitwork/engine/compiler"
+	requestscope "github.com/kitwork/engine/request"
 	"github.com/kitwork/engine/runtime"
 	httphelper "github.com/kitwork/engine/utilities/http"
+	"github.com/kitwork/engine/utilities/trace"
+	"github.com/kitwork/engine/utilities/upstream"
 	"github.com/kitwork/engine/value"
 )
 
-// router.proxy(target) — answer a folder from an UPSTREAM url instead of a page (a reverse proxy
-// mounted at the folder). Declared in tree_folder.go; executed here.
+// router.proxy(target) — answer a folder from an UPSTREAM instead of a page (a reverse proxy
+// mounted at the folder). Declared in router_folder.go; executed here.
 //
-// The design in one line: proxy is a THIN wrapper over the pieces that already exist — the
-// SSRF-guarded outbound client (helpers/http) for the fetch, and the route's own .cache()/.persist()
-// tiers for the storage. Nothing new is invented:
+// The design in one line: proxy is a THIN layer over pieces that already exist — the standard
+// library's reverse proxy for the exchange, the SSRF-guarded transport of the outbound client
+// (utilities/http) for the dial, and the route's own .cache()/.persist() tiers for the storage:
 //
 //	router.proxy("https://cdn.example.com/logo.png").persist("30d")   // fixed upstream
 //	router.proxy((ctx) => "https://cdn/" + ctx.params("id")).persist("30d")  // computed per request
+//	router.proxy("https://api.internal", { headers: ["Authorization", "Cookie"] })  // a gateway
 //
-// LIFECYCLE — the "cached mount":
-//   - MISS: resolve the target (VM only if it is a handler) → fetch → reply with the upstream bytes
-//     under the upstream's own Content-Type → the route tier stores body+type (tree_cache.go).
+// THE EXCHANGE — every verb the folder does not declare itself is forwarded: the method, the body
+// (streamed, never buffered), the allowed request headers, X-Forwarded-For/-Host/-Proto/-Prefix,
+// the query and, under a {...rest} folder, the rest of the path. Status, headers and cookies come
+// back, with Location and cookie Path/Domain moved from the upstream to the mount. The body streams
+// through as it arrives — SSE and chunked answers are flushed per write — and an Upgrade handshake
+// (WebSocket) becomes a tunnel between the two connections.
+//
+// LIFECYCLE — the "cached mount" (GET only):
+//   - MISS: resolve the target (VM only if it is a handler) → forward → stream the answer to the
+//     client while a copy is kept → the route tier stores a 2xx copy (router_cache.go). A request
+//     that forwards Authorization or Cookie keeps no copy unless the route is `shared`, and neither
+//     does an answer marked private or no-store, or one that varies on a header the key lacks.
 //   - HIT:  cachedResponse() replays the stored bytes BEFORE any of this runs — no VM, no refetch.
 //
-// This generalises past images: it is a reverse-proxy-with-cache (BFF / gateway) that happens to be
-// perfect for a remote image mount. A future .resize() would layer on top of the same bytes.
-
-// serveProxy answers one proxy request. Errors are returned so the caller can map them to 502.
-func (t *Tenant) serveProxy(vm *runtime.VM, bc *compiler.Bytecode, method *FolderMethod, ctxObj *Context) error {
-	// 1. Resolve the upstream. A handler computes it per request (params/path); otherwise it is a
-	//    fixed URL declared in the router — either way the TENANT owns it, never the visitor.
-	target := method.proxyTarget
-	if target.K == value.Func {
-		target = t.execTree(vm, bc, lambdaOf(target), ctxObj)
-		if target.K == value.Invalid {
-			return fmt.Errorf("proxy target handler failed: %v", target.V)
-		}
-	}
-	url := strings.TrimSpace(target.Text())
-	if url == "" {
-		return fmt.Errorf("proxy: target resolved to an empty url")
-	}
-
-	// 2. Fetch. No cache tiers are wired into the client on purpose: the ROUTE's .cache()/.persist()
-	//    is the tier the author declared, and storing in both would keep the same bytes twice. The
-	//    transport still blocks private/loopback space (SSRF backstop) regardless of the target.
-	// .Get() now returns a lazy *Request; .Fire() runs it and hands back the concrete Response.
-	req, ok := httphelper.NewClient(nil, nil).Get(url).V.(*httphelper.Request)
-	if !ok {
-		return fmt.Errorf("proxy %s: unexpected client result", url)
+// POOL — router.proxy({ upstreams }) spreads the route over interchangeable origins
+// (utilities/upstream): each attempt picks one, a failed attempt (transport error, timeout or 5xx)
+// moves on to another, and the failures feed the upstream's circuit breaker. When no upstream can
+// answer, the route's own tier serves its last copy even if expired (staleResponse) — stale beats
+// down.
+
+const (
+	// defaultProxyTimeout bounds the wait for an upstream's response headers, and each health check.
+	defaultProxyTimeout = 10 * time.Second
+	// maxProxyCopy caps the copy of a response kept for the route's tier; a larger body streams
+	// through uncached.
+	maxProxyCopy = 16 << 20
+)
+
+// proxyMethods are the verbs router.proxy answers unless `methods` narrows them.
+var proxyMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions,
+}
+
+// proxyHeaders are the request headers forwarded unless `headers` replaces the list. The body's own
+// Content-Type/-Encoding/-Length always go, hop-by-hop headers never do. Credentials are not on it:
+// the upstream may be a third party, so a gateway names Authorization/Cookie in `headers` itself.
+var proxyHeaders = []string{
+	"Accept", "Accept-Encoding", "Accept-Language", "Cache-Control",
+	"If-Match", "If-Modified-Since", "If-None-Match", "If-Range", "If-Unmodified-Since",
+	"Last-Event-ID", "Origin", "Pragma", "Range", "Referer", "User-Agent", "X-Requested-With",
+}
+
+// proxyStoredHeaders are the response headers a stored copy replays besides its Content-Type.
+var proxyStoredHeaders = []string{
+	"Cache-Control", "Content-Disposition", "Content-Language", "Etag", "Expires", "Last-Modified",
+}
+
+// proxyRoute is a router.proxy declaration: a fixed or computed target, or a pool of upstreams;
+// how long to wait for an upstream's headers and how many further upstreams a failed attempt moves
+// on to; which verbs and request headers go through; how the response is rewritten; and the
+// folder's splat parameter, whose value is appended to the upstream URL ("/api/{...path}" proxies
+// /api/a/b to <upstream>/a/b).
+type proxyRoute struct {
+	target       value.Value // a URL, or a handler computing one; unset with a pool
+	pool         *upstream.Pool
+	route        string
+	methods      []string
+	headers      map[string]bool // canonical names
+	timeout      time.Duration
+	retries      int
+	splat        string
+	location     bool    // rewrite Location from the upstream to the mount
+	shared       bool    // the answer is the same for every caller: store it even for credentialed requests
+	cookieDomain *string // replaces a Set-Cookie Domain ("" drops it); nil drops one naming the upstream
+}
+
+// newProxyRoute reads router.proxy's arguments — a target and optional options, or a pool:
+//
+//	router.proxy("https://app.internal", {
+//	  methods: ["GET", "POST"],            // default: every verb
+//	  headers: ["Authorization", "Cookie"], // request headers forwarded (replaces the default list)
+//	  location: true,                       // rewrite Location to the mount (default)
+//	  cookieDomain: "example.com",          // Set-Cookie Domain; "" drops it
+//	  timeout: "3s",                        // until the upstream's response headers
+//	  shared: true,                         // cache even requests forwarding Authorization/Cookie
+//	})
+//	router.proxy({
+//	  upstreams: ["https://a.internal", "https://b.internal"],
+//	  strategy: "least_conn",        // or "round_robin" (default)
+//	  healthCheck: "/healthz",       // probed every interval ("10s"); a 5xx or no answer is down
+//	  retries: 1,                    // other upstreams tried after a failure
+//	  breaker: { failures: 3, cooldown: "30s" },
+//	  ...                            // and any option above
+//	})
+func newProxyRoute(f *FolderRouter, args ...value.Value) (*proxyRoute, error) {
+	route := &proxyRoute{
+		route:    "/" + f.node.relPath(),
+		methods:  proxyMethods,
+		headers:  headerSet(proxyHeaders),
+		timeout:  defaultProxyTimeout,
+		retries:  1,
+		location: true,
+	}
+	if seg := parseSegment(f.node.seg); seg != nil && seg.kind == segSplat {
+		route.splat = seg.name
+	}
+	var m map[string]value.Value
+	if len(args) > 0 && args[0].IsMap() {
+		m = args[0].Map()
+	} else if len(args) > 0 {
+		route.target = args[0]
+		if len(args) > 1 && args[1].IsMap() {
+			m = args[1].Map()
+		}
+	}
+	if m == nil {
+		return route, nil
+	}
+
+	if list := m["methods"]; list.K == value.Array {
+		route.methods = nil
+		for _, item := range list.Array() {
+			route.methods = append(route.methods, strings.ToUpper(optText(item)))
+		}
+	}
+	if list := m["headers"]; list.K == value.Array {
+		var names []string
+		for _, item := range list.Array() {
+			names = append(names, optText(item))
+		}
+		route.headers = headerSet(names)
+	}
+	if location := m["location"]; location.K == value.Bool {
+		route.location = location.IsTrue()
+	}
+	if shared := m["shared"]; shared.K == value.Bool {
+		route.shared = shared.IsTrue()
+	}
+	if domain := m["cookieDomain"]; domain.K == value.String {
+		text := optText(domain)
+		route.cookieDomain = &text
+	}
+	if timeout := parseTTL(m["timeout"]); timeout > 0 {
+		route.timeout = timeout
+	}
+	if retries := m["retries"]; retries.IsNumeric() && retries.N >= 0 {
+		route.retries = int(retries.N)
+	}
+	if !args[0].IsMap() {
+		return route, nil
+	}
+
+	var urls []string
+	if list := m["upstreams"]; list.K == value.Array {
+		for _, item := range list.Array() {
+			urls = append(urls, optText(item))
+		}
+	} else if list.K == value.String {
+		urls = []string{optText(list)}
+	}
+	opts := upstream.Options{
+		Strategy:    optText(m["strategy"]),
+		HealthCheck: optText(m["healthCheck"]),
+		Interval:    parseTTL(m["interval"]),
+	}
+	if breaker := m["breaker"]; breaker.IsMap() {
+		b := breaker.Map()
+		if failures := b["failures"]; failures.IsNumeric() {
+			opts.Threshold = int(failures.N)
+		}
+		opts.Cooldown = parseTTL(b["cooldown"])
+	}
+	pool, err := upstream.New(urls, opts)
+	if err != nil {
+		return nil, err
+	}
+	route.pool = pool
+	f.tenant.addProxyRoute(route)
+	return route, nil
+}
+
+func headerSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[http.CanonicalHeaderKey(strings.TrimSpace(name))] = true
+	}
+	return set
+}
+
+// forwards reports whether a request header goes upstream. The headers of a protocol switch do
+// whatever the list says: without them a WebSocket could not be proxied.
+func (route *proxyRoute) forwards(name string) bool {
+	switch name {
+	case "Content-Type", "Content-Encoding", "Content-Length", "Connection", "Upgrade":
+		return true
+	}
+	return route.headers[name] || strings.HasPrefix(name, "Sec-Websocket-")
+}
+
+// credentialed reports whether a request forwards credentials upstream: its answer may be that
+// caller's own, so it is not stored under a key every caller shares.
+func (route *proxyRoute) credentialed(header http.Header) bool {
+	for _, name := range []string{"Authorization", "Cookie"} {
+		if header.Get(name) != "" && route.forwards(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// storable reports whether an upstream's answer may be kept for every caller: not when its
+// Cache-Control says private or no-store, nor when it varies on a header the route's key does not
+// hold. Accept-Encoding is the exception — a stored copy is always fetched as identity.
+func storable(header http.Header) bool {
+	for _, directives := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(directives, ",") {
+			name, _, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			if strings.EqualFold(name, "private") || strings.EqualFold(name, "no-store") {
+				return false
+			}
+		}
+	}
+	for _, fields := range header.Values("Vary") {
+		for _, field := range strings.Split(fields, ",") {
+			if field = strings.TrimSpace(field); field != "" && !strings.EqualFold(field, "Accept-Encoding") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// addProxyRoute starts the route's health checks for the life of this generation; Close stops them.
+func (t *Tenant) addProxyRoute(route *proxyRoute) {
+	t.proxyMu.Lock()
+	defer t.proxyMu.Unlock()
+	if t.proxyClosed {
+		return
+	}
+	t.proxyRoutes = append(t.proxyRoutes, route)
+	route.pool.Start(func(target string) error {
+		client := &http.Client{Transport: httphelper.Transport(), Timeout: route.timeout}
+		resp, err := client.Get(target)
+		if err != nil {
+			return err
+		}
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		resp.Body.Close()
+		if resp.StatusCode >= 500 {
+			return fmt.Errorf("status %d", resp.StatusCode)
+		}
+		return nil
+	})
+}
+
+// closeProxyRoutes stops every pool's health checks; a route declared afterwards never starts them.
+func (t *Tenant) closeProxyRoutes() {
+	t.proxyMu.Lock()
+	routes := t.proxyRoutes
+	t.proxyRoutes, t.proxyClosed = nil, true
+	t.proxyMu.Unlock()
+	for _, route := range routes {
+		route.pool.Close()
+	}
+}
+
+// ProxyUpstream is one upstream of a router.proxy pool, as the health metrics report it.
+type ProxyUpstream struct {
+	Route string `json:"route"`
+	upstream.Status
+}
+
+// ProxyUpstreams reports every upstream of this generation's proxy pools, in declaration order.
+func (t *Tenant) ProxyUpstreams() []ProxyUpstream {
+	t.proxyMu.Lock()
+	routes := append([]*proxyRoute(nil), t.proxyRoutes...)
+	t.proxyMu.Unlock()
+	var out []ProxyUpstream
+	for _, route := range routes {
+		for _, status := range route.pool.Status() {
+			out = append(out, ProxyUpstream{Route: route.route, Status: status})
+		}
+	}
+	return out
+}
+
+// proxySave stores a complete 2xx answer in the route's tier.
+type proxySave func(body []byte, contentType string, status int, headers map[string]string)
+
+// serveProxy forwards one request and answers the client straight from the upstream. An error means
+// nothing was written, so the caller may still serve a stale copy or a 502 — a 503 when the error
+// wraps upstream.ErrUnavailable. save is set for a GET whose route keeps a tier.
+func (t *Tenant) serveProxy(
+	requestScope *requestscope.Scope,
+	vm *runtime.VM,
+	bc *compiler.Bytecode,
+	method *FolderMethod,
+	ctxObj *Context,
+	save proxySave,
+) error {
+	route := method.proxy
+	reqRouter := ctxObj.router()
+	r := reqRouter.request
+	if save != nil && !route.shared && route.credentialed(r.Header) {
+		save = nil
+	}
+	exchange := &proxyExchange{route: route, scope: requestScope, router: reqRouter, save: save}
+	rest := ""
+	exchange.mount = strings.TrimSuffix(r.URL.Path, "/")
+	if route.splat != "" {
+		rest = ctxObj.Params(route.splat).Text()
+		exchange.mount = strings.TrimSuffix(exchange.mount, "/"+rest)
+	}
+
+	if route.pool == nil {
+		// Resolve the upstream. A handler computes it per request (params/path) and gets it used
+		// as is; a fixed URL declared in the router takes the rest of the path and the query —
+		// either way the TENANT owns it, never the visitor.
+		target := route.target
+		computed := target.K == value.Func
+		if computed {
+			target = t.execTree(vm, bc, lambdaOf(target), ctxObj)
+			if target.K == value.Invalid {
+				return fmt.Errorf("proxy target handler failed: %v", target.V)
+			}
+		}
+		address := strings.TrimSpace(target.Text())
+		if address == "" {
+			return fmt.Errorf("proxy: target resolved to an empty url")
+		}
+		base, err := url.Parse(address)
+		if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+			return fmt.Errorf("proxy: target %q is not an absolute http(s) url", address)
+		}
+		full := base
+		if !computed {
+			full = upstreamURL(base, rest, r.URL.RawQuery)
+		}
+		if err := exchange.attempt(base, full, false); err != nil {
+			return fmt.Errorf("proxy %s: %w", address, err)
+		}
+		return nil
+	}
+
+	// A pool retries on another upstream only while the request can be sent again: a body that
+	// already streamed to the first one is gone.
+	replayable := r.Body == nil || r.Body == http.NoBody
+	var tried []*upstream.Upstream
+	var failure error
+	for attempt := 0; attempt <= route.retries; attempt++ {
+		u, err := route.pool.Pick(tried)
+		if err != nil {
+			if failure == nil {
+				failure = fmt.Errorf("proxy %s: %w", route.route, err)
+			}
+			break
+		}
+		tried = append(tried, u)
+		base, _ := url.Parse(u.URL)
+		err = exchange.attempt(base, upstreamURL(base, rest, r.URL.RawQuery), true)
+		route.pool.Done(u, err)
+		if err == nil {
+			return nil
+		}
+		failure = fmt.Errorf("proxy %s: %w", u.URL, err)
+		if !replayable {
+			break
+		}
 	}
-	resp := req.Fire()
-	if resp.Error != "" {
-		return fmt.Errorf("proxy %s: %s", url, resp.Error)
+	return failure
+}
+
+// upstreamURL joins the rest of the request path (escaped again, segment by segment) and its query
+// to an upstream's base URL.
+func upstreamURL(base *url.URL, rest, query string) *url.URL {
+	target := *base
+	if rest != "" {
+		parts := strings.Split(rest, "/")
+		for i, part := range parts {
+			parts[i] = url.PathEscape(part)
+		}
+		target.Path = strings.TrimSuffix(base.Path, "/") + "/" + rest
+		target.RawPath = strings.TrimSuffix(base.EscapedPath(), "/") + "/" + strings.Join(parts, "/")
+	}
+	if query != "" && target.RawQuery != "" {
+		target.RawQuery += "&" + query
+	} else if query != "" {
+		target.RawQuery = query
+	}
+	return &target
+}
+
+// proxyExchange is one proxied request, across its attempts.
+type proxyExchange struct {
+	route     *proxyRoute
+	scope     *requestscope.Scope
+	router    *Router
+	mount     string // the public path the route answers at: where Location and cookies are moved to
+	save      proxySave
+	committed bool
+}
+
+// attempt runs the exchange with one upstream: base is its URL as declared, target the URL the
+// request goes to. It returns an error only while nothing was written — the transport failed, the
+// headers did not arrive within the timeout, or, with failOn5xx, the upstream answered 5xx.
+func (x *proxyExchange) attempt(base, target *url.URL, failOn5xx bool) error {
+	w, r := x.router.responseWriter, x.router.request
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	var timedOut atomic.Bool
+	timer := time.AfterFunc(x.route.timeout, func() {
+		timedOut.Store(true)
+		cancel()
+	})
+	defer timer.Stop()
+	ctx, span := trace.Start(ctx, "proxy "+r.Method, trace.KindClient)
+	defer span.End()
+	if span.Recording() {
+		span.SetAttribute("http.request.method", r.Method)
+		span.SetAttribute("server.address", target.Host)
+		span.SetAttribute("url.full", target.Scheme+"://"+target.Host+target.EscapedPath()) // no query: it may carry keys
 	}
 
-	// 3. Replay the upstream bytes under the upstream's OWN media type — an image must not come back
-	//    as octet-stream. "typed" is the response kind that carries an arbitrary Content-Type, and it
-	//    is exactly what tree_cache stores/serves, so a cached hit is byte-identical.
-	contentType := strings.TrimSpace(resp.ContentType)
-	if contentType == "" {
-		contentType = "application/octet-stream"
+	writer := w
+	var kept *proxyCopy
+	if x.save != nil {
+		kept = &proxyCopy{ResponseWriter: w}
+		writer = kept
+	}
+	var failure error
+	length := int64(-1)
+	proxy := &httputil.ReverseProxy{
+		Transport: httphelper.Transport(), // private space stays blocked unless the engine allows it
+		Rewrite:   func(pr *httputil.ProxyRequest) { x.rewrite(pr, target) },
+		ModifyResponse: func(resp *http.Response) error {
+			if !timer.Stop() {
+				return context.DeadlineExceeded
+			}
+			span.SetAttribute("http.response.status_code", resp.StatusCode)
+			if failOn5xx && resp.StatusCode >= 500 {
+				return fmt.Errorf("status %d", resp.StatusCode)
+			}
+			x.route.rewriteResponse(resp.Header, base, x.mount)
+			if x.router.cors != nil { // the folder's policy answers for CORS, not the upstream's
+				for name := range resp.Header {
+					if strings.HasPrefix(name, "Access-Control-") {
+						resp.Header.Del(name)
+					}
+				}
+			}
+			length = resp.ContentLength
+			x.commit(resp)
+			return nil
+		},
+		ErrorHandler: func(_ http.ResponseWriter, _ *http.Request, err error) { failure = err },
+		ErrorLog:     log.New(io.Discard, "", 0),
+	}
+	proxy.ServeHTTP(writer, r.WithContext(ctx))
+
+	if failure != nil {
+		if timedOut.Load() {
+			failure = fmt.Errorf("no response within %s", x.route.timeout)
+		}
+		span.Fail(failure.Error())
+		if x.committed { // the protocol switch failed after the upstream agreed to it
+			http.Error(w, failure.Error(), http.StatusBadGateway)
+			return nil
+		}
+		return failure
+	}
+	if kept != nil && !kept.over && kept.status >= 200 && kept.status < 300 &&
+		(length < 0 || int64(kept.body.Len()) == length) && w.Header().Get("Set-Cookie") == "" && storable(w.Header()) {
+		contentType := w.Header().Get("Content-Type")
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		headers := map[string]string{}
+		for _, name := range proxyStoredHeaders {
+			if data := w.Header().Get(name); data != "" {
+				headers[name] = data
+			}
+		}
+		x.save(kept.body.Bytes(), contentType, kept.status, headers)
 	}
-	ctxObj.Response().Type(contentType)
-	ctxObj.Response().Return(resp.Body, "typed", resp.Status)
 	return nil
 }
+
+// rewrite builds the outbound request: the upstream URL under the upstream's own Host, only the
+// allowed headers, the X-Forwarded-* set, and this span's trace context.
+func (x *proxyExchange) rewrite(pr *httputil.ProxyRequest, target *url.URL) {
+	out := *target
+	pr.Out.URL = &out
+	pr.Out.Host = ""
+	for name := range pr.Out.Header {
+		if !x.route.forwards(name) {
+			pr.Out.Header.Del(name)
+		}
+	}
+	if x.save != nil {
+		pr.Out.Header.Del("Accept-Encoding") // the stored copy must replay to any client: identity
+	}
+	in := pr.In
+	client := in.RemoteAddr
+	if host, _, err := net.SplitHostPort(client); err == nil {
+		client = host
+	}
+	forwardedFor, forwardedHost, forwardedProto := client, in.Host, "http"
+	if in.TLS != nil {
+		forwardedProto = "https"
+	}
+	if TrustProxyHeaders { // the hop in front of us vouches for what it saw
+		if prior := in.Header.Get("X-Forwarded-For"); prior != "" {
+			forwardedFor = prior + ", " + client
+		}
+		if host := in.Header.Get("X-Forwarded-Host"); host != "" {
+			forwardedHost = host
+		}
+		if proto := in.Header.Get("X-Forwarded-Proto"); proto != "" {
+			forwardedProto = proto
+		}
+	}
+	pr.Out.Header.Set("X-Forwarded-For", forwardedFor)
+	pr.Out.Header.Set("X-Forwarded-Host", forwardedHost)
+	pr.Out.Header.Set("X-Forwarded-Proto", forwardedProto)
+	if x.mount != "" {
+		pr.Out.Header.Set("X-Forwarded-Prefix", x.mount)
+	}
+	trace.Inject(pr.Out.Context(), pr.Out.Header)
+}
+
+// commit is the point of no return: the upstream answered and its response goes out. The guards'
+// cookies, headers and the folder's CORS answer go first; the VM's part is over — a stream or a
+// tunnel may last hours — and a long-lived one is freed from the server's deadlines.
+func (x *proxyExchange) commit(resp *http.Response) {
+	x.committed = true
+	w := x.router.responseWriter
+	if x.router.cors != nil {
+		writeCorsHeaders(x.router.cors, w, x.router.request)
+	}
+	x.router.response.writeCookies(w)
+	x.router.response.writeHeaders(w)
+	x.scope.ReleaseVM()
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if resp.StatusCode == http.StatusSwitchingProtocols || mediaType == "text/event-stream" {
+		control := http.NewResponseController(w)
+		_ = control.SetReadDeadline(time.Time{})
+		_ = control.SetWriteDeadline(time.Time{})
+	}
+}
+
+// rewriteResponse moves the upstream's Location and cookies to the mount: a redirect to
+// <upstream>/x becomes <mount>/x, a cookie's Path follows, and its Domain becomes cookieDomain — or
+// is dropped when it names the upstream host, which the browser would refuse anyway.
+func (route *proxyRoute) rewriteResponse(header http.Header, base *url.URL, mount string) {
+	if location := header.Get("Location"); location != "" && route.location {
+		header.Set("Location", rebaseLocation(location, base, mount))
+	}
+	cookies := header.Values("Set-Cookie")
+	if len(cookies) == 0 {
+		return
+	}
+	rewritten := make([]string, len(cookies))
+	for i, cookie := range cookies {
+		rewritten[i] = route.rewriteCookie(cookie, base, mount)
+	}
+	header["Set-Cookie"] = rewritten
+}
+
+// rebaseLocation maps a URL under the upstream's base to the same place under the mount. A
+// relative or foreign one is left alone.
+func rebaseLocation(location string, base *url.URL, mount string) string {
+	parsed, err := url.Parse(location)
+	if err != nil {
+		return location
+	}
+	if parsed.IsAbs() {
+		if !strings.EqualFold(parsed.Scheme, base.Scheme) || !strings.EqualFold(parsed.Host, base.Host) {
+			return location
+		}
+	} else if parsed.Host != "" || !strings.HasPrefix(parsed.Path, "/") {
+		return location
+	}
+	path, ok := rebasePath(parsed.Path, base.Path, mount)
+	if !ok {
+		return location
+	}
+	return (&url.URL{Path: path, RawQuery: parsed.RawQuery, Fragment: parsed.Fragment}).String()
+}
+
+// rebasePath swaps the base prefix of path for mount; ok is false when path is outside base.
+func rebasePath(path, base, mount string) (string, bool) {
+	base = strings.TrimSuffix(base, "/")
+	if path != base && !strings.HasPrefix(path, base+"/") {
+		return "", false
+	}
+	path = mount + strings.TrimPrefix(path, base)
+	if path == "" {
+		path = "/"
+	}
+	return path, true
+}
+
+func (route *proxyRoute) rewriteCookie(cookie string, base *url.URL, mount string) string {
+	parts := strings.Split(cookie, ";")
+	out := []string{parts[0]}
+	for _, part := range parts[1:] {
+		name, data, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch strings.ToLower(name) {
+		case "domain":
+			switch {
+			case route.cookieDomain != nil && *route.cookieDomain == "":
+				continue
+			case route.cookieDomain != nil:
+				part = " Domain=" + *route.cookieDomain
+			case strings.EqualFold(strings.TrimPrefix(data, "."), base.Hostname()):
+				continue
+			}
+		case "path":
+			if path, ok := rebasePath(data, base.Path, mount); ok {
+				if path != "/" {
+					path = strings.TrimSuffix(path, "/")
+				}
+				part = " Path=" + path
+			}
+		}
+		out = append(out, part)
+	}
+	return strings.Join(out, ";")
+}
+
+// proxyCopy passes the upstream's answer through to the client and keeps a copy of it for the
+// route's tier, up to maxProxyCopy.
+type proxyCopy struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+	over   bool
+}
+
+func (c *proxyCopy) WriteHeader(status int) {
+	if c.status == 0 && status >= 200 { // a 103 Early Hints passes through; the final status is kept
+		c.status = status
+	}
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *proxyCopy) Write(p []byte) (int, error) {
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	if !c.over && c.body.Len()+len(p) > maxProxyCopy {
+		c.over = true
+		c.body = bytes.Buffer{}
+	} else if !c.over {
+		c.body.Write(p)
+	}
+	return c.ResponseWriter.Write(p)
+}
+
+// Unwrap lets http.ResponseController reach the connection (flush) through the copy.
+func (c *proxyCopy) Unwrap() http.ResponseWriter { return c.ResponseWriter }
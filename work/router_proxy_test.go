@@ -1,13 +1,19 @@
 package work
 
 import (
+	"bufio"
 	"bytes"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 // router.proxy(target).persist(): a folder answered from an UPSTREAM.
@@ -107,3 +113,300 @@ func TestTreeProxyPersist(t *testing.T) {
 		t.Errorf("handler-target content-type = %q, want text/plain*", ct)
 	}
 }
+
+// A cached proxy route keys its copy on the path alone, so it stores only answers that are the same
+// for every caller: not one fetched with the caller's Authorization or Cookie (forwarded only when
+// the route lists them, and stored only when it is shared), nor one the upstream marks private or
+// varies on a header outside the key.
+func TestTreeProxyCacheCredentials(t *testing.T) {
+	var hits sync.Map
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count, _ := hits.LoadOrStore(r.URL.Path, new(atomic.Int32))
+		count.(*atomic.Int32).Add(1)
+		switch r.URL.Path {
+		case "/private":
+			w.Header().Set("Cache-Control", "max-age=60, private")
+		case "/vary":
+			w.Header().Set("Vary", "Accept-Encoding, Accept-Language")
+		}
+		_, _ = io.WriteString(w, "for "+r.Header.Get("Authorization"))
+	}))
+	defer origin.Close()
+	AllowLocal = true
+	defer func() { AllowLocal = false }()
+
+	tmp := t.TempDir()
+	dir := filepath.Join(tmp, "test", "localhost")
+	write := func(rel, content string) {
+		t.Helper()
+		p := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("router.kitwork.js", `import { router } from "kitwork";`)
+	for _, name := range []string{"private", "vary", "cdn"} {
+		write(name+"/router.kitwork.js", `import { router } from "kitwork";`+"\n"+
+			`router.proxy("`+origin.URL+`/`+name+`").cache("1h");`)
+	}
+	write("me/router.kitwork.js", `import { router } from "kitwork";`+"\n"+
+		`router.proxy("`+origin.URL+`/me", { headers: ["Authorization"] }).cache("1h");`)
+	write("shared/router.kitwork.js", `import { router } from "kitwork";`+"\n"+
+		`router.proxy("`+origin.URL+`/shared", { headers: ["Authorization"], shared: true }).cache("1h");`)
+
+	tenant := NewTenant(tmp, "localhost")
+	if err := tenant.Run(); err != nil {
+		t.Fatal(err)
+	}
+	defer tenant.Close()
+	get := func(path, authorization string) string {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost"+path, nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		tenant.Serve(rec, req)
+		return rec.Body.String()
+	}
+	count := func(path string) int32 {
+		if n, ok := hits.Load(path); ok {
+			return n.(*atomic.Int32).Load()
+		}
+		return 0
+	}
+
+	if got := get("/me", "Bearer alice"); got != "for Bearer alice" {
+		t.Fatalf("alice: %q", got)
+	}
+	if got := get("/me", "Bearer bob"); got != "for Bearer bob" {
+		t.Fatalf("bob got %q: a credentialed answer was stored", got)
+	}
+	get("/me", "")
+	if got := get("/me", ""); got != "for " || count("/me") != 3 {
+		t.Fatalf("anonymous: %q after %d upstream hits, want the stored copy", got, count("/me"))
+	}
+	// Credentials are not forwarded unless the route lists them, so the visitor's answer is shared.
+	get("/cdn", "Bearer alice")
+	if got := get("/cdn", "Bearer bob"); got != "for " || count("/cdn") != 1 {
+		t.Fatalf("default route: %q after %d upstream hits", got, count("/cdn"))
+	}
+	get("/shared", "Bearer alice")
+	if get("/shared", "Bearer bob"); count("/shared") != 1 {
+		t.Fatalf("shared route: %d upstream hits, want 1", count("/shared"))
+	}
+	for _, path := range []string{"/private", "/vary"} {
+		get(path, "")
+		if get(path, ""); count(path) != 2 {
+			t.Fatalf("%s: %d upstream hits, want 2 (not stored)", path, count(path))
+		}
+	}
+}
+
+// router.proxy({ upstreams }): a failing upstream is retried on the next one and its breaker opens;
+// the splat and query reach the upstream; with every upstream down the route's tier serves its
+// expired copy, and a path it never cached answers 503.
+func TestTreeProxyPool(t *testing.T) {
+	var healthy atomic.Bool
+	healthy.Store(true)
+	good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			http.Error(w, "down", http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte("good " + r.URL.RequestURI()))
+	}))
+	defer good.Close()
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusBadGateway)
+	}))
+	defer bad.Close()
+
+	AllowLocal = true
+	defer func() { AllowLocal = false }()
+
+	tmp := t.TempDir()
+	dir := filepath.Join(tmp, "test", "localhost")
+	write := func(rel, content string) {
+		t.Helper()
+		p := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("router.kitwork.js", `import { router } from "kitwork";`)
+	write("api/{...path}/router.kitwork.js", `import { router } from "kitwork";
+router.proxy({
+  upstreams: ["`+bad.URL+`", "`+good.URL+`/v1/"],
+  timeout: "2s", retries: 1,
+  breaker: { failures: 1, cooldown: "1m" },
+}).cache("50ms");`)
+
+	tenant := NewTenant(tmp, "localhost")
+	if err := tenant.Run(); err != nil {
+		t.Fatal(err)
+	}
+	defer tenant.Close()
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		tenant.Serve(rec, httptest.NewRequest(http.MethodGet, "http://localhost"+path, nil))
+		return rec
+	}
+
+	for i := 0; i < 3; i++ {
+		if rec := get("/api/a/b%20c?x=1"); rec.Code != http.StatusOK || rec.Body.String() != "good /v1/a/b%20c?x=1" {
+			t.Fatalf("request %d: %d %q", i, rec.Code, rec.Body.String())
+		}
+	}
+	if status := tenant.ProxyUpstreams(); len(status) != 2 || status[0].State != "open" || status[1].Successes != 1 ||
+		status[0].Route != "/api/{...path}" {
+		t.Fatalf("upstreams after failover: %+v", status)
+	}
+
+	healthy.Store(false)
+	time.Sleep(60 * time.Millisecond) // the cached copy expires
+	rec := get("/api/a/b%20c?x=1")
+	if rec.Code != http.StatusOK || rec.Body.String() != "good /v1/a/b%20c?x=1" || rec.Header().Get("X-Kitwork-Cache") != "stale" {
+		t.Fatalf("stale fallback: %d %q %q", rec.Code, rec.Body.String(), rec.Header().Get("X-Kitwork-Cache"))
+	}
+	if rec := get("/api/never-cached"); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("all upstreams open: %d %q", rec.Code, rec.Body.String())
+	}
+	for _, status := range tenant.ProxyUpstreams() {
+		if status.Up {
+			t.Fatalf("upstream still up: %+v", status)
+		}
+	}
+}
+
+// router.proxy forwards the whole exchange: method, body, the allowed headers and X-Forwarded-*,
+// the rest of the path and the query; it passes back status, headers and cookies with Location and
+// cookie Domain/Path moved to the mount, streams an event stream as it is written, tunnels an
+// Upgrade, and refuses private space unless the engine allows it.
+func TestTreeProxyForward(t *testing.T) {
+	release := make(chan struct{})
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/echo":
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("X-Seen", strings.Join([]string{r.Method, r.URL.RawQuery, string(body),
+				r.Header.Get("Authorization"), r.Header.Get("X-Secret"), r.Header.Get("X-Forwarded-Host"),
+				r.Header.Get("X-Forwarded-Prefix"), r.Header.Get("X-Forwarded-For")}, "|"))
+			http.SetCookie(w, &http.Cookie{Name: "sid", Value: "1", Path: "/v1/", Domain: "127.0.0.1"})
+			w.WriteHeader(http.StatusCreated)
+		case "/v1/login":
+			http.Redirect(w, r, "http://"+r.Host+"/v1/welcome?next=1", http.StatusFound)
+		case "/v1/events":
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = io.WriteString(w, "data: one\n\n")
+			w.(http.Flusher).Flush()
+			<-release
+			_, _ = io.WriteString(w, "data: two\n\n")
+		case "/v1/tunnel":
+			conn, rw, err := http.NewResponseController(w).Hijack()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			_, _ = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\n")
+			_ = rw.Flush()
+			line, _ := rw.ReadString('\n')
+			_, _ = rw.WriteString("echo " + line)
+			_ = rw.Flush()
+		}
+	}))
+	defer origin.Close()
+
+	tmp := t.TempDir()
+	dir := filepath.Join(tmp, "test", "localhost")
+	write := func(rel, content string) {
+		t.Helper()
+		p := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("router.kitwork.js", `import { router } from "kitwork";`)
+	write("app/{...rest}/router.kitwork.js", `import { router } from "kitwork";
+router.proxy("`+origin.URL+`/v1", { headers: ["Authorization"] });`)
+
+	tenant := NewTenant(tmp, "localhost")
+	if err := tenant.Run(); err != nil {
+		t.Fatal(err)
+	}
+	defer tenant.Close()
+	front := httptest.NewServer(http.HandlerFunc(tenant.Serve))
+	defer front.Close()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	do := func(method, path, body string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(method, front.URL+path, strings.NewReader(body))
+		req.Host = "localhost"
+		req.Header.Set("Authorization", "Bearer t")
+		req.Header.Set("X-Secret", "s")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	// Private space is refused until the engine allows it.
+	if resp := do(http.MethodGet, "/app/echo", ""); resp.StatusCode != http.StatusBadGateway {
+		t.Fatalf("private upstream: %d", resp.StatusCode)
+	}
+	AllowLocal = true
+	defer func() { AllowLocal = false }()
+
+	resp := do(http.MethodPost, "/app/echo?a=1", "form=data")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated ||
+		resp.Header.Get("X-Seen") != "POST|a=1|form=data|Bearer t||localhost|/app|127.0.0.1" {
+		t.Fatalf("forwarded: %d %q", resp.StatusCode, resp.Header.Get("X-Seen"))
+	}
+	if cookie := resp.Header.Get("Set-Cookie"); cookie != "sid=1; Path=/app" {
+		t.Fatalf("cookie: %q", cookie)
+	}
+
+	resp = do(http.MethodGet, "/app/login", "")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound || resp.Header.Get("Location") != "/app/welcome?next=1" {
+		t.Fatalf("redirect: %d %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	resp = do(http.MethodGet, "/app/events", "")
+	first := make([]byte, len("data: one\n\n"))
+	if _, err := io.ReadFull(resp.Body, first); err != nil || string(first) != "data: one\n\n" {
+		t.Fatalf("first event before the stream ended: %q %v", first, err)
+	}
+	close(release)
+	if rest, _ := io.ReadAll(resp.Body); string(rest) != "data: two\n\n" {
+		t.Fatalf("second event: %q", rest)
+	}
+	resp.Body.Close()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(front.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	_, _ = io.WriteString(conn, "GET /app/tunnel HTTP/1.1\r\nHost: localhost\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\n")
+	reader := bufio.NewReader(conn)
+	switched, err := http.ReadResponse(reader, nil)
+	if err != nil || switched.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("upgrade: %v %v", switched, err)
+	}
+	_, _ = io.WriteString(conn, "ping\n")
+	if line, _ := reader.ReadString('\n'); line != "echo ping\n" {
+		t.Fatalf("tunnel: %q", line)
+	}
+}
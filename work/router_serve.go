@@ -10,6 +10,7 @@ package work
 // router.kitwork.js), so the VM is FastReset per folder before its lambdas run — see execTree.
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -22,6 +23,9 @@ import (
 	"github.com/kitwork/engine/compiler"
 	requestscope "github.com/kitwork/engine/request"
 	"github.com/kitwork/engine/runtime"
+	"github.com/kitwork/engine/utilities/socket"
+	"github.com/kitwork/engine/utilities/trace"
+	"github.com/kitwork/engine/utilities/upstream"
 	"github.com/kitwork/engine/value"
 )
 
@@ -34,13 +38,45 @@ func (t *Tenant) serveTree(requestScope *requestscope.Scope) {
 		return
 	}
 
+	// _redirects: a forced rule answers before anything else, the others only where no static
+	// file does. A rewrite continues with the rewritten request.
+	rule, target, redirected := t.matchRedirect(r)
+	if redirected && rule.Force {
+		if applyRedirect(w, r, rule, target) {
+			return
+		}
+		redirected = false
+	}
+
 	// Static assets win first: a real file on disk under the tenant (e.g. /assets/logo.png) is
 	// streamed straight from disk (Zero-VM), never routed. Source files are never exposed.
 	if t.serveTreeStatic(w, r) {
 		return
 	}
+	if redirected {
+		if applyRedirect(w, r, rule, target) || t.serveTreeStatic(w, r) {
+			return
+		}
+	}
 
-	match := tree.Resolve(r.URL.Path)
+	// router.locales(): the locale prefix is not a folder — the tree resolves the path without it.
+	var locales *localeConfig
+	var lr localeRequest
+	routePath := r.URL.Path
+	if tree.root.folder != nil && tree.root.folder.locales != nil {
+		locales = tree.root.folder.locales
+		lr = locales.resolve(r)
+		routePath = lr.path
+		if lr.negotiate {
+			addVary(w, "Accept-Language", "Cookie")
+		}
+	}
+
+	match := tree.Resolve(routePath)
+	if lr.redirect != "" && match.Found && t.folderHasPage(match.Node) {
+		http.Redirect(w, r, lr.redirect, http.StatusFound)
+		return
+	}
 
 	reqRouter := &Router{
 		tenant:         t,
@@ -51,7 +87,16 @@ func (t *Tenant) serveTree(requestScope *requestscope.Scope) {
 		Method:         r.Method,
 		Path:           r.URL.Path,
 		requestID:      requestID(r),
+		locale:         lr.locale,
+		locales:        locales,
+		localePath:     routePath,
 	}
+	if principal := requestScope.Principal(); principal.Authenticated {
+		reqRouter.user = principal.Subject
+	}
+	// Flag exposures are written once the response is out, whichever way the request ended.
+	defer func() { reqRouter.flags.record() }()
+	defer reqRouter.settleWebhooks()
 	w.Header().Set("X-Request-ID", reqRouter.requestID)
 	ctxObj := &Context{request: &Request{router: reqRouter}}
 
@@ -67,8 +112,13 @@ func (t *Tenant) serveTree(requestScope *requestscope.Scope) {
 			chainJsonld = append(chainJsonld, node.folder.jsonld...)
 		}
 	}
+	if lr.locale != "" {
+		chainMeta["language"] = value.New(lr.locale)
+	}
 	reqRouter.chainMeta = chainMeta
 	reqRouter.chainJsonld = chainJsonld
+	// router.cors(): the responder (and a cache hit) writes the headers for THIS request's origin.
+	reqRouter.cors = corsPolicy(match.Chain)
 	// Presentation declarations were frozen with the graph before activation.
 	reqRouter.treeRender = t.treeRender(match.Node)
 
@@ -85,7 +135,11 @@ func (t *Tenant) serveTree(requestScope *requestscope.Scope) {
 
 	// savMethod (set once a cacheable method resolves) makes finalize persist the response.
 	var savMethod *FolderMethod
-	savKey := cacheKey(r)
+	baseKey := cacheKey(r)
+	if lr.negotiate {
+		baseKey += " locale=" + lr.locale
+	}
+	savKey := baseKey
 
 	// finalize renders any deferred view builder (ctx.view/bind/…), saves it if the method opted
 	// into caching, then writes the response.
@@ -109,13 +163,17 @@ func (t *Tenant) serveTree(requestScope *requestscope.Scope) {
 			reqRouter.viewBuilder.flush()
 		}
 		if savMethod != nil && reqRouter.err == nil {
-			t.saveResponse(savMethod, savKey, reqRouter.response)
+			if suffix, ok := reqRouter.learnFlags(savMethod); ok {
+				t.saveResponse(savMethod, savKey+suffix, reqRouter.response)
+			}
 		}
 		if reqRouter.response.Kind() == "sse" {
 			// Streaming can remain open for hours. The SSE response retains only
 			// Go-native broker state, so the request VM can return to the pool now.
 			requestScope.ReleaseVM()
 			reqRouter.streamSSE(w)
+		} else if reqRouter.response.Kind() == "stream" {
+			t.serveStream(requestScope, ctxObj, w)
 		} else {
 			reqRouter.responder(w)
 		}
@@ -134,6 +192,15 @@ func (t *Tenant) serveTree(requestScope *requestscope.Scope) {
 		notFound()
 		return
 	}
+	route := "/" + match.Node.relPath()
+	trace.FromContext(r.Context()).SetAttribute("http.route", route)
+	noteAccessRoute(r, route)
+
+	// A CORS preflight is answered from the policy alone: no rate-limit bucket, no VM, no guard.
+	if reqRouter.cors != nil && isPreflight(r) {
+		servePreflight(reqRouter.cors, w, r)
+		return
+	}
 
 	// Folder-level rate limits (router.ratelimit), outside-in: ONE bucket per client per rule for
 	// the folder's whole subtree — a root rule is a site-wide ceiling. Enforced before any guard
@@ -152,6 +219,16 @@ func (t *Tenant) serveTree(requestScope *requestscope.Scope) {
 		}
 	}
 
+	// Declared body limits (.body) hold before any VM work: an oversized Content-Length is refused
+	// here, and every other body is cut off at the limit while it is read.
+	if leaf := match.Node.folder; leaf != nil {
+		if m := leaf.methods[r.Method]; m != nil && m.body != nil && !limitBody(m.body, w, r) {
+			reqRouter.response.Text(value.New("Request Entity Too Large"), http.StatusRequestEntityTooLarge)
+			finalize()
+			return
+		}
+	}
+
 	vm, err := requestScope.LeaseVM(enginePool.Acquire, enginePool.Release)
 	if err != nil {
 		http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
@@ -176,6 +253,9 @@ func (t *Tenant) serveTree(requestScope *requestscope.Scope) {
 
 	leaf := match.Node.folder
 	method := generatedMethod
+	if method == nil && leaf.socket != nil && r.Method == http.MethodGet && socket.IsUpgrade(r) {
+		method = leaf.socket
+	}
 	if method == nil {
 		method = leaf.methods[r.Method]
 	}
@@ -184,8 +264,13 @@ func (t *Tenant) serveTree(requestScope *requestscope.Scope) {
 	// else is a not-found (method not allowed bubbles to the same 404 view for now).
 	if method == nil {
 		if r.Method == http.MethodGet && t.folderHasPage(match.Node) {
+			reqRouter.sendEarlyHints(w, t.earlyHints(r, match.Chain, match.Node))
 			ctxObj.View()
 			finalize()
+		} else if r.Method == http.MethodGet && leaf.socket != nil {
+			w.Header().Set("Upgrade", "websocket")
+			reqRouter.response.Text(value.New("Upgrade Required"), http.StatusUpgradeRequired)
+			finalize()
 		} else {
 			notFound()
 		}
@@ -202,15 +287,29 @@ func (t *Tenant) serveTree(requestScope *requestscope.Scope) {
 		}
 	}
 
-	// Response cache (.cache RAM / .persist disk) — serve a hit with no VM, no render.
-	if body, ct, status, headers, ok := t.cachedResponse(method, savKey); ok {
-		if t.runtimeHealth != nil {
-			t.runtimeHealth.RecordResponseCache(true)
-		}
-		serveCached(w, r, body, ct, status, headers)
-		return
+	// Response cache (.cache RAM / .persist disk) — serve a hit with no VM, no render. A route that
+	// answers through res.format() keeps one entry per negotiated type, one that reads feature flags
+	// one per combination of their answers. A proxy forwards every verb, but only a GET is stored.
+	savKey = negotiatedKey(method, savKey, r)
+	tiered := (method.cacheExpiry != nil || method.persistExpiry != nil) && !method.flagsByAttribute.Load()
+	if method.isProxy && r.Method != http.MethodGet && r.Method != http.MethodHead {
+		tiered = false
 	}
-	if method.cacheExpiry != nil || method.persistExpiry != nil {
+	if tiered {
+		if body, ct, status, headers, tier := t.cachedResponse(method, savKey+reqRouter.flagKey(method)); tier != "" {
+			noteAccessCache(r, tier)
+			if t.runtimeHealth != nil {
+				t.runtimeHealth.RecordResponseCache(true)
+			}
+			if reqRouter.cors != nil {
+				writeCorsHeaders(reqRouter.cors, w, r)
+			}
+			if method.formats.Load() != nil {
+				addVary(w, "Accept")
+			}
+			serveCached(w, r, body, ct, status, headers, "hit")
+			return
+		}
 		if t.runtimeHealth != nil {
 			t.runtimeHealth.RecordResponseCache(false)
 		}
@@ -225,17 +324,77 @@ func (t *Tenant) serveTree(requestScope *requestscope.Scope) {
 		}
 	}
 
+	// With every guard passed, a page's critical assets are announced (103 Early Hints) while the
+	// handler and the render still have to run.
+	if generatedMethod == nil && method.socket == nil && !method.isProxy && reqRouter.err == nil && !reqRouter.response.IsSend() {
+		reqRouter.sendEarlyHints(w, t.earlyHints(r, match.Chain, match.Node))
+	}
+
+	// A .body() route receives its multipart body once the guards let the request in: file parts
+	// stream to the tenant's temp area, checked against the declared sizes and types.
+	if method.body != nil && reqRouter.err == nil && !reqRouter.response.IsSend() {
+		if refused := t.receiveBody(method.body, reqRouter, requestScope); refused != nil {
+			reqRouter.response.Text(value.New(refused.msg), refused.status)
+			finalize()
+			return
+		}
+	}
+
+	// Declared schemas (.body(schema) / .query(schema)) are checked last before the handler, so a
+	// 422 names every bad field and the handler only ever sees typed input.
+	if (method.bodySchema != nil || method.querySchema != nil) && reqRouter.err == nil && !reqRouter.response.IsSend() {
+		if status, problem := t.validateInput(method, reqRouter); status != 0 {
+			reqRouter.response.Type(problemMediaType).Status(status).Send(value.New(string(problem)))
+			finalize()
+			return
+		}
+	}
+
 	// Handler.
 	if reqRouter.err == nil && !reqRouter.response.IsSend() {
 		switch {
+		case method.socket != nil:
+			// The connection is hijacked: nothing is left for finalize to write.
+			t.serveSocket(requestScope, leaf.bytecode, method, ctxObj)
+			return
 		case method.isProxy:
 			// Reverse proxy (router.proxy): a .cache()/.persist() hit already replayed above with no
-			// VM — reaching here means a miss, so fetch the upstream now. A failure is the UPSTREAM's
-			// fault, not ours: 502.
-			if err := t.serveProxy(vm, leaf.bytecode, method, ctxObj); err != nil {
-				reqRouter.err = err
-				reqRouter.response.Text(value.New(err.Error()), http.StatusBadGateway)
+			// VM — reaching here means a miss, so forward to the upstream now. Its answer goes
+			// straight to the client, streamed; a GET keeps a copy for the tier. A failure before
+			// anything was written is the UPSTREAM's fault, not ours: the tier's last copy if it kept
+			// one, else 502 — 503 when no upstream was even available to try.
+			var save proxySave
+			if savMethod != nil && r.Method == http.MethodGet && r.Header.Get("Upgrade") == "" {
+				// Copies, so only a proxied GET pays for the closure: capturing savKey itself would
+				// move every cache key built above to the heap.
+				proxied, key, router := method, strings.Clone(savKey), reqRouter
+				save = func(body []byte, contentType string, status int, headers map[string]string) {
+					if suffix, ok := router.learnFlags(proxied); ok {
+						t.storeResponse(proxied, key+suffix, body, contentType, status, headers)
+					}
+				}
+			}
+			err := t.serveProxy(requestScope, vm, leaf.bytecode, method, ctxObj, save)
+			if err == nil {
+				return
 			}
+			if savMethod != nil {
+				if body, ct, status, headers, ok := t.staleResponse(method, savKey+reqRouter.flagKey(method)); ok {
+					noteAccessCache(r, "stale")
+					if reqRouter.cors != nil {
+						writeCorsHeaders(reqRouter.cors, w, r)
+					}
+					serveCached(w, r, body, ct, status, headers, "stale")
+					return
+				}
+			}
+			status := http.StatusBadGateway
+			if errors.Is(err, upstream.ErrUnavailable) {
+				status = http.StatusServiceUnavailable
+			}
+			savMethod = nil
+			reqRouter.err = err
+			reqRouter.response.Text(value.New(err.Error()), status)
 		case method.outputKind != "":
 			if err := t.executeGeneratedOutput(vm, leaf.bytecode, method, ctxObj); err != nil {
 				reqRouter.err = err
@@ -246,6 +405,9 @@ func (t *Tenant) serveTree(requestScope *requestscope.Scope) {
 			if res.K == value.Invalid {
 				t.recordRuntimeFailure(reqRouter, leaf.bytecode, "handler", res)
 				reqRouter.err = fmt.Errorf("%s", res.Text())
+			} else if reqRouter.response.Kind() == "format" {
+				negotiated := t.negotiateFormat(vm, leaf.bytecode, method, ctxObj, w)
+				savKey = baseKey + " accept=" + negotiated
 			} else if reqRouter.viewBuilder == nil && !reqRouter.response.IsSend() && res.Truthy() {
 				// A raw value (not a deferred view builder) → send it directly (JSON or HTML).
 				if res.K == value.Map || res.K == value.Array {
@@ -333,21 +495,37 @@ func (t *Tenant) execTree(vm *runtime.VM, bc *compiler.Bytecode, l *value.Lambda
 	if l == nil || bc == nil {
 		return value.Value{K: value.Nil}
 	}
+	span, end := traceVM(vm, "vm.execute", trace.KindInternal)
+	defer end()
+	if span.Recording() {
+		span.SetAttribute("code.filepath", l.SourceFile)
+		span.SetAttribute("code.lineno", int(l.SourceLine))
+		if l.Name != "" {
+			span.SetAttribute("code.function", l.Name)
+		}
+	}
 	vm.FastResetPrepared(bc.Program)
 	started := time.Now()
 	result := vm.ExecuteLambda(l, ctxObj.arguments(l))
 	t.recordVMExecution(bc.Program, vm, result, time.Since(started))
+	if result.K == value.Invalid {
+		span.Fail(result.Text())
+	}
 	return result
 }
 
 // runStage runs one guard/middleware lambda and reports whether the pipeline may continue.
 // A guard that returns data auto-sends it (like the flat lifecycle); middleware does not.
 func (t *Tenant) runStage(vm *runtime.VM, bc *compiler.Bytecode, l *value.Lambda, ctxObj *Context, r *Router, isGuard bool) bool {
+	stage := "middleware"
+	if isGuard {
+		stage = "guard"
+	}
+	span, end := traceVM(vm, stage, trace.KindInternal)
+	defer end()
 	res := t.execTree(vm, bc, l, ctxObj)
 	if res.K == value.Invalid {
-		stage := "middleware"
 		if isGuard {
-			stage = "guard"
 			r.err = fmt.Errorf("guard error: %v", res.V)
 		} else {
 			r.err = fmt.Errorf("middleware error: %v", res.V)
@@ -360,6 +538,7 @@ func (t *Tenant) runStage(vm *runtime.VM, bc *compiler.Bytecode, l *value.Lambda
 	}
 	if res.IsBool() && !res.Truthy() {
 		r.err = fmt.Errorf("request rejected")
+		span.SetAttribute("kitwork.rejected", true)
 		return false
 	}
 	if isGuard && !res.IsBlank() && !res.IsBool() {
@@ -395,7 +574,7 @@ func (t *Tenant) serveTreeStatic(w http.ResponseWriter, r *http.Request) bool {
 	if clean == "/" || strings.Contains(clean, "..") {
 		return false
 	}
-	if strings.Contains(strings.ToLower(clean), extension+".") { // never expose *.kitwork.* sources
+	if strings.Contains(strings.ToLower(clean), extension+".") || clean == "/"+redirectsFile { // never expose *.kitwork.* sources or the rules file
 		return false
 	}
 	for _, seg := range strings.Split(clean, "/") { // any dot SEGMENT: .env, .persist/…, .git/…
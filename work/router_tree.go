@@ -311,7 +311,8 @@ func (rt *RouteTree) Resolve(urlPath string) *RouteMatch {
 		return &RouteMatch{Params: map[string]string{}, Found: false}
 	}
 	m := &RouteMatch{Node: rt.root, Chain: []*RouteNode{rt.root}, Params: map[string]string{}, Found: true}
-	for _, seg := range splitPath(urlPath) {
+	segs := splitPath(urlPath)
+	for i, seg := range segs {
 		c := m.Node.child(seg, m.Params)
 		if c == nil {
 			m.Found = false
@@ -319,6 +320,10 @@ func (rt *RouteTree) Resolve(urlPath string) *RouteMatch {
 		}
 		m.Node = c
 		m.Chain = append(m.Chain, c)
+		if c.matcher != nil && c.matcher.kind == segSplat { // {...rest} takes the rest, slashes included
+			m.Params[c.matcher.name] = strings.Join(segs[i:], "/")
+			break
+		}
 	}
 	return m
 }
@@ -12,7 +12,8 @@ import (
 
 // RuntimeHealth aggregates bounded process-local execution, request, render,
 // cache, and generation signals. It intentionally records no request,
-// argument, URL, or tenant data.
+// argument, or URL data; the per-tenant breakdown (tenant_health.go) is keyed
+// by app id and capped, so its size never depends on traffic.
 type RuntimeHealth struct {
 	executions   atomic.Uint64
 	successes    atomic.Uint64
@@ -53,6 +54,8 @@ type RuntimeHealth struct {
 	mu          sync.RWMutex
 	programs    map[[sha256.Size]byte]struct{}
 	diagnostics map[runtime.DiagnosticCode]uint64
+	tenants     map[string]*TenantHealth
+	tenantLimit int
 }
 
 const latencyBucketCount = 9
@@ -162,6 +165,8 @@ func NewRuntimeHealth() *RuntimeHealth {
 	return &RuntimeHealth{
 		programs:    make(map[[sha256.Size]byte]struct{}),
 		diagnostics: make(map[runtime.DiagnosticCode]uint64),
+		tenants:     make(map[string]*TenantHealth),
+		tenantLimit: DefaultTenantLimit,
 	}
 }
 
@@ -128,3 +128,37 @@ func TestRuntimeHealthOperationalSignalsAreBoundedSnapshots(t *testing.T) {
 		t.Fatal("latency snapshot exposed mutable health state")
 	}
 }
+
+func TestRuntimeHealthTenantLabelsAreBounded(t *testing.T) {
+	health := NewRuntimeHealth()
+	health.SetTenantLimit(2)
+	acme, globex := health.Tenant("acme"), health.Tenant("globex")
+	if acme.Label() != "acme" || globex.Label() != "globex" || health.Tenant("acme") != acme {
+		t.Fatalf("labels %q %q", acme.Label(), globex.Label())
+	}
+	// Past the limit every new tenant shares one series.
+	initech, umbrella := health.Tenant("initech"), health.Tenant("umbrella")
+	if initech != umbrella || initech.Label() != OtherTenant {
+		t.Fatalf("overflow labels %q %q", initech.Label(), umbrella.Label())
+	}
+
+	acme.RequestCompleted(200, time.Millisecond)
+	acme.RequestCompleted(503, time.Millisecond)
+	acme.Record(runtime.VMStats{Instructions: 5, Energy: 40}, value.New("ok"))
+	initech.Record(runtime.VMStats{Energy: 7}, value.Value{K: value.Invalid})
+	umbrella.RecordCronLag(1500 * time.Millisecond)
+
+	tenants := health.Tenants()
+	if len(tenants) != 3 || tenants[0].Tenant != OtherTenant || tenants[1].Tenant != "acme" {
+		t.Fatalf("tenants = %+v", tenants)
+	}
+	other, first := tenants[0], tenants[1]
+	if first.Requests != 2 || first.Successful != 1 || first.ServerErrors != 1 ||
+		first.RequestLatency.Count != 2 || first.Energy != 40 || first.Successes != 1 {
+		t.Fatalf("acme = %+v", first)
+	}
+	if other.Energy != 7 || other.Failures != 1 || other.CronRuns != 1 ||
+		other.CronLagNanoseconds != uint64(1500*time.Millisecond) {
+		t.Fatalf("other = %+v", other)
+	}
+}
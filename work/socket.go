@@ -0,0 +1,293 @@
+package work
+
+// WebSocket routes: router.socket({ open, message, close }) on a folder.
+//
+// The upgrade request walks the normal tree lifecycle — folder rate limits and guards, then the
+// socket method's own .guard()/.limit() — and only then switches protocols. A browser upgrade
+// must come from the site itself or from an origin the folder's .cors() allows: the handshake
+// carries the visitor's cookies, so any page could otherwise open a socket as them. Like SSE, the
+// long-lived connection holds NO VM: the request lease is released before the handshake, and each
+// open/message/close invocation borrows a pooled VM (with the tenant's energy limit) for just that
+// call. A connection's handlers run one at a time, in message order.
+//
+// Rooms and broadcast go through the tenant's socket hub. The hub belongs to the Tenant, i.e. to
+// one site generation, because the handlers it drives are that generation's bytecode: when a new
+// generation takes over, Close drains the old hub with 1012 (service restart) and clients
+// reconnect straight into the new code.
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/kitwork/engine/compiler"
+	requestscope "github.com/kitwork/engine/request"
+	"github.com/kitwork/engine/utilities/socket"
+	"github.com/kitwork/engine/value"
+)
+
+// socketRoute is the handler set and limits declared by router.socket().
+type socketRoute struct {
+	path           string // the folder, counted on its own against maxConnections
+	open           *value.Lambda
+	message        *value.Lambda
+	close          *value.Lambda
+	maxMessage     int64
+	maxConnections int
+	ping           time.Duration
+}
+
+// Socket declares this folder's WebSocket endpoint. A GET carrying the upgrade headers is served
+// here; a plain GET still reaches router.get()/the page. Options besides the handlers:
+// maxMessage (bytes, default 64KB), maxConnections (per route, default 1000) and ping (keep-alive
+// interval, default "30s"). A bare function is the message handler:
+//
+//	router.socket({
+//		open: (socket) => socket.join("lobby"),
+//		message: (socket, message) => socket.broadcast("lobby", message),
+//		close: (socket, code) => log.info("left", socket.id, code),
+//	}).guard(auth).limit(20, "1s") // limits apply to the upgrade AND to every message
+func (f *FolderRouter) Socket(args ...value.Value) *FolderMethod {
+	route := &socketRoute{path: "/" + f.node.relPath(), maxMessage: socket.DefaultMessage, maxConnections: 1000,
+		ping: socket.DefaultPing}
+	if len(args) > 0 {
+		if args[0].K == value.Func {
+			route.message = lambdaOf(args[0])
+		} else if args[0].IsMap() {
+			mp := args[0].Map()
+			route.open = lambdaOf(mp["open"])
+			route.message = lambdaOf(mp["message"])
+			route.close = lambdaOf(mp["close"])
+			if v, ok := mp["maxMessage"]; ok && v.N > 0 {
+				route.maxMessage = int64(v.N)
+			}
+			if v, ok := mp["maxConnections"]; ok && v.N > 0 {
+				route.maxConnections = int(v.N)
+			}
+			if v, ok := mp["ping"]; ok {
+				if d := parseTTL(v); d > 0 {
+					route.ping = d
+				}
+			}
+		}
+	}
+	m := &FolderMethod{method: http.MethodGet, socket: route}
+	f.socket = m
+	return m
+}
+
+// socketHub returns this tenant's hub, creating it on first use.
+func (t *Tenant) socketHub() *socket.Hub {
+	t.socketMu.Lock()
+	defer t.socketMu.Unlock()
+	if t.sockets == nil {
+		t.sockets = socket.NewHub()
+	}
+	return t.sockets
+}
+
+// closeSockets drains every open connection; a nil hub (no socket ever opened) costs nothing.
+func (t *Tenant) closeSockets(code int, reason string) {
+	t.socketMu.Lock()
+	hub := t.sockets
+	if hub == nil {
+		hub = socket.NewHub() // refuse handshakes that are still in flight
+		t.sockets = hub
+	}
+	t.socketMu.Unlock()
+	hub.Close(code, reason)
+}
+
+// socketOriginAllowed reports whether an upgrade may proceed: a client that sends no Origin is not
+// a browser acting for a visitor; a browser's must name this host or pass the folder's CORS policy.
+func socketOriginAllowed(r *http.Request, cors *CorsOptions) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if cors != nil && cors.allowOrigin(origin) != "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host != "" && strings.EqualFold(u.Host, r.Host)
+}
+
+// serveSocket upgrades the connection and runs it to the end. Guards and limits already passed.
+func (t *Tenant) serveSocket(requestScope *requestscope.Scope, bc *compiler.Bytecode, method *FolderMethod, ctxObj *Context) {
+	route := method.socket
+	reqRouter := ctxObj.router()
+	w, r := reqRouter.responseWriter, reqRouter.request
+	if !socketOriginAllowed(r, reqRouter.cors) {
+		http.Error(w, "cross-origin WebSocket refused", http.StatusForbidden)
+		return
+	}
+	hub := t.socketHub()
+	// The slot is taken before the handshake, so the cap holds however many upgrades race.
+	conn := socket.NewConn(socket.NewID())
+	conn.Route = route.path
+	registered, err := hub.RegisterLimit(conn, route.maxConnections)
+	if errors.Is(err, socket.ErrFull) {
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if registered {
+		defer hub.Unregister(conn)
+	}
+
+	// The connection may stay open for hours; it borrows a VM per event instead.
+	requestScope.ReleaseVM()
+	netConn, err := socket.Upgrade(w, r)
+	if err != nil {
+		return
+	}
+	sock := &Socket{tenant: t, hub: hub, conn: conn, ctx: ctxObj, data: value.New(map[string]value.Value{})}
+
+	// run executes one handler on a pooled VM; a failing handler closes the connection with 1011.
+	run := func(stage string, l *value.Lambda, event map[string]value.Value) {
+		if l == nil || bc == nil {
+			return
+		}
+		vm, done, err := requestScope.AcquireExecutionVM(enginePool.Acquire, enginePool.Release)
+		if err != nil {
+			conn.Close(socket.CloseGoingAway, "")
+			return
+		}
+		defer done()
+		t.prepareExecutionVM(vm, t.vm.Globals, t.vm.Builtins, requestScope)
+		vm.MaxEnergy = t.MaxEnergy
+		vm.FastResetPrepared(bc.Program)
+		started := time.Now()
+		res := vm.ExecuteLambda(l, sock.arguments(l, event))
+		t.recordVMExecution(bc.Program, vm, res, time.Since(started))
+		if res.K == value.Invalid {
+			t.recordRuntimeFailure(reqRouter, bc, "socket "+stage, res)
+			conn.Close(socket.CloseInternal, "internal error")
+		}
+	}
+
+	run("open", route.open, nil)
+	code, reason := socket.Pump(netConn, conn, socket.Options{MaxMessage: route.maxMessage, Ping: route.ping}, func(m socket.Message) {
+		for _, lim := range method.limits {
+			if !t.limiter.Allow(limitKey(lim, "socket:"+r.URL.Path, r), lim.Rate, lim.Per) {
+				conn.Close(socket.ClosePolicy, "rate limit exceeded")
+				return
+			}
+		}
+		message := value.NewString(string(m.Data))
+		if m.Binary {
+			message = value.New(m.Data)
+		}
+		run("message", route.message, map[string]value.Value{"message": message})
+	})
+	run("close", route.close, map[string]value.Value{"code": value.New(code), "reason": value.NewString(reason)})
+}
+
+// Socket is the connection object socket handlers receive.
+type Socket struct {
+	tenant *Tenant
+	hub    *socket.Hub
+	conn   *socket.Conn
+	ctx    *Context
+	data   value.Value
+}
+
+// arguments binds handler parameters by name, like ctx.arguments: socket/ws/conn is the
+// connection, message/msg/data the incoming message, code/reason the close status; ctx, req and
+// res still resolve to the upgrade request.
+func (s *Socket) arguments(l *value.Lambda, event map[string]value.Value) []value.Value {
+	args := s.ctx.arguments(l)
+	for i, name := range l.Params {
+		switch strings.ToLower(name) {
+		case "socket", "ws", "conn", "connection", "client":
+			args[i] = value.New(s)
+		case "message", "msg", "data", "payload":
+			if v, ok := event["message"]; ok {
+				args[i] = v
+			}
+		case "code":
+			if v, ok := event["code"]; ok {
+				args[i] = v
+			}
+		case "reason":
+			if v, ok := event["reason"]; ok {
+				args[i] = v
+			}
+		}
+	}
+	return args
+}
+
+// encode turns a handler value into a frame: strings go out as text, bytes as binary, anything
+// else as JSON text.
+func encode(v value.Value) socket.Message {
+	switch v.K {
+	case value.String:
+		return socket.Message{Data: []byte(v.String())}
+	case value.Bytes:
+		return socket.Message{Binary: true, Data: v.Bytes()}
+	}
+	return socket.Message{Data: v.ToJSON()}
+}
+
+// ID is the connection id, random per connection.
+func (s *Socket) ID() string { return s.conn.ID }
+
+// Data is per-connection state that survives between handler calls: socket.data.user = …
+func (s *Socket) Data() value.Value { return s.data }
+
+// Rooms lists the rooms this connection joined.
+func (s *Socket) Rooms() value.Value {
+	rooms := s.hub.Rooms(s.conn)
+	out := make([]value.Value, len(rooms))
+	for i, room := range rooms {
+		out[i] = value.NewString(room)
+	}
+	return value.New(out)
+}
+
+func (s *Socket) Params(key string) value.Value { return s.ctx.Params(key) }
+func (s *Socket) Query(key string) value.Value  { return s.ctx.Query(key) }
+
+// Send queues a message for this client.
+func (s *Socket) Send(v value.Value) bool { return s.conn.Send(encode(v)) }
+
+func (s *Socket) Join(room string) *Socket  { s.hub.Join(s.conn, room); return s }
+func (s *Socket) Leave(room string) *Socket { s.hub.Leave(s.conn, room); return s }
+
+// Broadcast sends to everyone else in room and returns how many received it.
+func (s *Socket) Broadcast(room string, v value.Value) int {
+	return s.hub.Broadcast(room, encode(v), s.conn)
+}
+
+// Publish sends to everyone in room, this connection included.
+func (s *Socket) Publish(room string, v value.Value) int {
+	return s.hub.Broadcast(room, encode(v), nil)
+}
+
+// SendTo sends to one connection by id.
+func (s *Socket) SendTo(id string, v value.Value) bool { return s.hub.SendTo(id, encode(v)) }
+
+// Size is the number of connections in room.
+func (s *Socket) Size(room string) int { return s.hub.Size(room) }
+
+// Close ends the connection: socket.close(), socket.close(4000, "kicked").
+func (s *Socket) Close(args ...value.Value) value.Value {
+	code, reason := socket.CloseNormal, ""
+	if len(args) > 0 && args[0].N > 0 {
+		code = int(args[0].N)
+	}
+	if len(args) > 1 {
+		reason = args[1].Text()
+	}
+	if code < 1000 || code > 4999 || code == socket.CloseNoStatus || code == socket.CloseAbnormal {
+		return value.Value{K: value.Invalid, V: fmt.Sprintf("socket.close: invalid close code %d", code)}
+	}
+	s.conn.Close(code, reason)
+	return value.Value{K: value.Nil}
+}
@@ -0,0 +1,227 @@
+package work
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+)
+
+// router.socket end to end: guards on the upgrade, per-connection data, rooms and broadcast, a
+// handler-initiated close, no VM held between events, and the drain when the tenant closes.
+func TestSocketRouteRoomsAndDrain(t *testing.T) {
+	tmp := t.TempDir()
+	dir := filepath.Join(tmp, "test", "localhost")
+	write := func(rel, content string) {
+		p := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("router.kitwork.js", `import { router } from "kitwork";`)
+	write("chat/router.kitwork.js", `import { router } from "kitwork";
+router.socket({
+	open: (socket, ctx) => {
+		socket.data.name = ctx.query("name");
+		socket.join("lobby");
+		socket.send({ welcome: socket.data.name, rooms: socket.rooms });
+	},
+	message: (socket, message) => {
+		if (message == "bye") {
+			socket.close(4000, "bye");
+			return;
+		}
+		socket.broadcast("lobby", socket.data.name + ": " + message);
+	},
+	close: (socket, code) => {
+		socket.broadcast("lobby", socket.data.name + " left " + code);
+	},
+}).guard((ctx) => ctx.query("name") != "");`)
+
+	tenant := NewTenant(tmp, "localhost")
+	if err := tenant.Run(); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(tenant.Serve))
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/chat"
+
+	dial := func(name string) net.Conn {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		conn, buffered, _, err := ws.Dial(ctx, wsURL+"?name="+name)
+		if err != nil {
+			t.Fatalf("dial %s: %v", name, err)
+		}
+		if buffered != nil { // frames that arrived with the handshake response
+			return &bufferedConn{Conn: conn, r: buffered}
+		}
+		return conn
+	}
+	read := func(conn net.Conn) (string, error) {
+		t.Helper()
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		data, _, err := wsutil.ReadServerData(conn)
+		return string(data), err
+	}
+	expect := func(conn net.Conn, want string) {
+		t.Helper()
+		if got, err := read(conn); err != nil || got != want {
+			t.Fatalf("read = %q, %v; want %q", got, err, want)
+		}
+	}
+
+	baseline := enginePool.Active()
+	alice := dial("alice")
+	defer alice.Close()
+	expect(alice, `{"rooms":["lobby"],"welcome":"alice"}`)
+	bob := dial("bob")
+	defer bob.Close()
+	expect(bob, `{"rooms":["lobby"],"welcome":"bob"}`)
+	if got := enginePool.Active(); got != baseline {
+		t.Fatalf("open sockets retained VMs: active=%d baseline=%d", got, baseline)
+	}
+
+	if err := wsutil.WriteClientText(alice, []byte("hi")); err != nil {
+		t.Fatal(err)
+	}
+	expect(bob, "alice: hi")
+
+	if err := wsutil.WriteClientText(bob, []byte("bye")); err != nil {
+		t.Fatal(err)
+	}
+	var closed wsutil.ClosedError
+	if _, err := read(bob); !errors.As(err, &closed) || closed.Code != 4000 || closed.Reason != "bye" {
+		t.Fatalf("bob close = %v", err)
+	}
+	expect(alice, "bob left 4000")
+
+	// The guard rejects the upgrade; a plain GET is told to upgrade.
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if _, _, _, err := ws.Dial(ctx, wsURL); err == nil {
+		t.Fatal("a guard returning false must refuse the upgrade")
+	}
+	if res, err := http.Get(server.URL + "/chat"); err != nil || res.StatusCode != http.StatusUpgradeRequired {
+		t.Fatalf("plain GET = %v, %v", res, err)
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		tenant.Close()
+		close(drained)
+	}()
+	if _, err := read(alice); !errors.As(err, &closed) || closed.Code != 1001 {
+		t.Fatalf("drain close = %v", err)
+	}
+	select {
+	case <-drained:
+	case <-time.After(3 * time.Second):
+		t.Fatal("Tenant.Close did not drain the open socket")
+	}
+}
+
+type bufferedConn struct {
+	net.Conn
+	r io.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) { return c.r.Read(p) }
+
+// Connection ids come from the server: two upgrades sending the same X-Request-ID get their own
+// ids and both stay reachable. A browser upgrade from another site is refused unless .cors()
+// allows that origin.
+func TestSocketRouteIdentityAndOrigin(t *testing.T) {
+	tmp := t.TempDir()
+	dir := filepath.Join(tmp, "test", "localhost")
+	write := func(rel, content string) {
+		p := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("router.kitwork.js", `import { router } from "kitwork";`)
+	write("echo/router.kitwork.js", `import { router } from "kitwork";
+router.socket({ open: (socket) => socket.send(socket.id) });`)
+	write("open/router.kitwork.js", `import { router } from "kitwork";
+router.cors("https://app.example");
+router.socket({ open: (socket) => socket.send("hello") });`)
+
+	tenant := NewTenant(tmp, "localhost")
+	if err := tenant.Run(); err != nil {
+		t.Fatal(err)
+	}
+	defer tenant.Close()
+	server := httptest.NewServer(http.HandlerFunc(tenant.Serve))
+	defer server.Close()
+	base := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	dial := func(path string, header http.Header) (net.Conn, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		conn, buffered, _, err := ws.Dialer{Header: ws.HandshakeHeaderHTTP(header)}.Dial(ctx, base+path)
+		if err == nil && buffered != nil {
+			return &bufferedConn{Conn: conn, r: buffered}, nil
+		}
+		return conn, err
+	}
+	read := func(conn net.Conn) string {
+		t.Helper()
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		data, _, err := wsutil.ReadServerData(conn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	same := http.Header{"X-Request-Id": {"fixed"}}
+	first, err := dial("/echo", same)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	second, err := dial("/echo", same)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+	a, b := read(first), read(second)
+	if a == "fixed" || b == "fixed" || a == b {
+		t.Fatalf("connection ids = %q, %q", a, b)
+	}
+	if hub := tenant.socketHub(); hub.Count() != 2 {
+		t.Fatalf("hub holds %d connections, want 2", hub.Count())
+	}
+
+	if _, err := dial("/echo", http.Header{"Origin": {"https://evil.example"}}); err == nil {
+		t.Fatal("a cross-origin upgrade was accepted")
+	}
+	if conn, err := dial("/echo", http.Header{"Origin": {server.URL}}); err != nil {
+		t.Fatalf("same-origin upgrade: %v", err)
+	} else {
+		conn.Close()
+	}
+	if conn, err := dial("/open", http.Header{"Origin": {"https://app.example"}}); err != nil {
+		t.Fatalf("CORS-allowed upgrade: %v", err)
+	} else {
+		conn.Close()
+	}
+}
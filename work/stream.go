@@ -0,0 +1,246 @@
+package work
+
+// Streaming responses: res.stream(producer, type?).
+//
+// Every other response kind is buffered whole (toBytes) before the first byte goes out. A stream
+// is written progressively instead: the handler returns first, then finalize runs the producer on
+// the request's own VM and hands chunks to the connection as they are made, with no
+// Content-Length, so HTTP/1.1 goes out chunked.
+//
+//   - BACKPRESSURE. write() goes straight to the connection; when the client stops reading, the
+//     socket buffer fills and write() blocks, so the producer never runs ahead of the client. A
+//     client that takes no bytes for streamWriteTimeout is dropped.
+//   - CANCELLATION. The VM runs under the request scope's context: a client that disconnects
+//     cancels it, which halts the producer mid-instruction and ends the stream.
+//   - ENERGY. Each producer call (each page, in pull mode) starts a fresh MaxEnergy budget, and
+//     every chunk written is charged against it — an export is capped per page, not per response.
+//   - COMPRESSION. Chunks are flushed as they are made; utilities/compress passes flushed responses
+//     through uncompressed, exactly as it does SSE.
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	requestscope "github.com/kitwork/engine/request"
+	"github.com/kitwork/engine/runtime"
+	"github.com/kitwork/engine/value"
+)
+
+const (
+	streamFlushBytes   = 32 << 10 // flush at least this often, and at the end of every producer call
+	streamWriteTimeout = 30 * time.Second
+	streamChunkEnergy  = 10 // per chunk, plus one unit per streamEnergyBytes written
+	streamEnergyBytes  = 64
+)
+
+// Stream responds with a body produced progressively by producer, in one of two styles:
+//
+//	// push: a producer that takes the stream (out/stream/writer) runs once and writes as it goes
+//	res.stream((out) => { for (const row of rows) out.write(row) }, "application/x-ndjson")
+//
+//	// pull: any other producer is called page after page (page/index/i = 0, 1, …) until it
+//	// returns nothing, an empty array or false
+//	res.stream((page) => db.orders.limit(1000).offset(page * 1000).get(), "application/x-ndjson")
+//
+// Strings and bytes are written as-is; anything else goes out as one JSON line, and a returned
+// array is written one line per element. The type defaults to the one set with res.type(), else
+// text/plain.
+func (r *Response) Stream(producer value.Value, mediaType ...string) {
+	if lambdaOf(producer) == nil {
+		r.ErrorString("res.stream: expected a producer function", http.StatusInternalServerError)
+		return
+	}
+	if len(mediaType) > 0 {
+		r.Type(mediaType[0])
+	}
+	r.Return(producer, "stream")
+}
+
+func (c *Context) Stream(producer value.Value, mediaType ...string) {
+	c.Response().Stream(producer, mediaType...)
+}
+
+// serveStream writes the head of a "stream" response, then runs the producer until it is done,
+// the client goes away, or it fails. A failure after the head went out aborts the connection so
+// the client sees a truncated body rather than one that merely looks complete.
+func (t *Tenant) serveStream(requestScope *requestscope.Scope, ctxObj *Context, w http.ResponseWriter) {
+	reqRouter := ctxObj.router()
+	request := reqRouter.request
+	producer := lambdaOf(reqRouter.response.Data())
+	program, _ := runtime.ProgramFromRef(producer.Program)
+	vm, err := requestScope.LeaseVM(enginePool.Acquire, enginePool.Release)
+	if program == nil || err != nil {
+		http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	if reqRouter.cors != nil {
+		writeCorsHeaders(reqRouter.cors, w, request)
+	}
+	reqRouter.response.writeCookies(w)
+	reqRouter.response.writeHeaders(w)
+	contentType := reqRouter.response.ContentType()
+	if contentType == "" {
+		contentType = "text/plain; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("X-Accel-Buffering", "no") // nginx: do not buffer the stream
+	w.Header().Del("Content-Length")
+	code := reqRouter.response.Code()
+	if code == 0 {
+		code = http.StatusOK
+	}
+	w.WriteHeader(code)
+	if request.Method == http.MethodHead {
+		return
+	}
+
+	s := &Stream{w: w, control: http.NewResponseController(w), done: requestScope.Context().Done(), vm: vm}
+	defer func() {
+		if s.deadline {
+			_ = s.control.SetWriteDeadline(time.Time{}) // the connection may serve another request
+		}
+	}()
+	for page := 0; ; page++ {
+		vm.FastResetPrepared(program)
+		vm.MaxEnergy = t.MaxEnergy
+		started := time.Now()
+		args, push := s.arguments(ctxObj, producer, page)
+		res := vm.ExecuteLambda(producer, args)
+		t.recordVMExecution(program, vm, res, time.Since(started))
+		if s.Closed() {
+			return // the client left; nothing to report
+		}
+		if res.K == value.Invalid {
+			t.recordRuntimeFailure(reqRouter, nil, "stream", res)
+			panic(http.ErrAbortHandler)
+		}
+		more := s.emit(res)
+		s.flush()
+		if push || !more || s.Closed() {
+			return
+		}
+	}
+}
+
+// Stream is the writer a res.stream() producer receives.
+type Stream struct {
+	w       http.ResponseWriter
+	control *http.ResponseController
+	done    <-chan struct{}
+	vm      *runtime.VM
+
+	sent     int64
+	unsent   int // bytes written since the last flush
+	closed   bool
+	deadline bool // the writer supports deadlines; checked once
+}
+
+// arguments binds the producer's parameters by name: stream/out/writer/w is the stream,
+// page/index/i the page number, anything else resolves as for a handler (ctx, req, …). push
+// reports whether the producer takes the stream, i.e. writes for itself and runs once.
+func (s *Stream) arguments(ctxObj *Context, l *value.Lambda, page int) (args []value.Value, push bool) {
+	args = ctxObj.arguments(l)
+	for i, name := range l.Params {
+		switch strings.ToLower(name) {
+		case "stream", "out", "writer", "w":
+			args[i] = value.New(s)
+			push = true
+		case "page", "index", "i":
+			args[i] = value.New(page)
+		}
+	}
+	return args, push
+}
+
+// emit writes what a producer call returned and reports whether to call it again.
+func (s *Stream) emit(res value.Value) bool {
+	switch {
+	case res.K == value.Nil || res.K == value.Invalid || res.K == value.Bool:
+		return false
+	case res.K == value.Array:
+		items := res.Array()
+		for _, item := range items {
+			if item.K == value.String && !strings.HasSuffix(item.String(), "\n") {
+				item = value.NewString(item.String() + "\n")
+			}
+			if !s.Write(item) {
+				return false
+			}
+		}
+		return len(items) > 0
+	case res.K == value.String && res.String() == "":
+		return false
+	}
+	return s.Write(res)
+}
+
+// Write sends one chunk: strings and bytes as-is, anything else as a JSON line. It blocks while
+// the client is not reading and returns false once the client is gone.
+func (s *Stream) Write(v value.Value) bool {
+	if s.Closed() {
+		return false
+	}
+	var chunk []byte
+	switch v.K {
+	case value.String:
+		chunk = []byte(v.String())
+	case value.Bytes:
+		chunk = v.Bytes()
+	default:
+		chunk = append(v.ToJSON(), '\n')
+	}
+	if len(chunk) == 0 {
+		return true
+	}
+	if s.vm != nil {
+		s.vm.Energy += uint64(streamChunkEnergy + len(chunk)/streamEnergyBytes)
+	}
+	if s.sent == 0 || s.deadline {
+		s.deadline = s.control.SetWriteDeadline(time.Now().Add(streamWriteTimeout)) == nil
+	}
+	if _, err := s.w.Write(chunk); err != nil {
+		s.closed = true
+		return false
+	}
+	s.sent += int64(len(chunk))
+	s.unsent += len(chunk)
+	if s.unsent >= streamFlushBytes {
+		s.flush()
+	}
+	return !s.closed
+}
+
+// Flush pushes everything written so far to the client now. Variadic so the reflection layer
+// does not treat it as a getter: out.flush().
+func (s *Stream) Flush(...value.Value) bool {
+	s.flush()
+	return !s.Closed()
+}
+
+func (s *Stream) flush() {
+	if s.unsent == 0 || s.closed {
+		return
+	}
+	s.unsent = 0
+	if err := s.control.Flush(); err != nil && err != http.ErrNotSupported {
+		s.closed = true
+	}
+}
+
+// Closed reports whether the client has gone away.
+func (s *Stream) Closed() bool {
+	if !s.closed {
+		select {
+		case <-s.done:
+			s.closed = true
+		default:
+		}
+	}
+	return s.closed
+}
+
+// Sent is the number of body bytes written so far.
+func (s *Stream) Sent() int64 { return s.sent }
@@ -0,0 +1,89 @@
+package work
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// res.stream() goes out chunked in both producer styles, a producer that fails mid-body truncates
+// the response instead of finishing it, and a client that hangs up stops the producer.
+func TestResponseStream(t *testing.T) {
+	tmp := t.TempDir()
+	dir := filepath.Join(tmp, "test", "localhost")
+	write := func(rel, content string) {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("router.kitwork.js", `import { router } from "kitwork";`)
+	write("push/router.kitwork.js", `import { router } from "kitwork";
+router.get((res) => res.stream((out) => {
+	for (let i = 0; i < 3; i++) { out.write({ n: i }); }
+}, "application/x-ndjson"));`)
+	write("pull/router.kitwork.js", `import { router } from "kitwork";
+router.get((ctx) => ctx.stream((page) => page < 2 ? ["a" + page, "b" + page] : null, "text/csv"));`)
+	write("broken/router.kitwork.js", `import { router } from "kitwork";
+router.get((res) => res.stream((page) => page == 0 ? "first\n" : fail("export failed")));`)
+	write("endless/router.kitwork.js", `import { router } from "kitwork";
+router.get((res) => res.stream((page) => "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef\n"));`)
+	tenant := NewTenant(tmp, "localhost")
+	if err := tenant.Run(); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(tenant.Serve))
+	defer server.Close()
+
+	get := func(path string) (*http.Response, string, error) {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return resp, string(body), err
+	}
+
+	resp, body, err := get("/push/")
+	if err != nil || body != "{\"n\":0}\n{\"n\":1}\n{\"n\":2}\n" ||
+		resp.Header.Get("Content-Type") != "application/x-ndjson" || len(resp.TransferEncoding) == 0 {
+		t.Fatalf("/push/ = %v %q %v %v", resp.Header, body, resp.TransferEncoding, err)
+	}
+	if resp, body, err = get("/pull/"); err != nil || body != "a0\nb0\na1\nb1\n" || resp.Header.Get("Content-Type") != "text/csv" {
+		t.Fatalf("/pull/ = %v %q %v", resp.Header, body, err)
+	}
+	if _, body, err = get("/broken/"); err == nil || body != "first\n" {
+		t.Fatalf("a failing producer must truncate the body, got %q, %v", body, err)
+	}
+
+	// Read a little of an endless stream, hang up, and the request must finish.
+	resp, err = http.Get(server.URL + "/endless/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line, err := bufio.NewReader(resp.Body).ReadString('\n'); err != nil || !strings.HasPrefix(line, "0123") {
+		t.Fatalf("endless stream = %q, %v", line, err)
+	}
+	resp.Body.Close()
+	server.CloseClientConnections()
+	done := make(chan struct{})
+	go func() {
+		tenant.requestWG.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the producer kept running after the client left")
+	}
+}
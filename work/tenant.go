@@ -17,12 +17,18 @@ import (
 	"github.com/kitwork/engine/database"
 	"github.com/kitwork/engine/runtime"
 	"github.com/kitwork/engine/site"
+	"github.com/kitwork/engine/utilities/accesslog"
 	"github.com/kitwork/engine/utilities/cache"
 	collectionhelper "github.com/kitwork/engine/utilities/collection"
+	"github.com/kitwork/engine/utilities/diskusage"
 	httphelper "github.com/kitwork/engine/utilities/http"
 	"github.com/kitwork/engine/utilities/persist"
 	"github.com/kitwork/engine/utilities/ratelimit"
+	"github.com/kitwork/engine/utilities/redirects"
 	"github.com/kitwork/engine/utilities/safepath"
+	"github.com/kitwork/engine/utilities/socket"
+	"github.com/kitwork/engine/utilities/trace"
+	"github.com/kitwork/engine/utilities/webhook"
 	"github.com/kitwork/engine/value"
 )
 
@@ -61,18 +67,35 @@ type Tenant struct {
 	generation  *site.Generation
 	ownsApp     bool
 
-	bytecode      *compiler.Bytecode
-	vm            *runtime.VM
-	runtimeHealth *RuntimeHealth
-	MaxEnergy     uint64
-	appBoundary   *safepath.Boundary
-	siteBoundary  *safepath.Boundary
+	bytecode       *compiler.Bytecode
+	bytecodeLoader BytecodeLoader
+	vm             *runtime.VM
+	runtimeHealth  *RuntimeHealth
+	tenantHealth   *TenantHealth     // this app's labelled share of runtimeHealth (tenant_health.go)
+	tracer         *trace.Tracer     // spans of the jobs this tenant runs (trace.go)
+	accessLog      *accesslog.Logger // the site's access log, shared by its generations (access_log.go)
+	MaxEnergy      uint64
+	UploadQuota    int64 // bytes the site's uploads may hold on disk; 0 = DefaultUploadQuota
+	appBoundary    *safepath.Boundary
+	siteBoundary   *safepath.Boundary
 
 	requestMu      sync.Mutex
 	requestWG      sync.WaitGroup
 	requestClosing bool
 	closeOnce      sync.Once
 
+	uploads *diskusage.Usage // the site's upload bytes on disk, charged against UploadQuota (upload.go)
+
+	// WebSocket connections of this generation (router.socket), drained by Close.
+	socketMu sync.Mutex
+	sockets  *socket.Hub
+
+	// Upstream pools of this generation's router.proxy({ upstreams }) routes; Close stops their
+	// health checks. See router_proxy.go.
+	proxyMu     sync.Mutex
+	proxyRoutes []*proxyRoute
+	proxyClosed bool
+
 	// App-only compatibility tenants have no site generation.
 	capabilitiesMu    sync.Mutex
 	capabilitiesCache *capabilities.InstanceCache
@@ -105,6 +128,17 @@ type Tenant struct {
 	rateLimitRules []rateRule
 
 	meta value.Value
+
+	// Feature flags: this generation's flags.kitwork.json and the database definitions (flags.go).
+	flagsOnce sync.Once
+	flags     *flagRuntime
+
+	// redirects is this generation's compiled _redirects file (redirects.go).
+	redirects *redirects.Table
+
+	// The app's store of accepted webhook delivery ids, opened on first use (webhook.go).
+	webhookOnce sync.Once
+	webhookSeen webhook.SeenStore
 }
 
 // CapabilitiesCache owns LifetimeSite instances for this tenant generation.
@@ -198,6 +232,25 @@ func (t *Tenant) ActivateGeneration() error {
 	return err
 }
 
+// StageGeneration publishes this tenant's prepared revision as a canary: it
+// serves the requests the host routes to it while another stays current.
+func (t *Tenant) StageGeneration() error {
+	if t == nil || t.siteRuntime == nil || t.generation == nil {
+		return nil
+	}
+	return t.siteRuntime.StageGeneration(t.generation)
+}
+
+// SwitchGeneration makes this tenant's published revision current again,
+// without recompiling: a canary promotion or a rollback.
+func (t *Tenant) SwitchGeneration() error {
+	if t == nil || t.siteRuntime == nil || t.generation == nil {
+		return nil
+	}
+	_, err := t.siteRuntime.SwitchGeneration(t.generation)
+	return err
+}
+
 func (t *Tenant) generationLease() (*site.Lease, error) {
 	if t == nil || t.generation == nil {
 		return nil, nil
@@ -207,9 +260,11 @@ func (t *Tenant) generationLease() (*site.Lease, error) {
 		if err := t.ActivateGeneration(); err != nil {
 			return nil, err
 		}
-	} else if current != t.generation {
+	} else if current != t.generation && !t.generation.Published() {
+		// Besides the current revision only published ones serve: a staged canary or a
+		// predecessor the host keeps warm for rollback, each until it is retired.
 		return nil, fmt.Errorf(
-			"site generation %d is no longer current",
+			"site generation %d is not published",
 			t.generation.Version(),
 		)
 	}
@@ -352,6 +407,12 @@ func (t *Tenant) Run() error {
 	} else {
 		t.env = environment
 	}
+	if err := t.loadFlags(); err != nil {
+		return err
+	}
+	if err := t.loadRedirects(); err != nil {
+		return err
+	}
 
 	kitworkFunc := value.NewFunc(func(args ...value.Value) value.Value {
 		return value.New(&KitWork{tenant: t, vm: t.vm})
@@ -368,10 +429,12 @@ func (t *Tenant) Run() error {
 		}
 		t.respCache = t.generation.ResponseCache()
 		t.persistStore = t.siteRuntime.PersistStore()
+		t.uploads = t.siteRuntime.Uploads()
 		t.limiter = t.siteRuntime.Limiter()
 	} else {
 		t.respCache = cache.NewStore(1000)
 		t.persistStore = persist.New(t.resolve(".persist"))
+		t.uploads = diskusage.Open(t.resolve(), t.resolve(".uploads"))
 		t.limiter = ratelimit.New()
 	}
 
@@ -398,10 +461,15 @@ func (t *Tenant) Run() error {
 		}
 	}
 
+	// Labelled only once the tenant's routes are known to exist: a request naming an unknown host
+	// never takes one of the bounded metrics labels.
+	t.tenantHealth = t.runtimeHealth.Tenant(t.appID())
+
 	// App-level background work, loaded once per identity (Domain == "" is the app tenant, not one of
 	// its domains). Both must register before any request arrives: they run on their own clock, so
 	// the lazy compile a route can afford would be too late.
 	if t.entity.Domain == "" {
+		t.registerMaintenanceSites()
 		t.LoadCronFiles()
 		t.LoadQueueFiles()
 	}
@@ -567,6 +635,17 @@ func (t *Tenant) SSEBroker() *SSEBroker {
 	return sseBrokerFor(t.brokerKey())
 }
 
+// SSEConnections counts the site's open live streams. It never creates a broker.
+func (t *Tenant) SSEConnections() int {
+	if t == nil || t.siteRuntime == nil {
+		return 0
+	}
+	if broker := t.siteRuntime.SSEBroker(); broker != nil {
+		return broker.ClientCount()
+	}
+	return 0
+}
+
 func (t *Tenant) brokerKey() string {
 	if t != nil && t.entity != nil && (t.entity.Identity != "" || t.entity.Domain != "") {
 		return t.entity.Identity + "/" + t.entity.Domain
@@ -590,7 +669,15 @@ func (t *Tenant) Close() {
 		} else if ownsCurrentSite {
 			releaseSSEBroker(t.brokerKey())
 		}
+		// Sockets run this generation's handlers, so they never outlive it: a replaced generation
+		// tells clients to reconnect (1012), a site going away says so (1001).
+		if ownsCurrentSite {
+			t.closeSockets(socket.CloseGoingAway, "site shutting down")
+		} else {
+			t.closeSockets(socket.CloseRestart, "site updated")
+		}
 		t.requestWG.Wait()
+		t.closeProxyRoutes()
 		if t.generation != nil {
 			t.generation.Retire()
 		} else if t.respCache != nil {
@@ -638,6 +725,14 @@ func (t *Tenant) SetRuntimeHealth(health *RuntimeHealth) {
 	}
 }
 
+// Health is this tenant's labelled aggregate; nil when no RuntimeHealth is attached.
+func (t *Tenant) Health() *TenantHealth {
+	if t == nil {
+		return nil
+	}
+	return t.tenantHealth
+}
+
 func (t *Tenant) recordVMExecution(
 	program *runtime.Program,
 	vm *runtime.VM,
@@ -645,14 +740,37 @@ func (t *Tenant) recordVMExecution(
 	elapsed ...time.Duration,
 ) {
 	if t != nil && t.runtimeHealth != nil && vm != nil {
-		t.runtimeHealth.Record(program, vm.Stats(), result)
+		stats := vm.Stats()
+		t.runtimeHealth.Record(program, stats, result)
+		t.tenantHealth.Record(stats, result)
+		if t.accessLog != nil {
+			noteAccessEnergy(vm, stats.Energy)
+		}
 		if len(elapsed) > 0 {
 			t.runtimeHealth.RecordVMLatency(elapsed[0])
 		}
 	}
 }
 
+// BytecodeLoader supplies programs without compiling source — the packaged
+// deployment mode (see bundle.Loader). When set it is authoritative: a file the
+// loader does not know is an error, never a silent compile from disk.
+type BytecodeLoader interface {
+	CompileFile(paths ...string) (*compiler.Bytecode, error)
+}
+
+// SetBytecodeLoader switches this tenant to precompiled programs. Call it
+// before Run; nil keeps source compilation.
+func (t *Tenant) SetBytecodeLoader(loader BytecodeLoader) {
+	if t != nil {
+		t.bytecodeLoader = loader
+	}
+}
+
 func (t *Tenant) compileFile(paths ...string) (*compiler.Bytecode, error) {
+	if t != nil && t.bytecodeLoader != nil {
+		return t.bytecodeLoader.CompileFile(paths...)
+	}
 	if t != nil && t.generation != nil {
 		if bytecodeCache := t.generation.BytecodeCache(); bytecodeCache != nil {
 			return bytecodeCache.CompileFile(paths...)
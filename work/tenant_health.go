@@ -0,0 +1,193 @@
+package work
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/kitwork/engine/runtime"
+	"github.com/kitwork/engine/value"
+)
+
+// OtherTenant is the label shared by every tenant past the RuntimeHealth tenant limit. A host with
+// thousands of apps still exports a bounded number of series; the overflow is visible as one.
+const OtherTenant = "_other"
+
+// DefaultTenantLimit is how many tenants RuntimeHealth breaks out by label before the rest share
+// OtherTenant.
+const DefaultTenantLimit = 200
+
+// TenantHealth is one tenant's share of RuntimeHealth: its requests, VM work and cron lag. Tenants
+// are labelled by app id (identity, or domain for a site without one), so every site of an app
+// counts together.
+type TenantHealth struct {
+	label string
+
+	requests            atomic.Uint64
+	successfulRequests  atomic.Uint64
+	clientErrorRequests atomic.Uint64
+	serverErrorRequests atomic.Uint64
+	executions          atomic.Uint64
+	successes           atomic.Uint64
+	failures            atomic.Uint64
+	instructions        atomic.Uint64
+	energy              atomic.Uint64
+	cronRuns            atomic.Uint64
+	cronLag             atomic.Uint64
+	maxCronLag          atomic.Uint64
+
+	requestLatency latencyHistogram
+}
+
+// TenantHealthSnapshot is one tenant's point-in-time report.
+type TenantHealthSnapshot struct {
+	Tenant                string          `json:"tenant"`
+	Requests              uint64          `json:"requests"`
+	Successful            uint64          `json:"successful"`
+	ClientErrors          uint64          `json:"client_errors"`
+	ServerErrors          uint64          `json:"server_errors"`
+	RequestLatency        LatencySnapshot `json:"request_latency"`
+	Executions            uint64          `json:"executions"`
+	Successes             uint64          `json:"successes"`
+	Failures              uint64          `json:"failures"`
+	Instructions          uint64          `json:"instructions"`
+	Energy                uint64          `json:"energy"`
+	CronRuns              uint64          `json:"cron_runs"`
+	CronLagNanoseconds    uint64          `json:"cron_lag_nanoseconds"` // the last run's delay past its slot
+	MaxCronLagNanoseconds uint64          `json:"max_cron_lag_nanoseconds"`
+}
+
+// SetTenantLimit bounds the tenant labels. Call it during host boot; tenants already labelled keep
+// their label.
+func (h *RuntimeHealth) SetTenantLimit(limit int) {
+	if h == nil || limit < 1 {
+		return
+	}
+	h.mu.Lock()
+	h.tenantLimit = limit
+	h.mu.Unlock()
+}
+
+// Tenant returns the aggregate for label, creating it while there is room; past the limit every new
+// label shares the OtherTenant aggregate.
+func (h *RuntimeHealth) Tenant(label string) *TenantHealth {
+	if h == nil {
+		return nil
+	}
+	h.mu.RLock()
+	current := h.tenants[label]
+	h.mu.RUnlock()
+	if current != nil {
+		return current
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if current = h.tenants[label]; current != nil {
+		return current
+	}
+	limit := h.tenantLimit
+	if limit < 1 {
+		limit = DefaultTenantLimit
+	}
+	if len(h.tenants) >= limit || label == OtherTenant {
+		label = OtherTenant
+		if current = h.tenants[label]; current != nil {
+			return current
+		}
+	}
+	current = &TenantHealth{label: label}
+	h.tenants[label] = current
+	return current
+}
+
+// Tenants reports every labelled tenant, sorted by label.
+func (h *RuntimeHealth) Tenants() []TenantHealthSnapshot {
+	if h == nil {
+		return nil
+	}
+	h.mu.RLock()
+	tenants := make([]*TenantHealth, 0, len(h.tenants))
+	for _, tenant := range h.tenants {
+		tenants = append(tenants, tenant)
+	}
+	h.mu.RUnlock()
+	sort.Slice(tenants, func(i, j int) bool { return tenants[i].label < tenants[j].label })
+	out := make([]TenantHealthSnapshot, len(tenants))
+	for i, tenant := range tenants {
+		out[i] = tenant.Snapshot()
+	}
+	return out
+}
+
+// Label is the tenant label this aggregate is exported under.
+func (h *TenantHealth) Label() string {
+	if h == nil {
+		return ""
+	}
+	return h.label
+}
+
+func (h *TenantHealth) RequestCompleted(status int, elapsed time.Duration) {
+	if h == nil {
+		return
+	}
+	h.requests.Add(1)
+	switch {
+	case status >= 500:
+		h.serverErrorRequests.Add(1)
+	case status >= 400:
+		h.clientErrorRequests.Add(1)
+	default:
+		h.successfulRequests.Add(1)
+	}
+	h.requestLatency.Record(elapsed)
+}
+
+func (h *TenantHealth) Record(stats runtime.VMStats, result value.Value) {
+	if h == nil {
+		return
+	}
+	h.executions.Add(1)
+	h.instructions.Add(stats.Instructions)
+	h.energy.Add(stats.Energy)
+	if result.K == value.Invalid {
+		h.failures.Add(1)
+	} else {
+		h.successes.Add(1)
+	}
+}
+
+// RecordCronLag notes how late a cron run started after its slot.
+func (h *TenantHealth) RecordCronLag(lag time.Duration) {
+	if h == nil {
+		return
+	}
+	if lag < 0 {
+		lag = 0
+	}
+	h.cronRuns.Add(1)
+	h.cronLag.Store(uint64(lag))
+	updateAtomicMax(&h.maxCronLag, uint64(lag))
+}
+
+func (h *TenantHealth) Snapshot() TenantHealthSnapshot {
+	if h == nil {
+		return TenantHealthSnapshot{}
+	}
+	return TenantHealthSnapshot{
+		Tenant:                h.label,
+		Requests:              h.requests.Load(),
+		Successful:            h.successfulRequests.Load(),
+		ClientErrors:          h.clientErrorRequests.Load(),
+		ServerErrors:          h.serverErrorRequests.Load(),
+		RequestLatency:        h.requestLatency.Snapshot(),
+		Executions:            h.executions.Load(),
+		Successes:             h.successes.Load(),
+		Failures:              h.failures.Load(),
+		Instructions:          h.instructions.Load(),
+		Energy:                h.energy.Load(),
+		CronRuns:              h.cronRuns.Load(),
+		CronLagNanoseconds:    h.cronLag.Load(),
+		MaxCronLagNanoseconds: h.maxCronLag.Load(),
+	}
+}
@@ -0,0 +1,20 @@
+package work
+
+import (
+	textcap "github.com/kitwork/engine/capabilities/text"
+)
+
+type Text = textcap.TextAdapter
+
+// Text is `import { text } from "kitwork"` — slugs, accent folding, Vietnamese collation, casing and
+// amounts in words. Stateless, so one adapter serves the whole app.
+func (w *KitWork) Text() *Text {
+	if w != nil {
+		if val := w.Capability("text"); val.V != nil {
+			if adapter, ok := val.V.(*textcap.TextAdapter); ok {
+				return adapter
+			}
+		}
+	}
+	return textcap.NewTextAdapter(nil)
+}
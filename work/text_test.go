@@ -0,0 +1,51 @@
+package work
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The text capability from a handler: slugs, amounts in words, and a host comparator handed to
+// Array.sort.
+func TestTextCapabilityInScripts(t *testing.T) {
+	tmp := t.TempDir()
+	dir := filepath.Join(tmp, "test", "localhost")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	router := `import { router, text } from "kitwork";
+router.get((ctx) => {
+	const names = ["Đào", "Dương", "Anh", "Ân", "ăn"];
+	names.sort(text.compare);
+	return ctx.json({
+		slug: text.slugify("Bánh mì Hội An"),
+		amount: text.sentenceCase(text.toWords(2021000, "vi", "đồng chẵn")),
+		names: names,
+		same: text.equals("Hà Nội", "HA NOI"),
+	});
+});`
+	if err := os.WriteFile(filepath.Join(dir, "router.kitwork.js"), []byte(router), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tenant := NewTenant(tmp, "localhost")
+	if err := tenant.Run(); err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	tenant.Serve(rec, httptest.NewRequest(http.MethodGet, "http://localhost/", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		`"slug":"banh-mi-hoi-an"`,
+		`"amount":"Hai triệu không trăm hai mươi mốt nghìn đồng chẵn"`,
+		`"names":["Anh","ăn","Ân","Dương","Đào"]`,
+		`"same":true`,
+	} {
+		if rec.Code != http.StatusOK || !strings.Contains(body, want) {
+			t.Fatalf("/ = %d %s\nmissing %s", rec.Code, body, want)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package work
+
+import (
+	timecap "github.com/kitwork/engine/capabilities/datetime"
+)
+
+type Time = timecap.TimeAdapter
+
+// Time is `import { time } from "kitwork"` — zoned date-times, durations, business days and the
+// lunar calendar. Stateless, so one adapter serves the whole app.
+func (w *KitWork) Time() *Time {
+	if w != nil {
+		if val := w.Capability("time"); val.V != nil {
+			if adapter, ok := val.V.(*timecap.TimeAdapter); ok {
+				return adapter
+			}
+		}
+	}
+	return timecap.NewTimeAdapter(nil)
+}
@@ -0,0 +1,119 @@
+package work
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kitwork/engine/value"
+)
+
+// The time capability end to end: zoned construction and formatting, calendar math, business days
+// over a lunar holiday list, Duration values, and zoned values rendered straight from a template.
+func TestTimeCapabilityInScriptsAndTemplates(t *testing.T) {
+	tmp := t.TempDir()
+	dir := filepath.Join(tmp, "test", "localhost")
+	write := func(rel, content string) {
+		p := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("router.kitwork.js", `import { router } from "kitwork";`)
+	write("index.kitwork.html", `<!doctype html><body>{{ @page }}</body>`)
+	write("api/router.kitwork.js", `import { router, time } from "kitwork";
+router.get((ctx) => {
+	const open = time.at("2025-01-27 08:00", "vn");
+	const tet = time.fromLunar({ day: 1, month: 1, year: 2025 });
+	const tetHolidays = time.calendar({ holidays: ["lunar:12-29", "lunar:12-30", "lunar:01-01", "lunar:01-02", "lunar:01-03"] });
+	return ctx.json({
+		label: open.format("EEEE dd/MM/yyyy HH:mm", "vi"),
+		utc: open.withZone("UTC").format("HH:mm XXX"),
+		tet: tet.date,
+		yearName: tet.lunar.yearName,
+		monthEnd: open.endOf("month").format("dd/MM HH:mm:ss"),
+		nextMonth: time.at("2025-01-31", "vn").add(1, "month").date,
+		later: open.add(time.duration(90, "minutes")).clock,
+		shipBy: open.addBusinessDays(2, tetHolidays).date,
+		working: time.businessDays("2025-01-27", "2025-02-03", tetHolidays),
+		gap: tet.diff(open, "days"),
+		elapsed: tet.diff(open),
+	});
+});`)
+	write("promo/page.kitwork.html", `<p>{{ when.datetime }} · {{ when.lunar }} · {{ when.lunar.yearName }} · {{ when }}</p>`)
+	write("promo/router.kitwork.js", `import { router, time } from "kitwork";
+router.get((ctx) => ctx.view({ when: time.at("2024-02-10 09:30", "Asia/Ho_Chi_Minh") }));`)
+
+	tenant := NewTenant(tmp, "localhost")
+	if err := tenant.Run(); err != nil {
+		t.Fatal(err)
+	}
+	get := func(path string) (int, string) {
+		rec := httptest.NewRecorder()
+		tenant.Serve(rec, httptest.NewRequest(http.MethodGet, "http://localhost"+path, nil))
+		return rec.Code, rec.Body.String()
+	}
+
+	code, body := get("/api")
+	for _, want := range []string{
+		`"label":"Thứ Hai 27/01/2025 08:00"`,
+		`"utc":"01:00 Z"`,
+		`"tet":"29/01/2025"`,
+		`"yearName":"Ất Tỵ"`,
+		`"monthEnd":"31/01 23:59:59"`,
+		`"nextMonth":"28/02/2025"`,
+		`"later":"09:30"`,
+		`"shipBy":"04/02/2025"`, // 28/01 (lunar 29/12) … 31/01 (lunar 03/01) are Tết; 01–02/02 a weekend
+		`"working":1`,
+		`"gap":1`,
+		`"elapsed":"40h0m0s"`,
+	} {
+		if code != http.StatusOK || !strings.Contains(body, want) {
+			t.Fatalf("/api = %d %s\nmissing %s", code, body, want)
+		}
+	}
+
+	code, body = get("/promo")
+	if want := "<p>10/02/2024 09:30 · 01/01/2024 · Giáp Thìn · 2024-02-10T09:30:00+07:00"; code != http.StatusOK || !strings.Contains(body, want) {
+		t.Fatalf("/promo = %d %s", code, body)
+	}
+}
+
+// A cron's calendar fields are read on its Timezone clock.
+func TestCronTimezoneDrivesSlots(t *testing.T) {
+	builder := newCronBuilder(nil)
+	builder.Daily(value.New("08:00")).Timezone(value.New("vn"))
+	job := builder.job
+	if job.Timezone != "Asia/Ho_Chi_Minh" {
+		t.Fatalf("timezone = %q", job.Timezone)
+	}
+	// 01:00 UTC is 08:00 in Vietnam.
+	if slot, due := jobSlot(job, time.Date(2025, 1, 27, 1, 0, 30, 0, time.UTC)); !due || !slot.Equal(time.Date(2025, 1, 27, 1, 0, 0, 0, time.UTC)) {
+		t.Fatalf("08:00 ICT slot = %v %v", slot, due)
+	}
+	if _, due := jobSlot(job, time.Date(2025, 1, 27, 8, 0, 0, 0, time.UTC)); due {
+		t.Fatal("08:00 UTC must not be due for a Vietnam 08:00 cron")
+	}
+
+	daily := &CronJob{Expression: "@daily", Timezone: "Asia/Ho_Chi_Minh"}
+	if slot, _ := jobSlot(daily, time.Date(2025, 1, 27, 20, 0, 0, 0, time.UTC)); !slot.Equal(time.Date(2025, 1, 27, 17, 0, 0, 0, time.UTC)) {
+		t.Fatalf("@daily slot = %v, want Vietnam midnight", slot)
+	}
+	// Half-hour zones too: the week starts at Monday midnight in Kolkata, 18:30 UTC on Sunday.
+	weekly := &CronJob{Expression: "@weekly", Timezone: "Asia/Kolkata"}
+	if slot, _ := jobSlot(weekly, time.Date(2025, 1, 29, 10, 15, 0, 0, time.UTC)); !slot.Equal(time.Date(2025, 1, 26, 18, 30, 0, 0, time.UTC)) {
+		t.Fatalf("@weekly slot = %v, want Kolkata Monday midnight", slot)
+	}
+
+	builder.Timezone(value.New("Mars/Olympus"))
+	if job.Timezone != "Asia/Ho_Chi_Minh" {
+		t.Fatalf("an unknown zone replaced the timezone: %q", job.Timezone)
+	}
+}
@@ -0,0 +1,48 @@
+package work
+
+import (
+	"context"
+
+	"github.com/kitwork/engine/runtime"
+	"github.com/kitwork/engine/utilities/trace"
+)
+
+// Tracing: core.Engine opens the server span of each request (continuing an incoming traceparent)
+// and the request context carries it into the VM (vm.Context). Everything below nests under it —
+// guards, handlers, the render, database queries, outbound fetches — and jobs carry it across
+// the queue in their stored row. Cron runs and queue jobs without a parent start their own trace.
+
+// SetTracer gives this tenant the host's tracer, for the traces its jobs start. Call it before Run;
+// nil leaves jobs untraced (request spans still follow the request context).
+func (t *Tenant) SetTracer(tracer *trace.Tracer) {
+	if t != nil {
+		t.tracer = tracer
+	}
+}
+
+// traceVM opens a child of the span the VM's context carries and makes it the VM's context, so
+// spans opened by the code it runs nest under it; end restores the previous context. Outside a
+// trace it is a no-op.
+func traceVM(vm *runtime.VM, name string, kind trace.Kind) (span *trace.Span, end func()) {
+	if vm == nil {
+		return nil, func() {}
+	}
+	parent := vm.Context
+	ctx, span := trace.Start(parent, name, kind)
+	if span == nil {
+		return nil, func() {}
+	}
+	vm.Context = ctx
+	return span, func() {
+		span.End()
+		vm.Context = parent
+	}
+}
+
+// vmContext is the context the VM runs under, never nil.
+func vmContext(vm *runtime.VM) context.Context {
+	if vm == nil || vm.Context == nil {
+		return context.Background()
+	}
+	return vm.Context
+}
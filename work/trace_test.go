@@ -0,0 +1,209 @@
+package work
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kitwork/engine/utilities/trace"
+	"github.com/kitwork/engine/value"
+)
+
+type collectedSpan struct {
+	TraceID, SpanID, ParentSpanID, Name string
+	Attributes                          map[string]string
+	Failed                              bool
+}
+
+// newSpanCollector stands in for an OTLP/HTTP collector and returns a tracer exporting to it, plus
+// a function that flushes the tracer and returns every span received so far.
+func newSpanCollector(t *testing.T) (*trace.Tracer, func() []collectedSpan) {
+	t.Helper()
+	var mu sync.Mutex
+	var spans []collectedSpan
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var doc struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []struct {
+						TraceID, SpanID, ParentSpanID, Name string
+						Attributes                          []struct {
+							Key   string
+							Value map[string]any
+						}
+						Status struct{ Code int }
+					}
+				}
+			}
+		}
+		if err := json.NewDecoder(r.Body).Decode(&doc); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, resource := range doc.ResourceSpans {
+			for _, scope := range resource.ScopeSpans {
+				for _, span := range scope.Spans {
+					out := collectedSpan{span.TraceID, span.SpanID, span.ParentSpanID, span.Name, map[string]string{}, span.Status.Code == 2}
+					for _, attr := range span.Attributes {
+						for _, v := range attr.Value {
+							out.Attributes[attr.Key] = toString(v)
+						}
+					}
+					spans = append(spans, out)
+				}
+			}
+		}
+	}))
+	t.Cleanup(collector.Close)
+	tracer := trace.NewTracer(trace.NewHTTPExporter(collector.URL, nil), trace.Options{FlushInterval: time.Hour})
+	t.Cleanup(func() { tracer.Close() })
+	return tracer, func() []collectedSpan {
+		if err := tracer.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]collectedSpan(nil), spans...)
+	}
+}
+
+func toString(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	encoded, _ := json.Marshal(v)
+	return string(encoded)
+}
+
+func spanNamed(spans []collectedSpan, name string) (collectedSpan, bool) {
+	for _, span := range spans {
+		if span.Name == name {
+			return span, true
+		}
+	}
+	return collectedSpan{}, false
+}
+
+// One request's spans nest under the server span: the handler's VM execution, and inside it the
+// query and the outbound fetch, which carries the trace to the upstream.
+func TestTraceRequestSpans(t *testing.T) {
+	savedLocal := AllowLocal
+	AllowLocal = true
+	defer func() { AllowLocal = savedLocal }()
+
+	var upstreamParent string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamParent = r.Header.Get("Traceparent")
+		w.Write([]byte("ok"))
+	}))
+	defer upstream.Close()
+
+	tmp := t.TempDir()
+	dir := filepath.Join(tmp, "test", "localhost")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	router := `import { router, sqlite } from "kitwork";
+router.get((ctx) => {
+    sqlite.exec("CREATE TABLE IF NOT EXISTS visits (at TEXT)");
+    const res = fetch("` + upstream.URL + `/ping?key=secret");
+    return ctx.text(res.text());
+});`
+	if err := os.WriteFile(filepath.Join(dir, "router.kitwork.js"), []byte(router), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tenant := NewTenant(tmp, "localhost")
+	if err := tenant.Run(); err != nil {
+		t.Fatal(err)
+	}
+	defer tenant.Close()
+
+	tracer, collected := newSpanCollector(t)
+	incoming, _ := trace.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx, server := tracer.Begin(context.Background(), "GET", trace.KindServer, incoming, "localhost")
+	req := httptest.NewRequest(http.MethodGet, "http://localhost/", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	tenant.Serve(rec, req)
+	server.End()
+	if rec.Body.String() != "ok" {
+		t.Fatalf("body %q", rec.Body.String())
+	}
+
+	spans := collected()
+	execute, ok := spanNamed(spans, "vm.execute")
+	if !ok || execute.ParentSpanID != server.Context().SpanID.String() || execute.TraceID != incoming.TraceID.String() {
+		t.Fatalf("vm.execute span: %+v in %+v", execute, spans)
+	}
+	query, ok := spanNamed(spans, "db.query")
+	if !ok || query.ParentSpanID != execute.SpanID || query.Attributes["db.system"] != "sqlite" ||
+		query.Attributes["db.statement"] != "CREATE TABLE IF NOT EXISTS visits (at TEXT)" {
+		t.Fatalf("db.query span: %+v in %+v", query, spans)
+	}
+	fetch, ok := spanNamed(spans, "fetch GET")
+	if !ok || fetch.ParentSpanID != execute.SpanID || fetch.Attributes["http.response.status_code"] != "200" ||
+		fetch.Attributes["url.full"] != upstream.URL+"/ping" {
+		t.Fatalf("fetch span: %+v", fetch)
+	}
+	if want := "00-" + incoming.TraceID.String() + "-" + fetch.SpanID + "-01"; upstreamParent != want {
+		t.Fatalf("upstream traceparent %q, want %q", upstreamParent, want)
+	}
+	if server, ok := spanNamed(spans, "GET"); !ok || server.Attributes["http.route"] != "/" {
+		t.Fatalf("server span: %+v", server)
+	}
+}
+
+// A queued message carries its dispatcher's trace on the row: the run is a consumer span in the
+// same trace, a child of the publish span.
+func TestTraceQueuePropagation(t *testing.T) {
+	tmp := t.TempDir()
+	site := filepath.Join(tmp, "acme", "localhost")
+	queueDir := filepath.Join(tmp, "acme", "_queue")
+	for _, dir := range []string{site, queueDir} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.WriteFile(filepath.Join(site, "router.kitwork.js"),
+		[]byte(`import { router } from "kitwork"; router.get((ctx) => ctx.text("ok"));`), 0o644)
+	os.WriteFile(filepath.Join(queueDir, "mail.kitwork.js"),
+		[]byte(`import { queue } from "kitwork"; queue.handle((job) => { job.log("sent " + job.payload.to); });`), 0o644)
+
+	tracer, collected := newSpanCollector(t)
+	tenant := NewAppTenant(tmp, "acme")
+	tenant.SetTracer(tracer)
+	if err := tenant.Run(); err != nil {
+		t.Fatal(err)
+	}
+	defer tenant.Close()
+
+	ctx, root := tracer.Begin(context.Background(), "POST", trace.KindServer, trace.SpanContext{}, "localhost")
+	result := (&Queue{tenant: tenant, ctx: ctx}).Dispatch(value.New("mail"), value.New(map[string]any{"to": "a@b.c"}))
+	root.End()
+	if !result.Get("queued").IsTrue() {
+		t.Fatalf("dispatch: %v", result.Get("error").Text())
+	}
+	if !waitFor(10*time.Second, func() bool {
+		return countQueueRows(t, tenant, `SELECT COUNT(*) FROM queue_jobs WHERE status='completed'`) == 1
+	}) {
+		t.Fatal("message never completed")
+	}
+	tenant.StopQueueWorker()
+
+	spans := collected()
+	publish, ok := spanNamed(spans, "queue mail publish")
+	if !ok || publish.ParentSpanID != root.Context().SpanID.String() {
+		t.Fatalf("publish span: %+v in %+v", publish, spans)
+	}
+	run, ok := spanNamed(spans, "queue mail")
+	if !ok || run.TraceID != publish.TraceID || run.ParentSpanID != publish.SpanID || run.Attributes["kitwork.job.attempt"] != "1" {
+		t.Fatalf("consumer span: %+v", run)
+	}
+}
@@ -0,0 +1,434 @@
+package work
+
+// Request body limits and streamed uploads: .body({ maxSize, files }) on a route.
+//
+//	router.post(save).body({
+//		maxSize: "20MB",
+//		files: { avatar: { maxSize: "2MB", types: ["image/*"] }, docs: { types: ["application/pdf"], maxCount: 5 } },
+//	})
+//
+// The limit is enforced in two places. Before a VM is leased, a Content-Length over maxSize is
+// refused with 413 and every other body is wrapped so reading past maxSize fails. Then, once the
+// route's guards have passed, a multipart body is parsed as a STREAM: each file part goes straight
+// to a temp file under the tenant's .uploads/ (never buffered in RAM), its real type is sniffed
+// from the first bytes rather than taken from the client, and its size counts against the file's
+// own cap and the site's UploadQuota. Temp files the handler did not save are removed when the
+// request ends.
+//
+// The quota is on what the site's uploads hold on disk: files being received plus every file a
+// handler kept with .save(). The count belongs to the site, not a generation, so a hot reload
+// does not reset it, and it is rebuilt from .uploads/kept at start (utilities/diskusage).
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	requestscope "github.com/kitwork/engine/request"
+	"github.com/kitwork/engine/utilities/diskusage"
+	"github.com/kitwork/engine/value"
+)
+
+const (
+	defaultBodySize = 32 << 20 // .body() without maxSize
+	maxFieldsSize   = 1 << 20  // all non-file fields of one multipart body together
+	sniffLength     = 512      // what http.DetectContentType looks at
+)
+
+// bodySpec is what .body() declared.
+type bodySpec struct {
+	maxSize int64
+	files   map[string]*fileSpec // nil: any file field, capped by maxSize alone
+}
+
+type fileSpec struct {
+	maxSize  int64
+	types    []string // "image/*", "application/pdf"; empty accepts any type
+	maxCount int      // files per field, default 1
+}
+
+// Body declares the route's body limits. A bare size is shorthand for { maxSize }:
+// .body("5MB"). With files declared, any other file field is refused. A JSON Schema object
+// (one with type or properties) validates the body instead — see bodySchema in validate.go — and
+// { maxSize, schema } declares both.
+func (m *FolderMethod) Body(v value.Value) *FolderMethod {
+	if isSchema(v) {
+		m.bodySchema = compileSchema(v)
+		if m.body == nil { // validation reads the whole body: it is held to the default cap
+			m.body = &bodySpec{maxSize: defaultBodySize}
+		}
+		return m
+	}
+	spec := &bodySpec{maxSize: defaultBodySize}
+	if !v.IsMap() {
+		if n := parseSize(v); n > 0 {
+			spec.maxSize = n
+		}
+		m.body = spec
+		return m
+	}
+	mp := v.Map()
+	if definition := mp["schema"]; definition.IsMap() {
+		m.bodySchema = compileSchema(definition)
+	}
+	if n := parseSize(mp["maxSize"]); n > 0 {
+		spec.maxSize = n
+	}
+	if files := mp["files"]; files.IsMap() {
+		spec.files = map[string]*fileSpec{}
+		for field, rule := range files.Map() {
+			fs := &fileSpec{maxSize: spec.maxSize, maxCount: 1}
+			if rule.IsMap() {
+				r := rule.Map()
+				if n := parseSize(r["maxSize"]); n > 0 {
+					fs.maxSize = n
+				}
+				fs.types = corsList(r["types"])
+				if n := int(r["maxCount"].N); n > 0 {
+					fs.maxCount = n
+				}
+			}
+			spec.files[field] = fs
+		}
+	}
+	m.body = spec
+	return m
+}
+
+// limitBody applies maxSize ahead of any VM work. It reports false when the declared length is
+// already too big; the caller answers 413.
+func limitBody(spec *bodySpec, w http.ResponseWriter, r *http.Request) bool {
+	if r.ContentLength > spec.maxSize {
+		return false
+	}
+	if r.Body != nil && r.Body != http.NoBody {
+		r.Body = http.MaxBytesReader(w, r.Body, spec.maxSize)
+	}
+	return true
+}
+
+// bodyError is a refused upload and the status that says why.
+type bodyError struct {
+	status int
+	msg    string
+}
+
+func (e *bodyError) Error() string { return e.msg }
+
+func refuse(status int, format string, args ...any) *bodyError {
+	return &bodyError{status: status, msg: fmt.Sprintf(format, args...)}
+}
+
+// Upload is one received file, already on disk in the tenant's temp area.
+type Upload struct {
+	tenant   *Tenant
+	field    string
+	name     string
+	size     int64
+	mimeType string
+	path     string // the temp file, or where Save moved it
+	moved    bool
+}
+
+// receiveBody streams a multipart body to disk under the route's rules. Other bodies are left for
+// the handler to read (already capped by limitBody).
+func (t *Tenant) receiveBody(spec *bodySpec, reqRouter *Router, requestScope *requestscope.Scope) *bodyError {
+	r := reqRouter.request
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" || r.MultipartForm != nil {
+		return nil
+	}
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return refuse(http.StatusBadRequest, "malformed multipart body")
+	}
+	dir := t.resolve(".uploads")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return refuse(http.StatusInternalServerError, "upload storage unavailable")
+	}
+
+	var reserved int64
+	recounted := false
+	uploads := map[string][]*Upload{}
+	cleanup := func() {
+		for _, list := range uploads {
+			for _, u := range list {
+				if !u.moved {
+					os.Remove(u.path)
+				}
+			}
+		}
+		t.uploads.Release(reserved)
+	}
+	if !requestScope.AddCleanup(cleanup) {
+		defer cleanup()
+	}
+
+	quota := t.UploadQuota
+	if quota <= 0 {
+		quota = DefaultUploadQuota
+	}
+	fields := url.Values{}
+	fieldBudget := int64(maxFieldsSize)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return readError(err)
+		}
+		field := part.FormName()
+		if part.FileName() == "" {
+			data, err := io.ReadAll(io.LimitReader(part, fieldBudget+1))
+			if err != nil {
+				return readError(err)
+			}
+			if fieldBudget -= int64(len(data)); fieldBudget < 0 {
+				return refuse(http.StatusRequestEntityTooLarge, "form fields too large")
+			}
+			fields.Add(field, string(data))
+			continue
+		}
+
+		rule := &fileSpec{maxSize: spec.maxSize, maxCount: 1 << 30}
+		if spec.files != nil {
+			if rule = spec.files[field]; rule == nil {
+				return refuse(http.StatusBadRequest, "unexpected file field %q", field)
+			}
+		}
+		if len(uploads[field]) >= rule.maxCount {
+			return refuse(http.StatusBadRequest, "too many files for %q", field)
+		}
+
+		head := make([]byte, sniffLength)
+		n, err := io.ReadFull(part, head)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return readError(err)
+		}
+		head = head[:n]
+		mimeType := sniffType(head, part.Header.Get("Content-Type"))
+		if !typeAllowed(mimeType, rule.types) {
+			return refuse(http.StatusUnsupportedMediaType, "%q: type %s is not accepted", field, mimeType)
+		}
+
+		file, err := os.CreateTemp(dir, "upload-*")
+		if err != nil {
+			return refuse(http.StatusInternalServerError, "upload storage unavailable")
+		}
+		u := &Upload{tenant: t, field: field, name: uploadName(part.FileName()), mimeType: mimeType, path: file.Name()}
+		uploads[field] = append(uploads[field], u)
+		sink := &quotaWriter{file: file, usage: t.uploads, quota: quota, limit: rule.maxSize, reserved: &reserved,
+			recounted: &recounted}
+		_, err = sink.Write(head)
+		if err == nil {
+			_, err = io.Copy(sink, part)
+		}
+		u.size = sink.written
+		if closeErr := file.Close(); err == nil && closeErr != nil {
+			err = closeErr
+		}
+		if err != nil {
+			var refused *bodyError
+			if errors.As(err, &refused) {
+				return refused
+			}
+			return readError(err)
+		}
+	}
+
+	// FormValue/FormParams read the fields as they would for a urlencoded form.
+	r.PostForm = fields
+	r.Form = url.Values{}
+	for k, v := range r.URL.Query() {
+		r.Form[k] = append(r.Form[k], v...)
+	}
+	for k, v := range fields {
+		r.Form[k] = append(r.Form[k], v...)
+	}
+	reqRouter.uploads = uploads
+	return nil
+}
+
+// readError maps a failed body read: the MaxBytesReader cut-off is a 413, the rest a bad request.
+func readError(err error) *bodyError {
+	var tooBig *http.MaxBytesError
+	if errors.As(err, &tooBig) {
+		return refuse(http.StatusRequestEntityTooLarge, "request body too large")
+	}
+	return refuse(http.StatusBadRequest, "malformed multipart body")
+}
+
+// quotaWriter writes one file part, holding it to its per-file cap and the site quota.
+type quotaWriter struct {
+	file     *os.File
+	usage    *diskusage.Usage
+	quota    int64
+	limit    int64
+	written  int64
+	reserved *int64
+	// recounted is shared by the request's parts: the kept files are re-read at most once per
+	// request, however many chunks arrive past the quota.
+	recounted *bool
+}
+
+func (q *quotaWriter) Write(p []byte) (int, error) {
+	if q.written+int64(len(p)) > q.limit {
+		return 0, refuse(http.StatusRequestEntityTooLarge, "file too large (limit %d bytes)", q.limit)
+	}
+	if !q.usage.Reserve(int64(len(p)), q.quota) {
+		if *q.recounted {
+			return 0, refuse(http.StatusInsufficientStorage, "upload quota exceeded")
+		}
+		*q.recounted = true
+		q.usage.Recount() // files deleted since the last count stop counting
+		if !q.usage.Reserve(int64(len(p)), q.quota) {
+			return 0, refuse(http.StatusInsufficientStorage, "upload quota exceeded")
+		}
+	}
+	*q.reserved += int64(len(p))
+	n, err := q.file.Write(p)
+	q.written += int64(n)
+	return n, err
+}
+
+// sniffType is the type the bytes really have. The client's claim only refines a generic text
+// sniff (text/plain → text/csv) or a zip container (application/zip → an OOXML/ODF document).
+func sniffType(head []byte, declared string) string {
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	claimed, _, _ := mime.ParseMediaType(declared)
+	switch {
+	case sniffed == "text/plain" && (strings.HasPrefix(claimed, "text/") || claimed == "application/json"):
+		return claimed
+	case sniffed == "application/zip" && (strings.Contains(claimed, "openxmlformats") || strings.Contains(claimed, "opendocument")):
+		return claimed
+	}
+	return sniffed
+}
+
+func typeAllowed(mimeType string, types []string) bool {
+	if len(types) == 0 {
+		return true
+	}
+	for _, t := range types {
+		t = strings.ToLower(t)
+		if t == "*/*" || t == mimeType || (strings.HasSuffix(t, "/*") && strings.HasPrefix(mimeType, strings.TrimSuffix(t, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// uploadName keeps only the base name of the client's filename.
+func uploadName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" || name == "" {
+		return "upload"
+	}
+	return name
+}
+
+// uploadDestination resolves where a file is saved: dest inside the app, or dest/filename when
+// dest names a directory (no extension). Both must stay inside the app root.
+func (t *Tenant) uploadDestination(dest, filename string) (string, bool) {
+	full := t.resolve(dest)
+	if !t.insideAppRoot(full) {
+		return "", false
+	}
+	if filepath.Ext(full) == "" {
+		full = filepath.Join(full, filename)
+		if !t.insideAppRoot(full) {
+			return "", false
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		return "", false
+	}
+	return full, true
+}
+
+func (u *Upload) Field() string { return u.field }
+func (u *Upload) Name() string  { return u.name }
+func (u *Upload) Size() int64   { return u.size }
+
+// Type is the sniffed media type, not the one the client claimed.
+func (u *Upload) Type() string { return u.mimeType }
+
+// Save moves the file to dest (a file path, or a directory that keeps the uploaded name) inside
+// the app and returns whether it landed: file.save("uploads/avatars/" + user.id + ".png").
+func (u *Upload) Save(dest string) bool {
+	full, ok := u.tenant.uploadDestination(dest, u.name)
+	if !ok {
+		return false
+	}
+	// A kept file counts against the quota until it is deleted.
+	if !u.moved && os.Rename(u.path, full) == nil {
+		u.path, u.moved = full, true
+		u.tenant.uploads.Keep(full, u.size)
+		return true
+	}
+	// Across filesystems, or a second save of a moved file: copy.
+	if err := copyFile(u.path, full); err != nil {
+		return false
+	}
+	if !u.moved {
+		os.Remove(u.path)
+		u.path, u.moved = full, true
+	}
+	u.tenant.uploads.Keep(full, u.size)
+	return true
+}
+
+func copyFile(from, to string) error {
+	in, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(to)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// Upload is the first file received for a field, or null: ctx.upload("avatar").
+func (r *Request) Upload(field string) value.Value {
+	if list := r.router.uploads[field]; len(list) > 0 {
+		return value.New(list[0])
+	}
+	return value.Value{K: value.Nil}
+}
+
+// Uploads lists a field's files, or with no field every upload keyed by field. Variadic so the
+// reflection layer does not turn it into a getter.
+func (r *Request) Uploads(args ...value.Value) value.Value {
+	list := func(uploads []*Upload) value.Value {
+		out := make([]value.Value, len(uploads))
+		for i, u := range uploads {
+			out[i] = value.New(u)
+		}
+		return value.New(out)
+	}
+	if len(args) > 0 {
+		return list(r.router.uploads[args[0].Text()])
+	}
+	all := make(map[string]value.Value, len(r.router.uploads))
+	for field, uploads := range r.router.uploads {
+		all[field] = list(uploads)
+	}
+	return value.New(all)
+}
+
+func (c *Context) Upload(field string) value.Value         { return c.request.Upload(field) }
+func (c *Context) Uploads(args ...value.Value) value.Value { return c.request.Uploads(args...) }
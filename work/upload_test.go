@@ -0,0 +1,117 @@
+package work
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// .body() streams declared file fields to disk, trusts sniffed types over claimed ones, and
+// refuses oversized bodies, oversized files, stray fields and a full quota with the right status.
+func TestRouteBodyUploads(t *testing.T) {
+	tmp := t.TempDir()
+	dir := filepath.Join(tmp, "test", "localhost")
+	write := func(rel, content string) {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("router.kitwork.js", `import { router } from "kitwork";`)
+	write("avatar/router.kitwork.js", `import { router } from "kitwork";
+router.post((ctx, req) => {
+	const file = ctx.upload("avatar");
+	return ctx.json({ name: file.name, size: file.size, type: file.type, title: req.formValue("title"), saved: file.save("files/") });
+}).body({ maxSize: "8KB", files: { avatar: { maxSize: "1KB", types: ["image/*"] } } });`)
+	tenant := NewTenant(tmp, "localhost")
+	if err := tenant.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	png := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 200)...)
+	post := func(field, filename, claimed string, data []byte) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		form.WriteField("title", "Ảnh đại diện")
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", `form-data; name="`+field+`"; filename="`+filename+`"`)
+		header.Set("Content-Type", claimed)
+		part, _ := form.CreatePart(header)
+		part.Write(data)
+		form.Close()
+		req := httptest.NewRequest(http.MethodPost, "http://localhost/avatar/", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		rec := httptest.NewRecorder()
+		tenant.Serve(rec, req)
+		return rec
+	}
+
+	rec := post("avatar", "../../me.png", "application/octet-stream", png)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"type":"image/png"`) ||
+		!strings.Contains(rec.Body.String(), `"size":208`) || !strings.Contains(rec.Body.String(), `"title":"Ảnh đại diện"`) ||
+		!strings.Contains(rec.Body.String(), `"saved":true`) {
+		t.Fatalf("upload = %d %s", rec.Code, rec.Body.String())
+	}
+	if info, err := os.Stat(filepath.Join(dir, "files", "me.png")); err != nil || info.Size() != 208 {
+		t.Fatalf("saved file: %v %v", info, err)
+	}
+
+	for name, tc := range map[string]struct {
+		rec  *httptest.ResponseRecorder
+		want int
+	}{
+		"claimed image":   {post("avatar", "x.png", "image/png", []byte("just some text")), http.StatusUnsupportedMediaType},
+		"file too large":  {post("avatar", "big.png", "image/png", append(png, make([]byte, 1024)...)), http.StatusRequestEntityTooLarge},
+		"undeclared file": {post("resume", "cv.png", "image/png", png), http.StatusBadRequest},
+	} {
+		if tc.rec.Code != tc.want {
+			t.Errorf("%s = %d %s, want %d", name, tc.rec.Code, tc.rec.Body.String(), tc.want)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "http://localhost/avatar/", bytes.NewReader(make([]byte, 9<<10)))
+	req.Header.Set("Content-Type", "multipart/form-data; boundary=x")
+	oversized := httptest.NewRecorder()
+	tenant.Serve(oversized, req)
+	if oversized.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversized body = %d", oversized.Code)
+	}
+
+	// The quota counts the kept file, not just bytes in flight: with me.png held, a second one does
+	// not fit in 300 bytes.
+	if held := tenant.uploads.Bytes(); held != 208 {
+		t.Fatalf("usage after the requests ended = %d, want the 208 kept bytes", held)
+	}
+	tenant.UploadQuota = 300
+	if rec := post("avatar", "me.png", "image/png", png); rec.Code != http.StatusInsufficientStorage {
+		t.Fatalf("over quota = %d %s", rec.Code, rec.Body.String())
+	}
+	if left, _ := filepath.Glob(filepath.Join(dir, ".uploads", "upload-*")); len(left) != 0 {
+		t.Fatalf("temp files left behind: %d", len(left))
+	}
+
+	// A fresh start (a new site runtime) rebuilds the count from the ledger.
+	restarted := NewTenant(tmp, "localhost")
+	if err := restarted.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if held := restarted.uploads.Bytes(); held != 208 {
+		t.Fatalf("usage after restart = %d, want 208", held)
+	}
+	// Deleting the kept file frees its share the next time the quota is reached.
+	if err := os.Remove(filepath.Join(dir, "files", "me.png")); err != nil {
+		t.Fatal(err)
+	}
+	if rec := post("avatar", "me.png", "image/png", png); rec.Code != http.StatusOK {
+		t.Fatalf("after deleting the kept file = %d %s", rec.Code, rec.Body.String())
+	}
+}
@@ -1,7 +1,12 @@
 package work
 
 import (
+	"encoding/json"
+	"io"
+	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -71,3 +76,135 @@ func TestValidateFailClosed(t *testing.T) {
 		t.Error("missing rule must fail closed")
 	}
 }
+
+// .body(schema)/.query(schema) refuse bad input with a 422 problem that names each field, and hand
+// the handler typed values: coerced query strings, defaults filled in.
+func TestRouteInputSchemas(t *testing.T) {
+	tmp := t.TempDir()
+	dir := filepath.Join(tmp, "test", "localhost")
+	write := func(rel, content string) {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("router.kitwork.js", `import { router } from "kitwork";`)
+	write("users/router.kitwork.js", `import { router } from "kitwork";
+router.get((ctx, query) => ctx.json({ page: query.page + 1, active: query.active, tags: query.tag }))
+	.query({ properties: { page: { type: "integer", min: 1, default: 1 }, active: "boolean", tag: { type: "array", items: "string" } } });
+router.post((ctx, body) => ctx.json({ name: body.name, age: body.age, role: body.role }))
+	.body({
+		type: "object",
+		required: ["name", "email"],
+		additionalProperties: false,
+		properties: {
+			name: { type: "string", minLength: 2 },
+			email: { type: "string", format: "email" },
+			age: { type: "integer", minimum: 18 },
+			role: { enum: ["admin", "member"], default: "member" },
+			address: { type: "object", properties: { city: { type: "string" } }, required: ["city"] }
+		}
+	});`)
+	tenant := NewTenant(tmp, "localhost")
+	if err := tenant.Run(); err != nil {
+		t.Fatal(err)
+	}
+	serve := func(method, target, contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "http://localhost"+target, strings.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		rec := httptest.NewRecorder()
+		tenant.Serve(rec, req)
+		return rec
+	}
+
+	if rec := serve(http.MethodGet, "/users/?page=2&active=on&tag=a&tag=b", "", ""); rec.Code != http.StatusOK ||
+		rec.Body.String() != `{"active":true,"page":3,"tags":["a","b"]}` {
+		t.Fatalf("typed query = %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := serve(http.MethodGet, "/users/?tag=solo", "", ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"page":2`) ||
+		!strings.Contains(rec.Body.String(), `"tags":["solo"]`) {
+		t.Fatalf("query defaults = %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := serve(http.MethodGet, "/users/?page=zero", "", ""); rec.Code != http.StatusUnprocessableEntity ||
+		!strings.Contains(rec.Body.String(), `{"in":"query","path":"/page","message":"must be an integer"}`) {
+		t.Fatalf("bad query = %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec := serve(http.MethodPost, "/users/", "application/json", `{"name":"An","email":"an@example.vn","age":30}`)
+	if rec.Code != http.StatusOK || rec.Body.String() != `{"age":30,"name":"An","role":"member"}` {
+		t.Fatalf("valid body = %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = serve(http.MethodPost, "/users/", "application/json", `{"name":"A","email":"nope","age":12,"role":"root","address":{},"extra":1}`)
+	if rec.Code != http.StatusUnprocessableEntity || rec.Header().Get("Content-Type") != "application/problem+json" {
+		t.Fatalf("invalid body = %d %v %s", rec.Code, rec.Header(), rec.Body.String())
+	}
+	var problem struct {
+		Status int
+		Errors []struct{ In, Path, Message string }
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+		t.Fatal(err)
+	}
+	paths := map[string]string{}
+	for _, e := range problem.Errors {
+		paths[e.Path] = e.Message
+	}
+	for path, message := range map[string]string{
+		"/extra":        "is not allowed",
+		"/name":         "must be at least 2 characters",
+		"/email":        "must be a valid email",
+		"/age":          "must be at least 18",
+		"/role":         `must be one of "admin", "member"`,
+		"/address/city": "is required",
+	} {
+		if paths[path] != message {
+			t.Errorf("%s = %q, want %q (all: %v)", path, paths[path], message, problem.Errors)
+		}
+	}
+	if problem.Status != http.StatusUnprocessableEntity || len(problem.Errors) != 6 {
+		t.Fatalf("problem = %+v", problem)
+	}
+
+	if rec := serve(http.MethodPost, "/users/", "application/x-www-form-urlencoded", "name=Binh&email=b%40example.vn&age=21"); rec.Code != http.StatusOK ||
+		!strings.Contains(rec.Body.String(), `"age":21`) {
+		t.Fatalf("form body = %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := serve(http.MethodPost, "/users/", "application/json", `{"name":`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("broken JSON = %d %s", rec.Code, rec.Body.String())
+	}
+
+	// A schema alone still caps the body it reads, whether the size is declared or streamed.
+	declared := httptest.NewRequest(http.MethodPost, "http://localhost/users/", strings.NewReader(`{}`))
+	declared.Header.Set("Content-Type", "application/json")
+	declared.ContentLength = defaultBodySize + 1
+	rec = httptest.NewRecorder()
+	tenant.Serve(rec, declared)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("declared oversized body = %d %s", rec.Code, rec.Body.String())
+	}
+	streamed := httptest.NewRequest(http.MethodPost, "http://localhost/users/",
+		io.MultiReader(strings.NewReader(`{"name":"`), io.LimitReader(zeros{}, defaultBodySize), strings.NewReader(`"}`)))
+	streamed.Header.Set("Content-Type", "application/json")
+	streamed.ContentLength = -1
+	rec = httptest.NewRecorder()
+	tenant.Serve(rec, streamed)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("streamed oversized body = %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+// zeros is an endless body of '0' bytes.
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = '0'
+	}
+	return len(p), nil
+}
@@ -2,7 +2,9 @@ package work
 
 import (
 	"encoding/json"
+	"strings"
 
+	"github.com/kitwork/engine/utilities/trace"
 	"github.com/kitwork/engine/value"
 )
 
@@ -119,11 +121,25 @@ func (vb *ViewBuilder) flush() {
 		}
 	}
 
+	// router.locales(): hreflang alternates for this page, and $.i18n for {{ t "key" }}.
+	var alternates []map[string]string
+	if r.locales != nil && !r.isNotfound {
+		request := c.Request()
+		alternates = r.locales.alternates(request.Scheme().String(), request.Host().String(), r.localePath)
+		if _, set := meta["alternates"]; !set {
+			meta["alternates"] = value.New(alternates)
+		}
+	}
+
 	// binding: two always-present defaults ($.request, $.meta) + the user data.
 	binding := map[string]value.Value{
 		"request": value.New(c.Request()),
 		"meta":    {K: value.Map, V: meta},
 	}
+	if l := r.localizer(); l != nil {
+		binding["i18n"] = value.Value{K: value.Struct, V: l}
+	}
+	binding["flags"] = value.Value{K: value.Struct, V: r.flagEvaluator()} // {{ if flag "name" }}
 	for k, v := range vb.data {
 		binding[k] = v
 	}
@@ -136,7 +152,30 @@ func (vb *ViewBuilder) flush() {
 		notfoundMode = true
 	}
 
+	var span *trace.Span
+	if r.request != nil {
+		_, span = trace.Start(r.request.Context(), "render", trace.KindInternal)
+		span.SetAttribute("kitwork.page", page)
+	}
 	html := rd.BindPage(page, notfoundMode, value.Value{K: value.Map, V: binding})
+	if html.K == value.Invalid {
+		span.Fail(html.Text())
+	}
+	span.End()
+	if len(alternates) > 0 {
+		// The head gets the alternates unless its template already writes them. Minified pages may
+		// have dropped the optional </head>; </title> always marks a point inside the head.
+		out := html.Text()
+		i := strings.Index(out, "</head>")
+		if i < 0 {
+			if i = strings.Index(out, "</title>"); i >= 0 {
+				i += len("</title>")
+			}
+		}
+		if i >= 0 && !strings.Contains(out[:i], "hreflang=") {
+			html = value.New(out[:i] + alternateLinks(alternates) + out[i:])
+		}
+	}
 	if notfound {
 		c.Response().HTML(html, 404)
 	} else {
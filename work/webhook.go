@@ -0,0 +1,209 @@
+package work
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/kitwork/engine/database"
+	"github.com/kitwork/engine/utilities/webhook"
+	"github.com/kitwork/engine/value"
+)
+
+// VerifyWebhook checks the signature of a webhook delivery against the raw body, refuses one
+// outside its timestamp window, and refuses a replay of one the app already processed — a delivery
+// counts as processed once the route answers it with a 2xx, so a retry of one the handler failed
+// on is let in again:
+//
+//	const hook = ctx.verifyWebhook({ scheme: "stripe", secret: env.STRIPE_WEBHOOK_SECRET });
+//	if (!hook.ok) return ctx.status(400).json({ error: hook.error });
+//	const event = hook.payload;
+//
+// scheme is "hmac-sha256" (default), "hmac-sha1", "hmac-sha512" or a sender preset — "stripe",
+// "github", "standard" (Standard Webhooks) or "slack". For a plain HMAC, header names the signature
+// header, prefix is stripped from it ("sha256="), encoding is "hex" or "base64" (default: either),
+// timestampHeader and idHeader name the timestamp and delivery id headers, and template is what was
+// signed ("{timestamp}.{body}"; default "{body}"). secret may be a list while a secret rotates.
+// tolerance is the timestamp window ("5m"); replay: false turns replay protection off, a duration
+// sets how long ids of un-timestamped deliveries are kept (default "24h").
+//
+// The result (WebhookResult) has ok, error, id, timestamp, body — the raw body as received — and
+// payload, its JSON or null. A missing secret or an unknown scheme throws — that is a bug in the
+// route, not a bad delivery.
+func (c *Context) VerifyWebhook(args ...value.Value) value.Value {
+	opts := webhook.Options{}
+	replay := true
+	if len(args) > 0 && args[0].IsMap() {
+		m := args[0].Map()
+		opts.Scheme = optText(m["scheme"])
+		opts.Header = optText(m["header"])
+		opts.Prefix = optText(m["prefix"])
+		opts.Encoding = optText(m["encoding"])
+		opts.TimestampHeader = optText(m["timestampHeader"])
+		opts.IDHeader = optText(m["idHeader"])
+		opts.Template = optText(m["template"])
+		if secret := m["secret"]; secret.K == value.Array {
+			for _, item := range secret.Array() {
+				opts.Secrets = append(opts.Secrets, optText(item))
+			}
+		} else if secret.K == value.String {
+			opts.Secrets = []string{optText(secret)}
+		}
+		if tolerance := m["tolerance"]; !tolerance.IsBlank() {
+			opts.Tolerance = parseTTL(tolerance)
+		}
+		if keep := m["replay"]; keep.K == value.Bool {
+			replay = keep.IsTrue()
+		} else if !keep.IsBlank() {
+			opts.Remember = parseTTL(keep)
+		}
+	}
+	resolved, err := webhook.Resolve(opts)
+	if err != nil {
+		return value.Value{K: value.Invalid, V: "verifyWebhook: " + err.Error()}
+	}
+
+	req := c.request.request()
+	if req == nil {
+		return webhookResult(nil, webhook.Result{}, webhook.ErrMissing)
+	}
+	body, err := keepBody(req)
+	if err != nil {
+		return webhookResult(nil, webhook.Result{}, fmt.Errorf("request body could not be read"))
+	}
+	result, err := webhook.Verify(resolved, req.Header, body, time.Now())
+	if err == nil && replay {
+		err = c.tenant().rememberWebhook(c.router(), result)
+	}
+	return webhookResult(body, result, err)
+}
+
+// WebhookResult is what ctx.verifyWebhook returns. A struct rather than a map, like SafeResult, so
+// `hook.ok` is a real boolean and `hook.error` the message — on a map, `.error` is the inline-error
+// slot every value carries. body and payload stay null unless the delivery verified.
+type WebhookResult struct {
+	err    error
+	result webhook.Result
+	body   []byte
+}
+
+func webhookResult(body []byte, result webhook.Result, err error) value.Value {
+	return value.New(&WebhookResult{err: err, result: result, body: body})
+}
+
+// Ok reports that the delivery is authentic, fresh and not a replay.
+func (w *WebhookResult) Ok() bool { return w.err == nil }
+
+// Error is why the delivery was refused, or null.
+func (w *WebhookResult) Error() value.Value {
+	if w.err == nil {
+		return value.Value{K: value.Nil}
+	}
+	return value.New(w.err.Error())
+}
+
+// ID is the delivery id: the sender's, or a digest of the signature when it sends none.
+func (w *WebhookResult) ID() value.Value {
+	if w.err != nil {
+		return value.Value{K: value.Nil}
+	}
+	return value.New(w.result.ID)
+}
+
+// Timestamp is when the sender signed the delivery (RFC 3339), or null for an un-timestamped scheme.
+func (w *WebhookResult) Timestamp() value.Value {
+	if w.err != nil || w.result.Timestamp.IsZero() {
+		return value.Value{K: value.Nil}
+	}
+	return value.New(w.result.Timestamp.UTC().Format(time.RFC3339))
+}
+
+// Body is the raw body, byte for byte as it was signed.
+func (w *WebhookResult) Body() value.Value {
+	if w.err != nil {
+		return value.Value{K: value.Nil}
+	}
+	return value.New(string(w.body))
+}
+
+// Payload is the body decoded as JSON, or null when it is not JSON.
+func (w *WebhookResult) Payload() value.Value {
+	var payload any
+	if w.err != nil || json.Unmarshal(w.body, &payload) != nil {
+		return value.Value{K: value.Nil}
+	}
+	return collectionValue(payload)
+}
+
+// rememberWebhook claims an accepted delivery's replay key in the app's seen-id store; a delivery
+// seen before is a replay. The claim is taken now, so a duplicate arriving while the handler runs is
+// refused, and released by settleWebhooks unless the route succeeds. Without a store it fails
+// closed — an unverifiable replay check is not a pass.
+func (t *Tenant) rememberWebhook(reqRouter *Router, result webhook.Result) error {
+	store := t.openWebhookStore()
+	if store == nil {
+		return fmt.Errorf("webhook replay store unavailable")
+	}
+	fresh, err := store.Remember(t.appID(), result.Key, result.Until)
+	if err != nil {
+		return fmt.Errorf("webhook replay store: %w", err)
+	}
+	if !fresh {
+		return webhook.ErrReplay
+	}
+	reqRouter.webhookKeys = append(reqRouter.webhookKeys, result.Key)
+	return nil
+}
+
+// settleWebhooks runs when the request ends: the replay keys it claimed stay only if the route
+// answered 2xx. Any other ending — a failed handler, a 4xx/5xx it chose — releases them for the
+// sender's retry.
+func (r *Router) settleWebhooks() {
+	if len(r.webhookKeys) == 0 {
+		return
+	}
+	status := r.response.Code()
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if r.err == nil && status >= 200 && status < 300 {
+		return
+	}
+	store := r.tenant.openWebhookStore()
+	if store == nil {
+		return
+	}
+	for _, key := range r.webhookKeys {
+		if err := store.Forget(r.tenant.appID(), key); err != nil {
+			fmt.Printf("[Webhook] release %s: %v\n", key, err)
+		}
+	}
+}
+
+// openWebhookStore picks the seen-id store the way the cron and queue stores do: the system
+// database when one is connected, else apps/<identity>/.data/webhooks.db. The ids belong to the
+// app, so every domain and generation of it shares them.
+func (t *Tenant) openWebhookStore() webhook.SeenStore {
+	t.webhookOnce.Do(func() {
+		var store webhook.SeenStore
+		switch {
+		case database.SystemIsPostgres():
+			store = &webhook.PgSeen{DB: database.System}
+		case database.System != nil:
+			store = &webhook.SqliteSeen{DB: database.System}
+		default:
+			db := appSqliteFor(t, "webhooks.db").db()
+			if db == nil {
+				return
+			}
+			store = &webhook.SqliteSeen{DB: db}
+		}
+		if err := store.InitSchema(); err != nil {
+			fmt.Printf("[Webhook] schema: %v\n", err)
+			return
+		}
+		t.webhookSeen = store
+	})
+	return t.webhookSeen
+}
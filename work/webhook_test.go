@@ -0,0 +1,127 @@
+package work
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kitwork/engine/utilities/webhook"
+)
+
+// ctx.verifyWebhook checks the raw body — even after the handler read a form field from it — and
+// refuses a replayed delivery the second time it arrives, unless the first attempt failed.
+func TestContextVerifyWebhook(t *testing.T) {
+	tmp := t.TempDir()
+	dir := filepath.Join(tmp, "test", "localhost")
+	write := func(rel, content string) {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("router.kitwork.js", `import { router } from "kitwork";
+router.get((ctx) => ctx.text("home"));`)
+	write("github/router.kitwork.js", `import { router } from "kitwork";
+router.post((ctx) => {
+  const hook = ctx.verifyWebhook({ scheme: "github", secret: ["old-secret", "gh-secret"] });
+  if (!hook.ok) return ctx.status(401).json({ error: hook.error });
+  ctx.json({ id: hook.id, action: hook.payload.action });
+});`)
+	write("flaky/router.kitwork.js", `import { router } from "kitwork";
+router.post((ctx) => {
+  const hook = ctx.verifyWebhook({ scheme: "github", secret: "gh-secret" });
+  if (!hook.ok) return ctx.status(401).json({ error: hook.error });
+  if (ctx.header("X-Fail") != "") return ctx.status(500).json({ error: "database down" });
+  ctx.json({ id: hook.id });
+});`)
+	write("slack/router.kitwork.js", `import { router } from "kitwork";
+router.post((ctx) => {
+  const command = ctx.req.formValue("command");
+  const hook = ctx.verifyWebhook({ scheme: "slack", secret: "slack-secret", replay: false });
+  ctx.json({ ok: hook.ok, error: hook.error, command: command });
+});`)
+	tenant := NewTenant(tmp, "localhost")
+	if err := tenant.Run(); err != nil {
+		t.Fatal(err)
+	}
+	defer tenant.Close()
+
+	serve := func(path, contentType, body string, header http.Header) (int, map[string]any) {
+		req := httptest.NewRequest(http.MethodPost, "http://localhost"+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		rec := httptest.NewRecorder()
+		tenant.Serve(rec, req)
+		var out map[string]any
+		_ = json.Unmarshal(rec.Body.Bytes(), &out)
+		return rec.Code, out
+	}
+
+	github, _ := webhook.Resolve(webhook.Options{Scheme: "github", Secrets: []string{"gh-secret"}})
+	body := `{"action":"opened"}`
+	delivery := http.Header{
+		"X-Hub-Signature-256": {webhook.Sign(github, "", time.Now(), []byte(body))},
+		"X-Github-Delivery":   {"d-1"},
+	}
+	if code, out := serve("/github/", "application/json", body, delivery); code != http.StatusOK ||
+		out["id"] != "d-1" || out["action"] != "opened" {
+		t.Fatalf("first delivery: %d %v", code, out)
+	}
+	if code, out := serve("/github/", "application/json", body, delivery); code != http.StatusUnauthorized ||
+		out["error"] != webhook.ErrReplay.Error() {
+		t.Fatalf("replayed delivery: %d %v", code, out)
+	}
+	delivery.Set("X-Github-Delivery", "d-2") // the delivery id is not signed: a new one is still a replay
+	if code, out := serve("/github/", "application/json", body, delivery); code != http.StatusUnauthorized ||
+		out["error"] != webhook.ErrReplay.Error() {
+		t.Fatalf("replayed under a new delivery id: %d %v", code, out)
+	}
+	if code, out := serve("/github/", "application/json", `{"action":"closed"}`, delivery); code != http.StatusUnauthorized ||
+		out["error"] != webhook.ErrMismatch.Error() {
+		t.Fatalf("tampered delivery: %d %v", code, out)
+	}
+
+	// The handler failed the first attempt, so the sender's retry of the same delivery goes through;
+	// once it succeeded, the next copy is a replay.
+	retry := http.Header{
+		"X-Hub-Signature-256": {webhook.Sign(github, "", time.Now(), []byte(`{"action":"retried"}`))},
+		"X-Github-Delivery":   {"d-3"},
+		"X-Fail":              {"1"},
+	}
+	if code, _ := serve("/flaky/", "application/json", `{"action":"retried"}`, retry); code != http.StatusInternalServerError {
+		t.Fatalf("failing attempt: %d", code)
+	}
+	retry.Del("X-Fail")
+	if code, out := serve("/flaky/", "application/json", `{"action":"retried"}`, retry); code != http.StatusOK || out["id"] != "d-3" {
+		t.Fatalf("retry after a failure: %d %v", code, out)
+	}
+	if code, out := serve("/flaky/", "application/json", `{"action":"retried"}`, retry); code != http.StatusUnauthorized ||
+		out["error"] != webhook.ErrReplay.Error() {
+		t.Fatalf("replay after success: %d %v", code, out)
+	}
+
+	slack, _ := webhook.Resolve(webhook.Options{Scheme: "slack", Secrets: []string{"slack-secret"}})
+	form := "command=%2Fdeploy&text=prod"
+	now := time.Now()
+	signed := http.Header{
+		"X-Slack-Signature":         {webhook.Sign(slack, "", now, []byte(form))},
+		"X-Slack-Request-Timestamp": {strconv.FormatInt(now.Unix(), 10)},
+	}
+	for i := 0; i < 2; i++ { // replay: false lets the same delivery through twice
+		if code, out := serve("/slack/", "application/x-www-form-urlencoded", form, signed); code != http.StatusOK ||
+			out["ok"] != true || out["command"] != "/deploy" {
+			t.Fatalf("slack delivery %d: %d %v", i, code, out)
+		}
+	}
+}
@@ -7,11 +7,15 @@ import (
 	"github.com/kitwork/engine/capabilities"
 	requestscope "github.com/kitwork/engine/request"
 	"github.com/kitwork/engine/runtime"
+	httphelper "github.com/kitwork/engine/utilities/http"
 	"github.com/kitwork/engine/value"
 )
 
 var enginePool = app.NewPool()
 
+// ActiveVMs reports how many pooled VMs are checked out right now, across every tenant.
+func ActiveVMs() int64 { return enginePool.Active() }
+
 // Router struct is defined in router.go
 type Config struct {
 	root     string
@@ -46,6 +50,10 @@ func (t *Tenant) prepareExecutionVM(
 		vm.Builtins[0] = kitworkFunc
 	}
 	vm.Globals[kitwork] = kitworkFunc
+	// fetch joins whatever span the VM is running under when it is called (trace.go).
+	vm.Globals["fetch"] = value.NewFunc(func(args ...value.Value) value.Value {
+		return httphelper.FetchWith(httphelper.NewClient(t.fetchRAM(), t.fetchDisk()).WithContext(vmContext(vm)), args...)
+	})
 	if requestScope != nil {
 		vm.Context = requestScope.Context()
 	}
@@ -111,6 +119,8 @@ func (t *Tenant) Serve(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	defer t.endRequest()
+	w, r, logAccess := t.beginAccessLog(w, r)
+	defer logAccess()
 	generationLease, err := t.generationLease()
 	if err != nil {
 		http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
@@ -140,5 +150,10 @@ func (t *Tenant) Serve(w http.ResponseWriter, r *http.Request) {
 	if serveFontIf(w, r) {
 		return
 	}
+	// Maintenance holds back everything the site itself answers; the shared assets above stay
+	// available so a custom maintenance page can use them.
+	if t.serveMaintenance(w, r) {
+		return
+	}
 	t.serveTree(requestScope)
 }